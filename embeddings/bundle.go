@@ -0,0 +1,137 @@
+package embeddings
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/amikos-tech/pure-onnx/ort"
+)
+
+const (
+	bundleModelFilename     = "model.onnx"
+	bundleTokenizerFilename = "tokenizer.json"
+	// bundleSourceSuffix names the file next to each cached download that records the
+	// URL it came from, so a file without a digest is only reused for the same URL.
+	bundleSourceSuffix  = ".source"
+	bundleDirPermission = 0o750
+)
+
+// BundleSpec describes where to fetch an embedder's model and tokenizer, for
+// ResolveBundle and the NewEmbedderFromBundle constructors.
+type BundleSpec struct {
+	// Name is the cache subdirectory the bundle is stored in, for example
+	// "all-MiniLM-L6-v2". It must be a single path element.
+	Name string
+	// ModelURL and TokenizerURL are the download locations of model.onnx and
+	// tokenizer.json.
+	ModelURL     string
+	TokenizerURL string
+	// ModelSHA256 and TokenizerSHA256 are the expected hex SHA-256 digests. Downloads and
+	// cached copies that do not match are rejected. Without a digest, a cached copy is
+	// reused only if it was downloaded from the same URL.
+	ModelSHA256     string
+	TokenizerSHA256 string
+	// CacheDir is the cache root; it defaults to onnx-purego/bundles under
+	// os.UserCacheDir.
+	CacheDir string
+	// DownloadOptions are passed to ort.DownloadVerifiedFile, e.g. WithBootstrapContext
+	// or WithBootstrapCertPin.
+	DownloadOptions []ort.BootstrapOption
+}
+
+// ResolveBundle returns local paths to the model and tokenizer described by spec,
+// downloading whichever is not already cached under <CacheDir>/<Name>. A cached file is
+// reused when it matches its expected digest or, when no digest is set, when it was
+// downloaded from the same URL; it is downloaded again otherwise.
+func ResolveBundle(spec BundleSpec) (modelPath, tokenizerPath string, err error) {
+	name := strings.TrimSpace(spec.Name)
+	if name == "" {
+		return "", "", fmt.Errorf("bundle name cannot be empty")
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", "", fmt.Errorf("bundle name %q must be a single path element", spec.Name)
+	}
+	if strings.TrimSpace(spec.ModelURL) == "" {
+		return "", "", fmt.Errorf("bundle model URL cannot be empty")
+	}
+	if strings.TrimSpace(spec.TokenizerURL) == "" {
+		return "", "", fmt.Errorf("bundle tokenizer URL cannot be empty")
+	}
+
+	cacheDir := spec.CacheDir
+	if cacheDir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", "", fmt.Errorf("cannot determine user cache directory: %w; set BundleSpec.CacheDir", err)
+		}
+		cacheDir = filepath.Join(userCacheDir, "onnx-purego", "bundles")
+	}
+	bundleDir := filepath.Join(cacheDir, name)
+	if err := os.MkdirAll(bundleDir, bundleDirPermission); err != nil {
+		return "", "", fmt.Errorf("failed to create bundle cache directory %q: %w", bundleDir, err)
+	}
+
+	modelPath = filepath.Join(bundleDir, bundleModelFilename)
+	if err := resolveBundleFile(modelPath, spec.ModelURL, spec.ModelSHA256, spec.DownloadOptions); err != nil {
+		return "", "", fmt.Errorf("failed to resolve bundle model: %w", err)
+	}
+	tokenizerPath = filepath.Join(bundleDir, bundleTokenizerFilename)
+	if err := resolveBundleFile(tokenizerPath, spec.TokenizerURL, spec.TokenizerSHA256, spec.DownloadOptions); err != nil {
+		return "", "", fmt.Errorf("failed to resolve bundle tokenizer: %w", err)
+	}
+	return modelPath, tokenizerPath, nil
+}
+
+func resolveBundleFile(path, url, expectedSHA256 string, opts []ort.BootstrapOption) error {
+	expectedSHA256 = strings.ToLower(strings.TrimSpace(expectedSHA256))
+	sourcePath := path + bundleSourceSuffix
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && info.Size() > 0 {
+		if expectedSHA256 == "" {
+			// #nosec G304 -- sourcePath is derived from the bundle cache path.
+			if source, err := os.ReadFile(sourcePath); err == nil && string(source) == url {
+				return nil
+			}
+		} else {
+			checksum, err := fileSHA256(path)
+			if err != nil {
+				return err
+			}
+			if checksum == expectedSHA256 {
+				return nil
+			}
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove stale cached file %q: %w", path, err)
+		}
+	}
+	if err := ort.DownloadVerifiedFile(url, path, expectedSHA256, opts...); err != nil {
+		return err
+	}
+	if err := os.WriteFile(sourcePath, []byte(url), 0o600); err != nil {
+		return fmt.Errorf("failed to record the source of %q: %w", path, err)
+	}
+	return nil
+}
+
+func fileSHA256(path string) (_ string, err error) {
+	// #nosec G304 -- path is a file in the bundle cache directory.
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %q: %w", path, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", fmt.Errorf("failed to hash %q: %w", path, err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,187 @@
+package embeddings_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/amikos-tech/pure-onnx/embeddings"
+)
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// newBundleServer serves files by URL path and counts requests per path.
+func newBundleServer(t *testing.T, files map[string]string) (*httptest.Server, func(path string) int) {
+	t.Helper()
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+	return server, func(path string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return hits[path]
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %q: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestResolveBundleDownloadsAndCaches(t *testing.T) {
+	const model, tokenizer = "model-bytes", `{"model": {}}`
+	server, hits := newBundleServer(t, map[string]string{"/model.onnx": model, "/model-v2.onnx": "model-v2", "/tokenizer.json": tokenizer})
+	spec := embeddings.BundleSpec{
+		Name:            "tiny",
+		ModelURL:        server.URL + "/model.onnx",
+		ModelSHA256:     sha256Hex(model),
+		TokenizerURL:    server.URL + "/tokenizer.json",
+		TokenizerSHA256: strings.ToUpper(sha256Hex(tokenizer)),
+		CacheDir:        t.TempDir(),
+	}
+
+	modelPath, tokenizerPath, err := embeddings.ResolveBundle(spec)
+	if err != nil {
+		t.Fatalf("ResolveBundle failed: %v", err)
+	}
+	if want := filepath.Join(spec.CacheDir, "tiny", "model.onnx"); modelPath != want {
+		t.Fatalf("unexpected model path: got %q, want %q", modelPath, want)
+	}
+	if got := readFile(t, modelPath); got != model {
+		t.Fatalf("unexpected model content: %q", got)
+	}
+	if got := readFile(t, tokenizerPath); got != tokenizer {
+		t.Fatalf("unexpected tokenizer content: %q", got)
+	}
+
+	if _, _, err := embeddings.ResolveBundle(spec); err != nil {
+		t.Fatalf("cached ResolveBundle failed: %v", err)
+	}
+	if hits("/model.onnx") != 1 || hits("/tokenizer.json") != 1 {
+		t.Fatalf("expected cached files to be reused, got model=%d tokenizer=%d requests", hits("/model.onnx"), hits("/tokenizer.json"))
+	}
+
+	// A cached file that no longer matches its digest is downloaded again.
+	if err := os.WriteFile(modelPath, []byte("stale"), 0o644); err != nil {
+		t.Fatalf("failed to corrupt cached model: %v", err)
+	}
+	if _, _, err := embeddings.ResolveBundle(spec); err != nil {
+		t.Fatalf("ResolveBundle after corruption failed: %v", err)
+	}
+	if got := readFile(t, modelPath); got != model || hits("/model.onnx") != 2 {
+		t.Fatalf("expected stale model to be replaced, got %q after %d requests", got, hits("/model.onnx"))
+	}
+
+	// Without a digest, a cached file is reused only for the URL it was downloaded from.
+	spec.ModelSHA256 = ""
+	if err := os.WriteFile(modelPath, []byte("local-override"), 0o644); err != nil {
+		t.Fatalf("failed to overwrite cached model: %v", err)
+	}
+	if _, _, err := embeddings.ResolveBundle(spec); err != nil {
+		t.Fatalf("ResolveBundle without digest failed: %v", err)
+	}
+	if got := readFile(t, modelPath); got != "local-override" || hits("/model.onnx") != 2 {
+		t.Fatalf("expected cached model to be reused without a digest, got %q", got)
+	}
+	spec.ModelURL = server.URL + "/model-v2.onnx"
+	if _, _, err := embeddings.ResolveBundle(spec); err != nil {
+		t.Fatalf("ResolveBundle with a new URL failed: %v", err)
+	}
+	if got := readFile(t, modelPath); got != "model-v2" || hits("/model-v2.onnx") != 1 {
+		t.Fatalf("expected a changed URL to be downloaded again, got %q", got)
+	}
+
+	info, err := os.Stat(filepath.Join(spec.CacheDir, "tiny"))
+	if err != nil {
+		t.Fatalf("failed to stat bundle directory: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm&0o027 != 0 {
+		t.Fatalf("expected a private bundle directory, got %v", perm)
+	}
+}
+
+func TestResolveBundleRejectsBadDownloads(t *testing.T) {
+	server, _ := newBundleServer(t, map[string]string{"/model.onnx": "tampered", "/tokenizer.json": "{}"})
+	cacheDir := t.TempDir()
+
+	_, _, err := embeddings.ResolveBundle(embeddings.BundleSpec{
+		Name:         "tampered",
+		ModelURL:     server.URL + "/model.onnx",
+		ModelSHA256:  sha256Hex("model-bytes"),
+		TokenizerURL: server.URL + "/tokenizer.json",
+		CacheDir:     cacheDir,
+	})
+	if err == nil || !strings.Contains(err.Error(), "download checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got: %v", err)
+	}
+	entries, readErr := os.ReadDir(filepath.Join(cacheDir, "tampered"))
+	if readErr != nil {
+		t.Fatalf("failed to list bundle directory: %v", readErr)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no files left after a rejected download, got %d entries", len(entries))
+	}
+
+	_, _, err = embeddings.ResolveBundle(embeddings.BundleSpec{
+		Name:         "missing",
+		ModelURL:     server.URL + "/model.onnx",
+		TokenizerURL: server.URL + "/absent.json",
+		CacheDir:     cacheDir,
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to resolve bundle tokenizer") || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Fatalf("expected tokenizer HTTP error, got: %v", err)
+	}
+}
+
+func TestResolveBundleValidation(t *testing.T) {
+	valid := embeddings.BundleSpec{
+		Name:         "bundle",
+		ModelURL:     "https://example.com/model.onnx",
+		TokenizerURL: "https://example.com/tokenizer.json",
+		CacheDir:     t.TempDir(),
+	}
+	tests := []struct {
+		name    string
+		mutate  func(*embeddings.BundleSpec)
+		wantErr string
+	}{
+		{name: "empty name", mutate: func(s *embeddings.BundleSpec) { s.Name = " " }, wantErr: "bundle name cannot be empty"},
+		{name: "nested name", mutate: func(s *embeddings.BundleSpec) { s.Name = "../escape" }, wantErr: "single path element"},
+		{name: "empty model URL", mutate: func(s *embeddings.BundleSpec) { s.ModelURL = "" }, wantErr: "model URL cannot be empty"},
+		{name: "empty tokenizer URL", mutate: func(s *embeddings.BundleSpec) { s.TokenizerURL = "" }, wantErr: "tokenizer URL cannot be empty"},
+		{name: "invalid digest", mutate: func(s *embeddings.BundleSpec) { s.ModelSHA256 = "abc" }, wantErr: "must be 64 hex characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := valid
+			tt.mutate(&spec)
+			if _, _, err := embeddings.ResolveBundle(spec); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,569 @@
+// Package clip embeds images and texts into a shared vector space with the two
+// sub-graphs (vision and text towers) of a CLIP ONNX export, so image/text pairs can
+// be compared by cosine similarity.
+package clip
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"sync"
+
+	"github.com/amikos-tech/pure-onnx/embeddings"
+	"github.com/amikos-tech/pure-onnx/embeddings/internal/ortutil"
+	"github.com/amikos-tech/pure-onnx/ort"
+	tokenizers "github.com/amikos-tech/pure-tokenizers"
+)
+
+const (
+	// DefaultImageSize is the square input resolution of ViT-B/32 CLIP vision towers.
+	DefaultImageSize = 224
+	// DefaultSequenceLength is CLIP's text context length.
+	DefaultSequenceLength = 77
+	// DefaultEmbeddingDimension is the ViT-B/32 projection width shared by both towers.
+	DefaultEmbeddingDimension = 512
+	// DefaultMaxCachedBatchSessions bounds in-memory ONNX session cache growth per tower.
+	DefaultMaxCachedBatchSessions = 8
+)
+
+const (
+	defaultPixelValuesName   = "pixel_values"
+	defaultImageOutputName   = "image_embeds"
+	defaultInputIDsName      = "input_ids"
+	defaultAttentionMaskName = "attention_mask"
+	defaultTextOutputName    = "text_embeds"
+)
+
+var (
+	// DefaultImageMean is the per-channel RGB mean used by OpenAI CLIP preprocessing.
+	DefaultImageMean = [3]float32{0.48145466, 0.4578275, 0.40821073}
+	// DefaultImageStd is the per-channel RGB standard deviation used by OpenAI CLIP preprocessing.
+	DefaultImageStd = [3]float32{0.26862954, 0.26130258, 0.27577711}
+)
+
+// Option customizes embedder initialization.
+type Option func(*config) error
+
+type config struct {
+	imageSize            int
+	sequenceLength       int
+	embeddingDimension   int64
+	imageMean            [3]float32
+	imageStd             [3]float32
+	maxCachedBatchCount  int
+	tokenizerLibraryPath string
+	pixelValuesName      string
+	imageOutputName      string
+	inputIDsName         string
+	attentionMaskName    string
+	textOutputName       string
+}
+
+func defaultConfig() config {
+	return config{
+		imageSize:           DefaultImageSize,
+		sequenceLength:      DefaultSequenceLength,
+		embeddingDimension:  DefaultEmbeddingDimension,
+		imageMean:           DefaultImageMean,
+		imageStd:            DefaultImageStd,
+		maxCachedBatchCount: DefaultMaxCachedBatchSessions,
+		pixelValuesName:     defaultPixelValuesName,
+		imageOutputName:     defaultImageOutputName,
+		inputIDsName:        defaultInputIDsName,
+		attentionMaskName:   defaultAttentionMaskName,
+		textOutputName:      defaultTextOutputName,
+	}
+}
+
+// WithImageSize sets the square resolution images are center-cropped and resized to.
+func WithImageSize(size int) Option {
+	return func(cfg *config) error {
+		if size <= 0 {
+			return fmt.Errorf("image size must be > 0, got %d", size)
+		}
+		cfg.imageSize = size
+		return nil
+	}
+}
+
+// WithSequenceLength sets text truncation and fixed padding length.
+func WithSequenceLength(length int) Option {
+	return func(cfg *config) error {
+		if length <= 0 {
+			return fmt.Errorf("sequence length must be > 0, got %d", length)
+		}
+		cfg.sequenceLength = length
+		return nil
+	}
+}
+
+// WithEmbeddingDimension configures the projection width both towers must output.
+func WithEmbeddingDimension(dim int64) Option {
+	return func(cfg *config) error {
+		if dim <= 0 {
+			return fmt.Errorf("embedding dimension must be > 0, got %d", dim)
+		}
+		cfg.embeddingDimension = dim
+		return nil
+	}
+}
+
+// WithImageNormalization overrides the per-channel RGB mean and std applied to pixel
+// values scaled to [0, 1].
+func WithImageNormalization(mean, std [3]float32) Option {
+	return func(cfg *config) error {
+		for c, s := range std {
+			if s == 0 {
+				return fmt.Errorf("image std[%d] must be non-zero", c)
+			}
+		}
+		cfg.imageMean = mean
+		cfg.imageStd = std
+		return nil
+	}
+}
+
+// WithMaxCachedBatchSessions limits how many batch-size specific sessions each tower keeps.
+func WithMaxCachedBatchSessions(limit int) Option {
+	return func(cfg *config) error {
+		if limit <= 0 {
+			return fmt.Errorf("max cached batch sessions must be > 0, got %d", limit)
+		}
+		cfg.maxCachedBatchCount = limit
+		return nil
+	}
+}
+
+// WithTokenizerLibraryPath sets the explicit pure-tokenizers shared library path.
+func WithTokenizerLibraryPath(path string) Option {
+	return func(cfg *config) error {
+		if path == "" {
+			return fmt.Errorf("tokenizer library path cannot be empty")
+		}
+		cfg.tokenizerLibraryPath = path
+		return nil
+	}
+}
+
+// WithImageInputOutputNames overrides the vision tower's input and output names.
+func WithImageInputOutputNames(pixelValuesName, outputName string) Option {
+	return func(cfg *config) error {
+		if pixelValuesName == "" || outputName == "" {
+			return fmt.Errorf("pixel values and image output names cannot be empty")
+		}
+		cfg.pixelValuesName = pixelValuesName
+		cfg.imageOutputName = outputName
+		return nil
+	}
+}
+
+// WithTextInputOutputNames overrides the text tower's input and output names.
+// attentionMaskName may be empty for exports that only consume input_ids.
+func WithTextInputOutputNames(inputIDsName, attentionMaskName, outputName string) Option {
+	return func(cfg *config) error {
+		if inputIDsName == "" || outputName == "" {
+			return fmt.Errorf("input_ids and text output names cannot be empty")
+		}
+		cfg.inputIDsName = inputIDsName
+		cfg.attentionMaskName = attentionMaskName
+		cfg.textOutputName = outputName
+		return nil
+	}
+}
+
+// Embedder embeds images and texts with the vision and text towers of a CLIP export.
+//
+// Both towers must output projected [batch, dim] embeddings (image_embeds and
+// text_embeds in Hugging Face exports); results are L2-normalized, so the dot product
+// of an image and a text embedding is their cosine similarity.
+// The caller must initialize ONNX Runtime via ort.SetSharedLibraryPath and
+// ort.InitializeEnvironment before calling EmbedImages/EmbedTexts.
+type Embedder struct {
+	imageModelPath     string
+	textModelPath      string
+	imageSize          int
+	sequenceLength     int
+	embeddingDimension int64
+	imageMean          [3]float32
+	imageStd           [3]float32
+	tokenizer          *tokenizers.Tokenizer
+	imageInputNames    []string
+	imageOutputNames   []string
+	textInputNames     []string
+	textOutputNames    []string
+	imageSessions      *ortutil.SessionCache[int, *towerSession]
+	textSessions       *ortutil.SessionCache[int, *towerSession]
+	runMu              sync.Mutex
+}
+
+// NewEmbedder creates a CLIP embedder.
+//
+// imageModelPath and textModelPath must point to the local vision and text tower
+// ONNX files; tokenizerPath must point to the CLIP tokenizer.json file.
+func NewEmbedder(imageModelPath string, textModelPath string, tokenizerPath string, opts ...Option) (*Embedder, error) {
+	if imageModelPath == "" || textModelPath == "" {
+		return nil, fmt.Errorf("image and text model paths cannot be empty")
+	}
+	if tokenizerPath == "" {
+		return nil, fmt.Errorf("tokenizer path cannot be empty")
+	}
+	for _, path := range []string{imageModelPath, textModelPath} {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("model path %q is not usable: %w", path, err)
+		}
+	}
+	if _, err := os.Stat(tokenizerPath); err != nil {
+		return nil, fmt.Errorf("tokenizer path %q is not usable: %w", tokenizerPath, err)
+	}
+
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	tokenizerOpts := []tokenizers.TokenizerOption{
+		tokenizers.WithTruncation(
+			uintptr(cfg.sequenceLength),
+			tokenizers.TruncationDirectionRight,
+			tokenizers.TruncationStrategyLongestFirst,
+		),
+		tokenizers.WithPadding(true, tokenizers.PaddingStrategy{
+			Tag:       tokenizers.PaddingStrategyFixed,
+			FixedSize: uintptr(cfg.sequenceLength),
+		}),
+	}
+	if cfg.tokenizerLibraryPath != "" {
+		tokenizerOpts = append(tokenizerOpts, tokenizers.WithLibraryPath(cfg.tokenizerLibraryPath))
+	}
+	tokenizer, err := tokenizers.FromFile(tokenizerPath, tokenizerOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizer: %w", err)
+	}
+
+	textInputNames := []string{cfg.inputIDsName}
+	if cfg.attentionMaskName != "" {
+		textInputNames = append(textInputNames, cfg.attentionMaskName)
+	}
+
+	return &Embedder{
+		imageModelPath:     imageModelPath,
+		textModelPath:      textModelPath,
+		imageSize:          cfg.imageSize,
+		sequenceLength:     cfg.sequenceLength,
+		embeddingDimension: cfg.embeddingDimension,
+		imageMean:          cfg.imageMean,
+		imageStd:           cfg.imageStd,
+		tokenizer:          tokenizer,
+		imageInputNames:    []string{cfg.pixelValuesName},
+		imageOutputNames:   []string{cfg.imageOutputName},
+		textInputNames:     textInputNames,
+		textOutputNames:    []string{cfg.textOutputName},
+		imageSessions:      ortutil.NewSessionCache[int, *towerSession](cfg.maxCachedBatchCount, describeTowerSession),
+		textSessions:       ortutil.NewSessionCache[int, *towerSession](cfg.maxCachedBatchCount, describeTowerSession),
+	}, nil
+}
+
+// Close releases ONNX session resources and tokenizer resources.
+func (e *Embedder) Close() error {
+	if e == nil {
+		return nil
+	}
+
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+
+	var err error
+	if destroyErr := e.imageSessions.DestroyAll(); destroyErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to destroy image sessions: %w", destroyErr))
+	}
+	if destroyErr := e.textSessions.DestroyAll(); destroyErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to destroy text sessions: %w", destroyErr))
+	}
+	e.imageSessions = nil
+	e.textSessions = nil
+
+	if e.tokenizer != nil {
+		if closeErr := e.tokenizer.Close(); closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+		e.tokenizer = nil
+	}
+	return err
+}
+
+// EmbedImages embeds images into L2-normalized vectors; result[i] is the embedding of images[i].
+// Each image is center-cropped to a square and resized to the configured image size.
+func (e *Embedder) EmbedImages(images []image.Image) ([][]float32, error) {
+	if e == nil {
+		return nil, fmt.Errorf("embedder is nil")
+	}
+	if len(images) == 0 {
+		return [][]float32{}, nil
+	}
+
+	total, err := e.pixelCount(len(images))
+	if err != nil {
+		return nil, err
+	}
+	pixels := make([]float32, 0, total)
+	for i, img := range images {
+		if img == nil {
+			return nil, fmt.Errorf("image %d is nil", i)
+		}
+		data, err := ort.ImageToNCHW(centerSquare(img), e.imageSize, e.imageSize, e.imageMean, e.imageStd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to preprocess image %d: %w", i, err)
+		}
+		pixels = append(pixels, data...)
+	}
+
+	return e.run(len(images), true, func(session *towerSession) error {
+		copy(session.pixelValues, pixels)
+		return nil
+	})
+}
+
+// EmbedTexts embeds texts into L2-normalized vectors; result[i] is the embedding of texts[i].
+func (e *Embedder) EmbedTexts(texts []string) ([][]float32, error) {
+	if e == nil {
+		return nil, fmt.Errorf("embedder is nil")
+	}
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	return e.run(len(texts), false, func(session *towerSession) error {
+		return e.tokenizeInto(texts, session.inputIDs, session.attentionMask)
+	})
+}
+
+// run embeds one batch with the image or text tower; fill populates the session inputs.
+func (e *Embedder) run(batchSize int, imageTower bool, fill func(*towerSession) error) ([][]float32, error) {
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+
+	if e.tokenizer == nil || e.imageSessions == nil || e.textSessions == nil {
+		return nil, fmt.Errorf("embedder has been closed")
+	}
+	if !ort.IsInitialized() {
+		return nil, fmt.Errorf("ONNX Runtime not initialized: call ort.SetSharedLibraryPath and ort.InitializeEnvironment first")
+	}
+
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be > 0, got %d", batchSize)
+	}
+	var session *towerSession
+	var err error
+	if imageTower {
+		session, err = e.imageSessions.Get(batchSize, e.newImageSession)
+	} else {
+		session, err = e.textSessions.Get(batchSize, e.newTextSession)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := fill(session); err != nil {
+		return nil, err
+	}
+	if err := session.session.Run(); err != nil {
+		return nil, fmt.Errorf("clip inference failed: %w", err)
+	}
+	return splitAndNormalize(session.outputTensor.GetData(), batchSize, e.embeddingDimension)
+}
+
+func (e *Embedder) tokenizeInto(texts []string, inputIDs []int64, attentionMask []int64) error {
+	totalTokens := len(texts) * e.sequenceLength
+	if len(inputIDs) != totalTokens {
+		return fmt.Errorf("token buffer length mismatch: got input_ids=%d, want %d", len(inputIDs), totalTokens)
+	}
+	clear(inputIDs)
+	clear(attentionMask)
+
+	for i, text := range texts {
+		encoding, err := e.tokenizer.Encode(
+			text,
+			tokenizers.WithAddSpecialTokens(),
+			tokenizers.WithReturnAttentionMask(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to tokenize text %d: %w", i, err)
+		}
+		if encoding == nil {
+			return fmt.Errorf("failed to tokenize text %d: empty tokenizer result", i)
+		}
+
+		rowStart := i * e.sequenceLength
+		row := inputIDs[rowStart : rowStart+e.sequenceLength]
+		for j := 0; j < len(row) && j < len(encoding.IDs); j++ {
+			row[j] = int64(encoding.IDs[j])
+		}
+		if attentionMask != nil {
+			maskRow := attentionMask[rowStart : rowStart+e.sequenceLength]
+			for j := 0; j < len(maskRow) && j < len(encoding.AttentionMask); j++ {
+				maskRow[j] = int64(encoding.AttentionMask[j])
+			}
+		}
+	}
+	return nil
+}
+
+// centerSquare crops img to its centered largest square, matching CLIP's
+// resize-shortest-side-then-center-crop preprocessing once resized.
+func centerSquare(img image.Image) image.Image {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+	crop := image.Rect(x0, y0, x0+side, y0+side)
+	if crop == bounds {
+		return img
+	}
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(crop)
+	}
+	return croppedImage{Image: img, bounds: crop}
+}
+
+// croppedImage restricts an image.Image without SubImage support to bounds.
+type croppedImage struct {
+	image.Image
+	bounds image.Rectangle
+}
+
+func (c croppedImage) Bounds() image.Rectangle { return c.bounds }
+
+// splitAndNormalize splits a [batch, dim] output into L2-normalized rows.
+func splitAndNormalize(output []float32, batchSize int, embeddingDim int64) ([][]float32, error) {
+	dim := int(embeddingDim)
+	if len(output) != batchSize*dim {
+		return nil, fmt.Errorf("unexpected output size: got %d, want %d ([%d, %d])", len(output), batchSize*dim, batchSize, dim)
+	}
+	rows := make([][]float32, batchSize)
+	for row := range rows {
+		embedding := make([]float32, dim)
+		copy(embedding, output[row*dim:(row+1)*dim])
+		rows[row] = embeddings.L2Normalize(embedding)
+	}
+	return rows, nil
+}
+
+// describeTowerSession names a cached session's resources in cache errors.
+func describeTowerSession(batchSize int) string {
+	return fmt.Sprintf("batch-%d clip resources", batchSize)
+}
+
+// towerSession holds one batch-size specific session for either tower. Image sessions
+// use pixelValues; text sessions use inputIDs and, when configured, attentionMask.
+type towerSession struct {
+	pixelValues   []float32
+	inputIDs      []int64
+	attentionMask []int64
+
+	pixelValuesTensor   *ort.Tensor[float32]
+	inputIDsTensor      *ort.Tensor[int64]
+	attentionMaskTensor *ort.Tensor[int64]
+	outputTensor        *ort.Tensor[float32]
+	session             *ort.AdvancedSession
+}
+
+// pixelCount returns the number of pixel values in a batch of images, the size of the
+// NCHW pixel_values tensor.
+func (e *Embedder) pixelCount(images int) (int, error) {
+	total := images
+	for _, factor := range []int{3, e.imageSize, e.imageSize} {
+		var err error
+		if total, err = ortutil.CheckedMul(total, factor); err != nil {
+			return 0, fmt.Errorf("invalid pixel buffer size: %w", err)
+		}
+	}
+	return total, nil
+}
+
+func (e *Embedder) newImageSession(batchSize int) (*towerSession, error) {
+	total, err := e.pixelCount(batchSize)
+	if err != nil {
+		return nil, err
+	}
+	pixelValues := make([]float32, total)
+	size := int64(e.imageSize)
+	pixelValuesTensor, err := ort.NewTensor[float32](ort.Shape{int64(batchSize), 3, size, size}, pixelValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pixel values tensor: %w", err)
+	}
+	session := &towerSession{pixelValues: pixelValues, pixelValuesTensor: pixelValuesTensor}
+	if err := session.bind(e.imageModelPath, e.imageInputNames, e.imageOutputNames, []ort.Value{pixelValuesTensor}, batchSize, e.embeddingDimension); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (e *Embedder) newTextSession(batchSize int) (*towerSession, error) {
+	shape := ort.Shape{int64(batchSize), int64(e.sequenceLength)}
+	totalTokens, err := ortutil.CheckedMul(batchSize, e.sequenceLength)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session size: %w", err)
+	}
+	inputIDs := make([]int64, totalTokens)
+	inputIDsTensor, err := ort.NewTensor[int64](shape, inputIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input_ids tensor: %w", err)
+	}
+	session := &towerSession{inputIDs: inputIDs, inputIDsTensor: inputIDsTensor}
+	inputValues := []ort.Value{inputIDsTensor}
+	if len(e.textInputNames) > 1 {
+		session.attentionMask = make([]int64, totalTokens)
+		session.attentionMaskTensor, err = ort.NewTensor[int64](shape, session.attentionMask)
+		if err != nil {
+			_ = inputIDsTensor.Destroy()
+			return nil, fmt.Errorf("failed to create attention_mask tensor: %w", err)
+		}
+		inputValues = append(inputValues, session.attentionMaskTensor)
+	}
+	if err := session.bind(e.textModelPath, e.textInputNames, e.textOutputNames, inputValues, batchSize, e.embeddingDimension); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// bind creates the output tensor and session; on failure it destroys the input tensors.
+func (s *towerSession) bind(modelPath string, inputNames []string, outputNames []string, inputValues []ort.Value, batchSize int, embeddingDimension int64) error {
+	outputTensor, err := ort.NewEmptyTensor[float32](ort.Shape{int64(batchSize), embeddingDimension})
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to create output tensor: %w", err), s.Destroy())
+	}
+	s.outputTensor = outputTensor
+
+	session, err := ort.NewAdvancedSession(modelPath, inputNames, outputNames, inputValues, []ort.Value{outputTensor}, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to create clip session for %q: %w", modelPath, err), s.Destroy())
+	}
+	s.session = session
+	return nil
+}
+
+func (s *towerSession) Destroy() error {
+	if s == nil {
+		return nil
+	}
+	err := ortutil.DestroyAll(
+		s.session,
+		s.outputTensor,
+		s.attentionMaskTensor,
+		s.inputIDsTensor,
+		s.pixelValuesTensor,
+	)
+	s.session = nil
+	s.outputTensor = nil
+	s.attentionMaskTensor = nil
+	s.inputIDsTensor = nil
+	s.pixelValuesTensor = nil
+	s.pixelValues = nil
+	s.inputIDs = nil
+	s.attentionMask = nil
+	return err
+}
@@ -0,0 +1,121 @@
+package clip
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"testing"
+
+	"github.com/amikos-tech/pure-onnx/ort"
+)
+
+// resolveCLIPAssets returns the CLIP tower and tokenizer paths from the environment,
+// skipping the test when they are not configured.
+func resolveCLIPAssets(t *testing.T) (imageModelPath, textModelPath, tokenizerPath string) {
+	t.Helper()
+
+	imageModelPath = os.Getenv("ONNXRUNTIME_TEST_CLIP_IMAGE_MODEL_PATH")
+	textModelPath = os.Getenv("ONNXRUNTIME_TEST_CLIP_TEXT_MODEL_PATH")
+	tokenizerPath = os.Getenv("ONNXRUNTIME_TEST_CLIP_TOKENIZER_PATH")
+	if imageModelPath == "" || textModelPath == "" || tokenizerPath == "" {
+		t.Skip("ONNXRUNTIME_TEST_CLIP_{IMAGE_MODEL,TEXT_MODEL,TOKENIZER}_PATH not set, skipping CLIP integration test")
+	}
+	return imageModelPath, textModelPath, tokenizerPath
+}
+
+func setupORTTestEnvironment(tb testing.TB) func() {
+	tb.Helper()
+
+	libPath := os.Getenv("ONNXRUNTIME_LIB_PATH")
+	if libPath == "" {
+		tb.Skip("ONNXRUNTIME_LIB_PATH not set, skipping integration test")
+	}
+
+	if err := ort.SetSharedLibraryPath(libPath); err != nil {
+		tb.Fatalf("failed to set ONNX Runtime library path: %v", err)
+	}
+	if err := ort.InitializeEnvironment(); err != nil {
+		tb.Fatalf("failed to initialize ONNX Runtime: %v", err)
+	}
+
+	return func() {
+		if err := ort.DestroyEnvironment(); err != nil {
+			tb.Errorf("failed to destroy ONNX Runtime environment: %v", err)
+		}
+	}
+}
+
+func solidImage(width, height int, c color.Color) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func TestCLIPMatchesImageToText(t *testing.T) {
+	imageModelPath, textModelPath, tokenizerPath := resolveCLIPAssets(t)
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	embedder, err := NewEmbedder(imageModelPath, textModelPath, tokenizerPath)
+	if err != nil {
+		t.Fatalf("failed to create CLIP embedder: %v", err)
+	}
+	defer func() {
+		if err := embedder.Close(); err != nil {
+			t.Errorf("failed to close embedder: %v", err)
+		}
+	}()
+
+	images, err := embedder.EmbedImages([]image.Image{
+		solidImage(320, 240, color.NRGBA{R: 230, G: 20, B: 20, A: 255}),
+		solidImage(240, 320, color.NRGBA{R: 20, G: 40, B: 230, A: 255}),
+	})
+	if err != nil {
+		t.Fatalf("EmbedImages failed: %v", err)
+	}
+	texts, err := embedder.EmbedTexts([]string{"a plain red image", "a plain blue image"})
+	if err != nil {
+		t.Fatalf("EmbedTexts failed: %v", err)
+	}
+
+	for i, vectors := range [][][]float32{images, texts} {
+		for j, vector := range vectors {
+			if norm := dot(vector, vector); norm < 0.999 || norm > 1.001 {
+				t.Fatalf("set %d row %d: expected unit norm, got squared norm %v", i, j, norm)
+			}
+		}
+	}
+
+	for i := range images {
+		matching := dot(images[i], texts[i])
+		other := dot(images[i], texts[1-i])
+		t.Logf("image %d: matching cosine %.4f, other cosine %.4f", i, matching, other)
+		if matching <= other {
+			t.Fatalf("image %d: expected matching caption to score higher (%.4f <= %.4f)", i, matching, other)
+		}
+	}
+
+	// A repeated batch size reuses the cached session and is deterministic.
+	again, err := embedder.EmbedTexts([]string{"a plain red image", "a plain blue image"})
+	if err != nil {
+		t.Fatalf("second EmbedTexts failed: %v", err)
+	}
+	if err := ort.ApproxEqual(again[0], texts[0], 1e-6); err != nil {
+		t.Fatalf("repeated text embedding differs: %v", err)
+	}
+	if got := embedder.textSessions.Len(); got != 1 {
+		t.Fatalf("expected one cached text session, got %d", got)
+	}
+}
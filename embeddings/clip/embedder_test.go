@@ -0,0 +1,145 @@
+package clip
+
+import (
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOptionsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     Option
+		wantErr string
+	}{
+		{name: "image size", opt: WithImageSize(0), wantErr: "image size must be > 0"},
+		{name: "sequence length", opt: WithSequenceLength(-1), wantErr: "sequence length must be > 0"},
+		{name: "embedding dimension", opt: WithEmbeddingDimension(0), wantErr: "embedding dimension must be > 0"},
+		{name: "zero std", opt: WithImageNormalization([3]float32{}, [3]float32{1, 1, 0}), wantErr: "image std[2] must be non-zero"},
+		{name: "max cached sessions", opt: WithMaxCachedBatchSessions(0), wantErr: "max cached batch sessions must be > 0"},
+		{name: "tokenizer library path", opt: WithTokenizerLibraryPath(""), wantErr: "tokenizer library path cannot be empty"},
+		{name: "image names", opt: WithImageInputOutputNames("", "image_embeds"), wantErr: "pixel values and image output names"},
+		{name: "text names", opt: WithTextInputOutputNames("input_ids", "", ""), wantErr: "input_ids and text output names"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			if err := tt.opt(&cfg); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	cfg := defaultConfig()
+	if err := WithTextInputOutputNames("ids", "", "embeds")(&cfg); err != nil {
+		t.Fatalf("expected optional attention mask name, got: %v", err)
+	}
+	if cfg.attentionMaskName != "" || cfg.textOutputName != "embeds" {
+		t.Fatalf("unexpected text names: %+v", cfg)
+	}
+}
+
+func TestNewEmbedderValidatesPaths(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "model.onnx")
+	if err := os.WriteFile(existing, []byte("x"), 0o600); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	missing := filepath.Join(dir, "missing.onnx")
+
+	tests := []struct {
+		name      string
+		image     string
+		text      string
+		tokenizer string
+		wantErr   string
+	}{
+		{name: "empty model path", image: "", text: existing, tokenizer: existing, wantErr: "model paths cannot be empty"},
+		{name: "empty tokenizer path", image: existing, text: existing, tokenizer: "", wantErr: "tokenizer path cannot be empty"},
+		{name: "missing text model", image: existing, text: missing, tokenizer: existing, wantErr: "is not usable"},
+		{name: "missing tokenizer", image: existing, text: existing, tokenizer: missing, wantErr: "tokenizer path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewEmbedder(tt.image, tt.text, tt.tokenizer); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEmbedValidation(t *testing.T) {
+	var nilEmbedder *Embedder
+	if _, err := nilEmbedder.EmbedImages(nil); err == nil || !strings.Contains(err.Error(), "embedder is nil") {
+		t.Fatalf("expected nil embedder error, got: %v", err)
+	}
+	if _, err := nilEmbedder.EmbedTexts(nil); err == nil || !strings.Contains(err.Error(), "embedder is nil") {
+		t.Fatalf("expected nil embedder error, got: %v", err)
+	}
+
+	closed := &Embedder{imageSize: 4}
+	if vectors, err := closed.EmbedTexts(nil); err != nil || vectors == nil || len(vectors) != 0 {
+		t.Fatalf("expected empty result for empty input, got %v, %v", vectors, err)
+	}
+	if _, err := closed.EmbedImages([]image.Image{nil}); err == nil || !strings.Contains(err.Error(), "image 0 is nil") {
+		t.Fatalf("expected nil image error, got: %v", err)
+	}
+	if _, err := closed.EmbedTexts([]string{"a photo of a cat"}); err == nil || !strings.Contains(err.Error(), "embedder has been closed") {
+		t.Fatalf("expected closed embedder error, got: %v", err)
+	}
+	if err := closed.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+}
+
+func TestCenterSquare(t *testing.T) {
+	wide := image.NewNRGBA(image.Rect(0, 0, 6, 2))
+	if got := centerSquare(wide).Bounds(); got != image.Rect(2, 0, 4, 2) {
+		t.Fatalf("unexpected wide crop: %v", got)
+	}
+	tall := image.NewGray(image.Rect(10, 10, 13, 18))
+	if got := centerSquare(tall).Bounds(); got != image.Rect(10, 12, 13, 15) {
+		t.Fatalf("unexpected tall crop: %v", got)
+	}
+	square := image.NewNRGBA(image.Rect(0, 0, 3, 3))
+	if centerSquare(square) != image.Image(square) {
+		t.Fatalf("expected square image to be returned unchanged")
+	}
+
+	// Images without SubImage are wrapped, keeping pixel lookups in source coordinates.
+	plain := struct{ image.Image }{Image: &image.NRGBA{Rect: image.Rect(0, 0, 4, 2)}}
+	if got := centerSquare(plain).Bounds(); got != image.Rect(1, 0, 3, 2) {
+		t.Fatalf("unexpected wrapped crop: %v", got)
+	}
+}
+
+func TestSplitAndNormalize(t *testing.T) {
+	rows, err := splitAndNormalize([]float32{3, 4, 0, 0}, 2, 2)
+	if err != nil {
+		t.Fatalf("splitAndNormalize failed: %v", err)
+	}
+	if rows[0][0] != 0.6 || rows[0][1] != 0.8 {
+		t.Fatalf("unexpected normalized row: %v", rows[0])
+	}
+	if rows[1][0] != 0 || rows[1][1] != 0 || math.IsNaN(float64(rows[1][0])) {
+		t.Fatalf("expected zero row to stay zero, got %v", rows[1])
+	}
+
+	if _, err := splitAndNormalize([]float32{1, 2, 3}, 2, 2); err == nil || !strings.Contains(err.Error(), "unexpected output size") {
+		t.Fatalf("expected output size error, got: %v", err)
+	}
+}
+
+func TestPixelCount(t *testing.T) {
+	e := &Embedder{imageSize: 224}
+	if got, err := e.pixelCount(2); err != nil || got != 2*3*224*224 {
+		t.Fatalf("pixelCount(2) = %d, %v; want %d", got, err, 2*3*224*224)
+	}
+	e.imageSize = math.MaxInt / 4
+	if _, err := e.pixelCount(2); err == nil || !strings.Contains(err.Error(), "invalid pixel buffer size") {
+		t.Fatalf("expected overflow error, got: %v", err)
+	}
+}
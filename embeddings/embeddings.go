@@ -0,0 +1,56 @@
+// Package embeddings defines the interfaces shared by the embedder implementations
+// in its subpackages, so retrieval code can be written against an abstraction and
+// swap minilm, splade, or other implementations without changes.
+package embeddings
+
+import "fmt"
+
+// DenseEmbedder produces fixed-width dense vectors, for example minilm.Embedder.
+type DenseEmbedder interface {
+	// EmbedDocuments embeds documents; result[i] is the embedding of documents[i].
+	EmbedDocuments(documents []string) ([][]float32, error)
+	// EmbedQuery embeds a single query.
+	EmbedQuery(query string) ([]float32, error)
+	// Close releases the embedder's resources.
+	Close() error
+}
+
+// SparseEmbedder produces sparse vectors, for example splade.Embedder.
+type SparseEmbedder interface {
+	// EmbedDocuments embeds documents; result[i] is the embedding of documents[i].
+	EmbedDocuments(documents []string) ([]SparseVector, error)
+	// EmbedQuery embeds a single query.
+	EmbedQuery(query string) (SparseVector, error)
+	// Close releases the embedder's resources.
+	Close() error
+}
+
+// SparseVector is a sparse representation of one document embedding.
+type SparseVector struct {
+	Indices []int     `json:"indices"`
+	Values  []float32 `json:"values"`
+	Labels  []string  `json:"labels,omitempty"`
+	// Counts holds, per index, how many attended token positions had a weight above
+	// the prune threshold. Populated only by embedders that support it.
+	Counts []int `json:"counts,omitempty"`
+	// RawValues holds, per index, the untransformed model logit the value was derived from
+	// (before any log1p/ReLU). Populated only by embedders that support it.
+	RawValues []float32 `json:"raw_values,omitempty"`
+}
+
+// Validate checks sparse vector parallel-slice invariants.
+func (v SparseVector) Validate() error {
+	if len(v.Indices) != len(v.Values) {
+		return fmt.Errorf("sparse vector has mismatched indices/values lengths: indices=%d values=%d", len(v.Indices), len(v.Values))
+	}
+	if len(v.Labels) > 0 && len(v.Labels) != len(v.Indices) {
+		return fmt.Errorf("sparse vector has mismatched labels/indices lengths: labels=%d indices=%d", len(v.Labels), len(v.Indices))
+	}
+	if len(v.Counts) > 0 && len(v.Counts) != len(v.Indices) {
+		return fmt.Errorf("sparse vector has mismatched counts/indices lengths: counts=%d indices=%d", len(v.Counts), len(v.Indices))
+	}
+	if len(v.RawValues) > 0 && len(v.RawValues) != len(v.Indices) {
+		return fmt.Errorf("sparse vector has mismatched raw values/indices lengths: raw_values=%d indices=%d", len(v.RawValues), len(v.Indices))
+	}
+	return nil
+}
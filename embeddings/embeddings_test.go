@@ -0,0 +1,85 @@
+package embeddings_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/amikos-tech/pure-onnx/embeddings"
+	"github.com/amikos-tech/pure-onnx/embeddings/minilm"
+	"github.com/amikos-tech/pure-onnx/embeddings/splade"
+)
+
+var (
+	_ embeddings.DenseEmbedder  = (*minilm.Embedder)(nil)
+	_ embeddings.SparseEmbedder = (*splade.Embedder)(nil)
+	_ embeddings.DenseEmbedder  = (*minilm.LazyEmbedder)(nil)
+	_ embeddings.SparseEmbedder = (*splade.LazyEmbedder)(nil)
+)
+
+func TestDenseEmbedderBehavior(t *testing.T) {
+	tests := []struct {
+		name     string
+		embedder embeddings.DenseEmbedder
+	}{
+		{name: "minilm", embedder: &minilm.Embedder{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vectors, err := tt.embedder.EmbedDocuments(nil)
+			if err != nil {
+				t.Fatalf("unexpected error for empty input: %v", err)
+			}
+			if vectors == nil || len(vectors) != 0 {
+				t.Fatalf("expected empty non-nil result, got %v", vectors)
+			}
+
+			if _, err := tt.embedder.EmbedQuery("hello"); err == nil || !strings.Contains(err.Error(), "embedder has been closed") {
+				t.Fatalf("expected closed embedder error, got: %v", err)
+			}
+			if err := tt.embedder.Close(); err != nil {
+				t.Fatalf("unexpected close error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSparseEmbedderBehavior(t *testing.T) {
+	tests := []struct {
+		name     string
+		embedder embeddings.SparseEmbedder
+	}{
+		{name: "splade", embedder: &splade.Embedder{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vectors, err := tt.embedder.EmbedDocuments(nil)
+			if err != nil {
+				t.Fatalf("unexpected error for empty input: %v", err)
+			}
+			if vectors == nil || len(vectors) != 0 {
+				t.Fatalf("expected empty non-nil result, got %v", vectors)
+			}
+
+			if _, err := tt.embedder.EmbedQuery("hello"); err == nil || !strings.Contains(err.Error(), "embedder has been closed") {
+				t.Fatalf("expected closed embedder error, got: %v", err)
+			}
+			if err := tt.embedder.Close(); err != nil {
+				t.Fatalf("unexpected close error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSparseVectorValidate(t *testing.T) {
+	valid := embeddings.SparseVector{Indices: []int{1, 4}, Values: []float32{0.5, 0.25}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	invalid := embeddings.SparseVector{Indices: []int{1, 4}, Values: []float32{0.5}}
+	if err := invalid.Validate(); err == nil || !strings.Contains(err.Error(), "mismatched indices/values") {
+		t.Fatalf("expected mismatched indices/values error, got: %v", err)
+	}
+}
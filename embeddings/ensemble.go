@@ -0,0 +1,65 @@
+package embeddings
+
+import (
+	"fmt"
+	"math"
+)
+
+// AverageEmbeddings returns the element-wise mean of rows, for ensembling dense vectors
+// from several models with the same width. Rows must be non-empty and of equal length;
+// they are not modified. Pass the result to L2Normalize to re-normalize it.
+func AverageEmbeddings(rows ...[]float32) ([]float32, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("at least one embedding is required")
+	}
+	dim := len(rows[0])
+	if dim == 0 {
+		return nil, fmt.Errorf("embeddings must be non-empty")
+	}
+	sums := make([]float64, dim)
+	for i, row := range rows {
+		if len(row) != dim {
+			return nil, fmt.Errorf("embedding %d has dimension %d, want %d", i, len(row), dim)
+		}
+		for j, value := range row {
+			sums[j] += float64(value)
+		}
+	}
+	average := make([]float32, dim)
+	for j, sum := range sums {
+		average[j] = float32(sum / float64(len(rows)))
+	}
+	return average, nil
+}
+
+// ConcatEmbeddings returns rows joined end to end in argument order, for ensembling
+// vectors from models of different widths. Rows are not modified. Pass the result to
+// L2Normalize to re-normalize it.
+func ConcatEmbeddings(rows ...[]float32) []float32 {
+	total := 0
+	for _, row := range rows {
+		total += len(row)
+	}
+	concatenated := make([]float32, 0, total)
+	for _, row := range rows {
+		concatenated = append(concatenated, row...)
+	}
+	return concatenated
+}
+
+// L2Normalize scales vector in place to unit L2 norm and returns it. A zero vector is
+// returned unchanged.
+func L2Normalize(vector []float32) []float32 {
+	sum := 0.0
+	for _, value := range vector {
+		sum += float64(value) * float64(value)
+	}
+	if sum == 0 {
+		return vector
+	}
+	invNorm := 1 / math.Sqrt(sum)
+	for i, value := range vector {
+		vector[i] = float32(float64(value) * invNorm)
+	}
+	return vector
+}
@@ -0,0 +1,82 @@
+package embeddings_test
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amikos-tech/pure-onnx/embeddings"
+)
+
+func TestAverageEmbeddings(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    [][]float32
+		want    []float32
+		wantErr string
+	}{
+		{name: "single row", rows: [][]float32{{1, -2, 3}}, want: []float32{1, -2, 3}},
+		{name: "two rows", rows: [][]float32{{1, 0, 4}, {3, 2, -4}}, want: []float32{2, 1, 0}},
+		{name: "three rows", rows: [][]float32{{0, 3}, {3, 0}, {0, 0}}, want: []float32{1, 1}},
+		{name: "no rows", wantErr: "at least one embedding is required"},
+		{name: "empty rows", rows: [][]float32{{}, {}}, wantErr: "embeddings must be non-empty"},
+		{name: "length mismatch", rows: [][]float32{{1, 2}, {1, 2, 3}}, wantErr: "embedding 1 has dimension 3, want 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := embeddings.AverageEmbeddings(tt.rows...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AverageEmbeddings failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected average: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcatEmbeddings(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]float32
+		want []float32
+	}{
+		{name: "no rows", want: []float32{}},
+		{name: "different widths", rows: [][]float32{{1, 2}, {3}, {}, {4, 5, 6}}, want: []float32{1, 2, 3, 4, 5, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := embeddings.ConcatEmbeddings(tt.rows...); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected concatenation: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	first := []float32{3, 0}
+	combined := embeddings.L2Normalize(embeddings.ConcatEmbeddings(first, []float32{4}))
+	if want := []float32{0.6, 0, 0.8}; !reflect.DeepEqual(combined, want) {
+		t.Fatalf("unexpected normalized concatenation: got %v, want %v", combined, want)
+	}
+	if first[0] != 3 {
+		t.Fatalf("expected the input rows to stay untouched, got %v", first)
+	}
+}
+
+func TestL2Normalize(t *testing.T) {
+	vector := embeddings.L2Normalize([]float32{1, 1, 1, 1})
+	for _, value := range vector {
+		if math.Abs(float64(value)-0.5) > 1e-7 {
+			t.Fatalf("unexpected normalized vector: %v", vector)
+		}
+	}
+	if zero := embeddings.L2Normalize([]float32{0, 0}); !reflect.DeepEqual(zero, []float32{0, 0}) {
+		t.Fatalf("expected a zero vector to stay zero, got %v", zero)
+	}
+}
@@ -0,0 +1,19 @@
+package ortutil
+
+// SubBatchBounds splits total rows into [start, end) ranges of maxBatchSize rows
+// followed by at most one smaller remainder, so a split call touches at most two
+// batch sizes (and therefore at most two cached sessions). maxBatchSize <= 0 means
+// no limit.
+func SubBatchBounds(total int, maxBatchSize int) [][2]int {
+	if total <= 0 {
+		return nil
+	}
+	if maxBatchSize <= 0 || maxBatchSize >= total {
+		return [][2]int{{0, total}}
+	}
+	bounds := make([][2]int, 0, (total+maxBatchSize-1)/maxBatchSize)
+	for start := 0; start < total; start += maxBatchSize {
+		bounds = append(bounds, [2]int{start, min(start+maxBatchSize, total)})
+	}
+	return bounds
+}
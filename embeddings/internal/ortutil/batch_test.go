@@ -0,0 +1,30 @@
+package ortutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubBatchBounds(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        int
+		maxBatchSize int
+		want         [][2]int
+	}{
+		{name: "empty", total: 0, maxBatchSize: 4, want: nil},
+		{name: "unlimited", total: 10, maxBatchSize: 0, want: [][2]int{{0, 10}}},
+		{name: "fits", total: 4, maxBatchSize: 4, want: [][2]int{{0, 4}}},
+		{name: "even split", total: 8, maxBatchSize: 4, want: [][2]int{{0, 4}, {4, 8}}},
+		{name: "remainder", total: 10, maxBatchSize: 4, want: [][2]int{{0, 4}, {4, 8}, {8, 10}}},
+		{name: "single rows", total: 3, maxBatchSize: 1, want: [][2]int{{0, 1}, {1, 2}, {2, 3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SubBatchBounds(tt.total, tt.maxBatchSize)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected bounds: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
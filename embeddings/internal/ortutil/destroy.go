@@ -10,7 +10,8 @@ type Destroyer interface {
 	Destroy() error
 }
 
-// DestroyAll destroys each resource and joins all non-nil errors.
+// DestroyAll destroys each resource in argument order and joins all non-nil errors.
+// Pass a session before the tensors it references; see ort.AdvancedSession.Destroy.
 // Typed nil values are ignored.
 func DestroyAll(resources ...Destroyer) error {
 	var err error
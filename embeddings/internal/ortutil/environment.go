@@ -0,0 +1,87 @@
+package ortutil
+
+import (
+	"fmt"
+
+	"github.com/amikos-tech/pure-onnx/ort"
+)
+
+// AcquireEnvironment takes a reference on the ONNX Runtime environment. If the runtime
+// is already initialized the caller's environment is reused; otherwise it is initialized
+// with ort.InitializeEnvironmentWithBootstrap (honoring the ONNXRUNTIME_* environment
+// variables). Each successful call must be paired with ort.DestroyEnvironment.
+func AcquireEnvironment() error {
+	var err error
+	if ort.IsInitialized() {
+		err = ort.InitializeEnvironment()
+	} else {
+		err = ort.InitializeEnvironmentWithBootstrap()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to initialize ONNX Runtime: %w", err)
+	}
+	return nil
+}
+
+// Runtime lifecycle functions used by BootstrapGuard; tests replace them.
+var (
+	isInitialized           = ort.IsInitialized
+	initializeEnvironment   = ort.InitializeEnvironment
+	initializeWithBootstrap = ort.InitializeEnvironmentWithBootstrap
+	destroyEnvironment      = ort.DestroyEnvironment
+)
+
+// BootstrapGuard lazily initializes ONNX Runtime for an embedder configured to
+// bootstrap it. When enabled, Ensure takes one environment reference for the embedder,
+// bootstrapping the runtime with ort.InitializeEnvironmentWithBootstrap if it is not
+// initialized yet, and Release drops that reference. Every embedder therefore holds its
+// own reference, and the runtime is destroyed only when the last one is released.
+// A BootstrapGuard is not safe for concurrent use; embedders call it under their run lock.
+type BootstrapGuard struct {
+	// Enabled allows Ensure to initialize the runtime; when false, Ensure only reports
+	// whether the caller has initialized it.
+	Enabled bool
+	// Options are passed to ort.InitializeEnvironmentWithBootstrap.
+	Options []ort.BootstrapOption
+
+	acquired bool
+}
+
+// Ensure returns nil when ONNX Runtime is initialized. An enabled guard takes its
+// reference on the first call, bootstrapping the runtime first if needed.
+func (g *BootstrapGuard) Ensure() error {
+	if !g.Enabled {
+		if isInitialized() {
+			return nil
+		}
+		return fmt.Errorf("ONNX Runtime not initialized: call ort.SetSharedLibraryPath and ort.InitializeEnvironment first")
+	}
+	if g.acquired {
+		return nil
+	}
+	// An already initialized runtime only needs another reference; bootstrapping again
+	// would resolve (and possibly download) a library that cannot be loaded anyway.
+	if isInitialized() {
+		if err := initializeEnvironment(); err != nil {
+			return fmt.Errorf("failed to acquire ONNX Runtime environment: %w", err)
+		}
+	} else if err := initializeWithBootstrap(g.Options...); err != nil {
+		return fmt.Errorf("failed to bootstrap ONNX Runtime: %w", err)
+	}
+	g.acquired = true
+	return nil
+}
+
+// Release destroys the environment reference taken by Ensure, if any. Sessions created
+// on it must be destroyed first. When destroying fails the reference is kept, so a
+// later Release can retry.
+func (g *BootstrapGuard) Release() error {
+	if !g.acquired {
+		return nil
+	}
+	if err := destroyEnvironment(); err != nil {
+		return err
+	}
+	g.acquired = false
+	return nil
+}
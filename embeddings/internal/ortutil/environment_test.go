@@ -0,0 +1,115 @@
+package ortutil
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amikos-tech/pure-onnx/ort"
+)
+
+func TestBootstrapGuardWithoutRuntime(t *testing.T) {
+	if ort.IsInitialized() {
+		t.Skip("ONNX Runtime is already initialized")
+	}
+
+	var disabled BootstrapGuard
+	if err := disabled.Ensure(); err == nil || !strings.Contains(err.Error(), "ONNX Runtime not initialized") {
+		t.Fatalf("expected not-initialized error, got: %v", err)
+	}
+
+	missing := filepath.Join(t.TempDir(), "libonnxruntime.so")
+	enabled := BootstrapGuard{Enabled: true, Options: []ort.BootstrapOption{ort.WithBootstrapLibraryPath(missing)}}
+	if err := enabled.Ensure(); err == nil || !strings.Contains(err.Error(), "failed to bootstrap ONNX Runtime") {
+		t.Fatalf("expected bootstrap error, got: %v", err)
+	}
+	if ort.IsInitialized() {
+		t.Fatalf("expected a failed bootstrap to leave ONNX Runtime uninitialized")
+	}
+	if err := enabled.Release(); err != nil {
+		t.Fatalf("expected Release without an acquired reference to be a no-op, got: %v", err)
+	}
+}
+
+// fakeRuntime stands in for the ORT environment reference count.
+type fakeRuntime struct {
+	refs        int
+	bootstraps  int
+	failDestroy bool
+}
+
+func (r *fakeRuntime) install(t *testing.T) {
+	t.Helper()
+	prevIsInitialized, prevInitialize, prevBootstrap, prevDestroy := isInitialized, initializeEnvironment, initializeWithBootstrap, destroyEnvironment
+	t.Cleanup(func() {
+		isInitialized, initializeEnvironment, initializeWithBootstrap, destroyEnvironment = prevIsInitialized, prevInitialize, prevBootstrap, prevDestroy
+	})
+	isInitialized = func() bool { return r.refs > 0 }
+	initializeEnvironment = func() error {
+		r.refs++
+		return nil
+	}
+	initializeWithBootstrap = func(...ort.BootstrapOption) error {
+		r.bootstraps++
+		r.refs++
+		return nil
+	}
+	destroyEnvironment = func() error {
+		if r.failDestroy {
+			return errors.New("sessions still alive")
+		}
+		r.refs--
+		return nil
+	}
+}
+
+func TestBootstrapGuardTwoEmbeddersShareRuntime(t *testing.T) {
+	runtime := &fakeRuntime{}
+	runtime.install(t)
+
+	// Each embedder owns its guard; both take a reference.
+	first := BootstrapGuard{Enabled: true}
+	second := BootstrapGuard{Enabled: true}
+	for _, guard := range []*BootstrapGuard{&first, &second, &first, &second} {
+		if err := guard.Ensure(); err != nil {
+			t.Fatalf("Ensure failed: %v", err)
+		}
+	}
+	if runtime.refs != 2 || runtime.bootstraps != 1 {
+		t.Fatalf("expected one bootstrap and one reference per embedder, got refs=%d bootstraps=%d", runtime.refs, runtime.bootstraps)
+	}
+
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if runtime.refs != 1 {
+		t.Fatalf("expected closing the first embedder to leave the runtime to the second, got refs=%d", runtime.refs)
+	}
+
+	// A failed destroy keeps the reference so that it can be released later.
+	runtime.failDestroy = true
+	if err := second.Release(); err == nil {
+		t.Fatalf("expected destroy failure to be reported")
+	}
+	runtime.failDestroy = false
+	if err := second.Release(); err != nil {
+		t.Fatalf("retried Release failed: %v", err)
+	}
+	if runtime.refs != 0 {
+		t.Fatalf("expected the last release to destroy the runtime, got refs=%d", runtime.refs)
+	}
+	if err := second.Release(); err != nil || runtime.refs != 0 {
+		t.Fatalf("expected a repeated Release to be a no-op, got err=%v refs=%d", err, runtime.refs)
+	}
+
+	// A disabled guard never takes or drops a reference.
+	runtime.refs = 1
+	disabled := BootstrapGuard{}
+	if err := disabled.Ensure(); err != nil {
+		t.Fatalf("Ensure on caller-initialized runtime failed: %v", err)
+	}
+	if err := disabled.Release(); err != nil || runtime.refs != 1 {
+		t.Fatalf("expected disabled guard to leave the caller's reference, got err=%v refs=%d", err, runtime.refs)
+	}
+}
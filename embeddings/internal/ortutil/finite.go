@@ -0,0 +1,35 @@
+package ortutil
+
+import (
+	"fmt"
+	"math"
+)
+
+// CheckFinite returns an error naming the first NaN or infinite value in values, which
+// holds rows equal-length rows. With a nil attentionMask every value is checked. Otherwise
+// each row is split into len(attentionMask)/rows token positions and only attended
+// positions are checked, since models may emit anything at padding. describeRow names a
+// row in the error.
+func CheckFinite[T float32 | float64](values []T, rows int, attentionMask []int64, describeRow func(row int) string) error {
+	if rows <= 0 || len(values)%rows != 0 {
+		return fmt.Errorf("output length %d does not split into %d rows", len(values), rows)
+	}
+	stride := len(values) / rows
+	positionWidth := stride
+	if attentionMask != nil {
+		positions := len(attentionMask) / rows
+		if positions == 0 || len(attentionMask)%rows != 0 || stride%positions != 0 {
+			return fmt.Errorf("attention mask length %d does not match output length %d for %d rows", len(attentionMask), len(values), rows)
+		}
+		positionWidth = stride / positions
+	}
+	for i, value := range values {
+		if attentionMask != nil && attentionMask[i/positionWidth] == 0 {
+			continue
+		}
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+			return fmt.Errorf("model output for %s contains non-finite value %v at offset %d", describeRow(i/stride), value, i%stride)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package ortutil
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCheckFinite(t *testing.T) {
+	inf := float32(math.Inf(1))
+	nan := float32(math.NaN())
+
+	tests := []struct {
+		name          string
+		values        []float32
+		rows          int
+		attentionMask []int64
+		wantErr       string
+	}{
+		{name: "finite", values: []float32{1, -2, 3, 4}, rows: 2},
+		{name: "empty", values: nil, rows: 1},
+		{name: "inf", values: []float32{1, 2, 3, inf}, rows: 2, wantErr: "model output for row 1 contains non-finite value +Inf at offset 1"},
+		{name: "nan", values: []float32{nan, 2, 3, 4}, rows: 2, wantErr: "model output for row 0 contains non-finite value NaN at offset 0"},
+		{
+			name:          "padding ignored",
+			values:        []float32{1, 2, nan, inf, 5, 6, 7, 8},
+			rows:          2,
+			attentionMask: []int64{1, 0, 1, 1},
+		},
+		{
+			name:          "attended position",
+			values:        []float32{1, 2, nan, inf, 5, 6, 7, inf},
+			rows:          2,
+			attentionMask: []int64{1, 0, 1, 1},
+			wantErr:       "model output for row 1 contains non-finite value +Inf at offset 3",
+		},
+		{name: "ragged", values: []float32{1, 2, 3}, rows: 2, wantErr: "does not split into 2 rows"},
+		{name: "no rows", values: []float32{1}, rows: 0, wantErr: "does not split into 0 rows"},
+		{name: "mask mismatch", values: []float32{1, 2, 3, 4}, rows: 2, attentionMask: []int64{1, 1, 1}, wantErr: "attention mask length 3 does not match"},
+		{name: "empty mask", values: []float32{1, 2}, rows: 2, attentionMask: []int64{}, wantErr: "attention mask length 0 does not match"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckFinite(tc.values, tc.rows, tc.attentionMask, func(row int) string { return fmt.Sprintf("row %d", row) })
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckFiniteFloat64(t *testing.T) {
+	err := CheckFinite([]float64{1, math.Inf(-1)}, 1, nil, func(row int) string { return fmt.Sprintf("row %d", row) })
+	if err == nil || !strings.Contains(err.Error(), "non-finite value -Inf at offset 1") {
+		t.Fatalf("expected float64 infinity to be reported, got: %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package ortutil
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/amikos-tech/pure-onnx/internal/onnxproto"
+	"github.com/amikos-tech/pure-onnx/ort"
+)
+
+// ONNX protobuf field numbers read by readInitializerShapes.
+const (
+	modelProtoGraphField       = 7
+	graphProtoInitializerField = 5
+	tensorProtoDimsField       = 1
+	tensorProtoNameField       = 8
+)
+
+// maxInitializerNameSize bounds an initializer name; real names are well below it.
+const maxInitializerNameSize = 1 << 16
+
+// maxPackedDimsSize bounds packed TensorProto dims (at most 10 bytes per dimension).
+const maxPackedDimsSize = 1 << 10
+
+// readInitializerShapes returns the shapes of the graph initializers (weights) whose
+// names satisfy match, keyed by name. Tensor data is skipped without being loaded into
+// memory, so this is cheap even for large models, and ONNX Runtime does not need to be
+// initialized.
+func readInitializerShapes(modelPath string, match func(name string) bool) (map[string]ort.Shape, error) {
+	if match == nil {
+		return nil, fmt.Errorf("initializer name matcher cannot be nil")
+	}
+	// #nosec G304 -- modelPath is the caller-provided model file.
+	file, err := os.Open(modelPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open model %q: %w", modelPath, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat model %q: %w", modelPath, err)
+	}
+
+	shapes, err := scanInitializerShapes(file, uint64(info.Size()), match)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read initializers of model %q: %w", modelPath, err)
+	}
+	return shapes, nil
+}
+
+// scanInitializerShapes scans ModelProto.graph in a stream of size bytes for
+// initializers. Only the dims and name of each TensorProto are decoded.
+func scanInitializerShapes(file io.ReadSeeker, size uint64, match func(name string) bool) (map[string]ort.Shape, error) {
+	s := onnxproto.NewScanner(file, size)
+	shapes := make(map[string]ort.Shape)
+	for {
+		key, err := s.ReadUvarint()
+		if errors.Is(err, io.EOF) {
+			return shapes, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("malformed model protobuf: %w", err)
+		}
+		field, wireType := key>>3, key&0x7
+		if field != modelProtoGraphField || wireType != 2 {
+			if err := s.SkipField(wireType); err != nil {
+				return nil, fmt.Errorf("malformed model protobuf: %w", err)
+			}
+			continue
+		}
+		length, err := s.ReadUvarint()
+		if err != nil {
+			return nil, fmt.Errorf("malformed model protobuf: %w", err)
+		}
+		end, err := s.End(length)
+		if err != nil {
+			return nil, fmt.Errorf("malformed model protobuf: %w", err)
+		}
+		if err := scanGraph(s, end, match, shapes); err != nil {
+			return nil, fmt.Errorf("malformed model protobuf: %w", err)
+		}
+	}
+}
+
+func scanGraph(s *onnxproto.Scanner, end uint64, match func(name string) bool, shapes map[string]ort.Shape) error {
+	for s.Offset() < end {
+		key, err := s.ReadUvarint()
+		if err != nil {
+			return err
+		}
+		field, wireType := key>>3, key&0x7
+		if field != graphProtoInitializerField || wireType != 2 {
+			if err := s.SkipField(wireType); err != nil {
+				return err
+			}
+			continue
+		}
+		length, err := s.ReadUvarint()
+		if err != nil {
+			return err
+		}
+		tensorEnd, err := s.End(length)
+		if err != nil {
+			return err
+		}
+		name, shape, err := scanTensorHeader(s, tensorEnd)
+		if err != nil {
+			return err
+		}
+		if match(name) {
+			shapes[name] = shape
+		}
+	}
+	if s.Offset() != end {
+		return fmt.Errorf("graph field overruns its length")
+	}
+	return nil
+}
+
+func scanTensorHeader(s *onnxproto.Scanner, end uint64) (string, ort.Shape, error) {
+	var (
+		name  string
+		shape ort.Shape
+	)
+	for s.Offset() < end {
+		key, err := s.ReadUvarint()
+		if err != nil {
+			return "", nil, err
+		}
+		field, wireType := key>>3, key&0x7
+		switch {
+		case field == tensorProtoDimsField && wireType == 0:
+			dim, err := s.ReadUvarint()
+			if err != nil {
+				return "", nil, err
+			}
+			shape = append(shape, int64(dim))
+		case field == tensorProtoDimsField && wireType == 2:
+			length, err := s.ReadUvarint()
+			if err != nil {
+				return "", nil, err
+			}
+			packed, err := s.ReadBytes(length, maxPackedDimsSize)
+			if err != nil {
+				return "", nil, err
+			}
+			for len(packed) > 0 {
+				dim, n := binary.Uvarint(packed)
+				if n <= 0 {
+					return "", nil, fmt.Errorf("malformed tensor dims")
+				}
+				shape = append(shape, int64(dim))
+				packed = packed[n:]
+			}
+		case field == tensorProtoNameField && wireType == 2:
+			length, err := s.ReadUvarint()
+			if err != nil {
+				return "", nil, err
+			}
+			data, err := s.ReadBytes(length, maxInitializerNameSize)
+			if err != nil {
+				return "", nil, err
+			}
+			name = string(data)
+		default:
+			if err := s.SkipField(wireType); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	if s.Offset() != end {
+		return "", nil, fmt.Errorf("initializer field overruns its length")
+	}
+	return name, shape, nil
+}
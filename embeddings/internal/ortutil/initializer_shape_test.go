@@ -0,0 +1,107 @@
+package ortutil
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func appendVarintField(buf []byte, field int, value uint64) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field<<3))
+	return binary.AppendUvarint(buf, value)
+}
+
+func appendBytesField(buf []byte, field int, value []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field<<3|2))
+	buf = binary.AppendUvarint(buf, uint64(len(value)))
+	return append(buf, value...)
+}
+
+// floatInitializer encodes a TensorProto with dims, name and raw float data.
+func floatInitializer(name string, dims []int64, values []float32) []byte {
+	var tensor []byte
+	for _, dim := range dims {
+		tensor = appendVarintField(tensor, 1, uint64(dim))
+	}
+	tensor = appendVarintField(tensor, 2, 1)
+	tensor = appendBytesField(tensor, 8, []byte(name))
+	raw := make([]byte, 0, 4*len(values))
+	for _, value := range values {
+		raw = binary.LittleEndian.AppendUint32(raw, math.Float32bits(value))
+	}
+	return appendBytesField(tensor, 9, raw)
+}
+
+func writeModel(t *testing.T, model []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "model.onnx")
+	if err := os.WriteFile(path, model, 0o600); err != nil {
+		t.Fatalf("failed to write test model: %v", err)
+	}
+	return path
+}
+
+func TestReadInitializerShapes(t *testing.T) {
+	var packedDims []byte
+	packedDims = binary.AppendUvarint(packedDims, 4)
+	packedDims = binary.AppendUvarint(packedDims, 2)
+	var packed []byte
+	packed = appendBytesField(packed, 1, packedDims)
+	packed = appendBytesField(packed, 8, []byte("encoder.layer.0.bias"))
+	packed = appendBytesField(packed, 9, make([]byte, 32))
+
+	var graph []byte
+	graph = appendBytesField(graph, 1, appendBytesField(nil, 4, []byte("MatMul")))
+	graph = appendBytesField(graph, 5, floatInitializer("embeddings.position_embeddings.weight", []int64{512, 3}, make([]float32, 512*3)))
+	graph = appendBytesField(graph, 5, floatInitializer("W", []int64{3, 2}, []float32{1, 0, 0, 1, 2, -1}))
+	graph = appendBytesField(graph, 5, packed)
+
+	var model []byte
+	model = appendVarintField(model, 1, 8)
+	model = appendBytesField(model, 7, graph)
+	model = appendBytesField(model, 8, appendVarintField(nil, 2, 13))
+	path := writeModel(t, model)
+
+	shapes, err := readInitializerShapes(path, func(name string) bool {
+		return name != "W"
+	})
+	if err != nil {
+		t.Fatalf("readInitializerShapes failed: %v", err)
+	}
+	if len(shapes) != 2 {
+		t.Fatalf("expected two matching initializers, got %v", shapes)
+	}
+	if got := shapes["embeddings.position_embeddings.weight"]; len(got) != 2 || got[0] != 512 || got[1] != 3 {
+		t.Fatalf("unexpected position embedding shape: %v", got)
+	}
+	if got := shapes["encoder.layer.0.bias"]; len(got) != 2 || got[0] != 4 || got[1] != 2 {
+		t.Fatalf("unexpected packed-dims shape: %v", got)
+	}
+
+	if _, err := readInitializerShapes(writeModel(t, model[:len(model)-40]), func(string) bool { return true }); err == nil {
+		t.Fatalf("expected truncated model error")
+	}
+	if _, err := readInitializerShapes(path, nil); err == nil || !strings.Contains(err.Error(), "matcher cannot be nil") {
+		t.Fatalf("expected nil matcher error, got: %v", err)
+	}
+	if _, err := readInitializerShapes(filepath.Join(t.TempDir(), "missing.onnx"), func(string) bool { return true }); err == nil {
+		t.Fatalf("expected missing model error")
+	}
+}
+
+func TestReadInitializerShapesRejectsSkipPastEnd(t *testing.T) {
+	// A top-level field whose declared length runs past the end of the file must fail
+	// instead of seeking past it and reading a clean EOF.
+	model := appendBytesField(nil, 7, appendBytesField(nil, 5, floatInitializer("embeddings.position_embeddings.weight", []int64{512, 8}, nil)))
+	truncated := binary.AppendUvarint(appendVarintField(nil, 1, 8), uint64(3<<3|2))
+	truncated = binary.AppendUvarint(truncated, 1<<20)
+	truncated = append(truncated, model...)
+
+	_, err := readInitializerShapes(writeModel(t, truncated), func(string) bool { return true })
+	if err == nil || !strings.Contains(err.Error(), "runs past the end") {
+		t.Fatalf("expected an out-of-bounds skip error, got: %v", err)
+	}
+}
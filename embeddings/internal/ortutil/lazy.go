@@ -0,0 +1,106 @@
+package ortutil
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/amikos-tech/pure-onnx/ort"
+)
+
+// Lazy constructs an embedder on first use, so request handlers can share one instance
+// without coordinating startup. It backs the LazyEmbedder type of each embedder package.
+//
+// The first Get takes an ONNX Runtime environment reference (see AcquireEnvironment) and
+// then constructs the embedder. Concurrent callers wait for that single initialization.
+// A failure is cached and returned by every later call.
+type Lazy[T interface{ Close() error }] struct {
+	construct func() (T, func() error, error)
+
+	once        sync.Once
+	value       T
+	constructed bool
+	release     func() error
+	err         error
+
+	closeMu sync.Mutex
+	closed  bool
+}
+
+// NewLazy returns a Lazy that acquires the ONNX Runtime environment and calls newValue
+// on first use. The environment reference is dropped again if newValue fails, and by
+// Close otherwise.
+func NewLazy[T interface{ Close() error }](newValue func() (T, error)) *Lazy[T] {
+	return &Lazy[T]{
+		construct: func() (T, func() error, error) {
+			if err := AcquireEnvironment(); err != nil {
+				var zero T
+				return zero, nil, err
+			}
+			value, err := newValue()
+			if err != nil {
+				return value, nil, errors.Join(err, ort.DestroyEnvironment())
+			}
+			return value, ort.DestroyEnvironment, nil
+		},
+	}
+}
+
+// Get returns the embedder, constructing it on the first call.
+func (l *Lazy[T]) Get() (T, error) {
+	var zero T
+	if l == nil {
+		return zero, fmt.Errorf("lazy embedder is nil")
+	}
+	l.once.Do(func() {
+		value, release, err := l.construct()
+		if err != nil {
+			l.err = fmt.Errorf("lazy embedder initialization failed: %w", err)
+			return
+		}
+		l.value = value
+		l.constructed = true
+		l.release = release
+	})
+	if l.err != nil {
+		return zero, l.err
+	}
+
+	l.closeMu.Lock()
+	closed := l.closed
+	l.closeMu.Unlock()
+	if closed {
+		return zero, fmt.Errorf("embedder has been closed")
+	}
+	return l.value, nil
+}
+
+// Close closes the embedder and releases the ONNX Runtime environment reference taken on
+// first use. Closing before first use prevents any later initialization.
+// It is safe to call more than once.
+func (l *Lazy[T]) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.once.Do(func() {
+		l.err = fmt.Errorf("embedder has been closed")
+	})
+
+	l.closeMu.Lock()
+	defer l.closeMu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+
+	var err error
+	if l.constructed {
+		err = l.value.Close()
+	}
+	if l.release != nil {
+		if releaseErr := l.release(); releaseErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to destroy ONNX Runtime environment: %w", releaseErr))
+		}
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package ortutil
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeLazyValue struct {
+	closes atomic.Int32
+}
+
+func (v *fakeLazyValue) Close() error {
+	v.closes.Add(1)
+	return nil
+}
+
+func TestLazyConstructsOnce(t *testing.T) {
+	var constructions, releases atomic.Int32
+	underlying := &fakeLazyValue{}
+	lazy := &Lazy[*fakeLazyValue]{
+		construct: func() (*fakeLazyValue, func() error, error) {
+			constructions.Add(1)
+			// Widen the window in which concurrent callers race the initialization.
+			time.Sleep(10 * time.Millisecond)
+			return underlying, func() error {
+				releases.Add(1)
+				return nil
+			}, nil
+		},
+	}
+
+	const callers = 32
+	var wg sync.WaitGroup
+	got := make([]*fakeLazyValue, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i], errs[i] = lazy.Get()
+		}(i)
+	}
+	wg.Wait()
+
+	if n := constructions.Load(); n != 1 {
+		t.Fatalf("expected a single construction, got %d", n)
+	}
+	for i := range got {
+		if errs[i] != nil {
+			t.Fatalf("caller %d: unexpected error: %v", i, errs[i])
+		}
+		if got[i] != underlying {
+			t.Fatalf("caller %d: got a different instance", i)
+		}
+	}
+
+	if err := lazy.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := lazy.Close(); err != nil {
+		t.Fatalf("second Close failed: %v", err)
+	}
+	if n := underlying.closes.Load(); n != 1 {
+		t.Fatalf("expected the value to be closed once, got %d", n)
+	}
+	if n := releases.Load(); n != 1 {
+		t.Fatalf("expected the environment reference to be released once, got %d", n)
+	}
+	if _, err := lazy.Get(); err == nil || !strings.Contains(err.Error(), "embedder has been closed") {
+		t.Fatalf("expected closed error after Close, got: %v", err)
+	}
+}
+
+func TestLazyCachesInitializationError(t *testing.T) {
+	var constructions atomic.Int32
+	lazy := &Lazy[*fakeLazyValue]{
+		construct: func() (*fakeLazyValue, func() error, error) {
+			constructions.Add(1)
+			return nil, nil, errors.New("tokenizer missing")
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := lazy.Get(); err == nil || !strings.Contains(err.Error(), "lazy embedder initialization failed: tokenizer missing") {
+			t.Fatalf("call %d: expected cached initialization error, got: %v", i, err)
+		}
+	}
+	if n := constructions.Load(); n != 1 {
+		t.Fatalf("expected a single construction attempt, got %d", n)
+	}
+	if err := lazy.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+}
+
+func TestLazyCloseBeforeFirstUse(t *testing.T) {
+	lazy := &Lazy[*fakeLazyValue]{
+		construct: func() (*fakeLazyValue, func() error, error) {
+			t.Fatalf("construct must not run after Close")
+			return nil, nil, nil
+		},
+	}
+	if err := lazy.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := lazy.Get(); err == nil || !strings.Contains(err.Error(), "embedder has been closed") {
+		t.Fatalf("expected closed error, got: %v", err)
+	}
+
+	var nilLazy *Lazy[*fakeLazyValue]
+	if _, err := nilLazy.Get(); err == nil || !strings.Contains(err.Error(), "lazy embedder is nil") {
+		t.Fatalf("expected nil lazy embedder error, got: %v", err)
+	}
+}
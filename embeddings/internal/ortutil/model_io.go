@@ -0,0 +1,59 @@
+package ortutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amikos-tech/pure-onnx/ort"
+)
+
+// inspectModel reads a model's declared inputs and outputs; tests replace it.
+var inspectModel = ort.GetInputOutputInfo
+
+// Probe backs the Probe method of each embedder: it checks that the model at modelPath
+// declares inputNames and outputNames, then runs embedQuery over a short dummy document
+// so shape and type mismatches surface with the failing step named. ONNX Runtime must
+// already be initialized.
+func Probe(modelPath string, inputNames []string, outputNames []string, embedQuery func(document string) error) error {
+	inputs, outputs, err := inspectModel(modelPath)
+	if err != nil {
+		return fmt.Errorf("probe: failed to inspect model: %w", err)
+	}
+	if err := CheckModelNames(inputs, outputs, inputNames, outputNames); err != nil {
+		return fmt.Errorf("probe: %w; configure the names with WithInputOutputNames", err)
+	}
+	if err := embedQuery("probe"); err != nil {
+		return fmt.Errorf("probe: dummy inference failed: %w", err)
+	}
+	return nil
+}
+
+// CheckModelNames reports the first configured input or output name the model does not
+// declare, listing the names it does declare so the configuration can be corrected.
+func CheckModelNames(inputs []ort.InputOutputInfo, outputs []ort.InputOutputInfo, inputNames []string, outputNames []string) error {
+	if err := checkDeclared("input", inputs, inputNames); err != nil {
+		return err
+	}
+	return checkDeclared("output", outputs, outputNames)
+}
+
+func checkDeclared(kind string, declared []ort.InputOutputInfo, names []string) error {
+	for _, name := range names {
+		found := false
+		for _, info := range declared {
+			if info.Name == name {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		available := make([]string, len(declared))
+		for i, info := range declared {
+			available[i] = info.Name
+		}
+		return fmt.Errorf("model does not declare %s %q (model %ss: %s)", kind, name, kind, strings.Join(available, ", "))
+	}
+	return nil
+}
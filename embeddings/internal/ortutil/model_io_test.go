@@ -0,0 +1,66 @@
+package ortutil
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/amikos-tech/pure-onnx/ort"
+)
+
+func TestCheckModelNames(t *testing.T) {
+	inputs := []ort.InputOutputInfo{{Name: "input_ids"}, {Name: "attention_mask"}}
+	outputs := []ort.InputOutputInfo{{Name: "last_hidden_state"}}
+
+	if err := CheckModelNames(inputs, outputs, []string{"input_ids", "attention_mask"}, []string{"last_hidden_state"}); err != nil {
+		t.Fatalf("expected matching names to pass, got: %v", err)
+	}
+
+	err := CheckModelNames(inputs, outputs, []string{"input_ids"}, []string{"logits"})
+	if err == nil || !strings.Contains(err.Error(), `model does not declare output "logits" (model outputs: last_hidden_state)`) {
+		t.Fatalf("expected missing output error, got: %v", err)
+	}
+
+	err = CheckModelNames(inputs, outputs, []string{"input_ids", "token_type_ids"}, nil)
+	if err == nil || !strings.Contains(err.Error(), `model does not declare input "token_type_ids" (model inputs: input_ids, attention_mask)`) {
+		t.Fatalf("expected missing input error, got: %v", err)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	original := inspectModel
+	t.Cleanup(func() { inspectModel = original })
+	inspectModel = func(modelPath string) ([]ort.InputOutputInfo, []ort.InputOutputInfo, error) {
+		if modelPath != "model.onnx" {
+			return nil, nil, errors.New("no such model")
+		}
+		return []ort.InputOutputInfo{{Name: "input_ids"}}, []ort.InputOutputInfo{{Name: "logits"}}, nil
+	}
+
+	var queried []string
+	embedQuery := func(document string) error {
+		queried = append(queried, document)
+		return nil
+	}
+	if err := Probe("model.onnx", []string{"input_ids"}, []string{"logits"}, embedQuery); err != nil {
+		t.Fatalf("Probe failed: %v", err)
+	}
+	if len(queried) != 1 {
+		t.Fatalf("expected one dummy inference, got %d", len(queried))
+	}
+
+	if err := Probe("missing.onnx", nil, nil, embedQuery); err == nil || !strings.Contains(err.Error(), "probe: failed to inspect model: no such model") {
+		t.Fatalf("expected inspection error, got: %v", err)
+	}
+	err := Probe("model.onnx", []string{"input_ids"}, []string{"output"}, embedQuery)
+	if err == nil || !strings.Contains(err.Error(), `probe: model does not declare output "output"`) || !strings.Contains(err.Error(), "WithInputOutputNames") {
+		t.Fatalf("expected missing output error, got: %v", err)
+	}
+	if len(queried) != 1 {
+		t.Fatalf("expected no inference after a failed name check, got %d", len(queried))
+	}
+	err = Probe("model.onnx", []string{"input_ids"}, []string{"logits"}, func(string) error { return errors.New("shape mismatch") })
+	if err == nil || !strings.Contains(err.Error(), "probe: dummy inference failed: shape mismatch") {
+		t.Fatalf("expected inference error, got: %v", err)
+	}
+}
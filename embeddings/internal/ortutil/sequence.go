@@ -0,0 +1,43 @@
+package ortutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// positionEmbeddingSuffix matches the position embedding table of BERT-style exports,
+// for example "embeddings.position_embeddings.weight".
+const positionEmbeddingSuffix = "position_embeddings.weight"
+
+// MaxSequenceLength returns the number of rows in the model's position embedding
+// table, which bounds the sequence length the model was trained for. It reports false
+// when the model has no recognizable table or cannot be read.
+func MaxSequenceLength(modelPath string) (int, bool) {
+	shapes, err := readInitializerShapes(modelPath, func(name string) bool {
+		return strings.HasSuffix(name, positionEmbeddingSuffix)
+	})
+	if err != nil || len(shapes) != 1 {
+		return 0, false
+	}
+	for _, shape := range shapes {
+		if len(shape) == 2 && shape[0] > 0 {
+			return int(shape[0]), true
+		}
+	}
+	return 0, false
+}
+
+// ResolveSequenceLength checks a configured sequence length against MaxSequenceLength.
+// A longer length is an error, or with clamp is reduced to the model maximum and
+// described in the returned warning. Models without a recognizable position embedding
+// table are not checked.
+func ResolveSequenceLength(modelPath string, sequenceLength int, clamp bool) (int, string, error) {
+	maxLength, ok := MaxSequenceLength(modelPath)
+	if !ok || sequenceLength <= maxLength {
+		return sequenceLength, "", nil
+	}
+	if clamp {
+		return maxLength, fmt.Sprintf("sequence length %d exceeds the model's %d position embeddings; clamping to %d", sequenceLength, maxLength, maxLength), nil
+	}
+	return 0, "", fmt.Errorf("sequence length %d exceeds the model's %d position embeddings; configure a sequence length <= %d", sequenceLength, maxLength, maxLength)
+}
@@ -0,0 +1,63 @@
+package ortutil
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePositionEmbeddingModel writes a ModelProto whose graph only declares a
+// [rows, 8] initializer with the given name.
+func writePositionEmbeddingModel(t *testing.T, name string, rows int) string {
+	t.Helper()
+
+	appendBytes := func(buf []byte, field int, value []byte) []byte {
+		buf = binary.AppendUvarint(buf, uint64(field<<3|2))
+		buf = binary.AppendUvarint(buf, uint64(len(value)))
+		return append(buf, value...)
+	}
+	var tensor []byte
+	for _, dim := range []int{rows, 8} {
+		tensor = binary.AppendUvarint(tensor, 1<<3)
+		tensor = binary.AppendUvarint(tensor, uint64(dim))
+	}
+	tensor = appendBytes(tensor, 8, []byte(name))
+	model := appendBytes(nil, 7, appendBytes(nil, 5, tensor))
+
+	path := filepath.Join(t.TempDir(), "model.onnx")
+	if err := os.WriteFile(path, model, 0o600); err != nil {
+		t.Fatalf("failed to write model: %v", err)
+	}
+	return path
+}
+
+func TestResolveSequenceLength(t *testing.T) {
+	bert := writePositionEmbeddingModel(t, "embeddings.position_embeddings.weight", 512)
+	if got, ok := MaxSequenceLength(bert); !ok || got != 512 {
+		t.Fatalf("unexpected max sequence length: got %d, %v", got, ok)
+	}
+
+	if got, warning, err := ResolveSequenceLength(bert, 512, false); err != nil || warning != "" || got != 512 {
+		t.Fatalf("expected 512 to be accepted, got %d, %q, %v", got, warning, err)
+	}
+	if _, _, err := ResolveSequenceLength(bert, 1024, false); err == nil || !strings.Contains(err.Error(), "exceeds the model's 512 position embeddings") {
+		t.Fatalf("expected oversized sequence length error, got: %v", err)
+	}
+	got, warning, err := ResolveSequenceLength(bert, 1024, true)
+	if err != nil || got != 512 || !strings.Contains(warning, "clamping to 512") {
+		t.Fatalf("expected clamp to 512, got %d, %q, %v", got, warning, err)
+	}
+
+	unrelated := writePositionEmbeddingModel(t, "classifier.weight", 2)
+	if _, ok := MaxSequenceLength(unrelated); ok {
+		t.Fatalf("expected no max sequence length for a model without position embeddings")
+	}
+	if got, _, err := ResolveSequenceLength(unrelated, 4096, false); err != nil || got != 4096 {
+		t.Fatalf("expected unchecked sequence length, got %d, %v", got, err)
+	}
+	if got, _, err := ResolveSequenceLength(filepath.Join(t.TempDir(), "missing.onnx"), 4096, false); err != nil || got != 4096 {
+		t.Fatalf("expected unreadable model to be unchecked, got %d, %v", got, err)
+	}
+}
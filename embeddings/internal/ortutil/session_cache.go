@@ -0,0 +1,114 @@
+package ortutil
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// SessionCache holds one session per key (for example a batch size), evicting the least
+// recently used session once maxCount sessions are held; maxCount <= 0 means no limit.
+// It is not safe for concurrent use; embedders call it under their run lock.
+type SessionCache[K comparable, S interface{ Destroy() error }] struct {
+	sessions map[K]S
+	lru      *list.List
+	lruIndex map[K]*list.Element
+	maxCount int
+	describe func(K) string
+}
+
+// NewSessionCache returns an empty cache. describe names a key's resources in eviction
+// and destruction errors, for example "batch-4 clip resources".
+func NewSessionCache[K comparable, S interface{ Destroy() error }](maxCount int, describe func(K) string) *SessionCache[K, S] {
+	return &SessionCache[K, S]{
+		sessions: make(map[K]S),
+		lru:      list.New(),
+		lruIndex: make(map[K]*list.Element),
+		maxCount: maxCount,
+		describe: describe,
+	}
+}
+
+// Get returns the session cached for key, marking it most recently used. On a miss it
+// evicts the least recently used session if the cache is full and caches the session
+// create returns.
+func (c *SessionCache[K, S]) Get(key K, create func(K) (S, error)) (S, error) {
+	if session, ok := c.sessions[key]; ok {
+		c.lru.MoveToBack(c.lruIndex[key])
+		return session, nil
+	}
+	if c.maxCount > 0 && len(c.sessions) >= c.maxCount {
+		if err := c.evictOldest(); err != nil {
+			var zero S
+			return zero, err
+		}
+	}
+	session, err := create(key)
+	if err != nil {
+		var zero S
+		return zero, err
+	}
+	c.sessions[key] = session
+	c.lruIndex[key] = c.lru.PushBack(key)
+	return session, nil
+}
+
+// Lookup returns the session cached for key without changing its recency.
+func (c *SessionCache[K, S]) Lookup(key K) (S, bool) {
+	session, ok := c.sessions[key]
+	return session, ok
+}
+
+// Len returns the number of cached sessions.
+func (c *SessionCache[K, S]) Len() int {
+	return len(c.sessions)
+}
+
+// All iterates over the cached sessions in no particular order.
+func (c *SessionCache[K, S]) All() iter.Seq2[K, S] {
+	return func(yield func(K, S) bool) {
+		for key, session := range c.sessions {
+			if !yield(key, session) {
+				return
+			}
+		}
+	}
+}
+
+func (c *SessionCache[K, S]) evictOldest() error {
+	oldest := c.lru.Front()
+	if oldest == nil {
+		return nil
+	}
+	key, ok := oldest.Value.(K)
+	if !ok {
+		return fmt.Errorf("invalid cache bookkeeping value: %T", oldest.Value)
+	}
+	session := c.sessions[key]
+	delete(c.sessions, key)
+	delete(c.lruIndex, key)
+	c.lru.Remove(oldest)
+	if err := session.Destroy(); err != nil {
+		return fmt.Errorf("failed to evict %s: %w", c.describe(key), err)
+	}
+	return nil
+}
+
+// DestroyAll destroys every cached session and empties the cache. It is safe to call
+// on a nil cache.
+func (c *SessionCache[K, S]) DestroyAll() error {
+	if c == nil {
+		return nil
+	}
+	var err error
+	for key, session := range c.sessions {
+		if destroyErr := session.Destroy(); destroyErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to destroy %s: %w", c.describe(key), destroyErr))
+		}
+	}
+	clear(c.sessions)
+	clear(c.lruIndex)
+	c.lru.Init()
+	return err
+}
@@ -0,0 +1,81 @@
+package ortutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type fakeCachedSession struct {
+	destroyed  int
+	destroyErr error
+}
+
+func (s *fakeCachedSession) Destroy() error {
+	s.destroyed++
+	return s.destroyErr
+}
+
+func TestSessionCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewSessionCache[int, *fakeCachedSession](2, func(batchSize int) string {
+		return fmt.Sprintf("batch-%d test resources", batchSize)
+	})
+	created := make(map[int]*fakeCachedSession)
+	create := func(batchSize int) (*fakeCachedSession, error) {
+		session := &fakeCachedSession{}
+		created[batchSize] = session
+		return session, nil
+	}
+
+	for _, batchSize := range []int{1, 2, 1, 3} {
+		if _, err := cache.Get(batchSize, create); err != nil {
+			t.Fatalf("Get(%d) failed: %v", batchSize, err)
+		}
+	}
+	if len(created) != 3 || cache.Len() != 2 {
+		t.Fatalf("expected 3 sessions created and 2 cached, got %d and %d", len(created), cache.Len())
+	}
+	if _, ok := cache.Lookup(2); ok || created[2].destroyed != 1 {
+		t.Fatalf("expected least recently used batch size 2 to be evicted and destroyed")
+	}
+	if session, ok := cache.Lookup(1); !ok || session != created[1] {
+		t.Fatalf("expected recently used batch size 1 to stay cached")
+	}
+
+	// Lookup does not refresh recency: 1 is still older than 3 after a Get of 3.
+	if _, err := cache.Get(3, create); err != nil {
+		t.Fatalf("Get(3) failed: %v", err)
+	}
+	cache.Lookup(1)
+	created[3].destroyErr = errors.New("boom")
+	if _, err := cache.Get(4, create); err != nil {
+		t.Fatalf("Get(4) failed: %v", err)
+	}
+	if _, ok := cache.Lookup(1); ok {
+		t.Fatalf("expected batch size 1 to be evicted")
+	}
+	if _, err := cache.Get(5, create); err == nil || !strings.Contains(err.Error(), "failed to evict batch-3 test resources: boom") {
+		t.Fatalf("expected eviction error, got: %v", err)
+	}
+
+	keys := 0
+	for range cache.All() {
+		keys++
+	}
+	if keys != cache.Len() {
+		t.Fatalf("All yielded %d sessions, want %d", keys, cache.Len())
+	}
+	created[4].destroyErr = errors.New("stuck")
+	if err := cache.DestroyAll(); err == nil || !strings.Contains(err.Error(), "failed to destroy batch-4 test resources: stuck") {
+		t.Fatalf("expected destroy error, got: %v", err)
+	}
+	if cache.Len() != 0 {
+		t.Fatalf("expected DestroyAll to empty the cache, got %d sessions", cache.Len())
+	}
+
+	var nilCache *SessionCache[int, *fakeCachedSession]
+	if err := nilCache.DestroyAll(); err != nil {
+		t.Fatalf("DestroyAll on nil cache failed: %v", err)
+	}
+}
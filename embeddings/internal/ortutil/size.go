@@ -0,0 +1,19 @@
+package ortutil
+
+import (
+	"fmt"
+	"math"
+)
+
+// CheckedMul returns a*b for non-negative sizes, or an error when either factor is
+// negative or the product overflows int. Use it for buffer sizes derived from batch,
+// sequence, and vocabulary dimensions before allocating.
+func CheckedMul(a, b int) (int, error) {
+	if a < 0 || b < 0 {
+		return 0, fmt.Errorf("size factors must be >= 0, got %d and %d", a, b)
+	}
+	if a != 0 && b > math.MaxInt/a {
+		return 0, fmt.Errorf("size %d x %d overflows int", a, b)
+	}
+	return a * b, nil
+}
@@ -0,0 +1,37 @@
+package ortutil
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCheckedMul(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    int
+		want    int
+		wantErr string
+	}{
+		{name: "small", a: 32, b: 512, want: 16384},
+		{name: "zero", a: 0, b: math.MaxInt, want: 0},
+		{name: "max", a: 1, b: math.MaxInt, want: math.MaxInt},
+		{name: "overflow", a: math.MaxInt/2 + 1, b: 2, wantErr: "overflows int"},
+		{name: "large overflow", a: 1 << 40, b: 1 << 40, wantErr: "overflows int"},
+		{name: "negative", a: -1, b: 8, wantErr: "must be >= 0"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := CheckedMul(tc.a, tc.b)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %d, %v", tc.wantErr, got, err)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Fatalf("CheckedMul(%d, %d) = %d, %v; want %d", tc.a, tc.b, got, err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// Package tokenizerutil holds tokenizer helpers shared by the embedder packages.
+package tokenizerutil
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// PadTokenID returns the pad token id declared in the "padding" section of a Hugging
+// Face tokenizer.json, or 0 (the BERT [PAD] id) when the file declares none or cannot
+// be read. Embedders use it to derive an attention mask when the tokenizer returns none.
+func PadTokenID(tokenizerPath string) int64 {
+	// #nosec G304 -- tokenizerPath is the caller-provided tokenizer file.
+	data, err := os.ReadFile(tokenizerPath)
+	if err != nil {
+		return 0
+	}
+	var config struct {
+		Padding *struct {
+			PadID *int64 `json:"pad_id"`
+		} `json:"padding"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil || config.Padding == nil || config.Padding.PadID == nil {
+		return 0
+	}
+	return *config.Padding.PadID
+}
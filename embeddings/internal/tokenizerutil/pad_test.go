@@ -0,0 +1,36 @@
+package tokenizerutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPadTokenID(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    int64
+	}{
+		{name: "declared", content: `{"padding": {"strategy": "BatchLongest", "pad_id": 1, "pad_token": "<pad>"}}`, want: 1},
+		{name: "null padding", content: `{"padding": null}`, want: 0},
+		{name: "no pad id", content: `{"padding": {"pad_token": "[PAD]"}}`, want: 0},
+		{name: "malformed", content: `{"padding": `, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("failed to write tokenizer: %v", err)
+			}
+			if got := PadTokenID(path); got != tt.want {
+				t.Fatalf("unexpected pad id: got %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if got := PadTokenID(filepath.Join(dir, "missing.json")); got != 0 {
+		t.Fatalf("expected 0 for a missing tokenizer, got %d", got)
+	}
+}
@@ -0,0 +1,33 @@
+package tokenizerutil
+
+import "slices"
+
+// PaddingSide selects which end of a fixed-length row receives padding tokens. The
+// tokenizer from pure-tokenizers has no padding-direction option and always pads on
+// the right, so left padding is applied to the encoded rows with MovePaddingLeft.
+type PaddingSide string
+
+const (
+	PaddingSideRight PaddingSide = "right"
+	PaddingSideLeft  PaddingSide = "left"
+)
+
+// MovePaddingLeft turns a right-padded row into a left-padded one by rotating the
+// trailing padding (attention mask 0) to the front. typeIDs may be nil.
+func MovePaddingLeft(inputIDs []int64, attentionMask []int64, typeIDs []int64) {
+	used := len(attentionMask)
+	for used > 0 && attentionMask[used-1] == 0 {
+		used--
+	}
+	if used == len(attentionMask) {
+		return
+	}
+	for _, row := range [][]int64{inputIDs, attentionMask, typeIDs} {
+		if row == nil {
+			continue
+		}
+		slices.Reverse(row)
+		slices.Reverse(row[:len(row)-used])
+		slices.Reverse(row[len(row)-used:])
+	}
+}
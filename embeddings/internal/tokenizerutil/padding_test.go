@@ -0,0 +1,35 @@
+package tokenizerutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMovePaddingLeft(t *testing.T) {
+	ids := []int64{101, 7, 102, 0, 0}
+	mask := []int64{1, 1, 1, 0, 0}
+	typeIDs := []int64{0, 1, 1, 0, 0}
+	MovePaddingLeft(ids, mask, typeIDs)
+	if want := []int64{0, 0, 101, 7, 102}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("unexpected input ids: got %v, want %v", ids, want)
+	}
+	if want := []int64{0, 0, 1, 1, 1}; !reflect.DeepEqual(mask, want) {
+		t.Fatalf("unexpected attention mask: got %v, want %v", mask, want)
+	}
+	if want := []int64{0, 0, 0, 1, 1}; !reflect.DeepEqual(typeIDs, want) {
+		t.Fatalf("unexpected token type ids: got %v, want %v", typeIDs, want)
+	}
+
+	full := []int64{101, 7, 102}
+	MovePaddingLeft(full, []int64{1, 1, 1}, nil)
+	if want := []int64{101, 7, 102}; !reflect.DeepEqual(full, want) {
+		t.Fatalf("expected an unpadded row to be unchanged, got %v", full)
+	}
+
+	empty := []int64{0, 0}
+	emptyMask := []int64{0, 0}
+	MovePaddingLeft(empty, emptyMask, nil)
+	if want := []int64{0, 0}; !reflect.DeepEqual(emptyMask, want) {
+		t.Fatalf("expected an all-padding row to be unchanged, got %v", emptyMask)
+	}
+}
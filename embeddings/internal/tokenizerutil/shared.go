@@ -0,0 +1,91 @@
+package tokenizerutil
+
+import (
+	"fmt"
+	"sync"
+
+	tokenizers "github.com/amikos-tech/pure-tokenizers"
+)
+
+// Shared is a reference-counted tokenizer that backs the SharedTokenizer type of each
+// embedder package. Each embedder holds a reference until its Close, and the creator
+// holds one until Shared.Close, so the tokenizer is closed only after all of them are done.
+type Shared struct {
+	path           string
+	padID          int64
+	tokenizer      *tokenizers.Tokenizer
+	closeTokenizer func() error
+
+	mu     sync.Mutex
+	refs   int
+	closed bool
+}
+
+// NewShared takes ownership of tokenizer, which was loaded from tokenizerPath, and holds
+// the creator's reference to it.
+func NewShared(tokenizerPath string, tokenizer *tokenizers.Tokenizer) *Shared {
+	return &Shared{
+		path:           tokenizerPath,
+		padID:          PadTokenID(tokenizerPath),
+		tokenizer:      tokenizer,
+		closeTokenizer: tokenizer.Close,
+		refs:           1,
+	}
+}
+
+// Path returns the tokenizer.json the tokenizer was loaded from.
+func (s *Shared) Path() string {
+	return s.path
+}
+
+// PadID returns the pad token id of the shared tokenizer (see PadTokenID).
+func (s *Shared) PadID() int64 {
+	return s.padID
+}
+
+// Close releases the creator's reference. It is safe to call more than once.
+func (s *Shared) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+	return s.Release()
+}
+
+// Acquire takes a reference for an embedder.
+func (s *Shared) Acquire() (*tokenizers.Tokenizer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.refs == 0 {
+		return nil, fmt.Errorf("shared tokenizer has been closed")
+	}
+	s.refs++
+	return s.tokenizer, nil
+}
+
+// Release drops a reference and closes the tokenizer when none remain.
+func (s *Shared) Release() error {
+	s.mu.Lock()
+	if s.refs == 0 {
+		s.mu.Unlock()
+		return fmt.Errorf("shared tokenizer released more times than acquired")
+	}
+	s.refs--
+	if s.refs > 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	closeTokenizer := s.closeTokenizer
+	s.tokenizer = nil
+	s.mu.Unlock()
+	if err := closeTokenizer(); err != nil {
+		return fmt.Errorf("failed to close shared tokenizer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package tokenizerutil
+
+import (
+	"strings"
+	"testing"
+
+	tokenizers "github.com/amikos-tech/pure-tokenizers"
+)
+
+func TestSharedReferenceCounting(t *testing.T) {
+	closes := 0
+	shared := &Shared{
+		tokenizer:      &tokenizers.Tokenizer{},
+		closeTokenizer: func() error { closes++; return nil },
+		refs:           1,
+	}
+
+	for i := 0; i < 2; i++ {
+		if tokenizer, err := shared.Acquire(); err != nil || tokenizer == nil {
+			t.Fatalf("Acquire %d failed: %v", i, err)
+		}
+	}
+	if err := shared.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := shared.Close(); err != nil {
+		t.Fatalf("second Close failed: %v", err)
+	}
+	if err := shared.Release(); err != nil {
+		t.Fatalf("first embedder release failed: %v", err)
+	}
+	if closes != 0 {
+		t.Fatalf("expected the tokenizer to stay open while an embedder holds it, got %d closes", closes)
+	}
+	if err := shared.Release(); err != nil {
+		t.Fatalf("last embedder release failed: %v", err)
+	}
+	if closes != 1 {
+		t.Fatalf("expected the last release to close the tokenizer once, got %d closes", closes)
+	}
+
+	if _, err := shared.Acquire(); err == nil || !strings.Contains(err.Error(), "shared tokenizer has been closed") {
+		t.Fatalf("expected Acquire after the final release to fail, got: %v", err)
+	}
+	if err := shared.Release(); err == nil || !strings.Contains(err.Error(), "released more times than acquired") {
+		t.Fatalf("expected an extra release to fail, got: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package embeddings
+
+import (
+	"fmt"
+	"math"
+)
+
+// MaxSim scores a document against a query with the ColBERT late-interaction relevance
+// function: for each query token it takes the maximum cosine similarity over the
+// document tokens and sums those maxima. Token vectors are typically produced by
+// minilm.Embedder.EmbedTokenEmbeddings. A zero-length token vector has cosine 0 with
+// every other token.
+//
+// Both inputs must be non-empty and every token vector must have the same non-zero width.
+func MaxSim(query [][]float32, doc [][]float32) (float32, error) {
+	if len(query) == 0 {
+		return 0, fmt.Errorf("query has no tokens")
+	}
+	if len(doc) == 0 {
+		return 0, fmt.Errorf("document has no tokens")
+	}
+	dim := len(query[0])
+	if dim == 0 {
+		return 0, fmt.Errorf("token vectors must be non-empty")
+	}
+	queryNorms, err := tokenNorms(query, dim, "query")
+	if err != nil {
+		return 0, err
+	}
+	docNorms, err := tokenNorms(doc, dim, "document")
+	if err != nil {
+		return 0, err
+	}
+
+	score := 0.0
+	for i, queryToken := range query {
+		best := math.Inf(-1)
+		for j, docToken := range doc {
+			best = math.Max(best, cosine(queryToken, queryNorms[i], docToken, docNorms[j]))
+		}
+		score += best
+	}
+	return float32(score), nil
+}
+
+// tokenNorms checks that every token has width dim and returns the L2 norm of each.
+func tokenNorms(tokens [][]float32, dim int, name string) ([]float64, error) {
+	norms := make([]float64, len(tokens))
+	for i, token := range tokens {
+		if len(token) != dim {
+			return nil, fmt.Errorf("%s token %d has dimension %d, want %d", name, i, len(token), dim)
+		}
+		sum := 0.0
+		for _, value := range token {
+			sum += float64(value) * float64(value)
+		}
+		norms[i] = math.Sqrt(sum)
+	}
+	return norms, nil
+}
+
+func cosine(a []float32, aNorm float64, b []float32, bNorm float64) float64 {
+	if aNorm == 0 || bNorm == 0 {
+		return 0
+	}
+	dot := 0.0
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot / (aNorm * bNorm)
+}
@@ -0,0 +1,83 @@
+package embeddings_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/amikos-tech/pure-onnx/embeddings"
+)
+
+func TestMaxSim(t *testing.T) {
+	tests := []struct {
+		name  string
+		query [][]float32
+		doc   [][]float32
+		want  float32
+	}{
+		{
+			name:  "exact token matches",
+			query: [][]float32{{1, 0}, {0, 1}},
+			doc:   [][]float32{{0, 2}, {3, 0}},
+			want:  2,
+		},
+		{
+			name:  "best match per query token",
+			query: [][]float32{{1, 0}, {1, 1}},
+			doc:   [][]float32{{1, 0}, {-1, 0}},
+			want:  1 + float32(1/math.Sqrt2),
+		},
+		{
+			name:  "single document token shared by all query tokens",
+			query: [][]float32{{1, 0}, {0, 1}, {-1, 0}},
+			doc:   [][]float32{{1, 0}},
+			want:  0,
+		},
+		{
+			name:  "zero vectors contribute zero",
+			query: [][]float32{{0, 0}, {0, 1}},
+			doc:   [][]float32{{0, 0}, {0, 4}},
+			want:  1,
+		},
+		{
+			name:  "negative similarities",
+			query: [][]float32{{1, 0}},
+			doc:   [][]float32{{-1, 0}, {-1, -1}},
+			want:  -float32(1 / math.Sqrt2),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := embeddings.MaxSim(tc.query, tc.doc)
+			if err != nil {
+				t.Fatalf("MaxSim failed: %v", err)
+			}
+			if math.Abs(float64(got-tc.want)) > 1e-6 {
+				t.Fatalf("MaxSim = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMaxSimValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   [][]float32
+		doc     [][]float32
+		wantErr string
+	}{
+		{name: "empty query", doc: [][]float32{{1}}, wantErr: "query has no tokens"},
+		{name: "empty document", query: [][]float32{{1}}, wantErr: "document has no tokens"},
+		{name: "empty token vector", query: [][]float32{{}}, doc: [][]float32{{}}, wantErr: "must be non-empty"},
+		{name: "ragged query", query: [][]float32{{1, 0}, {1}}, doc: [][]float32{{1, 0}}, wantErr: "query token 1 has dimension 1, want 2"},
+		{name: "document width mismatch", query: [][]float32{{1, 0}}, doc: [][]float32{{1, 0, 0}}, wantErr: "document token 0 has dimension 3, want 2"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := embeddings.MaxSim(tc.query, tc.doc)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -1,14 +1,22 @@
 package minilm
 
 import (
-	"container/list"
 	"errors"
 	"fmt"
+	"log"
 	"math"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/amikos-tech/pure-onnx/embeddings"
 	"github.com/amikos-tech/pure-onnx/embeddings/internal/ortutil"
+	"github.com/amikos-tech/pure-onnx/embeddings/internal/tokenizerutil"
 	"github.com/amikos-tech/pure-onnx/ort"
 	tokenizers "github.com/amikos-tech/pure-tokenizers"
 )
@@ -33,6 +41,17 @@ const (
 	defaultOutputName       = "last_hidden_state"
 )
 
+// inspectModel reads a model's declared inputs and outputs through the cached
+// ort.GetInputOutputInfo; tests replace it.
+var inspectModel = ort.GetInputOutputInfo
+
+// afterSubBatch runs after each completed sub-batch of a split call with the number of
+// rows embedded so far; tests replace it.
+var afterSubBatch = func(*Embedder, int) {}
+
+// newSession creates the session behind each session cache entry; tests replace it.
+var newSession = newEmbeddingSession
+
 // PoolingStrategy controls how sequence output is reduced into final embeddings.
 type PoolingStrategy string
 
@@ -40,6 +59,18 @@ const (
 	PoolingStrategyMean PoolingStrategy = "mean"
 	PoolingStrategyCLS  PoolingStrategy = "cls"
 	PoolingStrategyNone PoolingStrategy = "none"
+
+	// poolingStrategyTokens keeps only the attended token rows, flattened per document;
+	// it backs EmbedTokenEmbeddings and cannot be selected through options.
+	poolingStrategyTokens PoolingStrategy = "tokens"
+)
+
+// PaddingSide selects which end of a fixed-length row receives padding tokens.
+type PaddingSide = tokenizerutil.PaddingSide
+
+const (
+	PaddingSideRight = tokenizerutil.PaddingSideRight
+	PaddingSideLeft  = tokenizerutil.PaddingSideLeft
 )
 
 // Option customizes embedder initialization.
@@ -47,7 +78,9 @@ type Option func(*config) error
 
 type config struct {
 	sequenceLength       int
+	clampSequenceLength  bool
 	maxCachedBatchCount  int
+	maxBatchSize         int
 	tokenizerLibraryPath string
 	inputIDsName         string
 	attentionMaskName    string
@@ -56,7 +89,23 @@ type config struct {
 	embeddingDimension   int64
 	poolingStrategy      PoolingStrategy
 	l2Normalize          bool
+	l2NormalizeExplicit  bool
+	maxNormalize         bool
+	assumeNormalized     bool
 	useTokenTypeIDs      bool
+	pooledOutput         bool
+	highPrecisionPooling bool
+	runObserver          ort.RunObserver
+	floatAttentionMask   bool
+	paddingSide          PaddingSide
+	outputDataType       ort.TensorElementDataType
+	outputDataTypeSet    bool
+	rejectNonFinite      bool
+	executionProviders   []ort.ProviderSpec
+	autoBootstrap        bool
+	bootstrapOptions     []ort.BootstrapOption
+	outputPipeline       []PostProcessStep
+	sharedTokenizer      *SharedTokenizer
 }
 
 func defaultConfig() config {
@@ -71,10 +120,14 @@ func defaultConfig() config {
 		poolingStrategy:     PoolingStrategyMean,
 		l2Normalize:         true,
 		useTokenTypeIDs:     true,
+		paddingSide:         PaddingSideRight,
+		outputDataType:      ort.TensorElementDataTypeFloat,
 	}
 }
 
-// WithSequenceLength sets truncation and fixed padding length.
+// WithSequenceLength sets truncation and fixed padding length. A length above the
+// model's position embedding count is rejected at construction (see
+// WithClampSequenceLength).
 func WithSequenceLength(length int) Option {
 	return func(cfg *config) error {
 		if length <= 0 {
@@ -85,6 +138,26 @@ func WithSequenceLength(length int) Option {
 	}
 }
 
+// WithClampSequenceLength reduces a sequence length above the model's position
+// embedding count to that count, logging a warning, instead of failing construction.
+func WithClampSequenceLength() Option {
+	return func(cfg *config) error {
+		cfg.clampSequenceLength = true
+		return nil
+	}
+}
+
+// WithAutoBootstrap lets the embedder initialize ONNX Runtime itself when it is not
+// initialized at the first embed call, using ort.InitializeEnvironmentWithBootstrap
+// with opts. The environment reference taken this way is released by Close.
+func WithAutoBootstrap(opts ...ort.BootstrapOption) Option {
+	return func(cfg *config) error {
+		cfg.autoBootstrap = true
+		cfg.bootstrapOptions = append([]ort.BootstrapOption(nil), opts...)
+		return nil
+	}
+}
+
 // WithEmbeddingDimension configures the hidden width expected from the model output.
 func WithEmbeddingDimension(dim int64) Option {
 	return func(cfg *config) error {
@@ -96,6 +169,17 @@ func WithEmbeddingDimension(dim int64) Option {
 	}
 }
 
+// WithAutoEmbeddingDimension learns the hidden width from the model output instead of
+// configuring it, for models whose output width is symbolic or not known to the caller.
+// The first batch lets ONNX Runtime allocate the output and reads its dimensions;
+// later sessions pre-allocate with the detected width. See Embedder.EmbeddingDimension.
+func WithAutoEmbeddingDimension() Option {
+	return func(cfg *config) error {
+		cfg.embeddingDimension = 0
+		return nil
+	}
+}
+
 // WithMeanPooling enables attention-mask-aware mean pooling.
 func WithMeanPooling() Option {
 	return func(cfg *config) error {
@@ -120,10 +204,112 @@ func WithNoPooling() Option {
 	}
 }
 
+// WithRunObserver installs an ort.RunObserver on every inference session the embedder
+// creates, exposing the raw input tensors and model outputs after each run for debugging.
+func WithRunObserver(observer ort.RunObserver) Option {
+	return func(cfg *config) error {
+		if observer == nil {
+			return fmt.Errorf("run observer cannot be nil")
+		}
+		cfg.runObserver = observer
+		return nil
+	}
+}
+
+// WithFloatAttentionMask feeds the attention mask as a float32 tensor (1.0 for attended
+// tokens, 0.0 for padding) for models whose mask input is declared as float rather than int64.
+func WithFloatAttentionMask() Option {
+	return func(cfg *config) error {
+		cfg.floatAttentionMask = true
+		return nil
+	}
+}
+
+// WithOutputDataType sets the element type of the model output tensor:
+// ort.TensorElementDataTypeFloat (the default), ort.TensorElementDataTypeFloat16 for
+// half-precision exports, or ort.TensorElementDataTypeDouble for float64 exports. Other
+// precisions are converted to float32 before pooling. Without this option the type is
+// read from the model when ONNX Runtime is initialized at construction.
+func WithOutputDataType(dataType ort.TensorElementDataType) Option {
+	return func(cfg *config) error {
+		switch dataType {
+		case ort.TensorElementDataTypeFloat, ort.TensorElementDataTypeFloat16, ort.TensorElementDataTypeDouble:
+		default:
+			return fmt.Errorf("unsupported output data type: %d", dataType)
+		}
+		cfg.outputDataType = dataType
+		cfg.outputDataTypeSet = true
+		return nil
+	}
+}
+
+// WithPaddingSide selects where padding goes in each fixed-length row. The default is
+// PaddingSideRight; use PaddingSideLeft for models trained with left padding, since the
+// wrong side shifts token positions and corrupts the embeddings.
+func WithPaddingSide(side PaddingSide) Option {
+	return func(cfg *config) error {
+		switch side {
+		case PaddingSideRight, PaddingSideLeft:
+		default:
+			return fmt.Errorf("unsupported padding side: %q", side)
+		}
+		cfg.paddingSide = side
+		return nil
+	}
+}
+
+// WithRejectNonFinite scans each model output for NaN or infinite values and fails the
+// call with an error naming the offending document, instead of passing corrupted
+// values into the returned embeddings. Per-token outputs are only scanned at attended
+// positions, since padding never reaches the embeddings.
+func WithRejectNonFinite() Option {
+	return func(cfg *config) error {
+		cfg.rejectNonFinite = true
+		return nil
+	}
+}
+
+// WithExecutionProviders creates the embedder's sessions with the given execution
+// providers in priority order (see ort.WithExecutionProviderPriority); unavailable
+// providers are skipped and CPU remains the final fallback.
+func WithExecutionProviders(providers []ort.ProviderSpec) Option {
+	return func(cfg *config) error {
+		if len(providers) == 0 {
+			return fmt.Errorf("execution provider list cannot be empty")
+		}
+		cfg.executionProviders = cloneProviderSpecs(providers)
+		return nil
+	}
+}
+
+// WithHighPrecisionPooling accumulates mean pooling sums in float64 before casting the
+// final embedding to float32, matching reference implementations that pool in double.
+func WithHighPrecisionPooling() Option {
+	return func(cfg *config) error {
+		cfg.highPrecisionPooling = true
+		return nil
+	}
+}
+
 // WithL2Normalization applies L2 normalization to each output embedding row.
+// It replaces WithMaxNormNormalization.
 func WithL2Normalization() Option {
 	return func(cfg *config) error {
 		cfg.l2Normalize = true
+		cfg.l2NormalizeExplicit = true
+		cfg.maxNormalize = false
+		return nil
+	}
+}
+
+// WithMaxNormNormalization divides each output embedding row by its largest absolute
+// component (infinity norm) instead of its L2 norm, for systems that expect components
+// in [-1, 1]. It replaces WithL2Normalization.
+func WithMaxNormNormalization() Option {
+	return func(cfg *config) error {
+		cfg.maxNormalize = true
+		cfg.l2Normalize = false
+		cfg.l2NormalizeExplicit = false
 		return nil
 	}
 }
@@ -132,11 +318,24 @@ func WithL2Normalization() Option {
 func WithoutL2Normalization() Option {
 	return func(cfg *config) error {
 		cfg.l2Normalize = false
+		cfg.l2NormalizeExplicit = false
+		return nil
+	}
+}
+
+// WithAssumeNormalizedOutput declares that the model already emits unit-length vectors
+// (typically a pooled output such as sentence_embedding behind a Normalize layer), so
+// the redundant Go-side L2 normalization is skipped. It cannot be combined with
+// WithL2Normalization or WithMaxNormNormalization.
+func WithAssumeNormalizedOutput() Option {
+	return func(cfg *config) error {
+		cfg.assumeNormalized = true
 		return nil
 	}
 }
 
-// WithMaxCachedBatchSessions bounds how many batch-size-specific sessions are cached.
+// WithMaxCachedBatchSessions bounds how many sessions are cached; one session exists per
+// distinct batch size and sequence length (see EmbedDocumentsWithSeqLen).
 func WithMaxCachedBatchSessions(limit int) Option {
 	return func(cfg *config) error {
 		if limit <= 0 {
@@ -147,6 +346,19 @@ func WithMaxCachedBatchSessions(limit int) Option {
 	}
 }
 
+// WithMaxBatchSize splits EmbedDocuments/EmbedTokenized calls into sub-batches of at
+// most size rows, bounding per-run tensor allocations. Close requested while a split
+// call is in flight aborts it between sub-batches. The default (0) runs one batch per call.
+func WithMaxBatchSize(size int) Option {
+	return func(cfg *config) error {
+		if size <= 0 {
+			return fmt.Errorf("max batch size must be > 0, got %d", size)
+		}
+		cfg.maxBatchSize = size
+		return nil
+	}
+}
+
 // WithTokenizerLibraryPath sets the explicit pure-tokenizers shared library path.
 func WithTokenizerLibraryPath(path string) Option {
 	return func(cfg *config) error {
@@ -167,8 +379,24 @@ func WithoutTokenTypeIDsInput() Option {
 	}
 }
 
+// WithPooledOutputName reads an already-pooled 2-D output ([batch, dim]), such as
+// pooler_output or sentence_embedding, instead of last_hidden_state.
+// Go-side pooling is skipped and the configured pooling strategy is ignored;
+// L2 normalization still applies unless WithAssumeNormalizedOutput is set.
+func WithPooledOutputName(name string) Option {
+	return func(cfg *config) error {
+		if name == "" {
+			return fmt.Errorf("pooled output name cannot be empty")
+		}
+		cfg.outputName = name
+		cfg.pooledOutput = true
+		return nil
+	}
+}
+
 // WithInputOutputNames overrides ONNX input/output names.
-// tokenTypeIDsName may be empty for models without token_type_ids.
+// tokenTypeIDsName may be empty for models without token_type_ids. outputName is a
+// token-level output pooled in Go, replacing any earlier WithPooledOutputName.
 func WithInputOutputNames(inputIDsName, attentionMaskName, tokenTypeIDsName, outputName string) Option {
 	return func(cfg *config) error {
 		if inputIDsName == "" || attentionMaskName == "" || outputName == "" {
@@ -179,32 +407,159 @@ func WithInputOutputNames(inputIDsName, attentionMaskName, tokenTypeIDsName, out
 		cfg.tokenTypeIDsName = tokenTypeIDsName
 		cfg.useTokenTypeIDs = tokenTypeIDsName != ""
 		cfg.outputName = outputName
+		cfg.pooledOutput = false
 		return nil
 	}
 }
 
+// BatchResult holds the embeddings of one batch together with inference metadata.
+type BatchResult struct {
+	Embeddings [][]float32
+	// BatchSize is the number of rows submitted to the model.
+	BatchSize int
+	// InferenceDuration covers the ONNX Runtime session run only, excluding
+	// tokenization and post-processing.
+	InferenceDuration time.Duration
+	// CacheHit reports whether a cached session for this batch size was reused.
+	CacheHit bool
+}
+
+// EmbedderInfo describes the model and configuration an Embedder produces vectors
+// with, so stored vectors can be checked for configuration drift.
+type EmbedderInfo struct {
+	ModelPath          string
+	TokenizerPath      string
+	SequenceLength     int
+	PoolingStrategy    PoolingStrategy
+	PaddingSide        PaddingSide
+	L2Normalize        bool
+	MaxNormalize       bool
+	PooledOutput       bool
+	EmbeddingDimension int64
+	// ModelSHA256 is the hex-encoded SHA-256 of the model file, computed when the
+	// embedder was constructed so it keeps describing that model if the file is later
+	// replaced. It is empty when the file could not be read then.
+	ModelSHA256 string
+	// OutputPipeline lists the WithOutputPipeline steps separated by commas, for example
+	// "pool(mean),truncate(256),l2_normalize", or is empty for the default post-processing.
+	OutputPipeline string
+}
+
 // Embedder provides local dense transformer embeddings on top of ort.
 //
 // The default configuration matches all-MiniLM-L6-v2 behavior.
 // The caller must initialize ONNX Runtime via ort.SetSharedLibraryPath and
-// ort.InitializeEnvironment before calling EmbedDocuments/EmbedQuery.
+// ort.InitializeEnvironment before calling EmbedDocuments/EmbedQuery, unless the
+// embedder is constructed with WithAutoBootstrap.
 type Embedder struct {
 	modelPath          string
+	modelSHA256        string
+	tokenizerPath      string
 	sequenceLength     int
 	embeddingDimension int64
 	poolingStrategy    PoolingStrategy
 	l2Normalize        bool
+	maxNormalize       bool
+	outputPipeline     []PostProcessStep
+	outputSteps        []PostProcessStep
 	useTokenTypeIDs    bool
-	tokenizer          *tokenizers.Tokenizer
-	inputNames         []string
-	outputNames        []string
-	// sessionsByBatch caches one session per unique batch size and is LRU-bounded
-	// by maxCachedBatchCount to avoid unbounded memory growth.
-	sessionsByBatch     map[int]*embeddingSession
-	sessionLRU          *list.List
-	sessionLRUIndex     map[int]*list.Element
+	pooledOutput       bool
+	// pooledOutputChecked is set once a pooled output has been validated against the
+	// model: at construction when ONNX Runtime was initialized, else before the first
+	// session is created.
+	pooledOutputChecked bool
+	highPrecision       bool
+	tokenizer           *tokenizers.Tokenizer
+	// sharedTokenizer, when set, owns tokenizer; Close releases a reference instead
+	// of closing it.
+	sharedTokenizer *SharedTokenizer
+	padID           int64
+	inputNames      []string
+	outputNames     []string
+	// sessions caches one session per unique (batch size, sequence length) and is
+	// LRU-bounded by maxCachedBatchCount to avoid unbounded memory growth.
+	sessions            *ortutil.SessionCache[sessionKey, *embeddingSession]
 	maxCachedBatchCount int
-	runMu               sync.Mutex
+	maxBatchSize        int
+	runObserver         ort.RunObserver
+	floatAttentionMask  bool
+	paddingSide         PaddingSide
+	outputDataType      ort.TensorElementDataType
+	rejectNonFinite     bool
+	executionProviders  []ort.ProviderSpec
+	// environment bootstraps ONNX Runtime on first use under WithAutoBootstrap.
+	environment ortutil.BootstrapGuard
+	runMu       sync.Mutex
+	// closing is set by Close before it waits for runMu, so split calls can abort
+	// between sub-batches instead of holding shutdown until the whole call finishes.
+	closing atomic.Bool
+}
+
+// sessionKey identifies a cached session by its fixed input shape and the session
+// options it was created with, so calls with different options never share a session.
+type sessionKey struct {
+	batchSize      int
+	sequenceLength int
+	// providers is the providersKey of the session's execution providers.
+	providers string
+}
+
+// describeSessionKey names a cached session's resources in cache errors.
+func describeSessionKey(key sessionKey) string {
+	return fmt.Sprintf("batch-%d seq-%d embedding resources", key.batchSize, key.sequenceLength)
+}
+
+// sessionSpec describes the sessions one call runs on; only the batch size varies
+// between its sub-batches.
+type sessionSpec struct {
+	sequenceLength int
+	providers      []ort.ProviderSpec
+}
+
+func (s sessionSpec) key(batchSize int) sessionKey {
+	return sessionKey{batchSize: batchSize, sequenceLength: s.sequenceLength, providers: providersKey(s.providers)}
+}
+
+// providersKey returns a canonical encoding of an execution provider list: providers in
+// priority order, each with its options sorted by key. Equal configurations map to the
+// same key regardless of map iteration order.
+func providersKey(providers []ort.ProviderSpec) string {
+	var b strings.Builder
+	for _, provider := range providers {
+		b.WriteString(strconv.Quote(provider.Name))
+		keys := make([]string, 0, len(provider.Options))
+		for key := range provider.Options {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			b.WriteString(" " + strconv.Quote(key) + "=" + strconv.Quote(provider.Options[key]))
+		}
+		b.WriteString(";")
+	}
+	return b.String()
+}
+
+func cloneProviderSpecs(providers []ort.ProviderSpec) []ort.ProviderSpec {
+	if providers == nil {
+		return nil
+	}
+	cloned := make([]ort.ProviderSpec, len(providers))
+	for i, provider := range providers {
+		cloned[i] = ort.ProviderSpec{Name: provider.Name}
+		if provider.Options != nil {
+			cloned[i].Options = make(map[string]string, len(provider.Options))
+			for key, value := range provider.Options {
+				cloned[i].Options[key] = value
+			}
+		}
+	}
+	return cloned
+}
+
+// defaultSessionSpec returns the session spec for calls without per-call overrides.
+func (e *Embedder) defaultSessionSpec() sessionSpec {
+	return sessionSpec{sequenceLength: e.sequenceLength, providers: e.executionProviders}
 }
 
 type embeddingSession struct {
@@ -212,30 +567,113 @@ type embeddingSession struct {
 	attentionMask []int64
 	tokenTypeIDs  []int64
 
-	inputIDsTensor      *ort.Tensor[int64]
-	attentionMaskTensor *ort.Tensor[int64]
-	tokenTypeIDsTensor  *ort.Tensor[int64]
-	outputTensor        *ort.Tensor[float32]
+	// floatAttentionMask mirrors attentionMask for models with a float mask input;
+	// it is nil otherwise. attentionMask stays the source of truth for pooling.
+	floatAttentionMask []float32
+
+	inputIDsTensor           *ort.Tensor[int64]
+	attentionMaskTensor      *ort.Tensor[int64]
+	floatAttentionMaskTensor *ort.Tensor[float32]
+	tokenTypeIDsTensor       *ort.Tensor[int64]
+	outputTensor             *ort.Tensor[float32]
+	// float16OutputTensor or float64OutputTensor replaces outputTensor for float16 and
+	// float64 outputs; convertedOutput is the reusable float32 copy of their data.
+	float16OutputTensor *ort.Tensor[ort.Float16]
+	float64OutputTensor *ort.Tensor[float64]
+	convertedOutput     []float32
 	session             *ort.AdvancedSession
+	// runtimeAllocatedOutput is set when the output width was unknown at creation and
+	// outputTensor is allocated by ONNX Runtime on each run.
+	runtimeAllocatedOutput bool
+}
+
+// syncFloatAttentionMask copies the int64 attention mask into the float mask buffer.
+func (s *embeddingSession) syncFloatAttentionMask() {
+	if s.floatAttentionMask == nil {
+		return
+	}
+	for i, value := range s.attentionMask {
+		if value != 0 {
+			s.floatAttentionMask[i] = 1
+		} else {
+			s.floatAttentionMask[i] = 0
+		}
+	}
+}
+
+// outputShape returns the shape of the model output tensor.
+func (s *embeddingSession) outputShape() ort.Shape {
+	switch {
+	case s.float16OutputTensor != nil:
+		return s.float16OutputTensor.Shape()
+	case s.float64OutputTensor != nil:
+		return s.float64OutputTensor.Shape()
+	}
+	return s.outputTensor.Shape()
+}
+
+// outputData returns the model output as float32, converting float16 and float64 outputs.
+func (s *embeddingSession) outputData() []float32 {
+	switch {
+	case s.float16OutputTensor != nil:
+		s.convertedOutput = widenFloat16(s.convertedOutput, s.float16OutputTensor.GetData())
+		return s.convertedOutput
+	case s.float64OutputTensor != nil:
+		s.convertedOutput = narrowFloat64(s.convertedOutput, s.float64OutputTensor.GetData())
+		return s.convertedOutput
+	}
+	return s.outputTensor.GetData()
+}
+
+// widenFloat16 converts src to float32, reusing dst when it has enough capacity.
+func widenFloat16(dst []float32, src []ort.Float16) []float32 {
+	if cap(dst) < len(src) {
+		dst = make([]float32, len(src))
+	}
+	dst = dst[:len(src)]
+	for i, value := range src {
+		dst[i] = value.Float32()
+	}
+	return dst
+}
+
+// narrowFloat64 converts src to float32, reusing dst when it has enough capacity.
+func narrowFloat64(dst []float32, src []float64) []float32 {
+	if cap(dst) < len(src) {
+		dst = make([]float32, len(src))
+	}
+	dst = dst[:len(src)]
+	for i, value := range src {
+		dst[i] = float32(value)
+	}
+	return dst
+}
+
+// NewEmbedderFromBundle resolves spec with embeddings.ResolveBundle, downloading the
+// model and tokenizer into the bundle cache when needed, and creates an embedder from
+// the resolved paths.
+func NewEmbedderFromBundle(spec embeddings.BundleSpec, opts ...Option) (*Embedder, error) {
+	modelPath, tokenizerPath, err := embeddings.ResolveBundle(spec)
+	if err != nil {
+		return nil, err
+	}
+	return NewEmbedder(modelPath, tokenizerPath, opts...)
 }
 
 // NewEmbedder creates a high-level dense embedder.
 //
 // modelPath must point to the local ONNX model file.
-// tokenizerPath must point to the local tokenizer.json file.
+// tokenizerPath must point to the local tokenizer.json file; it may be empty with
+// WithSharedTokenizer, which supplies the path of the shared tokenizer.
+// When ONNX Runtime is already initialized, the configured token_type_ids input
+// is checked against the inputs the model declares.
 func NewEmbedder(modelPath string, tokenizerPath string, opts ...Option) (*Embedder, error) {
 	if modelPath == "" {
 		return nil, fmt.Errorf("model path cannot be empty")
 	}
-	if tokenizerPath == "" {
-		return nil, fmt.Errorf("tokenizer path cannot be empty")
-	}
 	if _, err := os.Stat(modelPath); err != nil {
 		return nil, fmt.Errorf("model path %q is not usable: %w", modelPath, err)
 	}
-	if _, err := os.Stat(tokenizerPath); err != nil {
-		return nil, fmt.Errorf("tokenizer path %q is not usable: %w", tokenizerPath, err)
-	}
 
 	cfg := defaultConfig()
 	for _, opt := range opts {
@@ -243,28 +681,90 @@ func NewEmbedder(modelPath string, tokenizerPath string, opts ...Option) (*Embed
 			return nil, err
 		}
 	}
+	if tokenizerPath == "" && cfg.sharedTokenizer != nil {
+		tokenizerPath = cfg.sharedTokenizer.shared.Path()
+	}
+	if tokenizerPath == "" {
+		return nil, fmt.Errorf("tokenizer path cannot be empty")
+	}
+	if _, err := os.Stat(tokenizerPath); err != nil {
+		return nil, fmt.Errorf("tokenizer path %q is not usable: %w", tokenizerPath, err)
+	}
 	switch cfg.poolingStrategy {
 	case PoolingStrategyMean, PoolingStrategyCLS, PoolingStrategyNone:
 	default:
 		return nil, fmt.Errorf("unsupported pooling strategy: %q", cfg.poolingStrategy)
 	}
-
-	tokenizerOpts := []tokenizers.TokenizerOption{
-		tokenizers.WithTruncation(
-			uintptr(cfg.sequenceLength),
-			tokenizers.TruncationDirectionRight,
-			tokenizers.TruncationStrategyLongestFirst,
-		),
-		tokenizers.WithPadding(true, tokenizers.PaddingStrategy{
-			Tag:       tokenizers.PaddingStrategyFixed,
-			FixedSize: uintptr(cfg.sequenceLength),
-		}),
+	outputSteps, err := resolveOutputPipeline(&cfg)
+	if err != nil {
+		return nil, err
 	}
-	if cfg.tokenizerLibraryPath != "" {
-		tokenizerOpts = append(tokenizerOpts, tokenizers.WithLibraryPath(cfg.tokenizerLibraryPath))
+	normalizationWarning, err := resolveNormalization(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	if normalizationWarning != "" {
+		log.Printf("minilm: %s", normalizationWarning)
+	}
+	sequenceLength, sequenceWarning, err := ortutil.ResolveSequenceLength(modelPath, cfg.sequenceLength, cfg.clampSequenceLength)
+	if err != nil {
+		return nil, err
+	}
+	if sequenceWarning != "" {
+		log.Printf("minilm: %s", sequenceWarning)
+	}
+	cfg.sequenceLength = sequenceLength
+	if cfg.sharedTokenizer != nil && cfg.sharedTokenizer.sequenceLength != sequenceLength {
+		return nil, fmt.Errorf(
+			"shared tokenizer pads to %d tokens, but the embedder's sequence length is %d; create it with WithSequenceLength(%d)",
+			cfg.sharedTokenizer.sequenceLength,
+			sequenceLength,
+			sequenceLength,
+		)
+	}
+
+	pooledOutputChecked := false
+	if ort.IsInitialized() {
+		inputs, outputs, err := inspectModel(modelPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect model inputs: %w", err)
+		}
+		if cfg.pooledOutput {
+			if err := validatePooledOutput(outputs, cfg.outputName, cfg.embeddingDimension); err != nil {
+				return nil, err
+			}
+			pooledOutputChecked = true
+		}
+		if err := validateAttentionMaskType(inputs, cfg.attentionMaskName, cfg.floatAttentionMask); err != nil {
+			return nil, err
+		}
+		outputDataType, err := resolveOutputDataType(outputs, cfg.outputName, cfg.outputDataType, cfg.outputDataTypeSet)
+		if err != nil {
+			return nil, err
+		}
+		cfg.outputDataType = outputDataType
+		useTokenTypeIDs, warning, err := resolveTokenTypeIDsInput(inputs, cfg.tokenTypeIDsName, cfg.useTokenTypeIDs)
+		if err != nil {
+			return nil, err
+		}
+		if warning != "" {
+			log.Printf("minilm: %s", warning)
+		}
+		if !useTokenTypeIDs {
+			cfg.useTokenTypeIDs = false
+			cfg.tokenTypeIDsName = ""
+		}
 	}
 
-	tokenizer, err := tokenizers.FromFile(tokenizerPath, tokenizerOpts...)
+	var tokenizer *tokenizers.Tokenizer
+	var padID int64
+	if cfg.sharedTokenizer != nil {
+		tokenizer, err = cfg.sharedTokenizer.shared.Acquire()
+		padID = cfg.sharedTokenizer.shared.PadID()
+	} else {
+		tokenizer, err = tokenizers.FromFile(tokenizerPath, tokenizerOptions(cfg)...)
+		padID = tokenizerutil.PadTokenID(tokenizerPath)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to load tokenizer: %w", err)
 	}
@@ -274,173 +774,928 @@ func NewEmbedder(modelPath string, tokenizerPath string, opts ...Option) (*Embed
 		inputNames = append(inputNames, cfg.tokenTypeIDsName)
 	}
 
+	// An unreadable model leaves the hash empty; creating a session reports the error.
+	modelSHA256, _ := ort.ModelSHA256(modelPath)
+
 	return &Embedder{
 		modelPath:           modelPath,
+		modelSHA256:         modelSHA256,
+		tokenizerPath:       tokenizerPath,
 		sequenceLength:      cfg.sequenceLength,
 		embeddingDimension:  cfg.embeddingDimension,
 		poolingStrategy:     cfg.poolingStrategy,
 		l2Normalize:         cfg.l2Normalize,
+		maxNormalize:        cfg.maxNormalize,
+		outputPipeline:      cfg.outputPipeline,
+		outputSteps:         outputSteps,
 		useTokenTypeIDs:     cfg.useTokenTypeIDs,
+		pooledOutput:        cfg.pooledOutput,
+		pooledOutputChecked: pooledOutputChecked,
+		highPrecision:       cfg.highPrecisionPooling,
 		tokenizer:           tokenizer,
+		sharedTokenizer:     cfg.sharedTokenizer,
+		padID:               padID,
 		inputNames:          inputNames,
 		outputNames:         []string{cfg.outputName},
-		sessionsByBatch:     make(map[int]*embeddingSession),
-		sessionLRU:          list.New(),
-		sessionLRUIndex:     make(map[int]*list.Element),
+		sessions:            ortutil.NewSessionCache[sessionKey, *embeddingSession](cfg.maxCachedBatchCount, describeSessionKey),
 		maxCachedBatchCount: cfg.maxCachedBatchCount,
+		maxBatchSize:        cfg.maxBatchSize,
+		runObserver:         cfg.runObserver,
+		floatAttentionMask:  cfg.floatAttentionMask,
+		paddingSide:         cfg.paddingSide,
+		outputDataType:      cfg.outputDataType,
+		rejectNonFinite:     cfg.rejectNonFinite,
+		executionProviders:  cfg.executionProviders,
+		environment:         ortutil.BootstrapGuard{Enabled: cfg.autoBootstrap, Options: cfg.bootstrapOptions},
 	}, nil
 }
 
+// tokenizerOptions truncates and pads encodings to cfg.sequenceLength.
+func tokenizerOptions(cfg config) []tokenizers.TokenizerOption {
+	opts := []tokenizers.TokenizerOption{
+		tokenizers.WithTruncation(
+			uintptr(cfg.sequenceLength),
+			tokenizers.TruncationDirectionRight,
+			tokenizers.TruncationStrategyLongestFirst,
+		),
+		tokenizers.WithPadding(true, tokenizers.PaddingStrategy{
+			Tag:       tokenizers.PaddingStrategyFixed,
+			FixedSize: uintptr(cfg.sequenceLength),
+		}),
+	}
+	if cfg.tokenizerLibraryPath != "" {
+		opts = append(opts, tokenizers.WithLibraryPath(cfg.tokenizerLibraryPath))
+	}
+	return opts
+}
+
+// resolveNormalization reconciles the L2 normalization options. It disables
+// normalization under WithAssumeNormalizedOutput and returns a warning when an
+// explicitly requested normalization is applied to an already-pooled output.
+func resolveNormalization(cfg *config) (string, error) {
+	if cfg.assumeNormalized {
+		if cfg.l2NormalizeExplicit {
+			return "", fmt.Errorf("WithAssumeNormalizedOutput cannot be combined with WithL2Normalization")
+		}
+		if cfg.maxNormalize {
+			return "", fmt.Errorf("WithAssumeNormalizedOutput cannot be combined with WithMaxNormNormalization")
+		}
+		cfg.l2Normalize = false
+		return "", nil
+	}
+	if cfg.pooledOutput && cfg.l2NormalizeExplicit {
+		return fmt.Sprintf("pooled output %q is L2-normalized again; if the model already emits normalized vectors, use WithAssumeNormalizedOutput to skip the redundant normalization", cfg.outputName), nil
+	}
+	return "", nil
+}
+
+// validatePooledOutput checks that a pooled output exists and is 2-D ([batch, dim]).
+func validatePooledOutput(outputs []ort.InputOutputInfo, name string, embeddingDim int64) error {
+	for _, output := range outputs {
+		if output.Name != name {
+			continue
+		}
+		if len(output.Dimensions) != 2 {
+			return fmt.Errorf("pooled output %q must be rank 2 [batch, dim], got rank %d %v", name, len(output.Dimensions), output.Dimensions)
+		}
+		if dim := output.Dimensions[1]; dim > 0 && embeddingDim > 0 && dim != embeddingDim {
+			return fmt.Errorf("pooled output %q width mismatch: model=%d configured=%d", name, dim, embeddingDim)
+		}
+		return nil
+	}
+	return fmt.Errorf("model does not declare pooled output %q", name)
+}
+
+// resolveTokenTypeIDsInput reconciles the configured token_type_ids input with the
+// inputs the model declares. A model that requires token_type_ids while the embedder
+// is configured without it is an error; the reverse degrades to running without the
+// input and returns a warning.
+func resolveTokenTypeIDsInput(inputs []ort.InputOutputInfo, tokenTypeIDsName string, useTokenTypeIDs bool) (bool, string, error) {
+	name := tokenTypeIDsName
+	if !useTokenTypeIDs || name == "" {
+		name = defaultTokenTypeIDsName
+	}
+
+	declared := false
+	for _, input := range inputs {
+		if input.Name == name {
+			declared = true
+			break
+		}
+	}
+
+	switch {
+	case declared && !useTokenTypeIDs:
+		return false, "", fmt.Errorf(
+			"model declares a %q input but the embedder is configured without token_type_ids; drop WithoutTokenTypeIDsInput or pass the name to WithInputOutputNames",
+			name,
+		)
+	case !declared && useTokenTypeIDs:
+		return false, fmt.Sprintf("model does not declare a %q input; running without token_type_ids", name), nil
+	default:
+		return useTokenTypeIDs, "", nil
+	}
+}
+
+// resolveOutputDataType returns the output element type to allocate. Without an explicit
+// WithOutputDataType, a float32, float16 or float64 output declared by the model is adopted;
+// an explicit type must match the declared one. Undeclared outputs keep dataType.
+func resolveOutputDataType(outputs []ort.InputOutputInfo, outputName string, dataType ort.TensorElementDataType, explicit bool) (ort.TensorElementDataType, error) {
+	for _, output := range outputs {
+		if output.Name != outputName {
+			continue
+		}
+		switch {
+		case explicit && output.DataType != dataType:
+			return 0, fmt.Errorf("model output %q has element type %d, but the embedder is configured with WithOutputDataType(%d)", outputName, output.DataType, dataType)
+		case output.DataType == ort.TensorElementDataTypeFloat, output.DataType == ort.TensorElementDataTypeFloat16, output.DataType == ort.TensorElementDataTypeDouble:
+			return output.DataType, nil
+		}
+		return dataType, nil
+	}
+	return dataType, nil
+}
+
+// validateAttentionMaskType checks that the model's attention mask input element type
+// matches the configured mask tensor type. Models that do not declare the input are
+// left to fail at session creation.
+func validateAttentionMaskType(inputs []ort.InputOutputInfo, attentionMaskName string, floatAttentionMask bool) error {
+	for _, input := range inputs {
+		if input.Name != attentionMaskName {
+			continue
+		}
+		switch {
+		case input.DataType == ort.TensorElementDataTypeFloat && !floatAttentionMask:
+			return fmt.Errorf("model declares a float %q input; configure the embedder with WithFloatAttentionMask", attentionMaskName)
+		case input.DataType == ort.TensorElementDataTypeInt64 && floatAttentionMask:
+			return fmt.Errorf("model declares an int64 %q input but the embedder is configured with WithFloatAttentionMask", attentionMaskName)
+		}
+		return nil
+	}
+	return nil
+}
+
+// EmbeddingDimension returns the width of the returned embeddings: the hidden width of
+// the model output, or the width an output pipeline TruncateStep shortens rows to. With
+// WithAutoEmbeddingDimension it is 0 until the first batch has been embedded.
+func (e *Embedder) EmbeddingDimension() int64 {
+	hidden := e.hiddenDimension()
+	if width := truncatedWidth(e.outputSteps); width > 0 && hidden > 0 {
+		return int64(width)
+	}
+	return hidden
+}
+
+// hiddenDimension returns the hidden width of the model output, 0 until detected.
+func (e *Embedder) hiddenDimension() int64 {
+	if e == nil {
+		return 0
+	}
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+	return e.embeddingDimension
+}
+
+// Info returns the model and configuration the embedder was constructed with.
+func (e *Embedder) Info() EmbedderInfo {
+	if e == nil {
+		return EmbedderInfo{}
+	}
+	return EmbedderInfo{
+		ModelPath:          e.modelPath,
+		TokenizerPath:      e.tokenizerPath,
+		SequenceLength:     e.sequenceLength,
+		PoolingStrategy:    e.poolingStrategy,
+		PaddingSide:        e.paddingSide,
+		L2Normalize:        e.l2Normalize,
+		MaxNormalize:       e.maxNormalize,
+		OutputPipeline:     describePostProcessSteps(e.outputPipeline),
+		PooledOutput:       e.pooledOutput,
+		EmbeddingDimension: e.EmbeddingDimension(),
+		ModelSHA256:        e.modelSHA256,
+	}
+}
+
+// Probe validates the embedder configuration against the model before real traffic:
+// it checks that the model declares the configured input and output names, then runs
+// one inference over a short dummy document so shape and type mismatches surface with
+// the failing step named. ONNX Runtime must be initialized (or WithAutoBootstrap set).
+func (e *Embedder) Probe() error {
+	if e == nil {
+		return fmt.Errorf("embedder is nil")
+	}
+	e.runMu.Lock()
+	err := e.environment.Ensure()
+	e.runMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return ortutil.Probe(e.modelPath, e.inputNames, e.outputNames, func(document string) error {
+		_, err := e.EmbedQuery(document)
+		return err
+	})
+}
+
 // Close releases ONNX session resources and tokenizer resources.
 func (e *Embedder) Close() error {
 	if e == nil {
 		return nil
 	}
 
-	e.runMu.Lock()
-	defer e.runMu.Unlock()
+	e.closing.Store(true)
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+
+	err := e.sessions.DestroyAll()
+	e.sessions = nil
+
+	if e.sharedTokenizer != nil {
+		if releaseErr := e.sharedTokenizer.shared.Release(); releaseErr != nil {
+			err = errors.Join(err, releaseErr)
+		}
+		e.sharedTokenizer = nil
+	} else if e.tokenizer != nil {
+		if closeErr := e.tokenizer.Close(); closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}
+	e.tokenizer = nil
+
+	if releaseErr := e.environment.Release(); releaseErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to release bootstrapped ONNX Runtime environment: %w", releaseErr))
+	}
+
+	return err
+}
+
+// EmbedOnce embeds documents with a short-lived embedder, managing the ONNX Runtime
+// lifecycle for one-off scripts and CLI tools.
+//
+// If ONNX Runtime is already initialized, EmbedOnce takes an extra environment reference
+// for the duration of the call and leaves the caller's environment running. Otherwise it
+// initializes ONNX Runtime with ort.InitializeEnvironmentWithBootstrap (honoring the
+// ONNXRUNTIME_* environment variables) and destroys it before returning. Long-running
+// programs should construct one Embedder and reuse it instead.
+func EmbedOnce(modelPath string, tokenizerPath string, documents []string, opts ...Option) (_ [][]float32, err error) {
+	if err := ortutil.AcquireEnvironment(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if destroyErr := ort.DestroyEnvironment(); destroyErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to destroy ONNX Runtime environment: %w", destroyErr))
+		}
+	}()
+
+	embedder, err := NewEmbedder(modelPath, tokenizerPath, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := embedder.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to close embedder: %w", closeErr))
+		}
+	}()
+
+	return embedder.EmbedDocuments(documents)
+}
+
+// EmbedDocuments embeds input documents into deterministic vectors.
+// The returned slice is always in input order: result[i] is the embedding of documents[i],
+// regardless of how the call is split into sub-batches (see WithMaxBatchSize).
+func (e *Embedder) EmbedDocuments(documents []string) ([][]float32, error) {
+	result, err := e.EmbedDocumentsDetailed(documents)
+	if err != nil {
+		return nil, err
+	}
+	return result.Embeddings, nil
+}
+
+// EmbedInto embeds documents like EmbedDocuments but writes embedding i into dst[i]
+// instead of allocating result rows, so high-throughput callers can reuse one buffer
+// across calls. dst must have at least len(documents) rows, each exactly as long as an
+// embedding (EmbeddingDimension, or sequence length times that with PoolingStrategyNone);
+// rows past len(documents) are left untouched. When an error is returned, dst may hold a
+// partial result.
+func (e *Embedder) EmbedInto(documents []string, dst [][]float32) error {
+	if e == nil {
+		return fmt.Errorf("embedder is nil")
+	}
+	if len(dst) < len(documents) {
+		return fmt.Errorf("dst has %d rows, want at least %d", len(dst), len(documents))
+	}
+	if truncatesRows(e.outputSteps) {
+		return fmt.Errorf("EmbedInto cannot write rows shortened by an output pipeline TruncateStep; use EmbedDocuments")
+	}
+	post := e.configuredPostProcessing()
+	post.dst = dst[:len(documents):len(documents)]
+	_, err := e.embedDocuments(documents, post)
+	return err
+}
+
+// EmbedDocumentsFlat embeds documents like EmbedDocuments but returns them in one
+// row-major buffer, for bulk copies into columnar stores: embedding i is
+// data[i*cols:(i+1)*cols]. When the row width is known up front, rows are written
+// straight into data; before WithAutoEmbeddingDimension has seen a batch, or with an
+// output pipeline TruncateStep, they are embedded first and then copied. Empty input
+// returns an empty buffer with rows and cols both 0, whether or not the width is known.
+func (e *Embedder) EmbedDocumentsFlat(documents []string) (data []float32, rows, cols int, err error) {
+	if e == nil {
+		return nil, 0, 0, fmt.Errorf("embedder is nil")
+	}
+	if len(documents) == 0 {
+		return []float32{}, 0, 0, nil
+	}
+	width := 0
+	if dim := e.hiddenDimension(); dim > 0 && !truncatesRows(e.outputSteps) {
+		width = int(dim)
+		if !e.pooledOutput && e.poolingStrategy == PoolingStrategyNone {
+			width *= e.sequenceLength
+		}
+	}
+	if width == 0 {
+		vectors, err := e.EmbedDocuments(documents)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		width = len(vectors[0])
+		data = make([]float32, 0, len(vectors)*width)
+		for _, vector := range vectors {
+			data = append(data, vector...)
+		}
+		return data, len(vectors), width, nil
+	}
+
+	total, err := ortutil.CheckedMul(len(documents), width)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("invalid flat embedding buffer size: %w", err)
+	}
+	data = make([]float32, total)
+	dst := make([][]float32, len(documents))
+	for i := range dst {
+		dst[i] = data[i*width : (i+1)*width : (i+1)*width]
+	}
+	if err := e.EmbedInto(documents, dst); err != nil {
+		return nil, 0, 0, err
+	}
+	return data, len(documents), width, nil
+}
+
+// checkDestinationRows checks that each caller-provided row holds exactly width values.
+func checkDestinationRows(dst [][]float32, firstRow int, width int) error {
+	for i, row := range dst {
+		if len(row) != width {
+			return fmt.Errorf("dst row %d has length %d, want %d", firstRow+i, len(row), width)
+		}
+	}
+	return nil
+}
+
+// EmbedDocumentsDetailed embeds input documents like EmbedDocuments and also reports
+// the batch size, session run duration, and whether a cached session was reused.
+func (e *Embedder) EmbedDocumentsDetailed(documents []string) (*BatchResult, error) {
+	if e == nil {
+		return nil, fmt.Errorf("embedder is nil")
+	}
+	return e.embedDocuments(documents, e.configuredPostProcessing())
+}
+
+// EmbedDocumentsWith embeds documents like EmbedDocuments, applying the overrides in
+// opts to this call only. Post-processing overrides share sessions with the embedder's
+// other calls, since pooling and normalization happen after inference; an execution
+// provider override runs on separately cached sessions.
+func (e *Embedder) EmbedDocumentsWith(opts RuntimeOpts, documents []string) ([][]float32, error) {
+	if e == nil {
+		return nil, fmt.Errorf("embedder is nil")
+	}
+	post, err := e.resolveRuntimeOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+	spec := e.defaultSessionSpec()
+	if opts.ExecutionProviders != nil {
+		spec.providers = cloneProviderSpecs(opts.ExecutionProviders)
+	}
+	result, err := e.embedDocumentsWithSpec(documents, spec, post)
+	if err != nil {
+		return nil, err
+	}
+	return result.Embeddings, nil
+}
+
+// EmbedDocumentsWithNorms embeds documents like EmbedDocuments and also returns
+// norms[i], the L2 norm of documents[i]'s pooled vector before any normalization.
+// With WithoutL2Normalization, vectors are returned un-normalized so callers can
+// score by dot product and normalize lazily; otherwise vectors are normalized as usual.
+func (e *Embedder) EmbedDocumentsWithNorms(documents []string) (vectors [][]float32, norms []float32, err error) {
+	if e == nil {
+		return nil, nil, fmt.Errorf("embedder is nil")
+	}
+	post := e.configuredPostProcessing()
+	normalize, maxNormalize, steps := post.l2Normalize, post.maxNormalize, post.steps
+	post.l2Normalize, post.maxNormalize, post.steps = false, false, nil
+
+	result, err := e.embedDocuments(documents, post)
+	if err != nil {
+		return nil, nil, err
+	}
+	vectors = result.Embeddings
+	norms = make([]float32, len(vectors))
+	for i, vector := range vectors {
+		norms[i] = l2Norm(vector)
+	}
+	if normalize {
+		l2NormalizeRows(vectors)
+	}
+	if maxNormalize {
+		maxNormalizeRows(vectors)
+	}
+	if len(steps) > 0 {
+		vectors, err = applyPostProcessSteps(steps, vectors)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return vectors, norms, nil
+}
+
+// EmbedDocumentsWithSeqLen embeds documents like EmbedDocuments, but tokenizes them to
+// sequenceLength tokens instead of the configured sequence length, so batches of short
+// queries run on a smaller input. sequenceLength must be between 1 and the configured
+// length. Longer documents are truncated from the right and keep their trailing special
+// tokens (such as [SEP]), as the tokenizer does for the configured length. Sessions are
+// cached per (batch size, sequence length) and share the WithMaxCachedBatchSessions bound.
+func (e *Embedder) EmbedDocumentsWithSeqLen(sequenceLength int, documents []string) ([][]float32, error) {
+	if e == nil {
+		return nil, fmt.Errorf("embedder is nil")
+	}
+	if sequenceLength <= 0 || sequenceLength > e.sequenceLength {
+		return nil, fmt.Errorf("sequence length must be between 1 and the configured %d, got %d", e.sequenceLength, sequenceLength)
+	}
+	spec := e.defaultSessionSpec()
+	spec.sequenceLength = sequenceLength
+	result, err := e.embedDocumentsWithSpec(documents, spec, e.configuredPostProcessing())
+	if err != nil {
+		return nil, err
+	}
+	return result.Embeddings, nil
+}
+
+func (e *Embedder) embedDocuments(documents []string, post postProcessing) (*BatchResult, error) {
+	return e.embedDocumentsWithSpec(documents, e.defaultSessionSpec(), post)
+}
+
+func (e *Embedder) embedDocumentsWithSpec(documents []string, spec sessionSpec, post postProcessing) (*BatchResult, error) {
+	if len(documents) == 0 {
+		return &BatchResult{Embeddings: [][]float32{}}, nil
+	}
+
+	return e.embedInBatches(len(documents), spec, post, func(session *embeddingSession, start int, end int) error {
+		return e.tokenizeInto(
+			documents[start:end],
+			start,
+			spec.sequenceLength,
+			session.inputIDs,
+			session.attentionMask,
+			session.tokenTypeIDs,
+		)
+	})
+}
+
+// EmbedTokenized embeds pre-tokenized rows, bypassing the embedder's tokenizer.
+//
+// Each row must already be truncated/padded to the configured sequence length.
+// attentionMask may be nil, in which case it is derived from the token ids that differ
+// from the tokenizer's declared pad id (0 when it declares none).
+// tokenTypeIDs may be nil (zeros are used) and must be nil when the embedder
+// was configured without a token_type_ids input.
+func (e *Embedder) EmbedTokenized(inputIDs [][]int64, attentionMask [][]int64, tokenTypeIDs [][]int64) ([][]float32, error) {
+	if e == nil {
+		return nil, fmt.Errorf("embedder is nil")
+	}
+	if len(inputIDs) == 0 {
+		return [][]float32{}, nil
+	}
+	if err := validateTokenizedRows(inputIDs, attentionMask, tokenTypeIDs, e.sequenceLength, e.useTokenTypeIDs); err != nil {
+		return nil, err
+	}
+
+	result, err := e.embedInBatches(len(inputIDs), e.defaultSessionSpec(), e.configuredPostProcessing(), func(session *embeddingSession, start int, end int) error {
+		return fillTokenizedRows(
+			session,
+			inputIDs[start:end],
+			rowRange(attentionMask, start, end),
+			rowRange(tokenTypeIDs, start, end),
+			e.sequenceLength,
+			e.padID,
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Embeddings, nil
+}
+
+// EmbedTokenEmbeddings returns the un-pooled token embeddings of each document as
+// result[i][token][dim], for late-interaction (ColBERT-style) retrieval. Padding and
+// other masked positions are dropped, so len(result[i]) is the number of attended
+// tokens of documents[i] after truncation. Vectors are returned as produced by the
+// model, without L2 normalization.
+func (e *Embedder) EmbedTokenEmbeddings(documents []string) ([][][]float32, error) {
+	if e == nil {
+		return nil, fmt.Errorf("embedder is nil")
+	}
+	if e.pooledOutput {
+		return nil, fmt.Errorf("token embeddings are unavailable when the model output is already pooled")
+	}
+	result, err := e.embedDocuments(documents, postProcessing{poolingStrategy: poolingStrategyTokens})
+	if err != nil {
+		return nil, err
+	}
+	return splitTokenRows(result.Embeddings, int(e.hiddenDimension()))
+}
+
+// splitTokenRows reshapes flattened per-document token rows into [token][dim] views.
+func splitTokenRows(rows [][]float32, dim int) ([][][]float32, error) {
+	tokens := make([][][]float32, len(rows))
+	for i, row := range rows {
+		if dim <= 0 || len(row)%dim != 0 {
+			return nil, fmt.Errorf("token embeddings row %d length %d is not a multiple of embedding dim %d", i, len(row), dim)
+		}
+		tokens[i] = make([][]float32, len(row)/dim)
+		for token := range tokens[i] {
+			tokens[i][token] = row[token*dim : (token+1)*dim : (token+1)*dim]
+		}
+	}
+	return tokens, nil
+}
+
+// embedInBatches embeds total rows on sessions described by spec, in sub-batches of at
+// most maxBatchSize rows.
+// fill populates a session's input buffers with rows [start, end).
+// For split calls, BatchResult.BatchSize is the largest sub-batch, InferenceDuration
+// is summed, and CacheHit is true only if every sub-batch reused a cached session.
+func (e *Embedder) embedInBatches(total int, spec sessionSpec, post postProcessing, fill func(session *embeddingSession, start int, end int) error) (*BatchResult, error) {
+	bounds := ortutil.SubBatchBounds(total, e.maxBatchSize)
+	if len(bounds) <= 1 {
+		return e.embedBatch(spec, total, 0, post, func(session *embeddingSession) error {
+			return fill(session, 0, total)
+		})
+	}
+
+	result := &BatchResult{
+		Embeddings: make([][]float32, 0, total),
+		CacheHit:   true,
+	}
+	for _, bound := range bounds {
+		start, end := bound[0], bound[1]
+		// The first sub-batch falls through to embedBatch, which reports an already closed embedder.
+		if start > 0 && e.closing.Load() {
+			return nil, fmt.Errorf("embedder is closing: aborted after %d of %d rows", start, total)
+		}
+		subResult, err := e.embedBatch(spec, end-start, start, post, func(session *embeddingSession) error {
+			return fill(session, start, end)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("sub-batch [%d:%d]: %w", start, end, err)
+		}
+		result.Embeddings = append(result.Embeddings, subResult.Embeddings...)
+		result.BatchSize = max(result.BatchSize, subResult.BatchSize)
+		result.InferenceDuration += subResult.InferenceDuration
+		result.CacheHit = result.CacheHit && subResult.CacheHit
+		afterSubBatch(e, end)
+	}
+	return result, nil
+}
+
+// RuntimeOpts overrides post-processing for a single EmbedDocumentsWith call.
+// Zero-valued fields keep the embedder's configured behavior.
+type RuntimeOpts struct {
+	// PoolingStrategy, when non-empty, replaces the configured pooling strategy.
+	// It cannot be overridden for embedders using WithPooledOutputName.
+	PoolingStrategy PoolingStrategy
+	// L2Normalize, when non-nil, replaces the configured normalization setting,
+	// including WithMaxNormNormalization.
+	L2Normalize *bool
+	// ExecutionProviders, when non-nil, replaces the providers set with
+	// WithExecutionProviders; an empty list runs on ONNX Runtime's default (CPU).
+	ExecutionProviders []ort.ProviderSpec
+}
+
+// postProcessing is the pooling/normalization applied to one call's model output.
+type postProcessing struct {
+	poolingStrategy PoolingStrategy
+	l2Normalize     bool
+	maxNormalize    bool
+	// steps are the WithOutputPipeline steps applied to pooled rows.
+	steps []PostProcessStep
+	// dst, when non-nil, receives the embedding rows in place of newly allocated ones;
+	// see EmbedInto.
+	dst [][]float32
+}
 
-	var err error
+func (e *Embedder) configuredPostProcessing() postProcessing {
+	return postProcessing{poolingStrategy: e.poolingStrategy, l2Normalize: e.l2Normalize, maxNormalize: e.maxNormalize, steps: e.outputSteps}
+}
 
-	for batchSize, session := range e.sessionsByBatch {
-		if destroyErr := session.Destroy(); destroyErr != nil {
-			err = errors.Join(err, fmt.Errorf("failed to destroy batch-%d embedding resources: %w", batchSize, destroyErr))
+func (e *Embedder) resolveRuntimeOpts(opts RuntimeOpts) (postProcessing, error) {
+	post := e.configuredPostProcessing()
+	if opts.PoolingStrategy != "" {
+		switch opts.PoolingStrategy {
+		case PoolingStrategyMean, PoolingStrategyCLS, PoolingStrategyNone:
+		default:
+			return postProcessing{}, fmt.Errorf("unsupported pooling strategy: %q", opts.PoolingStrategy)
+		}
+		if e.pooledOutput {
+			return postProcessing{}, fmt.Errorf("pooling strategy cannot be overridden when the model output is already pooled")
 		}
+		if opts.PoolingStrategy == PoolingStrategyNone && len(post.steps) > 0 {
+			return postProcessing{}, fmt.Errorf("pooling cannot be disabled for an embedder with output pipeline steps")
+		}
+		post.poolingStrategy = opts.PoolingStrategy
 	}
-	e.sessionsByBatch = nil
-	e.sessionLRU = nil
-	e.sessionLRUIndex = nil
-
-	if e.tokenizer != nil {
-		if closeErr := e.tokenizer.Close(); closeErr != nil {
-			err = errors.Join(err, closeErr)
+	if opts.L2Normalize != nil {
+		if e.outputPipeline != nil {
+			return postProcessing{}, fmt.Errorf("RuntimeOpts.L2Normalize cannot be combined with WithOutputPipeline")
 		}
-		e.tokenizer = nil
+		post.l2Normalize = *opts.L2Normalize
+		post.maxNormalize = false
 	}
-
-	return err
+	return post, nil
 }
 
-// EmbedDocuments embeds input documents into deterministic vectors.
-func (e *Embedder) EmbedDocuments(documents []string) (_ [][]float32, err error) {
-	if e == nil {
-		return nil, fmt.Errorf("embedder is nil")
+// checkFiniteOutput rejects NaN or infinite values in a batch's model output. Token
+// embeddings are only checked at attended positions; pooled rows are checked whole.
+// firstRow is the call-wide index of the batch's first document.
+func checkFiniteOutput[T float32 | float64](output []T, attentionMask []int64, pooled bool, batchSize int, firstRow int) error {
+	if pooled {
+		attentionMask = nil
 	}
-	if len(documents) == 0 {
-		return [][]float32{}, nil
+	return ortutil.CheckFinite(output, batchSize, attentionMask, func(row int) string {
+		return fmt.Sprintf("document %d", firstRow+row)
+	})
+}
+
+// rowRange returns rows[start:end], preserving nil for optional row sets.
+func rowRange(rows [][]int64, start int, end int) [][]int64 {
+	if rows == nil {
+		return nil
 	}
+	return rows[start:end]
+}
 
+// embedBatch runs one inference over a batch whose input buffers are populated by fill.
+// firstRow is the call-wide index of the batch's first row, used in error messages.
+func (e *Embedder) embedBatch(spec sessionSpec, batchSize int, firstRow int, post postProcessing, fill func(*embeddingSession) error) (*BatchResult, error) {
 	e.runMu.Lock()
 	defer e.runMu.Unlock()
 
-	if e.tokenizer == nil || e.sessionsByBatch == nil {
+	if e.tokenizer == nil || e.sessions == nil {
 		return nil, fmt.Errorf("embedder has been closed")
 	}
-	if !ort.IsInitialized() {
-		return nil, fmt.Errorf("ONNX Runtime not initialized: call ort.SetSharedLibraryPath and ort.InitializeEnvironment first")
+	if err := e.environment.Ensure(); err != nil {
+		return nil, err
 	}
 
-	session, err := e.sessionForBatchLocked(len(documents))
+	sequenceLength := spec.sequenceLength
+	_, cacheHit := e.sessions.Lookup(spec.key(batchSize))
+	session, err := e.sessionForBatchLocked(spec, batchSize)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := e.tokenizeInto(
-		documents,
-		session.inputIDs,
-		session.attentionMask,
-		session.tokenTypeIDs,
-	); err != nil {
+	if err := fill(session); err != nil {
 		return nil, err
 	}
+	session.syncFloatAttentionMask()
 
+	runStart := time.Now()
 	if err := session.session.Run(); err != nil {
 		return nil, fmt.Errorf("embedding inference failed: %w", err)
 	}
+	inferenceDuration := time.Since(runStart)
 
-	embeddings, err := postProcessDenseOutput(
-		session.outputTensor.GetData(),
-		session.attentionMask,
-		len(documents),
-		e.sequenceLength,
-		e.embeddingDimension,
-		e.poolingStrategy,
-		e.l2Normalize,
-	)
-	if err != nil {
-		return nil, err
+	embeddingDimension := e.embeddingDimension
+	if session.runtimeAllocatedOutput {
+		embeddingDimension, err = detectEmbeddingDimension(session.outputShape(), batchSize, sequenceLength, e.pooledOutput)
+		if err != nil {
+			return nil, err
+		}
+		if e.embeddingDimension == 0 {
+			e.embeddingDimension = embeddingDimension
+		} else if embeddingDimension != e.embeddingDimension {
+			return nil, fmt.Errorf("model output width changed between runs: got %d, previously detected %d", embeddingDimension, e.embeddingDimension)
+		}
 	}
 
-	return embeddings, nil
-}
-
-func (e *Embedder) sessionForBatchLocked(batchSize int) (_ *embeddingSession, err error) {
-	if batchSize <= 0 {
-		return nil, fmt.Errorf("batch size must be > 0, got %d", batchSize)
+	if e.rejectNonFinite {
+		if session.float64OutputTensor != nil {
+			err = checkFiniteOutput(session.float64OutputTensor.GetData(), session.attentionMask, e.pooledOutput, batchSize, firstRow)
+		} else {
+			err = checkFiniteOutput(session.outputData(), session.attentionMask, e.pooledOutput, batchSize, firstRow)
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	if session, ok := e.sessionsByBatch[batchSize]; ok {
-		e.touchBatchSizeLocked(batchSize)
-		return session, nil
-	}
-	if e.maxCachedBatchCount > 0 && len(e.sessionsByBatch) >= e.maxCachedBatchCount {
-		if err := e.evictLeastRecentlyUsedSessionLocked(); err != nil {
+	var dst [][]float32
+	if post.dst != nil {
+		dst = post.dst[firstRow : firstRow+batchSize]
+		width := int(embeddingDimension)
+		if !e.pooledOutput && post.poolingStrategy == PoolingStrategyNone {
+			width *= sequenceLength
+		}
+		if err := checkDestinationRows(dst, firstRow, width); err != nil {
 			return nil, err
 		}
 	}
 
-	session, err := newEmbeddingSession(
-		e.modelPath,
-		e.inputNames,
-		e.outputNames,
-		e.sequenceLength,
-		batchSize,
-		e.embeddingDimension,
-		e.useTokenTypeIDs,
-	)
+	var embeddings [][]float32
+	switch {
+	case e.pooledOutput:
+		embeddings, err = postProcessPooledOutput(
+			dst,
+			session.outputData(),
+			batchSize,
+			embeddingDimension,
+			post.l2Normalize,
+		)
+	case session.float64OutputTensor != nil && post.poolingStrategy == PoolingStrategyMean:
+		// Averaging is the only step that rounds, so a float64 output is pooled as is
+		// and only the pooled rows are narrowed.
+		embeddings, err = meanPoolFloat64Output(
+			dst,
+			session.float64OutputTensor.GetData(),
+			session.attentionMask,
+			batchSize,
+			sequenceLength,
+			embeddingDimension,
+			post.l2Normalize,
+		)
+	default:
+		embeddings, err = postProcessDenseOutput(
+			dst,
+			session.outputData(),
+			session.attentionMask,
+			batchSize,
+			sequenceLength,
+			embeddingDimension,
+			post.poolingStrategy,
+			post.l2Normalize,
+			e.highPrecision,
+		)
+	}
 	if err != nil {
 		return nil, err
 	}
-	e.sessionsByBatch[batchSize] = session
-	e.touchBatchSizeLocked(batchSize)
-	return session, nil
+	if post.maxNormalize {
+		maxNormalizeRows(embeddings)
+	}
+	if len(post.steps) > 0 {
+		embeddings, err = applyPostProcessSteps(post.steps, embeddings)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &BatchResult{
+		Embeddings:        embeddings,
+		BatchSize:         batchSize,
+		InferenceDuration: inferenceDuration,
+		CacheHit:          cacheHit,
+	}, nil
 }
 
-func (e *Embedder) touchBatchSizeLocked(batchSize int) {
-	if existing := e.sessionLRUIndex[batchSize]; existing != nil {
-		e.sessionLRU.MoveToBack(existing)
-		return
+// detectEmbeddingDimension returns the hidden width of a runtime-allocated output after
+// checking that its leading dimensions match the batch that produced it.
+func detectEmbeddingDimension(shape ort.Shape, batchSize int, sequenceLength int, pooledOutput bool) (int64, error) {
+	want := ort.Shape{int64(batchSize), int64(sequenceLength), -1}
+	if pooledOutput {
+		want = ort.Shape{int64(batchSize), -1}
+	}
+	if len(shape) != len(want) {
+		return 0, fmt.Errorf("model output must be rank %d, got shape %v", len(want), shape)
+	}
+	for i := 0; i < len(want)-1; i++ {
+		if shape[i] != want[i] {
+			return 0, fmt.Errorf("model output shape %v does not match batch size %d and sequence length %d", shape, batchSize, sequenceLength)
+		}
 	}
-	e.sessionLRUIndex[batchSize] = e.sessionLRU.PushBack(batchSize)
+	dim := shape[len(shape)-1]
+	if dim <= 0 {
+		return 0, fmt.Errorf("model output has no embedding width: shape %v", shape)
+	}
+	return dim, nil
 }
 
-func (e *Embedder) evictLeastRecentlyUsedSessionLocked() error {
-	if e.sessionLRU == nil {
-		return nil
+func validateTokenizedRows(inputIDs [][]int64, attentionMask [][]int64, tokenTypeIDs [][]int64, sequenceLength int, useTokenTypeIDs bool) error {
+	batchSize := len(inputIDs)
+	if attentionMask != nil && len(attentionMask) != batchSize {
+		return fmt.Errorf("attention_mask row count mismatch: got %d, want %d", len(attentionMask), batchSize)
 	}
-	oldest := e.sessionLRU.Front()
-	if oldest == nil {
-		return nil
+	if tokenTypeIDs != nil {
+		if !useTokenTypeIDs {
+			return fmt.Errorf("token_type_ids provided but embedder is configured without a token_type_ids input")
+		}
+		if len(tokenTypeIDs) != batchSize {
+			return fmt.Errorf("token_type_ids row count mismatch: got %d, want %d", len(tokenTypeIDs), batchSize)
+		}
 	}
-	batchSize, ok := oldest.Value.(int)
-	if !ok {
-		return fmt.Errorf("invalid cache bookkeeping value: %T", oldest.Value)
+
+	for row := 0; row < batchSize; row++ {
+		if len(inputIDs[row]) != sequenceLength {
+			return fmt.Errorf("input_ids row %d length mismatch: got %d, want sequence length %d", row, len(inputIDs[row]), sequenceLength)
+		}
+		if attentionMask != nil && len(attentionMask[row]) != sequenceLength {
+			return fmt.Errorf("attention_mask row %d length mismatch: got %d, want sequence length %d", row, len(attentionMask[row]), sequenceLength)
+		}
+		if tokenTypeIDs != nil && len(tokenTypeIDs[row]) != sequenceLength {
+			return fmt.Errorf("token_type_ids row %d length mismatch: got %d, want sequence length %d", row, len(tokenTypeIDs[row]), sequenceLength)
+		}
 	}
-	session := e.sessionsByBatch[batchSize]
-	delete(e.sessionsByBatch, batchSize)
-	delete(e.sessionLRUIndex, batchSize)
-	e.sessionLRU.Remove(oldest)
-	if session == nil {
-		return nil
+	return nil
+}
+
+func fillTokenizedRows(session *embeddingSession, inputIDs [][]int64, attentionMask [][]int64, tokenTypeIDs [][]int64, sequenceLength int, padID int64) error {
+	totalTokens := len(inputIDs) * sequenceLength
+	if len(session.inputIDs) != totalTokens || len(session.attentionMask) != totalTokens {
+		return fmt.Errorf(
+			"token buffer length mismatch: got input_ids=%d attention_mask=%d, want %d",
+			len(session.inputIDs),
+			len(session.attentionMask),
+			totalTokens,
+		)
 	}
-	if err := session.Destroy(); err != nil {
-		return fmt.Errorf("failed to evict batch-%d embedding resources: %w", batchSize, err)
+	if session.tokenTypeIDs != nil && len(session.tokenTypeIDs) != totalTokens {
+		return fmt.Errorf("token_type_ids buffer length mismatch: got %d, want %d", len(session.tokenTypeIDs), totalTokens)
+	}
+
+	clear(session.attentionMask)
+	if session.tokenTypeIDs != nil {
+		clear(session.tokenTypeIDs)
+	}
+
+	for row := range inputIDs {
+		rowStart := row * sequenceLength
+		rowEnd := rowStart + sequenceLength
+		copy(session.inputIDs[rowStart:rowEnd], inputIDs[row])
+		if attentionMask != nil {
+			copy(session.attentionMask[rowStart:rowEnd], attentionMask[row])
+		} else {
+			deriveAttentionMask(session.attentionMask[rowStart:rowEnd], session.inputIDs[rowStart:rowEnd], padID)
+		}
+		if session.tokenTypeIDs != nil && tokenTypeIDs != nil {
+			copy(session.tokenTypeIDs[rowStart:rowEnd], tokenTypeIDs[row])
+		}
 	}
 	return nil
 }
 
-func newEmbeddingSession(modelPath string, inputNames []string, outputNames []string, sequenceLength int, batchSize int, embeddingDimension int64, useTokenTypeIDs bool) (_ *embeddingSession, err error) {
-	totalTokens := batchSize * sequenceLength
+func (e *Embedder) sessionForBatchLocked(spec sessionSpec, batchSize int) (*embeddingSession, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be > 0, got %d", batchSize)
+	}
+	if e.pooledOutput && !e.pooledOutputChecked {
+		_, outputs, err := inspectModel(e.modelPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect model outputs: %w", err)
+		}
+		if err := validatePooledOutput(outputs, e.outputNames[0], e.embeddingDimension); err != nil {
+			return nil, err
+		}
+		e.pooledOutputChecked = true
+	}
+
+	return e.sessions.Get(spec.key(batchSize), func(key sessionKey) (*embeddingSession, error) {
+		session, err := newSession(
+			e.modelPath,
+			e.inputNames,
+			e.outputNames,
+			key.sequenceLength,
+			key.batchSize,
+			e.embeddingDimension,
+			e.useTokenTypeIDs,
+			e.pooledOutput,
+			e.floatAttentionMask,
+			e.outputDataType,
+			spec.providers,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if e.runObserver != nil {
+			session.session.SetRunObserver(e.runObserver)
+		}
+		return session, nil
+	})
+}
+
+func newEmbeddingSession(modelPath string, inputNames []string, outputNames []string, sequenceLength int, batchSize int, embeddingDimension int64, useTokenTypeIDs bool, pooledOutput bool, floatAttentionMask bool, outputDataType ort.TensorElementDataType, providers []ort.ProviderSpec) (_ *embeddingSession, err error) {
+	totalTokens, err := ortutil.CheckedMul(batchSize, sequenceLength)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session size: %w", err)
+	}
 	inputIDs := make([]int64, totalTokens)
 	attentionMask := make([]int64, totalTokens)
 
@@ -449,7 +1704,19 @@ func newEmbeddingSession(modelPath string, inputNames []string, outputNames []st
 	if err != nil {
 		return nil, fmt.Errorf("failed to create input_ids tensor: %w", err)
 	}
-	attentionMaskTensor, err := ort.NewTensor[int64](shape, attentionMask)
+
+	var attentionMaskValue ort.Value
+	var attentionMaskTensor *ort.Tensor[int64]
+	var floatMask []float32
+	var floatAttentionMaskTensor *ort.Tensor[float32]
+	if floatAttentionMask {
+		floatMask = make([]float32, totalTokens)
+		floatAttentionMaskTensor, err = ort.NewTensor[float32](shape, floatMask)
+		attentionMaskValue = floatAttentionMaskTensor
+	} else {
+		attentionMaskTensor, err = ort.NewTensor[int64](shape, attentionMask)
+		attentionMaskValue = attentionMaskTensor
+	}
 	if err != nil {
 		_ = inputIDsTensor.Destroy()
 		return nil, fmt.Errorf("failed to create attention_mask tensor: %w", err)
@@ -461,37 +1728,47 @@ func newEmbeddingSession(modelPath string, inputNames []string, outputNames []st
 		tokenTypeIDs = make([]int64, totalTokens)
 		tokenTypeIDsTensor, err = ort.NewTensor[int64](shape, tokenTypeIDs)
 		if err != nil {
-			_ = attentionMaskTensor.Destroy()
-			_ = inputIDsTensor.Destroy()
+			_ = ortutil.DestroyAll(attentionMaskTensor, floatAttentionMaskTensor, inputIDsTensor)
 			return nil, fmt.Errorf("failed to create token_type_ids tensor: %w", err)
 		}
 	}
 
+	runtimeAllocatedOutput := embeddingDimension <= 0
 	outputShape := ort.Shape{int64(batchSize), int64(sequenceLength), embeddingDimension}
-	outputTensor, err := ort.NewEmptyTensor[float32](outputShape)
+	if pooledOutput {
+		outputShape = ort.Shape{int64(batchSize), embeddingDimension}
+	}
+	var outputTensor *ort.Tensor[float32]
+	var float16OutputTensor *ort.Tensor[ort.Float16]
+	var float64OutputTensor *ort.Tensor[float64]
+	var outputValue ort.Value
+	switch outputDataType {
+	case ort.TensorElementDataTypeFloat16:
+		float16OutputTensor, err = newOutputTensor[ort.Float16](outputShape, runtimeAllocatedOutput)
+		outputValue = float16OutputTensor
+	case ort.TensorElementDataTypeDouble:
+		float64OutputTensor, err = newOutputTensor[float64](outputShape, runtimeAllocatedOutput)
+		outputValue = float64OutputTensor
+	default:
+		outputTensor, err = newOutputTensor[float32](outputShape, runtimeAllocatedOutput)
+		outputValue = outputTensor
+	}
 	if err != nil {
-		cleanupErr := ortutil.DestroyAll(tokenTypeIDsTensor, attentionMaskTensor, inputIDsTensor)
+		cleanupErr := ortutil.DestroyAll(tokenTypeIDsTensor, attentionMaskTensor, floatAttentionMaskTensor, inputIDsTensor)
 		if cleanupErr != nil {
 			return nil, errors.Join(fmt.Errorf("failed to create output tensor: %w", err), fmt.Errorf("failed to clean up session tensors: %w", cleanupErr))
 		}
 		return nil, fmt.Errorf("failed to create output tensor: %w", err)
 	}
 
-	inputValues := []ort.Value{inputIDsTensor, attentionMaskTensor}
+	inputValues := []ort.Value{inputIDsTensor, attentionMaskValue}
 	if tokenTypeIDsTensor != nil {
 		inputValues = append(inputValues, tokenTypeIDsTensor)
 	}
 
-	session, err := ort.NewAdvancedSession(
-		modelPath,
-		inputNames,
-		outputNames,
-		inputValues,
-		[]ort.Value{outputTensor},
-		nil,
-	)
+	session, err := newAdvancedSession(modelPath, inputNames, outputNames, inputValues, outputValue, providers)
 	if err != nil {
-		cleanupErr := ortutil.DestroyAll(outputTensor, tokenTypeIDsTensor, attentionMaskTensor, inputIDsTensor)
+		cleanupErr := ortutil.DestroyAll(outputTensor, float16OutputTensor, float64OutputTensor, tokenTypeIDsTensor, attentionMaskTensor, floatAttentionMaskTensor, inputIDsTensor)
 		if cleanupErr != nil {
 			return nil, errors.Join(fmt.Errorf("failed to create embedding session: %w", err), fmt.Errorf("failed to clean up session tensors: %w", cleanupErr))
 		}
@@ -499,17 +1776,53 @@ func newEmbeddingSession(modelPath string, inputNames []string, outputNames []st
 	}
 
 	return &embeddingSession{
-		inputIDs:            inputIDs,
-		attentionMask:       attentionMask,
-		tokenTypeIDs:        tokenTypeIDs,
-		inputIDsTensor:      inputIDsTensor,
-		attentionMaskTensor: attentionMaskTensor,
-		tokenTypeIDsTensor:  tokenTypeIDsTensor,
-		outputTensor:        outputTensor,
-		session:             session,
+		inputIDs:                 inputIDs,
+		attentionMask:            attentionMask,
+		tokenTypeIDs:             tokenTypeIDs,
+		floatAttentionMask:       floatMask,
+		inputIDsTensor:           inputIDsTensor,
+		attentionMaskTensor:      attentionMaskTensor,
+		floatAttentionMaskTensor: floatAttentionMaskTensor,
+		tokenTypeIDsTensor:       tokenTypeIDsTensor,
+		outputTensor:             outputTensor,
+		float16OutputTensor:      float16OutputTensor,
+		float64OutputTensor:      float64OutputTensor,
+		session:                  session,
+		runtimeAllocatedOutput:   runtimeAllocatedOutput,
 	}, nil
 }
 
+// newAdvancedSession creates the ORT session, on the given execution providers when any
+// are set. The session options are only needed while the session is created.
+func newAdvancedSession(modelPath string, inputNames []string, outputNames []string, inputValues []ort.Value, outputValue ort.Value, providers []ort.ProviderSpec) (*ort.AdvancedSession, error) {
+	if len(providers) == 0 {
+		return ort.NewAdvancedSession(modelPath, inputNames, outputNames, inputValues, []ort.Value{outputValue}, nil)
+	}
+
+	options, err := ort.NewSessionOptions(ort.WithExecutionProviderPriority(providers))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session options: %w", err)
+	}
+	session, err := ort.NewAdvancedSession(modelPath, inputNames, outputNames, inputValues, []ort.Value{outputValue}, options)
+	if destroyErr := options.Destroy(); destroyErr != nil {
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("failed to destroy session options: %w", destroyErr))
+		}
+		// The session does not depend on the options once created.
+		log.Printf("minilm: failed to destroy session options: %v", destroyErr)
+	}
+	return session, err
+}
+
+// newOutputTensor allocates the session output, leaving it to ONNX Runtime when the
+// output width is not known up front.
+func newOutputTensor[T any](shape ort.Shape, runtimeAllocated bool) (*ort.Tensor[T], error) {
+	if runtimeAllocated {
+		return ort.NewRuntimeAllocatedTensor[T]()
+	}
+	return ort.NewEmptyTensor[T](shape)
+}
+
 func (s *embeddingSession) Destroy() error {
 	if s == nil {
 		return nil
@@ -518,18 +1831,26 @@ func (s *embeddingSession) Destroy() error {
 	err := ortutil.DestroyAll(
 		s.session,
 		s.outputTensor,
+		s.float16OutputTensor,
+		s.float64OutputTensor,
 		s.tokenTypeIDsTensor,
 		s.attentionMaskTensor,
+		s.floatAttentionMaskTensor,
 		s.inputIDsTensor,
 	)
 
 	s.inputIDs = nil
 	s.attentionMask = nil
 	s.tokenTypeIDs = nil
+	s.floatAttentionMask = nil
 	s.session = nil
 	s.outputTensor = nil
+	s.float16OutputTensor = nil
+	s.float64OutputTensor = nil
+	s.convertedOutput = nil
 	s.tokenTypeIDsTensor = nil
 	s.attentionMaskTensor = nil
+	s.floatAttentionMaskTensor = nil
 	s.inputIDsTensor = nil
 	return err
 }
@@ -546,10 +1867,36 @@ func (e *Embedder) EmbedQuery(query string) ([]float32, error) {
 	return embeddings[0], nil
 }
 
-func (e *Embedder) tokenizeInto(documents []string, inputIDs []int64, attentionMask []int64, tokenTypeIDs []int64) error {
-	sequenceLength := e.sequenceLength
+// SequenceLengthMismatchError reports a tokenizer that pads or truncates documents to a
+// different length than the session's input tensors, for example a tokenizer shared
+// with WithSharedTokenizer or configured outside NewEmbedder. Use errors.As to inspect it.
+type SequenceLengthMismatchError struct {
+	// Document is the index of the document in the call.
+	Document int
+	// TokenizerLength is the number of tokens the tokenizer produced.
+	TokenizerLength int
+	// SessionLength is the configured sequence length the session tensors are built for.
+	SessionLength int
+}
+
+func (e *SequenceLengthMismatchError) Error() string {
+	return fmt.Sprintf(
+		"tokenizer produced %d tokens for document %d, but the session expects sequence length %d; align the tokenizer's padding and truncation with WithSequenceLength(%d)",
+		e.TokenizerLength,
+		e.Document,
+		e.SessionLength,
+		e.SessionLength,
+	)
+}
+
+// tokenizeInto fills the input buffers for documents, whose first element is document
+// firstRow of the call.
+func (e *Embedder) tokenizeInto(documents []string, firstRow int, sequenceLength int, inputIDs []int64, attentionMask []int64, tokenTypeIDs []int64) error {
 	batchSize := len(documents)
-	totalTokens := batchSize * sequenceLength
+	totalTokens, err := ortutil.CheckedMul(batchSize, sequenceLength)
+	if err != nil {
+		return fmt.Errorf("invalid token buffer size: %w", err)
+	}
 
 	if len(inputIDs) != totalTokens || len(attentionMask) != totalTokens {
 		return fmt.Errorf(
@@ -569,18 +1916,23 @@ func (e *Embedder) tokenizeInto(documents []string, inputIDs []int64, attentionM
 		clear(tokenTypeIDs)
 	}
 
+	shortened := sequenceLength < e.sequenceLength
+	encodeOpts := encodeOptions(tokenTypeIDs != nil, shortened)
+
 	for i, document := range documents {
-		encoding, err := e.tokenizer.Encode(
-			document,
-			tokenizers.WithAddSpecialTokens(),
-			tokenizers.WithReturnAttentionMask(),
-			tokenizers.WithReturnTypeIDs(),
-		)
+		encoding, err := e.tokenizer.Encode(document, encodeOpts...)
 		if err != nil {
-			return fmt.Errorf("failed to tokenize document %d: %w", i, err)
+			return fmt.Errorf("failed to tokenize document %d: %w", firstRow+i, err)
 		}
 		if encoding == nil {
-			return fmt.Errorf("failed to tokenize document %d: empty tokenizer result", i)
+			return fmt.Errorf("failed to tokenize document %d: empty tokenizer result", firstRow+i)
+		}
+		// The tokenizer pads to the configured length; shorter sessions truncate after.
+		if len(encoding.IDs) != e.sequenceLength {
+			return &SequenceLengthMismatchError{Document: firstRow + i, TokenizerLength: len(encoding.IDs), SessionLength: e.sequenceLength}
+		}
+		if shortened {
+			encoding = truncateEncoding(encoding, sequenceLength)
 		}
 
 		rowStart := i * sequenceLength
@@ -590,17 +1942,97 @@ func (e *Embedder) tokenizeInto(documents []string, inputIDs []int64, attentionM
 		if len(encoding.AttentionMask) > 0 {
 			fillUint32AsInt64(attentionMask[rowStart:rowEnd], encoding.AttentionMask)
 		} else {
-			deriveAttentionMask(attentionMask[rowStart:rowEnd], inputIDs[rowStart:rowEnd])
+			deriveAttentionMask(attentionMask[rowStart:rowEnd], inputIDs[rowStart:rowEnd], e.padID)
 		}
 
 		if tokenTypeIDs != nil && len(encoding.TypeIDs) > 0 {
 			fillUint32AsInt64(tokenTypeIDs[rowStart:rowEnd], encoding.TypeIDs)
 		}
+
+		if e.paddingSide == PaddingSideLeft {
+			var typeIDsRow []int64
+			if tokenTypeIDs != nil {
+				typeIDsRow = tokenTypeIDs[rowStart:rowEnd]
+			}
+			tokenizerutil.MovePaddingLeft(inputIDs[rowStart:rowEnd], attentionMask[rowStart:rowEnd], typeIDsRow)
+		}
 	}
 
 	return nil
 }
 
+// encodeOptions returns the tokenizer options for one encode call. Type ids are only
+// requested when the model takes a token_type_ids input, and the special tokens mask only
+// when the encoding must be shortened with truncateEncoding.
+func encodeOptions(typeIDs bool, specialTokensMask bool) []tokenizers.EncodeOption {
+	opts := []tokenizers.EncodeOption{
+		tokenizers.WithAddSpecialTokens(),
+		tokenizers.WithReturnAttentionMask(),
+	}
+	if typeIDs {
+		opts = append(opts, tokenizers.WithReturnTypeIDs())
+	}
+	if specialTokensMask {
+		opts = append(opts, tokenizers.WithReturnSpecialTokensMask())
+	}
+	return opts
+}
+
+// truncateEncoding shortens an encoding padded to the configured sequence length so its
+// attended tokens fit in length. Content tokens are dropped from the right while the
+// trailing special tokens (such as [SEP]) are kept, mirroring tokenizer-side truncation.
+// Encodings that already fit are returned unchanged; callers copy only the first length
+// positions.
+func truncateEncoding(encoding *tokenizers.EncodeResult, length int) *tokenizers.EncodeResult {
+	attended := 0
+	for i, id := range encoding.IDs {
+		attendedToken := id != 0
+		if len(encoding.AttentionMask) > i {
+			attendedToken = encoding.AttentionMask[i] != 0
+		}
+		if attendedToken {
+			attended = i + 1
+		}
+	}
+	if attended <= length {
+		return encoding
+	}
+
+	suffix := 0
+	if special := encoding.SpecialTokensMask; len(special) >= attended {
+		for suffix < attended && special[attended-1-suffix] != 0 {
+			suffix++
+		}
+	}
+	if suffix >= length {
+		suffix = 0
+	}
+	keep := make([]int, 0, length)
+	for i := 0; i < length-suffix; i++ {
+		keep = append(keep, i)
+	}
+	for i := attended - suffix; i < attended; i++ {
+		keep = append(keep, i)
+	}
+
+	pick := func(values []uint32) []uint32 {
+		if len(values) == 0 {
+			return nil
+		}
+		picked := make([]uint32, len(keep))
+		for j, i := range keep {
+			picked[j] = values[i]
+		}
+		return picked
+	}
+	return &tokenizers.EncodeResult{
+		IDs:               pick(encoding.IDs),
+		TypeIDs:           pick(encoding.TypeIDs),
+		SpecialTokensMask: pick(encoding.SpecialTokensMask),
+		AttentionMask:     pick(encoding.AttentionMask),
+	}
+}
+
 func fillUint32AsInt64(dst []int64, src []uint32) {
 	if len(dst) == 0 || len(src) == 0 {
 		return
@@ -614,19 +2046,21 @@ func fillUint32AsInt64(dst []int64, src []uint32) {
 	}
 }
 
-func deriveAttentionMask(dst []int64, tokenIDs []int64) {
+// deriveAttentionMask marks every position whose token id differs from padID, for
+// tokenizer results that carry no attention mask.
+func deriveAttentionMask(dst []int64, tokenIDs []int64, padID int64) {
 	for i := range dst {
-		if tokenIDs[i] != 0 {
+		if tokenIDs[i] != padID {
 			dst[i] = 1
 		}
 	}
 }
 
 func meanPoolAndNormalize(lastHiddenState []float32, attentionMask []int64, batchSize int, sequenceLength int, embeddingDim int64) ([][]float32, error) {
-	return postProcessDenseOutput(lastHiddenState, attentionMask, batchSize, sequenceLength, embeddingDim, PoolingStrategyMean, true)
+	return postProcessDenseOutput(nil, lastHiddenState, attentionMask, batchSize, sequenceLength, embeddingDim, PoolingStrategyMean, true, false)
 }
 
-func postProcessDenseOutput(lastHiddenState []float32, attentionMask []int64, batchSize int, sequenceLength int, embeddingDim int64, poolingStrategy PoolingStrategy, l2Normalize bool) ([][]float32, error) {
+func postProcessDenseOutput(dst [][]float32, lastHiddenState []float32, attentionMask []int64, batchSize int, sequenceLength int, embeddingDim int64, poolingStrategy PoolingStrategy, l2Normalize bool, highPrecision bool) ([][]float32, error) {
 	dim, err := validateDenseOutput(lastHiddenState, attentionMask, batchSize, sequenceLength, embeddingDim)
 	if err != nil {
 		return nil, err
@@ -635,11 +2069,17 @@ func postProcessDenseOutput(lastHiddenState []float32, attentionMask []int64, ba
 	var embeddings [][]float32
 	switch poolingStrategy {
 	case PoolingStrategyMean:
-		embeddings = meanPoolTokenEmbeddings(lastHiddenState, attentionMask, batchSize, sequenceLength, dim)
+		if highPrecision {
+			embeddings = meanPoolTokenEmbeddingsFloat64(dst, lastHiddenState, attentionMask, batchSize, sequenceLength, dim)
+		} else {
+			embeddings = meanPoolTokenEmbeddings(dst, lastHiddenState, attentionMask, batchSize, sequenceLength, dim)
+		}
 	case PoolingStrategyCLS:
-		embeddings = clsPoolTokenEmbeddings(lastHiddenState, batchSize, sequenceLength, dim)
+		embeddings = clsPoolTokenEmbeddings(dst, lastHiddenState, attentionMask, batchSize, sequenceLength, dim)
 	case PoolingStrategyNone:
-		embeddings = flattenTokenEmbeddings(lastHiddenState, batchSize, sequenceLength, dim)
+		embeddings = flattenTokenEmbeddings(dst, lastHiddenState, batchSize, sequenceLength, dim)
+	case poolingStrategyTokens:
+		embeddings = attendedTokenEmbeddings(lastHiddenState, attentionMask, batchSize, sequenceLength, dim)
 	default:
 		return nil, fmt.Errorf("unsupported pooling strategy: %q", poolingStrategy)
 	}
@@ -651,7 +2091,34 @@ func postProcessDenseOutput(lastHiddenState []float32, attentionMask []int64, ba
 	return embeddings, nil
 }
 
-func validateDenseOutput(lastHiddenState []float32, attentionMask []int64, batchSize int, sequenceLength int, embeddingDim int64) (int, error) {
+// postProcessPooledOutput splits an already-pooled [batch, dim] output into rows.
+func postProcessPooledOutput(dst [][]float32, pooled []float32, batchSize int, embeddingDim int64, l2Normalize bool) ([][]float32, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be > 0, got %d", batchSize)
+	}
+	if embeddingDim <= 0 {
+		return nil, fmt.Errorf("embedding dim must be > 0, got %d", embeddingDim)
+	}
+	dim := int(embeddingDim)
+	if len(pooled) != batchSize*dim {
+		return nil, fmt.Errorf("pooled output length mismatch: got %d, want %d", len(pooled), batchSize*dim)
+	}
+
+	embeddings := embeddingRows(dst, batchSize)
+	for row := 0; row < batchSize; row++ {
+		embedding := embeddingRow(dst, row, dim)
+		copy(embedding, pooled[row*dim:(row+1)*dim])
+		embeddings[row] = embedding
+	}
+
+	if l2Normalize {
+		l2NormalizeRows(embeddings)
+	}
+
+	return embeddings, nil
+}
+
+func validateDenseOutput[T float32 | float64](lastHiddenState []T, attentionMask []int64, batchSize int, sequenceLength int, embeddingDim int64) (int, error) {
 	if batchSize <= 0 {
 		return 0, fmt.Errorf("batch size must be > 0, got %d", batchSize)
 	}
@@ -676,10 +2143,10 @@ func validateDenseOutput(lastHiddenState []float32, attentionMask []int64, batch
 	return dim, nil
 }
 
-func meanPoolTokenEmbeddings(lastHiddenState []float32, attentionMask []int64, batchSize int, sequenceLength int, dim int) [][]float32 {
-	embeddings := make([][]float32, batchSize)
+func meanPoolTokenEmbeddings(dst [][]float32, lastHiddenState []float32, attentionMask []int64, batchSize int, sequenceLength int, dim int) [][]float32 {
+	embeddings := embeddingRows(dst, batchSize)
 	for row := 0; row < batchSize; row++ {
-		embedding := make([]float32, dim)
+		embedding := embeddingRow(dst, row, dim)
 		rowMaskOffset := row * sequenceLength
 
 		denominator := float32(0)
@@ -710,37 +2177,160 @@ func meanPoolTokenEmbeddings(lastHiddenState []float32, attentionMask []int64, b
 	return embeddings
 }
 
-func clsPoolTokenEmbeddings(lastHiddenState []float32, batchSize int, sequenceLength int, dim int) [][]float32 {
-	embeddings := make([][]float32, batchSize)
+// meanPoolFloat64Output mean-pools a float64 [batch, seq, dim] output with float64
+// accumulators, narrowing only the pooled rows to float32.
+func meanPoolFloat64Output(dst [][]float32, lastHiddenState []float64, attentionMask []int64, batchSize int, sequenceLength int, embeddingDim int64, l2Normalize bool) ([][]float32, error) {
+	dim, err := validateDenseOutput(lastHiddenState, attentionMask, batchSize, sequenceLength, embeddingDim)
+	if err != nil {
+		return nil, err
+	}
+	embeddings := meanPoolTokenEmbeddingsFloat64(dst, lastHiddenState, attentionMask, batchSize, sequenceLength, dim)
+	if l2Normalize {
+		l2NormalizeRows(embeddings)
+	}
+	return embeddings, nil
+}
+
+// meanPoolTokenEmbeddingsFloat64 mirrors meanPoolTokenEmbeddings with float64 accumulators.
+func meanPoolTokenEmbeddingsFloat64[T float32 | float64](dst [][]float32, lastHiddenState []T, attentionMask []int64, batchSize int, sequenceLength int, dim int) [][]float32 {
+	embeddings := embeddingRows(dst, batchSize)
+	sums := make([]float64, dim)
+	for row := 0; row < batchSize; row++ {
+		clear(sums)
+		rowMaskOffset := row * sequenceLength
+
+		denominator := 0.0
+		for tokenIndex := 0; tokenIndex < sequenceLength; tokenIndex++ {
+			mask := attentionMask[rowMaskOffset+tokenIndex]
+			if mask == 0 {
+				continue
+			}
+			weight := float64(mask)
+			denominator += weight
+
+			hiddenOffset := (rowMaskOffset + tokenIndex) * dim
+			for d := 0; d < dim; d++ {
+				sums[d] += float64(lastHiddenState[hiddenOffset+d]) * weight
+			}
+		}
+
+		if denominator < float64(poolingDenominatorEpsilon) {
+			denominator = float64(poolingDenominatorEpsilon)
+		}
+		embedding := embeddingRow(dst, row, dim)
+		for d := 0; d < dim; d++ {
+			embedding[d] = float32(sums[d] / denominator)
+		}
+
+		embeddings[row] = embedding
+	}
+	return embeddings
+}
+
+// clsPoolTokenEmbeddings takes each row's first attended token, which is the CLS token
+// at position 0 for right padding and follows the padding for left padding. Rows with
+// no attended tokens fall back to position 0.
+func clsPoolTokenEmbeddings(dst [][]float32, lastHiddenState []float32, attentionMask []int64, batchSize int, sequenceLength int, dim int) [][]float32 {
+	embeddings := embeddingRows(dst, batchSize)
 	stride := sequenceLength * dim
 	for row := 0; row < batchSize; row++ {
-		rowStart := row * stride
-		embedding := make([]float32, dim)
-		copy(embedding, lastHiddenState[rowStart:rowStart+dim])
+		token := 0
+		if mask := attentionMask[row*sequenceLength : (row+1)*sequenceLength]; mask[0] == 0 {
+			if first := slices.IndexFunc(mask, func(v int64) bool { return v != 0 }); first > 0 {
+				token = first
+			}
+		}
+		tokenStart := row*stride + token*dim
+		embedding := embeddingRow(dst, row, dim)
+		copy(embedding, lastHiddenState[tokenStart:tokenStart+dim])
 		embeddings[row] = embedding
 	}
 	return embeddings
 }
 
-func flattenTokenEmbeddings(lastHiddenState []float32, batchSize int, sequenceLength int, dim int) [][]float32 {
-	embeddings := make([][]float32, batchSize)
+func flattenTokenEmbeddings(dst [][]float32, lastHiddenState []float32, batchSize int, sequenceLength int, dim int) [][]float32 {
+	embeddings := embeddingRows(dst, batchSize)
 	stride := sequenceLength * dim
 	for row := 0; row < batchSize; row++ {
 		rowStart := row * stride
-		embedding := make([]float32, stride)
+		embedding := embeddingRow(dst, row, stride)
 		copy(embedding, lastHiddenState[rowStart:rowStart+stride])
 		embeddings[row] = embedding
 	}
 	return embeddings
 }
 
-func l2NormalizeRows(embeddings [][]float32) {
+// attendedTokenEmbeddings flattens each row's attended token vectors, skipping masked positions.
+func attendedTokenEmbeddings(lastHiddenState []float32, attentionMask []int64, batchSize int, sequenceLength int, dim int) [][]float32 {
+	embeddings := make([][]float32, batchSize)
+	for row := 0; row < batchSize; row++ {
+		rowMaskOffset := row * sequenceLength
+		embedding := make([]float32, 0, sequenceLength*dim)
+		for tokenIndex := 0; tokenIndex < sequenceLength; tokenIndex++ {
+			if attentionMask[rowMaskOffset+tokenIndex] == 0 {
+				continue
+			}
+			hiddenOffset := (rowMaskOffset + tokenIndex) * dim
+			embedding = append(embedding, lastHiddenState[hiddenOffset:hiddenOffset+dim]...)
+		}
+		embeddings[row] = embedding
+	}
+	return embeddings
+}
+
+// embeddingRows returns the slice that holds a batch's rows: dst itself when writing
+// into a caller buffer, otherwise a new slice.
+func embeddingRows(dst [][]float32, batchSize int) [][]float32 {
+	if dst != nil {
+		return dst[:batchSize]
+	}
+	return make([][]float32, batchSize)
+}
+
+// embeddingRow returns a zeroed row of width elements, reusing dst[row] when writing into
+// a caller buffer. Callers check the dst row widths beforehand (see checkDestinationRows).
+func embeddingRow(dst [][]float32, row int, width int) []float32 {
+	if dst != nil {
+		embedding := dst[row][:width]
+		clear(embedding)
+		return embedding
+	}
+	return make([]float32, width)
+}
+
+func l2Norm(values []float32) float32 {
+	normSquared := 0.0
+	for _, value := range values {
+		normSquared += float64(value * value)
+	}
+	return float32(math.Sqrt(normSquared))
+}
+
+// maxNormalizeRows divides each row by its largest absolute component.
+func maxNormalizeRows(embeddings [][]float32) {
 	for row := range embeddings {
-		normSquared := 0.0
+		var norm float32
 		for _, value := range embeddings[row] {
-			normSquared += float64(value * value)
+			if value < 0 {
+				value = -value
+			}
+			if value > norm {
+				norm = value
+			}
+		}
+		if norm < l2NormEpsilon {
+			norm = l2NormEpsilon
+		}
+		invNorm := float32(1.0) / norm
+		for i := range embeddings[row] {
+			embeddings[row][i] *= invNorm
 		}
-		norm := float32(math.Sqrt(normSquared))
+	}
+}
+
+func l2NormalizeRows(embeddings [][]float32) {
+	for row := range embeddings {
+		norm := l2Norm(embeddings[row])
 		if norm < l2NormEpsilon {
 			norm = l2NormEpsilon
 		}
@@ -3,17 +3,22 @@ package minilm
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"math"
-	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/amikos-tech/pure-onnx/embeddings"
+	"github.com/amikos-tech/pure-onnx/embeddings/internal/ortutil"
 	"github.com/amikos-tech/pure-onnx/ort"
+	tokenizers "github.com/amikos-tech/pure-tokenizers"
 )
 
 const (
@@ -38,29 +43,24 @@ var expectedThisIsATestEmbeddingPrefix = []float32{
 func resolveMiniLMAssets(t *testing.T) (modelPath string, tokenizerPath string) {
 	t.Helper()
 
-	modelPath = resolveAssetPath(
-		t,
-		"ONNXRUNTIME_TEST_ALL_MINILM_MODEL_PATH",
-		"ONNXRUNTIME_TEST_ALL_MINILM_MODEL_URL",
-		"ONNXRUNTIME_TEST_ALL_MINILM_MODEL_SHA256",
-		allMiniLMModelURL,
-		allMiniLMModelSHA256,
-		"models",
-		"all-MiniLM-L6-v2.onnx",
-	)
+	return resolveTestBundle(t, "ONNXRUNTIME_TEST_ALL_MINILM", embeddings.BundleSpec{
+		Name:            "all-MiniLM-L6-v2",
+		ModelURL:        allMiniLMModelURL,
+		ModelSHA256:     allMiniLMModelSHA256,
+		TokenizerURL:    allMiniLMTokenizerURL,
+		TokenizerSHA256: allMiniLMTokenizerSHA256,
+	})
+}
 
-	tokenizerPath = resolveAssetPath(
-		t,
-		"ONNXRUNTIME_TEST_ALL_MINILM_TOKENIZER_PATH",
-		"ONNXRUNTIME_TEST_ALL_MINILM_TOKENIZER_URL",
-		"ONNXRUNTIME_TEST_ALL_MINILM_TOKENIZER_SHA256",
-		allMiniLMTokenizerURL,
-		allMiniLMTokenizerSHA256,
-		"tokenizers",
-		"all-MiniLM-L6-v2-tokenizer.json",
-	)
+// cachedSession returns the cached session for batchSize at the configured sequence length.
+func cachedSession(e *Embedder, batchSize int) *embeddingSession {
+	return cachedSessionFor(e, e.defaultSessionSpec().key(batchSize))
+}
 
-	return modelPath, tokenizerPath
+// cachedSessionFor returns the cached session for key, or nil.
+func cachedSessionFor(e *Embedder, key sessionKey) *embeddingSession {
+	session, _ := e.sessions.Lookup(key)
+	return session
 }
 
 func TestEmbedDocumentsWithAllMiniLML6V2(t *testing.T) {
@@ -87,10 +87,10 @@ func TestEmbedDocumentsWithAllMiniLML6V2(t *testing.T) {
 	if len(embeddings) != len(documents) {
 		t.Fatalf("unexpected embedding row count: got %d, want %d", len(embeddings), len(documents))
 	}
-	if len(embedder.sessionsByBatch) != 1 {
-		t.Fatalf("expected exactly one cached session after first batch run, got %d", len(embedder.sessionsByBatch))
+	if embedder.sessions.Len() != 1 {
+		t.Fatalf("expected exactly one cached session after first batch run, got %d", embedder.sessions.Len())
 	}
-	batchTwoSession := embedder.sessionsByBatch[len(documents)]
+	batchTwoSession := cachedSession(embedder, len(documents))
 	if batchTwoSession == nil {
 		t.Fatalf("missing cached session for batch size %d", len(documents))
 	}
@@ -110,10 +110,10 @@ func TestEmbedDocumentsWithAllMiniLML6V2(t *testing.T) {
 	if len(queryEmbedding) != int(OutputEmbeddingDimension) {
 		t.Fatalf("unexpected query embedding width: got %d, want %d", len(queryEmbedding), OutputEmbeddingDimension)
 	}
-	if len(embedder.sessionsByBatch) != 2 {
-		t.Fatalf("expected two cached sessions after single-query run, got %d", len(embedder.sessionsByBatch))
+	if embedder.sessions.Len() != 2 {
+		t.Fatalf("expected two cached sessions after single-query run, got %d", embedder.sessions.Len())
 	}
-	batchOneSession := embedder.sessionsByBatch[1]
+	batchOneSession := cachedSession(embedder, 1)
 	if batchOneSession == nil {
 		t.Fatalf("missing cached session for batch size 1")
 	}
@@ -132,19 +132,96 @@ func TestEmbedDocumentsWithAllMiniLML6V2(t *testing.T) {
 	if len(singleDocEmbeddings) != 1 {
 		t.Fatalf("unexpected single-doc row count: got %d, want 1", len(singleDocEmbeddings))
 	}
-	if len(embedder.sessionsByBatch) != 2 {
-		t.Fatalf("expected session cache size to remain 2 after repeated single-doc call, got %d", len(embedder.sessionsByBatch))
+	if embedder.sessions.Len() != 2 {
+		t.Fatalf("expected session cache size to remain 2 after repeated single-doc call, got %d", embedder.sessions.Len())
 	}
-	if embedder.sessionsByBatch[1] != batchOneSession {
+	if cachedSession(embedder, 1) != batchOneSession {
 		t.Fatalf("expected batch size 1 session to be reused")
 	}
-	if embedder.sessionsByBatch[len(documents)] != batchTwoSession {
+	if cachedSession(embedder, len(documents)) != batchTwoSession {
 		t.Fatalf("expected batch size %d session to remain cached", len(documents))
 	}
 
 	assertVectorNear(t, "EmbedQuery parity", queryEmbedding, singleDocEmbeddings[0], 1e-6)
 }
 
+func TestInfoMatchesConstructorConfiguration(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+	embedder, err := NewEmbedder(modelPath, tokenizerPath, WithSequenceLength(128), WithCLSPooling(), WithPaddingSide(PaddingSideLeft))
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	defer func() {
+		if err := embedder.Close(); err != nil {
+			t.Errorf("failed to close embedder: %v", err)
+		}
+	}()
+
+	info := embedder.Info()
+	if info.ModelPath != modelPath || info.TokenizerPath != tokenizerPath {
+		t.Fatalf("unexpected paths in info: %+v", info)
+	}
+	if info.SequenceLength != 128 || info.PoolingStrategy != PoolingStrategyCLS || info.PaddingSide != PaddingSideLeft {
+		t.Fatalf("unexpected configuration in info: %+v", info)
+	}
+	if !info.L2Normalize || info.EmbeddingDimension != OutputEmbeddingDimension {
+		t.Fatalf("unexpected output configuration in info: %+v", info)
+	}
+	wantHash, err := ort.ModelSHA256(modelPath)
+	if err != nil {
+		t.Fatalf("ModelSHA256 failed: %v", err)
+	}
+	if info.ModelSHA256 != wantHash || len(info.ModelSHA256) != 64 {
+		t.Fatalf("unexpected model hash: got %q, want %q", info.ModelSHA256, wantHash)
+	}
+}
+
+func TestEmbedderConstructionReusesModelInfo(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	inspections := 0
+	originalInspectModel := inspectModel
+	inspectModel = func(path string) ([]ort.InputOutputInfo, []ort.InputOutputInfo, error) {
+		inspections++
+		return originalInspectModel(path)
+	}
+	defer func() { inspectModel = originalInspectModel }()
+
+	for i := 0; i < 2; i++ {
+		embedder, err := NewEmbedder(modelPath, tokenizerPath)
+		if err != nil {
+			t.Fatalf("embedder %d: NewEmbedder failed: %v", i, err)
+		}
+		if err := embedder.Close(); err != nil {
+			t.Fatalf("embedder %d: Close failed: %v", i, err)
+		}
+	}
+	if inspections != 2 {
+		t.Fatalf("expected each construction to introspect through the cached model info once, got %d inspections", inspections)
+	}
+}
+
+func TestNewEmbedderRejectsSequenceLengthAboveAllMiniLMPositions(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	maxLength, ok := ortutil.MaxSequenceLength(modelPath)
+	if !ok || maxLength != 512 {
+		t.Fatalf("expected all-MiniLM-L6-v2 to have 512 position embeddings, got %d, %v", maxLength, ok)
+	}
+	if _, err := NewEmbedder(modelPath, tokenizerPath, WithSequenceLength(1024)); err == nil || !strings.Contains(err.Error(), "exceeds the model's 512 position embeddings") {
+		t.Fatalf("expected oversized sequence length error, got: %v", err)
+	}
+}
+
 func TestEmbedderSessionCacheRespectsLRUBound(t *testing.T) {
 	cleanup := setupORTTestEnvironment(t)
 	defer cleanup()
@@ -167,10 +244,10 @@ func TestEmbedderSessionCacheRespectsLRUBound(t *testing.T) {
 	if _, err := embedder.EmbedDocuments([]string{"doc-1", "doc-2"}); err != nil {
 		t.Fatalf("second batch run failed: %v", err)
 	}
-	if len(embedder.sessionsByBatch) != 2 {
-		t.Fatalf("expected two cached sessions after warm-up, got %d", len(embedder.sessionsByBatch))
+	if embedder.sessions.Len() != 2 {
+		t.Fatalf("expected two cached sessions after warm-up, got %d", embedder.sessions.Len())
 	}
-	sessionBatchOne := embedder.sessionsByBatch[1]
+	sessionBatchOne := cachedSession(embedder, 1)
 	if sessionBatchOne == nil {
 		t.Fatalf("missing cached session for batch size 1")
 	}
@@ -184,241 +261,1228 @@ func TestEmbedderSessionCacheRespectsLRUBound(t *testing.T) {
 	if _, err := embedder.EmbedDocuments([]string{"doc-4", "doc-5", "doc-6"}); err != nil {
 		t.Fatalf("fourth batch run failed: %v", err)
 	}
-	if len(embedder.sessionsByBatch) != 2 {
-		t.Fatalf("expected cache size to remain 2 after eviction, got %d", len(embedder.sessionsByBatch))
+	if embedder.sessions.Len() != 2 {
+		t.Fatalf("expected cache size to remain 2 after eviction, got %d", embedder.sessions.Len())
 	}
-	if embedder.sessionsByBatch[1] != sessionBatchOne {
+	if cachedSession(embedder, 1) != sessionBatchOne {
 		t.Fatalf("expected batch size 1 session to remain cached as recently used")
 	}
-	if _, ok := embedder.sessionsByBatch[2]; ok {
+	if cachedSession(embedder, 2) != nil {
 		t.Fatalf("expected batch size 2 session to be evicted")
 	}
-	if embedder.sessionsByBatch[3] == nil {
+	if cachedSession(embedder, 3) == nil {
 		t.Fatalf("expected batch size 3 session to be cached")
 	}
 }
 
-func setupORTTestEnvironment(tb testing.TB) func() {
-	tb.Helper()
+func TestEmbedTokenizedWithAllMiniLML6V2(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
 
-	libPath := os.Getenv("ONNXRUNTIME_LIB_PATH")
-	if libPath == "" {
-		tb.Skip("ONNXRUNTIME_LIB_PATH not set, skipping integration test")
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	const sequenceLength = 8
+	embedder, err := NewEmbedder(modelPath, tokenizerPath, WithSequenceLength(sequenceLength))
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
 	}
+	defer func() {
+		if err := embedder.Close(); err != nil {
+			t.Errorf("failed to close embedder: %v", err)
+		}
+	}()
 
-	if err := ort.SetSharedLibraryPath(libPath); err != nil {
-		tb.Fatalf("failed to set ONNX Runtime library path: %v", err)
+	// [CLS] this is a test [SEP] followed by padding, and [CLS] hello [SEP].
+	inputIDs := [][]int64{
+		{101, 2023, 2003, 1037, 3231, 102, 0, 0},
+		{101, 7592, 102, 0, 0, 0, 0, 0},
 	}
-	if err := ort.InitializeEnvironment(); err != nil {
-		tb.Fatalf("failed to initialize ONNX Runtime: %v", err)
+	embeddings, err := embedder.EmbedTokenized(inputIDs, nil, nil)
+	if err != nil {
+		t.Fatalf("EmbedTokenized failed: %v", err)
 	}
-
-	return func() {
-		if err := ort.DestroyEnvironment(); err != nil {
-			tb.Errorf("failed to destroy ONNX Runtime environment: %v", err)
+	if len(embeddings) != len(inputIDs) {
+		t.Fatalf("unexpected embedding count: got %d, want %d", len(embeddings), len(inputIDs))
+	}
+	for i, embedding := range embeddings {
+		if int64(len(embedding)) != OutputEmbeddingDimension {
+			t.Fatalf("unexpected embedding dimension at %d: got %d, want %d", i, len(embedding), OutputEmbeddingDimension)
 		}
 	}
-}
 
-func resolveAssetPath(tb testing.TB, envPathKey string, envURLKey string, envSHAKey string, defaultURL string, defaultSHA string, cacheSubdir string, cacheFilename string) string {
-	tb.Helper()
+	documentEmbeddings, err := embedder.EmbedDocuments([]string{"This is a test", "hello"})
+	if err != nil {
+		t.Fatalf("EmbedDocuments failed: %v", err)
+	}
+	assertVectorNear(t, "EmbedTokenized parity", embeddings[0], documentEmbeddings[0], 1e-6)
 
-	if path := os.Getenv(envPathKey); path != "" {
-		if _, err := os.Stat(path); err != nil {
-			tb.Fatalf("%s %q is not usable: %v", envPathKey, path, err)
-		}
-		if expectedSHA := strings.TrimSpace(os.Getenv(envSHAKey)); expectedSHA != "" {
-			if err := verifyFileSHA256(path, expectedSHA); err != nil {
-				tb.Fatalf("%s failed checksum validation: %v", envPathKey, err)
-			}
-		}
-		return path
+	if _, err := embedder.EmbedTokenized([][]int64{{101, 102}}, nil, nil); err == nil || !strings.Contains(err.Error(), "length mismatch") {
+		t.Fatalf("expected row length mismatch error, got: %v", err)
 	}
+}
 
-	cacheRoot := os.Getenv("ONNXRUNTIME_TEST_MODEL_CACHE_DIR")
-	if cacheRoot == "" {
-		userCacheDir, err := os.UserCacheDir()
-		if err != nil {
-			tb.Skipf("cannot determine user cache directory: %v; set %s", err, envPathKey)
-		}
-		cacheRoot = filepath.Join(userCacheDir, "onnx-purego")
+func TestEmbedDocumentsDetailedWithAllMiniLML6V2(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	embedder, err := NewEmbedder(modelPath, tokenizerPath)
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
 	}
+	defer func() {
+		if err := embedder.Close(); err != nil {
+			t.Errorf("failed to close embedder: %v", err)
+		}
+	}()
 
-	assetPath := filepath.Join(cacheRoot, cacheSubdir, cacheFilename)
-	if err := os.MkdirAll(filepath.Dir(assetPath), 0o755); err != nil {
-		tb.Fatalf("failed to create cache directory: %v", err)
+	documents := []string{"This is a test", "local inference only"}
+	first, err := embedder.EmbedDocumentsDetailed(documents)
+	if err != nil {
+		t.Fatalf("EmbedDocumentsDetailed failed: %v", err)
+	}
+	if first.BatchSize != len(documents) {
+		t.Fatalf("unexpected batch size: got %d, want %d", first.BatchSize, len(documents))
+	}
+	if len(first.Embeddings) != len(documents) {
+		t.Fatalf("unexpected embedding count: got %d, want %d", len(first.Embeddings), len(documents))
+	}
+	if first.InferenceDuration <= 0 {
+		t.Fatalf("expected positive inference duration, got %s", first.InferenceDuration)
+	}
+	if first.CacheHit {
+		t.Fatalf("expected first run for batch size %d to miss the session cache", len(documents))
 	}
 
-	url := os.Getenv(envURLKey)
-	if url == "" {
-		url = defaultURL
+	second, err := embedder.EmbedDocumentsDetailed(documents)
+	if err != nil {
+		t.Fatalf("second EmbedDocumentsDetailed failed: %v", err)
 	}
-	expectedSHA := strings.TrimSpace(os.Getenv(envSHAKey))
-	if expectedSHA == "" && url == defaultURL {
-		expectedSHA = defaultSHA
+	if !second.CacheHit {
+		t.Fatalf("expected second run for batch size %d to hit the session cache", len(documents))
 	}
-	expectedSHA = strings.ToLower(expectedSHA)
+	assertVectorNear(t, "detailed repeatability", second.Embeddings[0], first.Embeddings[0], 1e-6)
+}
 
-	if info, err := os.Stat(assetPath); err == nil && info.Size() > 0 {
-		if expectedSHA == "" {
-			return assetPath
-		}
-		if err := verifyFileSHA256(assetPath, expectedSHA); err == nil {
-			return assetPath
-		}
-		if removeErr := os.Remove(assetPath); removeErr != nil {
-			tb.Fatalf("failed to remove stale cached asset: %v", removeErr)
-		}
+func TestEmbedDocumentsWithOverridesPooling(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	meanEmbedder, err := NewEmbedder(modelPath, tokenizerPath)
+	if err != nil {
+		t.Fatalf("failed to create mean embedder: %v", err)
+	}
+	defer func() {
+		_ = meanEmbedder.Close()
+	}()
+	clsEmbedder, err := NewEmbedder(modelPath, tokenizerPath, WithCLSPooling(), WithoutL2Normalization())
+	if err != nil {
+		t.Fatalf("failed to create CLS embedder: %v", err)
 	}
+	defer func() {
+		_ = clsEmbedder.Close()
+	}()
 
-	tb.Logf("downloading test asset from %s", url)
-	if err := downloadFile(assetPath, url); err != nil {
-		tb.Skipf("unable to download test asset from %s: %v", url, err)
+	documents := []string{"This is a test", "local inference only"}
+	disabled := false
+	overridden, err := meanEmbedder.EmbedDocumentsWith(RuntimeOpts{PoolingStrategy: PoolingStrategyCLS, L2Normalize: &disabled}, documents)
+	if err != nil {
+		t.Fatalf("EmbedDocumentsWith failed: %v", err)
 	}
-	if expectedSHA != "" {
-		if err := verifyFileSHA256(assetPath, expectedSHA); err != nil {
-			_ = os.Remove(assetPath)
-			tb.Fatalf("downloaded asset failed checksum validation: %v", err)
-		}
+	want, err := clsEmbedder.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("CLS EmbedDocuments failed: %v", err)
+	}
+	for i := range documents {
+		assertVectorNear(t, fmt.Sprintf("override row %d", i), overridden[i], want[i], 1e-6)
 	}
 
-	return assetPath
+	// The override applies to that call only.
+	configured, err := meanEmbedder.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("EmbedDocuments failed: %v", err)
+	}
+	assertApproxUnitNorm(t, "configured mean row", configured[0], 1e-4)
+	assertPrefixNear(t, "configured mean row", configured[0], expectedThisIsATestEmbeddingPrefix, 1e-4)
 }
 
-func downloadFile(destinationPath string, assetURL string) error {
-	const maxAttempts = 3
+func TestEmbedTokenEmbeddingsWithAllMiniLML6V2(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	embedder, err := NewEmbedder(modelPath, tokenizerPath)
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	defer func() {
+		_ = embedder.Close()
+	}()
 
-	var lastErr error
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		if attempt > 1 {
-			backoff := time.Second * time.Duration(1<<(attempt-1))
-			if backoff > 8*time.Second {
-				backoff = 8 * time.Second
+	documents := []string{"This is a test", "hi"}
+	tokens, err := embedder.EmbedTokenEmbeddings(documents)
+	if err != nil {
+		t.Fatalf("EmbedTokenEmbeddings failed: %v", err)
+	}
+	if len(tokens) != len(documents) {
+		t.Fatalf("expected %d documents, got %d", len(documents), len(tokens))
+	}
+	// [CLS] this is a test [SEP] and [CLS] hi [SEP]; padding up to the sequence length is dropped.
+	for i, wantTokens := range []int{6, 3} {
+		if len(tokens[i]) != wantTokens {
+			t.Fatalf("document %d: expected %d attended tokens, got %d", i, wantTokens, len(tokens[i]))
+		}
+		for j, vector := range tokens[i] {
+			if len(vector) != 384 {
+				t.Fatalf("document %d token %d: expected 384 dims, got %d", i, j, len(vector))
 			}
-			time.Sleep(backoff)
 		}
+	}
 
-		err := downloadFileOnce(destinationPath, assetURL)
-		if err == nil {
-			return nil
+	// Mean pooling over the returned tokens matches the pooled embedding.
+	pooled, err := embedder.EmbedDocumentsWith(RuntimeOpts{L2Normalize: new(bool)}, documents[:1])
+	if err != nil {
+		t.Fatalf("EmbedDocumentsWith failed: %v", err)
+	}
+	mean := make([]float32, 384)
+	for _, vector := range tokens[0] {
+		for d, value := range vector {
+			mean[d] += value / float32(len(tokens[0]))
 		}
-		lastErr = err
 	}
-
-	return fmt.Errorf("failed to download %s after %d attempts: %w", assetURL, maxAttempts, lastErr)
+	assertVectorNear(t, "mean of token embeddings", mean, pooled[0], 1e-4)
 }
 
-func downloadFileOnce(destinationPath string, assetURL string) (err error) {
-	client := &http.Client{Timeout: 3 * time.Minute}
-	response, err := client.Get(assetURL)
+func TestEmbedDocumentsWithSeqLenCachesPerSequenceLength(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	embedder, err := NewEmbedder(modelPath, tokenizerPath)
 	if err != nil {
-		return err
+		t.Fatalf("failed to create embedder: %v", err)
 	}
 	defer func() {
-		closeErr := response.Body.Close()
-		if err == nil && closeErr != nil {
-			err = closeErr
-		}
+		_ = embedder.Close()
 	}()
 
-	if response.StatusCode != http.StatusOK {
-		requestURL := assetURL
-		if response.Request != nil && response.Request.URL != nil {
-			requestURL = response.Request.URL.String()
-		}
-		return fmt.Errorf("unexpected HTTP status %d from %s", response.StatusCode, requestURL)
+	documents := []string{"This is a test"}
+	full, err := embedder.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("EmbedDocuments failed: %v", err)
+	}
+	short, err := embedder.EmbedDocumentsWithSeqLen(16, documents)
+	if err != nil {
+		t.Fatalf("EmbedDocumentsWithSeqLen failed: %v", err)
+	}
+	// Only padding is removed, so the mean-pooled embedding is unchanged.
+	assertVectorNear(t, "short window embedding", short[0], full[0], 1e-4)
+	assertPrefixNear(t, "short window embedding", short[0], expectedThisIsATestEmbeddingPrefix, 1e-4)
+
+	if embedder.sessions.Len() != 2 {
+		t.Fatalf("expected one session per sequence length, got %d", embedder.sessions.Len())
+	}
+	shortSession := cachedSessionFor(embedder, sessionSpec{sequenceLength: 16}.key(1))
+	if shortSession == nil || cachedSession(embedder, 1) == nil || shortSession == cachedSession(embedder, 1) {
+		t.Fatalf("expected distinct sessions for sequence lengths 16 and %d", embedder.sequenceLength)
+	}
+	if len(shortSession.inputIDs) != 16 {
+		t.Fatalf("expected a 16-token input buffer, got %d", len(shortSession.inputIDs))
+	}
+	if _, err := embedder.EmbedDocumentsWithSeqLen(16, documents); err != nil {
+		t.Fatalf("repeated EmbedDocumentsWithSeqLen failed: %v", err)
+	}
+	if cachedSessionFor(embedder, sessionSpec{sequenceLength: 16}.key(1)) != shortSession {
+		t.Fatalf("expected the sequence length 16 session to be reused")
+	}
+
+	// A window shorter than the document truncates content but keeps [SEP].
+	if _, err := embedder.EmbedDocumentsWithSeqLen(4, documents); err != nil {
+		t.Fatalf("truncating EmbedDocumentsWithSeqLen failed: %v", err)
+	}
+	truncated := cachedSessionFor(embedder, sessionSpec{sequenceLength: 4}.key(1))
+	if truncated == nil || !reflect.DeepEqual(truncated.attentionMask, []int64{1, 1, 1, 1}) || truncated.inputIDs[3] != 102 {
+		t.Fatalf("expected [CLS] this is [SEP], got input_ids=%v", truncated.inputIDs)
 	}
+}
+
+func TestEmbedDocumentsQuantizedWithAllMiniLML6V2(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
 
-	file, err := os.CreateTemp(filepath.Dir(destinationPath), "minilm-*.tmp")
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+	embedder, err := NewEmbedder(modelPath, tokenizerPath)
 	if err != nil {
-		return err
+		t.Fatalf("failed to create embedder: %v", err)
 	}
-	tempPath := file.Name()
 	defer func() {
-		_ = os.Remove(tempPath)
+		_ = embedder.Close()
 	}()
 
-	if _, err := io.Copy(file, response.Body); err != nil {
-		_ = file.Close()
-		return err
+	documents := []string{"this is a test", "hello world"}
+	want, err := embedder.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("EmbedDocuments failed: %v", err)
 	}
-	if err := file.Close(); err != nil {
-		return err
+	vectors, scales, err := embedder.EmbedDocumentsQuantized(documents)
+	if err != nil {
+		t.Fatalf("EmbedDocumentsQuantized failed: %v", err)
 	}
-
-	if err := os.Rename(tempPath, destinationPath); err != nil {
-		if info, statErr := os.Stat(destinationPath); statErr == nil && info.Size() > 0 {
-			return nil
+	if len(vectors) != len(documents) || len(scales) != len(documents) {
+		t.Fatalf("unexpected result lengths: vectors=%d scales=%d", len(vectors), len(scales))
+	}
+	for i := range documents {
+		dequantized := make([]float32, len(vectors[i]))
+		for j, q := range vectors[i] {
+			dequantized[j] = float32(q) * scales[i]
 		}
-		return err
+		assertVectorNear(t, fmt.Sprintf("dequantized row %d", i), dequantized, want[i], 1.0/254+1e-6)
 	}
-
-	return nil
 }
 
-func verifyFileSHA256(path string, expected string) error {
-	file, err := os.Open(path)
+func TestEmbedDocumentsRaggedMatchesPaddedEmbeddings(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+	embedder, err := NewEmbedder(modelPath, tokenizerPath)
 	if err != nil {
-		return err
+		t.Fatalf("failed to create embedder: %v", err)
 	}
 	defer func() {
-		_ = file.Close()
+		_ = embedder.Close()
 	}()
 
-	hash := sha256.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return err
+	documents := []string{
+		strings.Repeat("a much longer document that spans many tokens ", 6),
+		"short",
+		"This is a test",
+		strings.Repeat("medium length text ", 4),
+		"tiny",
 	}
-
-	actual := hex.EncodeToString(hash.Sum(nil))
-	expected = strings.ToLower(strings.TrimSpace(expected))
-	if actual != expected {
-		return fmt.Errorf("sha256 mismatch for %s: got %s want %s", path, actual, expected)
+	want, err := embedder.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("EmbedDocuments failed: %v", err)
 	}
-	return nil
-}
 
-func assertFiniteVector(tb testing.TB, label string, values []float32) {
-	tb.Helper()
-	for i, value := range values {
-		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
-			tb.Fatalf("%s contains non-finite value at %d: %v", label, i, value)
-		}
+	lengths, err := embedder.tokenLengths(documents)
+	if err != nil {
+		t.Fatalf("tokenLengths failed: %v", err)
+	}
+	buckets, _ := groupByBucketLength(lengths, embedder.sequenceLength)
+	if len(buckets) < 3 {
+		t.Fatalf("expected the documents to span at least 3 buckets, got %v for lengths %v", buckets, lengths)
 	}
-}
 
-func assertApproxUnitNorm(tb testing.TB, label string, values []float32, tolerance float64) {
-	tb.Helper()
-	normSquared := 0.0
-	for _, value := range values {
-		normSquared += float64(value * value)
+	got, err := embedder.EmbedDocumentsRagged(documents)
+	if err != nil {
+		t.Fatalf("EmbedDocumentsRagged failed: %v", err)
 	}
-	norm := math.Sqrt(normSquared)
-	if math.Abs(norm-1.0) > tolerance {
-		tb.Fatalf("%s norm not close to 1: got %.8f", label, norm)
+	if len(got) != len(documents) {
+		t.Fatalf("unexpected row count: got %d, want %d", len(got), len(documents))
 	}
-}
-
-func assertPrefixNear(tb testing.TB, label string, got []float32, prefix []float32, tolerance float64) {
-	tb.Helper()
-	if len(got) < len(prefix) {
-		tb.Fatalf("%s length mismatch: got %d want at least %d", label, len(got), len(prefix))
+	for i := range documents {
+		assertVectorNear(t, fmt.Sprintf("ragged row %d", i), got[i], want[i], 1e-4)
 	}
-	for i := range prefix {
-		if math.Abs(float64(got[i]-prefix[i])) > tolerance {
-			tb.Fatalf("%s mismatch at %d: got %.8f want %.8f", label, i, got[i], prefix[i])
+	for _, bucket := range buckets {
+		found := false
+		for key := range embedder.sessions.All() {
+			if key.sequenceLength == bucket {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("expected a cached session at bucket length %d", bucket)
 		}
 	}
 }
 
-func assertVectorNear(tb testing.TB, label string, got []float32, want []float32, tolerance float64) {
-	tb.Helper()
-	if len(got) != len(want) {
-		tb.Fatalf("%s length mismatch: got %d want %d", label, len(got), len(want))
+func TestEmbedDocumentsWithExecutionProvidersCachesSeparately(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	embedder, err := NewEmbedder(modelPath, tokenizerPath)
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
 	}
-	for i := range got {
-		if math.Abs(float64(got[i]-want[i])) > tolerance {
-			tb.Fatalf("%s mismatch at %d: got %.8f want %.8f", label, i, got[i], want[i])
-		}
+	defer func() {
+		_ = embedder.Close()
+	}()
+
+	documents := []string{"This is a test"}
+	defaults, err := embedder.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("EmbedDocuments failed: %v", err)
+	}
+	cpuOnly := RuntimeOpts{ExecutionProviders: []ort.ProviderSpec{{Name: ort.CPUExecutionProviderName}}}
+	explicit, err := embedder.EmbedDocumentsWith(cpuOnly, documents)
+	if err != nil {
+		t.Fatalf("EmbedDocumentsWith failed: %v", err)
+	}
+	assertVectorNear(t, "explicit CPU embedding", explicit[0], defaults[0], 1e-5)
+
+	if embedder.sessions.Len() != 2 {
+		t.Fatalf("expected one session per execution provider configuration, got %d", embedder.sessions.Len())
+	}
+	cpuSession := cachedSessionFor(embedder, sessionSpec{sequenceLength: embedder.sequenceLength, providers: cpuOnly.ExecutionProviders}.key(1))
+	if cpuSession == nil || cpuSession == cachedSession(embedder, 1) {
+		t.Fatalf("expected a separate session for the explicit CPU configuration")
+	}
+	if _, err := embedder.EmbedDocumentsWith(cpuOnly, documents); err != nil {
+		t.Fatalf("repeated EmbedDocumentsWith failed: %v", err)
+	}
+	if embedder.sessions.Len() != 2 {
+		t.Fatalf("expected the explicit CPU session to be reused, got %d sessions", embedder.sessions.Len())
+	}
+}
+
+func TestEmbedIntoWithAllMiniLML6V2(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	embedder, err := NewEmbedder(modelPath, tokenizerPath, WithMaxBatchSize(2))
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	defer func() {
+		_ = embedder.Close()
+	}()
+
+	documents := []string{"This is a test", "Another document", "A third one"}
+	want, err := embedder.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("EmbedDocuments failed: %v", err)
+	}
+
+	// One spare row checks that rows past len(documents) are left alone.
+	dst := make([][]float32, len(documents)+1)
+	backing := make([]float32, len(dst)*384)
+	for i := range dst {
+		dst[i] = backing[i*384 : (i+1)*384]
+	}
+	dst[3][0] = 42
+	for round := 0; round < 2; round++ {
+		if err := embedder.EmbedInto(documents, dst); err != nil {
+			t.Fatalf("EmbedInto failed: %v", err)
+		}
+		for i := range documents {
+			if &dst[i][0] != &backing[i*384] {
+				t.Fatalf("expected row %d to stay in the caller's buffer", i)
+			}
+			assertVectorNear(t, "EmbedInto row", dst[i], want[i], 1e-6)
+		}
+	}
+	if dst[3][0] != 42 {
+		t.Fatalf("expected the spare row to be untouched, got %v", dst[3][0])
+	}
+
+	if err := embedder.EmbedInto(documents, dst[:2]); err == nil || !strings.Contains(err.Error(), "want at least 3") {
+		t.Fatalf("expected too-small dst error, got: %v", err)
+	}
+	short := [][]float32{make([]float32, 384), make([]float32, 100)}
+	if err := embedder.EmbedInto(documents[:2], short); err == nil || !strings.Contains(err.Error(), "dst row 1 has length 100, want 384") {
+		t.Fatalf("expected dst row width error, got: %v", err)
+	}
+}
+
+func TestEmbedDocumentsFlatWithAllMiniLML6V2(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+	documents := []string{"This is a test", "Another document", "A third one"}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		wantCols int
+	}{
+		{name: "direct", opts: []Option{WithMaxBatchSize(2)}, wantCols: 384},
+		{name: "truncated copy", opts: []Option{WithOutputPipeline(PoolStep(PoolingStrategyMean), TruncateStep(128), L2NormalizeStep())}, wantCols: 128},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embedder, err := NewEmbedder(modelPath, tokenizerPath, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create embedder: %v", err)
+			}
+			defer func() {
+				_ = embedder.Close()
+			}()
+
+			want, err := embedder.EmbedDocuments(documents)
+			if err != nil {
+				t.Fatalf("EmbedDocuments failed: %v", err)
+			}
+			data, rows, cols, err := embedder.EmbedDocumentsFlat(documents)
+			if err != nil {
+				t.Fatalf("EmbedDocumentsFlat failed: %v", err)
+			}
+			if rows != len(documents) || cols != tt.wantCols {
+				t.Fatalf("unexpected flat shape: got %dx%d, want %dx%d", rows, cols, len(documents), tt.wantCols)
+			}
+			assertVectorNear(t, "flat buffer", data, embeddings.ConcatEmbeddings(want...), 1e-6)
+		})
+	}
+}
+
+func TestEmbedDocumentsWithNorms(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	rawEmbedder, err := NewEmbedder(modelPath, tokenizerPath, WithoutL2Normalization())
+	if err != nil {
+		t.Fatalf("failed to create un-normalized embedder: %v", err)
+	}
+	defer func() {
+		_ = rawEmbedder.Close()
+	}()
+	normalizedEmbedder, err := NewEmbedder(modelPath, tokenizerPath)
+	if err != nil {
+		t.Fatalf("failed to create normalized embedder: %v", err)
+	}
+	defer func() {
+		_ = normalizedEmbedder.Close()
+	}()
+
+	documents := []string{"This is a test", "local inference only"}
+	rawVectors, rawNorms, err := rawEmbedder.EmbedDocumentsWithNorms(documents)
+	if err != nil {
+		t.Fatalf("EmbedDocumentsWithNorms failed: %v", err)
+	}
+	normalized, normalizedNorms, err := normalizedEmbedder.EmbedDocumentsWithNorms(documents)
+	if err != nil {
+		t.Fatalf("normalized EmbedDocumentsWithNorms failed: %v", err)
+	}
+
+	for i := range documents {
+		if !float32Near(rawNorms[i], l2Norm(rawVectors[i]), 1e-6) {
+			t.Fatalf("row %d: returned norm %v does not match recomputed norm %v", i, rawNorms[i], l2Norm(rawVectors[i]))
+		}
+		if !float32Near(normalizedNorms[i], rawNorms[i], 1e-5) {
+			t.Fatalf("row %d: norms differ between embedders: %v vs %v", i, normalizedNorms[i], rawNorms[i])
+		}
+		assertApproxUnitNorm(t, fmt.Sprintf("normalized row %d", i), normalized[i], 1e-4)
+	}
+	assertPrefixNear(t, "normalized row", normalized[0], expectedThisIsATestEmbeddingPrefix, 1e-4)
+}
+
+func TestEmbedDocumentsPreservesOrderAcrossBatchSizes(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	whole, err := NewEmbedder(modelPath, tokenizerPath)
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	defer func() {
+		if err := whole.Close(); err != nil {
+			t.Errorf("failed to close embedder: %v", err)
+		}
+	}()
+	split, err := NewEmbedder(modelPath, tokenizerPath, WithMaxBatchSize(2))
+	if err != nil {
+		t.Fatalf("failed to create split embedder: %v", err)
+	}
+	defer func() {
+		if err := split.Close(); err != nil {
+			t.Errorf("failed to close split embedder: %v", err)
+		}
+	}()
+
+	documents := []string{
+		"The cat sat on the mat.",
+		"Quarterly revenue grew by twelve percent.",
+		"ONNX Runtime executes models on the CPU.",
+		"A recipe for sourdough bread.",
+		"Mountains covered in fresh snow.",
+	}
+
+	wholeEmbeddings, err := whole.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("single-batch EmbedDocuments failed: %v", err)
+	}
+	splitEmbeddings, err := split.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("split EmbedDocuments failed: %v", err)
+	}
+	splitAgain, err := split.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("repeated split EmbedDocuments failed: %v", err)
+	}
+
+	if len(wholeEmbeddings) != len(documents) || len(splitEmbeddings) != len(documents) {
+		t.Fatalf("unexpected embedding counts: whole=%d split=%d want %d", len(wholeEmbeddings), len(splitEmbeddings), len(documents))
+	}
+	for i := range documents {
+		// Rows are independent, so splitting the batch must not change any value, and a
+		// reordering of the unrelated documents would differ by far more than one bit.
+		if len(splitEmbeddings[i]) != len(wholeEmbeddings[i]) || len(splitAgain[i]) != len(wholeEmbeddings[i]) {
+			t.Fatalf("document %d widths differ: whole=%d split=%d repeated=%d", i, len(wholeEmbeddings[i]), len(splitEmbeddings[i]), len(splitAgain[i]))
+		}
+		for j := range wholeEmbeddings[i] {
+			if math.Float32bits(splitEmbeddings[i][j]) != math.Float32bits(wholeEmbeddings[i][j]) {
+				t.Fatalf("document %d dim %d differs between split and whole batches: %v vs %v", i, j, splitEmbeddings[i][j], wholeEmbeddings[i][j])
+			}
+			if math.Float32bits(splitEmbeddings[i][j]) != math.Float32bits(splitAgain[i][j]) {
+				t.Fatalf("split run not reproducible at document %d dim %d: %v vs %v", i, j, splitEmbeddings[i][j], splitAgain[i][j])
+			}
+		}
+	}
+}
+
+func TestCloseAbortsSplitEmbedBetweenSubBatches(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	embedder, err := NewEmbedder(modelPath, tokenizerPath, WithMaxBatchSize(1))
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+
+	closeErr := make(chan error, 1)
+	subBatches := 0
+	original := afterSubBatch
+	defer func() { afterSubBatch = original }()
+	afterSubBatch = func(e *Embedder, completed int) {
+		if e != embedder {
+			return
+		}
+		subBatches++
+		if subBatches != 1 {
+			return
+		}
+		go func() {
+			closeErr <- embedder.Close()
+		}()
+		for !embedder.closing.Load() {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	documents := make([]string, 16)
+	for i := range documents {
+		documents[i] = fmt.Sprintf("document %d", i)
+	}
+	_, err = embedder.EmbedDocuments(documents)
+	if err == nil || !strings.Contains(err.Error(), "embedder is closing: aborted after 1 of 16 rows") {
+		t.Fatalf("expected closing abort after first sub-batch, got: %v", err)
+	}
+	if subBatches != 1 {
+		t.Fatalf("unexpected completed sub-batches: got %d, want 1", subBatches)
+	}
+
+	select {
+	case err := <-closeErr:
+		if err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Close did not complete after the embed aborted")
+	}
+
+	if _, err := embedder.EmbedDocuments(documents); err == nil || !strings.Contains(err.Error(), "embedder has been closed") {
+		t.Fatalf("expected closed embedder error, got: %v", err)
+	}
+}
+
+func TestSplitEmbedRemainderBatchCaching(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	embedder, err := NewEmbedder(modelPath, tokenizerPath, WithMaxBatchSize(4), WithMaxCachedBatchSessions(2))
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	defer func() {
+		if err := embedder.Close(); err != nil {
+			t.Errorf("failed to close embedder: %v", err)
+		}
+	}()
+
+	documents := make([]string, 10)
+	for i := range documents {
+		documents[i] = fmt.Sprintf("document number %d about topic %d", i, i*7)
+	}
+
+	first, err := embedder.EmbedDocumentsDetailed(documents)
+	if err != nil {
+		t.Fatalf("split EmbedDocumentsDetailed failed: %v", err)
+	}
+	if first.BatchSize != 4 || first.CacheHit {
+		t.Fatalf("unexpected first split result: batchSize=%d cacheHit=%v", first.BatchSize, first.CacheHit)
+	}
+	if embedder.sessions.Len() != 2 || cachedSession(embedder, 4) == nil || cachedSession(embedder, 2) == nil {
+		t.Fatalf("expected exactly the batch-4 and batch-2 sessions to be cached, got %d sessions", embedder.sessions.Len())
+	}
+	fullSession := cachedSession(embedder, 4)
+	remainderSession := cachedSession(embedder, 2)
+
+	second, err := embedder.EmbedDocumentsDetailed(documents)
+	if err != nil {
+		t.Fatalf("repeated split EmbedDocumentsDetailed failed: %v", err)
+	}
+	if !second.CacheHit {
+		t.Fatalf("expected repeated split call to reuse both cached sessions")
+	}
+	if cachedSession(embedder, 4) != fullSession || cachedSession(embedder, 2) != remainderSession {
+		t.Fatalf("expected cached sessions to be reused without churn")
+	}
+
+	if len(second.Embeddings) != len(documents) {
+		t.Fatalf("unexpected embedding count: got %d, want %d", len(second.Embeddings), len(documents))
+	}
+	for i, document := range documents {
+		single, err := embedder.EmbedQuery(document)
+		if err != nil {
+			t.Fatalf("EmbedQuery(%d) failed: %v", i, err)
+		}
+		assertVectorNear(t, fmt.Sprintf("document %d split vs single", i), second.Embeddings[i], single, 1e-6)
+	}
+}
+
+func TestPaddingSideConfiguresTokenizedRows(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	const sequenceLength = 8
+	rows := make(map[PaddingSide][]int64)
+	masks := make(map[PaddingSide][]int64)
+	for _, side := range []PaddingSide{PaddingSideRight, PaddingSideLeft} {
+		embedder, err := NewEmbedder(modelPath, tokenizerPath, WithSequenceLength(sequenceLength), WithPaddingSide(side))
+		if err != nil {
+			t.Fatalf("failed to create %s-padded embedder: %v", side, err)
+		}
+		inputIDs := make([]int64, sequenceLength)
+		attentionMask := make([]int64, sequenceLength)
+		if err := embedder.tokenizeInto([]string{"hello world"}, 0, sequenceLength, inputIDs, attentionMask, nil); err != nil {
+			t.Fatalf("%s-padded tokenization failed: %v", side, err)
+		}
+		rows[side], masks[side] = inputIDs, attentionMask
+
+		if _, err := embedder.EmbedQuery("hello world"); err != nil {
+			t.Fatalf("%s-padded EmbedQuery failed: %v", side, err)
+		}
+		if err := embedder.Close(); err != nil {
+			t.Fatalf("failed to close %s-padded embedder: %v", side, err)
+		}
+	}
+
+	// "[CLS] hello world [SEP]" is four tokens followed by four padding tokens.
+	if want := []int64{1, 1, 1, 1, 0, 0, 0, 0}; !reflect.DeepEqual(masks[PaddingSideRight], want) {
+		t.Fatalf("unexpected right-padded mask: got %v, want %v", masks[PaddingSideRight], want)
+	}
+	if want := []int64{0, 0, 0, 0, 1, 1, 1, 1}; !reflect.DeepEqual(masks[PaddingSideLeft], want) {
+		t.Fatalf("unexpected left-padded mask: got %v, want %v", masks[PaddingSideLeft], want)
+	}
+	if !reflect.DeepEqual(rows[PaddingSideLeft][4:], rows[PaddingSideRight][:4]) {
+		t.Fatalf("expected the same tokens on both sides: right=%v left=%v", rows[PaddingSideRight], rows[PaddingSideLeft])
+	}
+}
+
+func TestAutoEmbeddingDimensionDetectsModelWidth(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	embedder, err := NewEmbedder(modelPath, tokenizerPath, WithAutoEmbeddingDimension(), WithMaxBatchSize(2))
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	defer func() {
+		if err := embedder.Close(); err != nil {
+			t.Errorf("failed to close embedder: %v", err)
+		}
+	}()
+	if dim := embedder.EmbeddingDimension(); dim != 0 {
+		t.Fatalf("expected no embedding dimension before the first batch, got %d", dim)
+	}
+
+	documents := []string{"first document", "second document", "third document"}
+	vectors, err := embedder.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("EmbedDocuments failed: %v", err)
+	}
+	if dim := embedder.EmbeddingDimension(); dim != OutputEmbeddingDimension {
+		t.Fatalf("unexpected detected embedding dimension: got %d, want %d", dim, OutputEmbeddingDimension)
+	}
+	if !cachedSession(embedder, 2).runtimeAllocatedOutput || cachedSession(embedder, 1).runtimeAllocatedOutput {
+		t.Fatalf("expected only the first session to use a runtime-allocated output")
+	}
+
+	reference, err := NewEmbedder(modelPath, tokenizerPath)
+	if err != nil {
+		t.Fatalf("failed to create reference embedder: %v", err)
+	}
+	defer func() {
+		if err := reference.Close(); err != nil {
+			t.Errorf("failed to close reference embedder: %v", err)
+		}
+	}()
+	want, err := reference.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("reference EmbedDocuments failed: %v", err)
+	}
+	for i := range documents {
+		assertVectorNear(t, fmt.Sprintf("document %d auto vs configured", i), vectors[i], want[i], 1e-6)
+	}
+}
+
+func TestNewEmbedderRejectsMissingTokenTypeIDsConfig(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	embedder, err := NewEmbedder(modelPath, tokenizerPath, WithoutTokenTypeIDsInput())
+	if err == nil {
+		_ = embedder.Close()
+		t.Fatalf("expected construction error for model that declares token_type_ids")
+	}
+	if !strings.Contains(err.Error(), "configured without token_type_ids") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWithRunObserverSeesModelInputsAndOutputs(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+
+	var observedInputs, observedOutputs []string
+	var firstInputID int64
+	embedder, err := NewEmbedder(modelPath, tokenizerPath, WithRunObserver(func(inputs, outputs map[string]ort.Value) {
+		for name := range inputs {
+			observedInputs = append(observedInputs, name)
+		}
+		for name := range outputs {
+			observedOutputs = append(observedOutputs, name)
+		}
+		if ids, ok := inputs["input_ids"].(*ort.Tensor[int64]); ok {
+			firstInputID = ids.GetData()[0]
+		}
+	}))
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	defer func() {
+		_ = embedder.Close()
+	}()
+
+	if _, err := embedder.EmbedQuery("This is a test"); err != nil {
+		t.Fatalf("EmbedQuery failed: %v", err)
+	}
+
+	sort.Strings(observedInputs)
+	if got, want := strings.Join(observedInputs, ","), "attention_mask,input_ids,token_type_ids"; got != want {
+		t.Fatalf("unexpected observed inputs: got %s, want %s", got, want)
+	}
+	if got, want := strings.Join(observedOutputs, ","), "last_hidden_state"; got != want {
+		t.Fatalf("unexpected observed outputs: got %s, want %s", got, want)
+	}
+	// 101 is the [CLS] token id in the MiniLM vocabulary.
+	if firstInputID != 101 {
+		t.Fatalf("unexpected first input id: got %d, want 101", firstInputID)
+	}
+}
+
+func TestEmbedOnceManagesRuntimeLifecycle(t *testing.T) {
+	if os.Getenv("ONNXRUNTIME_LIB_PATH") == "" {
+		t.Skip("ONNXRUNTIME_LIB_PATH not set, skipping integration test")
+	}
+	if ort.IsInitialized() {
+		t.Skip("ONNX Runtime already initialized by another test")
+	}
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+	documents := []string{"This is a test", "local inference only"}
+
+	embeddings, err := EmbedOnce(modelPath, tokenizerPath, documents)
+	if err != nil {
+		t.Fatalf("EmbedOnce failed: %v", err)
+	}
+	if ort.IsInitialized() {
+		t.Fatalf("expected EmbedOnce to tear down the runtime it initialized")
+	}
+	if len(embeddings) != len(documents) {
+		t.Fatalf("unexpected embedding count: got %d, want %d", len(embeddings), len(documents))
+	}
+	for i, embedding := range embeddings {
+		if len(embedding) != OutputEmbeddingDimension {
+			t.Fatalf("row %d: unexpected embedding width: got %d, want %d", i, len(embedding), OutputEmbeddingDimension)
+		}
+	}
+	assertPrefixNear(t, "EmbedOnce golden prefix", embeddings[0], expectedThisIsATestEmbeddingPrefix, 1e-4)
+
+	// With a caller-managed environment, EmbedOnce must leave it initialized.
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+	if _, err := EmbedOnce(modelPath, tokenizerPath, documents[:1]); err != nil {
+		t.Fatalf("EmbedOnce with initialized runtime failed: %v", err)
+	}
+	if !ort.IsInitialized() {
+		t.Fatalf("expected EmbedOnce to keep the caller's runtime initialized")
+	}
+}
+
+func TestWithAutoBootstrapManagesRuntimeLifecycle(t *testing.T) {
+	libPath := os.Getenv("ONNXRUNTIME_LIB_PATH")
+	if libPath == "" {
+		t.Skip("ONNXRUNTIME_LIB_PATH not set, skipping integration test")
+	}
+	if ort.IsInitialized() {
+		t.Skip("ONNX Runtime already initialized by another test")
+	}
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+	embedder, err := NewEmbedder(modelPath, tokenizerPath, WithAutoBootstrap(ort.WithBootstrapLibraryPath(libPath)))
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	if ort.IsInitialized() {
+		t.Fatalf("expected construction to leave the runtime uninitialized")
+	}
+
+	embedding, err := embedder.EmbedQuery("This is a test")
+	if err != nil {
+		t.Fatalf("EmbedQuery failed: %v", err)
+	}
+	if !ort.IsInitialized() {
+		t.Fatalf("expected the first embed to bootstrap the runtime")
+	}
+	assertPrefixNear(t, "auto-bootstrap golden prefix", embedding, expectedThisIsATestEmbeddingPrefix, 1e-4)
+
+	if err := embedder.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if ort.IsInitialized() {
+		t.Fatalf("expected Close to destroy the bootstrapped runtime")
+	}
+}
+
+func TestWithAutoBootstrapTwoEmbeddersShareRuntime(t *testing.T) {
+	libPath := os.Getenv("ONNXRUNTIME_LIB_PATH")
+	if libPath == "" {
+		t.Skip("ONNXRUNTIME_LIB_PATH not set, skipping integration test")
+	}
+	if ort.IsInitialized() {
+		t.Skip("ONNX Runtime already initialized by another test")
+	}
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+	first, err := NewEmbedder(modelPath, tokenizerPath, WithAutoBootstrap(ort.WithBootstrapLibraryPath(libPath)))
+	if err != nil {
+		t.Fatalf("failed to create first embedder: %v", err)
+	}
+	second, err := NewEmbedder(modelPath, tokenizerPath, WithAutoBootstrap(ort.WithBootstrapLibraryPath(libPath)))
+	if err != nil {
+		_ = first.Close()
+		t.Fatalf("failed to create second embedder: %v", err)
+	}
+
+	if _, err := first.EmbedQuery("This is a test"); err != nil {
+		t.Fatalf("first EmbedQuery failed: %v", err)
+	}
+	if _, err := second.EmbedQuery("This is a test"); err != nil {
+		t.Fatalf("second EmbedQuery failed: %v", err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("closing the first embedder failed: %v", err)
+	}
+	if !ort.IsInitialized() {
+		t.Fatalf("expected the runtime to stay initialized while the second embedder uses it")
+	}
+	embedding, err := second.EmbedQuery("This is a test")
+	if err != nil {
+		t.Fatalf("EmbedQuery after closing the first embedder failed: %v", err)
+	}
+	assertPrefixNear(t, "shared auto-bootstrap golden prefix", embedding, expectedThisIsATestEmbeddingPrefix, 1e-4)
+
+	if err := second.Close(); err != nil {
+		t.Fatalf("closing the second embedder failed: %v", err)
+	}
+	if ort.IsInitialized() {
+		t.Fatalf("expected the last Close to destroy the bootstrapped runtime")
+	}
+}
+
+func TestProbeReportsMisconfiguredOutputName(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+	embedder, err := NewEmbedder(modelPath, tokenizerPath)
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	defer func() {
+		if closeErr := embedder.Close(); closeErr != nil {
+			t.Errorf("failed to close embedder: %v", closeErr)
+		}
+	}()
+	if err := embedder.Probe(); err != nil {
+		t.Fatalf("expected probe of the default configuration to pass, got: %v", err)
+	}
+
+	misconfigured, err := NewEmbedder(modelPath, tokenizerPath, WithInputOutputNames("input_ids", "attention_mask", "token_type_ids", "sentence_embedding"))
+	if err != nil {
+		t.Fatalf("failed to create misconfigured embedder: %v", err)
+	}
+	defer func() {
+		if closeErr := misconfigured.Close(); closeErr != nil {
+			t.Errorf("failed to close misconfigured embedder: %v", closeErr)
+		}
+	}()
+	err = misconfigured.Probe()
+	if err == nil || !strings.Contains(err.Error(), `model does not declare output "sentence_embedding"`) {
+		t.Fatalf("expected probe to name the missing output, got: %v", err)
+	}
+}
+
+func TestSharedTokenizerOutlivesClosedEmbedder(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+	shared, err := NewSharedTokenizer(tokenizerPath)
+	if err != nil {
+		t.Fatalf("NewSharedTokenizer failed: %v", err)
+	}
+	first, err := NewEmbedder(modelPath, "", WithSharedTokenizer(shared))
+	if err != nil {
+		t.Fatalf("failed to create first embedder: %v", err)
+	}
+	// The pad id must come from the shared tokenizer, not from the path passed here.
+	otherTokenizerPath := filepath.Join(t.TempDir(), "tokenizer.json")
+	if err := os.WriteFile(otherTokenizerPath, []byte(`{"padding": {"pad_id": 7}}`), 0o600); err != nil {
+		t.Fatalf("failed to write tokenizer: %v", err)
+	}
+	second, err := NewEmbedder(modelPath, otherTokenizerPath, WithSharedTokenizer(shared))
+	if err != nil {
+		t.Fatalf("failed to create second embedder: %v", err)
+	}
+	defer func() {
+		if closeErr := second.Close(); closeErr != nil {
+			t.Errorf("failed to close second embedder: %v", closeErr)
+		}
+	}()
+	if err := shared.Close(); err != nil {
+		t.Fatalf("failed to release the creator reference: %v", err)
+	}
+	if first.tokenizer != second.tokenizer {
+		t.Fatalf("expected both embedders to use the shared tokenizer")
+	}
+	if second.padID != first.padID {
+		t.Fatalf("expected the pad id of the shared tokenizer (%d), got %d", first.padID, second.padID)
+	}
+
+	if _, err := first.EmbedQuery("This is a test"); err != nil {
+		t.Fatalf("first EmbedQuery failed: %v", err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("failed to close first embedder: %v", err)
+	}
+	embedding, err := second.EmbedQuery("This is a test")
+	if err != nil {
+		t.Fatalf("EmbedQuery after closing the other embedder failed: %v", err)
+	}
+	assertPrefixNear(t, "shared tokenizer golden prefix", embedding, expectedThisIsATestEmbeddingPrefix, 1e-4)
+
+	if _, err := NewEmbedder(modelPath, "", WithSharedTokenizer(shared), WithSequenceLength(128)); err == nil || !strings.Contains(err.Error(), "shared tokenizer pads to 256 tokens") {
+		t.Fatalf("expected a sequence length mismatch error, got: %v", err)
+	}
+}
+
+func TestTokenizerSequenceLengthMismatchIsTyped(t *testing.T) {
+	cleanup := setupORTTestEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolveMiniLMAssets(t)
+	embedder, err := NewEmbedder(modelPath, tokenizerPath)
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	defer func() {
+		if closeErr := embedder.Close(); closeErr != nil {
+			t.Errorf("failed to close embedder: %v", closeErr)
+		}
+	}()
+
+	// Simulate a misconfigured tokenizer that pads to 128 tokens for a 256-token session.
+	cfg := defaultConfig()
+	cfg.sequenceLength = 128
+	misconfigured, err := tokenizers.FromFile(tokenizerPath, tokenizerOptions(cfg)...)
+	if err != nil {
+		t.Fatalf("failed to load tokenizer: %v", err)
+	}
+	original := embedder.tokenizer
+	embedder.tokenizer = misconfigured
+	defer func() {
+		embedder.tokenizer = original
+		if closeErr := misconfigured.Close(); closeErr != nil {
+			t.Errorf("failed to close tokenizer: %v", closeErr)
+		}
+	}()
+
+	_, err = embedder.EmbedDocuments([]string{"first", "second"})
+	var mismatch *SequenceLengthMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected a SequenceLengthMismatchError, got: %v", err)
+	}
+	if mismatch.Document != 0 || mismatch.TokenizerLength != 128 || mismatch.SessionLength != DefaultSequenceLength {
+		t.Fatalf("unexpected mismatch details: %+v", *mismatch)
+	}
+	if !strings.Contains(err.Error(), "WithSequenceLength(256)") {
+		t.Fatalf("expected the error to name the fix, got: %v", err)
+	}
+}
+
+func setupORTTestEnvironment(tb testing.TB) func() {
+	tb.Helper()
+
+	libPath := os.Getenv("ONNXRUNTIME_LIB_PATH")
+	if libPath == "" {
+		tb.Skip("ONNXRUNTIME_LIB_PATH not set, skipping integration test")
+	}
+
+	if err := ort.SetSharedLibraryPath(libPath); err != nil {
+		tb.Fatalf("failed to set ONNX Runtime library path: %v", err)
+	}
+	if err := ort.InitializeEnvironment(); err != nil {
+		tb.Fatalf("failed to initialize ONNX Runtime: %v", err)
+	}
+
+	return func() {
+		if err := ort.DestroyEnvironment(); err != nil {
+			tb.Errorf("failed to destroy ONNX Runtime environment: %v", err)
+		}
+	}
+}
+
+// resolveTestBundle resolves a test model and tokenizer with embeddings.ResolveBundle,
+// caching them under ONNXRUNTIME_TEST_MODEL_CACHE_DIR when it is set. See
+// testAssetOverride for the per-file environment overrides under envPrefix.
+func resolveTestBundle(tb testing.TB, envPrefix string, spec embeddings.BundleSpec) (modelPath string, tokenizerPath string) {
+	tb.Helper()
+
+	spec.CacheDir = os.Getenv("ONNXRUNTIME_TEST_MODEL_CACHE_DIR")
+	modelPath = testAssetOverride(tb, envPrefix+"_MODEL", &spec.ModelURL, &spec.ModelSHA256)
+	tokenizerPath = testAssetOverride(tb, envPrefix+"_TOKENIZER", &spec.TokenizerURL, &spec.TokenizerSHA256)
+	if modelPath != "" && tokenizerPath != "" {
+		return modelPath, tokenizerPath
+	}
+
+	bundleModelPath, bundleTokenizerPath, err := embeddings.ResolveBundle(spec)
+	if err != nil {
+		tb.Skipf("unable to resolve %s test assets: %v", spec.Name, err)
+	}
+	if modelPath == "" {
+		modelPath = bundleModelPath
+	}
+	if tokenizerPath == "" {
+		tokenizerPath = bundleTokenizerPath
+	}
+	return modelPath, tokenizerPath
+}
+
+// testAssetOverride returns <envKey>_PATH when set, checked against <envKey>_SHA256 if
+// that is set too. Otherwise it applies <envKey>_URL and <envKey>_SHA256 to url and sha
+// and returns ""; a custom URL is only checksummed when <envKey>_SHA256 is set.
+func testAssetOverride(tb testing.TB, envKey string, url *string, sha *string) string {
+	tb.Helper()
+
+	expectedSHA := strings.TrimSpace(os.Getenv(envKey + "_SHA256"))
+	if path := os.Getenv(envKey + "_PATH"); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			tb.Fatalf("%s_PATH %q is not usable: %v", envKey, path, err)
+		}
+		if expectedSHA != "" {
+			if err := verifyFileSHA256(path, expectedSHA); err != nil {
+				tb.Fatalf("%s_PATH failed checksum validation: %v", envKey, err)
+			}
+		}
+		return path
+	}
+	if overrideURL := os.Getenv(envKey + "_URL"); overrideURL != "" && overrideURL != *url {
+		*url = overrideURL
+		*sha = ""
+	}
+	if expectedSHA != "" {
+		*sha = expectedSHA
+	}
+	return ""
+}
+
+func verifyFileSHA256(path string, expected string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+
+	actual := hex.EncodeToString(hash.Sum(nil))
+	expected = strings.ToLower(strings.TrimSpace(expected))
+	if actual != expected {
+		return fmt.Errorf("sha256 mismatch for %s: got %s want %s", path, actual, expected)
+	}
+	return nil
+}
+
+func assertFiniteVector(tb testing.TB, label string, values []float32) {
+	tb.Helper()
+	for i, value := range values {
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+			tb.Fatalf("%s contains non-finite value at %d: %v", label, i, value)
+		}
+	}
+}
+
+func assertApproxUnitNorm(tb testing.TB, label string, values []float32, tolerance float64) {
+	tb.Helper()
+	normSquared := 0.0
+	for _, value := range values {
+		normSquared += float64(value * value)
+	}
+	norm := math.Sqrt(normSquared)
+	if math.Abs(norm-1.0) > tolerance {
+		tb.Fatalf("%s norm not close to 1: got %.8f", label, norm)
+	}
+}
+
+func assertPrefixNear(tb testing.TB, label string, got []float32, prefix []float32, tolerance float64) {
+	tb.Helper()
+	if len(got) < len(prefix) {
+		tb.Fatalf("%s length mismatch: got %d want at least %d", label, len(got), len(prefix))
+	}
+	if err := ort.ApproxEqual(got[:len(prefix)], prefix, tolerance); err != nil {
+		tb.Fatalf("%s: %v", label, err)
 	}
 }
@@ -1,11 +1,21 @@
 package minilm
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"math"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/amikos-tech/pure-onnx/embeddings/internal/ortutil"
+	"github.com/amikos-tech/pure-onnx/ort"
+	tokenizers "github.com/amikos-tech/pure-tokenizers"
 )
 
 func TestMeanPoolAndNormalizeSingleMaskedToken(t *testing.T) {
@@ -64,9 +74,16 @@ func TestMeanPoolAndNormalizeValidation(t *testing.T) {
 	}
 }
 
+func TestNewEmbeddingSessionRejectsOverflowingSizes(t *testing.T) {
+	_, err := newEmbeddingSession("model.onnx", []string{"a", "b"}, []string{"c"}, math.MaxInt/2+1, 2, 384, false, false, false, ort.TensorElementDataTypeFloat, nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid session size") {
+		t.Fatalf("expected session size overflow error, got: %v", err)
+	}
+}
+
 func TestDeriveAttentionMask(t *testing.T) {
 	dst := make([]int64, 4)
-	deriveAttentionMask(dst, []int64{101, 2023, 0, 0})
+	deriveAttentionMask(dst, []int64{101, 2023, 0, 0}, 0)
 
 	expected := []int64{1, 1, 0, 0}
 	for i := range expected {
@@ -76,6 +93,23 @@ func TestDeriveAttentionMask(t *testing.T) {
 	}
 }
 
+func TestDeriveAttentionMaskWithNonZeroPadID(t *testing.T) {
+	// RoBERTa-style ids: <s>=0 is attended and <pad>=1 is padding.
+	dst := make([]int64, 5)
+	deriveAttentionMask(dst, []int64{0, 31414, 2, 1, 1}, 1)
+	if !reflect.DeepEqual(dst, []int64{1, 1, 1, 0, 0}) {
+		t.Fatalf("unexpected attention mask: %v", dst)
+	}
+
+	session := &embeddingSession{inputIDs: make([]int64, 4), attentionMask: make([]int64, 4)}
+	if err := fillTokenizedRows(session, [][]int64{{0, 713, 2, 1}}, nil, nil, 4, 1); err != nil {
+		t.Fatalf("fillTokenizedRows failed: %v", err)
+	}
+	if !reflect.DeepEqual(session.attentionMask, []int64{1, 1, 1, 0}) {
+		t.Fatalf("unexpected derived attention mask: %v", session.attentionMask)
+	}
+}
+
 func TestFillUint32AsInt64TruncatesToDestinationLength(t *testing.T) {
 	dst := make([]int64, 3)
 	fillUint32AsInt64(dst, []uint32{1, 2, 3, 4, 5})
@@ -88,6 +122,122 @@ func TestFillUint32AsInt64TruncatesToDestinationLength(t *testing.T) {
 	}
 }
 
+func TestSequenceLengthMismatchError(t *testing.T) {
+	err := fmt.Errorf("embed failed: %w", &SequenceLengthMismatchError{Document: 2, TokenizerLength: 128, SessionLength: 256})
+	var mismatch *SequenceLengthMismatchError
+	if !errors.As(err, &mismatch) || mismatch.TokenizerLength != 128 || mismatch.SessionLength != 256 {
+		t.Fatalf("expected to unwrap the typed error, got: %v", err)
+	}
+	want := "tokenizer produced 128 tokens for document 2, but the session expects sequence length 256; align the tokenizer's padding and truncation with WithSequenceLength(256)"
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("unexpected error message: %v", err)
+	}
+}
+
+func TestValidateTokenizedRows(t *testing.T) {
+	tests := []struct {
+		name            string
+		inputIDs        [][]int64
+		attentionMask   [][]int64
+		tokenTypeIDs    [][]int64
+		useTokenTypeIDs bool
+		wantErr         string
+	}{
+		{
+			name:            "valid with nil mask and type ids",
+			inputIDs:        [][]int64{{101, 102, 0}, {101, 7592, 102}},
+			useTokenTypeIDs: true,
+		},
+		{
+			name:            "short input row",
+			inputIDs:        [][]int64{{101, 102, 0}, {101, 102}},
+			useTokenTypeIDs: true,
+			wantErr:         "input_ids row 1 length mismatch",
+		},
+		{
+			name:            "mask row count mismatch",
+			inputIDs:        [][]int64{{101, 102, 0}},
+			attentionMask:   [][]int64{{1, 1, 0}, {1, 1, 1}},
+			useTokenTypeIDs: true,
+			wantErr:         "attention_mask row count mismatch",
+		},
+		{
+			name:            "mask row length mismatch",
+			inputIDs:        [][]int64{{101, 102, 0}},
+			attentionMask:   [][]int64{{1, 1}},
+			useTokenTypeIDs: true,
+			wantErr:         "attention_mask row 0 length mismatch",
+		},
+		{
+			name:            "type ids row length mismatch",
+			inputIDs:        [][]int64{{101, 102, 0}},
+			tokenTypeIDs:    [][]int64{{0, 0, 0, 0}},
+			useTokenTypeIDs: true,
+			wantErr:         "token_type_ids row 0 length mismatch",
+		},
+		{
+			name:         "type ids without token_type_ids input",
+			inputIDs:     [][]int64{{101, 102, 0}},
+			tokenTypeIDs: [][]int64{{0, 0, 0}},
+			wantErr:      "configured without a token_type_ids input",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTokenizedRows(tt.inputIDs, tt.attentionMask, tt.tokenTypeIDs, 3, tt.useTokenTypeIDs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFillTokenizedRows(t *testing.T) {
+	session := &embeddingSession{
+		inputIDs:      []int64{9, 9, 9, 9, 9, 9},
+		attentionMask: []int64{9, 9, 9, 9, 9, 9},
+		tokenTypeIDs:  []int64{9, 9, 9, 9, 9, 9},
+	}
+
+	err := fillTokenizedRows(session, [][]int64{{101, 102, 0}, {101, 2023, 102}}, nil, nil, 3, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedIDs := []int64{101, 102, 0, 101, 2023, 102}
+	expectedMask := []int64{1, 1, 0, 1, 1, 1}
+	for i := range expectedIDs {
+		if session.inputIDs[i] != expectedIDs[i] {
+			t.Fatalf("unexpected input_ids[%d]: got %d, want %d", i, session.inputIDs[i], expectedIDs[i])
+		}
+		if session.attentionMask[i] != expectedMask[i] {
+			t.Fatalf("unexpected attention_mask[%d]: got %d, want %d", i, session.attentionMask[i], expectedMask[i])
+		}
+		if session.tokenTypeIDs[i] != 0 {
+			t.Fatalf("unexpected token_type_ids[%d]: got %d, want 0", i, session.tokenTypeIDs[i])
+		}
+	}
+
+	if err := fillTokenizedRows(session, [][]int64{{101, 102, 0}}, nil, nil, 3, 0); err == nil || !strings.Contains(err.Error(), "token buffer length mismatch") {
+		t.Fatalf("expected buffer length mismatch error, got: %v", err)
+	}
+}
+
+func TestEmbedTokenizedValidation(t *testing.T) {
+	var embedder *Embedder
+	_, err := embedder.EmbedTokenized([][]int64{{101, 102}}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "embedder is nil") {
+		t.Fatalf("expected nil embedder error, got: %v", err)
+	}
+}
+
 func TestEmbedQueryValidation(t *testing.T) {
 	var embedder *Embedder
 	_, err := embedder.EmbedQuery("test")
@@ -96,6 +246,22 @@ func TestEmbedQueryValidation(t *testing.T) {
 	}
 }
 
+func TestEmbedDocumentsDetailedValidation(t *testing.T) {
+	var embedder *Embedder
+	_, err := embedder.EmbedDocumentsDetailed([]string{"test"})
+	if err == nil || !strings.Contains(err.Error(), "embedder is nil") {
+		t.Fatalf("expected nil embedder error, got: %v", err)
+	}
+
+	result, err := (&Embedder{}).EmbedDocumentsDetailed(nil)
+	if err != nil {
+		t.Fatalf("unexpected error for empty input: %v", err)
+	}
+	if result.BatchSize != 0 || len(result.Embeddings) != 0 {
+		t.Fatalf("unexpected result for empty input: %+v", result)
+	}
+}
+
 func TestWithMaxCachedBatchSessionsValidation(t *testing.T) {
 	cfg := defaultConfig()
 	if err := WithMaxCachedBatchSessions(0)(&cfg); err == nil {
@@ -109,6 +275,28 @@ func TestWithMaxCachedBatchSessionsValidation(t *testing.T) {
 	}
 }
 
+func TestWithSharedTokenizerValidation(t *testing.T) {
+	if err := WithSharedTokenizer(nil)(&config{}); err == nil {
+		t.Fatalf("expected a nil shared tokenizer to be rejected")
+	}
+}
+
+func TestWithMaxBatchSizeValidation(t *testing.T) {
+	cfg := defaultConfig()
+	if cfg.maxBatchSize != 0 {
+		t.Fatalf("unexpected default maxBatchSize: got %d, want 0", cfg.maxBatchSize)
+	}
+	if err := WithMaxBatchSize(0)(&cfg); err == nil {
+		t.Fatalf("expected validation error for zero max batch size")
+	}
+	if err := WithMaxBatchSize(4)(&cfg); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	if cfg.maxBatchSize != 4 {
+		t.Fatalf("unexpected maxBatchSize: got %d, want 4", cfg.maxBatchSize)
+	}
+}
+
 func TestWithEmbeddingDimensionValidation(t *testing.T) {
 	cfg := defaultConfig()
 	if err := WithEmbeddingDimension(0)(&cfg); err == nil {
@@ -122,6 +310,51 @@ func TestWithEmbeddingDimensionValidation(t *testing.T) {
 	}
 }
 
+func TestWithAutoEmbeddingDimension(t *testing.T) {
+	cfg := defaultConfig()
+	if err := WithAutoEmbeddingDimension()(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.embeddingDimension != 0 {
+		t.Fatalf("expected auto embedding dimension to clear the configured width, got %d", cfg.embeddingDimension)
+	}
+	if err := validatePooledOutput([]ort.InputOutputInfo{{Name: "pooler_output", Dimensions: ort.Shape{-1, 768}}}, "pooler_output", cfg.embeddingDimension); err != nil {
+		t.Fatalf("expected any declared width to be accepted in auto mode, got: %v", err)
+	}
+}
+
+func TestDetectEmbeddingDimension(t *testing.T) {
+	tests := []struct {
+		name    string
+		shape   ort.Shape
+		pooled  bool
+		want    int64
+		wantErr string
+	}{
+		{name: "token-level output", shape: ort.Shape{2, 8, 384}, want: 384},
+		{name: "pooled output", shape: ort.Shape{2, 768}, pooled: true, want: 768},
+		{name: "wrong rank", shape: ort.Shape{2, 384}, wantErr: "must be rank 3"},
+		{name: "wrong batch", shape: ort.Shape{3, 8, 384}, wantErr: "does not match batch size 2"},
+		{name: "wrong sequence length", shape: ort.Shape{2, 16, 384}, wantErr: "sequence length 8"},
+		{name: "zero width", shape: ort.Shape{2, 0}, pooled: true, wantErr: "no embedding width"},
+		{name: "not run yet", shape: nil, pooled: true, wantErr: "must be rank 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectEmbeddingDimension(tt.shape, 2, 8, tt.pooled)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("unexpected result: got %d, err %v; want %d", got, err, tt.want)
+			}
+		})
+	}
+}
+
 func TestPoolingOptions(t *testing.T) {
 	cfg := defaultConfig()
 
@@ -163,6 +396,161 @@ func TestNormalizationOptions(t *testing.T) {
 	if !cfg.l2Normalize {
 		t.Fatalf("expected l2Normalize=true after WithL2Normalization")
 	}
+
+	if err := WithMaxNormNormalization()(&cfg); err != nil {
+		t.Fatalf("WithMaxNormNormalization failed: %v", err)
+	}
+	if cfg.l2Normalize || !cfg.maxNormalize {
+		t.Fatalf("expected max-norm to replace L2 normalization, got l2Normalize=%v maxNormalize=%v", cfg.l2Normalize, cfg.maxNormalize)
+	}
+	if err := WithL2Normalization()(&cfg); err != nil {
+		t.Fatalf("WithL2Normalization failed: %v", err)
+	}
+	if !cfg.l2Normalize || cfg.maxNormalize {
+		t.Fatalf("expected L2 to replace max-norm normalization, got l2Normalize=%v maxNormalize=%v", cfg.l2Normalize, cfg.maxNormalize)
+	}
+}
+
+func TestResolveRuntimeOpts(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name         string
+		pooledOutput bool
+		opts         RuntimeOpts
+		want         postProcessing
+		wantErr      string
+	}{
+		{
+			name: "zero value keeps configuration",
+			want: postProcessing{poolingStrategy: PoolingStrategyMean, l2Normalize: true},
+		},
+		{
+			name: "pooling override",
+			opts: RuntimeOpts{PoolingStrategy: PoolingStrategyCLS},
+			want: postProcessing{poolingStrategy: PoolingStrategyCLS, l2Normalize: true},
+		},
+		{
+			name: "normalization override",
+			opts: RuntimeOpts{L2Normalize: &disabled},
+			want: postProcessing{poolingStrategy: PoolingStrategyMean, l2Normalize: false},
+		},
+		{
+			name:    "unsupported pooling",
+			opts:    RuntimeOpts{PoolingStrategy: PoolingStrategy("max")},
+			wantErr: "unsupported pooling strategy",
+		},
+		{
+			name:         "pooling override with pooled output",
+			pooledOutput: true,
+			opts:         RuntimeOpts{PoolingStrategy: PoolingStrategyCLS},
+			wantErr:      "already pooled",
+		},
+		{
+			name:         "normalization override with pooled output",
+			pooledOutput: true,
+			opts:         RuntimeOpts{L2Normalize: &disabled},
+			want:         postProcessing{poolingStrategy: PoolingStrategyMean, l2Normalize: false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embedder := &Embedder{poolingStrategy: PoolingStrategyMean, l2Normalize: true, pooledOutput: tt.pooledOutput}
+			got, err := embedder.resolveRuntimeOpts(tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected post-processing: got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbedDocumentsWithValidation(t *testing.T) {
+	var embedder *Embedder
+	if _, err := embedder.EmbedDocumentsWith(RuntimeOpts{}, []string{"x"}); err == nil || !strings.Contains(err.Error(), "embedder is nil") {
+		t.Fatalf("expected nil embedder error, got: %v", err)
+	}
+	embedder = &Embedder{poolingStrategy: PoolingStrategyMean}
+	if _, err := embedder.EmbedDocumentsWith(RuntimeOpts{PoolingStrategy: "max"}, []string{"x"}); err == nil || !strings.Contains(err.Error(), "unsupported pooling strategy") {
+		t.Fatalf("expected unsupported pooling error, got: %v", err)
+	}
+}
+
+func TestEmbedDocumentsWithNormsValidation(t *testing.T) {
+	var embedder *Embedder
+	if _, _, err := embedder.EmbedDocumentsWithNorms([]string{"x"}); err == nil || !strings.Contains(err.Error(), "embedder is nil") {
+		t.Fatalf("expected nil embedder error, got: %v", err)
+	}
+
+	vectors, norms, err := (&Embedder{}).EmbedDocumentsWithNorms(nil)
+	if err != nil {
+		t.Fatalf("unexpected error for empty input: %v", err)
+	}
+	if len(vectors) != 0 || norms == nil || len(norms) != 0 {
+		t.Fatalf("expected empty results, got vectors=%v norms=%v", vectors, norms)
+	}
+}
+
+func TestEmbedDocumentsFlatValidation(t *testing.T) {
+	var embedder *Embedder
+	if _, _, _, err := embedder.EmbedDocumentsFlat([]string{"x"}); err == nil || !strings.Contains(err.Error(), "embedder is nil") {
+		t.Fatalf("expected nil embedder error, got: %v", err)
+	}
+
+	// Empty input reports cols 0 whether the width is configured, still to be
+	// detected, or shortened by a TruncateStep.
+	for name, embedder := range map[string]*Embedder{
+		"known width":    {embeddingDimension: 384, poolingStrategy: PoolingStrategyMean},
+		"auto dimension": {poolingStrategy: PoolingStrategyMean},
+		"truncated":      {embeddingDimension: 384, poolingStrategy: PoolingStrategyMean, outputSteps: []PostProcessStep{TruncateStep(128)}},
+	} {
+		data, rows, cols, err := embedder.EmbedDocumentsFlat([]string{})
+		if err != nil {
+			t.Fatalf("%s: unexpected error for empty input: %v", name, err)
+		}
+		if data == nil || len(data) != 0 || rows != 0 || cols != 0 {
+			t.Fatalf("%s: expected an empty 0x0 buffer, got data=%v rows=%d cols=%d", name, data, rows, cols)
+		}
+	}
+}
+
+func TestL2NormMatchesNormalization(t *testing.T) {
+	vector := []float32{3, 4, 0, 12}
+	if got := l2Norm(vector); got != 13 {
+		t.Fatalf("unexpected norm: got %v, want 13", got)
+	}
+
+	rows := [][]float32{append([]float32(nil), vector...)}
+	l2NormalizeRows(rows)
+	for i := range vector {
+		if !float32Near(rows[0][i]*13, vector[i], 1e-6) {
+			t.Fatalf("normalized row scaled by norm does not match original: got %v, want %v", rows[0], vector)
+		}
+	}
+}
+
+func TestMaxNormalizeRows(t *testing.T) {
+	rows := [][]float32{{3, -8, 0, 2}, {0, 0, 0}}
+	maxNormalizeRows(rows)
+
+	want := []float32{0.375, -1, 0, 0.25}
+	for i := range want {
+		if !float32Near(rows[0][i], want[i], 1e-7) {
+			t.Fatalf("unexpected max-normalized row: got %v, want %v", rows[0], want)
+		}
+	}
+	for i, value := range rows[1] {
+		if value != 0 || math.IsNaN(float64(value)) {
+			t.Fatalf("expected zero vector to stay zero at %d, got %v", i, rows[1])
+		}
+	}
 }
 
 func TestWithoutTokenTypeIDsInput(t *testing.T) {
@@ -188,8 +576,81 @@ func TestWithInputOutputNamesAllowsEmptyTokenTypeIDs(t *testing.T) {
 	}
 }
 
+func TestResolveTokenTypeIDsInput(t *testing.T) {
+	twoInputs := []ort.InputOutputInfo{{Name: "input_ids"}, {Name: "attention_mask"}}
+	threeInputs := []ort.InputOutputInfo{{Name: "input_ids"}, {Name: "attention_mask"}, {Name: "token_type_ids"}}
+
+	tests := []struct {
+		name            string
+		inputs          []ort.InputOutputInfo
+		tokenTypeIDs    string
+		useTokenTypeIDs bool
+		wantUse         bool
+		wantWarning     string
+		wantErr         string
+	}{
+		{
+			name:            "three-input model configured with type ids",
+			inputs:          threeInputs,
+			tokenTypeIDs:    "token_type_ids",
+			useTokenTypeIDs: true,
+			wantUse:         true,
+		},
+		{
+			name:    "two-input model configured without type ids",
+			inputs:  twoInputs,
+			wantUse: false,
+		},
+		{
+			name:    "three-input model configured without type ids",
+			inputs:  threeInputs,
+			wantErr: "configured without token_type_ids",
+		},
+		{
+			name:            "two-input model configured with type ids",
+			inputs:          twoInputs,
+			tokenTypeIDs:    "token_type_ids",
+			useTokenTypeIDs: true,
+			wantUse:         false,
+			wantWarning:     "does not declare a \"token_type_ids\" input",
+		},
+		{
+			name:            "custom type ids name",
+			inputs:          []ort.InputOutputInfo{{Name: "input_ids"}, {Name: "attention_mask"}, {Name: "segment_ids"}},
+			tokenTypeIDs:    "segment_ids",
+			useTokenTypeIDs: true,
+			wantUse:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			use, warning, err := resolveTokenTypeIDsInput(tt.inputs, tt.tokenTypeIDs, tt.useTokenTypeIDs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if use != tt.wantUse {
+				t.Fatalf("unexpected useTokenTypeIDs: got %v, want %v", use, tt.wantUse)
+			}
+			if tt.wantWarning == "" && warning != "" {
+				t.Fatalf("unexpected warning: %q", warning)
+			}
+			if !strings.Contains(warning, tt.wantWarning) {
+				t.Fatalf("expected warning containing %q, got: %q", tt.wantWarning, warning)
+			}
+		})
+	}
+}
+
 func TestPostProcessDenseOutputCLSPooling(t *testing.T) {
 	embeddings, err := postProcessDenseOutput(
+		nil,
 		[]float32{1, 2, 3, 4, 5, 6},
 		[]int64{1, 1, 1},
 		1,
@@ -197,16 +658,18 @@ func TestPostProcessDenseOutputCLSPooling(t *testing.T) {
 		2,
 		PoolingStrategyCLS,
 		false,
+		false,
 	)
 	if err != nil {
 		t.Fatalf("postProcessDenseOutput failed: %v", err)
 	}
 	want := []float32{1, 2}
-	assertVectorNearLocal(t, "CLS pooling", embeddings[0], want, 1e-6)
+	assertVectorNear(t, "CLS pooling", embeddings[0], want, 1e-6)
 }
 
 func TestPostProcessDenseOutputNoPooling(t *testing.T) {
 	embeddings, err := postProcessDenseOutput(
+		nil,
 		[]float32{1, 2, 3, 4},
 		[]int64{1, 1},
 		1,
@@ -214,16 +677,18 @@ func TestPostProcessDenseOutputNoPooling(t *testing.T) {
 		2,
 		PoolingStrategyNone,
 		false,
+		false,
 	)
 	if err != nil {
 		t.Fatalf("postProcessDenseOutput failed: %v", err)
 	}
 	want := []float32{1, 2, 3, 4}
-	assertVectorNearLocal(t, "No pooling", embeddings[0], want, 1e-6)
+	assertVectorNear(t, "No pooling", embeddings[0], want, 1e-6)
 }
 
 func TestPostProcessDenseOutputCLSPoolingBatchTwo(t *testing.T) {
 	embeddings, err := postProcessDenseOutput(
+		nil,
 		[]float32{
 			1, 2, 3, 4, 5, 6, // row 0 tokens
 			7, 8, 9, 10, 11, 12, // row 1 tokens
@@ -234,6 +699,7 @@ func TestPostProcessDenseOutputCLSPoolingBatchTwo(t *testing.T) {
 		2,
 		PoolingStrategyCLS,
 		false,
+		false,
 	)
 	if err != nil {
 		t.Fatalf("postProcessDenseOutput failed: %v", err)
@@ -241,12 +707,13 @@ func TestPostProcessDenseOutputCLSPoolingBatchTwo(t *testing.T) {
 	if len(embeddings) != 2 {
 		t.Fatalf("expected 2 embedding rows, got %d", len(embeddings))
 	}
-	assertVectorNearLocal(t, "CLS pooling row 0", embeddings[0], []float32{1, 2}, 1e-6)
-	assertVectorNearLocal(t, "CLS pooling row 1", embeddings[1], []float32{7, 8}, 1e-6)
+	assertVectorNear(t, "CLS pooling row 0", embeddings[0], []float32{1, 2}, 1e-6)
+	assertVectorNear(t, "CLS pooling row 1", embeddings[1], []float32{7, 8}, 1e-6)
 }
 
 func TestPostProcessDenseOutputNoPoolingBatchTwo(t *testing.T) {
 	embeddings, err := postProcessDenseOutput(
+		nil,
 		[]float32{
 			1, 2, 3, 4, // row 0 tokens
 			5, 6, 7, 8, // row 1 tokens
@@ -257,6 +724,7 @@ func TestPostProcessDenseOutputNoPoolingBatchTwo(t *testing.T) {
 		2,
 		PoolingStrategyNone,
 		false,
+		false,
 	)
 	if err != nil {
 		t.Fatalf("postProcessDenseOutput failed: %v", err)
@@ -264,12 +732,48 @@ func TestPostProcessDenseOutputNoPoolingBatchTwo(t *testing.T) {
 	if len(embeddings) != 2 {
 		t.Fatalf("expected 2 embedding rows, got %d", len(embeddings))
 	}
-	assertVectorNearLocal(t, "No pooling row 0", embeddings[0], []float32{1, 2, 3, 4}, 1e-6)
-	assertVectorNearLocal(t, "No pooling row 1", embeddings[1], []float32{5, 6, 7, 8}, 1e-6)
+	assertVectorNear(t, "No pooling row 0", embeddings[0], []float32{1, 2, 3, 4}, 1e-6)
+	assertVectorNear(t, "No pooling row 1", embeddings[1], []float32{5, 6, 7, 8}, 1e-6)
+}
+
+func TestPostProcessDenseOutputTokenRowsDropPadding(t *testing.T) {
+	embeddings, err := postProcessDenseOutput(
+		nil,
+		[]float32{
+			1, 2, 3, 4, 9, 9, // row 0: two tokens, then padding
+			9, 9, 5, 6, 7, 8, // row 1: left padding, then two tokens
+		},
+		[]int64{1, 1, 0, 0, 1, 1},
+		2,
+		3,
+		2,
+		poolingStrategyTokens,
+		false,
+		false,
+	)
+	if err != nil {
+		t.Fatalf("postProcessDenseOutput failed: %v", err)
+	}
+	tokens, err := splitTokenRows(embeddings, 2)
+	if err != nil {
+		t.Fatalf("splitTokenRows failed: %v", err)
+	}
+	want := [][][]float32{
+		{{1, 2}, {3, 4}},
+		{{5, 6}, {7, 8}},
+	}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Fatalf("unexpected token rows: got %v, want %v", tokens, want)
+	}
+
+	if _, err := splitTokenRows([][]float32{{1, 2, 3}}, 2); err == nil || !strings.Contains(err.Error(), "not a multiple of embedding dim") {
+		t.Fatalf("expected ragged row error, got: %v", err)
+	}
 }
 
 func TestPostProcessDenseOutputCLSPoolingWithL2(t *testing.T) {
 	embeddings, err := postProcessDenseOutput(
+		nil,
 		[]float32{
 			3, 4, 10, 20, // row 0 tokens
 			5, 12, 1, 2, // row 1 tokens
@@ -280,16 +784,18 @@ func TestPostProcessDenseOutputCLSPoolingWithL2(t *testing.T) {
 		2,
 		PoolingStrategyCLS,
 		true,
+		false,
 	)
 	if err != nil {
 		t.Fatalf("postProcessDenseOutput failed: %v", err)
 	}
-	assertVectorNearLocal(t, "CLS + L2 row 0", embeddings[0], []float32{0.6, 0.8}, 1e-6)
-	assertVectorNearLocal(t, "CLS + L2 row 1", embeddings[1], []float32{5.0 / 13.0, 12.0 / 13.0}, 1e-6)
+	assertVectorNear(t, "CLS + L2 row 0", embeddings[0], []float32{0.6, 0.8}, 1e-6)
+	assertVectorNear(t, "CLS + L2 row 1", embeddings[1], []float32{5.0 / 13.0, 12.0 / 13.0}, 1e-6)
 }
 
 func TestPostProcessDenseOutputNoPoolingWithL2(t *testing.T) {
 	embeddings, err := postProcessDenseOutput(
+		nil,
 		[]float32{3, 4, 0, 0},
 		[]int64{1, 1},
 		1,
@@ -297,15 +803,17 @@ func TestPostProcessDenseOutputNoPoolingWithL2(t *testing.T) {
 		2,
 		PoolingStrategyNone,
 		true,
+		false,
 	)
 	if err != nil {
 		t.Fatalf("postProcessDenseOutput failed: %v", err)
 	}
-	assertVectorNearLocal(t, "No pooling + L2", embeddings[0], []float32{0.6, 0.8, 0, 0}, 1e-6)
+	assertVectorNear(t, "No pooling + L2", embeddings[0], []float32{0.6, 0.8, 0, 0}, 1e-6)
 }
 
 func TestPostProcessDenseOutputCLSPoolingWithL2ZeroVector(t *testing.T) {
 	embeddings, err := postProcessDenseOutput(
+		nil,
 		[]float32{0, 0, 5, 6},
 		[]int64{1, 1},
 		1,
@@ -313,15 +821,17 @@ func TestPostProcessDenseOutputCLSPoolingWithL2ZeroVector(t *testing.T) {
 		2,
 		PoolingStrategyCLS,
 		true,
+		false,
 	)
 	if err != nil {
 		t.Fatalf("postProcessDenseOutput failed: %v", err)
 	}
-	assertVectorNearLocal(t, "CLS + L2 zero vector", embeddings[0], []float32{0, 0}, 1e-6)
+	assertVectorNear(t, "CLS + L2 zero vector", embeddings[0], []float32{0, 0}, 1e-6)
 }
 
 func TestPostProcessDenseOutputNoPoolingWithL2ZeroVector(t *testing.T) {
 	embeddings, err := postProcessDenseOutput(
+		nil,
 		[]float32{0, 0, 0, 0},
 		[]int64{1, 1},
 		1,
@@ -329,15 +839,82 @@ func TestPostProcessDenseOutputNoPoolingWithL2ZeroVector(t *testing.T) {
 		2,
 		PoolingStrategyNone,
 		true,
+		false,
 	)
 	if err != nil {
 		t.Fatalf("postProcessDenseOutput failed: %v", err)
 	}
-	assertVectorNearLocal(t, "No pooling + L2 zero vector", embeddings[0], []float32{0, 0, 0, 0}, 1e-6)
+	assertVectorNear(t, "No pooling + L2 zero vector", embeddings[0], []float32{0, 0, 0, 0}, 1e-6)
+}
+
+func TestWithHighPrecisionPoolingOption(t *testing.T) {
+	cfg := defaultConfig()
+	if cfg.highPrecisionPooling {
+		t.Fatalf("expected float32 pooling by default")
+	}
+	if err := WithHighPrecisionPooling()(&cfg); err != nil {
+		t.Fatalf("unexpected option error: %v", err)
+	}
+	if !cfg.highPrecisionPooling {
+		t.Fatalf("expected highPrecisionPooling=true")
+	}
+}
+
+func TestHighPrecisionMeanPoolingReducesAccumulationError(t *testing.T) {
+	const sequenceLength = 1 << 16
+	const dim = 2
+
+	hidden := make([]float32, sequenceLength*dim)
+	mask := make([]int64, sequenceLength)
+	for i := 0; i < sequenceLength; i++ {
+		hidden[i*dim] = 0.1
+		hidden[i*dim+1] = float32(i%7) * 0.37
+		mask[i] = 1
+	}
+
+	reference := make([]float64, dim)
+	for i := 0; i < sequenceLength; i++ {
+		for d := 0; d < dim; d++ {
+			reference[d] += float64(hidden[i*dim+d])
+		}
+	}
+	for d := range reference {
+		reference[d] /= sequenceLength
+	}
+
+	maxAbsError := func(embedding []float32) float64 {
+		worst := 0.0
+		for d := range embedding {
+			worst = math.Max(worst, math.Abs(float64(embedding[d])-reference[d]))
+		}
+		return worst
+	}
+
+	lowPrecision := meanPoolTokenEmbeddings(nil, hidden, mask, 1, sequenceLength, dim)
+	highPrecision := meanPoolTokenEmbeddingsFloat64(nil, hidden, mask, 1, sequenceLength, dim)
+
+	lowErr := maxAbsError(lowPrecision[0])
+	highErr := maxAbsError(highPrecision[0])
+	if highErr >= lowErr {
+		t.Fatalf("expected float64 accumulation to reduce error: float32=%g float64=%g", lowErr, highErr)
+	}
+	// The float64 result is only limited by the final float32 cast.
+	for d := range reference {
+		if want := float32(reference[d]); highPrecision[0][d] != want {
+			t.Fatalf("unexpected float64-accumulated mean at dim %d: got %v, want %v", d, highPrecision[0][d], want)
+		}
+	}
+
+	viaPostProcess, err := postProcessDenseOutput(nil, hidden, mask, 1, sequenceLength, dim, PoolingStrategyMean, false, true)
+	if err != nil {
+		t.Fatalf("postProcessDenseOutput failed: %v", err)
+	}
+	assertVectorNear(t, "high precision post-process", viaPostProcess[0], highPrecision[0], 0)
 }
 
 func TestPostProcessDenseOutputInvalidPooling(t *testing.T) {
 	_, err := postProcessDenseOutput(
+		nil,
 		[]float32{1, 2, 3, 4},
 		[]int64{1, 1},
 		1,
@@ -345,12 +922,198 @@ func TestPostProcessDenseOutputInvalidPooling(t *testing.T) {
 		2,
 		PoolingStrategy("invalid"),
 		false,
+		false,
 	)
 	if err == nil || !strings.Contains(err.Error(), "unsupported pooling strategy") {
 		t.Fatalf("expected unsupported pooling strategy error, got: %v", err)
 	}
 }
 
+func TestWithPooledOutputName(t *testing.T) {
+	cfg := defaultConfig()
+	if err := WithPooledOutputName("")(&cfg); err == nil {
+		t.Fatalf("expected validation error for empty pooled output name")
+	}
+	if err := WithPooledOutputName("sentence_embedding")(&cfg); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	if !cfg.pooledOutput {
+		t.Fatalf("expected pooledOutput=true")
+	}
+	if cfg.outputName != "sentence_embedding" {
+		t.Fatalf("unexpected output name: got %q, want %q", cfg.outputName, "sentence_embedding")
+	}
+
+	// A later token-level output name replaces the pooled output.
+	if err := WithInputOutputNames("input_ids", "attention_mask", "", "last_hidden_state")(&cfg); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	if cfg.pooledOutput || cfg.outputName != "last_hidden_state" {
+		t.Fatalf("expected WithInputOutputNames to reset the pooled output, got pooled=%v output=%q", cfg.pooledOutput, cfg.outputName)
+	}
+}
+
+func TestResolveNormalization(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []Option
+		wantNormalize bool
+		wantWarning   string
+		wantErr       string
+	}{
+		{name: "default", wantNormalize: true},
+		{
+			name:          "pooled output with default normalization",
+			opts:          []Option{WithPooledOutputName("sentence_embedding")},
+			wantNormalize: true,
+		},
+		{
+			name:          "pooled output with explicit normalization",
+			opts:          []Option{WithPooledOutputName("sentence_embedding"), WithL2Normalization()},
+			wantNormalize: true,
+			wantWarning:   "WithAssumeNormalizedOutput",
+		},
+		{
+			name:          "assume normalized",
+			opts:          []Option{WithPooledOutputName("sentence_embedding"), WithAssumeNormalizedOutput()},
+			wantNormalize: false,
+		},
+		{
+			name:    "assume normalized with explicit normalization",
+			opts:    []Option{WithL2Normalization(), WithAssumeNormalizedOutput()},
+			wantErr: "cannot be combined",
+		},
+		{
+			name:    "assume normalized with max-norm normalization",
+			opts:    []Option{WithMaxNormNormalization(), WithAssumeNormalizedOutput()},
+			wantErr: "cannot be combined with WithMaxNormNormalization",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			for _, opt := range tt.opts {
+				if err := opt(&cfg); err != nil {
+					t.Fatalf("option failed: %v", err)
+				}
+			}
+			warning, err := resolveNormalization(&cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.l2Normalize != tt.wantNormalize {
+				t.Fatalf("unexpected l2Normalize: got %v, want %v", cfg.l2Normalize, tt.wantNormalize)
+			}
+			if tt.wantWarning == "" && warning != "" {
+				t.Fatalf("unexpected warning: %q", warning)
+			}
+			if !strings.Contains(warning, tt.wantWarning) {
+				t.Fatalf("expected warning containing %q, got %q", tt.wantWarning, warning)
+			}
+		})
+	}
+}
+
+func TestAssumeNormalizedOutputSkipsNormalization(t *testing.T) {
+	cfg := defaultConfig()
+	if err := WithAssumeNormalizedOutput()(&cfg); err != nil {
+		t.Fatalf("WithAssumeNormalizedOutput failed: %v", err)
+	}
+	if _, err := resolveNormalization(&cfg); err != nil {
+		t.Fatalf("resolveNormalization failed: %v", err)
+	}
+
+	// A non-unit pooled row must come back untouched.
+	embeddings, err := postProcessPooledOutput(nil, []float32{3, 4}, 1, 2, cfg.l2Normalize)
+	if err != nil {
+		t.Fatalf("postProcessPooledOutput failed: %v", err)
+	}
+	assertVectorNear(t, "assume normalized row", embeddings[0], []float32{3, 4}, 1e-6)
+}
+
+func TestPostProcessPooledOutput(t *testing.T) {
+	embeddings, err := postProcessPooledOutput(nil, []float32{3, 4, 1, 0}, 2, 2, false)
+	if err != nil {
+		t.Fatalf("postProcessPooledOutput failed: %v", err)
+	}
+	if len(embeddings) != 2 {
+		t.Fatalf("unexpected embedding count: got %d, want 2", len(embeddings))
+	}
+	assertVectorNear(t, "pooled row 0", embeddings[0], []float32{3, 4}, 1e-6)
+	assertVectorNear(t, "pooled row 1", embeddings[1], []float32{1, 0}, 1e-6)
+
+	normalized, err := postProcessPooledOutput(nil, []float32{3, 4, 0, 0}, 2, 2, true)
+	if err != nil {
+		t.Fatalf("postProcessPooledOutput with L2 failed: %v", err)
+	}
+	assertVectorNear(t, "pooled row 0 + L2", normalized[0], []float32{0.6, 0.8}, 1e-6)
+	assertVectorNear(t, "pooled row 1 + L2 zero vector", normalized[1], []float32{0, 0}, 1e-6)
+
+	// A token-level [batch, seq, dim] buffer must not be accepted as pooled output.
+	if _, err := postProcessPooledOutput(nil, make([]float32, 2*3*2), 2, 2, false); err == nil || !strings.Contains(err.Error(), "pooled output length mismatch") {
+		t.Fatalf("expected pooled output length mismatch error, got: %v", err)
+	}
+}
+
+func TestValidatePooledOutput(t *testing.T) {
+	outputs := []ort.InputOutputInfo{
+		{Name: "last_hidden_state", Dimensions: ort.Shape{-1, -1, 384}},
+		{Name: "pooler_output", Dimensions: ort.Shape{-1, 384}},
+	}
+
+	tests := []struct {
+		name    string
+		output  string
+		dim     int64
+		wantErr string
+	}{
+		{name: "rank 2 pooled output", output: "pooler_output", dim: 384},
+		{name: "token-level output", output: "last_hidden_state", dim: 384, wantErr: "must be rank 2"},
+		{name: "width mismatch", output: "pooler_output", dim: 768, wantErr: "width mismatch"},
+		{name: "missing output", output: "sentence_embedding", dim: 384, wantErr: "does not declare pooled output"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePooledOutput(outputs, tt.output, tt.dim)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSessionForBatchValidatesPooledOutputOnFirstSession(t *testing.T) {
+	original := inspectModel
+	defer func() { inspectModel = original }()
+	inspections := 0
+	inspectModel = func(string) ([]ort.InputOutputInfo, []ort.InputOutputInfo, error) {
+		inspections++
+		return nil, []ort.InputOutputInfo{{Name: "last_hidden_state", Dimensions: ort.Shape{-1, -1, 384}}}, nil
+	}
+
+	// Constructed before ONNX Runtime was initialized, so the output was not checked yet.
+	embedder := &Embedder{modelPath: "model.onnx", pooledOutput: true, outputNames: []string{"last_hidden_state"}}
+	_, err := embedder.sessionForBatchLocked(embedder.defaultSessionSpec(), 1)
+	if err == nil || !strings.Contains(err.Error(), "must be rank 2") {
+		t.Fatalf("expected a rank error before the first session, got: %v", err)
+	}
+	if inspections != 1 || embedder.pooledOutputChecked {
+		t.Fatalf("expected one failed inspection, got %d (checked=%v)", inspections, embedder.pooledOutputChecked)
+	}
+}
+
 type nilSafeDestroyer struct{}
 
 func (d *nilSafeDestroyer) Destroy() error {
@@ -364,18 +1127,641 @@ func TestDestroyAllIgnoresTypedNil(t *testing.T) {
 	}
 }
 
-func assertVectorNearLocal(tb testing.TB, label string, got []float32, want []float32, tolerance float64) {
+func assertVectorNear(tb testing.TB, label string, got []float32, want []float32, tolerance float64) {
 	tb.Helper()
-	if len(got) != len(want) {
-		tb.Fatalf("%s length mismatch: got %d want %d", label, len(got), len(want))
+	if err := ort.ApproxEqual(got, want, tolerance); err != nil {
+		tb.Fatalf("%s: %v", label, err)
+	}
+}
+
+func float32Near(got float32, want float32, tolerance float64) bool {
+	return ort.ApproxEqual([]float32{got}, []float32{want}, tolerance) == nil
+}
+
+func TestWithRunObserverOption(t *testing.T) {
+	cfg := defaultConfig()
+	if err := WithRunObserver(nil)(&cfg); err == nil {
+		t.Fatalf("expected error for nil run observer")
+	}
+	if err := WithRunObserver(func(inputs, outputs map[string]ort.Value) {})(&cfg); err != nil {
+		t.Fatalf("WithRunObserver failed: %v", err)
+	}
+	if cfg.runObserver == nil {
+		t.Fatalf("expected runObserver to be set")
+	}
+}
+
+func TestWithPaddingSideOption(t *testing.T) {
+	cfg := defaultConfig()
+	if cfg.paddingSide != PaddingSideRight {
+		t.Fatalf("expected right padding by default, got %q", cfg.paddingSide)
+	}
+	if err := WithPaddingSide(PaddingSideLeft)(&cfg); err != nil {
+		t.Fatalf("WithPaddingSide failed: %v", err)
+	}
+	if cfg.paddingSide != PaddingSideLeft {
+		t.Fatalf("unexpected padding side: got %q, want %q", cfg.paddingSide, PaddingSideLeft)
+	}
+	if err := WithPaddingSide("center")(&cfg); err == nil || !strings.Contains(err.Error(), "unsupported padding side") {
+		t.Fatalf("expected unsupported padding side error, got: %v", err)
+	}
+}
+
+func TestPostProcessDenseOutputCLSPoolingLeftPadded(t *testing.T) {
+	embeddings, err := postProcessDenseOutput(
+		nil,
+		[]float32{
+			1, 2, 3, 4, 5, 6, // row 0: one padding token, CLS at position 1
+			7, 8, 9, 10, 11, 12, // row 1: no padding
+		},
+		[]int64{0, 1, 1, 1, 1, 1},
+		2,
+		3,
+		2,
+		PoolingStrategyCLS,
+		false,
+		false,
+	)
+	if err != nil {
+		t.Fatalf("postProcessDenseOutput failed: %v", err)
+	}
+	assertVectorNear(t, "left-padded CLS pooling row 0", embeddings[0], []float32{3, 4}, 1e-6)
+	assertVectorNear(t, "left-padded CLS pooling row 1", embeddings[1], []float32{7, 8}, 1e-6)
+}
+
+func TestWithFloatAttentionMaskOption(t *testing.T) {
+	cfg := defaultConfig()
+	if cfg.floatAttentionMask {
+		t.Fatalf("expected int64 attention mask by default")
+	}
+	if err := WithFloatAttentionMask()(&cfg); err != nil {
+		t.Fatalf("WithFloatAttentionMask failed: %v", err)
+	}
+	if !cfg.floatAttentionMask {
+		t.Fatalf("expected floatAttentionMask to be set")
+	}
+}
+
+func TestValidateAttentionMaskType(t *testing.T) {
+	inputsWithMask := func(dataType ort.TensorElementDataType) []ort.InputOutputInfo {
+		return []ort.InputOutputInfo{
+			{Name: "input_ids", DataType: ort.TensorElementDataTypeInt64},
+			{Name: "attention_mask", DataType: dataType},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		inputs  []ort.InputOutputInfo
+		float   bool
+		wantErr string
+	}{
+		{name: "int64 mask", inputs: inputsWithMask(ort.TensorElementDataTypeInt64)},
+		{name: "float mask with option", inputs: inputsWithMask(ort.TensorElementDataTypeFloat), float: true},
+		{name: "float mask without option", inputs: inputsWithMask(ort.TensorElementDataTypeFloat), wantErr: "WithFloatAttentionMask"},
+		{name: "int64 mask with option", inputs: inputsWithMask(ort.TensorElementDataTypeInt64), float: true, wantErr: "declares an int64"},
+		{name: "undeclared mask", inputs: []ort.InputOutputInfo{{Name: "input_ids"}}, float: true},
 	}
-	for i := range got {
-		if math.Abs(float64(got[i]-want[i])) > tolerance {
-			tb.Fatalf("%s mismatch at %d: got %.8f want %.8f", label, i, got[i], want[i])
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAttentionMaskType(tt.inputs, "attention_mask", tt.float)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWithRejectNonFiniteOption(t *testing.T) {
+	cfg := defaultConfig()
+	if cfg.rejectNonFinite {
+		t.Fatalf("expected rejectNonFinite=false by default")
+	}
+	if err := WithRejectNonFinite()(&cfg); err != nil {
+		t.Fatalf("WithRejectNonFinite failed: %v", err)
+	}
+	if !cfg.rejectNonFinite {
+		t.Fatalf("expected rejectNonFinite=true")
+	}
+}
+
+func TestCheckFiniteOutputIgnoresPadding(t *testing.T) {
+	nan := math.NaN()
+	// Two documents of two tokens and width 2; the second token of document 3 is padding.
+	output := []float64{1, 2, 3, 4, 5, 6, nan, math.Inf(1)}
+	mask := []int64{1, 1, 1, 0}
+	if err := checkFiniteOutput(output, mask, false, 2, 2); err != nil {
+		t.Fatalf("expected padded positions to be ignored, got: %v", err)
+	}
+
+	mask[3] = 1
+	err := checkFiniteOutput(output, mask, false, 2, 2)
+	if err == nil || !strings.Contains(err.Error(), "model output for document 3 contains non-finite value NaN at offset 2") {
+		t.Fatalf("expected an attended NaN to be reported for document 3, got: %v", err)
+	}
+
+	// Pooled rows have no token positions, so the whole row is checked.
+	pooled := []float32{1, 2, 3, float32(math.Inf(-1))}
+	err = checkFiniteOutput(pooled, []int64{1, 0, 1, 0}, true, 2, 0)
+	if err == nil || !strings.Contains(err.Error(), "document 1 contains non-finite value -Inf at offset 1") {
+		t.Fatalf("expected a pooled infinity to be reported, got: %v", err)
+	}
+}
+
+func TestWithOutputDataType(t *testing.T) {
+	cfg := defaultConfig()
+	if cfg.outputDataType != ort.TensorElementDataTypeFloat || cfg.outputDataTypeSet {
+		t.Fatalf("expected float32 output by default, got %d (set=%v)", cfg.outputDataType, cfg.outputDataTypeSet)
+	}
+	if err := WithOutputDataType(ort.TensorElementDataTypeFloat16)(&cfg); err != nil {
+		t.Fatalf("WithOutputDataType failed: %v", err)
+	}
+	if cfg.outputDataType != ort.TensorElementDataTypeFloat16 || !cfg.outputDataTypeSet {
+		t.Fatalf("expected explicit float16 output, got %d (set=%v)", cfg.outputDataType, cfg.outputDataTypeSet)
+	}
+	if err := WithOutputDataType(ort.TensorElementDataTypeDouble)(&cfg); err != nil || cfg.outputDataType != ort.TensorElementDataTypeDouble {
+		t.Fatalf("expected explicit float64 output, got %d (err=%v)", cfg.outputDataType, err)
+	}
+	if err := WithOutputDataType(ort.TensorElementDataTypeInt64)(&cfg); err == nil || !strings.Contains(err.Error(), "unsupported output data type") {
+		t.Fatalf("expected unsupported output data type error, got: %v", err)
+	}
+}
+
+func TestResolveOutputDataType(t *testing.T) {
+	outputsWithType := func(dataType ort.TensorElementDataType) []ort.InputOutputInfo {
+		return []ort.InputOutputInfo{{Name: "last_hidden_state", DataType: dataType}}
+	}
+
+	tests := []struct {
+		name       string
+		outputs    []ort.InputOutputInfo
+		configured ort.TensorElementDataType
+		explicit   bool
+		want       ort.TensorElementDataType
+		wantErr    string
+	}{
+		{name: "float32 output", outputs: outputsWithType(ort.TensorElementDataTypeFloat), configured: ort.TensorElementDataTypeFloat, want: ort.TensorElementDataTypeFloat},
+		{name: "float16 output detected", outputs: outputsWithType(ort.TensorElementDataTypeFloat16), configured: ort.TensorElementDataTypeFloat, want: ort.TensorElementDataTypeFloat16},
+		{name: "explicit float16 matches", outputs: outputsWithType(ort.TensorElementDataTypeFloat16), configured: ort.TensorElementDataTypeFloat16, explicit: true, want: ort.TensorElementDataTypeFloat16},
+		{name: "explicit float16 mismatch", outputs: outputsWithType(ort.TensorElementDataTypeFloat), configured: ort.TensorElementDataTypeFloat16, explicit: true, wantErr: "configured with WithOutputDataType"},
+		{name: "float64 output detected", outputs: outputsWithType(ort.TensorElementDataTypeDouble), configured: ort.TensorElementDataTypeFloat, want: ort.TensorElementDataTypeDouble},
+		{name: "explicit float32 with float64 output", outputs: outputsWithType(ort.TensorElementDataTypeDouble), configured: ort.TensorElementDataTypeFloat, explicit: true, wantErr: "has element type 11"},
+		{name: "unsupported declared type", outputs: outputsWithType(ort.TensorElementDataTypeInt64), configured: ort.TensorElementDataTypeFloat, want: ort.TensorElementDataTypeFloat},
+		{name: "undeclared output", outputs: []ort.InputOutputInfo{{Name: "pooler_output"}}, configured: ort.TensorElementDataTypeFloat16, explicit: true, want: ort.TensorElementDataTypeFloat16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveOutputDataType(tt.outputs, "last_hidden_state", tt.configured, tt.explicit)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("resolveOutputDataType = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWidenFloat16OutputMatchesFloat32Pooling(t *testing.T) {
+	hidden := []float32{
+		0.5, -1.25, 2, 0.75, // row 0 tokens
+		1.5, 0.25, 9, 9, // row 1: second token is padding
+	}
+	mask := []int64{1, 1, 1, 0}
+	half := make([]ort.Float16, len(hidden))
+	for i, value := range hidden {
+		half[i] = ort.NewFloat16(value)
+	}
+
+	widened := widenFloat16(nil, half)
+	assertVectorNear(t, "widened output", widened, hidden, 0)
+	if reused := widenFloat16(widened, half[:4]); &reused[0] != &widened[0] {
+		t.Fatalf("expected widenFloat16 to reuse a large enough buffer")
+	}
+
+	got, err := postProcessDenseOutput(nil, widenFloat16(nil, half), mask, 2, 2, 2, PoolingStrategyMean, false, false)
+	if err != nil {
+		t.Fatalf("postProcessDenseOutput failed: %v", err)
+	}
+	want, err := postProcessDenseOutput(nil, hidden, mask, 2, 2, 2, PoolingStrategyMean, false, false)
+	if err != nil {
+		t.Fatalf("postProcessDenseOutput failed: %v", err)
+	}
+	for row := range want {
+		assertVectorNear(t, "float16 pooled row", got[row], want[row], 0)
+	}
+	assertVectorNear(t, "float16 pooled row 1", got[1], []float32{1.5, 0.25}, 0)
+}
+
+func TestEmbedDocumentsWithSeqLenValidation(t *testing.T) {
+	embedder := &Embedder{sequenceLength: 8}
+	for _, length := range []int{0, -1, 9} {
+		if _, err := embedder.EmbedDocumentsWithSeqLen(length, []string{"a"}); err == nil || !strings.Contains(err.Error(), "between 1 and the configured 8") {
+			t.Fatalf("expected sequence length error for %d, got: %v", length, err)
 		}
 	}
+	if _, err := (*Embedder)(nil).EmbedDocumentsWithSeqLen(4, []string{"a"}); err == nil || !strings.Contains(err.Error(), "embedder is nil") {
+		t.Fatalf("expected nil embedder error, got: %v", err)
+	}
 }
 
-func float32Near(got float32, want float32, tolerance float64) bool {
-	return math.Abs(float64(got-want)) <= tolerance
+func TestTruncateEncoding(t *testing.T) {
+	// [CLS] a b c d [SEP] [PAD] [PAD]
+	encoding := &tokenizers.EncodeResult{
+		IDs:               []uint32{101, 1, 2, 3, 4, 102, 0, 0},
+		TypeIDs:           []uint32{0, 0, 0, 0, 0, 0, 0, 0},
+		SpecialTokensMask: []uint32{1, 0, 0, 0, 0, 1, 1, 1},
+		AttentionMask:     []uint32{1, 1, 1, 1, 1, 1, 0, 0},
+	}
+
+	tests := []struct {
+		name    string
+		length  int
+		wantIDs []uint32
+	}{
+		{name: "fits with padding", length: 7, wantIDs: encoding.IDs},
+		{name: "fits exactly", length: 6, wantIDs: encoding.IDs},
+		{name: "keeps trailing separator", length: 4, wantIDs: []uint32{101, 1, 2, 102}},
+		{name: "only special tokens", length: 2, wantIDs: []uint32{101, 102}},
+		{name: "too short for the separator", length: 1, wantIDs: []uint32{101}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateEncoding(encoding, tt.length)
+			if !reflect.DeepEqual(got.IDs, tt.wantIDs) {
+				t.Fatalf("unexpected ids: got %v, want %v", got.IDs, tt.wantIDs)
+			}
+			if got != encoding {
+				for i, mask := range got.AttentionMask {
+					if mask != 1 {
+						t.Fatalf("expected every kept token to be attended, got mask %v at %d", got.AttentionMask, i)
+					}
+				}
+				if len(got.TypeIDs) != len(got.IDs) {
+					t.Fatalf("expected type ids to be truncated with ids, got %v", got.TypeIDs)
+				}
+			}
+		})
+	}
+
+	// Without a special tokens mask, truncation simply keeps the leading tokens.
+	plain := &tokenizers.EncodeResult{IDs: []uint32{101, 1, 2, 102}}
+	if got := truncateEncoding(plain, 3); !reflect.DeepEqual(got.IDs, []uint32{101, 1, 2}) || got.AttentionMask != nil {
+		t.Fatalf("unexpected truncation without masks: %+v", got)
+	}
+}
+
+func TestSyncFloatAttentionMask(t *testing.T) {
+	session := &embeddingSession{
+		attentionMask:      []int64{1, 1, 0, 1, 0, 0},
+		floatAttentionMask: []float32{0, 0, 1, 0, 1, 1},
+	}
+	session.syncFloatAttentionMask()
+
+	want := []float32{1, 1, 0, 1, 0, 0}
+	for i := range want {
+		if session.floatAttentionMask[i] != want[i] {
+			t.Fatalf("unexpected float mask: got %v, want %v", session.floatAttentionMask, want)
+		}
+	}
+
+	intOnly := &embeddingSession{attentionMask: []int64{1, 0}}
+	intOnly.syncFloatAttentionMask()
+	if intOnly.floatAttentionMask != nil {
+		t.Fatalf("expected no float mask buffer for int64 sessions")
+	}
+}
+
+func TestEmbedOnceReportsInitializationFailure(t *testing.T) {
+	if ort.IsInitialized() {
+		t.Skip("ONNX Runtime already initialized")
+	}
+	t.Setenv("ONNXRUNTIME_LIB_PATH", filepath.Join(t.TempDir(), "missing-libonnxruntime.so"))
+
+	_, err := EmbedOnce("model.onnx", "tokenizer.json", []string{"hello"})
+	if err == nil || !strings.Contains(err.Error(), "failed to initialize ONNX Runtime") {
+		t.Fatalf("expected initialization error, got: %v", err)
+	}
+	if ort.IsInitialized() {
+		t.Fatalf("expected runtime to remain uninitialized after failure")
+	}
+}
+
+func TestWithExecutionProvidersOption(t *testing.T) {
+	cfg := defaultConfig()
+	if cfg.executionProviders != nil {
+		t.Fatalf("expected no execution providers by default, got %v", cfg.executionProviders)
+	}
+	providers := []ort.ProviderSpec{{Name: "CUDAExecutionProvider", Options: map[string]string{"device_id": "0"}}}
+	if err := WithExecutionProviders(providers)(&cfg); err != nil {
+		t.Fatalf("WithExecutionProviders failed: %v", err)
+	}
+	providers[0].Options["device_id"] = "1"
+	if got := cfg.executionProviders[0].Options["device_id"]; got != "0" {
+		t.Fatalf("expected the provider list to be copied, got device_id=%q", got)
+	}
+	if err := WithExecutionProviders(nil)(&cfg); err == nil || !strings.Contains(err.Error(), "cannot be empty") {
+		t.Fatalf("expected empty provider list error, got: %v", err)
+	}
+}
+
+func TestSessionSpecKeyIncludesExecutionProviders(t *testing.T) {
+	cuda := func(options map[string]string) []ort.ProviderSpec {
+		return []ort.ProviderSpec{{Name: "CUDAExecutionProvider", Options: options}, {Name: "CPUExecutionProvider"}}
+	}
+	base := sessionSpec{sequenceLength: 256}
+	withCUDA := sessionSpec{sequenceLength: 256, providers: cuda(map[string]string{"device_id": "0", "arena_extend_strategy": "kSameAsRequested"})}
+
+	if base.key(2) == withCUDA.key(2) {
+		t.Fatalf("expected different keys for different execution providers, got %+v", base.key(2))
+	}
+	if withCUDA.key(2) == withCUDA.key(4) {
+		t.Fatalf("expected different keys for different batch sizes")
+	}
+	otherDevice := sessionSpec{sequenceLength: 256, providers: cuda(map[string]string{"device_id": "1", "arena_extend_strategy": "kSameAsRequested"})}
+	if withCUDA.key(2) == otherDevice.key(2) {
+		t.Fatalf("expected different keys for different provider options")
+	}
+	// Option order in the map does not matter; provider order does.
+	for i := 0; i < 10; i++ {
+		same := sessionSpec{sequenceLength: 256, providers: cuda(map[string]string{"arena_extend_strategy": "kSameAsRequested", "device_id": "0"})}
+		if same.key(2) != withCUDA.key(2) {
+			t.Fatalf("expected equal keys for equal provider options, got %q and %q", same.key(2).providers, withCUDA.key(2).providers)
+		}
+	}
+	reordered := sessionSpec{sequenceLength: 256, providers: []ort.ProviderSpec{{Name: "CPUExecutionProvider"}, {Name: "CUDAExecutionProvider"}}}
+	if reordered.key(2) == (sessionSpec{sequenceLength: 256, providers: cuda(nil)}).key(2) {
+		t.Fatalf("expected provider priority order to be part of the key")
+	}
+	// Quoting keeps names and options from running together.
+	a := sessionSpec{providers: []ort.ProviderSpec{{Name: "A", Options: map[string]string{"k": "v;\"B\""}}}}
+	b := sessionSpec{providers: []ort.ProviderSpec{{Name: "A", Options: map[string]string{"k": "v"}}, {Name: "B"}}}
+	if a.key(1) == b.key(1) {
+		t.Fatalf("expected distinct keys for ambiguous provider encodings")
+	}
+}
+
+func TestSessionForBatchCachesSessionsPerExecutionProviders(t *testing.T) {
+	original := newSession
+	defer func() { newSession = original }()
+	created := 0
+	newSession = func(string, []string, []string, int, int, int64, bool, bool, bool, ort.TensorElementDataType, []ort.ProviderSpec) (*embeddingSession, error) {
+		created++
+		return &embeddingSession{}, nil
+	}
+
+	e := &Embedder{sessions: ortutil.NewSessionCache[sessionKey, *embeddingSession](4, describeSessionKey)}
+	cpu := sessionSpec{sequenceLength: 256}
+	cuda := sessionSpec{sequenceLength: 256, providers: []ort.ProviderSpec{{Name: "CUDAExecutionProvider"}, {Name: "CPUExecutionProvider"}}}
+
+	cpuSession, err := e.sessionForBatchLocked(cpu, 2)
+	if err != nil {
+		t.Fatalf("CPU session failed: %v", err)
+	}
+	cudaSession, err := e.sessionForBatchLocked(cuda, 2)
+	if err != nil {
+		t.Fatalf("CUDA session failed: %v", err)
+	}
+	if again, err := e.sessionForBatchLocked(cuda, 2); err != nil || again != cudaSession {
+		t.Fatalf("expected the CUDA session to be reused, got %p (%v), want %p", again, err, cudaSession)
+	}
+
+	if created != 2 || e.sessions.Len() != 2 {
+		t.Fatalf("expected two cache entries, got %d entries after %d creations", e.sessions.Len(), created)
+	}
+	if cached, ok := e.sessions.Lookup(cpu.key(2)); !ok || cached != cpuSession {
+		t.Fatalf("expected the CPU entry to hold the CPU session")
+	}
+	if cached, ok := e.sessions.Lookup(cuda.key(2)); !ok || cached != cudaSession || cached == cpuSession {
+		t.Fatalf("expected the CUDA entry to hold its own session")
+	}
+	if err := e.sessions.DestroyAll(); err != nil {
+		t.Fatalf("DestroyAll failed: %v", err)
+	}
+}
+
+func TestEncodeOptionsRequestsTypeIDsOnlyWhenUsed(t *testing.T) {
+	apply := func(opts []tokenizers.EncodeOption) tokenizers.EncodeOptions {
+		var eo tokenizers.EncodeOptions
+		for _, opt := range opts {
+			if err := opt(&eo); err != nil {
+				t.Fatalf("encode option failed: %v", err)
+			}
+		}
+		return eo
+	}
+
+	withoutTypeIDs := apply(encodeOptions(false, false))
+	if withoutTypeIDs.ReturnTypeIDs {
+		t.Fatalf("expected type ids not to be requested for a 2-input model")
+	}
+	if !withoutTypeIDs.AddSpecialTokens || !withoutTypeIDs.ReturnAttentionMask || withoutTypeIDs.ReturnSpecialTokensMask {
+		t.Fatalf("unexpected encode options: %+v", withoutTypeIDs)
+	}
+	if withTypeIDs := apply(encodeOptions(true, false)); !withTypeIDs.ReturnTypeIDs {
+		t.Fatalf("expected type ids to be requested when token_type_ids is an input")
+	}
+	if shortened := apply(encodeOptions(false, true)); !shortened.ReturnSpecialTokensMask || shortened.ReturnTypeIDs {
+		t.Fatalf("expected only the special tokens mask to be added for shortened encodings, got %+v", shortened)
+	}
+}
+
+func TestPostProcessWritesIntoDestinationRows(t *testing.T) {
+	hidden := []float32{
+		1, 2, 3, 4, // row 0 tokens
+		5, 6, 7, 8, // row 1 tokens
+	}
+	mask := []int64{1, 1, 1, 0}
+	dst := [][]float32{{9, 9}, {9, 9}}
+	rows := [][]float32{dst[0], dst[1]}
+
+	embeddings, err := postProcessDenseOutput(dst, hidden, mask, 2, 2, 2, PoolingStrategyMean, false, false)
+	if err != nil {
+		t.Fatalf("postProcessDenseOutput failed: %v", err)
+	}
+	for i := range dst {
+		if &embeddings[i][0] != &rows[i][0] {
+			t.Fatalf("expected row %d to be written into the destination buffer", i)
+		}
+	}
+	assertVectorNear(t, "mean pooled row 0", dst[0], []float32{2, 3}, 1e-6)
+	assertVectorNear(t, "mean pooled row 1", dst[1], []float32{5, 6}, 1e-6)
+
+	pooled, err := postProcessPooledOutput(dst, []float32{3, 4, 0, 2}, 2, 2, true)
+	if err != nil {
+		t.Fatalf("postProcessPooledOutput failed: %v", err)
+	}
+	if &pooled[1][0] != &rows[1][0] {
+		t.Fatalf("expected pooled rows to be written into the destination buffer")
+	}
+	assertVectorNear(t, "pooled row 0", dst[0], []float32{0.6, 0.8}, 1e-6)
+	assertVectorNear(t, "pooled row 1", dst[1], []float32{0, 1}, 1e-6)
+}
+
+func TestEmbedIntoValidation(t *testing.T) {
+	var nilEmbedder *Embedder
+	if err := nilEmbedder.EmbedInto([]string{"a"}, make([][]float32, 1)); err == nil || !strings.Contains(err.Error(), "embedder is nil") {
+		t.Fatalf("expected nil embedder error, got: %v", err)
+	}
+	embedder := &Embedder{}
+	if err := embedder.EmbedInto([]string{"a", "b"}, make([][]float32, 1)); err == nil || !strings.Contains(err.Error(), "dst has 1 rows, want at least 2") {
+		t.Fatalf("expected too-small dst error, got: %v", err)
+	}
+	if err := checkDestinationRows([][]float32{make([]float32, 4), make([]float32, 3)}, 8, 4); err == nil || !strings.Contains(err.Error(), "dst row 9 has length 3, want 4") {
+		t.Fatalf("expected dst row width error, got: %v", err)
+	}
+}
+
+func TestFloat64OutputPoolsBeforeNarrowing(t *testing.T) {
+	// A float64 export declares its output as double; the embedder must allocate a float64
+	// tensor instead of reading its bytes as float32.
+	outputs := []ort.InputOutputInfo{{Name: "last_hidden_state", DataType: ort.TensorElementDataTypeDouble}}
+	dataType, err := resolveOutputDataType(outputs, "last_hidden_state", ort.TensorElementDataTypeFloat, false)
+	if err != nil || dataType != ort.TensorElementDataTypeDouble {
+		t.Fatalf("expected float64 output to be detected, got %d (err=%v)", dataType, err)
+	}
+
+	wide := []float64{
+		0.5, -1.25, 2, 0.75, // row 0 tokens
+		1.5, 0.25, 9, 9, // row 1: second token is padding
+	}
+	hidden := make([]float32, len(wide))
+	for i, value := range wide {
+		hidden[i] = float32(value)
+	}
+	mask := []int64{1, 1, 1, 0}
+
+	narrowed := narrowFloat64(nil, wide)
+	assertVectorNear(t, "narrowed output", narrowed, hidden, 0)
+	if reused := narrowFloat64(narrowed, wide[:4]); &reused[0] != &narrowed[0] {
+		t.Fatalf("expected narrowFloat64 to reuse a large enough buffer")
+	}
+
+	got, err := meanPoolFloat64Output(nil, wide, mask, 2, 2, 2, true)
+	if err != nil {
+		t.Fatalf("meanPoolFloat64Output failed: %v", err)
+	}
+	want, err := postProcessDenseOutput(nil, hidden, mask, 2, 2, 2, PoolingStrategyMean, true, false)
+	if err != nil {
+		t.Fatalf("postProcessDenseOutput failed: %v", err)
+	}
+	for i := range want {
+		assertVectorNear(t, "float64 pooled row", got[i], want[i], 1e-7)
+	}
+	assertVectorNear(t, "float64 pooled row 1", got[1], []float32{0.98639392, 0.16439899}, 1e-6)
+
+	// 1e7+0.3 has no float32 representation; narrowing before pooling would lose the
+	// 0.3 and average to 0 instead of 0.15.
+	cancelling := []float64{1e7 + 0.3, -1e7}
+	pooled, err := meanPoolFloat64Output(nil, cancelling, []int64{1, 1}, 1, 2, 1, false)
+	if err != nil {
+		t.Fatalf("meanPoolFloat64Output failed: %v", err)
+	}
+	assertVectorNear(t, "float64 pooled cancellation", pooled[0], []float32{0.15}, 1e-6)
+
+	if _, err := meanPoolFloat64Output(nil, wide[:6], mask, 2, 2, 2, true); err == nil || !strings.Contains(err.Error(), "last_hidden_state length mismatch") {
+		t.Fatalf("expected length mismatch error, got: %v", err)
+	}
+}
+
+func TestInfoReportsConfiguration(t *testing.T) {
+	modelPath := filepath.Join(t.TempDir(), "model.onnx")
+	if err := os.WriteFile(modelPath, []byte("model"), 0o600); err != nil {
+		t.Fatalf("failed to write model: %v", err)
+	}
+	modelSHA256, err := ort.ModelSHA256(modelPath)
+	if err != nil {
+		t.Fatalf("ModelSHA256 failed: %v", err)
+	}
+	e := &Embedder{
+		modelPath:          modelPath,
+		modelSHA256:        modelSHA256,
+		tokenizerPath:      "tokenizer.json",
+		sequenceLength:     128,
+		embeddingDimension: 384,
+		poolingStrategy:    PoolingStrategyCLS,
+		paddingSide:        PaddingSideLeft,
+		l2Normalize:        true,
+	}
+	sum := sha256.Sum256([]byte("model"))
+	want := EmbedderInfo{
+		ModelPath:          modelPath,
+		TokenizerPath:      "tokenizer.json",
+		SequenceLength:     128,
+		PoolingStrategy:    PoolingStrategyCLS,
+		PaddingSide:        PaddingSideLeft,
+		L2Normalize:        true,
+		EmbeddingDimension: 384,
+		ModelSHA256:        hex.EncodeToString(sum[:]),
+	}
+	if got := e.Info(); got != want {
+		t.Fatalf("unexpected info:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	// The hash describes the model the embedder was constructed with, not whatever
+	// file is at the path now.
+	if err := os.WriteFile(modelPath, []byte("replaced model"), 0o600); err != nil {
+		t.Fatalf("failed to replace model: %v", err)
+	}
+	if got := e.Info(); got != want {
+		t.Fatalf("expected the construction-time hash after the model was replaced, got %+v", got)
+	}
+
+	var nilEmbedder *Embedder
+	if got := nilEmbedder.Info(); got != (EmbedderInfo{}) {
+		t.Fatalf("expected zero info for nil embedder, got %+v", got)
+	}
+}
+
+func TestNewEmbedderRejectsSequenceLengthAboveModelPositions(t *testing.T) {
+	dir := t.TempDir()
+	// A ModelProto whose graph declares a [512, 384] BERT position embedding table.
+	var tensor []byte
+	for _, dim := range []uint64{512, 384} {
+		tensor = binary.AppendUvarint(tensor, 1<<3)
+		tensor = binary.AppendUvarint(tensor, dim)
+	}
+	name := "embeddings.position_embeddings.weight"
+	tensor = binary.AppendUvarint(tensor, 8<<3|2)
+	tensor = binary.AppendUvarint(tensor, uint64(len(name)))
+	tensor = append(tensor, name...)
+	graph := binary.AppendUvarint(nil, 5<<3|2)
+	graph = binary.AppendUvarint(graph, uint64(len(tensor)))
+	graph = append(graph, tensor...)
+	model := binary.AppendUvarint(nil, 7<<3|2)
+	model = binary.AppendUvarint(model, uint64(len(graph)))
+	model = append(model, graph...)
+
+	modelPath := filepath.Join(dir, "model.onnx")
+	tokenizerPath := filepath.Join(dir, "tokenizer.json")
+	if err := os.WriteFile(modelPath, model, 0o600); err != nil {
+		t.Fatalf("failed to write model: %v", err)
+	}
+	if err := os.WriteFile(tokenizerPath, []byte("{}"), 0o600); err != nil {
+		t.Fatalf("failed to write tokenizer: %v", err)
+	}
+
+	_, err := NewEmbedder(modelPath, tokenizerPath, WithSequenceLength(1024))
+	if err == nil || !strings.Contains(err.Error(), "sequence length 1024 exceeds the model's 512 position embeddings") {
+		t.Fatalf("expected oversized sequence length error, got: %v", err)
+	}
+
+	_, err = NewEmbedder(modelPath, tokenizerPath, WithSequenceLength(1024), WithClampSequenceLength())
+	if err != nil && strings.Contains(err.Error(), "exceeds the model's") {
+		t.Fatalf("expected the sequence length to be clamped, got: %v", err)
+	}
 }
@@ -0,0 +1,173 @@
+package minilm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PostProcessStep is one stage of an output pipeline configured with WithOutputPipeline.
+// Build steps with PoolStep, TruncateStep, L2NormalizeStep, and MaxNormalizeStep.
+type PostProcessStep struct {
+	name string
+	// pooling is set for PoolStep, which selects the pooling strategy instead of
+	// transforming pooled rows.
+	pooling PoolingStrategy
+	// truncateTo is the TruncateStep dimension, 0 for other steps; EmbedInto cannot
+	// write shortened rows in place.
+	truncateTo int
+	// err reports an invalid step argument when the pipeline is configured.
+	err   error
+	apply func(rows [][]float32) ([][]float32, error)
+}
+
+// String returns the step name, for example "pool(mean)" or "truncate(256)".
+func (s PostProcessStep) String() string {
+	return s.name
+}
+
+// PoolStep reduces token embeddings to one row per document with strategy
+// (PoolingStrategyMean or PoolingStrategyCLS). It may only be the first step.
+func PoolStep(strategy PoolingStrategy) PostProcessStep {
+	return PostProcessStep{name: fmt.Sprintf("pool(%s)", strategy), pooling: strategy}
+}
+
+// TruncateStep keeps the first dimension components of each row, as Matryoshka
+// embedding models allow. Rows shorter than dimension fail the call.
+func TruncateStep(dimension int) PostProcessStep {
+	step := PostProcessStep{
+		name:       fmt.Sprintf("truncate(%d)", dimension),
+		truncateTo: dimension,
+		apply: func(rows [][]float32) ([][]float32, error) {
+			for i, row := range rows {
+				if len(row) < dimension {
+					return nil, fmt.Errorf("cannot truncate %d-dimensional embeddings to %d dimensions", len(row), dimension)
+				}
+				rows[i] = row[:dimension:dimension]
+			}
+			return rows, nil
+		},
+	}
+	if dimension <= 0 {
+		step.err = fmt.Errorf("truncate dimension must be > 0, got %d", dimension)
+	}
+	return step
+}
+
+// L2NormalizeStep scales each row to unit L2 norm.
+func L2NormalizeStep() PostProcessStep {
+	return PostProcessStep{
+		name: "l2_normalize",
+		apply: func(rows [][]float32) ([][]float32, error) {
+			l2NormalizeRows(rows)
+			return rows, nil
+		},
+	}
+}
+
+// MaxNormalizeStep divides each row by its largest absolute component.
+func MaxNormalizeStep() PostProcessStep {
+	return PostProcessStep{
+		name: "max_normalize",
+		apply: func(rows [][]float32) ([][]float32, error) {
+			maxNormalizeRows(rows)
+			return rows, nil
+		},
+	}
+}
+
+// WithOutputPipeline replaces the default pool-then-normalize post-processing with
+// steps applied in order, for example pool, truncate, then normalize:
+//
+//	minilm.WithOutputPipeline(minilm.PoolStep(minilm.PoolingStrategyMean), minilm.TruncateStep(256), minilm.L2NormalizeStep())
+//
+// A leading PoolStep sets the pooling strategy; without one the configured strategy is
+// used. Normalization happens only where a step requests it, so the pipeline cannot be
+// combined with WithL2Normalization, WithMaxNormNormalization, or WithAssumeNormalizedOutput.
+// The default pipeline is PoolStep(PoolingStrategyMean) followed by L2NormalizeStep().
+func WithOutputPipeline(steps ...PostProcessStep) Option {
+	return func(cfg *config) error {
+		if len(steps) == 0 {
+			return fmt.Errorf("output pipeline must have at least one step")
+		}
+		for i, step := range steps {
+			switch {
+			case step.pooling != "":
+				if i != 0 {
+					return fmt.Errorf("output pipeline step %d: %s must be the first step", i, step)
+				}
+				if step.pooling != PoolingStrategyMean && step.pooling != PoolingStrategyCLS {
+					return fmt.Errorf("output pipeline step %d: unsupported pooling strategy %q; use mean or cls", i, step.pooling)
+				}
+			case step.err != nil:
+				return fmt.Errorf("output pipeline step %d: %w", i, step.err)
+			case step.apply == nil:
+				return fmt.Errorf("output pipeline step %d is not initialized", i)
+			}
+		}
+		cfg.outputPipeline = append([]PostProcessStep(nil), steps...)
+		return nil
+	}
+}
+
+// resolveOutputPipeline applies a configured output pipeline to cfg: a leading PoolStep
+// sets the pooling strategy and the built-in normalization is disabled. It returns the
+// steps that run on pooled rows.
+func resolveOutputPipeline(cfg *config) ([]PostProcessStep, error) {
+	if cfg.outputPipeline == nil {
+		return nil, nil
+	}
+	if cfg.l2NormalizeExplicit || cfg.maxNormalize || cfg.assumeNormalized {
+		return nil, fmt.Errorf("WithOutputPipeline cannot be combined with WithL2Normalization, WithMaxNormNormalization, or WithAssumeNormalizedOutput; add a normalization step instead")
+	}
+	steps := cfg.outputPipeline
+	if steps[0].pooling != "" {
+		if cfg.pooledOutput {
+			return nil, fmt.Errorf("WithOutputPipeline: %s cannot be used when the model output is already pooled", steps[0])
+		}
+		cfg.poolingStrategy = steps[0].pooling
+		steps = steps[1:]
+	}
+	if cfg.poolingStrategy == PoolingStrategyNone && !cfg.pooledOutput && len(steps) > 0 {
+		return nil, fmt.Errorf("WithOutputPipeline steps need pooled rows; they cannot be combined with WithNoPooling")
+	}
+	cfg.l2Normalize = false
+	return steps, nil
+}
+
+// applyPostProcessSteps runs steps over pooled rows in order.
+func applyPostProcessSteps(steps []PostProcessStep, rows [][]float32) ([][]float32, error) {
+	var err error
+	for _, step := range steps {
+		rows, err = step.apply(rows)
+		if err != nil {
+			return nil, fmt.Errorf("output pipeline step %s failed: %w", step, err)
+		}
+	}
+	return rows, nil
+}
+
+// describePostProcessSteps names the pipeline steps, for EmbedderInfo.
+func describePostProcessSteps(steps []PostProcessStep) string {
+	names := make([]string, len(steps))
+	for i, step := range steps {
+		names[i] = step.String()
+	}
+	return strings.Join(names, ",")
+}
+
+// truncatesRows reports whether any step shortens rows.
+func truncatesRows(steps []PostProcessStep) bool {
+	return truncatedWidth(steps) > 0
+}
+
+// truncatedWidth returns the row width left by the last TruncateStep, or 0 when no
+// step shortens rows.
+func truncatedWidth(steps []PostProcessStep) int {
+	width := 0
+	for _, step := range steps {
+		if step.truncateTo > 0 {
+			width = step.truncateTo
+		}
+	}
+	return width
+}
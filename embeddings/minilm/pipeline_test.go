@@ -0,0 +1,121 @@
+package minilm
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestOutputPipelinePoolTruncateNormalize(t *testing.T) {
+	cfg := defaultConfig()
+	if err := WithOutputPipeline(PoolStep(PoolingStrategyMean), TruncateStep(2), L2NormalizeStep())(&cfg); err != nil {
+		t.Fatalf("WithOutputPipeline failed: %v", err)
+	}
+	steps, err := resolveOutputPipeline(&cfg)
+	if err != nil {
+		t.Fatalf("resolveOutputPipeline failed: %v", err)
+	}
+	if cfg.poolingStrategy != PoolingStrategyMean || cfg.l2Normalize {
+		t.Fatalf("expected mean pooling without built-in normalization, got %q and l2Normalize=%v", cfg.poolingStrategy, cfg.l2Normalize)
+	}
+	if got, want := describePostProcessSteps(cfg.outputPipeline), "pool(mean),truncate(2),l2_normalize"; got != want {
+		t.Fatalf("unexpected pipeline description: got %q, want %q", got, want)
+	}
+
+	// One document, three positions (the last padded), hidden width 3.
+	hidden := []float32{
+		1, 2, 10,
+		3, 6, -4,
+		100, 100, 100,
+	}
+	mask := []int64{1, 1, 0}
+	pooled, err := postProcessDenseOutput(nil, hidden, mask, 1, 3, 3, cfg.poolingStrategy, cfg.l2Normalize, false)
+	if err != nil {
+		t.Fatalf("postProcessDenseOutput failed: %v", err)
+	}
+	rows, err := applyPostProcessSteps(steps, pooled)
+	if err != nil {
+		t.Fatalf("applyPostProcessSteps failed: %v", err)
+	}
+
+	// Manual: mean of attended rows is [2, 4, 3]; truncating keeps [2, 4]; its L2 norm is sqrt(20).
+	norm := float32(math.Sqrt(20))
+	want := []float32{2 / norm, 4 / norm}
+	if len(rows) != 1 || len(rows[0]) != len(want) {
+		t.Fatalf("unexpected output shape: %v", rows)
+	}
+	for i := range want {
+		if math.Abs(float64(rows[0][i]-want[i])) > 1e-6 {
+			t.Fatalf("unexpected pipeline output: got %v, want %v", rows[0], want)
+		}
+	}
+
+	// Normalizing before truncating is a different pipeline and a different result.
+	pooled, err = postProcessDenseOutput(nil, hidden, mask, 1, 3, 3, PoolingStrategyMean, false, false)
+	if err != nil {
+		t.Fatalf("postProcessDenseOutput failed: %v", err)
+	}
+	rows, err = applyPostProcessSteps([]PostProcessStep{L2NormalizeStep(), TruncateStep(2)}, pooled)
+	if err != nil {
+		t.Fatalf("applyPostProcessSteps failed: %v", err)
+	}
+	if norm := l2Norm(rows[0]); math.Abs(float64(norm)-1) < 1e-3 {
+		t.Fatalf("expected normalize-then-truncate to leave a non-unit row, got norm %v", norm)
+	}
+
+	if _, err := applyPostProcessSteps([]PostProcessStep{TruncateStep(4)}, [][]float32{{1, 2, 3}}); err == nil || !strings.Contains(err.Error(), "cannot truncate 3-dimensional embeddings to 4 dimensions") {
+		t.Fatalf("expected truncation error, got: %v", err)
+	}
+}
+
+func TestWithOutputPipelineValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr string
+	}{
+		{name: "empty", opts: []Option{WithOutputPipeline()}, wantErr: "at least one step"},
+		{name: "pool not first", opts: []Option{WithOutputPipeline(L2NormalizeStep(), PoolStep(PoolingStrategyCLS))}, wantErr: "must be the first step"},
+		{name: "no pooling", opts: []Option{WithOutputPipeline(PoolStep(PoolingStrategyNone))}, wantErr: "unsupported pooling strategy"},
+		{name: "bad truncate", opts: []Option{WithOutputPipeline(TruncateStep(0))}, wantErr: "truncate dimension must be > 0"},
+		{name: "zero step", opts: []Option{WithOutputPipeline(PostProcessStep{})}, wantErr: "not initialized"},
+		{name: "explicit normalization", opts: []Option{WithL2Normalization(), WithOutputPipeline(TruncateStep(8))}, wantErr: "cannot be combined with WithL2Normalization"},
+		{name: "token output", opts: []Option{WithNoPooling(), WithOutputPipeline(TruncateStep(8))}, wantErr: "cannot be combined with WithNoPooling"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			var err error
+			for _, opt := range tt.opts {
+				if err = opt(&cfg); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				_, err = resolveOutputPipeline(&cfg)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEmbeddingDimensionReportsTruncatedWidth(t *testing.T) {
+	steps := []PostProcessStep{PoolStep(PoolingStrategyMean), TruncateStep(256), TruncateStep(128), L2NormalizeStep()}
+	embedder := &Embedder{embeddingDimension: 384, outputSteps: steps}
+	if got := embedder.EmbeddingDimension(); got != 128 {
+		t.Fatalf("unexpected truncated dimension: got %d, want 128", got)
+	}
+	if got := embedder.hiddenDimension(); got != 384 {
+		t.Fatalf("unexpected hidden dimension: got %d, want 384", got)
+	}
+
+	// Until WithAutoEmbeddingDimension has seen a batch, the width is unknown either way.
+	if got := (&Embedder{outputSteps: steps}).EmbeddingDimension(); got != 0 {
+		t.Fatalf("expected 0 before the hidden width is detected, got %d", got)
+	}
+	if got := (&Embedder{embeddingDimension: 384}).EmbeddingDimension(); got != 384 {
+		t.Fatalf("unexpected untruncated dimension: got %d, want 384", got)
+	}
+}
@@ -0,0 +1,53 @@
+package minilm
+
+import (
+	"fmt"
+	"math"
+)
+
+// EmbedDocumentsQuantized embeds documents like EmbedDocuments, L2-normalizes each
+// embedding for cosine storage, and quantizes it to int8 for vector stores, a quarter
+// of the float32 size. Quantization is symmetric: row i has scale[i] = max|v|/127, so
+// vectors[i][j] = round(v[j]/scale[i]) lies in [-127, 127] and float32(vectors[i][j]) *
+// scales[i] recovers v[j] to within scales[i]/2 (at most 1/254 for a unit vector). An
+// all-zero embedding has scale 0. Token-level output (PoolingStrategyNone) is not
+// supported.
+func (e *Embedder) EmbedDocumentsQuantized(documents []string) ([][]int8, []float32, error) {
+	if e == nil {
+		return nil, nil, fmt.Errorf("embedder is nil")
+	}
+	if e.poolingStrategy == PoolingStrategyNone && !e.pooledOutput {
+		return nil, nil, fmt.Errorf("EmbedDocumentsQuantized needs pooled embeddings, not token-level output")
+	}
+	embeddings, err := e.EmbedDocuments(documents)
+	if err != nil {
+		return nil, nil, err
+	}
+	l2NormalizeRows(embeddings)
+	vectors, scales := quantizeRowsInt8(embeddings)
+	return vectors, scales, nil
+}
+
+// quantizeRowsInt8 symmetrically quantizes each row to int8 with a per-row scale of
+// max|v|/127.
+func quantizeRowsInt8(rows [][]float32) ([][]int8, []float32) {
+	vectors := make([][]int8, len(rows))
+	scales := make([]float32, len(rows))
+	for i, row := range rows {
+		var maxAbs float32
+		for _, value := range row {
+			maxAbs = max(maxAbs, float32(math.Abs(float64(value))))
+		}
+		vector := make([]int8, len(row))
+		if maxAbs > 0 {
+			scale := maxAbs / math.MaxInt8
+			for j, value := range row {
+				q := math.Round(float64(value / scale))
+				vector[j] = int8(min(max(q, -math.MaxInt8), math.MaxInt8))
+			}
+			scales[i] = scale
+		}
+		vectors[i] = vector
+	}
+	return vectors, scales
+}
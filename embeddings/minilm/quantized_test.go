@@ -0,0 +1,64 @@
+package minilm
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestQuantizeRowsInt8RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	rows := make([][]float32, 8)
+	for i := range rows {
+		rows[i] = make([]float32, 384)
+		for j := range rows[i] {
+			rows[i][j] = float32(rng.NormFloat64())
+		}
+	}
+	rows = append(rows, []float32{0, 0, 0}, []float32{-1, 0.5, 0})
+	l2NormalizeRows(rows[:8])
+
+	vectors, scales := quantizeRowsInt8(rows)
+	if len(vectors) != len(rows) || len(scales) != len(rows) {
+		t.Fatalf("unexpected result lengths: vectors=%d scales=%d, want %d", len(vectors), len(scales), len(rows))
+	}
+	for i, row := range rows {
+		if len(vectors[i]) != len(row) {
+			t.Fatalf("row %d: unexpected width %d, want %d", i, len(vectors[i]), len(row))
+		}
+		if scales[i] > 1.0/127+1e-7 {
+			t.Fatalf("row %d: scale %v exceeds the unit-vector bound", i, scales[i])
+		}
+		sawExtreme := false
+		for j, value := range row {
+			q := vectors[i][j]
+			if q == math.MinInt8 {
+				t.Fatalf("row %d: quantized value %d outside the symmetric range", i, q)
+			}
+			sawExtreme = sawExtreme || q == 127 || q == -127
+			if got := float32(q) * scales[i]; float32(math.Abs(float64(got-value))) > scales[i]/2+1e-7 {
+				t.Fatalf("row %d col %d: dequantized %v, want %v within %v", i, j, got, value, scales[i]/2)
+			}
+		}
+		if scales[i] > 0 && !sawExtreme {
+			t.Fatalf("row %d: expected the largest component to map to +/-127", i)
+		}
+	}
+	if scales[8] != 0 {
+		t.Fatalf("expected zero scale for an all-zero row, got %v", scales[8])
+	}
+	if want := []int8{-127, 64, 0}; vectors[9][0] != want[0] || vectors[9][1] != want[1] || vectors[9][2] != want[2] {
+		t.Fatalf("unexpected quantized row: got %v, want %v", vectors[9], want)
+	}
+}
+
+func TestEmbedDocumentsQuantizedValidation(t *testing.T) {
+	var embedder *Embedder
+	if _, _, err := embedder.EmbedDocumentsQuantized([]string{"x"}); err == nil || !strings.Contains(err.Error(), "embedder is nil") {
+		t.Fatalf("expected nil embedder error, got: %v", err)
+	}
+	if _, _, err := (&Embedder{poolingStrategy: PoolingStrategyNone}).EmbedDocumentsQuantized([]string{"x"}); err == nil || !strings.Contains(err.Error(), "needs pooled embeddings") {
+		t.Fatalf("expected token-level output error, got: %v", err)
+	}
+}
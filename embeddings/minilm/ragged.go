@@ -0,0 +1,113 @@
+package minilm
+
+import (
+	"fmt"
+	"sort"
+)
+
+// minRaggedBucketLength is the shortest sequence length EmbedDocumentsRagged runs at.
+const minRaggedBucketLength = 16
+
+// EmbedDocumentsRagged embeds documents like EmbedDocuments, but runs each document at
+// roughly its own token length instead of padding every document to the configured
+// sequence length, which saves compute on length-skewed corpora. Documents are grouped
+// into buckets by their tokenized length (special tokens included), rounded up to a power
+// of two between 16 and the configured length, and each bucket is embedded at its bucket
+// length as by EmbedDocumentsWithSeqLen; results are returned in input order. Padding
+// does not change pooled embeddings, so results match EmbedDocuments up to floating-point
+// noise. Documents are tokenized once to measure them and again when embedded.
+// Token-level output (PoolingStrategyNone) is not supported, since its width depends on
+// the sequence length.
+func (e *Embedder) EmbedDocumentsRagged(documents []string) ([][]float32, error) {
+	if e == nil {
+		return nil, fmt.Errorf("embedder is nil")
+	}
+	if e.poolingStrategy == PoolingStrategyNone && !e.pooledOutput {
+		return nil, fmt.Errorf("EmbedDocumentsRagged needs pooled embeddings; token-level output width depends on the sequence length")
+	}
+	if len(documents) == 0 {
+		return [][]float32{}, nil
+	}
+
+	lengths, err := e.tokenLengths(documents)
+	if err != nil {
+		return nil, err
+	}
+	bucketLengths, groups := groupByBucketLength(lengths, e.sequenceLength)
+
+	post := e.configuredPostProcessing()
+	embeddings := make([][]float32, len(documents))
+	bucketDocuments := make([]string, 0, len(documents))
+	for i, bucketLength := range bucketLengths {
+		bucketDocuments = bucketDocuments[:0]
+		for _, index := range groups[i] {
+			bucketDocuments = append(bucketDocuments, documents[index])
+		}
+		spec := e.defaultSessionSpec()
+		spec.sequenceLength = bucketLength
+		result, err := e.embedDocumentsWithSpec(bucketDocuments, spec, post)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed %d documents at sequence length %d: %w", len(bucketDocuments), bucketLength, err)
+		}
+		for j, index := range groups[i] {
+			embeddings[index] = result.Embeddings[j]
+		}
+	}
+	return embeddings, nil
+}
+
+// tokenLengths returns the number of attended tokens of each document at the configured
+// sequence length.
+func (e *Embedder) tokenLengths(documents []string) ([]int, error) {
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+	if e.tokenizer == nil {
+		return nil, fmt.Errorf("embedder has been closed")
+	}
+
+	lengths := make([]int, len(documents))
+	encodeOpts := encodeOptions(false, false)
+	for i, document := range documents {
+		encoding, err := e.tokenizer.Encode(document, encodeOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to tokenize document %d: %w", i, err)
+		}
+		if encoding == nil {
+			return nil, fmt.Errorf("failed to tokenize document %d: empty tokenizer result", i)
+		}
+		for j, id := range encoding.IDs {
+			attended := int64(id) != e.padID
+			if j < len(encoding.AttentionMask) {
+				attended = encoding.AttentionMask[j] != 0
+			}
+			if attended {
+				lengths[i]++
+			}
+		}
+	}
+	return lengths, nil
+}
+
+// groupByBucketLength assigns each token length to the smallest power-of-two bucket of
+// at least minRaggedBucketLength that holds it, capped at maxLength. It returns the bucket
+// lengths in ascending order and, for each, the indices of its lengths in input order.
+func groupByBucketLength(lengths []int, maxLength int) (bucketLengths []int, groups [][]int) {
+	byBucket := make(map[int][]int)
+	for i, length := range lengths {
+		bucket := minRaggedBucketLength
+		for bucket < length && bucket < maxLength {
+			bucket *= 2
+		}
+		bucket = min(bucket, maxLength)
+		byBucket[bucket] = append(byBucket[bucket], i)
+	}
+	for bucket := range byBucket {
+		bucketLengths = append(bucketLengths, bucket)
+	}
+	sort.Ints(bucketLengths)
+	groups = make([][]int, len(bucketLengths))
+	for i, bucket := range bucketLengths {
+		groups[i] = byBucket[bucket]
+	}
+	return bucketLengths, groups
+}
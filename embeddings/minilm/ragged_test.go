@@ -0,0 +1,63 @@
+package minilm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGroupByBucketLength(t *testing.T) {
+	tests := []struct {
+		name        string
+		lengths     []int
+		maxLength   int
+		wantBuckets []int
+		wantGroups  [][]int
+	}{
+		{
+			name:        "mixed lengths keep input order within buckets",
+			lengths:     []int{40, 5, 16, 200, 17, 9, 64},
+			maxLength:   256,
+			wantBuckets: []int{16, 32, 64, 256},
+			wantGroups:  [][]int{{1, 2, 5}, {4}, {0, 6}, {3}},
+		},
+		{
+			name:        "capped at the configured length",
+			lengths:     []int{100, 128, 3},
+			maxLength:   100,
+			wantBuckets: []int{16, 100},
+			wantGroups:  [][]int{{2}, {0, 1}},
+		},
+		{
+			name:        "configured length below the smallest bucket",
+			lengths:     []int{4, 8},
+			maxLength:   8,
+			wantBuckets: []int{8},
+			wantGroups:  [][]int{{0, 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets, groups := groupByBucketLength(tt.lengths, tt.maxLength)
+			if !reflect.DeepEqual(buckets, tt.wantBuckets) || !reflect.DeepEqual(groups, tt.wantGroups) {
+				t.Fatalf("unexpected buckets: got %v %v, want %v %v", buckets, groups, tt.wantBuckets, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestEmbedDocumentsRaggedValidation(t *testing.T) {
+	var embedder *Embedder
+	if _, err := embedder.EmbedDocumentsRagged([]string{"x"}); err == nil || !strings.Contains(err.Error(), "embedder is nil") {
+		t.Fatalf("expected nil embedder error, got: %v", err)
+	}
+	if _, err := (&Embedder{poolingStrategy: PoolingStrategyNone}).EmbedDocumentsRagged([]string{"x"}); err == nil || !strings.Contains(err.Error(), "needs pooled embeddings") {
+		t.Fatalf("expected token-level output error, got: %v", err)
+	}
+	if got, err := (&Embedder{poolingStrategy: PoolingStrategyMean}).EmbedDocumentsRagged(nil); err != nil || got == nil || len(got) != 0 {
+		t.Fatalf("expected empty result for no documents, got %v, %v", got, err)
+	}
+	if _, err := (&Embedder{poolingStrategy: PoolingStrategyMean}).EmbedDocumentsRagged([]string{"x"}); err == nil || !strings.Contains(err.Error(), "embedder has been closed") {
+		t.Fatalf("expected closed embedder error, got: %v", err)
+	}
+}
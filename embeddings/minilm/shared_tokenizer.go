@@ -0,0 +1,73 @@
+package minilm
+
+import (
+	"fmt"
+	"os"
+
+	tokenizers "github.com/amikos-tech/pure-tokenizers"
+
+	"github.com/amikos-tech/pure-onnx/embeddings/internal/tokenizerutil"
+)
+
+// SharedTokenizer is one loaded tokenizer that several embedders use through
+// WithSharedTokenizer, for deployments that serve fine-tuned models with a common
+// vocabulary. It is reference counted: each embedder holds a reference until its Close,
+// and the creator holds one until SharedTokenizer.Close, so the tokenizer is released
+// only after all of them are done.
+type SharedTokenizer struct {
+	shared         *tokenizerutil.Shared
+	sequenceLength int
+}
+
+// NewSharedTokenizer loads the tokenizer.json at tokenizerPath for sharing. Only
+// WithSequenceLength and WithTokenizerLibraryPath apply; the tokenizer pads and truncates
+// to that sequence length, which every embedder using it must also resolve to.
+func NewSharedTokenizer(tokenizerPath string, opts ...Option) (*SharedTokenizer, error) {
+	if tokenizerPath == "" {
+		return nil, fmt.Errorf("tokenizer path cannot be empty")
+	}
+	if _, err := os.Stat(tokenizerPath); err != nil {
+		return nil, fmt.Errorf("tokenizer path %q is not usable: %w", tokenizerPath, err)
+	}
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+	tokenizer, err := tokenizers.FromFile(tokenizerPath, tokenizerOptions(cfg)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizer: %w", err)
+	}
+	return &SharedTokenizer{
+		shared:         tokenizerutil.NewShared(tokenizerPath, tokenizer),
+		sequenceLength: cfg.sequenceLength,
+	}, nil
+}
+
+// WithSharedTokenizer makes the embedder use shared instead of loading its own tokenizer.
+// The embedder's sequence length must match the one shared was created with, and the
+// tokenizerPath passed to NewEmbedder may be empty.
+func WithSharedTokenizer(shared *SharedTokenizer) Option {
+	return func(cfg *config) error {
+		if shared == nil {
+			return fmt.Errorf("shared tokenizer cannot be nil")
+		}
+		cfg.sharedTokenizer = shared
+		return nil
+	}
+}
+
+// SequenceLength returns the length the shared tokenizer pads and truncates to.
+func (s *SharedTokenizer) SequenceLength() int {
+	return s.sequenceLength
+}
+
+// Close releases the creator's reference. The tokenizer is closed once every embedder
+// using it is closed as well. It is safe to call more than once.
+func (s *SharedTokenizer) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.shared.Close()
+}
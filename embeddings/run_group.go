@@ -0,0 +1,60 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// RunGroup runs tasks concurrently, at most GOMAXPROCS at a time, and waits for every
+// started task to finish. It is intended for fanning one input out to several
+// embedders, for example dense and sparse vectors for hybrid retrieval:
+//
+//	err := embeddings.RunGroup(ctx,
+//		func() (err error) { dense, err = denseEmbedder.EmbedDocuments(docs); return err },
+//		func() (err error) { sparse, err = sparseEmbedder.EmbedDocuments(docs); return err },
+//	)
+//
+// The errors of all failed tasks are joined in task order, each naming its task index.
+// Once a task has failed or ctx is done, tasks that have not started yet are skipped,
+// and ctx.Err() is included when ctx ended the group early. Running tasks are not
+// interrupted; close over ctx to make them cancellable.
+func RunGroup(ctx context.Context, tasks ...func() error) error {
+	errs := make([]error, len(tasks)+1)
+	slots := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Bool
+	)
+	for i, task := range tasks {
+		if task == nil {
+			errs[i] = fmt.Errorf("task %d is nil", i)
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			errs[len(tasks)] = ctx.Err()
+			break
+		}
+		if failed.Load() {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := task(); err != nil {
+				errs[i] = fmt.Errorf("task %d: %w", i, err)
+				failed.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
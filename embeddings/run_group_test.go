@@ -0,0 +1,56 @@
+package embeddings_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/amikos-tech/pure-onnx/embeddings"
+)
+
+func TestRunGroupJoinsTaskErrors(t *testing.T) {
+	errSparse := errors.New("sparse embedding failed")
+	var dense []float32
+	err := embeddings.RunGroup(context.Background(),
+		func() error {
+			dense = []float32{1, 0}
+			return nil
+		},
+		func() error { return errSparse },
+	)
+	if !errors.Is(err, errSparse) {
+		t.Fatalf("expected the sparse task error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "task 1: sparse embedding failed") {
+		t.Fatalf("expected the error to name the failing task, got: %v", err)
+	}
+	if len(dense) != 2 {
+		t.Fatalf("expected the successful task to complete, got %v", dense)
+	}
+
+	if err := embeddings.RunGroup(context.Background(), func() error { return nil }, func() error { return nil }); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := embeddings.RunGroup(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "task 0 is nil") {
+		t.Fatalf("expected nil task error, got: %v", err)
+	}
+}
+
+func TestRunGroupSkipsTasksAfterCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var ran atomic.Int32
+	err := embeddings.RunGroup(ctx,
+		func() error { ran.Add(1); return nil },
+		func() error { ran.Add(1); return nil },
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context cancellation error, got: %v", err)
+	}
+	if got := ran.Load(); got != 0 {
+		t.Fatalf("expected no task to run after cancellation, got %d", got)
+	}
+}
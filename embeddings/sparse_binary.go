@@ -0,0 +1,188 @@
+package embeddings
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// sparseBinaryVersion is the leading byte of the MarshalBinary layout.
+const sparseBinaryVersion = 1
+
+const (
+	sparseBinaryHasLabels byte = 1 << iota
+	sparseBinaryHasCounts
+	sparseBinaryHasRawValues
+)
+
+// MarshalBinary encodes the vector in a compact layout for on-disk sparse indexes:
+//
+//	version byte | flags byte | uvarint n | n varint index deltas | n float32 values
+//
+// followed, when present, by n uvarint counts, n float32 raw values, and n
+// length-prefixed labels. Index deltas are zigzag-encoded, so any index order
+// round-trips, but ascending indices (as produced by splade) encode smallest.
+// Floats are little-endian IEEE 754.
+func (v SparseVector) MarshalBinary() ([]byte, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+
+	var flags byte
+	if len(v.Labels) > 0 {
+		flags |= sparseBinaryHasLabels
+	}
+	if len(v.Counts) > 0 {
+		flags |= sparseBinaryHasCounts
+	}
+	if len(v.RawValues) > 0 {
+		flags |= sparseBinaryHasRawValues
+	}
+
+	buf := make([]byte, 0, 2+binary.MaxVarintLen64+len(v.Indices)*6)
+	buf = append(buf, sparseBinaryVersion, flags)
+	buf = binary.AppendUvarint(buf, uint64(len(v.Indices)))
+	previous := 0
+	for _, index := range v.Indices {
+		buf = binary.AppendVarint(buf, int64(index)-int64(previous))
+		previous = index
+	}
+	buf = appendFloat32s(buf, v.Values)
+	for _, count := range v.Counts {
+		if count < 0 {
+			return nil, fmt.Errorf("sparse vector has negative count %d", count)
+		}
+		buf = binary.AppendUvarint(buf, uint64(count))
+	}
+	buf = appendFloat32s(buf, v.RawValues)
+	for _, label := range v.Labels {
+		buf = binary.AppendUvarint(buf, uint64(len(label)))
+		buf = append(buf, label...)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes data produced by MarshalBinary, replacing the vector's contents.
+func (v *SparseVector) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return fmt.Errorf("sparse vector binary data too short: %d bytes", len(data))
+	}
+	if data[0] != sparseBinaryVersion {
+		return fmt.Errorf("unsupported sparse vector binary version %d", data[0])
+	}
+	flags := data[1]
+	if flags&^(sparseBinaryHasLabels|sparseBinaryHasCounts|sparseBinaryHasRawValues) != 0 {
+		return fmt.Errorf("unknown sparse vector binary flags %#x", flags)
+	}
+	r := sparseBinaryReader{data: data, offset: 2}
+
+	n, err := r.uvarint("length")
+	if err != nil {
+		return err
+	}
+	// Every entry takes at least one delta byte and four value bytes.
+	if n > uint64(len(data)-r.offset)/5 {
+		return fmt.Errorf("sparse vector binary length %d exceeds remaining %d bytes", n, len(data)-r.offset)
+	}
+	count := int(n)
+
+	decoded := SparseVector{
+		Indices: make([]int, count),
+		Values:  make([]float32, count),
+	}
+	previous := int64(0)
+	for i := range decoded.Indices {
+		delta, err := r.varint("index delta")
+		if err != nil {
+			return err
+		}
+		previous += delta
+		if int64(int(previous)) != previous {
+			return fmt.Errorf("sparse vector index %d overflows int", previous)
+		}
+		decoded.Indices[i] = int(previous)
+	}
+	if err := r.float32s(decoded.Values, "values"); err != nil {
+		return err
+	}
+	if flags&sparseBinaryHasCounts != 0 {
+		decoded.Counts = make([]int, count)
+		for i := range decoded.Counts {
+			c, err := r.uvarint("count")
+			if err != nil {
+				return err
+			}
+			if c > math.MaxInt {
+				return fmt.Errorf("sparse vector count %d out of range", c)
+			}
+			decoded.Counts[i] = int(c)
+		}
+	}
+	if flags&sparseBinaryHasRawValues != 0 {
+		decoded.RawValues = make([]float32, count)
+		if err := r.float32s(decoded.RawValues, "raw values"); err != nil {
+			return err
+		}
+	}
+	if flags&sparseBinaryHasLabels != 0 {
+		decoded.Labels = make([]string, count)
+		for i := range decoded.Labels {
+			size, err := r.uvarint("label length")
+			if err != nil {
+				return err
+			}
+			if size > uint64(len(data)-r.offset) {
+				return fmt.Errorf("sparse vector label length %d exceeds remaining %d bytes", size, len(data)-r.offset)
+			}
+			decoded.Labels[i] = string(data[r.offset : r.offset+int(size)])
+			r.offset += int(size)
+		}
+	}
+	if r.offset != len(data) {
+		return fmt.Errorf("sparse vector binary data has %d trailing bytes", len(data)-r.offset)
+	}
+
+	*v = decoded
+	return nil
+}
+
+func appendFloat32s(buf []byte, values []float32) []byte {
+	for _, value := range values {
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(value))
+	}
+	return buf
+}
+
+type sparseBinaryReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *sparseBinaryReader) uvarint(field string) (uint64, error) {
+	value, n := binary.Uvarint(r.data[r.offset:])
+	if n <= 0 {
+		return 0, fmt.Errorf("sparse vector binary data has malformed %s at offset %d", field, r.offset)
+	}
+	r.offset += n
+	return value, nil
+}
+
+func (r *sparseBinaryReader) varint(field string) (int64, error) {
+	value, n := binary.Varint(r.data[r.offset:])
+	if n <= 0 {
+		return 0, fmt.Errorf("sparse vector binary data has malformed %s at offset %d", field, r.offset)
+	}
+	r.offset += n
+	return value, nil
+}
+
+func (r *sparseBinaryReader) float32s(dst []float32, field string) error {
+	if len(dst)*4 > len(r.data)-r.offset {
+		return fmt.Errorf("sparse vector binary data truncated in %s at offset %d", field, r.offset)
+	}
+	for i := range dst {
+		dst[i] = math.Float32frombits(binary.LittleEndian.Uint32(r.data[r.offset:]))
+		r.offset += 4
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package embeddings_test
+
+import (
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amikos-tech/pure-onnx/embeddings"
+)
+
+func TestSparseVectorBinaryRoundTrip(t *testing.T) {
+	large := embeddings.SparseVector{
+		Indices: make([]int, 5000),
+		Values:  make([]float32, 5000),
+	}
+	for i := range large.Indices {
+		large.Indices[i] = i*7 + 3
+		large.Values[i] = float32(i) / 3
+	}
+
+	tests := []struct {
+		name   string
+		vector embeddings.SparseVector
+	}{
+		{name: "empty", vector: embeddings.SparseVector{Indices: []int{}, Values: []float32{}}},
+		{name: "single", vector: embeddings.SparseVector{Indices: []int{30521}, Values: []float32{1.25}}},
+		{name: "unsorted with negative delta", vector: embeddings.SparseVector{Indices: []int{900, 12, 13, 70000}, Values: []float32{0.5, -1, 0, 3.5}}},
+		{name: "large", vector: large},
+		{
+			name: "optional fields",
+			vector: embeddings.SparseVector{
+				Indices:   []int{5, 1996, 2054},
+				Values:    []float32{0.1, 0.2, 0.3},
+				Labels:    []string{"[unused4]", "the", ""},
+				Counts:    []int{1, 0, 300},
+				RawValues: []float32{-0.5, 0.22, 0.35},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.vector.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary failed: %v", err)
+			}
+			var decoded embeddings.SparseVector
+			if err := decoded.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary failed: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, tt.vector) {
+				t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", decoded, tt.vector)
+			}
+		})
+	}
+}
+
+func TestSparseVectorBinaryDeltaEncoding(t *testing.T) {
+	vector := embeddings.SparseVector{Indices: []int{100, 101, 300, 250}, Values: []float32{1, 2, 3, 4}}
+	data, err := vector.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+
+	// version, flags, length, then zigzag varint deltas 100, 1, 199, -50.
+	offset := 3
+	var deltas []int64
+	for range vector.Indices {
+		delta, n := binary.Varint(data[offset:])
+		if n <= 0 {
+			t.Fatalf("malformed delta at offset %d", offset)
+		}
+		deltas = append(deltas, delta)
+		offset += n
+	}
+	if want := []int64{100, 1, 199, -50}; !reflect.DeepEqual(deltas, want) {
+		t.Fatalf("unexpected deltas: got %v, want %v", deltas, want)
+	}
+	if got, want := len(data), offset+4*len(vector.Values); got != want {
+		t.Fatalf("unexpected encoded size: got %d, want %d", got, want)
+	}
+}
+
+func TestSparseVectorBinaryErrors(t *testing.T) {
+	if _, err := (embeddings.SparseVector{Indices: []int{1}}).MarshalBinary(); err == nil || !strings.Contains(err.Error(), "mismatched indices/values") {
+		t.Fatalf("expected validation error, got: %v", err)
+	}
+
+	valid, err := (embeddings.SparseVector{Indices: []int{1, 2}, Values: []float32{0.5, 0.25}, Labels: []string{"a", "b"}}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{name: "too short", data: []byte{1}, wantErr: "too short"},
+		{name: "unknown version", data: []byte{9, 0, 0}, wantErr: "unsupported sparse vector binary version"},
+		{name: "unknown flags", data: []byte{1, 0x80, 0}, wantErr: "unknown sparse vector binary flags"},
+		{name: "length exceeds data", data: []byte{1, 0, 100, 1}, wantErr: "exceeds remaining"},
+		{name: "truncated labels", data: valid[:len(valid)-1], wantErr: "label length"},
+		{name: "trailing bytes", data: append(append([]byte{}, valid...), 0), wantErr: "trailing bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := embeddings.SparseVector{Indices: []int{7}, Values: []float32{7}}
+			vector := original
+			err := vector.UnmarshalBinary(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(vector, original) {
+				t.Fatalf("expected vector to be unchanged on error, got %+v", vector)
+			}
+		})
+	}
+}
@@ -1,16 +1,19 @@
 package splade
 
 import (
-	"container/list"
 	"errors"
 	"fmt"
+	"log"
 	"math"
 	"os"
 	"runtime/debug"
+	"slices"
 	"sort"
 	"sync"
 
+	"github.com/amikos-tech/pure-onnx/embeddings"
 	"github.com/amikos-tech/pure-onnx/embeddings/internal/ortutil"
+	"github.com/amikos-tech/pure-onnx/embeddings/internal/tokenizerutil"
 	"github.com/amikos-tech/pure-onnx/ort"
 	tokenizers "github.com/amikos-tech/pure-tokenizers"
 )
@@ -40,23 +43,17 @@ const (
 	OutputLayoutDocumentLogits OutputLayout = "document_logits"
 )
 
-// SparseVector is a sparse representation of one document embedding.
-type SparseVector struct {
-	Indices []int     `json:"indices"`
-	Values  []float32 `json:"values"`
-	Labels  []string  `json:"labels,omitempty"`
-}
+// PaddingSide selects which end of a fixed-length row receives padding tokens.
+type PaddingSide = tokenizerutil.PaddingSide
 
-// Validate checks sparse vector parallel-slice invariants.
-func (v SparseVector) Validate() error {
-	if len(v.Indices) != len(v.Values) {
-		return fmt.Errorf("sparse vector has mismatched indices/values lengths: indices=%d values=%d", len(v.Indices), len(v.Values))
-	}
-	if len(v.Labels) > 0 && len(v.Labels) != len(v.Indices) {
-		return fmt.Errorf("sparse vector has mismatched labels/indices lengths: labels=%d indices=%d", len(v.Labels), len(v.Indices))
-	}
-	return nil
-}
+const (
+	PaddingSideRight = tokenizerutil.PaddingSideRight
+	PaddingSideLeft  = tokenizerutil.PaddingSideLeft
+)
+
+// SparseVector is a sparse representation of one document embedding.
+// It is shared with other embedders through the embeddings package.
+type SparseVector = embeddings.SparseVector
 
 // SparseEmbedding is an alias for SparseVector.
 type SparseEmbedding = SparseVector
@@ -66,7 +63,9 @@ type Option func(*config) error
 
 type config struct {
 	sequenceLength       int
+	clampSequenceLength  bool
 	maxCachedBatchCount  int
+	maxBatchSize         int
 	tokenizerLibraryPath string
 	inputIDsName         string
 	attentionMaskName    string
@@ -75,13 +74,28 @@ type config struct {
 	useTokenTypeIDs      bool
 	vocabSize            int
 	outputLayout         OutputLayout
+	outputLayoutExplicit bool
 	pruneThreshold       float32
 	topK                 int
+	minNonZero           int
 	applyLog1pReLU       bool
+	valueTransform       func(float32) float32
 	returnLabels         bool
+	returnCounts         bool
+	returnRawLogits      bool
 	slidingWindowEnabled bool
 	slidingWindowStride  int
 	preProcessor         func(string) string
+	strictVocabCheck     bool
+	runObserver          ort.RunObserver
+	paddingSide          PaddingSide
+	rejectNonFinite      bool
+	l2Normalize          bool
+	sortByValue          bool
+	excludedIndices      []int
+	autoBootstrap        bool
+	bootstrapOptions     []ort.BootstrapOption
+	sharedTokenizer      *SharedTokenizer
 }
 
 func defaultConfig() config {
@@ -98,14 +112,20 @@ func defaultConfig() config {
 		topK:                 0,
 		applyLog1pReLU:       true,
 		returnLabels:         false,
+		returnCounts:         false,
+		returnRawLogits:      false,
 		slidingWindowEnabled: false,
 		slidingWindowStride:  0,
 		preProcessor:         nil,
+		strictVocabCheck:     false,
+		paddingSide:          PaddingSideRight,
 	}
 }
 
 // WithSequenceLength sets truncation and fixed padding length.
-// When sliding-window mode is enabled, it also defines each window width.
+// When sliding-window mode is enabled, it also defines each window width. A length
+// above the model's position embedding count is rejected at construction (see
+// WithClampSequenceLength).
 func WithSequenceLength(length int) Option {
 	return func(cfg *config) error {
 		if length <= 0 {
@@ -116,6 +136,26 @@ func WithSequenceLength(length int) Option {
 	}
 }
 
+// WithClampSequenceLength reduces a sequence length above the model's position
+// embedding count to that count, logging a warning, instead of failing construction.
+func WithClampSequenceLength() Option {
+	return func(cfg *config) error {
+		cfg.clampSequenceLength = true
+		return nil
+	}
+}
+
+// WithAutoBootstrap lets the embedder initialize ONNX Runtime itself when it is not
+// initialized at the first embed call, using ort.InitializeEnvironmentWithBootstrap
+// with opts. The environment reference taken this way is released by Close.
+func WithAutoBootstrap(opts ...ort.BootstrapOption) Option {
+	return func(cfg *config) error {
+		cfg.autoBootstrap = true
+		cfg.bootstrapOptions = append([]ort.BootstrapOption(nil), opts...)
+		return nil
+	}
+}
+
 // WithMaxCachedBatchSessions bounds how many batch-size-specific sessions are cached.
 func WithMaxCachedBatchSessions(limit int) Option {
 	return func(cfg *config) error {
@@ -127,6 +167,20 @@ func WithMaxCachedBatchSessions(limit int) Option {
 	}
 }
 
+// WithMaxBatchSize splits EmbedDocuments calls into inference runs of at most size rows
+// (documents, or windows of one document with WithSlidingWindow), bounding the output
+// tensor to size*sequenceLength*vocabSize floats for token logits. The default (0)
+// runs one batch per call.
+func WithMaxBatchSize(size int) Option {
+	return func(cfg *config) error {
+		if size <= 0 {
+			return fmt.Errorf("max batch size must be > 0, got %d", size)
+		}
+		cfg.maxBatchSize = size
+		return nil
+	}
+}
+
 // WithTokenizerLibraryPath sets the explicit pure-tokenizers shared library path.
 func WithTokenizerLibraryPath(path string) Option {
 	return func(cfg *config) error {
@@ -176,17 +230,22 @@ func WithVocabularySize(size int) Option {
 }
 
 // WithTokenLogitsOutput configures output layout [batch, sequenceLength, vocabSize].
+// Without a layout option it is detected from the model output rank when ONNX Runtime
+// is initialized at construction, and defaults to token logits otherwise.
 func WithTokenLogitsOutput() Option {
 	return func(cfg *config) error {
 		cfg.outputLayout = OutputLayoutTokenLogits
+		cfg.outputLayoutExplicit = true
 		return nil
 	}
 }
 
-// WithDocumentLogitsOutput configures output layout [batch, vocabSize].
+// WithDocumentLogitsOutput configures output layout [batch, vocabSize], overriding
+// layout detection.
 func WithDocumentLogitsOutput() Option {
 	return func(cfg *config) error {
 		cfg.outputLayout = OutputLayoutDocumentLogits
+		cfg.outputLayoutExplicit = true
 		return nil
 	}
 }
@@ -213,6 +272,20 @@ func WithTopK(topK int) Option {
 	}
 }
 
+// WithMinNonZero keeps at least n non-zero dimensions per embedding: when the prune
+// threshold would leave fewer, the highest below-threshold values are kept as well.
+// Only positive values count, so a document with fewer than n positive dimensions
+// keeps all of them. n must not exceed a configured top-k.
+func WithMinNonZero(n int) Option {
+	return func(cfg *config) error {
+		if n < 0 {
+			return fmt.Errorf("min non-zero must be >= 0, got %d", n)
+		}
+		cfg.minNonZero = n
+		return nil
+	}
+}
+
 // WithLog1pReLU enables SPLADE-style log(1+relu(x)) transformation.
 func WithLog1pReLU() Option {
 	return func(cfg *config) error {
@@ -229,6 +302,20 @@ func WithoutLog1pReLU() Option {
 	}
 }
 
+// WithValueTransform replaces the log(1+relu(x)) transformation with fn, which is applied
+// to every attended logit before max-pooling, thresholding and top-k (for example relu,
+// softplus or sqrt). As with the built-in transform, transformed values <= 0 never become
+// sparse entries. It takes precedence over WithLog1pReLU and WithoutLog1pReLU.
+func WithValueTransform(fn func(float32) float32) Option {
+	return func(cfg *config) error {
+		if fn == nil {
+			return fmt.Errorf("value transform cannot be nil")
+		}
+		cfg.valueTransform = fn
+		return nil
+	}
+}
+
 // WithReturnLabels includes decoded token labels for each sparse index.
 func WithReturnLabels() Option {
 	return func(cfg *config) error {
@@ -237,6 +324,55 @@ func WithReturnLabels() Option {
 	}
 }
 
+// WithRunObserver installs an ort.RunObserver on every inference session the embedder
+// creates, exposing the raw input tensors and model outputs after each run for debugging.
+func WithRunObserver(observer ort.RunObserver) Option {
+	return func(cfg *config) error {
+		if observer == nil {
+			return fmt.Errorf("run observer cannot be nil")
+		}
+		cfg.runObserver = observer
+		return nil
+	}
+}
+
+// WithReturnContributionCounts populates SparseVector.Counts with the number of attended
+// token positions whose weight exceeded the prune threshold for each sparse index.
+// With sliding windows, counts are summed across windows, so overlapping positions
+// count once per window. Requires the token-logits output layout.
+func WithReturnContributionCounts() Option {
+	return func(cfg *config) error {
+		cfg.returnCounts = true
+		return nil
+	}
+}
+
+// WithReturnRawLogits populates SparseVector.RawValues with the untransformed model logit
+// behind each value (the max over attended tokens for token logits), so callers can apply
+// their own scoring transform instead of log1p(relu(x)). With sliding windows the max is
+// taken across windows.
+func WithReturnRawLogits() Option {
+	return func(cfg *config) error {
+		cfg.returnRawLogits = true
+		return nil
+	}
+}
+
+// WithPaddingSide selects where padding goes in each fixed-length row, including the
+// last sliding window. The default is PaddingSideRight; use PaddingSideLeft for models
+// trained with left padding, since the wrong side shifts token positions.
+func WithPaddingSide(side PaddingSide) Option {
+	return func(cfg *config) error {
+		switch side {
+		case PaddingSideRight, PaddingSideLeft:
+		default:
+			return fmt.Errorf("unsupported padding side: %q", side)
+		}
+		cfg.paddingSide = side
+		return nil
+	}
+}
+
 // WithSlidingWindow enables overlapping token-window inference.
 // Window size is sequence length configured via WithSequenceLength.
 func WithSlidingWindow(stride int) Option {
@@ -261,34 +397,130 @@ func WithPreProcessor(fn func(string) string) Option {
 	}
 }
 
+// WithStrictVocabCheck verifies at construction that the tokenizer vocabulary size,
+// the configured vocabulary size (WithVocabularySize), and the model output vocabulary
+// dimension all agree.
+// The check inspects the model, so ONNX Runtime must be initialized before NewEmbedder.
+func WithStrictVocabCheck() Option {
+	return func(cfg *config) error {
+		cfg.strictVocabCheck = true
+		return nil
+	}
+}
+
+// WithRejectNonFinite scans each model output for NaN or infinite values and fails the
+// call with an error naming the offending document (and window, in sliding mode), instead of passing corrupted
+// values into the returned embeddings. Per-token outputs are only scanned at attended
+// positions, since padding never reaches the embeddings.
+func WithRejectNonFinite() Option {
+	return func(cfg *config) error {
+		cfg.rejectNonFinite = true
+		return nil
+	}
+}
+
+// WithSparseL2Normalization scales each returned vector's Values (after pruning and
+// top-k selection) to unit L2 norm, so sparse dot products behave like cosine
+// similarity. Empty and all-zero vectors are returned unchanged. RawValues are not
+// affected.
+func WithSparseL2Normalization() Option {
+	return func(cfg *config) error {
+		cfg.l2Normalize = true
+		return nil
+	}
+}
+
+// WithSparseSortByValue orders each returned vector's entries by descending value, ties
+// broken by lower index, instead of the default ascending index order. Labels, Counts,
+// and RawValues are reordered with their indices.
+func WithSparseSortByValue() Option {
+	return func(cfg *config) error {
+		cfg.sortByValue = true
+		return nil
+	}
+}
+
+// WithExcludeSpecialTokenIndices drops the given vocabulary indices (typically special
+// tokens such as [CLS] and [SEP], which are not content terms) from each pooled vector
+// after the value transform and before sparsification, so they never appear in returned
+// vectors, whatever the transform maps zero to. Indices must be below the vocabulary
+// size. Repeated calls accumulate.
+func WithExcludeSpecialTokenIndices(ids ...int) Option {
+	return func(cfg *config) error {
+		for _, id := range ids {
+			if id < 0 {
+				return fmt.Errorf("excluded token index must be >= 0, got %d", id)
+			}
+		}
+		cfg.excludedIndices = append(cfg.excludedIndices, ids...)
+		return nil
+	}
+}
+
+// EmbedderInfo describes the model and configuration an Embedder produces vectors
+// with, so stored vectors can be checked for configuration drift.
+type EmbedderInfo struct {
+	ModelPath      string
+	TokenizerPath  string
+	SequenceLength int
+	OutputLayout   OutputLayout
+	PaddingSide    PaddingSide
+	VocabSize      int
+	SlidingWindow  bool
+	L2Normalize    bool
+	// ModelSHA256 is the hex-encoded SHA-256 of the model file, computed when the
+	// embedder was constructed so it keeps describing that model if the file is later
+	// replaced. It is empty when the file could not be read then.
+	ModelSHA256 string
+}
+
 // Embedder provides sparse transformer embeddings on top of ort.
 //
 // The caller must initialize ONNX Runtime via ort.SetSharedLibraryPath and
-// ort.InitializeEnvironment before calling EmbedDocuments/EmbedQuery.
+// ort.InitializeEnvironment before calling EmbedDocuments/EmbedQuery, unless the
+// embedder is constructed with WithAutoBootstrap.
 type Embedder struct {
 	modelPath       string
+	modelSHA256     string
+	tokenizerPath   string
 	sequenceLength  int
 	vocabSize       int
 	outputLayout    OutputLayout
 	pruneThreshold  float32
 	topK            int
+	minNonZero      int
 	applyLog1pReLU  bool
+	valueTransform  func(float32) float32
 	returnLabels    bool
+	returnCounts    bool
+	returnRawLogits bool
 	slidingWindow   bool
 	slidingStride   int
 	preProcessor    func(string) string
 	useTokenTypeIDs bool
 	tokenizer       *tokenizers.Tokenizer
+	// sharedTokenizer, when set, owns tokenizer; Close releases a reference instead
+	// of closing it.
+	sharedTokenizer *SharedTokenizer
+	padID           int64
 	labelCache      map[int]string
 	inputNames      []string
 	outputNames     []string
 	// sessionsByBatch caches one session per unique batch size and is LRU-bounded
 	// by maxCachedBatchCount to avoid unbounded memory growth.
-	sessionsByBatch     map[int]*embeddingSession
-	sessionLRU          *list.List
-	sessionLRUIndex     map[int]*list.Element
+	sessionsByBatch     *ortutil.SessionCache[int, *embeddingSession]
 	maxCachedBatchCount int
-	runMu               sync.Mutex
+	maxBatchSize        int
+	runObserver         ort.RunObserver
+	paddingSide         PaddingSide
+	rejectNonFinite     bool
+	l2Normalize         bool
+	sortByValue         bool
+	// excludedIndices are vocabulary indices dropped from every pooled vector.
+	excludedIndices []int
+	// environment bootstraps ONNX Runtime on first use under WithAutoBootstrap.
+	environment ortutil.BootstrapGuard
+	runMu       sync.Mutex
 }
 
 type embeddingSession struct {
@@ -309,23 +541,29 @@ type tokenWindow struct {
 	tokenTypeIDs  []int64
 }
 
+// NewEmbedderFromBundle resolves spec with embeddings.ResolveBundle, downloading the
+// model and tokenizer into the bundle cache when needed, and creates an embedder from
+// the resolved paths.
+func NewEmbedderFromBundle(spec embeddings.BundleSpec, opts ...Option) (*Embedder, error) {
+	modelPath, tokenizerPath, err := embeddings.ResolveBundle(spec)
+	if err != nil {
+		return nil, err
+	}
+	return NewEmbedder(modelPath, tokenizerPath, opts...)
+}
+
 // NewEmbedder creates a SPLADE-compatible sparse embedder.
 //
 // modelPath must point to the local ONNX model file.
-// tokenizerPath must point to the local tokenizer.json file.
+// tokenizerPath must point to the local tokenizer.json file; it may be empty under
+// WithSharedTokenizer.
 func NewEmbedder(modelPath string, tokenizerPath string, opts ...Option) (*Embedder, error) {
 	if modelPath == "" {
 		return nil, fmt.Errorf("model path cannot be empty")
 	}
-	if tokenizerPath == "" {
-		return nil, fmt.Errorf("tokenizer path cannot be empty")
-	}
 	if _, err := os.Stat(modelPath); err != nil {
 		return nil, fmt.Errorf("model path %q is not usable: %w", modelPath, err)
 	}
-	if _, err := os.Stat(tokenizerPath); err != nil {
-		return nil, fmt.Errorf("tokenizer path %q is not usable: %w", tokenizerPath, err)
-	}
 
 	cfg := defaultConfig()
 	for _, opt := range opts {
@@ -333,44 +571,78 @@ func NewEmbedder(modelPath string, tokenizerPath string, opts ...Option) (*Embed
 			return nil, err
 		}
 	}
+	if tokenizerPath == "" && cfg.sharedTokenizer != nil {
+		tokenizerPath = cfg.sharedTokenizer.shared.Path()
+	}
+	if tokenizerPath == "" {
+		return nil, fmt.Errorf("tokenizer path cannot be empty")
+	}
+	if _, err := os.Stat(tokenizerPath); err != nil {
+		return nil, fmt.Errorf("tokenizer path %q is not usable: %w", tokenizerPath, err)
+	}
+	if cfg.topK > 0 && cfg.minNonZero > cfg.topK {
+		return nil, fmt.Errorf("min non-zero (%d) cannot exceed topK (%d)", cfg.minNonZero, cfg.topK)
+	}
+	sequenceLength, sequenceWarning, err := ortutil.ResolveSequenceLength(modelPath, cfg.sequenceLength, cfg.clampSequenceLength)
+	if err != nil {
+		return nil, err
+	}
+	if sequenceWarning != "" {
+		log.Printf("splade: %s", sequenceWarning)
+	}
+	cfg.sequenceLength = sequenceLength
+	if !cfg.outputLayoutExplicit && ort.IsInitialized() {
+		_, outputs, err := ort.GetInputOutputInfo(modelPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect model outputs: %w", err)
+		}
+		if layout, ok := detectOutputLayout(outputs, cfg.outputName); ok {
+			cfg.outputLayout = layout
+		}
+	}
 	switch cfg.outputLayout {
 	case OutputLayoutTokenLogits, OutputLayoutDocumentLogits:
 	default:
 		return nil, fmt.Errorf("unsupported output layout: %q", cfg.outputLayout)
 	}
 
+	if cfg.returnCounts && cfg.outputLayout != OutputLayoutTokenLogits {
+		return nil, fmt.Errorf("contribution counts require the %q output layout, got %q", OutputLayoutTokenLogits, cfg.outputLayout)
+	}
+
 	if cfg.slidingWindowEnabled && cfg.slidingWindowStride > cfg.sequenceLength {
 		return nil, fmt.Errorf("sliding window stride must be <= sequence length (%d), got %d", cfg.sequenceLength, cfg.slidingWindowStride)
 	}
 
-	tokenizerOpts := []tokenizers.TokenizerOption{}
-	if !cfg.slidingWindowEnabled {
-		tokenizerOpts = append(tokenizerOpts,
-			tokenizers.WithTruncation(
-				uintptr(cfg.sequenceLength),
-				tokenizers.TruncationDirectionRight,
-				tokenizers.TruncationStrategyLongestFirst,
-			),
-			tokenizers.WithPadding(true, tokenizers.PaddingStrategy{
-				Tag:       tokenizers.PaddingStrategyFixed,
-				FixedSize: uintptr(cfg.sequenceLength),
-			}),
-		)
-	}
-	if cfg.tokenizerLibraryPath != "" {
-		tokenizerOpts = append(tokenizerOpts, tokenizers.WithLibraryPath(cfg.tokenizerLibraryPath))
+	if cfg.sharedTokenizer != nil {
+		if err := checkSharedTokenizer(cfg.sharedTokenizer, cfg.sequenceLength, cfg.slidingWindowEnabled); err != nil {
+			return nil, err
+		}
 	}
 
-	tokenizer, err := tokenizers.FromFile(tokenizerPath, tokenizerOpts...)
+	var tokenizer *tokenizers.Tokenizer
+	var padID int64
+	if cfg.sharedTokenizer != nil {
+		tokenizer, err = cfg.sharedTokenizer.shared.Acquire()
+		padID = cfg.sharedTokenizer.shared.PadID()
+	} else {
+		tokenizer, err = tokenizers.FromFile(tokenizerPath, tokenizerOptions(cfg)...)
+		padID = tokenizerutil.PadTokenID(tokenizerPath)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to load tokenizer: %w", err)
 	}
+	// closeTokenizer undoes the load above when a later check fails.
+	closeTokenizer := tokenizer.Close
+	if cfg.sharedTokenizer != nil {
+		closeTokenizer = cfg.sharedTokenizer.shared.Release
+	}
 
 	vocabSize := cfg.vocabSize
 	if vocabSize == 0 {
 		size, err := tokenizer.VocabSize()
 		if err != nil {
-			if closeErr := tokenizer.Close(); closeErr != nil {
+			if closeErr := closeTokenizer(); closeErr != nil {
 				return nil, errors.Join(
 					fmt.Errorf("failed to derive vocabulary size from tokenizer: %w", err),
 					fmt.Errorf("failed to close tokenizer after initialization failure: %w", closeErr),
@@ -379,7 +651,7 @@ func NewEmbedder(modelPath string, tokenizerPath string, opts ...Option) (*Embed
 			return nil, fmt.Errorf("failed to derive vocabulary size from tokenizer: %w", err)
 		}
 		if size == 0 {
-			if closeErr := tokenizer.Close(); closeErr != nil {
+			if closeErr := closeTokenizer(); closeErr != nil {
 				return nil, errors.Join(
 					fmt.Errorf("derived vocabulary size is zero"),
 					fmt.Errorf("failed to close tokenizer after initialization failure: %w", closeErr),
@@ -389,36 +661,212 @@ func NewEmbedder(modelPath string, tokenizerPath string, opts ...Option) (*Embed
 		}
 		vocabSize = int(size)
 	}
+	for _, id := range cfg.excludedIndices {
+		if id >= vocabSize {
+			err := fmt.Errorf("excluded token index %d is out of range for vocabulary size %d", id, vocabSize)
+			if closeErr := closeTokenizer(); closeErr != nil {
+				return nil, errors.Join(err, fmt.Errorf("failed to close tokenizer after initialization failure: %w", closeErr))
+			}
+			return nil, err
+		}
+	}
+
+	if cfg.strictVocabCheck {
+		if err := verifyVocabularySizes(tokenizer, cfg.vocabSize, modelPath, cfg.outputName, cfg.outputLayout); err != nil {
+			if closeErr := closeTokenizer(); closeErr != nil {
+				return nil, errors.Join(err, fmt.Errorf("failed to close tokenizer after initialization failure: %w", closeErr))
+			}
+			return nil, err
+		}
+	}
 
 	inputNames := []string{cfg.inputIDsName, cfg.attentionMaskName}
 	if cfg.useTokenTypeIDs {
 		inputNames = append(inputNames, cfg.tokenTypeIDsName)
 	}
 
+	// An unreadable model leaves the hash empty; creating a session reports the error.
+	modelSHA256, _ := ort.ModelSHA256(modelPath)
+	applyLog1pReLU, valueTransform := cfg.resolveValueTransform()
+
 	return &Embedder{
 		modelPath:           modelPath,
+		modelSHA256:         modelSHA256,
+		tokenizerPath:       tokenizerPath,
 		sequenceLength:      cfg.sequenceLength,
 		vocabSize:           vocabSize,
 		outputLayout:        cfg.outputLayout,
 		pruneThreshold:      cfg.pruneThreshold,
 		topK:                cfg.topK,
-		applyLog1pReLU:      cfg.applyLog1pReLU,
+		minNonZero:          cfg.minNonZero,
+		applyLog1pReLU:      applyLog1pReLU,
+		valueTransform:      valueTransform,
 		returnLabels:        cfg.returnLabels,
+		returnCounts:        cfg.returnCounts,
+		returnRawLogits:     cfg.returnRawLogits,
 		slidingWindow:       cfg.slidingWindowEnabled,
 		slidingStride:       cfg.slidingWindowStride,
 		preProcessor:        cfg.preProcessor,
 		useTokenTypeIDs:     cfg.useTokenTypeIDs,
 		tokenizer:           tokenizer,
+		sharedTokenizer:     cfg.sharedTokenizer,
+		padID:               padID,
 		labelCache:          make(map[int]string),
 		inputNames:          inputNames,
 		outputNames:         []string{cfg.outputName},
-		sessionsByBatch:     make(map[int]*embeddingSession),
-		sessionLRU:          list.New(),
-		sessionLRUIndex:     make(map[int]*list.Element),
+		sessionsByBatch:     ortutil.NewSessionCache[int, *embeddingSession](cfg.maxCachedBatchCount, describeBatchSession),
 		maxCachedBatchCount: cfg.maxCachedBatchCount,
+		maxBatchSize:        cfg.maxBatchSize,
+		runObserver:         cfg.runObserver,
+		paddingSide:         cfg.paddingSide,
+		rejectNonFinite:     cfg.rejectNonFinite,
+		l2Normalize:         cfg.l2Normalize,
+		sortByValue:         cfg.sortByValue,
+		excludedIndices:     append([]int(nil), cfg.excludedIndices...),
+		environment:         ortutil.BootstrapGuard{Enabled: cfg.autoBootstrap, Options: cfg.bootstrapOptions},
 	}, nil
 }
 
+// tokenizerOptions pads and truncates to the sequence length, except in sliding-window
+// mode, which splits the full encoding into windows itself.
+func tokenizerOptions(cfg config) []tokenizers.TokenizerOption {
+	opts := []tokenizers.TokenizerOption{}
+	if !cfg.slidingWindowEnabled {
+		opts = append(opts,
+			tokenizers.WithTruncation(
+				uintptr(cfg.sequenceLength),
+				tokenizers.TruncationDirectionRight,
+				tokenizers.TruncationStrategyLongestFirst,
+			),
+			tokenizers.WithPadding(true, tokenizers.PaddingStrategy{
+				Tag:       tokenizers.PaddingStrategyFixed,
+				FixedSize: uintptr(cfg.sequenceLength),
+			}),
+		)
+	}
+	if cfg.tokenizerLibraryPath != "" {
+		opts = append(opts, tokenizers.WithLibraryPath(cfg.tokenizerLibraryPath))
+	}
+	return opts
+}
+
+// detectOutputLayout infers the layout from the rank of the named model output: rank 3
+// is token logits and rank 2 is document logits. It reports false when the output is
+// missing or has another rank, leaving the configured layout in place.
+func detectOutputLayout(outputs []ort.InputOutputInfo, outputName string) (OutputLayout, bool) {
+	for _, output := range outputs {
+		if output.Name != outputName {
+			continue
+		}
+		switch len(output.Dimensions) {
+		case 3:
+			return OutputLayoutTokenLogits, true
+		case 2:
+			return OutputLayoutDocumentLogits, true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+func verifyVocabularySizes(tokenizer *tokenizers.Tokenizer, configuredSize int, modelPath string, outputName string, outputLayout OutputLayout) error {
+	if !ort.IsInitialized() {
+		return fmt.Errorf("strict vocabulary check requires ONNX Runtime to be initialized: call ort.SetSharedLibraryPath and ort.InitializeEnvironment first")
+	}
+
+	size, err := tokenizer.VocabSize()
+	if err != nil {
+		return fmt.Errorf("failed to read tokenizer vocabulary size for strict check: %w", err)
+	}
+
+	_, outputs, err := ort.GetInputOutputInfo(modelPath)
+	if err != nil {
+		return fmt.Errorf("failed to inspect model for strict vocabulary check: %w", err)
+	}
+	modelSize := int64(-1)
+	found := false
+	for _, output := range outputs {
+		if output.Name != outputName {
+			continue
+		}
+		found = true
+		wantRank := 3
+		if outputLayout == OutputLayoutDocumentLogits {
+			wantRank = 2
+		}
+		if len(output.Dimensions) != wantRank {
+			return fmt.Errorf("model output %q has rank %d, want %d for %s layout", outputName, len(output.Dimensions), wantRank, outputLayout)
+		}
+		modelSize = output.Dimensions[len(output.Dimensions)-1]
+		break
+	}
+	if !found {
+		return fmt.Errorf("model does not declare output %q", outputName)
+	}
+
+	return checkVocabularySizes(int64(size), int64(configuredSize), modelSize)
+}
+
+// checkVocabularySizes reports a mismatch between tokenizer, configured, and model vocabulary sizes.
+// configuredSize <= 0 means no explicit size was configured; modelSize < 0 means the model
+// declares a dynamic vocabulary dimension. Unknown values are excluded from the comparison.
+func checkVocabularySizes(tokenizerSize int64, configuredSize int64, modelSize int64) error {
+	configuredMatches := configuredSize <= 0 || configuredSize == tokenizerSize
+	modelMatches := modelSize < 0 || modelSize == tokenizerSize
+	if configuredMatches && modelMatches {
+		return nil
+	}
+
+	configured := "unset"
+	if configuredSize > 0 {
+		configured = fmt.Sprintf("%d", configuredSize)
+	}
+	model := "dynamic"
+	if modelSize >= 0 {
+		model = fmt.Sprintf("%d", modelSize)
+	}
+	return fmt.Errorf("vocabulary size mismatch: tokenizer=%d configured=%s model_output=%s", tokenizerSize, configured, model)
+}
+
+// Info returns the model and configuration the embedder was constructed with.
+func (e *Embedder) Info() EmbedderInfo {
+	if e == nil {
+		return EmbedderInfo{}
+	}
+	return EmbedderInfo{
+		ModelPath:      e.modelPath,
+		TokenizerPath:  e.tokenizerPath,
+		SequenceLength: e.sequenceLength,
+		OutputLayout:   e.outputLayout,
+		PaddingSide:    e.paddingSide,
+		VocabSize:      e.vocabSize,
+		SlidingWindow:  e.slidingWindow,
+		L2Normalize:    e.l2Normalize,
+		ModelSHA256:    e.modelSHA256,
+	}
+}
+
+// Probe validates the embedder configuration against the model before real traffic:
+// it checks that the model declares the configured input and output names, then runs
+// one inference over a short dummy document so shape and type mismatches surface with
+// the failing step named. ONNX Runtime must be initialized (or WithAutoBootstrap set).
+func (e *Embedder) Probe() error {
+	if e == nil {
+		return fmt.Errorf("embedder is nil")
+	}
+	e.runMu.Lock()
+	err := e.environment.Ensure()
+	e.runMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return ortutil.Probe(e.modelPath, e.inputNames, e.outputNames, func(document string) error {
+		_, err := e.EmbedQuery(document)
+		return err
+	})
+}
+
 // Close releases ONNX session resources and tokenizer resources.
 func (e *Embedder) Close() error {
 	if e == nil {
@@ -428,23 +876,24 @@ func (e *Embedder) Close() error {
 	e.runMu.Lock()
 	defer e.runMu.Unlock()
 
-	var err error
-
-	for batchSize, session := range e.sessionsByBatch {
-		if destroyErr := session.Destroy(); destroyErr != nil {
-			err = errors.Join(err, fmt.Errorf("failed to destroy batch-%d sparse embedding resources: %w", batchSize, destroyErr))
-		}
-	}
+	err := e.sessionsByBatch.DestroyAll()
 	e.sessionsByBatch = nil
-	e.sessionLRU = nil
-	e.sessionLRUIndex = nil
 	e.labelCache = nil
 
-	if e.tokenizer != nil {
+	if e.sharedTokenizer != nil {
+		if releaseErr := e.sharedTokenizer.shared.Release(); releaseErr != nil {
+			err = errors.Join(err, releaseErr)
+		}
+		e.sharedTokenizer = nil
+	} else if e.tokenizer != nil {
 		if closeErr := e.tokenizer.Close(); closeErr != nil {
 			err = errors.Join(err, closeErr)
 		}
-		e.tokenizer = nil
+	}
+	e.tokenizer = nil
+
+	if releaseErr := e.environment.Release(); releaseErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to release bootstrapped ONNX Runtime environment: %w", releaseErr))
 	}
 
 	return err
@@ -465,8 +914,8 @@ func (e *Embedder) EmbedDocuments(documents []string) (_ []SparseVector, err err
 	if e.tokenizer == nil || e.sessionsByBatch == nil {
 		return nil, fmt.Errorf("embedder has been closed")
 	}
-	if !ort.IsInitialized() {
-		return nil, fmt.Errorf("ONNX Runtime not initialized: call ort.SetSharedLibraryPath and ort.InitializeEnvironment first")
+	if err := e.environment.Ensure(); err != nil {
+		return nil, err
 	}
 
 	processedDocuments, err := e.preprocessDocuments(documents)
@@ -474,56 +923,62 @@ func (e *Embedder) EmbedDocuments(documents []string) (_ []SparseVector, err err
 		return nil, err
 	}
 
-	var embeddings []SparseVector
+	var vectors []SparseVector
 	if e.slidingWindow {
-		embeddings, err = e.embedDocumentsSlidingLocked(processedDocuments)
+		vectors, err = e.embedDocumentsSlidingLocked(processedDocuments)
 	} else {
-		embeddings, err = e.embedDocumentsFixedWindowLocked(processedDocuments)
+		vectors, err = e.embedDocumentsFixedWindowLocked(processedDocuments)
 	}
 	if err != nil {
 		return nil, err
 	}
+	if e.l2Normalize {
+		l2NormalizeSparseValues(vectors)
+	}
 	if e.returnLabels {
-		if err := e.attachLabels(embeddings); err != nil {
+		if err := e.attachLabels(vectors); err != nil {
 			return nil, err
 		}
 	}
+	if e.sortByValue {
+		for i := range vectors {
+			sortSparseByValue(&vectors[i])
+		}
+	}
 
-	return embeddings, nil
+	return vectors, nil
 }
 
 func (e *Embedder) embedDocumentsFixedWindowLocked(documents []string) ([]SparseVector, error) {
-	session, err := e.sessionForBatchLocked(len(documents))
-	if err != nil {
-		return nil, err
-	}
-
-	if err := e.tokenizeInto(
-		documents,
-		session.inputIDs,
-		session.attentionMask,
-		session.tokenTypeIDs,
-	); err != nil {
-		return nil, err
-	}
-
-	if err := session.session.Run(); err != nil {
-		return nil, fmt.Errorf("sparse embedding inference failed: %w", err)
-	}
-
-	embeddings, err := sparseFromOutput(
-		session.outputTensor.GetData(),
-		session.attentionMask,
-		len(documents),
-		e.sequenceLength,
-		e.vocabSize,
-		e.outputLayout,
-		e.pruneThreshold,
-		e.topK,
-		e.applyLog1pReLU,
-	)
-	if err != nil {
-		return nil, err
+	bounds := ortutil.SubBatchBounds(len(documents), e.maxBatchSize)
+	embeddings := make([]SparseVector, 0, len(documents))
+	for _, bound := range bounds {
+		start, end := bound[0], bound[1]
+		describeRow := func(row int) string { return fmt.Sprintf("document %d", start+row) }
+		output, err := e.runBatchLocked(end-start, describeRow, e.pruneThreshold, e.topK, e.minNonZero, func(session *embeddingSession) error {
+			return e.tokenizeInto(
+				documents[start:end],
+				start,
+				session.inputIDs,
+				session.attentionMask,
+				session.tokenTypeIDs,
+			)
+		})
+		if err != nil {
+			if len(bounds) > 1 {
+				return nil, fmt.Errorf("sub-batch [%d:%d]: %w", start, end, err)
+			}
+			return nil, err
+		}
+		for i := range output.vectors {
+			if e.returnCounts {
+				attachCounts(&output.vectors[i], output.counts[i])
+			}
+			if e.returnRawLogits {
+				attachRawValues(&output.vectors[i], output.rawLogits[i])
+			}
+		}
+		embeddings = append(embeddings, output.vectors...)
 	}
 	return embeddings, nil
 }
@@ -536,105 +991,139 @@ func (e *Embedder) embedDocumentsSlidingLocked(documents []string) ([]SparseVect
 			return nil, fmt.Errorf("failed to tokenize sliding windows for document %d: %w", docIndex, err)
 		}
 
-		session, err := e.sessionForBatchLocked(len(windows))
-		if err != nil {
-			return nil, err
-		}
-		if err := fillSessionFromWindows(session, windows, e.sequenceLength); err != nil {
-			return nil, fmt.Errorf("failed to prepare sliding window tensors for document %d: %w", docIndex, err)
-		}
-
-		if err := session.session.Run(); err != nil {
-			return nil, fmt.Errorf("sparse embedding inference failed: %w", err)
+		// Windows are kept unpruned until they are merged into the document vector.
+		var windowOutput batchOutput
+		for _, bound := range ortutil.SubBatchBounds(len(windows), e.maxBatchSize) {
+			start, end := bound[0], bound[1]
+			describeRow := func(row int) string { return fmt.Sprintf("document %d window %d", docIndex, start+row) }
+			output, err := e.runBatchLocked(end-start, describeRow, 0, 0, 0, func(session *embeddingSession) error {
+				if err := fillSessionFromWindows(session, windows[start:end], e.sequenceLength); err != nil {
+					return fmt.Errorf("failed to prepare sliding window tensors for document %d: %w", docIndex, err)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			windowOutput.vectors = append(windowOutput.vectors, output.vectors...)
+			windowOutput.counts = append(windowOutput.counts, output.counts...)
+			windowOutput.rawLogits = append(windowOutput.rawLogits, output.rawLogits...)
 		}
 
-		windowEmbeddings, err := sparseFromOutput(
-			session.outputTensor.GetData(),
-			session.attentionMask,
-			len(windows),
-			e.sequenceLength,
-			e.vocabSize,
-			e.outputLayout,
-			0,
-			0,
-			e.applyLog1pReLU,
-		)
-		if err != nil {
-			return nil, err
-		}
-		merged, mergeErr := mergeWindowEmbeddings(windowEmbeddings, e.pruneThreshold, e.topK)
+		merged, mergeErr := mergeWindowEmbeddings(windowOutput.vectors, e.pruneThreshold, e.topK, e.minNonZero)
 		if mergeErr != nil {
 			return nil, fmt.Errorf("failed to merge sliding window embeddings for document %d: %w", docIndex, mergeErr)
 		}
+		if e.returnCounts {
+			documentCounts := windowOutput.counts[0]
+			for _, counts := range windowOutput.counts[1:] {
+				for vocabIndex, count := range counts {
+					documentCounts[vocabIndex] += count
+				}
+			}
+			attachCounts(&merged, documentCounts)
+		}
+		if e.returnRawLogits {
+			documentLogits := windowOutput.rawLogits[0]
+			for _, logits := range windowOutput.rawLogits[1:] {
+				for vocabIndex, logit := range logits {
+					documentLogits[vocabIndex] = max(documentLogits[vocabIndex], logit)
+				}
+			}
+			attachRawValues(&merged, documentLogits)
+		}
 		embeddings[docIndex] = merged
 	}
 	return embeddings, nil
 }
 
-func (e *Embedder) sessionForBatchLocked(batchSize int) (_ *embeddingSession, err error) {
-	if batchSize <= 0 {
-		return nil, fmt.Errorf("batch size must be > 0, got %d", batchSize)
-	}
+// batchOutput holds the per-row results decoded from one inference run. counts and
+// rawLogits are only populated when the embedder returns them.
+type batchOutput struct {
+	vectors   []SparseVector
+	counts    [][]int
+	rawLogits [][]float32
+}
 
-	if session, ok := e.sessionsByBatch[batchSize]; ok {
-		e.touchBatchSizeLocked(batchSize)
-		return session, nil
+// runBatchLocked runs one inference over rows rows whose input buffers are populated by
+// fill, and decodes the output with the given pruning. describeRow names a row of the
+// batch (a document, or a window in sliding mode) in error messages.
+func (e *Embedder) runBatchLocked(rows int, describeRow func(row int) string, pruneThreshold float32, topK int, minNonZero int, fill func(*embeddingSession) error) (batchOutput, error) {
+	session, err := e.sessionForBatchLocked(rows)
+	if err != nil {
+		return batchOutput{}, err
 	}
-	if e.maxCachedBatchCount > 0 && len(e.sessionsByBatch) >= e.maxCachedBatchCount {
-		if err := e.evictLeastRecentlyUsedSessionLocked(); err != nil {
-			return nil, err
+	if err := fill(session); err != nil {
+		return batchOutput{}, err
+	}
+	if err := session.session.Run(); err != nil {
+		return batchOutput{}, fmt.Errorf("sparse embedding inference failed: %w", err)
+	}
+	if e.rejectNonFinite {
+		if err := checkFiniteOutput(session.outputTensor.GetData(), session.attentionMask, e.outputLayout, rows, describeRow); err != nil {
+			return batchOutput{}, err
 		}
 	}
 
-	session, err := newEmbeddingSession(
-		e.modelPath,
-		e.inputNames,
-		e.outputNames,
+	output, err := sparseFromOutput(
+		session.outputTensor.GetData(),
+		session.attentionMask,
+		rows,
 		e.sequenceLength,
-		batchSize,
 		e.vocabSize,
 		e.outputLayout,
-		e.useTokenTypeIDs,
+		pruneThreshold,
+		topK,
+		minNonZero,
+		e.applyLog1pReLU,
+		e.valueTransform,
+		e.excludedIndices,
+		sparseExtras{counts: e.returnCounts, countThreshold: e.pruneThreshold, rawLogits: e.returnRawLogits},
 	)
 	if err != nil {
-		return nil, err
+		return batchOutput{}, err
 	}
-	e.sessionsByBatch[batchSize] = session
-	e.touchBatchSizeLocked(batchSize)
-	return session, nil
+	return output, nil
 }
 
-func (e *Embedder) touchBatchSizeLocked(batchSize int) {
-	if existing := e.sessionLRUIndex[batchSize]; existing != nil {
-		e.sessionLRU.MoveToBack(existing)
-		return
+// checkFiniteOutput rejects NaN or infinite values in a batch's model output. Token
+// logits are only checked at attended positions; document logits are checked whole.
+func checkFiniteOutput(output []float32, attentionMask []int64, layout OutputLayout, rows int, describeRow func(row int) string) error {
+	if layout != OutputLayoutTokenLogits {
+		attentionMask = nil
 	}
-	e.sessionLRUIndex[batchSize] = e.sessionLRU.PushBack(batchSize)
+	return ortutil.CheckFinite(output, rows, attentionMask, describeRow)
 }
 
-func (e *Embedder) evictLeastRecentlyUsedSessionLocked() error {
-	if e.sessionLRU == nil {
-		return nil
-	}
-	oldest := e.sessionLRU.Front()
-	if oldest == nil {
-		return nil
-	}
-	batchSize, ok := oldest.Value.(int)
-	if !ok {
-		return fmt.Errorf("invalid cache bookkeeping value: %T", oldest.Value)
-	}
-	session := e.sessionsByBatch[batchSize]
-	delete(e.sessionsByBatch, batchSize)
-	delete(e.sessionLRUIndex, batchSize)
-	e.sessionLRU.Remove(oldest)
-	if session == nil {
-		return nil
-	}
-	if err := session.Destroy(); err != nil {
-		return fmt.Errorf("failed to evict batch-%d sparse embedding resources: %w", batchSize, err)
+func (e *Embedder) sessionForBatchLocked(batchSize int) (*embeddingSession, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be > 0, got %d", batchSize)
 	}
-	return nil
+
+	return e.sessionsByBatch.Get(batchSize, func(batchSize int) (*embeddingSession, error) {
+		session, err := newEmbeddingSession(
+			e.modelPath,
+			e.inputNames,
+			e.outputNames,
+			e.sequenceLength,
+			batchSize,
+			e.vocabSize,
+			e.outputLayout,
+			e.useTokenTypeIDs,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if e.runObserver != nil {
+			session.session.SetRunObserver(e.runObserver)
+		}
+		return session, nil
+	})
+}
+
+// describeBatchSession names a cached session's resources in cache errors.
+func describeBatchSession(batchSize int) string {
+	return fmt.Sprintf("batch-%d sparse embedding resources", batchSize)
 }
 
 func fillSessionFromWindows(session *embeddingSession, windows []tokenWindow, sequenceLength int) error {
@@ -692,7 +1181,15 @@ func fillSessionFromWindows(session *embeddingSession, windows []tokenWindow, se
 }
 
 func newEmbeddingSession(modelPath string, inputNames []string, outputNames []string, sequenceLength int, batchSize int, vocabSize int, outputLayout OutputLayout, useTokenTypeIDs bool) (_ *embeddingSession, err error) {
-	totalTokens := batchSize * sequenceLength
+	totalTokens, err := ortutil.CheckedMul(batchSize, sequenceLength)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session size: %w", err)
+	}
+	if outputLayout == OutputLayoutTokenLogits {
+		if _, err := ortutil.CheckedMul(totalTokens, vocabSize); err != nil {
+			return nil, fmt.Errorf("invalid output size: %w", err)
+		}
+	}
 	inputIDs := make([]int64, totalTokens)
 	attentionMask := make([]int64, totalTokens)
 
@@ -857,10 +1354,37 @@ func intToUint32Checked(value int) (uint32, error) {
 	return uint32(value), nil
 }
 
-func (e *Embedder) tokenizeInto(documents []string, inputIDs []int64, attentionMask []int64, tokenTypeIDs []int64) error {
+// SequenceLengthMismatchError reports a tokenizer that pads or truncates documents to a
+// different length than the session's input tensors, for example a tokenizer shared
+// with WithSharedTokenizer or configured outside NewEmbedder. Use errors.As to inspect it.
+type SequenceLengthMismatchError struct {
+	// Document is the index of the document in the call.
+	Document int
+	// TokenizerLength is the number of tokens the tokenizer produced.
+	TokenizerLength int
+	// SessionLength is the configured sequence length the session tensors are built for.
+	SessionLength int
+}
+
+func (e *SequenceLengthMismatchError) Error() string {
+	return fmt.Sprintf(
+		"tokenizer produced %d tokens for document %d, but the session expects sequence length %d; align the tokenizer's padding and truncation with WithSequenceLength(%d)",
+		e.TokenizerLength,
+		e.Document,
+		e.SessionLength,
+		e.SessionLength,
+	)
+}
+
+// tokenizeInto fills the input buffers for documents, whose first element is document
+// firstRow of the call.
+func (e *Embedder) tokenizeInto(documents []string, firstRow int, inputIDs []int64, attentionMask []int64, tokenTypeIDs []int64) error {
 	sequenceLength := e.sequenceLength
 	batchSize := len(documents)
-	totalTokens := batchSize * sequenceLength
+	totalTokens, err := ortutil.CheckedMul(batchSize, sequenceLength)
+	if err != nil {
+		return fmt.Errorf("invalid token buffer size: %w", err)
+	}
 
 	if len(inputIDs) != totalTokens || len(attentionMask) != totalTokens {
 		return fmt.Errorf(
@@ -888,10 +1412,13 @@ func (e *Embedder) tokenizeInto(documents []string, inputIDs []int64, attentionM
 			tokenizers.WithReturnTypeIDs(),
 		)
 		if err != nil {
-			return fmt.Errorf("failed to tokenize document %d: %w", i, err)
+			return fmt.Errorf("failed to tokenize document %d: %w", firstRow+i, err)
 		}
 		if encoding == nil {
-			return fmt.Errorf("failed to tokenize document %d: empty tokenizer result", i)
+			return fmt.Errorf("failed to tokenize document %d: empty tokenizer result", firstRow+i)
+		}
+		if len(encoding.IDs) != sequenceLength {
+			return &SequenceLengthMismatchError{Document: firstRow + i, TokenizerLength: len(encoding.IDs), SessionLength: sequenceLength}
 		}
 
 		rowStart := i * sequenceLength
@@ -901,12 +1428,20 @@ func (e *Embedder) tokenizeInto(documents []string, inputIDs []int64, attentionM
 		if len(encoding.AttentionMask) > 0 {
 			fillUint32AsInt64(attentionMask[rowStart:rowEnd], encoding.AttentionMask)
 		} else {
-			deriveAttentionMask(attentionMask[rowStart:rowEnd], inputIDs[rowStart:rowEnd])
+			deriveAttentionMask(attentionMask[rowStart:rowEnd], inputIDs[rowStart:rowEnd], e.padID)
 		}
 
 		if tokenTypeIDs != nil && len(encoding.TypeIDs) > 0 {
 			fillUint32AsInt64(tokenTypeIDs[rowStart:rowEnd], encoding.TypeIDs)
 		}
+
+		if e.paddingSide == PaddingSideLeft {
+			var typeIDsRow []int64
+			if tokenTypeIDs != nil {
+				typeIDsRow = tokenTypeIDs[rowStart:rowEnd]
+			}
+			tokenizerutil.MovePaddingLeft(inputIDs[rowStart:rowEnd], attentionMask[rowStart:rowEnd], typeIDsRow)
+		}
 	}
 
 	return nil
@@ -950,10 +1485,19 @@ func (e *Embedder) tokenizeSlidingWindows(document string) ([]tokenWindow, error
 	if encoding == nil {
 		return nil, fmt.Errorf("empty tokenizer result")
 	}
-	return splitEncodingIntoWindows(encoding, e.sequenceLength, e.slidingStride, e.useTokenTypeIDs)
+	windows, err := splitEncodingIntoWindows(encoding, e.sequenceLength, e.slidingStride, e.useTokenTypeIDs, e.padID)
+	if err != nil {
+		return nil, err
+	}
+	if e.paddingSide == PaddingSideLeft {
+		for _, window := range windows {
+			tokenizerutil.MovePaddingLeft(window.inputIDs, window.attentionMask, window.tokenTypeIDs)
+		}
+	}
+	return windows, nil
 }
 
-func splitEncodingIntoWindows(encoding *tokenizers.EncodeResult, sequenceLength int, stride int, useTokenTypeIDs bool) ([]tokenWindow, error) {
+func splitEncodingIntoWindows(encoding *tokenizers.EncodeResult, sequenceLength int, stride int, useTokenTypeIDs bool, padID int64) ([]tokenWindow, error) {
 	if encoding == nil {
 		return nil, fmt.Errorf("encoding cannot be nil")
 	}
@@ -977,22 +1521,30 @@ func splitEncodingIntoWindows(encoding *tokenizers.EncodeResult, sequenceLength
 		return []tokenWindow{empty}, nil
 	}
 
+	// Windows cover the whole encoding, so every per-token slice must match the id
+	// count; a mismatch points at a tokenizer misconfiguration, not truncation.
 	tokenCount := len(encoding.IDs)
 	ids := make([]int64, tokenCount)
-	fillUint32AsInt64(ids, encoding.IDs)
+	if err := fillUint32AsInt64Strict(ids, encoding.IDs); err != nil {
+		return nil, fmt.Errorf("input ids: %w", err)
+	}
 
 	attention := make([]int64, tokenCount)
 	if len(encoding.AttentionMask) > 0 {
-		fillUint32AsInt64(attention, encoding.AttentionMask)
+		if err := fillUint32AsInt64Strict(attention, encoding.AttentionMask); err != nil {
+			return nil, fmt.Errorf("attention mask: %w", err)
+		}
 	} else {
-		deriveAttentionMask(attention, ids)
+		deriveAttentionMask(attention, ids, padID)
 	}
 
 	var typeIDs []int64
 	if useTokenTypeIDs {
 		typeIDs = make([]int64, tokenCount)
 		if len(encoding.TypeIDs) > 0 {
-			fillUint32AsInt64(typeIDs, encoding.TypeIDs)
+			if err := fillUint32AsInt64Strict(typeIDs, encoding.TypeIDs); err != nil {
+				return nil, fmt.Errorf("token type ids: %w", err)
+			}
 		}
 	}
 
@@ -1024,6 +1576,8 @@ func splitEncodingIntoWindows(encoding *tokenizers.EncodeResult, sequenceLength
 	return windows, nil
 }
 
+// fillUint32AsInt64 copies src into dst, truncating src to len(dst). Fixed-window
+// padding relies on the truncation; use fillUint32AsInt64Strict where it is not intended.
 func fillUint32AsInt64(dst []int64, src []uint32) {
 	if len(dst) == 0 || len(src) == 0 {
 		return
@@ -1037,45 +1591,102 @@ func fillUint32AsInt64(dst []int64, src []uint32) {
 	}
 }
 
-func deriveAttentionMask(dst []int64, tokenIDs []int64) {
+// fillUint32AsInt64Strict copies src into dst and fails unless both have the same length.
+func fillUint32AsInt64Strict(dst []int64, src []uint32) error {
+	if len(dst) != len(src) {
+		return fmt.Errorf("length mismatch: got %d values, want %d", len(src), len(dst))
+	}
+	for i, value := range src {
+		dst[i] = int64(value)
+	}
+	return nil
+}
+
+// deriveAttentionMask marks every position whose token id differs from padID, for
+// tokenizer results that carry no attention mask.
+func deriveAttentionMask(dst []int64, tokenIDs []int64, padID int64) {
 	for i := range dst {
-		if tokenIDs[i] != 0 {
+		if tokenIDs[i] != padID {
 			dst[i] = 1
 		}
 	}
 }
 
-func sparseFromOutput(output []float32, attentionMask []int64, batchSize int, sequenceLength int, vocabSize int, outputLayout OutputLayout, pruneThreshold float32, topK int, applyLog1pReLU bool) ([]SparseVector, error) {
+// sparseExtras selects the dense per-vocabulary side outputs sparseFromOutput gathers in
+// the same pass over the logits that pools the sparse vectors.
+type sparseExtras struct {
+	// counts requests, per row, the number of attended token positions whose transformed
+	// logit exceeds countThreshold. It needs the token logits layout.
+	counts         bool
+	countThreshold float32
+	// rawLogits requests, per row, the untransformed logits: the max over attended
+	// tokens for token logits, or the row itself for document logits. Rows with no
+	// attended tokens hold -Inf.
+	rawLogits bool
+}
+
+// sparseFromOutput pools model logits into sparse vectors. transform, when set, is
+// applied to every logit in place of the log(1+relu(x)) weighting that applyLog1pReLU
+// selects; the built-in weighting is inlined so the common case avoids a call per logit.
+func sparseFromOutput(output []float32, attentionMask []int64, batchSize int, sequenceLength int, vocabSize int, outputLayout OutputLayout, pruneThreshold float32, topK int, minNonZero int, applyLog1pReLU bool, transform func(float32) float32, excluded []int, extras sparseExtras) (batchOutput, error) {
 	if batchSize <= 0 {
-		return nil, fmt.Errorf("batch size must be > 0, got %d", batchSize)
+		return batchOutput{}, fmt.Errorf("batch size must be > 0, got %d", batchSize)
 	}
 	if sequenceLength <= 0 {
-		return nil, fmt.Errorf("sequence length must be > 0, got %d", sequenceLength)
+		return batchOutput{}, fmt.Errorf("sequence length must be > 0, got %d", sequenceLength)
 	}
 	if vocabSize <= 0 {
-		return nil, fmt.Errorf("vocabulary size must be > 0, got %d", vocabSize)
+		return batchOutput{}, fmt.Errorf("vocabulary size must be > 0, got %d", vocabSize)
 	}
 	if pruneThreshold < 0 {
-		return nil, fmt.Errorf("prune threshold must be >= 0, got %f", pruneThreshold)
+		return batchOutput{}, fmt.Errorf("prune threshold must be >= 0, got %f", pruneThreshold)
 	}
 	if topK < 0 {
-		return nil, fmt.Errorf("topK must be >= 0, got %d", topK)
+		return batchOutput{}, fmt.Errorf("topK must be >= 0, got %d", topK)
+	}
+	if minNonZero < 0 {
+		return batchOutput{}, fmt.Errorf("min non-zero must be >= 0, got %d", minNonZero)
+	}
+	for _, index := range excluded {
+		if index < 0 || index >= vocabSize {
+			return batchOutput{}, fmt.Errorf("excluded token index %d is out of range for vocabulary size %d", index, vocabSize)
+		}
 	}
 
 	expectedMaskLen := batchSize * sequenceLength
 	if len(attentionMask) != expectedMaskLen {
-		return nil, fmt.Errorf("attention mask length mismatch: got %d, want %d", len(attentionMask), expectedMaskLen)
+		return batchOutput{}, fmt.Errorf("attention mask length mismatch: got %d, want %d", len(attentionMask), expectedMaskLen)
 	}
 
-	embeddings := make([]SparseVector, batchSize)
+	result := batchOutput{vectors: make([]SparseVector, batchSize)}
+	if extras.counts {
+		result.counts = make([][]int, batchSize)
+	}
+	if extras.rawLogits {
+		result.rawLogits = make([][]float32, batchSize)
+	}
 	switch outputLayout {
 	case OutputLayoutTokenLogits:
 		expectedLen := expectedMaskLen * vocabSize
 		if len(output) != expectedLen {
-			return nil, fmt.Errorf("token logits length mismatch: got %d, want %d", len(output), expectedLen)
+			return batchOutput{}, fmt.Errorf("token logits length mismatch: got %d, want %d", len(output), expectedLen)
 		}
+		negInf := float32(math.Inf(-1))
 		for row := 0; row < batchSize; row++ {
 			dense := make([]float32, vocabSize)
+			var rowCounts []int
+			if extras.counts {
+				rowCounts = make([]int, vocabSize)
+				result.counts[row] = rowCounts
+			}
+			var rowLogits []float32
+			if extras.rawLogits {
+				rowLogits = make([]float32, vocabSize)
+				for i := range rowLogits {
+					rowLogits[i] = negInf
+				}
+				result.rawLogits[row] = rowLogits
+			}
 			rowTokenOffset := row * sequenceLength
 			for tokenIndex := 0; tokenIndex < sequenceLength; tokenIndex++ {
 				if attentionMask[rowTokenOffset+tokenIndex] == 0 {
@@ -1084,7 +1695,13 @@ func sparseFromOutput(output []float32, attentionMask []int64, batchSize int, se
 				tokenOffset := (rowTokenOffset + tokenIndex) * vocabSize
 				for vocabIndex := 0; vocabIndex < vocabSize; vocabIndex++ {
 					value := output[tokenOffset+vocabIndex]
-					if applyLog1pReLU {
+					if rowLogits != nil {
+						rowLogits[vocabIndex] = max(rowLogits[vocabIndex], value)
+					}
+					switch {
+					case transform != nil:
+						value = transform(value)
+					case applyLog1pReLU:
 						if value <= 0 {
 							continue
 						}
@@ -1093,35 +1710,134 @@ func sparseFromOutput(output []float32, attentionMask []int64, batchSize int, se
 					if value > dense[vocabIndex] {
 						dense[vocabIndex] = value
 					}
+					if rowCounts != nil && value > extras.countThreshold {
+						rowCounts[vocabIndex]++
+					}
 				}
 			}
-			embeddings[row] = denseToSparse(dense, pruneThreshold, topK)
+			excludeIndices(dense, excluded)
+			result.vectors[row] = denseToSparse(dense, pruneThreshold, topK, minNonZero)
 		}
 	case OutputLayoutDocumentLogits:
+		if extras.counts {
+			return batchOutput{}, fmt.Errorf("contribution counts require the token logits output layout")
+		}
 		expectedLen := batchSize * vocabSize
 		if len(output) != expectedLen {
-			return nil, fmt.Errorf("document logits length mismatch: got %d, want %d", len(output), expectedLen)
+			return batchOutput{}, fmt.Errorf("document logits length mismatch: got %d, want %d", len(output), expectedLen)
 		}
 		for row := 0; row < batchSize; row++ {
 			rowStart := row * vocabSize
 			dense := make([]float32, vocabSize)
 			copy(dense, output[rowStart:rowStart+vocabSize])
-			if applyLog1pReLU {
+			if extras.rawLogits {
+				result.rawLogits[row] = slices.Clone(dense)
+			}
+			switch {
+			case transform != nil:
 				for i := range dense {
-					if dense[i] <= 0 {
-						dense[i] = 0
-						continue
-					}
-					dense[i] = float32(math.Log1p(float64(dense[i])))
+					dense[i] = transform(dense[i])
+				}
+			case applyLog1pReLU:
+				for i := range dense {
+					dense[i] = log1pReLU(dense[i])
 				}
 			}
-			embeddings[row] = denseToSparse(dense, pruneThreshold, topK)
+			excludeIndices(dense, excluded)
+			result.vectors[row] = denseToSparse(dense, pruneThreshold, topK, minNonZero)
 		}
 	default:
-		return nil, fmt.Errorf("unsupported output layout: %q", outputLayout)
+		return batchOutput{}, fmt.Errorf("unsupported output layout: %q", outputLayout)
 	}
 
-	return embeddings, nil
+	return result, nil
+}
+
+// excludeIndices zeroes the given indices of a pooled, transformed dense row, so
+// denseToSparse (which keeps only positive values) never emits them.
+func excludeIndices(dense []float32, indices []int) {
+	for _, index := range indices {
+		dense[index] = 0
+	}
+}
+
+// l2NormalizeSparseValues scales each vector's Values to unit L2 norm, leaving vectors
+// without non-zero values unchanged.
+func l2NormalizeSparseValues(vectors []SparseVector) {
+	for i := range vectors {
+		embeddings.L2Normalize(vectors[i].Values)
+	}
+}
+
+// sortSparseByValue reorders the vector's parallel slices by descending value, ties
+// broken by lower index.
+func sortSparseByValue(vector *SparseVector) {
+	order := make([]int, len(vector.Indices))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if vector.Values[a] == vector.Values[b] {
+			return vector.Indices[a] < vector.Indices[b]
+		}
+		return vector.Values[a] > vector.Values[b]
+	})
+	vector.Indices = permute(vector.Indices, order)
+	vector.Values = permute(vector.Values, order)
+	vector.Labels = permute(vector.Labels, order)
+	vector.Counts = permute(vector.Counts, order)
+	vector.RawValues = permute(vector.RawValues, order)
+}
+
+// permute returns values reordered so that element i is values[order[i]]; an empty
+// slice is returned unchanged.
+func permute[T any](values []T, order []int) []T {
+	if len(values) == 0 {
+		return values
+	}
+	reordered := make([]T, len(order))
+	for i, source := range order {
+		reordered[i] = values[source]
+	}
+	return reordered
+}
+
+// log1pReLU is the SPLADE weighting log(1+relu(x)).
+func log1pReLU(value float32) float32 {
+	if value <= 0 {
+		return 0
+	}
+	return float32(math.Log1p(float64(value)))
+}
+
+// resolveValueTransform reports how model logits are weighted: with the built-in
+// log(1+relu(x)), or with a custom transform that takes precedence over it.
+func (cfg config) resolveValueTransform() (applyLog1pReLU bool, transform func(float32) float32) {
+	if cfg.valueTransform != nil {
+		return false, cfg.valueTransform
+	}
+	return cfg.applyLog1pReLU, nil
+}
+
+// attachCounts copies the dense per-vocabulary counts onto the vector's indices.
+func attachCounts(vector *SparseVector, counts []int) {
+	vector.Counts = make([]int, len(vector.Indices))
+	for i, index := range vector.Indices {
+		if index >= 0 && index < len(counts) {
+			vector.Counts[i] = counts[index]
+		}
+	}
+}
+
+// attachRawValues copies the dense per-vocabulary raw logits onto the vector's indices.
+func attachRawValues(vector *SparseVector, logits []float32) {
+	vector.RawValues = make([]float32, len(vector.Indices))
+	for i, index := range vector.Indices {
+		if index >= 0 && index < len(logits) {
+			vector.RawValues[i] = logits[index]
+		}
+	}
 }
 
 type indexedValue struct {
@@ -1129,15 +1845,23 @@ type indexedValue struct {
 	value float32
 }
 
-func denseToSparse(dense []float32, pruneThreshold float32, topK int) SparseVector {
-	candidates := make([]indexedValue, 0, len(dense)/16)
-	for i, value := range dense {
-		if value <= pruneThreshold {
-			continue
-		}
-		candidates = append(candidates, indexedValue{index: i, value: value})
+// TopKSparse keeps the k highest-valued (index, value) pairs and returns them
+// ordered by ascending index. Ties on value are broken by lower index first, which
+// matches the selection EmbedDocuments applies, so results are deterministic.
+// A k <= 0 keeps every pair. Indices and values must have the same length.
+func TopKSparse(indices []int, values []float32, k int) (SparseVector, error) {
+	if len(indices) != len(values) {
+		return SparseVector{}, fmt.Errorf("mismatched indices/values lengths: indices=%d values=%d", len(indices), len(values))
+	}
+	candidates := make([]indexedValue, len(indices))
+	for i := range indices {
+		candidates[i] = indexedValue{index: indices[i], value: values[i]}
 	}
+	return selectTopK(candidates, k), nil
+}
 
+// selectTopK sorts candidates in place and converts the selection into a SparseVector.
+func selectTopK(candidates []indexedValue, topK int) SparseVector {
 	if topK > 0 && len(candidates) > topK {
 		sort.Slice(candidates, func(i, j int) bool {
 			if candidates[i].value == candidates[j].value {
@@ -1149,6 +1873,10 @@ func denseToSparse(dense []float32, pruneThreshold float32, topK int) SparseVect
 	}
 
 	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].index == candidates[j].index {
+			// Only reachable for caller-provided duplicate indices via TopKSparse.
+			return candidates[i].value > candidates[j].value
+		}
 		return candidates[i].index < candidates[j].index
 	})
 
@@ -1165,7 +1893,44 @@ func denseToSparse(dense []float32, pruneThreshold float32, topK int) SparseVect
 	}
 }
 
-func mergeWindowEmbeddings(windows []SparseVector, pruneThreshold float32, topK int) (SparseVector, error) {
+func denseToSparse(dense []float32, pruneThreshold float32, topK int, minNonZero int) SparseVector {
+	candidates := make([]indexedValue, 0, len(dense)/16)
+	for i, value := range dense {
+		if value <= pruneThreshold {
+			continue
+		}
+		candidates = append(candidates, indexedValue{index: i, value: value})
+	}
+	if len(candidates) < minNonZero {
+		var pruned []indexedValue
+		for i, value := range dense {
+			if value > 0 && value <= pruneThreshold {
+				pruned = append(pruned, indexedValue{index: i, value: value})
+			}
+		}
+		candidates = keepMinNonZero(candidates, pruned, minNonZero)
+	}
+
+	return selectTopK(candidates, topK)
+}
+
+// keepMinNonZero tops candidates up to minNonZero entries with the highest-valued
+// pruned entries (ties broken by lower index). pruned is reordered in place.
+func keepMinNonZero(candidates []indexedValue, pruned []indexedValue, minNonZero int) []indexedValue {
+	missing := minNonZero - len(candidates)
+	if missing <= 0 || len(pruned) == 0 {
+		return candidates
+	}
+	sort.Slice(pruned, func(i, j int) bool {
+		if pruned[i].value == pruned[j].value {
+			return pruned[i].index < pruned[j].index
+		}
+		return pruned[i].value > pruned[j].value
+	})
+	return append(candidates, pruned[:min(missing, len(pruned))]...)
+}
+
+func mergeWindowEmbeddings(windows []SparseVector, pruneThreshold float32, topK int, minNonZero int) (SparseVector, error) {
 	if len(windows) == 0 {
 		return SparseVector{}, nil
 	}
@@ -1186,36 +1951,17 @@ func mergeWindowEmbeddings(windows []SparseVector, pruneThreshold float32, topK
 	}
 
 	candidates := make([]indexedValue, 0, len(maxPerIndex))
+	var pruned []indexedValue
 	for index, value := range maxPerIndex {
 		if value <= pruneThreshold {
+			if value > 0 {
+				pruned = append(pruned, indexedValue{index: index, value: value})
+			}
 			continue
 		}
 		candidates = append(candidates, indexedValue{index: index, value: value})
 	}
+	candidates = keepMinNonZero(candidates, pruned, minNonZero)
 
-	if topK > 0 && len(candidates) > topK {
-		sort.Slice(candidates, func(i, j int) bool {
-			if candidates[i].value == candidates[j].value {
-				return candidates[i].index < candidates[j].index
-			}
-			return candidates[i].value > candidates[j].value
-		})
-		candidates = candidates[:topK]
-	}
-
-	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].index < candidates[j].index
-	})
-
-	indices := make([]int, len(candidates))
-	values := make([]float32, len(candidates))
-	for i := range candidates {
-		indices[i] = candidates[i].index
-		values[i] = candidates[i].value
-	}
-
-	return SparseVector{
-		Indices: indices,
-		Values:  values,
-	}, nil
+	return selectTopK(candidates, topK), nil
 }
@@ -3,17 +3,19 @@ package splade
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
-	"time"
 
+	"github.com/amikos-tech/pure-onnx/embeddings"
 	"github.com/amikos-tech/pure-onnx/ort"
+	tokenizers "github.com/amikos-tech/pure-tokenizers"
 )
 
 const (
@@ -31,6 +33,14 @@ const (
 	spladeDefaultOutputName        = "output"
 )
 
+var spladeBundle = embeddings.BundleSpec{
+	Name:            "Splade_PP_en_v1",
+	ModelURL:        spladeModelURL,
+	ModelSHA256:     spladeModelSHA256,
+	TokenizerURL:    spladeTokenizerURL,
+	TokenizerSHA256: spladeTokenizerSHA256,
+}
+
 func TestEmbedDocumentsWithSPLADEModel(t *testing.T) {
 	libPath := os.Getenv("ONNXRUNTIME_LIB_PATH")
 	if libPath == "" {
@@ -125,168 +135,265 @@ func TestEmbedDocumentsWithSPLADEModel(t *testing.T) {
 	}
 }
 
-func resolveSpladeAssets(t *testing.T) (modelPath string, tokenizerPath string) {
-	t.Helper()
+func TestInfoMatchesConstructorConfiguration(t *testing.T) {
+	cleanup := setupORTEnvironment(t)
+	defer cleanup()
 
-	modelPath = resolveAssetPath(
-		t,
-		"ONNXRUNTIME_TEST_SPLADE_MODEL_PATH",
-		"ONNXRUNTIME_TEST_SPLADE_MODEL_URL",
-		"ONNXRUNTIME_TEST_SPLADE_MODEL_SHA256",
-		spladeModelURL,
-		spladeModelSHA256,
-		"models",
-		"Splade_PP_en_v1.onnx",
-	)
-
-	tokenizerPath = resolveAssetPath(
-		t,
-		"ONNXRUNTIME_TEST_SPLADE_TOKENIZER_PATH",
-		"ONNXRUNTIME_TEST_SPLADE_TOKENIZER_URL",
-		"ONNXRUNTIME_TEST_SPLADE_TOKENIZER_SHA256",
-		spladeTokenizerURL,
-		spladeTokenizerSHA256,
-		"tokenizers",
-		"Splade_PP_en_v1-tokenizer.json",
-	)
+	modelPath, tokenizerPath := resolvePinnedSpladeAssets(t)
+	embedder, err := NewEmbedder(modelPath, tokenizerPath, WithSequenceLength(256), WithPaddingSide(PaddingSideLeft))
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	defer func() {
+		if err := embedder.Close(); err != nil {
+			t.Errorf("failed to close embedder: %v", err)
+		}
+	}()
 
-	return modelPath, tokenizerPath
+	info := embedder.Info()
+	if info.ModelPath != modelPath || info.TokenizerPath != tokenizerPath {
+		t.Fatalf("unexpected paths in info: %+v", info)
+	}
+	if info.SequenceLength != 256 || info.PaddingSide != PaddingSideLeft || info.SlidingWindow {
+		t.Fatalf("unexpected configuration in info: %+v", info)
+	}
+	if info.OutputLayout != OutputLayoutTokenLogits || info.VocabSize <= 0 {
+		t.Fatalf("unexpected output configuration in info: %+v", info)
+	}
+	wantHash, err := ort.ModelSHA256(modelPath)
+	if err != nil {
+		t.Fatalf("ModelSHA256 failed: %v", err)
+	}
+	if info.ModelSHA256 != wantHash || len(info.ModelSHA256) != 64 {
+		t.Fatalf("unexpected model hash: got %q, want %q", info.ModelSHA256, wantHash)
+	}
 }
 
-func resolveAssetPath(tb testing.TB, envPathKey string, envURLKey string, envSHAKey string, defaultURL string, defaultSHA string, cacheSubdir string, cacheFilename string) string {
-	tb.Helper()
+func TestSharedTokenizerOutlivesClosedEmbedder(t *testing.T) {
+	cleanup := setupORTEnvironment(t)
+	defer cleanup()
 
-	if path := os.Getenv(envPathKey); path != "" {
-		if _, err := os.Stat(path); err != nil {
-			tb.Fatalf("%s %q is not usable: %v", envPathKey, path, err)
-		}
-		if expectedSHA := strings.TrimSpace(os.Getenv(envSHAKey)); expectedSHA != "" {
-			if err := verifyFileSHA256(path, expectedSHA); err != nil {
-				tb.Fatalf("%s failed checksum validation: %v", envPathKey, err)
-			}
+	modelPath, tokenizerPath := resolvePinnedSpladeAssets(t)
+	shared, err := NewSharedTokenizer(tokenizerPath)
+	if err != nil {
+		t.Fatalf("NewSharedTokenizer failed: %v", err)
+	}
+	first, err := NewEmbedder(modelPath, "", WithSharedTokenizer(shared))
+	if err != nil {
+		t.Fatalf("failed to create first embedder: %v", err)
+	}
+	// The pad id must come from the shared tokenizer, not from the path passed here.
+	otherTokenizerPath := filepath.Join(t.TempDir(), "tokenizer.json")
+	if err := os.WriteFile(otherTokenizerPath, []byte(`{"padding": {"pad_id": 7}}`), 0o600); err != nil {
+		t.Fatalf("failed to write tokenizer: %v", err)
+	}
+	second, err := NewEmbedder(modelPath, otherTokenizerPath, WithSharedTokenizer(shared))
+	if err != nil {
+		t.Fatalf("failed to create second embedder: %v", err)
+	}
+	defer func() {
+		if closeErr := second.Close(); closeErr != nil {
+			t.Errorf("failed to close second embedder: %v", closeErr)
 		}
-		return path
+	}()
+	if err := shared.Close(); err != nil {
+		t.Fatalf("failed to release the creator reference: %v", err)
+	}
+	if first.tokenizer != second.tokenizer || second.padID != first.padID {
+		t.Fatalf("expected both embedders to use the shared tokenizer and its pad id")
 	}
 
-	cacheRoot := os.Getenv("ONNXRUNTIME_TEST_MODEL_CACHE_DIR")
-	if cacheRoot == "" {
-		userCacheDir, err := os.UserCacheDir()
-		if err != nil {
-			tb.Skipf("cannot determine user cache directory: %v; set %s", err, envPathKey)
-		}
-		cacheRoot = filepath.Join(userCacheDir, "onnx-purego")
+	want, err := first.EmbedQuery("This is a test")
+	if err != nil {
+		t.Fatalf("first EmbedQuery failed: %v", err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("failed to close first embedder: %v", err)
+	}
+	got, err := second.EmbedQuery("This is a test")
+	if err != nil {
+		t.Fatalf("EmbedQuery after closing the other embedder failed: %v", err)
 	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected identical vectors from both embedders, got %+v and %+v", got, want)
+	}
+}
 
-	assetPath := filepath.Join(cacheRoot, cacheSubdir, cacheFilename)
-	if err := os.MkdirAll(filepath.Dir(assetPath), 0o755); err != nil {
-		tb.Fatalf("failed to create cache directory: %v", err)
+func TestEmbedDocumentsSplitsLargeBatches(t *testing.T) {
+	libPath := os.Getenv("ONNXRUNTIME_LIB_PATH")
+	if libPath == "" {
+		t.Skip("ONNXRUNTIME_LIB_PATH not set, skipping integration test")
 	}
 
-	url := os.Getenv(envURLKey)
-	if url == "" {
-		url = defaultURL
+	modelPath, tokenizerPath := resolveSpladeAssets(t)
+
+	if err := ort.SetSharedLibraryPath(libPath); err != nil {
+		t.Fatalf("failed to set ONNX Runtime library path: %v", err)
 	}
-	expectedSHA := strings.TrimSpace(os.Getenv(envSHAKey))
-	if expectedSHA == "" && url == defaultURL {
-		expectedSHA = defaultSHA
+	if err := ort.InitializeEnvironment(); err != nil {
+		t.Fatalf("failed to initialize ONNX Runtime: %v", err)
 	}
-	expectedSHA = strings.ToLower(expectedSHA)
-
-	if info, err := os.Stat(assetPath); err == nil && info.Size() > 0 {
-		if expectedSHA == "" {
-			return assetPath
+	defer func() {
+		if err := ort.DestroyEnvironment(); err != nil {
+			t.Errorf("failed to destroy ONNX Runtime environment: %v", err)
 		}
-		if err := verifyFileSHA256(assetPath, expectedSHA); err == nil {
-			return assetPath
+	}()
+
+	const maxBatchSize = 2
+	documents := make([]string, 5)
+	for i := range documents {
+		documents[i] = fmt.Sprintf("document %d about sparse retrieval topic %d", i, i*3)
+	}
+
+	split, err := NewEmbedder(modelPath, tokenizerPath, WithSequenceLength(32), WithTopK(64), WithMaxBatchSize(maxBatchSize))
+	if err != nil {
+		t.Fatalf("failed to create split SPLADE embedder: %v", err)
+	}
+	defer func() {
+		if err := split.Close(); err != nil {
+			t.Errorf("failed to close split SPLADE embedder: %v", err)
 		}
-		if removeErr := os.Remove(assetPath); removeErr != nil {
-			tb.Fatalf("failed to remove stale cached asset: %v", removeErr)
+	}()
+	got, err := split.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("split EmbedDocuments failed: %v", err)
+	}
+	if len(got) != len(documents) {
+		t.Fatalf("unexpected embedding row count: got %d, want %d", len(got), len(documents))
+	}
+	_, hasBatch2 := split.sessionsByBatch.Lookup(2)
+	_, hasBatch1 := split.sessionsByBatch.Lookup(1)
+	if split.sessionsByBatch.Len() != 2 || !hasBatch2 || !hasBatch1 {
+		t.Fatalf("expected only batch-2 and batch-1 sessions, got %d sessions", split.sessionsByBatch.Len())
+	}
+	for batchSize, session := range split.sessionsByBatch.All() {
+		if rows := session.outputTensor.Shape()[0]; rows > maxBatchSize {
+			t.Fatalf("batch-%d session output has %d rows, want at most %d", batchSize, rows, maxBatchSize)
 		}
 	}
 
-	tb.Logf("downloading test asset from %s", url)
-	if err := downloadFile(assetPath, url); err != nil {
-		tb.Skipf("unable to download test asset from %s: %v", url, err)
+	whole, err := NewEmbedder(modelPath, tokenizerPath, WithSequenceLength(32), WithTopK(64))
+	if err != nil {
+		t.Fatalf("failed to create SPLADE embedder: %v", err)
 	}
-	if expectedSHA != "" {
-		if err := verifyFileSHA256(assetPath, expectedSHA); err != nil {
-			_ = os.Remove(assetPath)
-			tb.Fatalf("downloaded asset failed checksum validation: %v", err)
+	defer func() {
+		if err := whole.Close(); err != nil {
+			t.Errorf("failed to close SPLADE embedder: %v", err)
 		}
+	}()
+	want, err := whole.EmbedDocuments(documents)
+	if err != nil {
+		t.Fatalf("EmbedDocuments failed: %v", err)
 	}
-
-	return assetPath
-}
-
-func downloadFile(destinationPath string, assetURL string) error {
-	const maxAttempts = 3
-
-	var lastErr error
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		if attempt > 1 {
-			backoff := time.Second * time.Duration(1<<(attempt-1))
-			if backoff > 8*time.Second {
-				backoff = 8 * time.Second
-			}
-			time.Sleep(backoff)
+	for i := range documents {
+		if !reflect.DeepEqual(got[i].Indices, want[i].Indices) {
+			t.Fatalf("document %d: split indices differ from a single batch", i)
 		}
-
-		err := downloadFileOnce(destinationPath, assetURL)
-		if err == nil {
-			return nil
+		if err := ort.ApproxEqual(got[i].Values, want[i].Values, 1e-5); err != nil {
+			t.Fatalf("document %d: split values differ from a single batch: %v", i, err)
 		}
-		lastErr = err
 	}
-
-	return fmt.Errorf("failed to download %s after %d attempts: %w", assetURL, maxAttempts, lastErr)
 }
 
-func downloadFileOnce(destinationPath string, assetURL string) (err error) {
-	client := &http.Client{Timeout: 10 * time.Minute}
-	response, err := client.Get(assetURL)
+func TestTokenizerSequenceLengthMismatchNamesCallDocument(t *testing.T) {
+	cleanup := setupORTEnvironment(t)
+	defer cleanup()
+
+	modelPath, tokenizerPath := resolvePinnedSpladeAssets(t)
+	embedder, err := NewEmbedder(modelPath, tokenizerPath, WithSequenceLength(32), WithMaxBatchSize(1))
 	if err != nil {
-		return err
+		t.Fatalf("failed to create embedder: %v", err)
 	}
 	defer func() {
-		closeErr := response.Body.Close()
-		if err == nil && closeErr != nil {
-			err = closeErr
+		if closeErr := embedder.Close(); closeErr != nil {
+			t.Errorf("failed to close embedder: %v", closeErr)
 		}
 	}()
 
-	if response.StatusCode != http.StatusOK {
-		requestURL := assetURL
-		if response.Request != nil && response.Request.URL != nil {
-			requestURL = response.Request.URL.String()
-		}
-		return fmt.Errorf("unexpected HTTP status %d from %s", response.StatusCode, requestURL)
-	}
-
-	file, err := os.CreateTemp(filepath.Dir(destinationPath), "splade-*.tmp")
+	// Simulate a misconfigured tokenizer that truncates but does not pad, so only the
+	// short second document comes out shorter than the session.
+	misconfigured, err := tokenizers.FromFile(tokenizerPath, tokenizers.WithTruncation(32, tokenizers.TruncationDirectionRight, tokenizers.TruncationStrategyLongestFirst))
 	if err != nil {
-		return err
+		t.Fatalf("failed to load tokenizer: %v", err)
 	}
-	tempPath := file.Name()
+	original := embedder.tokenizer
+	embedder.tokenizer = misconfigured
 	defer func() {
-		_ = os.Remove(tempPath)
+		embedder.tokenizer = original
+		if closeErr := misconfigured.Close(); closeErr != nil {
+			t.Errorf("failed to close tokenizer: %v", closeErr)
+		}
 	}()
 
-	if _, err := io.Copy(file, response.Body); err != nil {
-		_ = file.Close()
-		return err
+	long := strings.Repeat("sparse retrieval ranks documents by weighted terms ", 8)
+	_, err = embedder.EmbedDocuments([]string{long, "short"})
+	var mismatch *SequenceLengthMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected a SequenceLengthMismatchError, got: %v", err)
 	}
-	if err := file.Close(); err != nil {
-		return err
+	if mismatch.Document != 1 || mismatch.TokenizerLength >= 32 || mismatch.SessionLength != 32 {
+		t.Fatalf("unexpected mismatch details: %+v", *mismatch)
 	}
+}
 
-	if err := os.Rename(tempPath, destinationPath); err != nil {
-		if info, statErr := os.Stat(destinationPath); statErr == nil && info.Size() > 0 {
-			return nil
-		}
-		return err
+func resolveSpladeAssets(t *testing.T) (modelPath string, tokenizerPath string) {
+	t.Helper()
+
+	return resolveTestBundle(t, "ONNXRUNTIME_TEST_SPLADE", spladeBundle)
+}
+
+// resolveTestBundle resolves a test model and tokenizer with embeddings.ResolveBundle,
+// caching them under ONNXRUNTIME_TEST_MODEL_CACHE_DIR when it is set. See
+// testAssetOverride for the per-file environment overrides under envPrefix.
+func resolveTestBundle(tb testing.TB, envPrefix string, spec embeddings.BundleSpec) (modelPath string, tokenizerPath string) {
+	tb.Helper()
+
+	spec.CacheDir = os.Getenv("ONNXRUNTIME_TEST_MODEL_CACHE_DIR")
+	modelPath = testAssetOverride(tb, envPrefix+"_MODEL", &spec.ModelURL, &spec.ModelSHA256)
+	tokenizerPath = testAssetOverride(tb, envPrefix+"_TOKENIZER", &spec.TokenizerURL, &spec.TokenizerSHA256)
+	if modelPath != "" && tokenizerPath != "" {
+		return modelPath, tokenizerPath
 	}
 
-	return nil
+	bundleModelPath, bundleTokenizerPath, err := embeddings.ResolveBundle(spec)
+	if err != nil {
+		tb.Skipf("unable to resolve %s test assets: %v", spec.Name, err)
+	}
+	if modelPath == "" {
+		modelPath = bundleModelPath
+	}
+	if tokenizerPath == "" {
+		tokenizerPath = bundleTokenizerPath
+	}
+	return modelPath, tokenizerPath
+}
+
+// testAssetOverride returns <envKey>_PATH when set, checked against <envKey>_SHA256 if
+// that is set too. Otherwise it applies <envKey>_URL and <envKey>_SHA256 to url and sha
+// and returns ""; a custom URL is only checksummed when <envKey>_SHA256 is set.
+func testAssetOverride(tb testing.TB, envKey string, url *string, sha *string) string {
+	tb.Helper()
+
+	expectedSHA := strings.TrimSpace(os.Getenv(envKey + "_SHA256"))
+	if path := os.Getenv(envKey + "_PATH"); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			tb.Fatalf("%s_PATH %q is not usable: %v", envKey, path, err)
+		}
+		if expectedSHA != "" {
+			if err := verifyFileSHA256(path, expectedSHA); err != nil {
+				tb.Fatalf("%s_PATH failed checksum validation: %v", envKey, err)
+			}
+		}
+		return path
+	}
+	if overrideURL := os.Getenv(envKey + "_URL"); overrideURL != "" && overrideURL != *url {
+		*url = overrideURL
+		*sha = ""
+	}
+	if expectedSHA != "" {
+		*sha = expectedSHA
+	}
+	return ""
 }
 
 func verifyFileSHA256(path string, expected string) error {
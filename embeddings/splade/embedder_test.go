@@ -1,13 +1,28 @@
 package splade
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"math"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/amikos-tech/pure-onnx/embeddings/internal/tokenizerutil"
+	"github.com/amikos-tech/pure-onnx/ort"
 	tokenizers "github.com/amikos-tech/pure-tokenizers"
 )
 
+// sparseVectorsFromOutput decodes output without side outputs.
+func sparseVectorsFromOutput(output []float32, attentionMask []int64, batchSize int, sequenceLength int, vocabSize int, outputLayout OutputLayout, pruneThreshold float32, topK int, minNonZero int, applyLog1pReLU bool, transform func(float32) float32, excluded []int) ([]SparseVector, error) {
+	decoded, err := sparseFromOutput(output, attentionMask, batchSize, sequenceLength, vocabSize, outputLayout, pruneThreshold, topK, minNonZero, applyLog1pReLU, transform, excluded, sparseExtras{})
+	return decoded.vectors, err
+}
+
 func TestSparseFromOutputTokenLogitsTopK(t *testing.T) {
 	output := []float32{
 		0, 1, 2, -1,
@@ -15,7 +30,7 @@ func TestSparseFromOutputTokenLogitsTopK(t *testing.T) {
 	}
 	attentionMask := []int64{1, 1}
 
-	embeddings, err := sparseFromOutput(
+	embeddings, err := sparseVectorsFromOutput(
 		output,
 		attentionMask,
 		1,
@@ -24,7 +39,10 @@ func TestSparseFromOutputTokenLogitsTopK(t *testing.T) {
 		OutputLayoutTokenLogits,
 		1.0,
 		2,
+		0,
 		true,
+		nil,
+		nil,
 	)
 	if err != nil {
 		t.Fatalf("sparseFromOutput failed: %v", err)
@@ -50,7 +68,7 @@ func TestSparseFromOutputTokenLogitsTopK(t *testing.T) {
 }
 
 func TestSparseFromOutputTokenLogitsRespectsAttentionMask(t *testing.T) {
-	embeddings, err := sparseFromOutput(
+	embeddings, err := sparseVectorsFromOutput(
 		[]float32{
 			0, 2, -1, // token 0
 			50, 60, 70, // token 1 (masked out)
@@ -62,7 +80,10 @@ func TestSparseFromOutputTokenLogitsRespectsAttentionMask(t *testing.T) {
 		OutputLayoutTokenLogits,
 		0,
 		0,
+		0,
 		false,
+		nil,
+		nil,
 	)
 	if err != nil {
 		t.Fatalf("sparseFromOutput failed: %v", err)
@@ -77,7 +98,7 @@ func TestSparseFromOutputTokenLogitsRespectsAttentionMask(t *testing.T) {
 }
 
 func TestSparseFromOutputTokenLogitsMultiBatch(t *testing.T) {
-	embeddings, err := sparseFromOutput(
+	embeddings, err := sparseVectorsFromOutput(
 		[]float32{
 			1, 0, 2, // row0 token0
 			0, 3, 1, // row0 token1
@@ -91,7 +112,10 @@ func TestSparseFromOutputTokenLogitsMultiBatch(t *testing.T) {
 		OutputLayoutTokenLogits,
 		0,
 		0,
+		0,
 		false,
+		nil,
+		nil,
 	)
 	if err != nil {
 		t.Fatalf("sparseFromOutput failed: %v", err)
@@ -110,7 +134,7 @@ func TestSparseFromOutputTokenLogitsMultiBatch(t *testing.T) {
 }
 
 func TestSparseFromOutputDocumentLogitsWithoutTransform(t *testing.T) {
-	embeddings, err := sparseFromOutput(
+	embeddings, err := sparseVectorsFromOutput(
 		[]float32{0.1, 0.8, 0.5, -0.1},
 		[]int64{1, 1},
 		1,
@@ -119,7 +143,10 @@ func TestSparseFromOutputDocumentLogitsWithoutTransform(t *testing.T) {
 		OutputLayoutDocumentLogits,
 		0.4,
 		0,
+		0,
 		false,
+		nil,
+		nil,
 	)
 	if err != nil {
 		t.Fatalf("sparseFromOutput failed: %v", err)
@@ -145,7 +172,7 @@ func TestSparseFromOutputDocumentLogitsWithoutTransform(t *testing.T) {
 }
 
 func TestSparseFromOutputDocumentLogitsWithTransform(t *testing.T) {
-	embeddings, err := sparseFromOutput(
+	embeddings, err := sparseVectorsFromOutput(
 		[]float32{1, 0, -2, 3},
 		[]int64{1, 1},
 		1,
@@ -154,7 +181,10 @@ func TestSparseFromOutputDocumentLogitsWithTransform(t *testing.T) {
 		OutputLayoutDocumentLogits,
 		0,
 		0,
+		0,
 		true,
+		nil,
+		nil,
 	)
 	if err != nil {
 		t.Fatalf("sparseFromOutput failed: %v", err)
@@ -174,7 +204,7 @@ func TestSparseFromOutputDocumentLogitsWithTransform(t *testing.T) {
 }
 
 func TestSparseFromOutputDocumentLogitsMultiBatch(t *testing.T) {
-	embeddings, err := sparseFromOutput(
+	embeddings, err := sparseVectorsFromOutput(
 		[]float32{
 			0.2, 0.9, -1, 0.5,
 			1.1, 0.2, 0.7, 0,
@@ -186,7 +216,10 @@ func TestSparseFromOutputDocumentLogitsMultiBatch(t *testing.T) {
 		OutputLayoutDocumentLogits,
 		0.4,
 		0,
+		0,
 		false,
+		nil,
+		nil,
 	)
 	if err != nil {
 		t.Fatalf("sparseFromOutput failed: %v", err)
@@ -206,7 +239,7 @@ func TestSparseFromOutputDocumentLogitsMultiBatch(t *testing.T) {
 }
 
 func TestSparseFromOutputValidation(t *testing.T) {
-	_, err := sparseFromOutput(
+	_, err := sparseVectorsFromOutput(
 		[]float32{1, 2, 3, 4},
 		[]int64{1},
 		1,
@@ -215,13 +248,16 @@ func TestSparseFromOutputValidation(t *testing.T) {
 		OutputLayoutTokenLogits,
 		0,
 		0,
+		0,
 		true,
+		nil,
+		nil,
 	)
 	if err == nil || !strings.Contains(err.Error(), "attention mask length mismatch") {
 		t.Fatalf("expected attention mask length mismatch error, got: %v", err)
 	}
 
-	_, err = sparseFromOutput(
+	_, err = sparseVectorsFromOutput(
 		[]float32{1, 2, 3},
 		[]int64{1, 1},
 		1,
@@ -230,13 +266,16 @@ func TestSparseFromOutputValidation(t *testing.T) {
 		OutputLayoutDocumentLogits,
 		0,
 		0,
+		0,
 		true,
+		nil,
+		nil,
 	)
 	if err == nil || !strings.Contains(err.Error(), "document logits length mismatch") {
 		t.Fatalf("expected document logits length mismatch error, got: %v", err)
 	}
 
-	_, err = sparseFromOutput(
+	_, err = sparseVectorsFromOutput(
 		[]float32{1, 2, 3},
 		[]int64{1, 1},
 		1,
@@ -245,13 +284,16 @@ func TestSparseFromOutputValidation(t *testing.T) {
 		OutputLayoutTokenLogits,
 		0,
 		0,
+		0,
 		true,
+		nil,
+		nil,
 	)
 	if err == nil || !strings.Contains(err.Error(), "token logits length mismatch") {
 		t.Fatalf("expected token logits length mismatch error, got: %v", err)
 	}
 
-	_, err = sparseFromOutput(
+	_, err = sparseVectorsFromOutput(
 		[]float32{1, 2, 3, 4},
 		[]int64{1, 1},
 		1,
@@ -260,7 +302,10 @@ func TestSparseFromOutputValidation(t *testing.T) {
 		OutputLayout("unknown"),
 		0,
 		0,
+		0,
 		true,
+		nil,
+		nil,
 	)
 	if err == nil || !strings.Contains(err.Error(), "unsupported output layout") {
 		t.Fatalf("expected unsupported output layout error, got: %v", err)
@@ -280,6 +325,109 @@ func TestWithTopKValidation(t *testing.T) {
 	}
 }
 
+func TestNewEmbeddingSessionRejectsOverflowingSizes(t *testing.T) {
+	_, err := newEmbeddingSession("model.onnx", []string{"a", "b"}, []string{"c"}, math.MaxInt/2+1, 2, 30522, OutputLayoutTokenLogits, false)
+	if err == nil || !strings.Contains(err.Error(), "invalid session size") {
+		t.Fatalf("expected session size overflow error, got: %v", err)
+	}
+	_, err = newEmbeddingSession("model.onnx", []string{"a", "b"}, []string{"c"}, 1<<20, 1<<20, 1<<30, OutputLayoutTokenLogits, false)
+	if err == nil || !strings.Contains(err.Error(), "invalid output size") {
+		t.Fatalf("expected output size overflow error, got: %v", err)
+	}
+}
+
+func TestWithMaxBatchSizeValidation(t *testing.T) {
+	cfg := defaultConfig()
+	if err := WithMaxBatchSize(0)(&cfg); err == nil {
+		t.Fatalf("expected validation error for zero max batch size")
+	}
+	if err := WithMaxBatchSize(16)(&cfg); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	if cfg.maxBatchSize != 16 {
+		t.Fatalf("unexpected maxBatchSize: got %d, want 16", cfg.maxBatchSize)
+	}
+}
+
+func TestWithMinNonZeroValidation(t *testing.T) {
+	cfg := defaultConfig()
+	if err := WithMinNonZero(-1)(&cfg); err == nil {
+		t.Fatalf("expected validation error for negative min non-zero")
+	}
+	if err := WithMinNonZero(8)(&cfg); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	if cfg.minNonZero != 8 {
+		t.Fatalf("unexpected minNonZero: got %d, want 8", cfg.minNonZero)
+	}
+}
+
+func TestDenseToSparseMinNonZero(t *testing.T) {
+	dense := []float32{0.1, 0, 0.4, 0.3, -0.2, 0.4, 0.05}
+
+	// The threshold would drop every dimension; the top three by value survive.
+	got := denseToSparse(dense, 1.0, 0, 3)
+	assertIntSliceEqual(t, got.Indices, []int{2, 3, 5})
+	if err := ort.ApproxEqual(got.Values, []float32{0.4, 0.3, 0.4}, 1e-6); err != nil {
+		t.Fatalf("unexpected values: %v", err)
+	}
+
+	// Values above the threshold are kept first and topped up from the pruned ones.
+	got = denseToSparse(dense, 0.35, 0, 3)
+	assertIntSliceEqual(t, got.Indices, []int{2, 3, 5})
+
+	// Zero and negative values never count as non-zeros.
+	got = denseToSparse(dense, 1.0, 0, 10)
+	assertIntSliceEqual(t, got.Indices, []int{0, 2, 3, 5, 6})
+
+	// Enough dimensions already pass the threshold, so nothing is added.
+	got = denseToSparse(dense, 0.2, 0, 2)
+	assertIntSliceEqual(t, got.Indices, []int{2, 3, 5})
+}
+
+func TestSparseFromOutputMinNonZero(t *testing.T) {
+	embeddings, err := sparseVectorsFromOutput(
+		[]float32{0.2, 0.1, 0.3, 0.0},
+		[]int64{1},
+		1,
+		1,
+		4,
+		OutputLayoutDocumentLogits,
+		5,
+		0,
+		2,
+		false,
+		nil,
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("sparseFromOutput failed: %v", err)
+	}
+	assertIntSliceEqual(t, embeddings[0].Indices, []int{0, 2})
+	if err := ort.ApproxEqual(embeddings[0].Values, []float32{0.2, 0.3}, 1e-6); err != nil {
+		t.Fatalf("unexpected values: %v", err)
+	}
+}
+
+func TestMergeWindowEmbeddingsMinNonZero(t *testing.T) {
+	merged, err := mergeWindowEmbeddings(
+		[]SparseVector{
+			{Indices: []int{1, 5}, Values: []float32{0.2, 0.7}},
+			{Indices: []int{1, 3}, Values: []float32{0.4, 0.6}},
+		},
+		1.0,
+		0,
+		2,
+	)
+	if err != nil {
+		t.Fatalf("mergeWindowEmbeddings failed: %v", err)
+	}
+	assertIntSliceEqual(t, merged.Indices, []int{3, 5})
+	if err := ort.ApproxEqual(merged.Values, []float32{0.6, 0.7}, 1e-6); err != nil {
+		t.Fatalf("unexpected values: %v", err)
+	}
+}
+
 func TestWithReturnLabelsOption(t *testing.T) {
 	cfg := defaultConfig()
 	if err := WithReturnLabels()(&cfg); err != nil {
@@ -290,6 +438,247 @@ func TestWithReturnLabelsOption(t *testing.T) {
 	}
 }
 
+func TestWithReturnContributionCountsOption(t *testing.T) {
+	cfg := defaultConfig()
+	if err := WithReturnContributionCounts()(&cfg); err != nil {
+		t.Fatalf("WithReturnContributionCounts failed: %v", err)
+	}
+	if !cfg.returnCounts {
+		t.Fatalf("expected returnCounts=true")
+	}
+}
+
+func TestNewEmbedderRejectsContributionCountsWithDocumentLogits(t *testing.T) {
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "model.onnx")
+	tokenizerPath := filepath.Join(dir, "tokenizer.json")
+	for _, path := range []string{modelPath, tokenizerPath} {
+		if err := os.WriteFile(path, []byte("{}"), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	_, err := NewEmbedder(modelPath, tokenizerPath, WithDocumentLogitsOutput(), WithReturnContributionCounts())
+	if err == nil || !strings.Contains(err.Error(), "contribution counts require") {
+		t.Fatalf("expected contribution counts layout error, got: %v", err)
+	}
+}
+
+func TestNewEmbedderRejectsMismatchedSharedTokenizer(t *testing.T) {
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "model.onnx")
+	tokenizerPath := filepath.Join(dir, "tokenizer.json")
+	for _, path := range []string{modelPath, tokenizerPath} {
+		if err := os.WriteFile(path, []byte("{}"), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+	shared := &SharedTokenizer{
+		shared:         tokenizerutil.NewShared(tokenizerPath, &tokenizers.Tokenizer{}),
+		sequenceLength: 128,
+	}
+
+	if err := WithSharedTokenizer(nil)(&config{}); err == nil {
+		t.Fatalf("expected a nil shared tokenizer to be rejected")
+	}
+	_, err := NewEmbedder(modelPath, "", WithSharedTokenizer(shared))
+	if err == nil || !strings.Contains(err.Error(), "shared tokenizer pads to 128 tokens, but the embedder's sequence length is 256") {
+		t.Fatalf("expected a sequence length mismatch error, got: %v", err)
+	}
+	_, err = NewEmbedder(modelPath, "", WithSharedTokenizer(shared), WithSequenceLength(128), WithSlidingWindow(64))
+	if err == nil || !strings.Contains(err.Error(), "the embedder uses a sliding window") {
+		t.Fatalf("expected a sliding-window mismatch error, got: %v", err)
+	}
+	if err := checkSharedTokenizer(&SharedTokenizer{sequenceLength: 128, slidingWindow: true}, 512, true); err != nil {
+		t.Fatalf("expected sliding-window mode to ignore the sequence length, got: %v", err)
+	}
+}
+
+func TestDetectOutputLayout(t *testing.T) {
+	outputs := []ort.InputOutputInfo{
+		{Name: "token_logits", Dimensions: ort.Shape{-1, -1, 30522}},
+		{Name: "document_logits", Dimensions: ort.Shape{-1, 30522}},
+		{Name: "scalar", Dimensions: ort.Shape{1}},
+	}
+	tests := []struct {
+		name       string
+		outputName string
+		want       OutputLayout
+		wantOK     bool
+	}{
+		{name: "rank 3", outputName: "token_logits", want: OutputLayoutTokenLogits, wantOK: true},
+		{name: "rank 2", outputName: "document_logits", want: OutputLayoutDocumentLogits, wantOK: true},
+		{name: "unsupported rank", outputName: "scalar"},
+		{name: "missing output", outputName: "missing"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := detectOutputLayout(outputs, tc.outputName)
+			if got != tc.want || ok != tc.wantOK {
+				t.Fatalf("detectOutputLayout(%q) = (%q, %v), want (%q, %v)", tc.outputName, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestOutputLayoutOptionsAreExplicit(t *testing.T) {
+	cfg := defaultConfig()
+	if cfg.outputLayoutExplicit || cfg.outputLayout != OutputLayoutTokenLogits {
+		t.Fatalf("expected detected token logits layout by default, got %q explicit=%v", cfg.outputLayout, cfg.outputLayoutExplicit)
+	}
+	if err := WithDocumentLogitsOutput()(&cfg); err != nil {
+		t.Fatalf("WithDocumentLogitsOutput failed: %v", err)
+	}
+	if !cfg.outputLayoutExplicit || cfg.outputLayout != OutputLayoutDocumentLogits {
+		t.Fatalf("expected explicit document logits layout, got %q explicit=%v", cfg.outputLayout, cfg.outputLayoutExplicit)
+	}
+	if err := WithTokenLogitsOutput()(&cfg); err != nil {
+		t.Fatalf("WithTokenLogitsOutput failed: %v", err)
+	}
+	if !cfg.outputLayoutExplicit || cfg.outputLayout != OutputLayoutTokenLogits {
+		t.Fatalf("expected explicit token logits layout, got %q explicit=%v", cfg.outputLayout, cfg.outputLayoutExplicit)
+	}
+}
+
+func TestTokenContributionCounts(t *testing.T) {
+	output := []float32{
+		0.5, 0, 2, -1, // row0 token0
+		0.2, 3, 1, 4, // row0 token1
+		1, 0.28, 0.9, 0, // row0 token2
+		7, 7, 7, 7, // row0 token3 (masked out)
+		0, 0, 0, 1, // row1 token0
+		0, 0, 0, 2, // row1 token1
+		5, 0, 0, 0, // row1 token2 (masked out)
+		0, 0, 0, 0, // row1 token3 (masked out)
+	}
+	attentionMask := []int64{1, 1, 1, 0, 1, 1, 0, 0}
+
+	decoded, err := sparseFromOutput(output, attentionMask, 2, 4, 4, OutputLayoutTokenLogits, 0.25, 0, 0, false, nil, nil, sparseExtras{counts: true, countThreshold: 0.25})
+	if err != nil {
+		t.Fatalf("sparseFromOutput failed: %v", err)
+	}
+	assertIntSliceEqual(t, decoded.counts[0], []int{2, 2, 3, 1})
+	assertIntSliceEqual(t, decoded.counts[1], []int{0, 0, 0, 2})
+
+	vectors := decoded.vectors
+	for i := range vectors {
+		attachCounts(&vectors[i], decoded.counts[i])
+		if err := vectors[i].Validate(); err != nil {
+			t.Fatalf("row %d: unexpected validation error: %v", i, err)
+		}
+	}
+	assertIntSliceEqual(t, vectors[0].Indices, []int{0, 1, 2, 3})
+	assertIntSliceEqual(t, vectors[0].Counts, []int{2, 2, 3, 1})
+	assertIntSliceEqual(t, vectors[1].Indices, []int{3})
+	assertIntSliceEqual(t, vectors[1].Counts, []int{2})
+
+	// log1p(0.2) and log1p(0.28) fall below 0.25, and non-positive logits never contribute.
+	decoded, err = sparseFromOutput(output, attentionMask, 2, 4, 4, OutputLayoutTokenLogits, 0.25, 0, 0, true, nil, nil, sparseExtras{counts: true, countThreshold: 0.25})
+	if err != nil {
+		t.Fatalf("sparseFromOutput failed: %v", err)
+	}
+	assertIntSliceEqual(t, decoded.counts[0], []int{2, 1, 3, 1})
+
+	// Counts use their own threshold, so a sliding-window pass that keeps every window
+	// value still counts against the configured pruning threshold.
+	decoded, err = sparseFromOutput(output, attentionMask, 2, 4, 4, OutputLayoutTokenLogits, 0, 0, 0, false, nil, nil, sparseExtras{counts: true, countThreshold: 0.25})
+	if err != nil {
+		t.Fatalf("sparseFromOutput failed: %v", err)
+	}
+	assertIntSliceEqual(t, decoded.counts[0], []int{2, 2, 3, 1})
+	if decoded, err := sparseFromOutput(output, attentionMask, 2, 4, 4, OutputLayoutTokenLogits, 0, 0, 0, false, nil, nil, sparseExtras{}); err != nil || decoded.counts != nil {
+		t.Fatalf("expected no counts unless requested, got %v (err %v)", decoded.counts, err)
+	}
+}
+
+func TestTokenContributionCountsValidation(t *testing.T) {
+	_, err := sparseFromOutput([]float32{1, 2}, []int64{1}, 1, 1, 2, OutputLayoutDocumentLogits, 0, 0, 0, false, nil, nil, sparseExtras{counts: true})
+	if err == nil || !strings.Contains(err.Error(), "contribution counts require the token logits output layout") {
+		t.Fatalf("expected layout error, got: %v", err)
+	}
+}
+
+func TestWithReturnRawLogitsOption(t *testing.T) {
+	cfg := defaultConfig()
+	if err := WithReturnRawLogits()(&cfg); err != nil {
+		t.Fatalf("WithReturnRawLogits failed: %v", err)
+	}
+	if !cfg.returnRawLogits {
+		t.Fatalf("expected returnRawLogits=true")
+	}
+}
+
+func TestMaxRawLogitsTokenLogits(t *testing.T) {
+	output := []float32{
+		0.5, -2, 3, // token0
+		1.5, -1, 0, // token1
+		9, 9, 9, // token2 (masked out)
+	}
+	attentionMask := []int64{1, 1, 0}
+
+	decoded, err := sparseFromOutput(output, attentionMask, 1, 3, 3, OutputLayoutTokenLogits, 0, 0, 0, true, nil, nil, sparseExtras{rawLogits: true})
+	if err != nil {
+		t.Fatalf("sparseFromOutput failed: %v", err)
+	}
+	if err := ort.ApproxEqual(decoded.rawLogits[0], []float32{1.5, -1, 3}, 1e-6); err != nil {
+		t.Fatalf("unexpected raw logits: %v", err)
+	}
+
+	vector := decoded.vectors[0]
+	attachRawValues(&vector, decoded.rawLogits[0])
+	if err := vector.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	assertIntSliceEqual(t, vector.Indices, []int{0, 2})
+	if err := ort.ApproxEqual(vector.RawValues, []float32{1.5, 3}, 1e-6); err != nil {
+		t.Fatalf("unexpected raw logits: %v", err)
+	}
+	for i, raw := range vector.RawValues {
+		want := float32(math.Log1p(float64(raw)))
+		if !float32Near(vector.Values[i], want, 1e-6) {
+			t.Fatalf("value %d: got %f, want log1p(raw)=%f", i, vector.Values[i], want)
+		}
+		if float32Near(vector.Values[i], raw, 1e-6) {
+			t.Fatalf("value %d: expected transformed value to differ from raw logit %f", i, raw)
+		}
+	}
+}
+
+func TestMaxRawLogitsDocumentLogits(t *testing.T) {
+	output := []float32{
+		-1, 2, 0.25,
+		4, 0, -3,
+	}
+	decoded, err := sparseFromOutput(output, []int64{1, 1}, 2, 1, 3, OutputLayoutDocumentLogits, 0, 0, 0, true, nil, nil, sparseExtras{rawLogits: true})
+	if err != nil {
+		t.Fatalf("sparseFromOutput failed: %v", err)
+	}
+	rawLogits := decoded.rawLogits
+	if err := ort.ApproxEqual(rawLogits[0], []float32{-1, 2, 0.25}, 1e-6); err != nil {
+		t.Fatalf("unexpected raw logits: %v", err)
+	}
+	if err := ort.ApproxEqual(rawLogits[1], []float32{4, 0, -3}, 1e-6); err != nil {
+		t.Fatalf("unexpected raw logits: %v", err)
+	}
+
+	// The result must not alias the output buffer, which the session reuses.
+	output[0] = 100
+	if rawLogits[0][0] != -1 {
+		t.Fatalf("raw logits alias the output buffer")
+	}
+
+	// A row without attended tokens has no max, so its raw logits stay -Inf.
+	decoded, err = sparseFromOutput([]float32{1, 2, 3}, []int64{0}, 1, 1, 3, OutputLayoutTokenLogits, 0, 0, 0, false, nil, nil, sparseExtras{rawLogits: true})
+	if err != nil {
+		t.Fatalf("sparseFromOutput failed: %v", err)
+	}
+	for _, value := range decoded.rawLogits[0] {
+		if !math.IsInf(float64(value), -1) {
+			t.Fatalf("expected -Inf raw logits for a fully masked row, got %v", decoded.rawLogits[0])
+		}
+	}
+}
+
 func TestWithoutTokenTypeIDsInput(t *testing.T) {
 	cfg := defaultConfig()
 	if err := WithoutTokenTypeIDsInput()(&cfg); err != nil {
@@ -380,6 +769,16 @@ func TestSparseVectorValidate(t *testing.T) {
 			vector:  SparseVector{Indices: []int{1, 2}, Values: []float32{0.1, 0.2}, Labels: []string{"tok"}},
 			wantErr: "mismatched labels/indices",
 		},
+		{
+			name:    "mismatched counts and indices",
+			vector:  SparseVector{Indices: []int{1, 2}, Values: []float32{0.1, 0.2}, Counts: []int{3}},
+			wantErr: "mismatched counts/indices",
+		},
+		{
+			name:    "mismatched raw values and indices",
+			vector:  SparseVector{Indices: []int{1, 2}, Values: []float32{0.1, 0.2}, RawValues: []float32{0.3}},
+			wantErr: "mismatched raw values/indices",
+		},
 	}
 
 	for _, tc := range tests {
@@ -412,7 +811,7 @@ func TestSplitEncodingIntoWindows(t *testing.T) {
 		TypeIDs:       []uint32{0, 0, 0, 0, 0, 0},
 	}
 
-	windows, err := splitEncodingIntoWindows(encoding, 4, 3, true)
+	windows, err := splitEncodingIntoWindows(encoding, 4, 3, true, 0)
 	if err != nil {
 		t.Fatalf("splitEncodingIntoWindows failed: %v", err)
 	}
@@ -429,14 +828,55 @@ func TestSplitEncodingIntoWindows(t *testing.T) {
 	assertInt64SliceEqual(t, windows[1].tokenTypeIDs, []int64{0, 0, 0, 0})
 }
 
+func TestSplitEncodingIntoWindowsDerivesMaskWithPadID(t *testing.T) {
+	// No returned attention mask; <s>=0 must be attended and <pad>=1 masked.
+	encoding := &tokenizers.EncodeResult{IDs: []uint32{0, 11, 2, 1}}
+
+	windows, err := splitEncodingIntoWindows(encoding, 4, 4, false, 1)
+	if err != nil {
+		t.Fatalf("splitEncodingIntoWindows failed: %v", err)
+	}
+	if len(windows) != 1 {
+		t.Fatalf("unexpected window count: got %d, want 1", len(windows))
+	}
+	assertInt64SliceEqual(t, windows[0].attentionMask, []int64{1, 1, 1, 0})
+}
+
+func TestFillUint32AsInt64StrictRejectsLengthMismatch(t *testing.T) {
+	lenient := make([]int64, 3)
+	fillUint32AsInt64(lenient, []uint32{1, 2, 3, 4, 5})
+	assertInt64SliceEqual(t, lenient, []int64{1, 2, 3})
+
+	strict := make([]int64, 3)
+	if err := fillUint32AsInt64Strict(strict, []uint32{1, 2, 3, 4, 5}); err == nil || !strings.Contains(err.Error(), "got 5 values, want 3") {
+		t.Fatalf("expected length mismatch error for a longer source, got: %v", err)
+	}
+	if err := fillUint32AsInt64Strict(strict, []uint32{1, 2}); err == nil {
+		t.Fatalf("expected length mismatch error for a shorter source")
+	}
+	if err := fillUint32AsInt64Strict(strict, []uint32{7, 8, 9}); err != nil {
+		t.Fatalf("expected matching lengths to succeed, got: %v", err)
+	}
+	assertInt64SliceEqual(t, strict, []int64{7, 8, 9})
+
+	// The sliding-window path must not silently truncate a mismatched attention mask.
+	encoding := &tokenizers.EncodeResult{
+		IDs:           []uint32{101, 11, 102},
+		AttentionMask: []uint32{1, 1, 1, 1, 1},
+	}
+	if _, err := splitEncodingIntoWindows(encoding, 4, 2, false, 0); err == nil || !strings.Contains(err.Error(), "attention mask: length mismatch") {
+		t.Fatalf("expected attention mask length mismatch error, got: %v", err)
+	}
+}
+
 func TestSplitEncodingIntoWindowsValidation(t *testing.T) {
 	encoding := &tokenizers.EncodeResult{
 		IDs: []uint32{1, 2, 3},
 	}
-	if _, err := splitEncodingIntoWindows(encoding, 4, 0, false); err == nil {
+	if _, err := splitEncodingIntoWindows(encoding, 4, 0, false, 0); err == nil {
 		t.Fatalf("expected stride validation error")
 	}
-	if _, err := splitEncodingIntoWindows(encoding, 4, 5, false); err == nil {
+	if _, err := splitEncodingIntoWindows(encoding, 4, 5, false, 0); err == nil {
 		t.Fatalf("expected stride > sequence length validation error")
 	}
 }
@@ -548,6 +988,7 @@ func TestMergeWindowEmbeddings(t *testing.T) {
 		},
 		0.5,
 		2,
+		0,
 	)
 	if err != nil {
 		t.Fatalf("mergeWindowEmbeddings failed: %v", err)
@@ -565,6 +1006,95 @@ func TestMergeWindowEmbeddings(t *testing.T) {
 	}
 }
 
+func TestTopKSparseTieBreaking(t *testing.T) {
+	tests := []struct {
+		name        string
+		indices     []int
+		values      []float32
+		k           int
+		wantIndices []int
+		wantValues  []float32
+	}{
+		{
+			name:        "all tied keeps lowest indices",
+			indices:     []int{9, 3, 7, 1},
+			values:      []float32{0.5, 0.5, 0.5, 0.5},
+			k:           2,
+			wantIndices: []int{1, 3},
+			wantValues:  []float32{0.5, 0.5},
+		},
+		{
+			name:        "tie at cutoff keeps lower index",
+			indices:     []int{4, 8, 2, 6},
+			values:      []float32{0.9, 0.4, 0.4, 0.1},
+			k:           2,
+			wantIndices: []int{2, 4},
+			wantValues:  []float32{0.4, 0.9},
+		},
+		{
+			name:        "input order does not matter",
+			indices:     []int{2, 8, 4, 6},
+			values:      []float32{0.4, 0.4, 0.9, 0.1},
+			k:           2,
+			wantIndices: []int{2, 4},
+			wantValues:  []float32{0.4, 0.9},
+		},
+		{
+			name:        "non-positive k keeps all sorted by index",
+			indices:     []int{5, 1, 3},
+			values:      []float32{0.2, 0.2, 0.7},
+			k:           0,
+			wantIndices: []int{1, 3, 5},
+			wantValues:  []float32{0.2, 0.7, 0.2},
+		},
+		{
+			name:        "empty input",
+			k:           3,
+			wantIndices: []int{},
+			wantValues:  []float32{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TopKSparse(tt.indices, tt.values, tt.k)
+			if err != nil {
+				t.Fatalf("TopKSparse failed: %v", err)
+			}
+			assertIntSliceEqual(t, got.Indices, tt.wantIndices)
+			if err := ort.ApproxEqual(got.Values, tt.wantValues, 0); err != nil {
+				t.Fatalf("unexpected values: %v", err)
+			}
+		})
+	}
+}
+
+func TestTopKSparseRejectsMismatchedLengths(t *testing.T) {
+	_, err := TopKSparse([]int{3, 1, 2}, []float32{0.3, 0.3}, 5)
+	if err == nil || !strings.Contains(err.Error(), "mismatched indices/values lengths: indices=3 values=2") {
+		t.Fatalf("expected a length mismatch error, got: %v", err)
+	}
+}
+
+func TestTopKSparseMatchesDenseToSparse(t *testing.T) {
+	dense := []float32{0.3, 0.8, 0.3, 0.0, 0.8, 0.3}
+	// Pairs surviving denseToSparse's prune threshold of 0, in shuffled order.
+	indices := []int{5, 1, 4, 0, 2}
+	values := []float32{0.3, 0.8, 0.8, 0.3, 0.3}
+
+	for k := 0; k <= len(dense); k++ {
+		want := denseToSparse(dense, 0, k, 0)
+		got, err := TopKSparse(indices, values, k)
+		if err != nil {
+			t.Fatalf("k=%d: TopKSparse failed: %v", k, err)
+		}
+		assertIntSliceEqual(t, got.Indices, want.Indices)
+		if err := ort.ApproxEqual(got.Values, want.Values, 0); err != nil {
+			t.Fatalf("k=%d: unexpected values: %v", k, err)
+		}
+	}
+}
+
 func TestMergeWindowEmbeddingsRejectsMismatchedVectors(t *testing.T) {
 	_, err := mergeWindowEmbeddings(
 		[]SparseVector{
@@ -572,6 +1102,7 @@ func TestMergeWindowEmbeddingsRejectsMismatchedVectors(t *testing.T) {
 		},
 		0,
 		0,
+		0,
 	)
 	if err == nil || !strings.Contains(err.Error(), "mismatched indices/values lengths") {
 		t.Fatalf("expected mismatched indices/values error, got: %v", err)
@@ -603,5 +1134,428 @@ func assertIntSliceEqual(t *testing.T, got []int, want []int) {
 }
 
 func float32Near(got float32, want float32, tolerance float64) bool {
-	return math.Abs(float64(got-want)) <= tolerance
+	return ort.ApproxEqual([]float32{got}, []float32{want}, tolerance) == nil
+}
+
+func TestWithRejectNonFiniteOption(t *testing.T) {
+	cfg := defaultConfig()
+	if cfg.rejectNonFinite {
+		t.Fatalf("expected rejectNonFinite=false by default")
+	}
+	if err := WithRejectNonFinite()(&cfg); err != nil {
+		t.Fatalf("WithRejectNonFinite failed: %v", err)
+	}
+	if !cfg.rejectNonFinite {
+		t.Fatalf("expected rejectNonFinite=true")
+	}
+}
+
+func TestSequenceLengthMismatchError(t *testing.T) {
+	err := fmt.Errorf("embed failed: %w", &SequenceLengthMismatchError{Document: 2, TokenizerLength: 128, SessionLength: 256})
+	var mismatch *SequenceLengthMismatchError
+	if !errors.As(err, &mismatch) || mismatch.TokenizerLength != 128 || mismatch.SessionLength != 256 {
+		t.Fatalf("expected to unwrap the typed error, got: %v", err)
+	}
+	want := "tokenizer produced 128 tokens for document 2, but the session expects sequence length 256; align the tokenizer's padding and truncation with WithSequenceLength(256)"
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("unexpected error message: %v", err)
+	}
+}
+
+func TestCheckFiniteOutputIgnoresPadding(t *testing.T) {
+	nan := float32(math.NaN())
+	describeRow := func(row int) string { return fmt.Sprintf("window %d", row) }
+	// Two windows of two tokens over a vocabulary of 2; the second token of window 1 is padding.
+	output := []float32{1, 2, 3, 4, 5, 6, nan, nan}
+	mask := []int64{1, 1, 1, 0}
+	if err := checkFiniteOutput(output, mask, OutputLayoutTokenLogits, 2, describeRow); err != nil {
+		t.Fatalf("expected padded positions to be ignored, got: %v", err)
+	}
+
+	mask[3] = 1
+	err := checkFiniteOutput(output, mask, OutputLayoutTokenLogits, 2, describeRow)
+	if err == nil || !strings.Contains(err.Error(), "model output for window 1 contains non-finite value NaN at offset 2") {
+		t.Fatalf("expected an attended NaN to be reported for window 1, got: %v", err)
+	}
+
+	// Document logits have no token positions, so the whole row is checked.
+	err = checkFiniteOutput([]float32{1, nan}, []int64{1, 0}, OutputLayoutDocumentLogits, 1, describeRow)
+	if err == nil || !strings.Contains(err.Error(), "window 0 contains non-finite value NaN at offset 1") {
+		t.Fatalf("expected document logits to be checked whole, got: %v", err)
+	}
+}
+
+func TestWithStrictVocabCheckOption(t *testing.T) {
+	cfg := defaultConfig()
+	if cfg.strictVocabCheck {
+		t.Fatalf("expected strictVocabCheck=false by default")
+	}
+	if err := WithStrictVocabCheck()(&cfg); err != nil {
+		t.Fatalf("WithStrictVocabCheck failed: %v", err)
+	}
+	if !cfg.strictVocabCheck {
+		t.Fatalf("expected strictVocabCheck=true")
+	}
+}
+
+func TestCheckVocabularySizes(t *testing.T) {
+	tests := []struct {
+		name       string
+		tokenizer  int64
+		configured int64
+		model      int64
+		wantErr    string
+	}{
+		{name: "all equal", tokenizer: 30522, configured: 30522, model: 30522},
+		{name: "configured unset", tokenizer: 30522, configured: 0, model: 30522},
+		{name: "model dynamic", tokenizer: 30522, configured: 30522, model: -1},
+		{
+			name:       "three-way mismatch",
+			tokenizer:  30522,
+			configured: 30000,
+			model:      32000,
+			wantErr:    "vocabulary size mismatch: tokenizer=30522 configured=30000 model_output=32000",
+		},
+		{
+			name:       "model disagrees with unset configured",
+			tokenizer:  30522,
+			configured: 0,
+			model:      32000,
+			wantErr:    "vocabulary size mismatch: tokenizer=30522 configured=unset model_output=32000",
+		},
+		{
+			name:       "configured disagrees with dynamic model",
+			tokenizer:  30522,
+			configured: 30000,
+			model:      -1,
+			wantErr:    "vocabulary size mismatch: tokenizer=30522 configured=30000 model_output=dynamic",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkVocabularySizes(tc.tokenizer, tc.configured, tc.model)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("unexpected error: got %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithRunObserverOption(t *testing.T) {
+	cfg := defaultConfig()
+	if err := WithRunObserver(nil)(&cfg); err == nil {
+		t.Fatalf("expected error for nil run observer")
+	}
+	if err := WithRunObserver(func(inputs, outputs map[string]ort.Value) {})(&cfg); err != nil {
+		t.Fatalf("WithRunObserver failed: %v", err)
+	}
+	if cfg.runObserver == nil {
+		t.Fatalf("expected runObserver to be set")
+	}
+}
+
+func TestWithPaddingSideOption(t *testing.T) {
+	cfg := defaultConfig()
+	if cfg.paddingSide != PaddingSideRight {
+		t.Fatalf("expected right padding by default, got %q", cfg.paddingSide)
+	}
+	if err := WithPaddingSide(PaddingSideLeft)(&cfg); err != nil {
+		t.Fatalf("WithPaddingSide failed: %v", err)
+	}
+	if cfg.paddingSide != PaddingSideLeft {
+		t.Fatalf("unexpected padding side: got %q, want %q", cfg.paddingSide, PaddingSideLeft)
+	}
+	if err := WithPaddingSide("center")(&cfg); err == nil || !strings.Contains(err.Error(), "unsupported padding side") {
+		t.Fatalf("expected unsupported padding side error, got: %v", err)
+	}
+}
+
+func TestMovePaddingLeftOnSlidingWindows(t *testing.T) {
+	encoding := &tokenizers.EncodeResult{
+		IDs:           []uint32{101, 11, 12, 13, 14, 102},
+		AttentionMask: []uint32{1, 1, 1, 1, 1, 1},
+		TypeIDs:       []uint32{0, 0, 0, 1, 1, 1},
+	}
+	windows, err := splitEncodingIntoWindows(encoding, 4, 3, true, 0)
+	if err != nil {
+		t.Fatalf("splitEncodingIntoWindows failed: %v", err)
+	}
+	for _, window := range windows {
+		tokenizerutil.MovePaddingLeft(window.inputIDs, window.attentionMask, window.tokenTypeIDs)
+	}
+
+	// Full windows are unchanged; the partial last window gets its padding in front.
+	assertInt64SliceEqual(t, windows[0].inputIDs, []int64{101, 11, 12, 13})
+	assertInt64SliceEqual(t, windows[1].inputIDs, []int64{0, 13, 14, 102})
+	assertInt64SliceEqual(t, windows[1].attentionMask, []int64{0, 1, 1, 1})
+	assertInt64SliceEqual(t, windows[1].tokenTypeIDs, []int64{0, 1, 1, 1})
+}
+
+func TestSparseFromOutputInlinedLog1pReLUMatchesTransform(t *testing.T) {
+	output := []float32{
+		1, -3, 0.5, 2,
+		-1, 4, 0.2, -2.5,
+	}
+	attentionMask := []int64{1, 1}
+	extras := sparseExtras{counts: true, countThreshold: 0.3, rawLogits: true}
+	for _, layout := range []OutputLayout{OutputLayoutTokenLogits, OutputLayoutDocumentLogits} {
+		batchSize, sequenceLength := 1, 2
+		if layout == OutputLayoutDocumentLogits {
+			batchSize, sequenceLength, extras.counts = 2, 1, false
+		}
+		inlined, err := sparseFromOutput(output, attentionMask, batchSize, sequenceLength, 4, layout, 0.3, 0, 0, true, nil, nil, extras)
+		if err != nil {
+			t.Fatalf("%s: inlined decode failed: %v", layout, err)
+		}
+		called, err := sparseFromOutput(output, attentionMask, batchSize, sequenceLength, 4, layout, 0.3, 0, 0, false, log1pReLU, nil, extras)
+		if err != nil {
+			t.Fatalf("%s: transform decode failed: %v", layout, err)
+		}
+		if !reflect.DeepEqual(inlined, called) {
+			t.Fatalf("%s: inlined log1p(relu(x)) differs from the transform: %+v vs %+v", layout, inlined, called)
+		}
+	}
+}
+
+func TestWithValueTransform(t *testing.T) {
+	square := func(x float32) float32 { return x * x }
+
+	cfg := defaultConfig()
+	if applyLog1pReLU, got := cfg.resolveValueTransform(); !applyLog1pReLU || got != nil {
+		t.Fatalf("expected the built-in log1p(relu(x)) by default")
+	}
+	if err := WithValueTransform(nil)(&cfg); err == nil || !strings.Contains(err.Error(), "cannot be nil") {
+		t.Fatalf("expected nil transform error, got: %v", err)
+	}
+	if err := WithValueTransform(square)(&cfg); err != nil {
+		t.Fatalf("WithValueTransform failed: %v", err)
+	}
+	if err := WithoutLog1pReLU()(&cfg); err != nil {
+		t.Fatalf("WithoutLog1pReLU failed: %v", err)
+	}
+	if applyLog1pReLU, got := cfg.resolveValueTransform(); applyLog1pReLU || got == nil || got(-3) != 9 {
+		t.Fatalf("expected the custom transform to take precedence")
+	}
+	cfg = defaultConfig()
+	_ = WithoutLog1pReLU()(&cfg)
+	if applyLog1pReLU, got := cfg.resolveValueTransform(); applyLog1pReLU || got != nil {
+		t.Fatalf("expected no transform without log1p(relu(x))")
+	}
+
+	// Squaring turns the negative logit -3 into the largest weight, and applies before
+	// max-pooling, thresholding and top-k.
+	output := []float32{
+		1, -3, 0.5, 2,
+		-1, 1, 0.2, -2.5,
+	}
+	attentionMask := []int64{1, 1}
+	vectors, err := sparseVectorsFromOutput(output, attentionMask, 1, 2, 4, OutputLayoutTokenLogits, 0.3, 3, 0, false, square, nil)
+	if err != nil {
+		t.Fatalf("sparseFromOutput failed: %v", err)
+	}
+	assertIntSliceEqual(t, vectors[0].Indices, []int{0, 1, 3})
+	for i, want := range []float32{1, 9, 6.25} {
+		if !float32Near(vectors[0].Values[i], want, 1e-6) {
+			t.Fatalf("unexpected value at %d: got %v, want %v", i, vectors[0].Values[i], want)
+		}
+	}
+
+	documentVectors, err := sparseVectorsFromOutput([]float32{-2, 0.5, 0, 1}, []int64{1}, 1, 1, 4, OutputLayoutDocumentLogits, 0, 0, 0, false, square, nil)
+	if err != nil {
+		t.Fatalf("sparseFromOutput failed: %v", err)
+	}
+	assertIntSliceEqual(t, documentVectors[0].Indices, []int{0, 1, 3})
+	for i, want := range []float32{4, 0.25, 1} {
+		if !float32Near(documentVectors[0].Values[i], want, 1e-6) {
+			t.Fatalf("unexpected document value at %d: got %v, want %v", i, documentVectors[0].Values[i], want)
+		}
+	}
+
+	decoded, err := sparseFromOutput(output, attentionMask, 1, 2, 4, OutputLayoutTokenLogits, 0.3, 3, 0, false, square, nil, sparseExtras{counts: true, countThreshold: 0.3})
+	if err != nil {
+		t.Fatalf("sparseFromOutput failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.counts[0], []int{2, 2, 0, 2}) {
+		t.Fatalf("unexpected contribution counts: %v", decoded.counts[0])
+	}
+}
+
+func TestInfoReportsConfiguration(t *testing.T) {
+	modelPath := filepath.Join(t.TempDir(), "model.onnx")
+	if err := os.WriteFile(modelPath, []byte("model"), 0o600); err != nil {
+		t.Fatalf("failed to write model: %v", err)
+	}
+	modelSHA256, err := ort.ModelSHA256(modelPath)
+	if err != nil {
+		t.Fatalf("ModelSHA256 failed: %v", err)
+	}
+	e := &Embedder{
+		modelPath:      modelPath,
+		modelSHA256:    modelSHA256,
+		tokenizerPath:  "tokenizer.json",
+		sequenceLength: 256,
+		vocabSize:      30522,
+		outputLayout:   OutputLayoutDocumentLogits,
+		paddingSide:    PaddingSideLeft,
+		slidingWindow:  true,
+	}
+	sum := sha256.Sum256([]byte("model"))
+	want := EmbedderInfo{
+		ModelPath:      modelPath,
+		TokenizerPath:  "tokenizer.json",
+		SequenceLength: 256,
+		OutputLayout:   OutputLayoutDocumentLogits,
+		PaddingSide:    PaddingSideLeft,
+		VocabSize:      30522,
+		SlidingWindow:  true,
+		ModelSHA256:    hex.EncodeToString(sum[:]),
+	}
+	if got := e.Info(); got != want {
+		t.Fatalf("unexpected info:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	// The hash describes the model the embedder was constructed with, not whatever
+	// file is at the path now.
+	if err := os.WriteFile(modelPath, []byte("replaced model"), 0o600); err != nil {
+		t.Fatalf("failed to replace model: %v", err)
+	}
+	if got := e.Info(); got != want {
+		t.Fatalf("expected the construction-time hash after the model was replaced, got %+v", got)
+	}
+
+	var nilEmbedder *Embedder
+	if got := nilEmbedder.Info(); got != (EmbedderInfo{}) {
+		t.Fatalf("expected zero info for nil embedder, got %+v", got)
+	}
+}
+
+func TestL2NormalizeSparseValues(t *testing.T) {
+	vectors := []SparseVector{
+		{Indices: []int{3, 7, 9}, Values: []float32{3, 4, 12}, RawValues: []float32{3, 4, 12}},
+		{},
+		{Indices: []int{1}, Values: []float32{0}},
+	}
+	l2NormalizeSparseValues(vectors)
+
+	want := []float32{3.0 / 13, 4.0 / 13, 12.0 / 13}
+	sumSquares := float32(0)
+	for i, value := range vectors[0].Values {
+		if !float32Near(value, want[i], 1e-6) {
+			t.Fatalf("unexpected normalized values: got %v, want %v", vectors[0].Values, want)
+		}
+		sumSquares += value * value
+	}
+	if !float32Near(sumSquares, 1, 1e-6) {
+		t.Fatalf("expected unit value norm, got sum of squares %v", sumSquares)
+	}
+	assertIntSliceEqual(t, vectors[0].Indices, []int{3, 7, 9})
+	if vectors[0].RawValues[2] != 12 {
+		t.Fatalf("expected raw values to stay untouched, got %v", vectors[0].RawValues)
+	}
+	if len(vectors[1].Values) != 0 {
+		t.Fatalf("expected empty vector to stay empty, got %v", vectors[1].Values)
+	}
+	if vectors[2].Values[0] != 0 {
+		t.Fatalf("expected all-zero vector to stay zero, got %v", vectors[2].Values)
+	}
+}
+
+func TestWithSparseL2NormalizationOption(t *testing.T) {
+	cfg := defaultConfig()
+	if err := WithSparseL2Normalization()(&cfg); err != nil {
+		t.Fatalf("WithSparseL2Normalization failed: %v", err)
+	}
+	if !cfg.l2Normalize {
+		t.Fatalf("expected l2Normalize=true")
+	}
+}
+
+func TestSortSparseByValue(t *testing.T) {
+	dense := []float32{0, 0.5, 0, 2, 0.5, 1}
+	vector := denseToSparse(dense, 0, 0, 0)
+	assertIntSliceEqual(t, vector.Indices, []int{1, 3, 4, 5})
+	if !reflect.DeepEqual(vector.Values, []float32{0.5, 2, 0.5, 1}) {
+		t.Fatalf("unexpected index-ordered values: %v", vector.Values)
+	}
+
+	vector.Labels = []string{"b", "d", "e", "f"}
+	vector.Counts = []int{1, 3, 4, 5}
+	sortSparseByValue(&vector)
+	assertIntSliceEqual(t, vector.Indices, []int{3, 5, 1, 4})
+	if !reflect.DeepEqual(vector.Values, []float32{2, 1, 0.5, 0.5}) {
+		t.Fatalf("unexpected value-ordered values: %v", vector.Values)
+	}
+	if !reflect.DeepEqual(vector.Labels, []string{"d", "f", "b", "e"}) {
+		t.Fatalf("expected labels to follow their indices, got %v", vector.Labels)
+	}
+	assertIntSliceEqual(t, vector.Counts, []int{3, 5, 1, 4})
+	if vector.RawValues != nil {
+		t.Fatalf("expected absent raw values to stay absent, got %v", vector.RawValues)
+	}
+
+	cfg := defaultConfig()
+	if err := WithSparseSortByValue()(&cfg); err != nil {
+		t.Fatalf("WithSparseSortByValue failed: %v", err)
+	}
+	if !cfg.sortByValue {
+		t.Fatalf("expected sortByValue=true")
+	}
+}
+
+func TestSparseFromOutputExcludesIndices(t *testing.T) {
+	// Two attended tokens over a 4-term vocabulary; index 0 plays [CLS] and has the
+	// highest logit of every token.
+	output := []float32{
+		5, 1, 0, 2,
+		6, 0, 3, 0,
+	}
+	attentionMask := []int64{1, 1}
+
+	embeddings, err := sparseVectorsFromOutput(output, attentionMask, 1, 2, 4, OutputLayoutTokenLogits, 0, 0, 0, true, nil, []int{0})
+	if err != nil {
+		t.Fatalf("sparseFromOutput failed: %v", err)
+	}
+	assertIntSliceEqual(t, embeddings[0].Indices, []int{1, 2, 3})
+
+	// A transform with f(0) > 0 must not bring the excluded index back: exclusion applies
+	// to the transformed, pooled row rather than to the raw logits.
+	offset := func(v float32) float32 { return v + 1 }
+	for _, tc := range []struct {
+		name   string
+		output []float32
+		seq    int
+		layout OutputLayout
+	}{
+		{name: "token logits", output: output, seq: 2, layout: OutputLayoutTokenLogits},
+		{name: "document logits", output: []float32{5, 1, 0, 2}, seq: 1, layout: OutputLayoutDocumentLogits},
+	} {
+		vectors, err := sparseVectorsFromOutput(tc.output, attentionMask[:tc.seq], 1, tc.seq, 4, tc.layout, 0, 0, 4, false, offset, []int{0, 2})
+		if err != nil {
+			t.Fatalf("%s: sparseFromOutput failed: %v", tc.name, err)
+		}
+		assertIntSliceEqual(t, vectors[0].Indices, []int{1, 3})
+	}
+
+	if _, err := sparseVectorsFromOutput(output, attentionMask, 1, 2, 4, OutputLayoutTokenLogits, 0, 0, 0, true, nil, []int{4}); err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Fatalf("expected out-of-range error, got: %v", err)
+	}
+
+	cfg := defaultConfig()
+	if err := WithExcludeSpecialTokenIndices(101, 102)(&cfg); err != nil {
+		t.Fatalf("WithExcludeSpecialTokenIndices failed: %v", err)
+	}
+	if err := WithExcludeSpecialTokenIndices(0)(&cfg); err != nil {
+		t.Fatalf("WithExcludeSpecialTokenIndices failed: %v", err)
+	}
+	assertIntSliceEqual(t, cfg.excludedIndices, []int{101, 102, 0})
+	if err := WithExcludeSpecialTokenIndices(-1)(&cfg); err == nil {
+		t.Fatalf("expected negative index to be rejected")
+	}
 }
@@ -1,8 +1,9 @@
 package splade
 
 import (
-	"math"
 	"testing"
+
+	"github.com/amikos-tech/pure-onnx/ort"
 )
 
 const (
@@ -134,13 +135,13 @@ func TestSPLADERepeatabilityTopK16(t *testing.T) {
 			if len(got[row].Labels) != len(baseline[row].Labels) {
 				t.Fatalf("repeat run %d row %d label length mismatch: got %d want %d", run, row, len(got[row].Labels), len(baseline[row].Labels))
 			}
+			if err := ort.ApproxEqual(got[row].Values, baseline[row].Values, 1e-6); err != nil {
+				t.Fatalf("repeat run %d row %d values: %v", run, row, err)
+			}
 			for i := range baseline[row].Indices {
 				if got[row].Indices[i] != baseline[row].Indices[i] {
 					t.Fatalf("repeat run %d row %d index[%d] mismatch: got %d want %d", run, row, i, got[row].Indices[i], baseline[row].Indices[i])
 				}
-				if math.Abs(float64(got[row].Values[i]-baseline[row].Values[i])) > float64(1e-6) {
-					t.Fatalf("repeat run %d row %d value[%d] mismatch: got %.8f want %.8f", run, row, i, got[row].Values[i], baseline[row].Values[i])
-				}
 				if got[row].Labels[i] != baseline[row].Labels[i] {
 					t.Fatalf("repeat run %d row %d label[%d] mismatch: got %q want %q", run, row, i, got[row].Labels[i], baseline[row].Labels[i])
 				}
@@ -162,13 +163,13 @@ func assertSparseVectorGoldenRow(t *testing.T, row int, got SparseVector, want s
 		t.Fatalf("row %d label length mismatch: got %d want %d", row, len(got.Labels), len(want.labels))
 	}
 
+	if err := ort.ApproxEqual(got.Values, want.values, float64(spladeGoldenValueTolerance)); err != nil {
+		t.Fatalf("row %d values: %v", row, err)
+	}
 	for i := range want.indices {
 		if got.Indices[i] != want.indices[i] {
 			t.Fatalf("row %d index[%d] mismatch: got %d want %d", row, i, got.Indices[i], want.indices[i])
 		}
-		if math.Abs(float64(got.Values[i]-want.values[i])) > float64(spladeGoldenValueTolerance) {
-			t.Fatalf("row %d value[%d] mismatch: got %.8f want %.8f tolerance %.8f", row, i, got.Values[i], want.values[i], spladeGoldenValueTolerance)
-		}
 		if got.Labels[i] != want.labels[i] {
 			t.Fatalf("row %d label[%d] mismatch: got %q want %q", row, i, got.Labels[i], want.labels[i])
 		}
@@ -0,0 +1,66 @@
+package splade
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/amikos-tech/pure-onnx/embeddings/internal/ortutil"
+)
+
+// LazyEmbedder constructs an Embedder on first use, so request handlers can share one
+// instance without coordinating startup.
+//
+// The first call initializes ONNX Runtime (an already initialized runtime is reused,
+// otherwise ort.InitializeEnvironmentWithBootstrap is used) and then the embedder.
+// Concurrent callers wait for that single initialization. A failure is cached and
+// returned by every later call; construct a new LazyEmbedder to retry.
+type LazyEmbedder struct {
+	lazy *ortutil.Lazy[*Embedder]
+}
+
+// NewLazyEmbedder returns a LazyEmbedder that calls NewEmbedder(modelPath, tokenizerPath, opts...)
+// on first use. Paths and options are only validated then.
+func NewLazyEmbedder(modelPath string, tokenizerPath string, opts ...Option) *LazyEmbedder {
+	opts = slices.Clone(opts)
+	return &LazyEmbedder{
+		lazy: ortutil.NewLazy(func() (*Embedder, error) {
+			return NewEmbedder(modelPath, tokenizerPath, opts...)
+		}),
+	}
+}
+
+// Embedder returns the underlying embedder, constructing it on the first call.
+func (l *LazyEmbedder) Embedder() (*Embedder, error) {
+	if l == nil {
+		return nil, fmt.Errorf("lazy embedder is nil")
+	}
+	return l.lazy.Get()
+}
+
+// EmbedDocuments embeds documents with the lazily constructed embedder.
+func (l *LazyEmbedder) EmbedDocuments(documents []string) ([]SparseVector, error) {
+	embedder, err := l.Embedder()
+	if err != nil {
+		return nil, err
+	}
+	return embedder.EmbedDocuments(documents)
+}
+
+// EmbedQuery embeds a single query with the lazily constructed embedder.
+func (l *LazyEmbedder) EmbedQuery(query string) (SparseVector, error) {
+	embedder, err := l.Embedder()
+	if err != nil {
+		return SparseVector{}, err
+	}
+	return embedder.EmbedQuery(query)
+}
+
+// Close releases the embedder and the ONNX Runtime environment reference taken on
+// first use. Closing before first use prevents any later initialization.
+// It is safe to call more than once.
+func (l *LazyEmbedder) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.lazy.Close()
+}
@@ -0,0 +1,96 @@
+package splade
+
+import (
+	"fmt"
+	"os"
+
+	tokenizers "github.com/amikos-tech/pure-tokenizers"
+
+	"github.com/amikos-tech/pure-onnx/embeddings/internal/tokenizerutil"
+)
+
+// SharedTokenizer is one loaded tokenizer that several embedders use through
+// WithSharedTokenizer, for deployments that serve fine-tuned models with a common
+// vocabulary. It is reference counted: each embedder holds a reference until its Close,
+// and the creator holds one until SharedTokenizer.Close, so the tokenizer is released
+// only after all of them are done.
+type SharedTokenizer struct {
+	shared         *tokenizerutil.Shared
+	sequenceLength int
+	slidingWindow  bool
+}
+
+// NewSharedTokenizer loads the tokenizer.json at tokenizerPath for sharing. Only
+// WithSequenceLength, WithSlidingWindow and WithTokenizerLibraryPath apply: without a
+// sliding window the tokenizer pads and truncates to the sequence length, and every
+// embedder using it must resolve to the same sequence length and sliding-window mode.
+func NewSharedTokenizer(tokenizerPath string, opts ...Option) (*SharedTokenizer, error) {
+	if tokenizerPath == "" {
+		return nil, fmt.Errorf("tokenizer path cannot be empty")
+	}
+	if _, err := os.Stat(tokenizerPath); err != nil {
+		return nil, fmt.Errorf("tokenizer path %q is not usable: %w", tokenizerPath, err)
+	}
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+	tokenizer, err := tokenizers.FromFile(tokenizerPath, tokenizerOptions(cfg)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizer: %w", err)
+	}
+	return &SharedTokenizer{
+		shared:         tokenizerutil.NewShared(tokenizerPath, tokenizer),
+		sequenceLength: cfg.sequenceLength,
+		slidingWindow:  cfg.slidingWindowEnabled,
+	}, nil
+}
+
+// WithSharedTokenizer makes the embedder use shared instead of loading its own tokenizer.
+// The embedder's sequence length and sliding-window mode must match the ones shared was
+// created with, and the tokenizerPath passed to NewEmbedder may be empty.
+func WithSharedTokenizer(shared *SharedTokenizer) Option {
+	return func(cfg *config) error {
+		if shared == nil {
+			return fmt.Errorf("shared tokenizer cannot be nil")
+		}
+		cfg.sharedTokenizer = shared
+		return nil
+	}
+}
+
+// SequenceLength returns the length the shared tokenizer pads and truncates to.
+func (s *SharedTokenizer) SequenceLength() int {
+	return s.sequenceLength
+}
+
+// Close releases the creator's reference. The tokenizer is closed once every embedder
+// using it is closed as well. It is safe to call more than once.
+func (s *SharedTokenizer) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.shared.Close()
+}
+
+// checkSharedTokenizer reports an error when the embedder's resolved configuration
+// tokenizes differently from the shared tokenizer.
+func checkSharedTokenizer(shared *SharedTokenizer, sequenceLength int, slidingWindow bool) error {
+	if shared.slidingWindow != slidingWindow {
+		if shared.slidingWindow {
+			return fmt.Errorf("shared tokenizer was created for sliding-window mode; create the embedder with WithSlidingWindow as well")
+		}
+		return fmt.Errorf("shared tokenizer pads and truncates, but the embedder uses a sliding window; create it with WithSlidingWindow")
+	}
+	if !slidingWindow && shared.sequenceLength != sequenceLength {
+		return fmt.Errorf(
+			"shared tokenizer pads to %d tokens, but the embedder's sequence length is %d; create it with WithSequenceLength(%d)",
+			shared.sequenceLength,
+			sequenceLength,
+			sequenceLength,
+		)
+	}
+	return nil
+}
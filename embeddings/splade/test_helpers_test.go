@@ -32,27 +32,5 @@ func setupORTEnvironment(tb testing.TB) func() {
 func resolvePinnedSpladeAssets(tb testing.TB) (modelPath string, tokenizerPath string) {
 	tb.Helper()
 
-	modelPath = resolveAssetPath(
-		tb,
-		"ONNXRUNTIME_TEST_SPLADE_GOLDEN_MODEL_PATH",
-		"ONNXRUNTIME_TEST_SPLADE_GOLDEN_MODEL_URL",
-		"ONNXRUNTIME_TEST_SPLADE_GOLDEN_MODEL_SHA256",
-		spladeModelURL,
-		spladeModelSHA256,
-		"models",
-		"Splade_PP_en_v1.onnx",
-	)
-
-	tokenizerPath = resolveAssetPath(
-		tb,
-		"ONNXRUNTIME_TEST_SPLADE_GOLDEN_TOKENIZER_PATH",
-		"ONNXRUNTIME_TEST_SPLADE_GOLDEN_TOKENIZER_URL",
-		"ONNXRUNTIME_TEST_SPLADE_GOLDEN_TOKENIZER_SHA256",
-		spladeTokenizerURL,
-		spladeTokenizerSHA256,
-		"tokenizers",
-		"Splade_PP_en_v1-tokenizer.json",
-	)
-
-	return modelPath, tokenizerPath
+	return resolveTestBundle(tb, "ONNXRUNTIME_TEST_SPLADE_GOLDEN", spladeBundle)
 }
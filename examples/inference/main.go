@@ -122,14 +122,22 @@ func parseInputData(raw string, expected int) ([]float32, error) {
 		return data, nil
 	}
 
+	// Values pasted from spreadsheet exports may carry a UTF-8 BOM.
+	raw = strings.TrimPrefix(raw, "\ufeff")
 	parts := strings.Split(raw, ",")
 	if len(parts) != expected {
+		if len(parts) == expected+1 && strings.TrimSpace(parts[expected]) == "" {
+			return nil, fmt.Errorf("expected %d elements, got %d (trailing comma?)", expected, len(parts))
+		}
 		return nil, fmt.Errorf("expected %d elements, got %d", expected, len(parts))
 	}
 
 	data := make([]float32, expected)
 	for i, part := range parts {
 		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("empty element at index %d", i)
+		}
 		value, err := strconv.ParseFloat(part, 32)
 		if err != nil {
 			return nil, fmt.Errorf("invalid float at index %d (%q): %w", i, part, err)
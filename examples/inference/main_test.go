@@ -0,0 +1,117 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseInputData(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected int
+		want     []float32
+		wantErr  string
+	}{
+		{
+			name:     "default ramp",
+			raw:      "",
+			expected: 3,
+			want:     []float32{1, 2, 3},
+		},
+		{
+			name:     "standard",
+			raw:      "0.5,1,-2",
+			expected: 3,
+			want:     []float32{0.5, 1, -2},
+		},
+		{
+			name:     "trim spaces",
+			raw:      " 1 , 2,3 ",
+			expected: 3,
+			want:     []float32{1, 2, 3},
+		},
+		{
+			name:     "leading BOM",
+			raw:      "\ufeff1,2",
+			expected: 2,
+			want:     []float32{1, 2},
+		},
+		{
+			name:     "BOM before whitespace",
+			raw:      "\ufeff 1, 2",
+			expected: 2,
+			want:     []float32{1, 2},
+		},
+		{
+			name:     "trailing comma",
+			raw:      "1,2,",
+			expected: 2,
+			wantErr:  "expected 2 elements, got 3 (trailing comma?)",
+		},
+		{
+			name:     "trailing comma with space",
+			raw:      "1,2, ",
+			expected: 2,
+			wantErr:  "(trailing comma?)",
+		},
+		{
+			name:     "trailing comma after BOM",
+			raw:      "\ufeff1,2,",
+			expected: 2,
+			wantErr:  "(trailing comma?)",
+		},
+		{
+			name:     "too few elements",
+			raw:      "1,2",
+			expected: 3,
+			wantErr:  "expected 3 elements, got 2",
+		},
+		{
+			name:     "too many elements",
+			raw:      "1,2,3,4",
+			expected: 2,
+			wantErr:  "expected 2 elements, got 4",
+		},
+		{
+			name:     "empty element",
+			raw:      "1,,3",
+			expected: 3,
+			wantErr:  "empty element at index 1",
+		},
+		{
+			name:     "trailing empty element at expected count",
+			raw:      "1,2,",
+			expected: 3,
+			wantErr:  "empty element at index 2",
+		},
+		{
+			name:     "invalid float",
+			raw:      "1,x",
+			expected: 2,
+			wantErr:  `invalid float at index 1 ("x")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseInputData(tt.raw, tt.expected)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %q", tt.wantErr, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected data: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Command modelinfo prints the inputs and outputs of an ONNX model: names, element
+// types and shapes, with dynamic dimensions shown by their symbolic names. Use it to
+// find the input and output names to configure an embedder for a new model.
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/amikos-tech/pure-onnx/ort"
+)
+
+func main() {
+	modelPath := os.Getenv("ONNX_MODEL_PATH")
+	if len(os.Args) > 1 {
+		modelPath = os.Args[1]
+	}
+	if modelPath == "" {
+		log.Fatal("usage: modelinfo <model.onnx> (or set ONNX_MODEL_PATH)")
+	}
+
+	if err := initializeOrtEnvironment(); err != nil {
+		log.Fatalf("failed to initialize ONNX Runtime: %v", err)
+	}
+	defer func() {
+		if err := ort.DestroyEnvironment(); err != nil {
+			log.Printf("failed to destroy environment: %v", err)
+		}
+	}()
+
+	inputs, outputs, err := ort.GetInputOutputInfo(modelPath)
+	if err != nil {
+		log.Fatalf("failed to inspect model: %v", err)
+	}
+	if err := writeModelInfo(os.Stdout, modelPath, inputs, outputs); err != nil {
+		log.Fatalf("failed to print model info: %v", err)
+	}
+}
+
+// writeModelInfo prints one row per input and output, aligned within each section.
+func writeModelInfo(w io.Writer, modelPath string, inputs, outputs []ort.InputOutputInfo) error {
+	if _, err := fmt.Fprintf(w, "model: %s\n", modelPath); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, section := range []struct {
+		title string
+		infos []ort.InputOutputInfo
+	}{
+		{title: "inputs", infos: inputs},
+		{title: "outputs", infos: outputs},
+	} {
+		if _, err := fmt.Fprintf(tw, "%s:\n", section.title); err != nil {
+			return err
+		}
+		if len(section.infos) == 0 {
+			if _, err := fmt.Fprintln(tw, "  (none)"); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, info := range section.infos {
+			if _, err := fmt.Fprintf(tw, "  %s\t%s\t%s\n", info.Name, formatType(info), formatShape(info)); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+// formatType names a tensor's element type, or the value kind for non-tensor values.
+func formatType(info ort.InputOutputInfo) string {
+	if info.OrtValueType != ort.ONNXTypeTensor {
+		if name, ok := valueTypeNames[info.OrtValueType]; ok {
+			return name
+		}
+		return fmt.Sprintf("value type %d", info.OrtValueType)
+	}
+	if name, ok := elementTypeNames[info.DataType]; ok {
+		return name
+	}
+	return fmt.Sprintf("element type %d", info.DataType)
+}
+
+// formatShape renders dimensions as [batch_size, sequence_length, 384]; dynamic
+// dimensions without a symbolic name are shown as "?".
+func formatShape(info ort.InputOutputInfo) string {
+	if info.OrtValueType != ort.ONNXTypeTensor {
+		return "-"
+	}
+	dims := make([]string, len(info.Dimensions))
+	for i, dim := range info.Dimensions {
+		switch {
+		case dim >= 0:
+			dims[i] = strconv.FormatInt(dim, 10)
+		case i < len(info.SymbolicDimensions) && info.SymbolicDimensions[i] != "":
+			dims[i] = info.SymbolicDimensions[i]
+		default:
+			dims[i] = "?"
+		}
+	}
+	return "[" + strings.Join(dims, ", ") + "]"
+}
+
+var elementTypeNames = map[ort.TensorElementDataType]string{
+	ort.TensorElementDataTypeFloat:    "float32",
+	ort.TensorElementDataTypeUint8:    "uint8",
+	ort.TensorElementDataTypeInt8:     "int8",
+	ort.TensorElementDataTypeUint16:   "uint16",
+	ort.TensorElementDataTypeInt16:    "int16",
+	ort.TensorElementDataTypeInt32:    "int32",
+	ort.TensorElementDataTypeInt64:    "int64",
+	ort.TensorElementDataTypeString:   "string",
+	ort.TensorElementDataTypeBool:     "bool",
+	ort.TensorElementDataTypeFloat16:  "float16",
+	ort.TensorElementDataTypeDouble:   "float64",
+	ort.TensorElementDataTypeUint32:   "uint32",
+	ort.TensorElementDataTypeUint64:   "uint64",
+	ort.TensorElementDataTypeBFloat16: "bfloat16",
+}
+
+var valueTypeNames = map[ort.ONNXType]string{
+	ort.ONNXTypeSequence:  "sequence",
+	ort.ONNXTypeMap:       "map",
+	ort.ONNXTypeOpaque:    "opaque",
+	ort.ONNXTypeSparseMap: "sparse tensor",
+	ort.ONNXTypeOptional:  "optional",
+}
+
+func initializeOrtEnvironment() error {
+	libPath := os.Getenv("ONNXRUNTIME_LIB_PATH")
+	if libPath != "" {
+		if err := ort.SetSharedLibraryPath(libPath); err != nil {
+			return fmt.Errorf("failed to set explicit ONNX Runtime library path: %w", err)
+		}
+		return ort.InitializeEnvironment()
+	}
+
+	bootstrappedPath, err := ort.EnsureOnnxRuntimeSharedLibrary()
+	if err != nil {
+		return fmt.Errorf("failed to bootstrap ONNX Runtime shared library: %w", err)
+	}
+
+	log.Printf("ONNXRUNTIME_LIB_PATH not set; using bootstrapped library at %s", bootstrappedPath)
+	if err := ort.SetSharedLibraryPath(bootstrappedPath); err != nil {
+		return fmt.Errorf("failed to set bootstrapped ONNX Runtime library path: %w", err)
+	}
+
+	return ort.InitializeEnvironment()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/amikos-tech/pure-onnx/ort"
+)
+
+func TestWriteModelInfo(t *testing.T) {
+	inputs := []ort.InputOutputInfo{
+		{Name: "input_ids", OrtValueType: ort.ONNXTypeTensor, DataType: ort.TensorElementDataTypeInt64, Dimensions: ort.Shape{-1, -1}, SymbolicDimensions: []string{"batch_size", "sequence_length"}},
+		{Name: "attention_mask", OrtValueType: ort.ONNXTypeTensor, DataType: ort.TensorElementDataTypeInt64, Dimensions: ort.Shape{-1, -1}, SymbolicDimensions: []string{"batch_size", ""}},
+	}
+	outputs := []ort.InputOutputInfo{
+		{Name: "last_hidden_state", OrtValueType: ort.ONNXTypeTensor, DataType: ort.TensorElementDataTypeFloat, Dimensions: ort.Shape{-1, -1, 384}, SymbolicDimensions: []string{"batch_size", "sequence_length", ""}},
+		{Name: "scores", OrtValueType: ort.ONNXTypeTensor, DataType: ort.TensorElementDataTypeFloat8E5M2, Dimensions: ort.Shape{}},
+		{Name: "labels", OrtValueType: ort.ONNXTypeSequence},
+	}
+
+	var out strings.Builder
+	if err := writeModelInfo(&out, "model.onnx", inputs, outputs); err != nil {
+		t.Fatalf("writeModelInfo failed: %v", err)
+	}
+	want := strings.Join([]string{
+		"model: model.onnx",
+		"inputs:",
+		"  input_ids       int64  [batch_size, sequence_length]",
+		"  attention_mask  int64  [batch_size, ?]",
+		"outputs:",
+		"  last_hidden_state  float32          [batch_size, sequence_length, 384]",
+		"  scores             element type 19  []",
+		"  labels             sequence         -",
+		"",
+	}, "\n")
+	if got := out.String(); got != want {
+		t.Fatalf("unexpected model info:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteModelInfoWithoutOutputs(t *testing.T) {
+	var out strings.Builder
+	if err := writeModelInfo(&out, "empty.onnx", nil, nil); err != nil {
+		t.Fatalf("writeModelInfo failed: %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, "inputs:\n  (none)\noutputs:\n  (none)\n") {
+		t.Fatalf("expected empty sections, got:\n%s", got)
+	}
+}
@@ -0,0 +1,116 @@
+// Package onnxproto reads fields of serialized ONNX protobuf messages from a stream
+// without decoding whole messages, so large fields such as tensor data can be skipped
+// instead of loaded into memory.
+package onnxproto
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Scanner reads protobuf fields from a stream while tracking the offset, so nested
+// messages can be bounded and large fields skipped by seeking. Lengths are checked
+// against the stream size, since seeking past the end of a file does not fail.
+type Scanner struct {
+	file   io.ReadSeeker
+	reader *bufio.Reader
+	offset uint64
+	size   uint64
+}
+
+// NewScanner returns a Scanner over the size bytes of file from its current position.
+func NewScanner(file io.ReadSeeker, size uint64) *Scanner {
+	return &Scanner{file: file, reader: bufio.NewReader(file), size: size}
+}
+
+// Offset returns the number of bytes consumed so far.
+func (s *Scanner) Offset() uint64 {
+	return s.offset
+}
+
+// End returns the offset at which a field of length bytes starting at the current
+// offset ends, or an error when it would run past the end of the stream.
+func (s *Scanner) End(length uint64) (uint64, error) {
+	if length > s.size-s.offset {
+		return 0, fmt.Errorf("field of %d bytes at offset %d runs past the end of the %d byte stream", length, s.offset, s.size)
+	}
+	return s.offset + length, nil
+}
+
+// ReadByte reads one byte, for binary.ReadUvarint.
+func (s *Scanner) ReadByte() (byte, error) {
+	b, err := s.reader.ReadByte()
+	if err == nil {
+		s.offset++
+	}
+	return b, err
+}
+
+// ReadUvarint reads a varint, such as a field key or length. It returns io.EOF only
+// when the stream ends before the first byte.
+func (s *Scanner) ReadUvarint() (uint64, error) {
+	return binary.ReadUvarint(s)
+}
+
+// ReadBytes reads a field value of n bytes, rejecting values longer than limit.
+func (s *Scanner) ReadBytes(n uint64, limit uint64) ([]byte, error) {
+	if n > limit {
+		return nil, fmt.Errorf("field of %d bytes exceeds the %d byte limit", n, limit)
+	}
+	if _, err := s.End(n); err != nil {
+		return nil, err
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(s.reader, data); err != nil {
+		return nil, err
+	}
+	s.offset += n
+	return data, nil
+}
+
+// Skip discards n bytes, seeking past those that are not buffered.
+func (s *Scanner) Skip(n uint64) error {
+	if _, err := s.End(n); err != nil {
+		return err
+	}
+	if n <= uint64(s.reader.Buffered()) {
+		if _, err := s.reader.Discard(int(n)); err != nil {
+			return err
+		}
+		s.offset += n
+		return nil
+	}
+	offset := int64(n) - int64(s.reader.Buffered())
+	if offset < 0 {
+		return fmt.Errorf("field length %d overflows", n)
+	}
+	if _, err := s.file.Seek(offset, io.SeekCurrent); err != nil {
+		return err
+	}
+	s.reader.Reset(s.file)
+	s.offset += n
+	return nil
+}
+
+// SkipField skips the value of a field with the given wire type.
+func (s *Scanner) SkipField(wireType uint64) error {
+	switch wireType {
+	case 0:
+		_, err := s.ReadUvarint()
+		return err
+	case 1:
+		return s.Skip(8)
+	case 2:
+		length, err := s.ReadUvarint()
+		if err != nil {
+			return err
+		}
+		return s.Skip(length)
+	case 5:
+		return s.Skip(4)
+	default:
+		return fmt.Errorf("unsupported wire type %d", wireType)
+	}
+}
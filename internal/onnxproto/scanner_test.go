@@ -0,0 +1,60 @@
+package onnxproto
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestScannerBoundsFieldsBySize(t *testing.T) {
+	// Field 1 (varint 150), then field 2 (bytes "hi"), then field 3 claiming 100 bytes.
+	data := []byte{0x08, 0x96, 0x01, 0x12, 0x02, 'h', 'i', 0x1a, 0x64, 'x'}
+	s := NewScanner(bytes.NewReader(data), uint64(len(data)))
+
+	if key, err := s.ReadUvarint(); err != nil || key != 0x08 {
+		t.Fatalf("unexpected first key: %d, %v", key, err)
+	}
+	if err := s.SkipField(0); err != nil {
+		t.Fatalf("failed to skip varint field: %v", err)
+	}
+	if key, err := s.ReadUvarint(); err != nil || key != 0x12 {
+		t.Fatalf("unexpected second key: %d, %v", key, err)
+	}
+	length, err := s.ReadUvarint()
+	if err != nil {
+		t.Fatalf("failed to read length: %v", err)
+	}
+	if _, err := s.ReadBytes(length, 1); err == nil || !strings.Contains(err.Error(), "exceeds the 1 byte limit") {
+		t.Fatalf("expected the byte limit to apply, got: %v", err)
+	}
+	if value, err := s.ReadBytes(length, 16); err != nil || string(value) != "hi" {
+		t.Fatalf("unexpected bytes field: %q, %v", value, err)
+	}
+	if s.Offset() != 7 {
+		t.Fatalf("unexpected offset: got %d, want 7", s.Offset())
+	}
+
+	if _, err := s.ReadUvarint(); err != nil {
+		t.Fatalf("failed to read third key: %v", err)
+	}
+	if err := s.SkipField(2); err == nil || !strings.Contains(err.Error(), "runs past the end of the 10 byte stream") {
+		t.Fatalf("expected a skip past the end to fail, got: %v", err)
+	}
+}
+
+func TestScannerSkipsUnbufferedFieldsBySeeking(t *testing.T) {
+	data := make([]byte, 10000)
+	data[len(data)-1] = 0x2a
+	s := NewScanner(bytes.NewReader(data), uint64(len(data)))
+	if err := s.Skip(uint64(len(data) - 1)); err != nil {
+		t.Fatalf("Skip failed: %v", err)
+	}
+	if b, err := s.ReadByte(); err != nil || b != 0x2a {
+		t.Fatalf("unexpected byte after skip: %#x, %v", b, err)
+	}
+	if _, err := s.ReadUvarint(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF at the end of the stream, got: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package ort
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// CheckAPICapabilities reports, for each OrtApi function pointer wrapped by this
+// package (keyed by its C API name, for example "SessionGetProfilingStartTimeNs"),
+// whether the loaded ONNX Runtime library provides it. Calling a missing function
+// crashes the process, so features such as profiling, string tensors or a specific
+// execution provider can be gated on the map instead. ONNX Runtime must be initialized.
+func CheckAPICapabilities() (map[string]bool, error) {
+	ortCallMu.RLock()
+	defer ortCallMu.RUnlock()
+
+	mu.Lock()
+	api, version := ortAPI, apiVersion
+	mu.Unlock()
+	if api == nil {
+		return nil, fmt.Errorf("ONNX Runtime not initialized")
+	}
+	return apiCapabilities(api, version), nil
+}
+
+// apiCapabilities maps each OrtApi field name to whether its pointer is non-null. The
+// table returned for API version v only holds ortAPIFunctionCounts[v] pointers, so
+// fields past it are reported missing without being read.
+func apiCapabilities(api *OrtApi, version uint32) map[string]bool {
+	value := reflect.ValueOf(api).Elem()
+	fields := value.Type()
+	available := apiFunctionCount(version)
+	capabilities := make(map[string]bool, fields.NumField())
+	for i := 0; i < fields.NumField(); i++ {
+		capabilities[fields.Field(i).Name] = i < available && value.Field(i).Uint() != 0
+	}
+	return capabilities
+}
+
+// apiProvides reports whether the OrtApi table negotiated at version provides the
+// function in field name, reading the pointer only when the field is part of that table.
+func apiProvides(api *OrtApi, version uint32, name string) bool {
+	field, ok := reflect.TypeOf(OrtApi{}).FieldByName(name)
+	if !ok || field.Index[0] >= apiFunctionCount(version) {
+		return false
+	}
+	return reflect.ValueOf(api).Elem().Field(field.Index[0]).Uint() != 0
+}
+
+// apiFunctionCount returns the number of function pointers in the OrtApi table of
+// version, or zero for versions this package does not know.
+func apiFunctionCount(version uint32) int {
+	if int(version) < len(ortAPIFunctionCounts) {
+		return ortAPIFunctionCounts[version]
+	}
+	return 0
+}
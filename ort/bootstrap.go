@@ -3,9 +3,13 @@ package ort
 import (
 	"archive/tar"
 	"archive/zip"
+	"cmp"
 	"compress/gzip"
+	"context"
 	"crypto/sha256"
+	"crypto/tls"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -18,6 +22,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -30,7 +35,9 @@ const (
 	// This should track the runtime version validated by CI and examples.
 	DefaultOnnxRuntimeVersion = "1.23.1"
 
-	defaultBootstrapBaseURL = "https://github.com/microsoft/onnxruntime/releases/download"
+	defaultBootstrapBaseURL   = "https://github.com/microsoft/onnxruntime/releases/download"
+	defaultBootstrapUserAgent = "pure-onnx-bootstrap"
+	defaultReleasesAPIURL     = "https://api.github.com/repos/microsoft/onnxruntime/releases"
 
 	secureDirectoryPermission = 0o750
 	secureLockFilePermission  = 0o600
@@ -38,12 +45,17 @@ const (
 	maxExtractedFileBytes  int64 = 1 << 30 // 1 GiB
 	maxExtractedTotalBytes int64 = 4 << 30 // 4 GiB
 	maxDownloadBytes       int64 = 1 << 30 // 1 GiB
+	maxReleasesAPIBytes    int64 = 8 << 20 // 8 MiB
 )
 
 var errSharedLibraryNotFound = errors.New("ONNX Runtime shared library not found")
 var bootstrapCacheFallbackWarnOnce sync.Once
 var bootstrapInitMu sync.Mutex
 
+// loadedRuntimeVersion reports the ONNX Runtime version loaded in this process; tests
+// replace it.
+var loadedRuntimeVersion = GetVersionString
+
 var (
 	bootstrapLockAcquireTimeout = 2 * time.Minute
 	bootstrapLockRetryInterval  = 200 * time.Millisecond
@@ -60,10 +72,25 @@ type bootstrapConfig struct {
 	disableDownload bool
 	expectedSHA256  string
 	baseURL         string
+	mirrors         []string
+	userAgent       string
+	muslURL         string
+	sharedStoreDir  string
+	latestStable    bool
+	releasesURL     string
+	opsetCheckModel string
+	skipReextract   bool
+	isMusl          func() bool
 	httpClient      *http.Client
+	certPins        [][sha256.Size]byte // Accepted certificate SHA256 digests for archive downloads.
+	pinnedClient    *http.Client        // Built once from httpClient and certPins by resolveBootstrapConfig.
 	maxDownloadSize int64
+	extraction      extractionLimits
 	goos            string
 	goarch          string
+	crossPlatform   bool            // Set when WithBootstrapPlatform overrides goos/goarch.
+	librarySearch   []string        // Extra install-relative library directories.
+	ctx             context.Context // Cancels bootstrap HTTP requests; set by WithBootstrapContext.
 }
 
 type runtimeArtifact struct {
@@ -71,9 +98,27 @@ type runtimeArtifact struct {
 	archiveExtension string
 	primaryLibrary   string
 	libraryGlob      string
+	// nugetRID is the NuGet runtime identifier, naming the runtimes/<rid>/native/
+	// directory that NuGet packages place the library in.
+	nugetRID string
+	// archiveURL, when set, is the only download location (used for musl builds).
+	archiveURL string
+	// extraLibraryDirs are searched, relative to the install directory, after lib/.
+	extraLibraryDirs []string
+}
+
+// extractionLimits bounds archive extraction so corrupt or hostile archives cannot
+// exhaust disk space.
+type extractionLimits struct {
+	perFile int64
+	total   int64
 }
 
+var defaultExtractionLimits = extractionLimits{perFile: maxExtractedFileBytes, total: maxExtractedTotalBytes}
+
 type archiveExtractionReport struct {
+	regularFiles               int
+	extractedBytes             int64
 	skippedLinkEntries         int
 	skippedLibraryLinkEntries  int
 	skippedLibraryLinkExamples []string
@@ -127,19 +172,252 @@ func WithBootstrapDisableDownload(disable bool) BootstrapOption {
 // WithBootstrapExpectedSHA256 enforces an expected SHA256 checksum for the downloaded archive.
 func WithBootstrapExpectedSHA256(checksum string) BootstrapOption {
 	return func(cfg *bootstrapConfig) error {
-		checksum = strings.TrimSpace(strings.ToLower(checksum))
-		if checksum == "" {
-			return fmt.Errorf("expected SHA256 checksum cannot be empty")
+		checksum, err := normalizeSHA256Hex(checksum, "expected SHA256 checksum")
+		if err != nil {
+			return err
+		}
+		cfg.expectedSHA256 = checksum
+		return nil
+	}
+}
+
+// WithBootstrapCertPin pins archive downloads to a TLS certificate: the handshake fails
+// unless a certificate in the verified chain has the given SHA256 digest (hex of the
+// DER encoding), so a compromised CA cannot serve a malicious runtime. Pinning an
+// intermediate certificate also covers redirect hosts that share it. Repeat the option
+// to accept several certificates, for example across a rotation. Pinned downloads
+// require https, including for loopback mirrors and redirects.
+func WithBootstrapCertPin(sha256OfCert string) BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		pin, err := normalizeSHA256Hex(sha256OfCert, "certificate pin")
+		if err != nil {
+			return err
+		}
+		var digest [sha256.Size]byte
+		if _, err := hex.Decode(digest[:], []byte(pin)); err != nil {
+			return fmt.Errorf("invalid certificate pin: %w", err)
+		}
+		cfg.certPins = append(cfg.certPins, digest)
+		return nil
+	}
+}
+
+// normalizeSHA256Hex lowercases and validates a hex-encoded SHA256 digest; label names
+// the value in errors.
+func normalizeSHA256Hex(value, label string) (string, error) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "" {
+		return "", fmt.Errorf("%s cannot be empty", label)
+	}
+	if len(value) != 64 {
+		return "", fmt.Errorf("%s must be 64 hex characters", label)
+	}
+	for _, r := range value {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return "", fmt.Errorf("%s must be hex characters (0-9, a-f)", label)
 		}
-		if len(checksum) != 64 {
-			return fmt.Errorf("expected SHA256 checksum must be 64 hex characters")
+	}
+	return value, nil
+}
+
+// WithBootstrapMirrors sets an ordered list of base URLs to download the ONNX Runtime
+// archive from. Bootstrap tries each mirror in order and falls back to the next one
+// when a download fails. Each mirror must serve the GitHub release layout
+// (<mirror>/v<version>/<archive>) and must use https (http is allowed only for loopback hosts).
+func WithBootstrapMirrors(mirrors []string) BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		if len(mirrors) == 0 {
+			return fmt.Errorf("bootstrap mirror list cannot be empty")
 		}
-		for _, r := range checksum {
-			if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
-				return fmt.Errorf("expected SHA256 checksum must be hex characters (0-9, a-f)")
+		normalized := make([]string, 0, len(mirrors))
+		for i, mirror := range mirrors {
+			mirror = strings.TrimRight(strings.TrimSpace(mirror), "/")
+			if mirror == "" {
+				return fmt.Errorf("bootstrap mirror at index %d cannot be empty", i)
+			}
+			if err := validateBootstrapBaseURL(mirror); err != nil {
+				return fmt.Errorf("invalid bootstrap mirror at index %d: %w", i, err)
 			}
+			normalized = append(normalized, mirror)
 		}
-		cfg.expectedSHA256 = checksum
+		cfg.mirrors = normalized
+		return nil
+	}
+}
+
+// WithBootstrapUserAgent sets the User-Agent header sent with ONNX Runtime archive
+// downloads, so proxies and WAFs can identify the client. Defaults to "pure-onnx-bootstrap".
+func WithBootstrapUserAgent(userAgent string) BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		userAgent = strings.TrimSpace(userAgent)
+		if userAgent == "" {
+			return fmt.Errorf("bootstrap user agent cannot be empty")
+		}
+		if strings.ContainsFunc(userAgent, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+			return fmt.Errorf("bootstrap user agent cannot contain control characters")
+		}
+		cfg.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithBootstrapMuslURL sets the URL of a musl-compatible ONNX Runtime .tgz archive used
+// when bootstrap detects a musl-based Linux (for example Alpine). Official Microsoft
+// builds link against glibc and fail to load on musl. The archive must follow the
+// official layout, with the shared library under lib/.
+func WithBootstrapMuslURL(archiveURL string) BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		archiveURL = strings.TrimSpace(archiveURL)
+		if archiveURL == "" {
+			return fmt.Errorf("bootstrap musl archive URL cannot be empty")
+		}
+		if err := validateBootstrapBaseURL(archiveURL); err != nil {
+			return fmt.Errorf("invalid bootstrap musl archive URL: %w", err)
+		}
+		cfg.muslURL = archiveURL
+		return nil
+	}
+}
+
+// WithBootstrapSharedStore extracts downloaded runtimes into a content-addressed store
+// under dir (one directory per archive SHA256) and points each cache directory's install
+// at it, so several cache directories using the same version share one extracted copy.
+// Installs are symlinks where the platform allows them; otherwise (for example on Windows
+// without symlink privileges) the store entry is copied into the cache directory.
+// PruneBootstrapCache removes only the cache-side links; the store is never pruned.
+func WithBootstrapSharedStore(dir string) BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			return fmt.Errorf("bootstrap shared store directory cannot be empty")
+		}
+		cfg.sharedStoreDir = dir
+		return nil
+	}
+}
+
+// WithBootstrapLatestStable resolves the newest non-prerelease ONNX Runtime release from
+// the GitHub releases API and bootstraps that version instead of the configured one.
+// It is opt-in because it adds a network call on every bootstrap, and it cannot be
+// combined with disabled downloads or with WithBootstrapExpectedSHA256, whose checksum
+// pins one archive while the resolved version changes with each release.
+func WithBootstrapLatestStable() BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		cfg.latestStable = true
+		return nil
+	}
+}
+
+// WithBootstrapModelOpsetCheck fails bootstrap before any download when the resolved
+// ONNX Runtime version is known to be too old for the opset of the model at modelPath,
+// instead of failing later at session creation with a less specific error.
+// It has no effect when an explicit library path is used, since its version is unknown
+// until the library is loaded; use CheckModelOpsetCompatibility with GetVersionString there.
+func WithBootstrapModelOpsetCheck(modelPath string) BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		modelPath = strings.TrimSpace(modelPath)
+		if modelPath == "" {
+			return fmt.Errorf("bootstrap opset check model path cannot be empty")
+		}
+		cfg.opsetCheckModel = modelPath
+		return nil
+	}
+}
+
+// WithBootstrapSkipReextractIfPresent makes bootstrap use an existing install directory
+// for the requested version in place. When enabled and the directory exists, bootstrap
+// never downloads, extracts or takes the cache lock; an install without a usable library
+// is reported as an error instead of being repaired, which suits read-only caches and
+// immutable container layers. Complete cached installs are reused either way.
+func WithBootstrapSkipReextractIfPresent(skip bool) BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		cfg.skipReextract = skip
+		return nil
+	}
+}
+
+// WithBootstrapPlatform resolves and downloads the runtime for the given GOOS/GOARCH
+// pair (for example "linux", "arm64") instead of the host platform, so a build pipeline
+// can prefetch the library for an image built for another architecture. Host libc
+// detection is skipped for an overridden platform, so musl archives are never selected.
+// The resulting library cannot be loaded by InitializeEnvironmentWithBootstrap unless
+// the platform matches the host.
+func WithBootstrapPlatform(goos, goarch string) BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		goos, goarch = strings.TrimSpace(goos), strings.TrimSpace(goarch)
+		if goos == "" || goarch == "" {
+			return fmt.Errorf("bootstrap platform GOOS and GOARCH cannot be empty")
+		}
+		if _, err := resolveRuntimeArtifact(goos, goarch); err != nil {
+			return err
+		}
+		cfg.goos = goos
+		cfg.goarch = goarch
+		cfg.crossPlatform = true
+		return nil
+	}
+}
+
+// WithBootstrapLibrarySearchPaths adds directories, relative to the extracted archive
+// root, that are searched for the shared library after lib/. Use it for repackaged
+// archives that place the library elsewhere, such as "bin" or "runtimes/linux-x64/native"
+// in NuGet-style packages. Paths are searched in the order given.
+func WithBootstrapLibrarySearchPaths(paths []string) BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		if len(paths) == 0 {
+			return fmt.Errorf("bootstrap library search paths cannot be empty")
+		}
+		cleaned := make([]string, 0, len(paths))
+		for _, path := range paths {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				return fmt.Errorf("bootstrap library search path cannot be empty")
+			}
+			path = filepath.Clean(filepath.FromSlash(path))
+			if !filepath.IsLocal(path) {
+				return fmt.Errorf("bootstrap library search path %q must be relative to the install directory", path)
+			}
+			cleaned = append(cleaned, path)
+		}
+		cfg.librarySearch = cleaned
+		return nil
+	}
+}
+
+func withBootstrapReleasesURL(releasesURL string) BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		releasesURL = strings.TrimSpace(releasesURL)
+		if releasesURL == "" {
+			return fmt.Errorf("bootstrap releases URL cannot be empty")
+		}
+		if err := validateBootstrapBaseURL(releasesURL); err != nil {
+			return err
+		}
+		cfg.releasesURL = releasesURL
+		return nil
+	}
+}
+
+// WithBootstrapExtractionLimits overrides the per-file and total extraction size limits
+// and the archive download size limit, all in bytes. The defaults (1 GiB per file, 4 GiB
+// total, 1 GiB download) fit CPU builds; raise them deliberately for large GPU archives.
+func WithBootstrapExtractionLimits(perFile, total, download int64) BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		if perFile <= 0 || total <= 0 || download <= 0 {
+			return fmt.Errorf("bootstrap extraction limits must be > 0, got perFile=%d total=%d download=%d", perFile, total, download)
+		}
+		cfg.extraction = extractionLimits{perFile: perFile, total: total}
+		cfg.maxDownloadSize = download
+		return nil
+	}
+}
+
+func withBootstrapMuslDetector(isMusl func() bool) BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		if isMusl == nil {
+			return fmt.Errorf("bootstrap musl detector cannot be nil")
+		}
+		cfg.isMusl = isMusl
 		return nil
 	}
 }
@@ -158,6 +436,21 @@ func withBootstrapBaseURL(baseURL string) BootstrapOption {
 	}
 }
 
+// WithBootstrapContext cancels bootstrap network I/O when ctx is done: the latest-stable
+// release query and archive downloads, including a transfer that is already streaming.
+// A canceled download removes its partial temporary archive and returns an error
+// wrapping ctx's error. Waiting on the bootstrap file lock held by another process is
+// bounded by the lock timeout rather than ctx.
+func WithBootstrapContext(ctx context.Context) BootstrapOption {
+	return func(cfg *bootstrapConfig) error {
+		if ctx == nil {
+			return fmt.Errorf("bootstrap context cannot be nil")
+		}
+		cfg.ctx = ctx
+		return nil
+	}
+}
+
 func withBootstrapHTTPClient(client *http.Client) BootstrapOption {
 	return func(cfg *bootstrapConfig) error {
 		if client == nil {
@@ -177,12 +470,30 @@ func EnsureOnnxRuntimeSharedLibrary(opts ...BootstrapOption) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	defer cfg.closeIdleConnections()
 
 	if cfg.libraryPath != "" {
 		return validateLibraryFile(cfg.libraryPath)
 	}
 
-	artifact, err := resolveRuntimeArtifact(cfg.goos, cfg.goarch)
+	if cfg.latestStable {
+		if cfg.disableDownload {
+			return "", fmt.Errorf("latest stable ONNX Runtime resolution requires network access but download is disabled")
+		}
+		version, err := resolveLatestStableVersion(cfg)
+		if err != nil {
+			return "", err
+		}
+		cfg.version = version
+	}
+
+	if cfg.opsetCheckModel != "" {
+		if err := CheckModelOpsetCompatibility(cfg.opsetCheckModel, cfg.version); err != nil {
+			return "", err
+		}
+	}
+
+	artifact, err := cfg.runtimeArtifact()
 	if err != nil {
 		return "", err
 	}
@@ -194,6 +505,12 @@ func EnsureOnnxRuntimeSharedLibrary(opts ...BootstrapOption) (string, error) {
 		return "", resolveErr
 	}
 
+	if cfg.skipReextract {
+		if info, statErr := os.Stat(installDir); statErr == nil && info.IsDir() {
+			return "", fmt.Errorf("ONNX Runtime install %q contains no shared library and re-extraction is skipped", installDir)
+		}
+	}
+
 	if cfg.disableDownload {
 		return "", fmt.Errorf("ONNX Runtime library not found in cache and download is disabled: %s", installDir)
 	}
@@ -202,6 +519,10 @@ func EnsureOnnxRuntimeSharedLibrary(opts ...BootstrapOption) (string, error) {
 		return "", fmt.Errorf("failed to create bootstrap cache directory %q: %w", cfg.cacheDir, err)
 	}
 
+	if err := cfg.context().Err(); err != nil {
+		return "", fmt.Errorf("ONNX Runtime bootstrap canceled: %w", err)
+	}
+
 	lockPath := filepath.Join(cfg.cacheDir, ".locks", fmt.Sprintf("%s-%s.lock", artifact.platform, cfg.version))
 	var resolvedPath string
 	if err := withProcessFileLock(lockPath, func() error {
@@ -212,7 +533,11 @@ func EnsureOnnxRuntimeSharedLibrary(opts ...BootstrapOption) (string, error) {
 			return resolveErr
 		}
 
-		if err := downloadAndInstallRuntime(cfg, artifact, installDir); err != nil {
+		install := downloadAndInstallRuntime
+		if cfg.sharedStoreDir != "" {
+			install = installRuntimeFromSharedStore
+		}
+		if err := install(cfg, artifact, installDir); err != nil {
 			return err
 		}
 
@@ -229,9 +554,54 @@ func EnsureOnnxRuntimeSharedLibrary(opts ...BootstrapOption) (string, error) {
 	return resolvedPath, nil
 }
 
+// VerifyCachedRuntime checks that cacheDir already holds an extracted ONNX Runtime
+// version for the current platform and returns the absolute path to its shared library.
+// It never touches the network or modifies the cache, so it can back readiness checks on
+// immutable infrastructure. An empty version selects DefaultOnnxRuntimeVersion.
+func VerifyCachedRuntime(cacheDir, version string) (string, error) {
+	cacheDir = strings.TrimSpace(cacheDir)
+	if cacheDir == "" {
+		return "", fmt.Errorf("bootstrap cache directory cannot be empty")
+	}
+	version = strings.TrimSpace(version)
+	if version == "" {
+		version = DefaultOnnxRuntimeVersion
+	}
+
+	cfg, err := resolveBootstrapConfig(WithBootstrapCacheDir(cacheDir), WithBootstrapVersion(version))
+	if err != nil {
+		return "", err
+	}
+	artifact, err := cfg.runtimeArtifact()
+	if err != nil {
+		return "", err
+	}
+
+	installDir := filepath.Join(cfg.cacheDir, artifact.archiveName(cfg.version))
+	path, err := resolveExtractedLibraryPath(installDir, artifact)
+	if errors.Is(err, errSharedLibraryNotFound) {
+		return "", fmt.Errorf("ONNX Runtime %s is not cached in %q: %w", cfg.version, installDir, err)
+	}
+	return path, err
+}
+
 // InitializeEnvironmentWithBootstrap resolves a shared library path via bootstrap,
 // sets it on the runtime, and initializes the ONNX Runtime environment.
+// When the environment is already initialized with a different ONNX Runtime version than
+// the one requested, it fails before downloading anything: one process can only load
+// one runtime, so every caller must request the same version.
 func InitializeEnvironmentWithBootstrap(opts ...BootstrapOption) error {
+	cfg, err := resolveBootstrapConfig(opts...)
+	if err != nil {
+		return err
+	}
+	if cfg.goos != runtime.GOOS || cfg.goarch != runtime.GOARCH {
+		return fmt.Errorf("cannot load an ONNX Runtime built for GOOS=%s GOARCH=%s on this %s/%s host; use EnsureOnnxRuntimeSharedLibrary to prefetch it", cfg.goos, cfg.goarch, runtime.GOOS, runtime.GOARCH)
+	}
+	if err := checkInitializedRuntimeVersion(cfg); err != nil {
+		return err
+	}
+
 	path, err := EnsureOnnxRuntimeSharedLibrary(opts...)
 	if err != nil {
 		return err
@@ -254,6 +624,120 @@ func InitializeEnvironmentWithBootstrap(opts ...BootstrapOption) error {
 	return InitializeEnvironment()
 }
 
+// checkInitializedRuntimeVersion reports a conflict between the version a bootstrap
+// call requests and the ONNX Runtime already loaded in this process. Explicit library
+// paths and latest-stable resolution request no fixed version and are not checked.
+func checkInitializedRuntimeVersion(cfg bootstrapConfig) error {
+	if cfg.libraryPath != "" || cfg.latestStable {
+		return nil
+	}
+	loaded := loadedRuntimeVersion()
+	if loaded == "0.0.0-dev" || loaded == cfg.version {
+		return nil
+	}
+	return fmt.Errorf("ONNX Runtime %s is already initialized in this process, but bootstrap requested version %s; request the same version everywhere (for example via ONNXRUNTIME_VERSION)", loaded, cfg.version)
+}
+
+// PruneBootstrapCache removes cached ONNX Runtime installs from cacheDir, keeping the
+// keep most recent versions per platform (ordered by semantic version). Each removal
+// takes the same per-version lock used by bootstrap, so installs in progress are not
+// deleted underneath another process. Entries that are not bootstrap installs are left untouched.
+func PruneBootstrapCache(cacheDir string, keep int) error {
+	cacheDir = strings.TrimSpace(cacheDir)
+	if cacheDir == "" {
+		return fmt.Errorf("bootstrap cache directory cannot be empty")
+	}
+	if keep < 1 {
+		return fmt.Errorf("keep must be at least 1, got %d", keep)
+	}
+
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read bootstrap cache directory %q: %w", cacheDir, err)
+	}
+
+	installsByPlatform := make(map[string][]cachedRuntimeInstall)
+	for _, entry := range entries {
+		// Shared-store installs are symlinks; removing them leaves the store untouched.
+		if !entry.IsDir() && entry.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+		install, ok := parseCachedRuntimeInstall(entry.Name())
+		if !ok {
+			continue
+		}
+		installsByPlatform[install.platform] = append(installsByPlatform[install.platform], install)
+	}
+
+	platforms := make([]string, 0, len(installsByPlatform))
+	for platform := range installsByPlatform {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+
+	var errs []error
+	for _, platform := range platforms {
+		installs := installsByPlatform[platform]
+		if len(installs) <= keep {
+			continue
+		}
+		sort.Slice(installs, func(i, j int) bool {
+			return compareRuntimeVersions(installs[i].version, installs[j].version) > 0
+		})
+		for _, install := range installs[keep:] {
+			installDir := filepath.Join(cacheDir, install.dirName)
+			lockPath := filepath.Join(cacheDir, ".locks", fmt.Sprintf("%s-%s.lock", install.platform, install.version))
+			if err := withProcessFileLock(lockPath, func() error {
+				return os.RemoveAll(installDir)
+			}); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove cached ONNX Runtime %q: %w", installDir, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type cachedRuntimeInstall struct {
+	dirName  string
+	platform string
+	version  string
+}
+
+// parseCachedRuntimeInstall recognizes install directories named by runtimeArtifact.archiveName.
+func parseCachedRuntimeInstall(dirName string) (cachedRuntimeInstall, bool) {
+	rest, ok := strings.CutPrefix(dirName, "onnxruntime-")
+	if !ok {
+		return cachedRuntimeInstall{}, false
+	}
+	sep := strings.LastIndex(rest, "-")
+	if sep <= 0 {
+		return cachedRuntimeInstall{}, false
+	}
+	platform, version := rest[:sep], rest[sep+1:]
+	if _, err := normalizeRuntimeVersion(version); err != nil || strings.HasPrefix(version, "v") {
+		return cachedRuntimeInstall{}, false
+	}
+	return cachedRuntimeInstall{dirName: dirName, platform: platform, version: version}, true
+}
+
+// compareRuntimeVersions compares two x.y.z versions that have passed normalizeRuntimeVersion.
+// It returns a negative value when a < b, zero when equal, and a positive value when a > b.
+func compareRuntimeVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aValue, _ := strconv.Atoi(aParts[i])
+		bValue, _ := strconv.Atoi(bParts[i])
+		if c := cmp.Compare(aValue, bValue); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(aParts), len(bParts))
+}
+
 func resolveBootstrapConfig(opts ...BootstrapOption) (bootstrapConfig, error) {
 	disableDownload, err := parseBootstrapBoolEnv("ONNXRUNTIME_DISABLE_DOWNLOAD")
 	if err != nil {
@@ -266,12 +750,17 @@ func resolveBootstrapConfig(opts ...BootstrapOption) (bootstrapConfig, error) {
 		version:         strings.TrimSpace(os.Getenv("ONNXRUNTIME_VERSION")),
 		disableDownload: disableDownload,
 		baseURL:         defaultBootstrapBaseURL,
+		userAgent:       defaultBootstrapUserAgent,
+		releasesURL:     defaultReleasesAPIURL,
+		isMusl:          detectMuslLibc,
 		httpClient: &http.Client{
 			Timeout: 2 * time.Minute,
 		},
 		maxDownloadSize: maxDownloadBytes,
+		extraction:      defaultExtractionLimits,
 		goos:            runtime.GOOS,
 		goarch:          runtime.GOARCH,
+		ctx:             context.Background(),
 	}
 
 	if cfg.version == "" {
@@ -300,6 +789,17 @@ func resolveBootstrapConfig(opts ...BootstrapOption) (bootstrapConfig, error) {
 		return bootstrapConfig{}, fmt.Errorf("bootstrap cache directory is empty")
 	}
 	cfg.cacheDir = filepath.Clean(cfg.cacheDir)
+	if cfg.sharedStoreDir != "" {
+		storeDir, err := filepath.Abs(cfg.sharedStoreDir)
+		if err != nil {
+			return bootstrapConfig{}, fmt.Errorf("failed to resolve bootstrap shared store directory %q: %w", cfg.sharedStoreDir, err)
+		}
+		cfg.sharedStoreDir = storeDir
+	}
+
+	if cfg.latestStable && cfg.expectedSHA256 != "" {
+		return bootstrapConfig{}, fmt.Errorf("latest stable ONNX Runtime resolution cannot be combined with an expected SHA256 checksum; pin the version with WithBootstrapVersion instead")
+	}
 
 	if strings.TrimSpace(cfg.baseURL) == "" {
 		return bootstrapConfig{}, fmt.Errorf("bootstrap base URL is empty")
@@ -312,6 +812,9 @@ func resolveBootstrapConfig(opts ...BootstrapOption) (bootstrapConfig, error) {
 	if cfg.httpClient == nil {
 		return bootstrapConfig{}, fmt.Errorf("bootstrap HTTP client cannot be nil")
 	}
+	if err := cfg.buildPinnedClient(); err != nil {
+		return bootstrapConfig{}, err
+	}
 	if cfg.maxDownloadSize <= 0 {
 		return bootstrapConfig{}, fmt.Errorf("bootstrap max download bytes must be > 0, got %d", cfg.maxDownloadSize)
 	}
@@ -366,6 +869,7 @@ func resolveRuntimeArtifact(goos, goarch string) (runtimeArtifact, error) {
 				archiveExtension: "tgz",
 				primaryLibrary:   "libonnxruntime.dylib",
 				libraryGlob:      "libonnxruntime*.dylib",
+				nugetRID:         "osx-arm64",
 			}, nil
 		case "amd64":
 			return runtimeArtifact{
@@ -373,6 +877,7 @@ func resolveRuntimeArtifact(goos, goarch string) (runtimeArtifact, error) {
 				archiveExtension: "tgz",
 				primaryLibrary:   "libonnxruntime.dylib",
 				libraryGlob:      "libonnxruntime*.dylib",
+				nugetRID:         "osx-x64",
 			}, nil
 		}
 	case "linux":
@@ -383,6 +888,7 @@ func resolveRuntimeArtifact(goos, goarch string) (runtimeArtifact, error) {
 				archiveExtension: "tgz",
 				primaryLibrary:   "libonnxruntime.so",
 				libraryGlob:      "libonnxruntime.so*",
+				nugetRID:         "linux-arm64",
 			}, nil
 		case "amd64":
 			return runtimeArtifact{
@@ -390,6 +896,7 @@ func resolveRuntimeArtifact(goos, goarch string) (runtimeArtifact, error) {
 				archiveExtension: "tgz",
 				primaryLibrary:   "libonnxruntime.so",
 				libraryGlob:      "libonnxruntime.so*",
+				nugetRID:         "linux-x64",
 			}, nil
 		}
 	case "windows":
@@ -400,6 +907,7 @@ func resolveRuntimeArtifact(goos, goarch string) (runtimeArtifact, error) {
 				archiveExtension: "zip",
 				primaryLibrary:   "onnxruntime.dll",
 				libraryGlob:      "onnxruntime*.dll",
+				nugetRID:         "win-x64",
 			}, nil
 		case "arm64":
 			return runtimeArtifact{
@@ -407,6 +915,7 @@ func resolveRuntimeArtifact(goos, goarch string) (runtimeArtifact, error) {
 				archiveExtension: "zip",
 				primaryLibrary:   "onnxruntime.dll",
 				libraryGlob:      "onnxruntime*.dll",
+				nugetRID:         "win-arm64",
 			}, nil
 		}
 	}
@@ -414,6 +923,57 @@ func resolveRuntimeArtifact(goos, goarch string) (runtimeArtifact, error) {
 	return runtimeArtifact{}, fmt.Errorf("unsupported platform for ONNX Runtime bootstrap: GOOS=%s GOARCH=%s", goos, goarch)
 }
 
+// resolveMuslRuntimeArtifact adapts a glibc Linux artifact to a configured musl archive.
+// Without a musl archive URL it returns an actionable error instead of letting the
+// official glibc build fail later with an opaque dynamic loader error.
+func resolveMuslRuntimeArtifact(artifact runtimeArtifact, muslURL string) (runtimeArtifact, error) {
+	if muslURL == "" {
+		return runtimeArtifact{}, fmt.Errorf(
+			"musl libc detected (e.g. Alpine Linux): official ONNX Runtime %s builds require glibc and cannot be loaded; "+
+				"use a glibc-based image, set ONNXRUNTIME_LIB_PATH to a musl-compatible library, "+
+				"or configure a musl-compatible archive with WithBootstrapMuslURL",
+			artifact.platform,
+		)
+	}
+	artifact.platform += "-musl"
+	artifact.nugetRID = strings.Replace(artifact.nugetRID, "linux-", "linux-musl-", 1)
+	artifact.archiveURL = muslURL
+	return artifact, nil
+}
+
+// detectMuslLibc reports whether the host uses the musl dynamic loader.
+func detectMuslLibc() bool {
+	matches, err := filepath.Glob("/lib/ld-musl-*.so.1")
+	return err == nil && len(matches) > 0
+}
+
+// runtimeArtifact resolves the artifact for the configured platform, including musl builds.
+func (cfg bootstrapConfig) runtimeArtifact() (runtimeArtifact, error) {
+	artifact, err := resolveRuntimeArtifact(cfg.goos, cfg.goarch)
+	if err != nil {
+		return runtimeArtifact{}, err
+	}
+	if cfg.goos == "linux" && !cfg.crossPlatform && cfg.isMusl != nil && cfg.isMusl() {
+		if artifact, err = resolveMuslRuntimeArtifact(artifact, cfg.muslURL); err != nil {
+			return runtimeArtifact{}, err
+		}
+	}
+	artifact.extraLibraryDirs = cfg.librarySearch
+	return artifact, nil
+}
+
+// libraryDirs returns the install-relative directories searched for the shared library.
+// Archives without lib/ are searched in the NuGet runtimes/<rid>/native/ layout instead.
+func (a runtimeArtifact) libraryDirs(installDir string) []string {
+	primaryDir := "lib"
+	if a.nugetRID != "" {
+		if _, err := os.Stat(filepath.Join(installDir, primaryDir)); errors.Is(err, os.ErrNotExist) {
+			primaryDir = filepath.Join("runtimes", a.nugetRID, "native")
+		}
+	}
+	return append([]string{primaryDir}, a.extraLibraryDirs...)
+}
+
 func (a runtimeArtifact) archiveName(version string) string {
 	return fmt.Sprintf("onnxruntime-%s-%s", a.platform, version)
 }
@@ -426,22 +986,184 @@ func (a runtimeArtifact) downloadURL(baseURL, version string) string {
 	return fmt.Sprintf("%s/v%s/%s", strings.TrimRight(baseURL, "/"), version, a.archiveFilename(version))
 }
 
+// context returns the context bootstrap HTTP requests run under, context.Background()
+// unless WithBootstrapContext set one.
+func (cfg bootstrapConfig) context() context.Context {
+	if cfg.ctx == nil {
+		return context.Background()
+	}
+	return cfg.ctx
+}
+
+// downloadBaseURLs returns the base URLs to try in order.
+// Configured mirrors take precedence over the single base URL.
+func (cfg bootstrapConfig) downloadBaseURLs() []string {
+	if len(cfg.mirrors) > 0 {
+		return cfg.mirrors
+	}
+	return []string{cfg.baseURL}
+}
+
 func downloadAndInstallRuntime(cfg bootstrapConfig, artifact runtimeArtifact, installDir string) error {
-	url := artifact.downloadURL(cfg.baseURL, cfg.version)
-	archivePath, checksum, err := downloadRuntimeArchive(cfg, url)
+	archivePath, _, err := downloadVerifiedRuntimeArchive(cfg, artifact)
+	if err != nil {
+		return err
+	}
+	defer removeTemporaryArchive(archivePath)
+
+	return installRuntimeArchive(cfg, artifact, archivePath, installDir)
+}
+
+// downloadVerifiedRuntimeArchive downloads the runtime archive and enforces the
+// configured checksum. The caller owns the returned temporary archive.
+func downloadVerifiedRuntimeArchive(cfg bootstrapConfig, artifact runtimeArtifact) (archivePath string, checksum string, err error) {
+	archivePath, checksum, err = downloadRuntimeArchiveWithFailover(cfg, artifact)
 	if err != nil {
+		return "", "", err
+	}
+	if cfg.expectedSHA256 != "" && checksum != cfg.expectedSHA256 {
+		removeTemporaryArchive(archivePath)
+		return "", "", fmt.Errorf("download checksum mismatch: expected %s, got %s", cfg.expectedSHA256, checksum)
+	}
+	return archivePath, checksum, nil
+}
+
+func removeTemporaryArchive(archivePath string) {
+	if removeErr := os.Remove(archivePath); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+		log.Printf("WARNING: failed to remove temporary ONNX Runtime archive %q: %v", archivePath, removeErr)
+	}
+}
+
+// installRuntimeFromSharedStore makes installDir reference the store entry for the
+// archive checksum, downloading and extracting only when the store has no valid copy.
+// A per-archive index records the checksum so later cache directories can skip the download.
+func installRuntimeFromSharedStore(cfg bootstrapConfig, artifact runtimeArtifact, installDir string) error {
+	archiveName := artifact.archiveName(cfg.version)
+	indexPath := filepath.Join(cfg.sharedStoreDir, "index", archiveName+".sha256")
+	lockPath := filepath.Join(cfg.sharedStoreDir, ".locks", archiveName+".lock")
+
+	var storeEntry string
+	if err := withProcessFileLock(lockPath, func() error {
+		checksum := cfg.expectedSHA256
+		if checksum == "" {
+			checksum = readSharedStoreIndex(indexPath)
+		}
+		if checksum != "" {
+			entry := filepath.Join(cfg.sharedStoreDir, "sha256-"+checksum)
+			if _, err := resolveExtractedLibraryPath(entry, artifact); err == nil {
+				storeEntry = entry
+				return nil
+			}
+		}
+
+		archivePath, checksum, err := downloadVerifiedRuntimeArchive(cfg, artifact)
+		if err != nil {
+			return err
+		}
+		defer removeTemporaryArchive(archivePath)
+
+		entry := filepath.Join(cfg.sharedStoreDir, "sha256-"+checksum)
+		if _, err := resolveExtractedLibraryPath(entry, artifact); err != nil {
+			if err := installRuntimeArchive(cfg, artifact, archivePath, entry); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(indexPath), secureDirectoryPermission); err != nil {
+			return fmt.Errorf("failed to create bootstrap shared store index directory: %w", err)
+		}
+		if err := os.WriteFile(indexPath, []byte(checksum+"\n"), secureLockFilePermission); err != nil {
+			return fmt.Errorf("failed to write bootstrap shared store index %q: %w", indexPath, err)
+		}
+		storeEntry = entry
+		return nil
+	}); err != nil {
 		return err
 	}
+
+	return linkSharedStoreEntry(storeEntry, installDir)
+}
+
+// readSharedStoreIndex returns the recorded archive checksum, or "" when the index is
+// missing or malformed (which simply forces a download).
+func readSharedStoreIndex(indexPath string) string {
+	// #nosec G304 -- indexPath is constructed from the configured store directory and archive name.
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return ""
+	}
+	checksum := strings.ToLower(strings.TrimSpace(string(data)))
+	if decoded, err := hex.DecodeString(checksum); err != nil || len(decoded) != sha256.Size {
+		return ""
+	}
+	return checksum
+}
+
+func linkSharedStoreEntry(storeEntry, installDir string) error {
+	if err := os.RemoveAll(installDir); err != nil {
+		return fmt.Errorf("failed to remove previous ONNX Runtime install at %q: %w", installDir, err)
+	}
+	symlinkErr := os.Symlink(storeEntry, installDir)
+	if symlinkErr == nil {
+		return nil
+	}
+	log.Printf("INFO: could not symlink ONNX Runtime install %q to shared store, copying instead: %v", installDir, symlinkErr)
+
+	stagingDir := installDir + fmt.Sprintf(".staging-%d", time.Now().UnixNano())
 	defer func() {
-		if removeErr := os.Remove(archivePath); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
-			log.Printf("WARNING: failed to remove temporary ONNX Runtime archive %q: %v", archivePath, removeErr)
+		if removeErr := os.RemoveAll(stagingDir); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+			log.Printf("WARNING: failed to remove bootstrap staging directory %q: %v", stagingDir, removeErr)
 		}
 	}()
-
-	if cfg.expectedSHA256 != "" && checksum != cfg.expectedSHA256 {
-		return fmt.Errorf("download checksum mismatch: expected %s, got %s", cfg.expectedSHA256, checksum)
+	if err := copyRuntimeInstall(storeEntry, stagingDir); err != nil {
+		return fmt.Errorf("failed to copy ONNX Runtime from shared store %q: %w", storeEntry, err)
 	}
+	if err := os.Rename(stagingDir, installDir); err != nil {
+		return fmt.Errorf("failed to install ONNX Runtime to %q: %w", installDir, err)
+	}
+	return nil
+}
 
+// copyRuntimeInstall copies directories and regular files from src to dst.
+// Extraction never produces links, so any other entry type is skipped.
+func copyRuntimeInstall(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, secureDirectoryPermission)
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		// #nosec G304 -- path is produced by walking the configured shared store entry.
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = in.Close() }()
+		// #nosec G304 -- target is dst joined with a path relative to the store entry.
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			return errors.Join(err, out.Close())
+		}
+		return out.Close()
+	})
+}
+
+func installRuntimeArchive(cfg bootstrapConfig, artifact runtimeArtifact, archivePath string, installDir string) error {
 	stagingRoot := installDir + fmt.Sprintf(".staging-%d", time.Now().UnixNano())
 	if err := os.RemoveAll(stagingRoot); err != nil {
 		return fmt.Errorf("failed to clean bootstrap staging directory %q: %w", stagingRoot, err)
@@ -455,7 +1177,7 @@ func downloadAndInstallRuntime(cfg bootstrapConfig, artifact runtimeArtifact, in
 		}
 	}()
 
-	extractReport, err := extractArchiveFile(archivePath, stagingRoot, artifact.archiveExtension, artifact.libraryGlob)
+	extractReport, err := extractArchiveFile(archivePath, stagingRoot, artifact.archiveExtension, artifact.libraryGlob, cfg.extraction)
 	if err != nil {
 		return err
 	}
@@ -473,7 +1195,7 @@ func downloadAndInstallRuntime(cfg bootstrapConfig, artifact runtimeArtifact, in
 
 	if _, err := resolveExtractedLibraryPath(extractedInstallDir, artifact); err != nil {
 		if errors.Is(err, errSharedLibraryNotFound) {
-			errMessage := fmt.Sprintf("downloaded archive did not contain expected shared library in %q", filepath.Join(extractedInstallDir, "lib"))
+			errMessage := fmt.Sprintf("downloaded archive did not contain expected shared library in %q (searched: %s)", extractedInstallDir, strings.Join(artifact.libraryDirs(extractedInstallDir), ", "))
 			switch {
 			case extractReport.skippedLibraryLinkEntries > 0:
 				if len(extractReport.skippedLibraryLinkExamples) > 0 {
@@ -517,15 +1239,196 @@ func downloadAndInstallRuntime(cfg bootstrapConfig, artifact runtimeArtifact, in
 	return nil
 }
 
+func downloadRuntimeArchiveWithFailover(cfg bootstrapConfig, artifact runtimeArtifact) (archivePath string, checksum string, err error) {
+	if artifact.archiveURL != "" {
+		return downloadRuntimeArchive(cfg, artifact.archiveURL)
+	}
+
+	baseURLs := cfg.downloadBaseURLs()
+	var downloadErrs []error
+	for i, baseURL := range baseURLs {
+		url := artifact.downloadURL(baseURL, cfg.version)
+		archivePath, checksum, err := downloadRuntimeArchive(cfg, url)
+		if err == nil {
+			return archivePath, checksum, nil
+		}
+		downloadErrs = append(downloadErrs, err)
+		if ctxErr := cfg.context().Err(); ctxErr != nil {
+			return "", "", fmt.Errorf("ONNX Runtime download canceled: %w", errors.Join(append(downloadErrs, ctxErr)...))
+		}
+		if i < len(baseURLs)-1 {
+			log.Printf("WARNING: ONNX Runtime download from mirror %q failed, trying next mirror: %v", baseURL, err)
+		}
+	}
+	if len(downloadErrs) == 1 {
+		return "", "", downloadErrs[0]
+	}
+	return "", "", fmt.Errorf("all %d ONNX Runtime download mirrors failed: %w", len(baseURLs), errors.Join(downloadErrs...))
+}
+
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// resolveLatestStableVersion returns the highest x.y.z version among the published,
+// non-prerelease releases listed by cfg.releasesURL. Tags that do not parse as
+// x.y.z (with an optional "v" prefix) are ignored.
+func resolveLatestStableVersion(cfg bootstrapConfig) (string, error) {
+	req, err := http.NewRequestWithContext(cfg.context(), http.MethodGet, cfg.releasesURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create releases request for %q: %w", cfg.releasesURL, err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if cfg.userAgent != "" {
+		req.Header.Set("User-Agent", cfg.userAgent)
+	}
+
+	resp, err := cfg.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to query ONNX Runtime releases from %q: %w", cfg.releasesURL, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to query ONNX Runtime releases from %q: HTTP %d", cfg.releasesURL, resp.StatusCode)
+	}
+
+	var releases []githubRelease
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxReleasesAPIBytes)).Decode(&releases); err != nil {
+		return "", fmt.Errorf("failed to decode ONNX Runtime releases from %q: %w", cfg.releasesURL, err)
+	}
+
+	latest := ""
+	for _, release := range releases {
+		if release.Draft || release.Prerelease {
+			continue
+		}
+		version, err := normalizeRuntimeVersion(release.TagName)
+		if err != nil {
+			continue
+		}
+		if latest == "" || compareRuntimeVersions(version, latest) > 0 {
+			latest = version
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no stable ONNX Runtime release found at %q", cfg.releasesURL)
+	}
+	return latest, nil
+}
+
+// archiveHTTPClient returns the client for an archive download request: cfg.httpClient
+// without certificate pins, otherwise the pinned client built by buildPinnedClient.
+func archiveHTTPClient(cfg bootstrapConfig, req *http.Request) (*http.Client, error) {
+	if len(cfg.certPins) == 0 {
+		return cfg.httpClient, nil
+	}
+	if req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("certificate pinning requires an https download URL, got %q", req.URL.Redacted())
+	}
+	if cfg.pinnedClient == nil {
+		return nil, fmt.Errorf("certificate pins are configured but no pinned HTTP client was built")
+	}
+	return cfg.pinnedClient, nil
+}
+
+// buildPinnedClient sets cfg.pinnedClient, when certificate pins are configured, to a copy
+// of cfg.httpClient with its own transport whose TLS handshakes require a pinned
+// certificate in the verified chain and whose redirects must stay on https. Callers
+// release its connections with closeIdleConnections.
+func (cfg *bootstrapConfig) buildPinnedClient() error {
+	cfg.pinnedClient = nil
+	if len(cfg.certPins) == 0 {
+		return nil
+	}
+
+	var transport *http.Transport
+	switch base := cfg.httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = base.Clone()
+	default:
+		return fmt.Errorf("certificate pinning requires an *http.Transport, got %T", base)
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	pins := slices.Clone(cfg.certPins)
+	verify := transport.TLSClientConfig.VerifyConnection
+	transport.TLSClientConfig.VerifyConnection = func(state tls.ConnectionState) error {
+		if verify != nil {
+			if err := verify(state); err != nil {
+				return err
+			}
+		}
+		// Only certificates in a verified chain count: a server can send arbitrary extra
+		// certificates in its handshake, so PeerCertificates must not satisfy a pin.
+		for _, chain := range state.VerifiedChains {
+			for _, cert := range chain {
+				if slices.Contains(pins, sha256.Sum256(cert.Raw)) {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("no certificate in the verified chain of %q matches the configured certificate pins", state.ServerName)
+	}
+
+	client := *cfg.httpClient
+	client.Transport = transport
+	checkRedirect := cfg.httpClient.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "https" {
+			return fmt.Errorf("certificate pinning requires https, but the download redirected to %q", req.URL.Redacted())
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	cfg.pinnedClient = &client
+	return nil
+}
+
+// closeIdleConnections closes the idle connections of the pinned client, whose transport
+// belongs to this configuration. cfg.httpClient is left alone.
+func (cfg bootstrapConfig) closeIdleConnections() {
+	if cfg.pinnedClient != nil {
+		cfg.pinnedClient.CloseIdleConnections()
+	}
+}
+
 func downloadRuntimeArchive(cfg bootstrapConfig, url string) (archivePath string, checksum string, err error) {
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	return downloadToTempFile(cfg, url, cfg.cacheDir, "onnxruntime-*.archive", "ONNX Runtime archive")
+}
+
+// downloadToTempFile streams url into a new temporary file in dir, applying the
+// bootstrap download hardening (context, user agent, certificate pins, size limit), and
+// returns the file path and the hex SHA-256 of its content. what names the download in
+// errors. The file is removed on failure.
+func downloadToTempFile(cfg bootstrapConfig, url, dir, pattern, what string) (filePath string, checksum string, err error) {
+	req, err := http.NewRequestWithContext(cfg.context(), http.MethodGet, url, nil)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create download request for %q: %w", url, err)
 	}
+	if cfg.userAgent != "" {
+		req.Header.Set("User-Agent", cfg.userAgent)
+	}
 
-	resp, err := cfg.httpClient.Do(req)
+	client, err := archiveHTTPClient(cfg, req)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := client.Do(req)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to download ONNX Runtime archive from %q: %w", url, err)
+		return "", "", fmt.Errorf("failed to download %s from %q: %w", what, url, err)
 	}
 	defer func() {
 		_ = resp.Body.Close()
@@ -535,21 +1438,20 @@ func downloadRuntimeArchive(cfg bootstrapConfig, url string) (archivePath string
 		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
 		snippet = []byte(strings.TrimSpace(string(snippet)))
 		if len(snippet) > 0 {
-			return "", "", fmt.Errorf("failed to download ONNX Runtime archive from %q: HTTP %d: %s", url, resp.StatusCode, string(snippet))
+			return "", "", fmt.Errorf("failed to download %s from %q: HTTP %d: %s", what, url, resp.StatusCode, string(snippet))
 		}
-		return "", "", fmt.Errorf("failed to download ONNX Runtime archive from %q: HTTP %d", url, resp.StatusCode)
+		return "", "", fmt.Errorf("failed to download %s from %q: HTTP %d", what, url, resp.StatusCode)
 	}
 
-	if err := os.MkdirAll(cfg.cacheDir, secureDirectoryPermission); err != nil {
-		return "", "", fmt.Errorf("failed to create cache directory %q: %w", cfg.cacheDir, err)
+	if err := os.MkdirAll(dir, secureDirectoryPermission); err != nil {
+		return "", "", fmt.Errorf("failed to create cache directory %q: %w", dir, err)
 	}
 
-	tmpFile, err := os.CreateTemp(cfg.cacheDir, "onnxruntime-*.archive")
+	tmpFile, err := os.CreateTemp(dir, pattern)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to create temporary archive file: %w", err)
+		return "", "", fmt.Errorf("failed to create temporary file for %s: %w", what, err)
 	}
 	tmpPath := tmpFile.Name()
-	archivePath = tmpPath
 	success := false
 	defer func() {
 		closeErr := tmpFile.Close()
@@ -558,7 +1460,7 @@ func downloadRuntimeArchive(cfg bootstrapConfig, url string) (archivePath string
 		}
 		if !success {
 			if removeErr := os.Remove(tmpPath); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
-				err = errors.Join(err, fmt.Errorf("failed to remove temporary archive %q: %w", tmpPath, removeErr))
+				err = errors.Join(err, fmt.Errorf("failed to remove temporary file %q: %w", tmpPath, removeErr))
 			}
 		}
 	}()
@@ -569,7 +1471,7 @@ func downloadRuntimeArchive(cfg bootstrapConfig, url string) (archivePath string
 	}
 
 	if resp.ContentLength > downloadLimit {
-		err = fmt.Errorf("downloaded ONNX Runtime archive exceeds maximum size limit: content-length=%d limit=%d", resp.ContentLength, downloadLimit)
+		err = fmt.Errorf("downloaded %s exceeds maximum size limit: content-length=%d limit=%d", what, resp.ContentLength, downloadLimit)
 		return "", "", err
 	}
 
@@ -577,36 +1479,40 @@ func downloadRuntimeArchive(cfg bootstrapConfig, url string) (archivePath string
 	limitedBody := io.LimitReader(resp.Body, downloadLimit+1)
 	written, copyErr := io.Copy(io.MultiWriter(tmpFile, hasher), limitedBody)
 	if copyErr != nil {
-		err = fmt.Errorf("failed to write ONNX Runtime archive to %q: %w", archivePath, copyErr)
+		if ctxErr := cfg.context().Err(); ctxErr != nil {
+			err = fmt.Errorf("%s download from %q canceled after %d bytes: %w", what, url, written, errors.Join(ctxErr, copyErr))
+			return "", "", err
+		}
+		err = fmt.Errorf("failed to write %s to %q: %w", what, tmpPath, copyErr)
 		return "", "", err
 	}
 	if written > downloadLimit {
-		err = fmt.Errorf("downloaded ONNX Runtime archive exceeds maximum size limit: bytes=%d limit=%d", written, downloadLimit)
+		err = fmt.Errorf("downloaded %s exceeds maximum size limit: bytes=%d limit=%d", what, written, downloadLimit)
 		return "", "", err
 	}
 	if written == 0 {
-		err = fmt.Errorf("downloaded ONNX Runtime archive is empty")
+		err = fmt.Errorf("downloaded %s is empty", what)
 		return "", "", err
 	}
 
 	checksum = hex.EncodeToString(hasher.Sum(nil))
 	success = true
-	return archivePath, checksum, nil
+	return tmpPath, checksum, nil
 }
 
-func extractArchiveFile(archivePath, destinationDir, extension, libraryGlob string) (archiveExtractionReport, error) {
+func extractArchiveFile(archivePath, destinationDir, extension, libraryGlob string, limits extractionLimits) (archiveExtractionReport, error) {
 	switch extension {
 	case "tgz":
-		return extractTGZArchive(archivePath, destinationDir, libraryGlob)
+		return extractTGZArchive(archivePath, destinationDir, libraryGlob, limits)
 	case "zip":
-		return extractZIPArchive(archivePath, destinationDir, libraryGlob)
+		return extractZIPArchive(archivePath, destinationDir, libraryGlob, limits)
 	default:
 		return archiveExtractionReport{}, fmt.Errorf("unsupported archive extension %q", extension)
 	}
 }
 
-func extractTGZArchive(archivePath, destinationDir, libraryGlob string) (archiveExtractionReport, error) {
-	// #nosec G304 -- archivePath is generated internally (downloadRuntimeArchive) and not user-controlled input.
+func extractTGZArchive(archivePath, destinationDir, libraryGlob string, limits extractionLimits) (archiveExtractionReport, error) {
+	// #nosec G304 -- archivePath is a downloaded archive or a path the SafeExtract caller chose to open.
 	archiveFile, err := os.Open(archivePath)
 	if err != nil {
 		return archiveExtractionReport{}, fmt.Errorf("failed to open archive %q: %w", archivePath, err)
@@ -666,7 +1572,7 @@ func extractTGZArchive(archivePath, destinationDir, libraryGlob string) (archive
 				return archiveExtractionReport{}, fmt.Errorf("failed to create extracted file %q: %w", targetPath, err)
 			}
 
-			if err := copyExtractedFile(outFile, tarReader, header.Size, &totalExtracted, targetPath); err != nil {
+			if err := copyExtractedFile(outFile, tarReader, header.Size, &totalExtracted, targetPath, limits); err != nil {
 				_ = outFile.Close()
 				return archiveExtractionReport{}, err
 			}
@@ -690,11 +1596,11 @@ func extractTGZArchive(archivePath, destinationDir, libraryGlob string) (archive
 					}
 				}
 			}
-			log.Printf("WARNING: skipping link archive entry %q (type=%d) during ONNX Runtime bootstrap extraction", header.Name, header.Typeflag)
+			log.Printf("WARNING: skipping link archive entry %q (type=%d) during archive extraction", header.Name, header.Typeflag)
 			continue
 		default:
 			// Skip non-regular archive entries (device files, FIFOs, etc.) for safety.
-			log.Printf("WARNING: skipping unsupported archive entry %q (type=%d) during ONNX Runtime bootstrap extraction", header.Name, header.Typeflag)
+			log.Printf("WARNING: skipping unsupported archive entry %q (type=%d) during archive extraction", header.Name, header.Typeflag)
 			continue
 		}
 	}
@@ -703,10 +1609,12 @@ func extractTGZArchive(archivePath, destinationDir, libraryGlob string) (archive
 		return archiveExtractionReport{}, fmt.Errorf("archive %q did not contain regular files", archivePath)
 	}
 
+	report.regularFiles = regularFiles
+	report.extractedBytes = totalExtracted
 	return report, nil
 }
 
-func extractZIPArchive(archivePath, destinationDir, libraryGlob string) (archiveExtractionReport, error) {
+func extractZIPArchive(archivePath, destinationDir, libraryGlob string, limits extractionLimits) (archiveExtractionReport, error) {
 	reader, err := zip.OpenReader(archivePath)
 	if err != nil {
 		return archiveExtractionReport{}, fmt.Errorf("failed to open ZIP archive %q: %w", archivePath, err)
@@ -745,7 +1653,7 @@ func extractZIPArchive(archivePath, destinationDir, libraryGlob string) (archive
 					}
 				}
 			}
-			log.Printf("WARNING: skipping symlink ZIP entry %q during ONNX Runtime bootstrap extraction", entry.Name)
+			log.Printf("WARNING: skipping symlink ZIP entry %q during archive extraction", entry.Name)
 			continue
 		}
 
@@ -776,7 +1684,7 @@ func extractZIPArchive(archivePath, destinationDir, libraryGlob string) (archive
 		}
 		// #nosec G115 -- upper-bound checked against math.MaxInt64 immediately above.
 		entrySize := int64(entry.UncompressedSize64)
-		if err := copyExtractedFile(outFile, rc, entrySize, &totalExtracted, targetPath); err != nil {
+		if err := copyExtractedFile(outFile, rc, entrySize, &totalExtracted, targetPath, limits); err != nil {
 			_ = outFile.Close()
 			_ = rc.Close()
 			return archiveExtractionReport{}, err
@@ -797,12 +1705,12 @@ func extractZIPArchive(archivePath, destinationDir, libraryGlob string) (archive
 		return archiveExtractionReport{}, fmt.Errorf("archive %q did not contain regular files", archivePath)
 	}
 
+	report.regularFiles = regularFiles
+	report.extractedBytes = totalExtracted
 	return report, nil
 }
 
 func resolveExtractedLibraryPath(installDir string, artifact runtimeArtifact) (string, error) {
-	libDir := filepath.Join(installDir, "lib")
-
 	var invalidCandidates []error
 	trackCandidateError := func(path string, validationErr error) {
 		if validationErr == nil {
@@ -814,28 +1722,31 @@ func resolveExtractedLibraryPath(installDir string, artifact runtimeArtifact) (s
 		invalidCandidates = append(invalidCandidates, fmt.Errorf("%s: %w", path, validationErr))
 	}
 
-	primaryPath := filepath.Join(libDir, artifact.primaryLibrary)
-	if path, err := validateLibraryFile(primaryPath); err == nil {
-		return path, nil
-	} else {
-		trackCandidateError(primaryPath, err)
-	}
-
-	matches, err := filepath.Glob(filepath.Join(libDir, artifact.libraryGlob))
-	if err != nil {
-		return "", fmt.Errorf("failed to resolve ONNX Runtime library path: %w", err)
-	}
-	sort.Strings(matches)
-	for _, match := range matches {
-		path, err := validateLibraryFile(match)
-		if err == nil {
+	for _, dir := range artifact.libraryDirs(installDir) {
+		libDir := filepath.Join(installDir, dir)
+		primaryPath := filepath.Join(libDir, artifact.primaryLibrary)
+		if path, err := validateLibraryFile(primaryPath); err == nil {
 			return path, nil
+		} else {
+			trackCandidateError(primaryPath, err)
+		}
+
+		matches, err := filepath.Glob(filepath.Join(libDir, artifact.libraryGlob))
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve ONNX Runtime library path: %w", err)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			path, err := validateLibraryFile(match)
+			if err == nil {
+				return path, nil
+			}
+			trackCandidateError(match, err)
 		}
-		trackCandidateError(match, err)
 	}
 
 	if len(invalidCandidates) > 0 {
-		return "", fmt.Errorf("found ONNX Runtime shared library candidates in %q but none are valid: %w", libDir, errors.Join(invalidCandidates...))
+		return "", fmt.Errorf("found ONNX Runtime shared library candidates in %q but none are valid: %w", installDir, errors.Join(invalidCandidates...))
 	}
 
 	return "", errSharedLibraryNotFound
@@ -1015,15 +1926,15 @@ func parseBootstrapBoolEnv(name string) (bool, error) {
 	}
 }
 
-func copyExtractedFile(dst io.Writer, src io.Reader, expectedSize int64, totalExtracted *int64, targetPath string) error {
+func copyExtractedFile(dst io.Writer, src io.Reader, expectedSize int64, totalExtracted *int64, targetPath string, limits extractionLimits) error {
 	if expectedSize < 0 {
 		return fmt.Errorf("invalid negative size while extracting %q", targetPath)
 	}
-	if expectedSize > maxExtractedFileBytes {
-		return fmt.Errorf("refusing to extract %q: entry size %d exceeds limit %d", targetPath, expectedSize, maxExtractedFileBytes)
+	if expectedSize > limits.perFile {
+		return fmt.Errorf("refusing to extract %q: entry size %d exceeds limit %d", targetPath, expectedSize, limits.perFile)
 	}
-	if totalExtracted != nil && *totalExtracted+expectedSize > maxExtractedTotalBytes {
-		return fmt.Errorf("refusing to extract %q: total extracted size would exceed limit %d", targetPath, maxExtractedTotalBytes)
+	if totalExtracted != nil && *totalExtracted+expectedSize > limits.total {
+		return fmt.Errorf("refusing to extract %q: total extracted size would exceed limit %d", targetPath, limits.total)
 	}
 
 	limitedSrc := io.LimitReader(src, expectedSize+1)
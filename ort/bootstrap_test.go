@@ -5,15 +5,23 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"sync"
@@ -39,6 +47,7 @@ func TestResolveRuntimeArtifact(t *testing.T) {
 				archiveExtension: "tgz",
 				primaryLibrary:   "libonnxruntime.dylib",
 				libraryGlob:      "libonnxruntime*.dylib",
+				nugetRID:         "osx-arm64",
 			},
 		},
 		{
@@ -50,6 +59,7 @@ func TestResolveRuntimeArtifact(t *testing.T) {
 				archiveExtension: "tgz",
 				primaryLibrary:   "libonnxruntime.dylib",
 				libraryGlob:      "libonnxruntime*.dylib",
+				nugetRID:         "osx-x64",
 			},
 		},
 		{
@@ -61,6 +71,7 @@ func TestResolveRuntimeArtifact(t *testing.T) {
 				archiveExtension: "tgz",
 				primaryLibrary:   "libonnxruntime.so",
 				libraryGlob:      "libonnxruntime.so*",
+				nugetRID:         "linux-x64",
 			},
 		},
 		{
@@ -72,6 +83,7 @@ func TestResolveRuntimeArtifact(t *testing.T) {
 				archiveExtension: "tgz",
 				primaryLibrary:   "libonnxruntime.so",
 				libraryGlob:      "libonnxruntime.so*",
+				nugetRID:         "linux-arm64",
 			},
 		},
 		{
@@ -83,6 +95,7 @@ func TestResolveRuntimeArtifact(t *testing.T) {
 				archiveExtension: "zip",
 				primaryLibrary:   "onnxruntime.dll",
 				libraryGlob:      "onnxruntime*.dll",
+				nugetRID:         "win-x64",
 			},
 		},
 		{
@@ -94,6 +107,7 @@ func TestResolveRuntimeArtifact(t *testing.T) {
 				archiveExtension: "zip",
 				primaryLibrary:   "onnxruntime.dll",
 				libraryGlob:      "onnxruntime*.dll",
+				nugetRID:         "win-arm64",
 			},
 		},
 		{
@@ -117,7 +131,7 @@ func TestResolveRuntimeArtifact(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
-			if got != tc.want {
+			if !reflect.DeepEqual(got, tc.want) {
 				t.Fatalf("unexpected artifact resolution: got %+v, want %+v", got, tc.want)
 			}
 		})
@@ -185,6 +199,352 @@ func TestEnsureOnnxRuntimeSharedLibraryDownloadAndCache(t *testing.T) {
 	}
 }
 
+func TestEnsureOnnxRuntimeSharedLibraryNuGetLayout(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	artifact, err := resolveRuntimeArtifact(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		t.Skipf("unsupported runtime for bootstrap test: %v", err)
+	}
+
+	// NuGet packages have no archive root directory and no lib/.
+	files := map[string]string{"Microsoft.ML.OnnxRuntime.nuspec": "nuspec"}
+	files[fmt.Sprintf("runtimes/%s/native/%s", artifact.nugetRID, artifact.primaryLibrary)] = "fake-onnxruntime-library-bytes"
+	var archiveBytes []byte
+	switch artifact.archiveExtension {
+	case "tgz":
+		archiveBytes = buildTGZArchive(t, files)
+	case "zip":
+		archiveBytes = buildZIPArchive(t, files)
+	default:
+		t.Fatalf("unsupported archive extension in test: %s", artifact.archiveExtension)
+	}
+
+	cacheDir := t.TempDir()
+	version := "1.99.1"
+	server, _ := newArchiveServer(t, artifact, version, archiveBytes)
+
+	path, err := EnsureOnnxRuntimeSharedLibrary(
+		WithBootstrapCacheDir(cacheDir),
+		WithBootstrapVersion(version),
+		withBootstrapBaseURL(server.URL),
+		withBootstrapHTTPClient(server.Client()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected bootstrap error: %v", err)
+	}
+	want := filepath.Join(cacheDir, artifact.archiveName(version), "runtimes", artifact.nugetRID, "native", artifact.primaryLibrary)
+	if path != want {
+		t.Fatalf("unexpected resolved path: got %q, want %q", path, want)
+	}
+}
+
+func TestEnsureOnnxRuntimeSharedLibrarySharedStoreDedupesCacheDirs(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	artifact, err := resolveRuntimeArtifact(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		t.Skipf("unsupported runtime for bootstrap test: %v", err)
+	}
+
+	storeDir := t.TempDir()
+	version := "1.99.1"
+	archiveBytes := buildORTArchive(t, artifact, version, true)
+	server, hits := newArchiveServer(t, artifact, version, archiveBytes)
+
+	var realPaths []string
+	for _, cacheDir := range []string{t.TempDir(), t.TempDir()} {
+		path, err := EnsureOnnxRuntimeSharedLibrary(
+			WithBootstrapCacheDir(cacheDir),
+			WithBootstrapVersion(version),
+			WithBootstrapSharedStore(storeDir),
+			withBootstrapBaseURL(server.URL),
+			withBootstrapHTTPClient(server.Client()),
+		)
+		if err != nil {
+			t.Fatalf("unexpected bootstrap error for cache %q: %v", cacheDir, err)
+		}
+		if !strings.HasPrefix(path, cacheDir) {
+			t.Fatalf("expected resolved path under cache dir %q, got %q", cacheDir, path)
+		}
+		realPath, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			t.Fatalf("failed to resolve %q: %v", path, err)
+		}
+		realPaths = append(realPaths, realPath)
+	}
+
+	if realPaths[0] != realPaths[1] {
+		t.Fatalf("expected cache dirs to share one extracted copy, got %q and %q", realPaths[0], realPaths[1])
+	}
+	realStoreDir, err := filepath.EvalSymlinks(storeDir)
+	if err != nil {
+		t.Fatalf("failed to resolve store dir: %v", err)
+	}
+	if !strings.HasPrefix(realPaths[0], filepath.Join(realStoreDir, "sha256-")) {
+		t.Fatalf("expected library inside the content-addressed store, got %q", realPaths[0])
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected exactly one archive download, got %d", got)
+	}
+}
+
+func TestCopyRuntimeInstall(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "lib"), 0o755); err != nil {
+		t.Fatalf("failed to create source lib dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "lib", "libonnxruntime.so"), []byte("library"), 0o644); err != nil {
+		t.Fatalf("failed to write source library: %v", err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "install")
+	if err := copyRuntimeInstall(src, dst); err != nil {
+		t.Fatalf("copyRuntimeInstall failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dst, "lib", "libonnxruntime.so"))
+	if err != nil {
+		t.Fatalf("copied library missing: %v", err)
+	}
+	if string(data) != "library" {
+		t.Fatalf("unexpected copied library content: %q", data)
+	}
+}
+
+func TestReadSharedStoreIndex(t *testing.T) {
+	dir := t.TempDir()
+	checksum := strings.Repeat("ab", sha256.Size)
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "valid", content: checksum + "\n", want: checksum},
+		{name: "uppercase", content: strings.ToUpper(checksum), want: checksum},
+		{name: "too short", content: "abcd", want: ""},
+		{name: "not hex", content: strings.Repeat("zz", sha256.Size), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-"))
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("failed to write index: %v", err)
+			}
+			if got := readSharedStoreIndex(path); got != tt.want {
+				t.Fatalf("unexpected checksum: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got := readSharedStoreIndex(filepath.Join(dir, "missing")); got != "" {
+		t.Fatalf("expected empty checksum for missing index, got %q", got)
+	}
+}
+
+func TestWithBootstrapSharedStoreRejectsEmpty(t *testing.T) {
+	if err := WithBootstrapSharedStore("  ")(&bootstrapConfig{}); err == nil {
+		t.Fatalf("expected error for empty shared store directory")
+	}
+}
+
+func TestResolveLatestStableVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		want    string
+		wantErr string
+	}{
+		{
+			name: "skips prereleases and drafts",
+			body: `[
+				{"tag_name": "v1.25.0", "prerelease": true},
+				{"tag_name": "v1.24.9", "draft": true},
+				{"tag_name": "v1.23.2"},
+				{"tag_name": "v1.9.0"},
+				{"tag_name": "v1.23.10"}
+			]`,
+			status: http.StatusOK,
+			want:   "1.23.10",
+		},
+		{
+			name:   "ignores non-version tags",
+			body:   `[{"tag_name": "nightly"}, {"tag_name": "v1.22.0-rc1"}, {"tag_name": "1.20.1"}]`,
+			status: http.StatusOK,
+			want:   "1.20.1",
+		},
+		{
+			name:    "no stable release",
+			body:    `[{"tag_name": "v1.25.0", "prerelease": true}]`,
+			status:  http.StatusOK,
+			wantErr: "no stable ONNX Runtime release found",
+		},
+		{
+			name:    "malformed body",
+			body:    `{"message": "oops"}`,
+			status:  http.StatusOK,
+			wantErr: "failed to decode ONNX Runtime releases",
+		},
+		{
+			name:    "http error",
+			body:    `rate limited`,
+			status:  http.StatusForbidden,
+			wantErr: "HTTP 403",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUserAgent string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUserAgent = r.Header.Get("User-Agent")
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			cfg, err := resolveBootstrapConfig(
+				withBootstrapReleasesURL(server.URL+"/releases"),
+				withBootstrapHTTPClient(server.Client()),
+			)
+			if err != nil {
+				t.Fatalf("unexpected config error: %v", err)
+			}
+			got, err := resolveLatestStableVersion(cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("unexpected latest version: got %q, want %q", got, tt.want)
+			}
+			if gotUserAgent != defaultBootstrapUserAgent {
+				t.Fatalf("unexpected User-Agent: got %q, want %q", gotUserAgent, defaultBootstrapUserAgent)
+			}
+		})
+	}
+}
+
+func TestEnsureOnnxRuntimeSharedLibraryLatestStable(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	artifact, err := resolveRuntimeArtifact(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		t.Skipf("unsupported runtime for bootstrap test: %v", err)
+	}
+
+	version := "1.99.3"
+	archiveBytes := buildORTArchive(t, artifact, version, true)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/releases", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"tag_name": "v1.99.4", "prerelease": true}, {"tag_name": "v1.99.3"}, {"tag_name": "v1.99.1"}]`)
+	})
+	mux.HandleFunc("/v"+version+"/"+artifact.archiveFilename(version), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archiveBytes)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	path, err := EnsureOnnxRuntimeSharedLibrary(
+		WithBootstrapCacheDir(cacheDir),
+		WithBootstrapLatestStable(),
+		withBootstrapReleasesURL(server.URL+"/releases"),
+		withBootstrapBaseURL(server.URL),
+		withBootstrapHTTPClient(server.Client()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected bootstrap error: %v", err)
+	}
+	if !strings.Contains(path, artifact.archiveName(version)) {
+		t.Fatalf("expected library from resolved version %s, got %q", version, path)
+	}
+
+	_, err = EnsureOnnxRuntimeSharedLibrary(
+		WithBootstrapCacheDir(cacheDir),
+		WithBootstrapLatestStable(),
+		WithBootstrapDisableDownload(true),
+	)
+	if err == nil || !strings.Contains(err.Error(), "download is disabled") {
+		t.Fatalf("expected disabled download error, got: %v", err)
+	}
+
+	_, err = EnsureOnnxRuntimeSharedLibrary(
+		WithBootstrapCacheDir(cacheDir),
+		WithBootstrapLatestStable(),
+		WithBootstrapExpectedSHA256(strings.Repeat("a", 64)),
+	)
+	if err == nil || !strings.Contains(err.Error(), "cannot be combined with an expected SHA256 checksum") {
+		t.Fatalf("expected latest stable with a checksum to be rejected, got: %v", err)
+	}
+}
+
+func TestEnsureOnnxRuntimeSharedLibraryExtractionLimits(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	artifact, err := resolveRuntimeArtifact(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		t.Skipf("unsupported runtime for bootstrap test: %v", err)
+	}
+
+	version := "1.99.1"
+	archiveBytes := buildORTArchive(t, artifact, version, true)
+	server, _ := newArchiveServer(t, artifact, version, archiveBytes)
+	archiveSize := int64(len(archiveBytes))
+
+	tests := []struct {
+		name     string
+		perFile  int64
+		total    int64
+		download int64
+		wantErr  string
+	}{
+		{name: "per-file limit below entry size", perFile: 1, total: 1 << 20, download: 1 << 20, wantErr: "exceeds limit 1"},
+		{name: "total limit below archive contents", perFile: 1 << 20, total: 1, download: 1 << 20, wantErr: "total extracted size would exceed limit 1"},
+		{name: "download limit below archive size", perFile: 1 << 20, total: 1 << 20, download: archiveSize - 1, wantErr: "exceeds"},
+		{name: "raised limits accept archive", perFile: 8 << 30, total: 16 << 30, download: 8 << 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := EnsureOnnxRuntimeSharedLibrary(
+				WithBootstrapCacheDir(t.TempDir()),
+				WithBootstrapVersion(version),
+				WithBootstrapExtractionLimits(tt.perFile, tt.total, tt.download),
+				withBootstrapBaseURL(server.URL),
+				withBootstrapHTTPClient(server.Client()),
+			)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got path=%q err=%v", tt.wantErr, path, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected bootstrap error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWithBootstrapExtractionLimitsValidation(t *testing.T) {
+	for _, limits := range [][3]int64{{0, 1, 1}, {1, -1, 1}, {1, 1, 0}} {
+		if err := WithBootstrapExtractionLimits(limits[0], limits[1], limits[2])(&bootstrapConfig{}); err == nil {
+			t.Fatalf("expected validation error for limits %v", limits)
+		}
+	}
+
+	cfg := bootstrapConfig{}
+	if err := WithBootstrapExtractionLimits(2, 3, 4)(&cfg); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	if cfg.extraction.perFile != 2 || cfg.extraction.total != 3 || cfg.maxDownloadSize != 4 {
+		t.Fatalf("unexpected limits: extraction=%+v download=%d", cfg.extraction, cfg.maxDownloadSize)
+	}
+}
+
 func TestEnsureOnnxRuntimeSharedLibraryConcurrentLockSingleDownload(t *testing.T) {
 	clearBootstrapEnv(t)
 
@@ -205,45 +565,458 @@ func TestEnsureOnnxRuntimeSharedLibraryConcurrentLockSingleDownload(t *testing.T
 		withBootstrapHTTPClient(server.Client()),
 	}
 
-	const workers = 8
-	var wg sync.WaitGroup
-	errCh := make(chan error, workers)
-	pathCh := make(chan string, workers)
+	const workers = 8
+	var wg sync.WaitGroup
+	errCh := make(chan error, workers)
+	pathCh := make(chan string, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path, err := EnsureOnnxRuntimeSharedLibrary(opts...)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			pathCh <- path
+		}()
+	}
+
+	wg.Wait()
+	close(errCh)
+	close(pathCh)
+
+	for err := range errCh {
+		t.Fatalf("unexpected bootstrap error in concurrent call: %v", err)
+	}
+
+	var expectedPath string
+	for path := range pathCh {
+		if expectedPath == "" {
+			expectedPath = path
+			continue
+		}
+		if path != expectedPath {
+			t.Fatalf("expected same resolved path across workers, got %q and %q", expectedPath, path)
+		}
+	}
+
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected exactly one download under concurrent access, got %d", got)
+	}
+}
+
+func TestEnsureOnnxRuntimeSharedLibraryMirrorFailover(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	artifact, err := resolveRuntimeArtifact(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		t.Skipf("unsupported runtime for bootstrap test: %v", err)
+	}
+
+	unavailableHits := &atomic.Int32{}
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		unavailableHits.Add(1)
+		http.Error(w, "mirror unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(unavailable.Close)
+
+	version := "1.99.6"
+	archiveBytes := buildORTArchive(t, artifact, version, true)
+	server, hits := newArchiveServer(t, artifact, version, archiveBytes)
+
+	path, err := EnsureOnnxRuntimeSharedLibrary(
+		WithBootstrapCacheDir(t.TempDir()),
+		WithBootstrapVersion(version),
+		WithBootstrapMirrors([]string{unavailable.URL, server.URL + "/"}),
+		withBootstrapHTTPClient(server.Client()),
+	)
+	if err != nil {
+		t.Fatalf("expected mirror failover to succeed, got: %v", err)
+	}
+	if _, statErr := os.Stat(path); statErr != nil {
+		t.Fatalf("resolved library path does not exist: %v", statErr)
+	}
+	if got := unavailableHits.Load(); got != 1 {
+		t.Fatalf("expected first mirror to be tried once, got %d", got)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected second mirror to serve one download, got %d", got)
+	}
+}
+
+func TestEnsureOnnxRuntimeSharedLibrarySendsUserAgent(t *testing.T) {
+	artifact, err := resolveRuntimeArtifact(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		t.Skipf("unsupported runtime for bootstrap test: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    []BootstrapOption
+		wantUA  string
+		version string
+	}{
+		{
+			name:    "default",
+			wantUA:  defaultBootstrapUserAgent,
+			version: "1.99.8",
+		},
+		{
+			name:    "configured",
+			opts:    []BootstrapOption{WithBootstrapUserAgent("acme-indexer/2.1 (+https://acme.example.com)")},
+			wantUA:  "acme-indexer/2.1 (+https://acme.example.com)",
+			version: "1.99.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearBootstrapEnv(t)
+
+			archiveBytes := buildORTArchive(t, artifact, tt.version, true)
+			var gotUA atomic.Value
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUA.Store(r.Header.Get("User-Agent"))
+				_, _ = w.Write(archiveBytes)
+			}))
+			t.Cleanup(server.Close)
+
+			opts := append([]BootstrapOption{
+				WithBootstrapCacheDir(t.TempDir()),
+				WithBootstrapVersion(tt.version),
+				withBootstrapBaseURL(server.URL),
+				withBootstrapHTTPClient(server.Client()),
+			}, tt.opts...)
+			if _, err := EnsureOnnxRuntimeSharedLibrary(opts...); err != nil {
+				t.Fatalf("bootstrap failed: %v", err)
+			}
+			if got, _ := gotUA.Load().(string); got != tt.wantUA {
+				t.Fatalf("unexpected User-Agent: got %q, want %q", got, tt.wantUA)
+			}
+		})
+	}
+}
+
+func TestWithBootstrapUserAgentValidation(t *testing.T) {
+	cfg := bootstrapConfig{}
+	if err := WithBootstrapUserAgent("  ")(&cfg); err == nil || !strings.Contains(err.Error(), "cannot be empty") {
+		t.Fatalf("expected empty user agent error, got: %v", err)
+	}
+	if err := WithBootstrapUserAgent("tool\r\nX-Injected: 1")(&cfg); err == nil || !strings.Contains(err.Error(), "control characters") {
+		t.Fatalf("expected control character error, got: %v", err)
+	}
+	if err := WithBootstrapUserAgent(" my-tool/1.0 ")(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.userAgent != "my-tool/1.0" {
+		t.Fatalf("unexpected user agent: got %q, want %q", cfg.userAgent, "my-tool/1.0")
+	}
+}
+
+func TestPruneBootstrapCacheKeepsNewestVersions(t *testing.T) {
+	cacheDir := t.TempDir()
+	for _, name := range []string{
+		"onnxruntime-linux-x64-1.2.3",
+		"onnxruntime-linux-x64-1.9.0",
+		"onnxruntime-linux-x64-1.10.0",
+		"onnxruntime-linux-x64-1.23.1",
+		"onnxruntime-osx-arm64-1.0.0",
+		"unrelated",
+		".locks",
+	} {
+		if err := os.MkdirAll(filepath.Join(cacheDir, name, "lib"), 0o755); err != nil {
+			t.Fatalf("failed to seed %q: %v", name, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(cacheDir, "onnxruntime-123.archive"), []byte("partial"), 0o600); err != nil {
+		t.Fatalf("failed to seed temporary archive: %v", err)
+	}
+
+	if err := PruneBootstrapCache(cacheDir, 2); err != nil {
+		t.Fatalf("PruneBootstrapCache failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatalf("failed to read cache dir: %v", err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	want := []string{
+		".locks",
+		"onnxruntime-123.archive",
+		"onnxruntime-linux-x64-1.10.0",
+		"onnxruntime-linux-x64-1.23.1",
+		"onnxruntime-osx-arm64-1.0.0",
+		"unrelated",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected cache entries after prune:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+func TestPruneBootstrapCacheValidation(t *testing.T) {
+	if err := PruneBootstrapCache(" ", 1); err == nil || !strings.Contains(err.Error(), "cannot be empty") {
+		t.Fatalf("expected empty cache dir error, got: %v", err)
+	}
+	if err := PruneBootstrapCache(t.TempDir(), 0); err == nil || !strings.Contains(err.Error(), "keep must be at least 1") {
+		t.Fatalf("expected keep validation error, got: %v", err)
+	}
+	if err := PruneBootstrapCache(filepath.Join(t.TempDir(), "missing"), 1); err != nil {
+		t.Fatalf("expected missing cache dir to be a no-op, got: %v", err)
+	}
+}
+
+func TestCompareRuntimeVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.23.1", b: "1.23.1", want: 0},
+		{a: "1.10.0", b: "1.9.9", want: 1},
+		{a: "1.2.3", b: "1.20.0", want: -1},
+		{a: "2.0.0", b: "1.99.99", want: 1},
+		{a: "1.23.0", b: "1.23.1", want: -1},
+	}
+	for _, tt := range tests {
+		if got := compareRuntimeVersions(tt.a, tt.b); got != tt.want {
+			t.Fatalf("compareRuntimeVersions(%q, %q): got %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestResolveMuslRuntimeArtifact(t *testing.T) {
+	glibc, err := resolveRuntimeArtifact("linux", "amd64")
+	if err != nil {
+		t.Fatalf("failed to resolve linux artifact: %v", err)
+	}
+
+	_, err = resolveMuslRuntimeArtifact(glibc, "")
+	if err == nil {
+		t.Fatalf("expected musl diagnostic error without a musl archive URL")
+	}
+	for _, want := range []string{"musl libc detected", "require glibc", "ONNXRUNTIME_LIB_PATH", "WithBootstrapMuslURL"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected musl diagnostic to mention %q, got: %v", want, err)
+		}
+	}
+
+	musl, err := resolveMuslRuntimeArtifact(glibc, "https://artifacts.example.com/onnxruntime-musl.tgz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if musl.platform != "linux-x64-musl" {
+		t.Fatalf("unexpected musl platform: got %q, want %q", musl.platform, "linux-x64-musl")
+	}
+	if musl.archiveURL != "https://artifacts.example.com/onnxruntime-musl.tgz" {
+		t.Fatalf("unexpected musl archive URL: %q", musl.archiveURL)
+	}
+}
+
+func TestEnsureOnnxRuntimeSharedLibraryMuslDetected(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("musl detection only applies to linux")
+	}
+	clearBootstrapEnv(t)
+
+	artifact, err := resolveRuntimeArtifact(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		t.Skipf("unsupported runtime for bootstrap test: %v", err)
+	}
+	isMusl := func() bool { return true }
+
+	_, err = EnsureOnnxRuntimeSharedLibrary(
+		WithBootstrapCacheDir(t.TempDir()),
+		WithBootstrapVersion("1.99.10"),
+		withBootstrapMuslDetector(isMusl),
+	)
+	if err == nil || !strings.Contains(err.Error(), "musl libc detected") {
+		t.Fatalf("expected musl diagnostic error, got: %v", err)
+	}
+
+	version := "1.99.11"
+	muslArtifact, err := resolveMuslRuntimeArtifact(artifact, "https://unused.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	archiveBytes := buildORTArchive(t, muslArtifact, version, true)
+	hits := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/custom/onnxruntime-musl.tgz" {
+			http.NotFound(w, r)
+			return
+		}
+		hits.Add(1)
+		_, _ = w.Write(archiveBytes)
+	}))
+	t.Cleanup(server.Close)
+
+	cacheDir := t.TempDir()
+	path, err := EnsureOnnxRuntimeSharedLibrary(
+		WithBootstrapCacheDir(cacheDir),
+		WithBootstrapVersion(version),
+		WithBootstrapMuslURL(server.URL+"/custom/onnxruntime-musl.tgz"),
+		withBootstrapMuslDetector(isMusl),
+		withBootstrapHTTPClient(server.Client()),
+	)
+	if err != nil {
+		t.Fatalf("expected musl archive bootstrap to succeed, got: %v", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected musl archive to be downloaded once, got %d", got)
+	}
+	wantDir := filepath.Join(cacheDir, muslArtifact.archiveName(version))
+	if !strings.HasPrefix(path, wantDir+string(filepath.Separator)) {
+		t.Fatalf("expected musl install under %q, got %q", wantDir, path)
+	}
+}
+
+func TestEnsureOnnxRuntimeSharedLibraryWithBootstrapPlatform(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	// Resolve linux-aarch64 from any host, including a musl amd64 one.
+	artifact, err := resolveRuntimeArtifact("linux", "arm64")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cacheDir := t.TempDir()
+	version := "1.99.12"
+	archiveBytes := buildORTArchive(t, artifact, version, true)
+	server, hits := newArchiveServer(t, artifact, version, archiveBytes)
+
+	path, err := EnsureOnnxRuntimeSharedLibrary(
+		WithBootstrapCacheDir(cacheDir),
+		WithBootstrapVersion(version),
+		WithBootstrapPlatform("linux", "arm64"),
+		withBootstrapMuslDetector(func() bool { return true }),
+		withBootstrapBaseURL(server.URL),
+		withBootstrapHTTPClient(server.Client()),
+	)
+	if err != nil {
+		t.Fatalf("expected cross-platform bootstrap to succeed, got: %v", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected the linux-aarch64 archive to be downloaded once, got %d", got)
+	}
+	wantDir := filepath.Join(cacheDir, "onnxruntime-linux-aarch64-"+version)
+	if !strings.HasPrefix(path, wantDir+string(filepath.Separator)) || filepath.Base(path) != "libonnxruntime.so" {
+		t.Fatalf("expected the linux-aarch64 library under %q, got %q", wantDir, path)
+	}
+}
+
+func TestWithBootstrapPlatformValidation(t *testing.T) {
+	cfg := bootstrapConfig{goos: runtime.GOOS, goarch: runtime.GOARCH}
+	if err := WithBootstrapPlatform(" ", "arm64")(&cfg); err == nil || !strings.Contains(err.Error(), "cannot be empty") {
+		t.Fatalf("expected empty platform error, got: %v", err)
+	}
+	if err := WithBootstrapPlatform("linux", "386")(&cfg); err == nil || !strings.Contains(err.Error(), "unsupported platform") {
+		t.Fatalf("expected unsupported platform error, got: %v", err)
+	}
+	if cfg.goos != runtime.GOOS || cfg.goarch != runtime.GOARCH || cfg.crossPlatform {
+		t.Fatalf("expected rejected platforms to leave the config unchanged, got %+v", cfg)
+	}
+	if err := WithBootstrapPlatform("windows", "arm64")(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.goos != "windows" || cfg.goarch != "arm64" || !cfg.crossPlatform {
+		t.Fatalf("expected the windows/arm64 override, got goos=%q goarch=%q", cfg.goos, cfg.goarch)
+	}
+}
+
+func TestInitializeEnvironmentWithBootstrapRejectsForeignPlatform(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	goarch := "arm64"
+	if runtime.GOARCH == "arm64" {
+		goarch = "amd64"
+	}
+	err := InitializeEnvironmentWithBootstrap(
+		WithBootstrapCacheDir(t.TempDir()),
+		WithBootstrapPlatform("linux", goarch),
+		WithBootstrapDisableDownload(true),
+	)
+	if err == nil || !strings.Contains(err.Error(), "use EnsureOnnxRuntimeSharedLibrary to prefetch") {
+		t.Fatalf("expected foreign platform error, got: %v", err)
+	}
+}
+
+func TestWithBootstrapMuslURLValidation(t *testing.T) {
+	cfg := bootstrapConfig{}
+	if err := WithBootstrapMuslURL(" ")(&cfg); err == nil || !strings.Contains(err.Error(), "cannot be empty") {
+		t.Fatalf("expected empty musl URL error, got: %v", err)
+	}
+	if err := WithBootstrapMuslURL("http://artifacts.example.com/ort.tgz")(&cfg); err == nil || !strings.Contains(err.Error(), "must use https") {
+		t.Fatalf("expected https requirement error, got: %v", err)
+	}
+	if err := WithBootstrapMuslURL("https://artifacts.example.com/ort.tgz")(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestEnsureOnnxRuntimeSharedLibraryAllMirrorsFail(t *testing.T) {
+	clearBootstrapEnv(t)
 
-	for i := 0; i < workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			path, err := EnsureOnnxRuntimeSharedLibrary(opts...)
-			if err != nil {
-				errCh <- err
-				return
-			}
-			pathCh <- path
-		}()
+	if _, err := resolveRuntimeArtifact(runtime.GOOS, runtime.GOARCH); err != nil {
+		t.Skipf("unsupported runtime for bootstrap test: %v", err)
 	}
 
-	wg.Wait()
-	close(errCh)
-	close(pathCh)
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "mirror unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(unavailable.Close)
+	missing := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(missing.Close)
 
-	for err := range errCh {
-		t.Fatalf("unexpected bootstrap error in concurrent call: %v", err)
+	_, err := EnsureOnnxRuntimeSharedLibrary(
+		WithBootstrapCacheDir(t.TempDir()),
+		WithBootstrapVersion("1.99.7"),
+		WithBootstrapMirrors([]string{unavailable.URL, missing.URL}),
+		withBootstrapHTTPClient(unavailable.Client()),
+	)
+	if err == nil {
+		t.Fatalf("expected error when all mirrors fail")
+	}
+	if !strings.Contains(err.Error(), "all 2 ONNX Runtime download mirrors failed") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(err.Error(), "HTTP 503") || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Fatalf("expected each mirror failure in error, got: %v", err)
 	}
+}
 
-	var expectedPath string
-	for path := range pathCh {
-		if expectedPath == "" {
-			expectedPath = path
-			continue
-		}
-		if path != expectedPath {
-			t.Fatalf("expected same resolved path across workers, got %q and %q", expectedPath, path)
-		}
+func TestWithBootstrapMirrorsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		mirrors []string
+		wantErr string
+	}{
+		{name: "reject empty list", mirrors: nil, wantErr: "mirror list cannot be empty"},
+		{name: "reject empty entry", mirrors: []string{"https://example.com", " "}, wantErr: "mirror at index 1 cannot be empty"},
+		{name: "reject non-loopback http", mirrors: []string{"http://example.com"}, wantErr: "invalid bootstrap mirror at index 0"},
+		{name: "accept https and loopback http", mirrors: []string{"https://example.com/", "http://127.0.0.1:8080"}},
 	}
 
-	if got := hits.Load(); got != 1 {
-		t.Fatalf("expected exactly one download under concurrent access, got %d", got)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg bootstrapConfig
+			err := WithBootstrapMirrors(tc.mirrors)(&cfg)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			if len(cfg.mirrors) != len(tc.mirrors) || cfg.mirrors[0] != "https://example.com" {
+				t.Fatalf("unexpected normalized mirrors: %v", cfg.mirrors)
+			}
+		})
 	}
 }
 
@@ -291,6 +1064,83 @@ func TestEnsureOnnxRuntimeSharedLibraryDisableDownload(t *testing.T) {
 	}
 }
 
+func TestVerifyCachedRuntimeAndSkipReextract(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	cfg, err := resolveBootstrapConfig()
+	if err != nil {
+		t.Fatalf("failed to resolve bootstrap config: %v", err)
+	}
+	artifact, err := cfg.runtimeArtifact()
+	if err != nil {
+		t.Skipf("unsupported runtime for bootstrap test: %v", err)
+	}
+
+	cacheDir := t.TempDir()
+	version := "1.99.6"
+	libDir := filepath.Join(cacheDir, artifact.archiveName(version), "lib")
+	if err := os.MkdirAll(libDir, 0o755); err != nil {
+		t.Fatalf("failed to create install dir: %v", err)
+	}
+	libPath := filepath.Join(libDir, artifact.primaryLibrary)
+	if err := os.WriteFile(libPath, []byte("dummy"), 0o644); err != nil {
+		t.Fatalf("failed to write test library: %v", err)
+	}
+	want, _ := filepath.Abs(libPath)
+
+	resolved, err := VerifyCachedRuntime(cacheDir, version)
+	if err != nil {
+		t.Fatalf("VerifyCachedRuntime failed: %v", err)
+	}
+	if resolved != want {
+		t.Fatalf("unexpected verified path: got %q, want %q", resolved, want)
+	}
+	if _, err := VerifyCachedRuntime(cacheDir, "1.99.7"); err == nil || !strings.Contains(err.Error(), "ONNX Runtime 1.99.7 is not cached") {
+		t.Fatalf("expected missing version error, got: %v", err)
+	}
+	if _, err := VerifyCachedRuntime(" ", version); err == nil || !strings.Contains(err.Error(), "cache directory cannot be empty") {
+		t.Fatalf("expected empty cache dir error, got: %v", err)
+	}
+
+	server, hits := newArchiveServer(t, artifact, version, buildORTArchive(t, artifact, version, true))
+	opts := []BootstrapOption{
+		WithBootstrapCacheDir(cacheDir),
+		WithBootstrapVersion(version),
+		WithBootstrapSkipReextractIfPresent(true),
+		withBootstrapBaseURL(server.URL),
+		withBootstrapHTTPClient(server.Client()),
+	}
+	resolved, err = EnsureOnnxRuntimeSharedLibrary(opts...)
+	if err != nil {
+		t.Fatalf("unexpected bootstrap error: %v", err)
+	}
+	if resolved != want {
+		t.Fatalf("unexpected resolved path: got %q, want %q", resolved, want)
+	}
+
+	// An install without a library is reported, not repaired, when re-extraction is skipped.
+	if err := os.Remove(libPath); err != nil {
+		t.Fatalf("failed to remove test library: %v", err)
+	}
+	if _, err := EnsureOnnxRuntimeSharedLibrary(opts...); err == nil || !strings.Contains(err.Error(), "re-extraction is skipped") {
+		t.Fatalf("expected skipped re-extraction error, got: %v", err)
+	}
+	if got := hits.Load(); got != 0 {
+		t.Fatalf("expected no network access, got %d archive downloads", got)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, ".locks")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the cache to be left untouched, got lock dir stat error: %v", err)
+	}
+
+	// Without the option the incomplete install is re-extracted as before.
+	if _, err := EnsureOnnxRuntimeSharedLibrary(append(opts[:2:2], opts[3:]...)...); err != nil {
+		t.Fatalf("expected incomplete install to be re-extracted without the option: %v", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected one archive download after re-extraction, got %d", got)
+	}
+}
+
 func TestEnsureOnnxRuntimeSharedLibraryInvalidArchive(t *testing.T) {
 	clearBootstrapEnv(t)
 
@@ -547,6 +1397,77 @@ func TestResolveExtractedLibraryPathReturnsNotFoundWhenMissing(t *testing.T) {
 	}
 }
 
+func TestWithBootstrapLibrarySearchPathsValidation(t *testing.T) {
+	for _, paths := range [][]string{nil, {" "}, {"/opt/onnxruntime/lib"}, {"../lib"}, {"bin", "runtimes/../../lib"}} {
+		var cfg bootstrapConfig
+		if err := WithBootstrapLibrarySearchPaths(paths)(&cfg); err == nil {
+			t.Fatalf("expected search paths %q to be rejected", paths)
+		}
+	}
+
+	var cfg bootstrapConfig
+	if err := WithBootstrapLibrarySearchPaths([]string{" bin/ ", "runtimes/linux-x64/native"})(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"bin", filepath.Join("runtimes", "linux-x64", "native")}
+	if !reflect.DeepEqual(cfg.librarySearch, want) {
+		t.Fatalf("unexpected search paths: got %q, want %q", cfg.librarySearch, want)
+	}
+}
+
+func TestResolveExtractedLibraryPathWithSearchPaths(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	cfg, err := resolveBootstrapConfig(
+		WithBootstrapPlatform("linux", "amd64"),
+		WithBootstrapLibrarySearchPaths([]string{"bin", "runtimes/linux-x64/native"}),
+	)
+	if err != nil {
+		t.Fatalf("failed to resolve bootstrap config: %v", err)
+	}
+	artifact, err := cfg.runtimeArtifact()
+	if err != nil {
+		t.Fatalf("failed to resolve runtime artifact: %v", err)
+	}
+
+	installDir := t.TempDir()
+	nativeDir := filepath.Join(installDir, "runtimes", "linux-x64", "native")
+	if err := os.MkdirAll(nativeDir, 0o755); err != nil {
+		t.Fatalf("failed to create native directory: %v", err)
+	}
+	libPath := filepath.Join(nativeDir, artifact.primaryLibrary)
+	if err := os.WriteFile(libPath, []byte("dummy"), 0o644); err != nil {
+		t.Fatalf("failed to write test library: %v", err)
+	}
+
+	if _, err := resolveExtractedLibraryPath(installDir, runtimeArtifact{
+		primaryLibrary: artifact.primaryLibrary,
+		libraryGlob:    artifact.libraryGlob,
+	}); !errors.Is(err, errSharedLibraryNotFound) {
+		t.Fatalf("expected not-found error without search paths, got: %v", err)
+	}
+
+	got, err := resolveExtractedLibraryPath(installDir, artifact)
+	if err != nil {
+		t.Fatalf("expected library in search path to resolve, got: %v", err)
+	}
+	if got != libPath {
+		t.Fatalf("unexpected library path: got %q, want %q", got, libPath)
+	}
+
+	libDir := filepath.Join(installDir, "lib")
+	if err := os.MkdirAll(libDir, 0o755); err != nil {
+		t.Fatalf("failed to create lib directory: %v", err)
+	}
+	preferred := filepath.Join(libDir, artifact.primaryLibrary)
+	if err := os.WriteFile(preferred, []byte("dummy"), 0o644); err != nil {
+		t.Fatalf("failed to write test library: %v", err)
+	}
+	if got, err := resolveExtractedLibraryPath(installDir, artifact); err != nil || got != preferred {
+		t.Fatalf("expected lib/ to take precedence, got %q, %v", got, err)
+	}
+}
+
 func TestWithBootstrapVersionRejectsEmpty(t *testing.T) {
 	var cfg bootstrapConfig
 	if err := WithBootstrapVersion("   ")(&cfg); err == nil {
@@ -600,6 +1521,200 @@ func TestWithBootstrapExpectedSHA256Validation(t *testing.T) {
 	}
 }
 
+func TestEnsureOnnxRuntimeSharedLibraryCancelsStreamingDownload(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	if _, err := resolveRuntimeArtifact(runtime.GOOS, runtime.GOARCH); err != nil {
+		t.Skipf("unsupported runtime for bootstrap test: %v", err)
+	}
+
+	streaming := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1048576")
+		_, _ = w.Write(bytes.Repeat([]byte{0x1f}, 4096))
+		w.(http.Flusher).Flush()
+		close(streaming)
+		// Stall mid-transfer until the client gives up.
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	cacheDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-streaming
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := EnsureOnnxRuntimeSharedLibrary(
+		WithBootstrapCacheDir(cacheDir),
+		WithBootstrapVersion("1.99.6"),
+		WithBootstrapContext(ctx),
+		withBootstrapBaseURL(server.URL),
+		withBootstrapHTTPClient(server.Client()),
+	)
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "canceled after 4096 bytes") {
+		t.Fatalf("expected a mid-stream cancellation error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("expected the download to abort promptly, took %s", elapsed)
+	}
+
+	var leftovers []string
+	walkErr := filepath.WalkDir(cacheDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, ".archive") {
+			leftovers = append(leftovers, path)
+		}
+		return nil
+	})
+	if walkErr != nil {
+		t.Fatalf("failed to walk cache directory: %v", walkErr)
+	}
+	if len(leftovers) != 0 {
+		t.Fatalf("expected the partial archive to be removed, found %v", leftovers)
+	}
+
+	if err := WithBootstrapContext(nil)(&bootstrapConfig{}); err == nil {
+		t.Fatalf("expected a nil context to be rejected")
+	}
+}
+
+// newSelfSignedCertificate returns the DER encoding of a fresh self-signed certificate.
+func newSelfSignedCertificate(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "unrelated.example"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return der
+}
+
+func TestBuildPinnedClientOncePerConfig(t *testing.T) {
+	clearBootstrapEnv(t)
+	pin := strings.Repeat("ab", 32)
+	cfg, err := resolveBootstrapConfig(WithBootstrapCertPin(pin), WithBootstrapCacheDir(t.TempDir()))
+	if err != nil {
+		t.Fatalf("resolveBootstrapConfig failed: %v", err)
+	}
+	if cfg.pinnedClient == nil || cfg.pinnedClient == cfg.httpClient {
+		t.Fatalf("expected a dedicated pinned client to be built with the configuration")
+	}
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/archive", nil)
+	first, err := archiveHTTPClient(cfg, req)
+	if err != nil {
+		t.Fatalf("archiveHTTPClient failed: %v", err)
+	}
+	second, err := archiveHTTPClient(cfg, req)
+	if err != nil {
+		t.Fatalf("archiveHTTPClient failed: %v", err)
+	}
+	if first != cfg.pinnedClient || second != first {
+		t.Fatalf("expected every download to reuse the pinned client")
+	}
+
+	unpinned, err := resolveBootstrapConfig(WithBootstrapCacheDir(t.TempDir()))
+	if err != nil {
+		t.Fatalf("resolveBootstrapConfig failed: %v", err)
+	}
+	if unpinned.pinnedClient != nil {
+		t.Fatalf("expected no pinned client without certificate pins")
+	}
+}
+
+func TestDownloadRuntimeArchiveCertPin(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	payload := []byte("pinned archive")
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(payload)
+	}))
+	t.Cleanup(server.Close)
+
+	certDigest := sha256.Sum256(server.Certificate().Raw)
+
+	// A server that also sends an unrelated certificate: it appears in PeerCertificates
+	// but not in the verified chain, so pinning it must not be accepted.
+	unrelatedDER := newSelfSignedCertificate(t)
+	unrelatedDigest := sha256.Sum256(unrelatedDER)
+	padded := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(payload)
+	}))
+	padded.StartTLS()
+	t.Cleanup(padded.Close)
+	padded.TLS.Certificates[0].Certificate = append(padded.TLS.Certificates[0].Certificate, unrelatedDER)
+
+	tests := []struct {
+		name    string
+		pins    []string
+		url     string
+		wantErr string
+	}{
+		{name: "correct pin", pins: []string{hex.EncodeToString(certDigest[:])}, url: server.URL + "/archive"},
+		{name: "one of several pins", pins: []string{strings.Repeat("0", 64), strings.ToUpper(hex.EncodeToString(certDigest[:]))}, url: server.URL + "/archive"},
+		{name: "incorrect pin", pins: []string{strings.Repeat("0", 64)}, url: server.URL + "/archive", wantErr: "matches the configured certificate pins"},
+		{name: "plain http", pins: []string{hex.EncodeToString(certDigest[:])}, url: "http://127.0.0.1/archive", wantErr: "requires an https download URL"},
+		{name: "pin sent but not in verified chain", pins: []string{hex.EncodeToString(unrelatedDigest[:])}, url: padded.URL + "/archive", wantErr: "matches the configured certificate pins"},
+		{name: "extra certificate with verified pin", pins: []string{hex.EncodeToString(certDigest[:])}, url: padded.URL + "/archive"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := bootstrapConfig{
+				cacheDir:        t.TempDir(),
+				httpClient:      server.Client(),
+				maxDownloadSize: maxDownloadBytes,
+			}
+			for _, pin := range tc.pins {
+				if err := WithBootstrapCertPin(pin)(&cfg); err != nil {
+					t.Fatalf("WithBootstrapCertPin failed: %v", err)
+				}
+			}
+			if err := cfg.buildPinnedClient(); err != nil {
+				t.Fatalf("buildPinnedClient failed: %v", err)
+			}
+			defer cfg.closeIdleConnections()
+
+			archivePath, checksum, err := downloadRuntimeArchive(cfg, tc.url)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("pinned download failed: %v", err)
+			}
+			payloadDigest := sha256.Sum256(payload)
+			if checksum != hex.EncodeToString(payloadDigest[:]) {
+				t.Fatalf("unexpected archive checksum %q", checksum)
+			}
+			if err := os.Remove(archivePath); err != nil {
+				t.Fatalf("failed to remove downloaded archive: %v", err)
+			}
+		})
+	}
+
+	var cfg bootstrapConfig
+	if err := WithBootstrapCertPin("not-a-digest")(&cfg); err == nil || !strings.Contains(err.Error(), "certificate pin must be 64 hex characters") {
+		t.Fatalf("expected pin validation error, got: %v", err)
+	}
+}
+
 func TestWithBootstrapBaseURLValidation(t *testing.T) {
 	var cfg bootstrapConfig
 
@@ -742,22 +1857,22 @@ func TestValidateLibraryFile(t *testing.T) {
 }
 
 func TestCopyExtractedFileLimits(t *testing.T) {
-	if err := copyExtractedFile(io.Discard, strings.NewReader(""), maxExtractedFileBytes+1, nil, "big.bin"); err == nil {
+	if err := copyExtractedFile(io.Discard, strings.NewReader(""), maxExtractedFileBytes+1, nil, "big.bin", defaultExtractionLimits); err == nil {
 		t.Fatalf("expected per-file extraction limit error")
 	}
 
 	total := maxExtractedTotalBytes - 2
-	if err := copyExtractedFile(io.Discard, strings.NewReader("1234"), 4, &total, "cumulative.bin"); err == nil {
+	if err := copyExtractedFile(io.Discard, strings.NewReader("1234"), 4, &total, "cumulative.bin", defaultExtractionLimits); err == nil {
 		t.Fatalf("expected cumulative extraction limit error")
 	}
 
 	var totalWritten int64
-	if err := copyExtractedFile(io.Discard, strings.NewReader("abc"), 5, &totalWritten, "short.bin"); err == nil {
+	if err := copyExtractedFile(io.Discard, strings.NewReader("abc"), 5, &totalWritten, "short.bin", defaultExtractionLimits); err == nil {
 		t.Fatalf("expected size mismatch extraction error")
 	}
 
 	var okTotal int64
-	if err := copyExtractedFile(io.Discard, strings.NewReader("hello"), 5, &okTotal, "ok.bin"); err != nil {
+	if err := copyExtractedFile(io.Discard, strings.NewReader("hello"), 5, &okTotal, "ok.bin", defaultExtractionLimits); err != nil {
 		t.Fatalf("unexpected extraction error for valid sizes: %v", err)
 	}
 	if okTotal != 5 {
@@ -908,7 +2023,7 @@ func TestExtractArchiveFileCrossFormat(t *testing.T) {
 			}
 
 			destDir := t.TempDir()
-			if _, err := extractArchiveFile(archivePath, destDir, tc.extension, ""); err != nil {
+			if _, err := extractArchiveFile(archivePath, destDir, tc.extension, "", defaultExtractionLimits); err != nil {
 				t.Fatalf("unexpected extraction error: %v", err)
 			}
 
@@ -961,7 +2076,7 @@ func TestExtractTGZArchiveSkipsSymlinkEntries(t *testing.T) {
 	}
 
 	destDir := t.TempDir()
-	report, err := extractArchiveFile(archivePath, destDir, "tgz", "libonnxruntime*.so")
+	report, err := extractArchiveFile(archivePath, destDir, "tgz", "libonnxruntime*.so", defaultExtractionLimits)
 	if err != nil {
 		t.Fatalf("unexpected extraction error: %v", err)
 	}
@@ -1019,7 +2134,7 @@ func TestExtractZIPArchiveSkipsSymlinkEntries(t *testing.T) {
 	}
 
 	destDir := t.TempDir()
-	report, err := extractArchiveFile(archivePath, destDir, "zip", "onnxruntime*.dll")
+	report, err := extractArchiveFile(archivePath, destDir, "zip", "onnxruntime*.dll", defaultExtractionLimits)
 	if err != nil {
 		t.Fatalf("unexpected extraction error: %v", err)
 	}
@@ -1072,6 +2187,52 @@ func TestInitializeEnvironmentWithBootstrapInitializedDifferentPath(t *testing.T
 	}
 }
 
+func TestInitializeEnvironmentWithBootstrapRejectsConflictingVersion(t *testing.T) {
+	clearBootstrapEnv(t)
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	// Simulate an environment a first module bootstrapped with ONNX Runtime 1.22.0.
+	mu.Lock()
+	refCount = 1
+	mu.Unlock()
+	originalLoadedRuntimeVersion := loadedRuntimeVersion
+	loadedRuntimeVersion = func() string { return "1.22.0" }
+	defer func() { loadedRuntimeVersion = originalLoadedRuntimeVersion }()
+
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	err := InitializeEnvironmentWithBootstrap(
+		WithBootstrapVersion("1.23.0"),
+		WithBootstrapCacheDir(t.TempDir()),
+		withBootstrapBaseURL(server.URL),
+	)
+	if err == nil || !strings.Contains(err.Error(), "ONNX Runtime 1.22.0 is already initialized in this process, but bootstrap requested version 1.23.0") {
+		t.Fatalf("expected version conflict error, got: %v", err)
+	}
+	if got := hits.Load(); got != 0 {
+		t.Fatalf("expected the conflict to be detected before downloading, got %d requests", got)
+	}
+
+	cfg, err := resolveBootstrapConfig(WithBootstrapVersion("v1.22.0"))
+	if err != nil {
+		t.Fatalf("resolveBootstrapConfig failed: %v", err)
+	}
+	if err := checkInitializedRuntimeVersion(cfg); err != nil {
+		t.Fatalf("expected the loaded version to be accepted, got: %v", err)
+	}
+	cfg.version = "1.23.0"
+	cfg.libraryPath = "/opt/onnxruntime/libonnxruntime.so"
+	if err := checkInitializedRuntimeVersion(cfg); err != nil {
+		t.Fatalf("expected explicit library paths to skip the version check, got: %v", err)
+	}
+}
+
 func clearBootstrapEnv(t *testing.T) {
 	t.Helper()
 	t.Setenv("ONNXRUNTIME_LIB_PATH", "")
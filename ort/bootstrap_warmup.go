@@ -0,0 +1,57 @@
+package ort
+
+import (
+	"context"
+)
+
+// BootstrapWarmup is a handle to a background EnsureOnnxRuntimeSharedLibrary call
+// started by StartBootstrapWarmup.
+type BootstrapWarmup struct {
+	done chan struct{}
+	path string
+	err  error
+}
+
+// StartBootstrapWarmup resolves (downloading and extracting if needed) the ONNX Runtime
+// shared library in a background goroutine, so the download overlaps other startup work.
+// opts are the same options accepted by EnsureOnnxRuntimeSharedLibrary.
+//
+// Canceling ctx aborts in-flight downloads; the goroutine then exits with ctx's error.
+// Waiting on the bootstrap file lock held by another process is bounded by the lock
+// timeout rather than ctx. A nil ctx is treated as context.Background().
+func StartBootstrapWarmup(ctx context.Context, opts ...BootstrapOption) *BootstrapWarmup {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	warmup := &BootstrapWarmup{done: make(chan struct{})}
+	warmupOpts := make([]BootstrapOption, 0, len(opts)+1)
+	warmupOpts = append(warmupOpts, opts...)
+	warmupOpts = append(warmupOpts, WithBootstrapContext(ctx))
+
+	go func() {
+		defer close(warmup.done)
+		warmup.path, warmup.err = EnsureOnnxRuntimeSharedLibrary(warmupOpts...)
+	}()
+	return warmup
+}
+
+// Done returns a channel that is closed once the warmup has finished.
+func (w *BootstrapWarmup) Done() <-chan struct{} {
+	return w.done
+}
+
+// Wait blocks until the warmup finishes or ctx is canceled, and returns the resolved
+// shared library path. Pass the path to SetSharedLibraryPath, or call
+// InitializeEnvironmentWithBootstrap with the same options to reuse the warmed cache.
+// Canceling ctx stops waiting but does not cancel the warmup itself.
+func (w *BootstrapWarmup) Wait(ctx context.Context) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case <-w.done:
+		return w.path, w.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
@@ -0,0 +1,128 @@
+package ort
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestStartBootstrapWarmupResolvesPath(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	artifact, err := resolveRuntimeArtifact(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		t.Skipf("unsupported runtime for bootstrap test: %v", err)
+	}
+
+	version := "1.99.4"
+	server, hits := newArchiveServer(t, artifact, version, buildORTArchive(t, artifact, version, true))
+	opts := []BootstrapOption{
+		WithBootstrapCacheDir(t.TempDir()),
+		WithBootstrapVersion(version),
+		withBootstrapBaseURL(server.URL),
+		withBootstrapHTTPClient(server.Client()),
+	}
+
+	warmup := StartBootstrapWarmup(context.Background(), opts...)
+	waitCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	path, err := warmup.Wait(waitCtx)
+	if err != nil {
+		t.Fatalf("warmup failed: %v", err)
+	}
+	if _, statErr := os.Stat(path); statErr != nil {
+		t.Fatalf("warmed library path does not exist: %v", statErr)
+	}
+	select {
+	case <-warmup.Done():
+	default:
+		t.Fatalf("expected Done to be closed after Wait returned")
+	}
+
+	// A later bootstrap with the same options reuses the warmed cache.
+	again, err := EnsureOnnxRuntimeSharedLibrary(opts...)
+	if err != nil {
+		t.Fatalf("unexpected bootstrap error after warmup: %v", err)
+	}
+	if again != path {
+		t.Fatalf("expected warmed path %q, got %q", path, again)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected exactly one archive download, got %d", got)
+	}
+}
+
+func TestStartBootstrapWarmupCancellation(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	if _, err := resolveRuntimeArtifact(runtime.GOOS, runtime.GOARCH); err != nil {
+		t.Skipf("unsupported runtime for bootstrap test: %v", err)
+	}
+
+	requested := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested <- struct{}{}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	warmup := StartBootstrapWarmup(ctx,
+		WithBootstrapCacheDir(t.TempDir()),
+		WithBootstrapVersion("1.99.5"),
+		withBootstrapBaseURL(server.URL),
+		withBootstrapHTTPClient(server.Client()),
+	)
+
+	select {
+	case <-requested:
+	case <-time.After(10 * time.Second):
+		t.Fatal("warmup never requested the archive")
+	}
+
+	// A canceled Wait does not cancel the warmup itself.
+	expiredCtx, expire := context.WithCancel(context.Background())
+	expire()
+	if _, err := warmup.Wait(expiredCtx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected Wait to report its own canceled context, got: %v", err)
+	}
+	select {
+	case <-warmup.Done():
+		t.Fatal("warmup finished before its context was canceled")
+	default:
+	}
+
+	cancel()
+	select {
+	case <-warmup.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("warmup goroutine did not exit after cancellation")
+	}
+	if _, err := warmup.Wait(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled warmup error, got: %v", err)
+	}
+}
+
+func TestStartBootstrapWarmupCanceledBeforeStart(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	if _, err := resolveRuntimeArtifact(runtime.GOOS, runtime.GOARCH); err != nil {
+		t.Skipf("unsupported runtime for bootstrap test: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	warmup := StartBootstrapWarmup(ctx,
+		WithBootstrapCacheDir(t.TempDir()),
+		WithBootstrapVersion("1.99.6"),
+		withBootstrapBaseURL("http://127.0.0.1:1"),
+	)
+	if _, err := warmup.Wait(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled warmup error, got: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package ort
+
+import (
+	"fmt"
+	"math"
+)
+
+// ApproxEqual compares two float32 slices element-wise with an absolute tolerance.
+// It returns nil when both slices have the same length and every pair of elements
+// differs by at most tol, otherwise an error describing the first mismatch.
+// NaN values never compare equal.
+func ApproxEqual(a, b []float32, tol float64) error {
+	if tol < 0 || math.IsNaN(tol) {
+		return fmt.Errorf("tolerance must be >= 0, got %v", tol)
+	}
+	if len(a) != len(b) {
+		return fmt.Errorf("length mismatch: got %d and %d elements", len(a), len(b))
+	}
+	for i := range a {
+		diff := math.Abs(float64(a[i]) - float64(b[i]))
+		if math.IsNaN(diff) || diff > tol {
+			return fmt.Errorf("mismatch at index %d: got %.8f and %.8f (|diff|=%.3g, tolerance %.3g)", i, a[i], b[i], diff, tol)
+		}
+	}
+	return nil
+}
+
+// TensorsApproxEqual compares the shapes and data of two float32 tensors.
+// Shapes must match exactly; data is compared with ApproxEqual.
+func TensorsApproxEqual(a, b *Tensor[float32], tol float64) error {
+	if a == nil || b == nil {
+		return fmt.Errorf("cannot compare nil tensors")
+	}
+	shapeA, shapeB := a.Shape(), b.Shape()
+	if len(shapeA) != len(shapeB) {
+		return fmt.Errorf("shape mismatch: %v vs %v", shapeA, shapeB)
+	}
+	for i := range shapeA {
+		if shapeA[i] != shapeB[i] {
+			return fmt.Errorf("shape mismatch: %v vs %v", shapeA, shapeB)
+		}
+	}
+	if err := ApproxEqual(a.GetData(), b.GetData(), tol); err != nil {
+		return fmt.Errorf("tensor data mismatch: %w", err)
+	}
+	return nil
+}
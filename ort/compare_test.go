@@ -0,0 +1,64 @@
+package ort
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestApproxEqual(t *testing.T) {
+	nan := float32(math.NaN())
+
+	tests := []struct {
+		name    string
+		a       []float32
+		b       []float32
+		tol     float64
+		wantErr string
+	}{
+		{name: "equal", a: []float32{1, 2, 3}, b: []float32{1, 2, 3}, tol: 0},
+		{name: "within tolerance", a: []float32{1, 2}, b: []float32{1.0000005, 1.9999995}, tol: 1e-6},
+		{name: "both empty", a: nil, b: []float32{}, tol: 1e-6},
+		{name: "outside tolerance", a: []float32{1, 2}, b: []float32{1, 2.1}, tol: 1e-3, wantErr: "mismatch at index 1"},
+		{name: "length mismatch", a: []float32{1}, b: []float32{1, 2}, tol: 1e-6, wantErr: "length mismatch: got 1 and 2 elements"},
+		{name: "nan never equal", a: []float32{nan}, b: []float32{nan}, tol: 1, wantErr: "mismatch at index 0"},
+		{name: "negative tolerance", a: []float32{1}, b: []float32{1}, tol: -1, wantErr: "tolerance must be >= 0"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ApproxEqual(tc.a, tc.b, tc.tol)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTensorsApproxEqualWithoutORT(t *testing.T) {
+	a := &Tensor[float32]{shape: Shape{2, 2}, data: []float32{1, 2, 3, 4}}
+	b := &Tensor[float32]{shape: Shape{2, 2}, data: []float32{1, 2, 3, 4.0000001}}
+	if err := TensorsApproxEqual(a, b, 1e-6); err != nil {
+		t.Fatalf("expected tensors to be approximately equal, got: %v", err)
+	}
+
+	reshaped := &Tensor[float32]{shape: Shape{4}, data: []float32{1, 2, 3, 4}}
+	if err := TensorsApproxEqual(a, reshaped, 1e-6); err == nil || !strings.Contains(err.Error(), "shape mismatch") {
+		t.Fatalf("expected shape mismatch error, got: %v", err)
+	}
+
+	different := &Tensor[float32]{shape: Shape{2, 2}, data: []float32{1, 2, 3, 5}}
+	if err := TensorsApproxEqual(a, different, 1e-6); err == nil || !strings.Contains(err.Error(), "tensor data mismatch: mismatch at index 3") {
+		t.Fatalf("expected data mismatch error, got: %v", err)
+	}
+
+	if err := TensorsApproxEqual(nil, a, 1e-6); err == nil {
+		t.Fatalf("expected nil tensor error")
+	}
+}
@@ -0,0 +1,54 @@
+package ort
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DownloadVerifiedFile downloads url to destPath with the same hardening bootstrap uses
+// for runtime archives. The response streams into a temporary file next to destPath,
+// is checked against expectedSHA256 when it is non-empty, and is then renamed into
+// place, so destPath never holds a partial or unverified file. Of the bootstrap
+// options, WithBootstrapContext, WithBootstrapUserAgent, WithBootstrapCertPin, and the
+// download limit of WithBootstrapExtractionLimits apply. Downloads disabled with
+// WithBootstrapDisableDownload (or ONNXRUNTIME_DISABLE_DOWNLOAD) fail.
+func DownloadVerifiedFile(url, destPath, expectedSHA256 string, opts ...BootstrapOption) error {
+	if strings.TrimSpace(url) == "" {
+		return fmt.Errorf("download URL cannot be empty")
+	}
+	if strings.TrimSpace(destPath) == "" {
+		return fmt.Errorf("download destination path cannot be empty")
+	}
+	if expectedSHA256 != "" {
+		normalized, err := normalizeSHA256Hex(expectedSHA256, "expected checksum")
+		if err != nil {
+			return err
+		}
+		expectedSHA256 = normalized
+	}
+	cfg, err := resolveBootstrapConfig(opts...)
+	if err != nil {
+		return err
+	}
+	defer cfg.closeIdleConnections()
+	if cfg.disableDownload {
+		return fmt.Errorf("downloads are disabled; cannot fetch %q", url)
+	}
+
+	name := filepath.Base(destPath)
+	tmpPath, checksum, err := downloadToTempFile(cfg, url, filepath.Dir(destPath), "."+name+"-*.tmp", name)
+	if err != nil {
+		return err
+	}
+	if expectedSHA256 != "" && checksum != expectedSHA256 {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("download checksum mismatch for %q: expected %s, got %s", url, expectedSHA256, checksum)
+	}
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to move download into place at %q: %w", destPath, err)
+	}
+	return nil
+}
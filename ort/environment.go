@@ -29,10 +29,12 @@ var (
 	mu                                 sync.Mutex
 	ortCallMu                          sync.RWMutex
 	refCount                           int
+	liveSessions                       int // AdvancedSessions created and not yet destroyed.
 	ortLib                             uintptr
 	ortAPI                             *OrtApi
 	ortEnv                             uintptr
 	libPath                            string
+	apiVersion                         uint32                             // API version negotiated by the current environment; 0 when uninitialized.
 	logLevel                           LoggingLevel = LoggingLevelWarning // Default to Warning
 	getVersionStringFunc               func() uintptr
 	getErrorMessageFunc                func(uintptr) uintptr
@@ -46,6 +48,7 @@ var (
 	createSessionFunc                  func(env uintptr, modelPath uintptr, sessionOptions uintptr, out *uintptr) uintptr
 	runSessionFunc                     func(session uintptr, runOptions uintptr, inputNames *uintptr, inputValues *uintptr, inputLen uintptr, outputNames *uintptr, outputLen uintptr, outputValues *uintptr) uintptr
 	releaseSessionFunc                 func(uintptr)
+	defaultSessionOptions              *SessionOptions // Applied by NewAdvancedSession when it gets nil options.
 )
 
 // getErrorMessage extracts the error message from an ORT status code.
@@ -70,6 +73,22 @@ func releaseStatus(status uintptr) {
 
 // InitializeEnvironment initializes the ONNX Runtime environment
 func InitializeEnvironment() error {
+	return initializeEnvironment(ORT_API_VERSION, false)
+}
+
+// InitializeEnvironmentWithAPIVersion initializes the ONNX Runtime environment requesting
+// API version v instead of ORT_API_VERSION, for runtime builds that do not support the
+// default. v must be between 1 and ORT_API_VERSION. Only API functions that exist in
+// version v may be used; this package's session and tensor calls require version 1.
+// If the environment is already initialized, v must match the negotiated version.
+func InitializeEnvironmentWithAPIVersion(v uint32) error {
+	if v == 0 || v > ORT_API_VERSION {
+		return fmt.Errorf("invalid ONNX Runtime API version %d: must be between 1 and %d", v, ORT_API_VERSION)
+	}
+	return initializeEnvironment(v, true)
+}
+
+func initializeEnvironment(requestedAPIVersion uint32, strictVersion bool) error {
 	ortCallMu.Lock()
 	defer ortCallMu.Unlock()
 
@@ -77,6 +96,9 @@ func InitializeEnvironment() error {
 	defer mu.Unlock()
 
 	if refCount > 0 {
+		if strictVersion && apiVersion != requestedAPIVersion {
+			return fmt.Errorf("ONNX Runtime environment already initialized with API version %d, cannot use version %d", apiVersion, requestedAPIVersion)
+		}
 		refCount++
 		return nil
 	}
@@ -110,7 +132,7 @@ func InitializeEnvironment() error {
 	}()
 
 	var err error
-	ortLib, err = loadLibrary(libPath)
+	ortLib, err = loadLibraryWithRetry(libPath, libraryLoadAttempts, libraryLoadBackoff, loadLibrary, isTransientLibraryLoadError, libraryLoadSleep)
 	if err != nil {
 		return fmt.Errorf("failed to load ONNX Runtime library: %w", err)
 	}
@@ -126,13 +148,12 @@ func InitializeEnvironment() error {
 
 	purego.RegisterFunc(&getVersionStringFunc, apiBase.GetVersionString)
 
-	var getApi func(uint32) uintptr
+	var getApi func(uint32) unsafe.Pointer
 	purego.RegisterFunc(&getApi, apiBase.GetApi)
-	apiPtr := getApi(ORT_API_VERSION)
-	// #nosec G103 -- This unsafe conversion is required for purego FFI.
-	// The OrtApi struct layout exactly matches the C API struct returned by GetApi.
-	// This pattern is the standard way to use purego for calling C libraries without CGO.
-	ortAPI = (*OrtApi)(unsafe.Pointer(apiPtr))
+	ortAPI, err = negotiateAPI(getApi, requestedAPIVersion)
+	if err != nil {
+		return err
+	}
 
 	// Register frequently-used API functions once to avoid repeated RegisterFunc calls
 	purego.RegisterFunc(&getErrorMessageFunc, ortAPI.GetErrorMessage)
@@ -159,7 +180,7 @@ func InitializeEnvironment() error {
 			if err == nil && minor < 22 {
 				log.Printf("WARNING: ONNX Runtime version %s is older than 1.22.0 (API version %d). "+
 					"This package was built against 1.22.0+. You may encounter compatibility issues. "+
-					"To suppress this warning, set ONNXRUNTIME_SKIP_VERSION_CHECK=1", version, ORT_API_VERSION)
+					"To suppress this warning, set ONNXRUNTIME_SKIP_VERSION_CHECK=1", version, requestedAPIVersion)
 			}
 		}
 	}
@@ -180,10 +201,27 @@ func InitializeEnvironment() error {
 	// Success - prevent cleanup
 	cleanupNeeded = false
 	refCount = 1
+	apiVersion = requestedAPIVersion
 	return nil
 }
 
-// DestroyEnvironment cleans up the ONNX Runtime environment
+// negotiateAPI requests the OrtApi table for version from getApi. ORT returns a null
+// pointer (and prints to stderr) when the library does not support the version.
+func negotiateAPI(getApi func(uint32) unsafe.Pointer, version uint32) (*OrtApi, error) {
+	apiPtr := getApi(version)
+	if apiPtr == nil {
+		return nil, fmt.Errorf("ONNX Runtime library does not support API version %d", version)
+	}
+	// #nosec G103 -- This unsafe conversion is required for purego FFI.
+	// The OrtApi struct layout exactly matches the C API struct returned by GetApi.
+	// This pattern is the standard way to use purego for calling C libraries without CGO.
+	return (*OrtApi)(apiPtr), nil
+}
+
+// DestroyEnvironment cleans up the ONNX Runtime environment.
+// Releasing the last reference fails while sessions are still alive, since running or
+// destroying them after the runtime is unloaded would crash; destroy every session first
+// and call DestroyEnvironment again.
 func DestroyEnvironment() error {
 	ortCallMu.Lock()
 	defer ortCallMu.Unlock()
@@ -194,6 +232,9 @@ func DestroyEnvironment() error {
 	if refCount == 0 {
 		return nil
 	}
+	if refCount == 1 && liveSessions > 0 {
+		return fmt.Errorf("cannot destroy ONNX Runtime environment: %d session(s) still alive; destroy them first", liveSessions)
+	}
 
 	refCount--
 	if refCount > 0 {
@@ -201,7 +242,7 @@ func DestroyEnvironment() error {
 	}
 
 	if ortAPI != nil && ortEnv != 0 {
-		// Now that we have the complete OrtApi struct layout (all 305 functions),
+		// Now that we have the complete OrtApi struct layout (all 315 functions),
 		// we can properly call ReleaseEnv
 		var releaseEnv func(uintptr)
 		purego.RegisterFunc(&releaseEnv, ortAPI.ReleaseEnv)
@@ -217,6 +258,7 @@ func DestroyEnvironment() error {
 	}
 
 	ortAPI = nil
+	apiVersion = 0
 	getVersionStringFunc = nil
 	getErrorMessageFunc = nil
 	releaseStatusFunc = nil
@@ -229,6 +271,7 @@ func DestroyEnvironment() error {
 	createSessionFunc = nil
 	runSessionFunc = nil
 	releaseSessionFunc = nil
+	defaultSessionOptions = nil
 
 	return nil
 }
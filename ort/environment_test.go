@@ -5,6 +5,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"unsafe"
 )
 
 // resetEnvironmentState resets global state for testing
@@ -12,10 +13,12 @@ func resetEnvironmentState() {
 	mu.Lock()
 	defer mu.Unlock()
 	refCount = 0
+	liveSessions = 0
 	ortLib = 0
 	ortAPI = nil
 	ortEnv = 0
 	libPath = ""
+	apiVersion = 0
 	logLevel = LoggingLevelWarning
 	getVersionStringFunc = nil
 	getErrorMessageFunc = nil
@@ -29,6 +32,7 @@ func resetEnvironmentState() {
 	createSessionFunc = nil
 	runSessionFunc = nil
 	releaseSessionFunc = nil
+	defaultSessionOptions = nil
 }
 
 func TestIsInitialized(t *testing.T) {
@@ -240,6 +244,73 @@ func TestDestroyEnvironmentDecrements(t *testing.T) {
 	resetEnvironmentState()
 }
 
+func TestDestroyEnvironmentRefusesWhileSessionsAlive(t *testing.T) {
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	var releasedSessions []uintptr
+	mu.Lock()
+	refCount = 2
+	ortAPI = &OrtApi{}
+	ortEnv = 99
+	createSessionOptionsFunc = func(out *uintptr) uintptr { return 0 }
+	releaseSessionOptionsFunc = func(handle uintptr) {}
+	createSessionFunc = func(env uintptr, modelPath uintptr, sessionOptions uintptr, out *uintptr) uintptr {
+		*out = 123
+		return 0
+	}
+	releaseSessionFunc = func(handle uintptr) {
+		releasedSessions = append(releasedSessions, handle)
+	}
+	mu.Unlock()
+
+	session, err := NewAdvancedSession("model.onnx", []string{"input"}, []string{"output"}, []Value{&fakeValue{handle: 1}}, []Value{&fakeValue{handle: 2}}, nil)
+	if err != nil {
+		t.Fatalf("NewAdvancedSession failed: %v", err)
+	}
+
+	// Releasing a non-final reference is allowed with live sessions.
+	if err := DestroyEnvironment(); err != nil {
+		t.Fatalf("unexpected error releasing a shared reference: %v", err)
+	}
+	err = DestroyEnvironment()
+	if err == nil || !strings.Contains(err.Error(), "1 session(s) still alive") {
+		t.Fatalf("expected live session error, got: %v", err)
+	}
+	if !IsInitialized() {
+		t.Fatalf("expected the environment to stay initialized after the refused destroy")
+	}
+
+	if err := session.Destroy(); err != nil {
+		t.Fatalf("session Destroy failed: %v", err)
+	}
+	if len(releasedSessions) != 1 || releasedSessions[0] != 123 {
+		t.Fatalf("expected the session to be released once, got %v", releasedSessions)
+	}
+	// A second Destroy must not decrement the live session count again.
+	if err := session.Destroy(); err != nil {
+		t.Fatalf("second session Destroy failed: %v", err)
+	}
+	mu.Lock()
+	remaining := liveSessions
+	mu.Unlock()
+	if remaining != 0 {
+		t.Fatalf("expected no live sessions, got %d", remaining)
+	}
+
+	// Skip the real library teardown; only the guard is under test.
+	mu.Lock()
+	ortAPI = nil
+	ortEnv = 0
+	mu.Unlock()
+	if err := DestroyEnvironment(); err != nil {
+		t.Fatalf("expected destroy to succeed once sessions are gone, got: %v", err)
+	}
+	if IsInitialized() {
+		t.Fatalf("expected the environment to be destroyed")
+	}
+}
+
 func TestConcurrentInitialization(t *testing.T) {
 	resetEnvironmentState()
 
@@ -367,6 +438,86 @@ func TestInitializeWithActualLibrary(t *testing.T) {
 	resetEnvironmentState()
 }
 
+func TestNegotiateAPI(t *testing.T) {
+	var table OrtApi
+	var requested []uint32
+	getApi := func(version uint32) unsafe.Pointer {
+		requested = append(requested, version)
+		if version <= 18 {
+			// #nosec G103 -- Test fake returns the address of a Go-allocated OrtApi table.
+			return unsafe.Pointer(&table)
+		}
+		return nil
+	}
+
+	api, err := negotiateAPI(getApi, 18)
+	if err != nil {
+		t.Fatalf("negotiateAPI failed: %v", err)
+	}
+	if api != &table {
+		t.Fatalf("expected negotiated table pointer %p, got %p", &table, api)
+	}
+
+	if _, err := negotiateAPI(getApi, ORT_API_VERSION); err == nil || !strings.Contains(err.Error(), "does not support API version 22") {
+		t.Fatalf("expected unsupported version error, got: %v", err)
+	}
+	if want := []uint32{18, ORT_API_VERSION}; len(requested) != 2 || requested[0] != want[0] || requested[1] != want[1] {
+		t.Fatalf("unexpected requested versions: got %v, want %v", requested, want)
+	}
+}
+
+func TestInitializeEnvironmentWithAPIVersionValidation(t *testing.T) {
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	for _, v := range []uint32{0, ORT_API_VERSION + 1} {
+		if err := InitializeEnvironmentWithAPIVersion(v); err == nil || !strings.Contains(err.Error(), "invalid ONNX Runtime API version") {
+			t.Fatalf("expected invalid version error for %d, got: %v", v, err)
+		}
+	}
+
+	mu.Lock()
+	refCount = 1
+	apiVersion = 18
+	mu.Unlock()
+
+	if err := InitializeEnvironmentWithAPIVersion(17); err == nil || !strings.Contains(err.Error(), "already initialized with API version 18") {
+		t.Fatalf("expected version mismatch error, got: %v", err)
+	}
+	if err := InitializeEnvironmentWithAPIVersion(18); err != nil {
+		t.Fatalf("expected matching version to share environment, got: %v", err)
+	}
+	mu.Lock()
+	gotRefCount := refCount
+	mu.Unlock()
+	if gotRefCount != 2 {
+		t.Fatalf("expected refCount 2, got %d", gotRefCount)
+	}
+}
+
+func TestInitializeWithAPIVersionActualLibrary(t *testing.T) {
+	libPath := os.Getenv("ONNXRUNTIME_LIB_PATH")
+	if libPath == "" {
+		t.Skip("Skipping integration test: ONNXRUNTIME_LIB_PATH not set")
+	}
+
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	if err := SetSharedLibraryPath(libPath); err != nil {
+		t.Fatalf("failed to set library path: %v", err)
+	}
+	if err := InitializeEnvironmentWithAPIVersion(17); err != nil {
+		t.Fatalf("failed to initialize environment with API version 17: %v", err)
+	}
+	if GetVersionString() == "0.0.0-dev" {
+		t.Fatalf("expected a runtime version string")
+	}
+	if err := DestroyEnvironment(); err != nil {
+		t.Fatalf("failed to destroy environment: %v", err)
+	}
+}
+
 func TestGetErrorMessageWithNullStatus(t *testing.T) {
 	result := getErrorMessage(0)
 	if result != "" {
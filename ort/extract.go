@@ -0,0 +1,168 @@
+package ort
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractOption configures SafeExtract.
+type ExtractOption func(*extractConfig) error
+
+type extractConfig struct {
+	format string
+	limits extractionLimits
+}
+
+// ExtractionReport summarizes a SafeExtract call.
+type ExtractionReport struct {
+	// Files is the number of regular files written.
+	Files int
+	// Bytes is the total size of the files written.
+	Bytes int64
+	// SkippedLinks is the number of symlink and hard-link entries that were not extracted.
+	SkippedLinks int
+}
+
+// WithExtractFormat sets the archive format ("tgz" or "zip") instead of inferring it from
+// the archive file name.
+func WithExtractFormat(format string) ExtractOption {
+	return func(cfg *extractConfig) error {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format != "tgz" && format != "zip" {
+			return fmt.Errorf("unsupported archive format %q; use tgz or zip", format)
+		}
+		cfg.format = format
+		return nil
+	}
+}
+
+// WithExtractLimits overrides the per-file and total extracted size limits, in bytes.
+// The defaults are 1 GiB per file and 4 GiB in total.
+func WithExtractLimits(perFile, total int64) ExtractOption {
+	return func(cfg *extractConfig) error {
+		if perFile <= 0 || total <= 0 {
+			return fmt.Errorf("extraction limits must be > 0, got perFile=%d total=%d", perFile, total)
+		}
+		cfg.limits = extractionLimits{perFile: perFile, total: total}
+		return nil
+	}
+}
+
+// SafeExtract extracts a .tgz/.tar.gz or .zip archive into destDir, creating destDir if
+// needed, with the hardening bootstrap applies to ONNX Runtime archives: entries that
+// would escape destDir (absolute paths, drive letters, ".." segments) fail the call,
+// symlinks, hard links, and special files are skipped, and extracted sizes are bounded
+// (see WithExtractLimits). An archive without regular files is an error. Entries are
+// extracted into a temporary directory inside destDir and then moved into place, so a
+// failed extraction leaves destDir untouched and symlinks already under destDir are
+// never followed: a file replaces the link itself, and a directory that is a symlink
+// fails the call.
+func SafeExtract(archivePath, destDir string, opts ...ExtractOption) (ExtractionReport, error) {
+	cfg := extractConfig{limits: defaultExtractionLimits}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(&cfg); err != nil {
+			return ExtractionReport{}, err
+		}
+	}
+	if strings.TrimSpace(archivePath) == "" {
+		return ExtractionReport{}, fmt.Errorf("archive path cannot be empty")
+	}
+	if strings.TrimSpace(destDir) == "" {
+		return ExtractionReport{}, fmt.Errorf("destination directory cannot be empty")
+	}
+	if cfg.format == "" {
+		format, err := archiveFormatFromName(archivePath)
+		if err != nil {
+			return ExtractionReport{}, err
+		}
+		cfg.format = format
+	}
+
+	if err := os.MkdirAll(destDir, secureDirectoryPermission); err != nil {
+		return ExtractionReport{}, fmt.Errorf("failed to create destination directory %q: %w", destDir, err)
+	}
+	stagingDir, err := os.MkdirTemp(destDir, ".extract-*")
+	if err != nil {
+		return ExtractionReport{}, fmt.Errorf("failed to create staging directory in %q: %w", destDir, err)
+	}
+	defer func() {
+		_ = os.RemoveAll(stagingDir)
+	}()
+	report, err := extractArchiveFile(archivePath, stagingDir, cfg.format, "", cfg.limits)
+	if err != nil {
+		return ExtractionReport{}, err
+	}
+	// Every destination is checked before the first move, so a conflict anywhere in the
+	// tree leaves destDir untouched.
+	if err := moveExtractedTree(stagingDir, destDir, true); err != nil {
+		return ExtractionReport{}, err
+	}
+	if err := moveExtractedTree(stagingDir, destDir, false); err != nil {
+		return ExtractionReport{}, err
+	}
+	return ExtractionReport{
+		Files:        report.regularFiles,
+		Bytes:        report.extractedBytes,
+		SkippedLinks: report.skippedLinkEntries,
+	}, nil
+}
+
+// moveExtractedTree moves the contents of src into dst, merging into directories that
+// already exist there. Paths in dst are inspected with Lstat, so an existing symlink is
+// replaced rather than followed when a file lands on it, and rejected when the archive
+// needs it to be a directory. With checkOnly nothing is moved; only the conflicts that
+// would stop the move are reported.
+func moveExtractedTree(src, dst string, checkOnly bool) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return fmt.Errorf("failed to read staged directory %q: %w", src, err)
+	}
+	for _, entry := range entries {
+		from := filepath.Join(src, entry.Name())
+		to := filepath.Join(dst, entry.Name())
+		existing, err := os.Lstat(to)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			if checkOnly {
+				continue
+			}
+			if err := os.Rename(from, to); err != nil {
+				return fmt.Errorf("failed to move extracted entry into %q: %w", to, err)
+			}
+		case err != nil:
+			return fmt.Errorf("failed to inspect %q: %w", to, err)
+		case entry.IsDir() && existing.IsDir():
+			if err := moveExtractedTree(from, to, checkOnly); err != nil {
+				return err
+			}
+		case entry.IsDir() && existing.Mode()&os.ModeSymlink != 0:
+			return fmt.Errorf("refusing to extract into %q: it is a symlink", to)
+		case entry.IsDir() || existing.IsDir():
+			return fmt.Errorf("cannot extract %q: an entry of a different type already exists there", to)
+		case checkOnly:
+		default:
+			if err := os.Rename(from, to); err != nil {
+				return fmt.Errorf("failed to move extracted file into %q: %w", to, err)
+			}
+		}
+	}
+	return nil
+}
+
+func archiveFormatFromName(archivePath string) (string, error) {
+	name := strings.ToLower(filepath.Base(archivePath))
+	switch {
+	case strings.HasSuffix(name, ".tgz"), strings.HasSuffix(name, ".tar.gz"):
+		return "tgz", nil
+	case strings.HasSuffix(name, ".zip"):
+		return "zip", nil
+	default:
+		return "", fmt.Errorf("cannot infer archive format from %q; use WithExtractFormat", archivePath)
+	}
+}
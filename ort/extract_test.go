@@ -0,0 +1,259 @@
+package ort
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestArchive(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	archivePath := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(archivePath, data, 0o644); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	return archivePath
+}
+
+func TestSafeExtractCrossFormat(t *testing.T) {
+	files := map[string]string{
+		"bundle/tokenizer.json":   `{"model": {}}`,
+		"bundle/onnx/model.onnx":  "model-bytes",
+		"bundle/config/meta.json": "{}",
+	}
+
+	testCases := []struct {
+		name string
+		file string
+		data []byte
+	}{
+		{name: "tgz", file: "bundle.tgz", data: buildTGZArchive(t, files)},
+		{name: "tar.gz", file: "bundle.TAR.GZ", data: buildTGZArchive(t, files)},
+		{name: "zip", file: "bundle.zip", data: buildZIPArchive(t, files)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			archivePath := writeTestArchive(t, tc.file, tc.data)
+			destDir := filepath.Join(t.TempDir(), "nested", "dest")
+			report, err := SafeExtract(archivePath, destDir)
+			if err != nil {
+				t.Fatalf("SafeExtract failed: %v", err)
+			}
+			if report.Files != len(files) || report.SkippedLinks != 0 {
+				t.Fatalf("unexpected extraction report: %+v", report)
+			}
+			var wantBytes int64
+			for name, content := range files {
+				wantBytes += int64(len(content))
+				got, err := os.ReadFile(filepath.Join(destDir, filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatalf("expected extracted file %q: %v", name, err)
+				}
+				if string(got) != content {
+					t.Fatalf("unexpected content for %q: got %q, want %q", name, got, content)
+				}
+			}
+			if report.Bytes != wantBytes {
+				t.Fatalf("unexpected extracted bytes: got %d, want %d", report.Bytes, wantBytes)
+			}
+		})
+	}
+}
+
+func TestSafeExtractSkipsSymlinkEntries(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	regularEntry, err := zw.Create("bundle/model.onnx")
+	if err != nil {
+		t.Fatalf("failed to create regular zip entry: %v", err)
+	}
+	if _, err := regularEntry.Write([]byte("model-bytes")); err != nil {
+		t.Fatalf("failed to write regular zip entry: %v", err)
+	}
+	symlinkHeader := &zip.FileHeader{Name: "bundle/escape", Method: zip.Deflate}
+	symlinkHeader.SetMode(os.ModeSymlink | 0o777)
+	symlinkEntry, err := zw.CreateHeader(symlinkHeader)
+	if err != nil {
+		t.Fatalf("failed to create symlink zip entry: %v", err)
+	}
+	if _, err := symlinkEntry.Write([]byte("/etc/passwd")); err != nil {
+		t.Fatalf("failed to write symlink zip payload: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close zip writer: %v", err)
+	}
+
+	destDir := t.TempDir()
+	report, err := SafeExtract(writeTestArchive(t, "bundle.zip", buf.Bytes()), destDir)
+	if err != nil {
+		t.Fatalf("SafeExtract failed: %v", err)
+	}
+	if report.Files != 1 || report.SkippedLinks != 1 {
+		t.Fatalf("unexpected extraction report: %+v", report)
+	}
+	if _, err := os.Lstat(filepath.Join(destDir, "bundle", "escape")); !os.IsNotExist(err) {
+		t.Fatalf("expected symlink entry to be skipped, got: %v", err)
+	}
+}
+
+func TestSafeExtractRejectsUnsafeArchives(t *testing.T) {
+	testCases := []struct {
+		name    string
+		file    string
+		data    []byte
+		opts    []ExtractOption
+		wantErr string
+	}{
+		{
+			name:    "zip slip",
+			file:    "evil.tgz",
+			data:    buildTGZArchive(t, map[string]string{"../evil.txt": "pwned"}),
+			wantErr: "unsafe archive entry path",
+		},
+		{
+			name:    "absolute path",
+			file:    "evil.zip",
+			data:    buildZIPArchive(t, map[string]string{"/tmp/evil.txt": "pwned"}),
+			wantErr: "invalid absolute archive entry path",
+		},
+		{
+			name:    "per-file limit",
+			file:    "big.zip",
+			data:    buildZIPArchive(t, map[string]string{"big.bin": "0123456789"}),
+			opts:    []ExtractOption{WithExtractLimits(4, 100)},
+			wantErr: "exceeds limit 4",
+		},
+		{
+			name:    "total limit",
+			file:    "big.tgz",
+			data:    buildTGZArchive(t, map[string]string{"a.bin": "0123", "b.bin": "4567"}),
+			opts:    []ExtractOption{WithExtractLimits(4, 6)},
+			wantErr: "total extracted size would exceed limit 6",
+		},
+		{
+			name:    "unknown extension",
+			file:    "bundle.rar",
+			data:    []byte("not an archive"),
+			wantErr: "cannot infer archive format",
+		},
+		{
+			name:    "format mismatch",
+			file:    "bundle.bin",
+			data:    []byte("not a zip"),
+			opts:    []ExtractOption{WithExtractFormat("zip")},
+			wantErr: "failed to open ZIP archive",
+		},
+		{
+			name:    "invalid limits",
+			file:    "bundle.zip",
+			data:    buildZIPArchive(t, map[string]string{"a.txt": "a"}),
+			opts:    []ExtractOption{WithExtractLimits(0, 1)},
+			wantErr: "extraction limits must be > 0",
+		},
+		{
+			name:    "invalid format",
+			file:    "bundle.zip",
+			data:    buildZIPArchive(t, map[string]string{"a.txt": "a"}),
+			opts:    []ExtractOption{WithExtractFormat("rar")},
+			wantErr: "unsupported archive format",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			archivePath := writeTestArchive(t, tc.file, tc.data)
+			destDir := filepath.Join(t.TempDir(), "dest")
+			_, err := SafeExtract(archivePath, destDir, tc.opts...)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+			if _, statErr := os.Stat(filepath.Join(filepath.Dir(destDir), "evil.txt")); !os.IsNotExist(statErr) {
+				t.Fatalf("expected no file outside the destination directory, got: %v", statErr)
+			}
+		})
+	}
+}
+
+func TestSafeExtractDoesNotFollowExistingSymlinks(t *testing.T) {
+	outsideDir := t.TempDir()
+	outsideFile := filepath.Join(outsideDir, "victim.txt")
+	if err := os.WriteFile(outsideFile, []byte("original"), 0o644); err != nil {
+		t.Fatalf("failed to write outside file: %v", err)
+	}
+
+	// A file entry landing on a pre-planted symlink replaces the link, not its target.
+	destDir := t.TempDir()
+	if err := os.Symlink(outsideFile, filepath.Join(destDir, "model.onnx")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	archive := writeTestArchive(t, "bundle.tgz", buildTGZArchive(t, map[string]string{"model.onnx": "model-bytes"}))
+	if _, err := SafeExtract(archive, destDir); err != nil {
+		t.Fatalf("SafeExtract failed: %v", err)
+	}
+	if got := readTestFile(t, outsideFile); got != "original" {
+		t.Fatalf("symlink target was overwritten: %q", got)
+	}
+	info, err := os.Lstat(filepath.Join(destDir, "model.onnx"))
+	if err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("expected model.onnx to be replaced by a regular file, got %v, %v", info, err)
+	}
+
+	// A pre-planted symlinked directory is refused and nothing is written through it.
+	destDir = t.TempDir()
+	if err := os.Symlink(outsideDir, filepath.Join(destDir, "bundle")); err != nil {
+		t.Fatalf("failed to plant directory symlink: %v", err)
+	}
+	archive = writeTestArchive(t, "nested.zip", buildZIPArchive(t, map[string]string{"bundle/victim.txt": "pwned"}))
+	if _, err := SafeExtract(archive, destDir); err == nil || !strings.Contains(err.Error(), "is a symlink") {
+		t.Fatalf("expected symlinked directory to be refused, got: %v", err)
+	}
+	if got := readTestFile(t, outsideFile); got != "original" {
+		t.Fatalf("file behind symlinked directory was overwritten: %q", got)
+	}
+	entries, err := os.ReadDir(destDir)
+	if err != nil {
+		t.Fatalf("failed to list destination: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected the staging directory to be removed, got %d entries", len(entries))
+	}
+}
+
+func TestSafeExtractConflictLeavesDestinationUntouched(t *testing.T) {
+	// ReadDir sorts the staged entries, so a.txt would be moved before the conflict on
+	// b is found if the destinations were not all checked first.
+	destDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(destDir, "b"), []byte("file"), 0o644); err != nil {
+		t.Fatalf("failed to write conflicting file: %v", err)
+	}
+	archive := writeTestArchive(t, "bundle.tgz", buildTGZArchive(t, map[string]string{
+		"a.txt":   "first",
+		"b/c.txt": "second",
+	}))
+	if _, err := SafeExtract(archive, destDir); err == nil || !strings.Contains(err.Error(), "an entry of a different type already exists") {
+		t.Fatalf("expected type conflict error, got: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(destDir, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected a.txt not to be extracted after a later conflict, got: %v", err)
+	}
+	entries, err := os.ReadDir(destDir)
+	if err != nil {
+		t.Fatalf("failed to list destination: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected only the original file to remain, got %d entries", len(entries))
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %q: %v", path, err)
+	}
+	return string(data)
+}
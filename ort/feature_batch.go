@@ -0,0 +1,117 @@
+package ort
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RunFeatureBatch runs a model that maps float32 feature rows to float32 output rows.
+//
+// The rows in features are stacked into a single [batch, featureDim] input tensor named
+// inputName, and the [batch, outputDim] output named outputName is split back into rows
+// in input order. The output width is read from the model metadata, so outputName must
+// be declared as a rank-2 tensor with a static second dimension.
+// Each call creates and releases its own session; use NewAdvancedSession directly
+// for repeated inference on the same model.
+func RunFeatureBatch(modelPath, inputName, outputName string, features [][]float32) (_ [][]float32, err error) {
+	if modelPath == "" {
+		return nil, fmt.Errorf("model path cannot be empty")
+	}
+	if inputName == "" || outputName == "" {
+		return nil, fmt.Errorf("input and output names cannot be empty")
+	}
+	if len(features) == 0 {
+		return [][]float32{}, nil
+	}
+
+	featureDim := len(features[0])
+	if featureDim == 0 {
+		return nil, fmt.Errorf("feature rows cannot be empty")
+	}
+	batchSize := len(features)
+	flat := make([]float32, 0, batchSize*featureDim)
+	for i, row := range features {
+		if len(row) != featureDim {
+			return nil, fmt.Errorf("feature row %d length mismatch: got %d, want %d", i, len(row), featureDim)
+		}
+		flat = append(flat, row...)
+	}
+
+	_, outputs, err := GetInputOutputInfo(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	outputDim, err := featureOutputDim(outputs, outputName)
+	if err != nil {
+		return nil, err
+	}
+
+	inputTensor, err := NewTensor[float32](Shape{int64(batchSize), int64(featureDim)}, flat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create feature input tensor: %w", err)
+	}
+	defer func() {
+		if destroyErr := inputTensor.Destroy(); destroyErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to destroy feature input tensor: %w", destroyErr))
+		}
+	}()
+
+	outputTensor, err := NewEmptyTensor[float32](Shape{int64(batchSize), outputDim})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create feature output tensor: %w", err)
+	}
+	defer func() {
+		if destroyErr := outputTensor.Destroy(); destroyErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to destroy feature output tensor: %w", destroyErr))
+		}
+	}()
+
+	session, err := NewAdvancedSession(
+		modelPath,
+		[]string{inputName},
+		[]string{outputName},
+		[]Value{inputTensor},
+		[]Value{outputTensor},
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	runErr := session.Run()
+	destroyErr := session.Destroy()
+	if runErr != nil {
+		return nil, errors.Join(fmt.Errorf("feature batch inference failed: %w", runErr), destroyErr)
+	}
+	if destroyErr != nil {
+		return nil, fmt.Errorf("failed to destroy feature batch session: %w", destroyErr)
+	}
+
+	data := outputTensor.GetData()
+	rows := make([][]float32, batchSize)
+	for i := range rows {
+		row := make([]float32, outputDim)
+		copy(row, data[i*int(outputDim):(i+1)*int(outputDim)])
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+func featureOutputDim(outputs []InputOutputInfo, outputName string) (int64, error) {
+	for _, output := range outputs {
+		if output.Name != outputName {
+			continue
+		}
+		if output.DataType != TensorElementDataTypeFloat {
+			return 0, fmt.Errorf("output %q must be a float32 tensor, got element type %d", outputName, output.DataType)
+		}
+		if len(output.Dimensions) != 2 {
+			return 0, fmt.Errorf("output %q must be rank 2 [batch, outputDim], got %v", outputName, output.Dimensions)
+		}
+		if output.Dimensions[1] <= 0 {
+			return 0, fmt.Errorf("output %q has a dynamic feature dimension", outputName)
+		}
+		return output.Dimensions[1], nil
+	}
+	return 0, fmt.Errorf("model does not declare output %q", outputName)
+}
@@ -0,0 +1,92 @@
+package ort
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunFeatureBatchValidation(t *testing.T) {
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	tests := []struct {
+		name       string
+		modelPath  string
+		inputName  string
+		outputName string
+		features   [][]float32
+		wantErr    string
+	}{
+		{name: "empty model path", inputName: "x", outputName: "y", features: [][]float32{{1}}, wantErr: "model path cannot be empty"},
+		{name: "empty names", modelPath: "model.onnx", features: [][]float32{{1}}, wantErr: "input and output names cannot be empty"},
+		{name: "empty row", modelPath: "model.onnx", inputName: "x", outputName: "y", features: [][]float32{{}}, wantErr: "feature rows cannot be empty"},
+		{name: "ragged rows", modelPath: "model.onnx", inputName: "x", outputName: "y", features: [][]float32{{1, 2}, {3}}, wantErr: "feature row 1 length mismatch"},
+		{name: "not initialized", modelPath: "model.onnx", inputName: "x", outputName: "y", features: [][]float32{{1, 2}}, wantErr: "ONNX Runtime not initialized"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := RunFeatureBatch(tt.modelPath, tt.inputName, tt.outputName, tt.features)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	rows, err := RunFeatureBatch("model.onnx", "x", "y", nil)
+	if err != nil || len(rows) != 0 {
+		t.Fatalf("expected empty result for empty batch, got rows=%v err=%v", rows, err)
+	}
+}
+
+func TestFeatureOutputDim(t *testing.T) {
+	outputs := []InputOutputInfo{
+		{Name: "y", DataType: TensorElementDataTypeFloat, Dimensions: Shape{-1, 2}},
+		{Name: "dynamic", DataType: TensorElementDataTypeFloat, Dimensions: Shape{-1, -1}},
+		{Name: "seq", DataType: TensorElementDataTypeFloat, Dimensions: Shape{-1, -1, 4}},
+		{Name: "ids", DataType: TensorElementDataTypeInt64, Dimensions: Shape{-1, 2}},
+	}
+	if dim, err := featureOutputDim(outputs, "y"); err != nil || dim != 2 {
+		t.Fatalf("unexpected output dim: got %d, err %v", dim, err)
+	}
+	for name, wantErr := range map[string]string{
+		"dynamic": "dynamic feature dimension",
+		"seq":     "must be rank 2",
+		"ids":     "must be a float32 tensor",
+		"missing": "does not declare output",
+	} {
+		if _, err := featureOutputDim(outputs, name); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Fatalf("output %q: expected error containing %q, got: %v", name, wantErr, err)
+		}
+	}
+}
+
+func TestRunFeatureBatchWithLinearModel(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	// W maps [a, b, c] to [a + 2c, b - c].
+	modelPath := writeLinearTestModel(t, "features", "projected", 3, 2, []float32{
+		1, 0,
+		0, 1,
+		2, -1,
+	})
+
+	rows, err := RunFeatureBatch(modelPath, "features", "projected", [][]float32{
+		{1, 2, 3},
+		{0, 0, 1},
+		{-1, 4, 0},
+	})
+	if err != nil {
+		t.Fatalf("RunFeatureBatch failed: %v", err)
+	}
+
+	want := [][]float32{{7, -1}, {2, -1}, {-1, 4}}
+	if len(rows) != len(want) {
+		t.Fatalf("unexpected row count: got %d, want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if err := ApproxEqual(rows[i], want[i], 1e-6); err != nil {
+			t.Fatalf("row %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package ort
+
+import "math"
+
+// Float16 is an IEEE 754 half-precision value stored as its raw bits. It is the
+// element type for float16 tensors, e.g. NewEmptyTensor[Float16] for the outputs of
+// half-precision model exports.
+type Float16 uint16
+
+// NewFloat16 converts f to half precision, rounding to nearest even. Values beyond
+// the float16 range become infinities and NaN stays NaN.
+func NewFloat16(f float32) Float16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exponent := int32(bits>>23) & 0xff
+	mantissa := bits & 0x7fffff
+
+	if exponent == 0xff {
+		if mantissa != 0 {
+			return Float16(sign | 0x7e00)
+		}
+		return Float16(sign | 0x7c00)
+	}
+
+	halfExponent := exponent - 127 + 15
+	if halfExponent >= 0x1f {
+		return Float16(sign | 0x7c00)
+	}
+	if halfExponent <= 0 {
+		// Subnormal half (or zero): shift the implicit leading bit into the mantissa.
+		if halfExponent < -10 {
+			return Float16(sign)
+		}
+		mantissa |= 0x800000
+		shift := uint32(14 - halfExponent)
+		half := mantissa >> shift
+		remainder := mantissa & (1<<shift - 1)
+		halfway := uint32(1) << (shift - 1)
+		if remainder > halfway || (remainder == halfway && half&1 == 1) {
+			half++
+		}
+		return Float16(sign | uint16(half))
+	}
+
+	half := uint32(halfExponent)<<10 | mantissa>>13
+	remainder := mantissa & 0x1fff
+	// A carry out of the mantissa correctly bumps the exponent, up to infinity.
+	if remainder > 0x1000 || (remainder == 0x1000 && half&1 == 1) {
+		half++
+	}
+	return Float16(sign | uint16(half))
+}
+
+// Float32 returns h widened to single precision; the conversion is exact.
+func (h Float16) Float32() float32 {
+	sign := uint32(h&0x8000) << 16
+	exponent := uint32(h>>10) & 0x1f
+	mantissa := uint32(h) & 0x3ff
+
+	switch exponent {
+	case 0:
+		if mantissa == 0 {
+			return math.Float32frombits(sign)
+		}
+		value := float32(mantissa) / (1 << 24)
+		if sign != 0 {
+			return -value
+		}
+		return value
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mantissa<<13)
+	}
+	return math.Float32frombits(sign | (exponent+112)<<23 | mantissa<<13)
+}
@@ -0,0 +1,42 @@
+package ort
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFloat16Conversion(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float32
+		bits  Float16
+		back  float32
+	}{
+		{name: "zero", value: 0, bits: 0x0000, back: 0},
+		{name: "one", value: 1, bits: 0x3c00, back: 1},
+		{name: "negative two", value: -2, bits: 0xc000, back: -2},
+		{name: "fraction", value: 0.333251953125, bits: 0x3555, back: 0.333251953125},
+		{name: "max finite", value: 65504, bits: 0x7bff, back: 65504},
+		{name: "overflow to infinity", value: 65520, bits: 0x7c00, back: float32(math.Inf(1))},
+		{name: "negative infinity", value: float32(math.Inf(-1)), bits: 0xfc00, back: float32(math.Inf(-1))},
+		{name: "smallest subnormal", value: 1.0 / (1 << 24), bits: 0x0001, back: 1.0 / (1 << 24)},
+		{name: "underflow to zero", value: 1e-8, bits: 0x0000, back: 0},
+		{name: "tie rounds to even down", value: 1 + 1.0/(1<<11), bits: 0x3c00, back: 1},
+		{name: "tie rounds to even up", value: 1 + 3.0/(1<<11), bits: 0x3c02, back: 1 + 2.0/(1<<10)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := NewFloat16(tc.value)
+			if got != tc.bits {
+				t.Fatalf("NewFloat16(%v) = %#04x, want %#04x", tc.value, uint16(got), uint16(tc.bits))
+			}
+			if back := got.Float32(); back != tc.back {
+				t.Fatalf("Float16(%#04x).Float32() = %v, want %v", uint16(got), back, tc.back)
+			}
+		})
+	}
+
+	if nan := NewFloat16(float32(math.NaN())); !math.IsNaN(float64(nan.Float32())) {
+		t.Fatalf("expected NaN to round-trip, got %#04x", uint16(nan))
+	}
+}
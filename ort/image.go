@@ -0,0 +1,98 @@
+package ort
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// TensorFromImage converts img into a normalized [1, 3, H, W] float32 tensor in NCHW
+// layout, using the image's own width and height. Channels are ordered R, G, B; each
+// value is scaled to [0, 1] and then normalized as (value - mean[c]) / std[c].
+// Alpha is ignored after un-premultiplying.
+func TensorFromImage(img image.Image, mean, std [3]float32) (*Tensor[float32], error) {
+	if img == nil {
+		return nil, fmt.Errorf("image cannot be nil")
+	}
+	bounds := img.Bounds()
+	return TensorFromImageResized(img, bounds.Dx(), bounds.Dy(), mean, std)
+}
+
+// TensorFromImageResized is like TensorFromImage but first resizes img to width x height
+// with bilinear interpolation (half-pixel centers, no antialiasing), producing a
+// [1, 3, height, width] tensor. Results are close to, but not bit-identical with,
+// the resizing done by Python preprocessing libraries.
+func TensorFromImageResized(img image.Image, width, height int, mean, std [3]float32) (*Tensor[float32], error) {
+	data, err := ImageToNCHW(img, width, height, mean, std)
+	if err != nil {
+		return nil, err
+	}
+	return NewTensor[float32](Shape{1, 3, int64(height), int64(width)}, data)
+}
+
+// ImageToNCHW returns the normalized planar RGB data ([3, height, width]) that
+// TensorFromImageResized wraps in a tensor. Use it to stack several images into one
+// batch buffer.
+func ImageToNCHW(img image.Image, width, height int, mean, std [3]float32) ([]float32, error) {
+	if img == nil {
+		return nil, fmt.Errorf("image cannot be nil")
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("image size must be positive, got %dx%d", width, height)
+	}
+	for c, s := range std {
+		if s == 0 || math.IsNaN(float64(s)) {
+			return nil, fmt.Errorf("std[%d] must be non-zero, got %v", c, s)
+		}
+	}
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if srcWidth <= 0 || srcHeight <= 0 {
+		return nil, fmt.Errorf("image is empty: %dx%d", srcWidth, srcHeight)
+	}
+
+	// Decode once into interleaved RGB in [0, 1]; image.Image.At is too slow to call per tap.
+	src := make([]float32, srcWidth*srcHeight*3)
+	for y := 0; y < srcHeight; y++ {
+		for x := 0; x < srcWidth; x++ {
+			pixel := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			offset := (y*srcWidth + x) * 3
+			src[offset] = float32(pixel.R) / 255
+			src[offset+1] = float32(pixel.G) / 255
+			src[offset+2] = float32(pixel.B) / 255
+		}
+	}
+
+	plane := width * height
+	data := make([]float32, 3*plane)
+	scaleX := float64(srcWidth) / float64(width)
+	scaleY := float64(srcHeight) / float64(height)
+	for y := 0; y < height; y++ {
+		y0, y1, wy := bilinearTaps(y, scaleY, srcHeight)
+		for x := 0; x < width; x++ {
+			x0, x1, wx := bilinearTaps(x, scaleX, srcWidth)
+			for c := 0; c < 3; c++ {
+				top := src[(y0*srcWidth+x0)*3+c]*(1-wx) + src[(y0*srcWidth+x1)*3+c]*wx
+				bottom := src[(y1*srcWidth+x0)*3+c]*(1-wx) + src[(y1*srcWidth+x1)*3+c]*wx
+				value := top*(1-wy) + bottom*wy
+				data[c*plane+y*width+x] = (value - mean[c]) / std[c]
+			}
+		}
+	}
+	return data, nil
+}
+
+// bilinearTaps maps destination index dst to the two source indices it interpolates
+// between and the weight of the second, using half-pixel centers clamped to the edges.
+func bilinearTaps(dst int, scale float64, srcSize int) (int, int, float32) {
+	pos := (float64(dst)+0.5)*scale - 0.5
+	if pos < 0 {
+		pos = 0
+	}
+	i0 := int(pos)
+	if i0 >= srcSize-1 {
+		return srcSize - 1, srcSize - 1, 0
+	}
+	return i0, i0 + 1, float32(pos - float64(i0))
+}
@@ -0,0 +1,129 @@
+package ort
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestImage() *image.NRGBA {
+	// 2x2: red, green / blue, white.
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+	img.SetNRGBA(1, 0, color.NRGBA{G: 255, A: 255})
+	img.SetNRGBA(0, 1, color.NRGBA{B: 255, A: 255})
+	img.SetNRGBA(1, 1, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	return img
+}
+
+func TestImageToNCHWNormalizes(t *testing.T) {
+	mean := [3]float32{0.5, 0.5, 0.5}
+	std := [3]float32{0.5, 0.5, 0.5}
+
+	data, err := ImageToNCHW(newTestImage(), 2, 2, mean, std)
+	if err != nil {
+		t.Fatalf("ImageToNCHW failed: %v", err)
+	}
+	// Planes are R, G, B; within a plane pixels are row-major. (v - 0.5) / 0.5 maps 0 -> -1, 1 -> 1.
+	want := []float32{
+		1, -1, -1, 1, // R
+		-1, 1, -1, 1, // G
+		-1, -1, 1, 1, // B
+	}
+	if err := ApproxEqual(data, want, 1e-6); err != nil {
+		t.Fatalf("unexpected NCHW data: %v (got %v)", err, data)
+	}
+}
+
+func TestImageToNCHWResizes(t *testing.T) {
+	identity := [3]float32{0, 0, 0}
+	unit := [3]float32{1, 1, 1}
+
+	averaged, err := ImageToNCHW(newTestImage(), 1, 1, identity, unit)
+	if err != nil {
+		t.Fatalf("ImageToNCHW failed: %v", err)
+	}
+	// A single output pixel centered on the 2x2 source averages all four pixels.
+	if err := ApproxEqual(averaged, []float32{0.5, 0.5, 0.5}, 1e-6); err != nil {
+		t.Fatalf("unexpected downscaled data: %v (got %v)", err, averaged)
+	}
+
+	upscaled, err := ImageToNCHW(newTestImage(), 4, 2, identity, unit)
+	if err != nil {
+		t.Fatalf("ImageToNCHW failed: %v", err)
+	}
+	// Red channel, top row: edge pixels clamp to the source, inner pixels blend 3:1.
+	if err := ApproxEqual(upscaled[:4], []float32{1, 0.75, 0.25, 0}, 1e-6); err != nil {
+		t.Fatalf("unexpected upscaled red row: %v (got %v)", err, upscaled[:4])
+	}
+}
+
+func TestImageToNCHWHonorsBoundsOrigin(t *testing.T) {
+	img := image.NewGray(image.Rect(10, 20, 11, 21))
+	img.SetGray(10, 20, color.Gray{Y: 51})
+
+	data, err := ImageToNCHW(img, 1, 1, [3]float32{}, [3]float32{1, 1, 1})
+	if err != nil {
+		t.Fatalf("ImageToNCHW failed: %v", err)
+	}
+	if err := ApproxEqual(data, []float32{0.2, 0.2, 0.2}, 1e-6); err != nil {
+		t.Fatalf("unexpected data for offset bounds: %v (got %v)", err, data)
+	}
+}
+
+func TestImageToNCHWValidation(t *testing.T) {
+	unit := [3]float32{1, 1, 1}
+	tests := []struct {
+		name    string
+		img     image.Image
+		width   int
+		height  int
+		std     [3]float32
+		wantErr string
+	}{
+		{name: "nil image", img: nil, width: 1, height: 1, std: unit, wantErr: "image cannot be nil"},
+		{name: "empty image", img: image.NewNRGBA(image.Rect(0, 0, 0, 0)), width: 1, height: 1, std: unit, wantErr: "image is empty"},
+		{name: "zero size", img: newTestImage(), width: 0, height: 1, std: unit, wantErr: "image size must be positive"},
+		{name: "zero std", img: newTestImage(), width: 1, height: 1, std: [3]float32{1, 0, 1}, wantErr: "std[1] must be non-zero"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ImageToNCHW(tt.img, tt.width, tt.height, [3]float32{}, tt.std)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTensorFromImageWithORT(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	tensor, err := TensorFromImage(newTestImage(), [3]float32{0.5, 0.5, 0.5}, [3]float32{0.5, 0.5, 0.5})
+	if err != nil {
+		t.Fatalf("TensorFromImage failed: %v", err)
+	}
+	defer func() {
+		_ = tensor.Destroy()
+	}()
+	if !reflect.DeepEqual(tensor.Shape(), Shape{1, 3, 2, 2}) {
+		t.Fatalf("unexpected shape: %v", tensor.Shape())
+	}
+	if got := tensor.GetData(); got[0] != 1 || got[4] != -1 {
+		t.Fatalf("unexpected tensor data: %v", got)
+	}
+
+	resized, err := TensorFromImageResized(newTestImage(), 4, 3, [3]float32{}, [3]float32{1, 1, 1})
+	if err != nil {
+		t.Fatalf("TensorFromImageResized failed: %v", err)
+	}
+	defer func() {
+		_ = resized.Destroy()
+	}()
+	if !reflect.DeepEqual(resized.Shape(), Shape{1, 3, 3, 4}) {
+		t.Fatalf("unexpected resized shape: %v", resized.Shape())
+	}
+}
@@ -0,0 +1,63 @@
+package ort
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultLibraryLoadAttempts = 5
+	defaultLibraryLoadBackoff  = 100 * time.Millisecond
+)
+
+var (
+	libraryLoadAttempts = defaultLibraryLoadAttempts
+	libraryLoadBackoff  = defaultLibraryLoadBackoff
+	libraryLoadSleep    = time.Sleep
+)
+
+// SetLibraryLoadRetry configures how InitializeEnvironment retries loading the shared
+// library when the load fails with a transient file lock (on Windows, a sharing or lock
+// violation raised while antivirus scanners inspect a freshly extracted DLL). The delay
+// starts at initialBackoff and doubles after each failed attempt. attempts=1 disables
+// retries. Other platforms and non-transient errors never retry.
+// Returns an error if the environment is already initialized.
+func SetLibraryLoadRetry(attempts int, initialBackoff time.Duration) error {
+	if attempts < 1 {
+		return fmt.Errorf("library load attempts must be at least 1, got %d", attempts)
+	}
+	if initialBackoff < 0 {
+		return fmt.Errorf("library load backoff must be non-negative, got %s", initialBackoff)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if refCount > 0 {
+		return fmt.Errorf("cannot change library load retry after environment is initialized")
+	}
+	libraryLoadAttempts = attempts
+	libraryLoadBackoff = initialBackoff
+	return nil
+}
+
+// loadLibraryWithRetry calls load until it succeeds, fails with an error that
+// isTransient rejects, or attempts are exhausted.
+func loadLibraryWithRetry(path string, attempts int, backoff time.Duration, load func(string) (uintptr, error), isTransient func(error) bool, sleep func(time.Duration)) (uintptr, error) {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var handle uintptr
+		handle, err = load(path)
+		if err == nil {
+			return handle, nil
+		}
+		if attempt >= attempts || !isTransient(err) {
+			break
+		}
+		sleep(backoff)
+		backoff *= 2
+	}
+	if attempts > 1 && isTransient(err) {
+		return 0, fmt.Errorf("still locked after %d attempts: %w", attempts, err)
+	}
+	return 0, err
+}
@@ -0,0 +1,141 @@
+package ort
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errFakeLocked = errors.New("fake sharing violation")
+
+func TestLoadLibraryWithRetry(t *testing.T) {
+	isTransient := func(err error) bool { return errors.Is(err, errFakeLocked) }
+	errNotFound := errors.New("module not found")
+
+	tests := []struct {
+		name         string
+		failures     []error
+		attempts     int
+		wantHandle   uintptr
+		wantCalls    int
+		wantSleeps   []time.Duration
+		wantErr      string
+		wantErrorsIs error
+	}{
+		{
+			name:       "first attempt succeeds",
+			attempts:   3,
+			wantHandle: 42,
+			wantCalls:  1,
+		},
+		{
+			name:       "transient lock clears",
+			failures:   []error{errFakeLocked, errFakeLocked},
+			attempts:   5,
+			wantHandle: 42,
+			wantCalls:  3,
+			wantSleeps: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+		},
+		{
+			name:         "attempts exhausted",
+			failures:     []error{errFakeLocked, errFakeLocked, errFakeLocked},
+			attempts:     3,
+			wantCalls:    3,
+			wantSleeps:   []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+			wantErr:      "still locked after 3 attempts",
+			wantErrorsIs: errFakeLocked,
+		},
+		{
+			name:         "non-transient error is not retried",
+			failures:     []error{errNotFound},
+			attempts:     5,
+			wantCalls:    1,
+			wantErr:      "module not found",
+			wantErrorsIs: errNotFound,
+		},
+		{
+			name:         "single attempt disables retries",
+			failures:     []error{errFakeLocked},
+			attempts:     1,
+			wantCalls:    1,
+			wantErr:      "fake sharing violation",
+			wantErrorsIs: errFakeLocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			load := func(path string) (uintptr, error) {
+				if path != "lib.dll" {
+					t.Fatalf("unexpected path %q", path)
+				}
+				calls++
+				if calls <= len(tt.failures) {
+					return 0, tt.failures[calls-1]
+				}
+				return 42, nil
+			}
+			var sleeps []time.Duration
+			sleep := func(d time.Duration) { sleeps = append(sleeps, d) }
+
+			handle, err := loadLibraryWithRetry("lib.dll", tt.attempts, 10*time.Millisecond, load, isTransient, sleep)
+			if calls != tt.wantCalls {
+				t.Fatalf("unexpected load calls: got %d, want %d", calls, tt.wantCalls)
+			}
+			if len(sleeps) != len(tt.wantSleeps) {
+				t.Fatalf("unexpected sleeps: got %v, want %v", sleeps, tt.wantSleeps)
+			}
+			for i := range sleeps {
+				if sleeps[i] != tt.wantSleeps[i] {
+					t.Fatalf("unexpected sleeps: got %v, want %v", sleeps, tt.wantSleeps)
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				if !errors.Is(err, tt.wantErrorsIs) {
+					t.Fatalf("expected error wrapping %v, got: %v", tt.wantErrorsIs, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if handle != tt.wantHandle {
+				t.Fatalf("unexpected handle: got %d, want %d", handle, tt.wantHandle)
+			}
+		})
+	}
+}
+
+func TestSetLibraryLoadRetry(t *testing.T) {
+	resetEnvironmentState()
+	t.Cleanup(func() {
+		libraryLoadAttempts = defaultLibraryLoadAttempts
+		libraryLoadBackoff = defaultLibraryLoadBackoff
+	})
+
+	if err := SetLibraryLoadRetry(0, time.Second); err == nil || !strings.Contains(err.Error(), "at least 1") {
+		t.Fatalf("expected attempts validation error, got: %v", err)
+	}
+	if err := SetLibraryLoadRetry(2, -time.Second); err == nil || !strings.Contains(err.Error(), "non-negative") {
+		t.Fatalf("expected backoff validation error, got: %v", err)
+	}
+	if err := SetLibraryLoadRetry(7, 50*time.Millisecond); err != nil {
+		t.Fatalf("SetLibraryLoadRetry failed: %v", err)
+	}
+	if libraryLoadAttempts != 7 || libraryLoadBackoff != 50*time.Millisecond {
+		t.Fatalf("unexpected retry settings: attempts=%d backoff=%s", libraryLoadAttempts, libraryLoadBackoff)
+	}
+
+	mu.Lock()
+	refCount = 1
+	mu.Unlock()
+	defer resetEnvironmentState()
+	if err := SetLibraryLoadRetry(2, time.Millisecond); err == nil || !strings.Contains(err.Error(), "after environment is initialized") {
+		t.Fatalf("expected initialized environment error, got: %v", err)
+	}
+}
@@ -14,6 +14,12 @@ func loadLibrary(path string) (uintptr, error) {
 	return libHandle, nil
 }
 
+// isTransientLibraryLoadError reports whether a load failure is worth retrying.
+// dlopen has no transient file-lock failures, so it never is.
+func isTransientLibraryLoadError(error) bool {
+	return false
+}
+
 func getSymbol(handle uintptr, symbol string) (uintptr, error) {
 	return purego.Dlsym(handle, symbol)
 }
@@ -3,6 +3,7 @@
 package ort
 
 import (
+	"errors"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
@@ -16,6 +17,12 @@ func loadLibrary(path string) (uintptr, error) {
 	return uintptr(handle), nil
 }
 
+// isTransientLibraryLoadError reports whether LoadLibrary failed because another
+// process (typically an antivirus scanner) briefly holds the DLL open.
+func isTransientLibraryLoadError(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) || errors.Is(err, windows.ERROR_LOCK_VIOLATION)
+}
+
 func getSymbol(handle uintptr, symbol string) (uintptr, error) {
 	proc, err := windows.GetProcAddress(windows.Handle(handle), symbol)
 	if err != nil {
@@ -0,0 +1,43 @@
+//go:build windows
+
+package ort
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestIsTransientLibraryLoadError(t *testing.T) {
+	if !isTransientLibraryLoadError(windows.ERROR_SHARING_VIOLATION) {
+		t.Fatalf("expected sharing violation to be transient")
+	}
+	if !isTransientLibraryLoadError(fmt.Errorf("wrapped: %w", windows.ERROR_LOCK_VIOLATION)) {
+		t.Fatalf("expected wrapped lock violation to be transient")
+	}
+	if isTransientLibraryLoadError(windows.ERROR_MOD_NOT_FOUND) {
+		t.Fatalf("expected missing module not to be transient")
+	}
+}
+
+func TestLoadLibraryWithRetryRecoversFromSharingViolation(t *testing.T) {
+	lockedAttempts := 2
+	calls := 0
+	load := func(string) (uintptr, error) {
+		calls++
+		if calls <= lockedAttempts {
+			return 0, windows.ERROR_SHARING_VIOLATION
+		}
+		return 1, nil
+	}
+
+	handle, err := loadLibraryWithRetry("onnxruntime.dll", 5, time.Millisecond, load, isTransientLibraryLoadError, func(time.Duration) {})
+	if err != nil {
+		t.Fatalf("expected eventual success, got: %v", err)
+	}
+	if handle != 1 || calls != lockedAttempts+1 {
+		t.Fatalf("unexpected result: handle=%d calls=%d", handle, calls)
+	}
+}
@@ -0,0 +1,380 @@
+package ort
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+// maxModelInfoCacheEntries bounds the introspection cache; models are few per process.
+const maxModelInfoCacheEntries = 32
+
+// InputOutputInfo describes one model input or output as reported by ONNX Runtime.
+// Dynamic (symbolic or unknown) dimensions are reported as -1.
+type InputOutputInfo struct {
+	Name         string
+	OrtValueType ONNXType
+	DataType     TensorElementDataType
+	Dimensions   Shape
+	// SymbolicDimensions holds the name of each dimension, such as "batch_size", or ""
+	// for fixed and unnamed dimensions, and for all dimensions when ONNX Runtime could not
+	// report the names. Dimensions sharing a name have the same size.
+	SymbolicDimensions []string
+}
+
+// modelInfoAPI holds the ORT functions used for model introspection.
+// They are registered per call because introspection is not on the inference hot path.
+type modelInfoAPI struct {
+	getAllocatorWithDefaultOptions func(out *uintptr) uintptr
+	allocatorFree                  func(allocator uintptr, ptr uintptr) uintptr
+	sessionGetInputCount           func(session uintptr, out *uintptr) uintptr
+	sessionGetOutputCount          func(session uintptr, out *uintptr) uintptr
+	sessionGetInputName            func(session uintptr, index uintptr, allocator uintptr, out *uintptr) uintptr
+	sessionGetOutputName           func(session uintptr, index uintptr, allocator uintptr, out *uintptr) uintptr
+	sessionGetInputTypeInfo        func(session uintptr, index uintptr, out *uintptr) uintptr
+	sessionGetOutputTypeInfo       func(session uintptr, index uintptr, out *uintptr) uintptr
+	getOnnxTypeFromTypeInfo        func(typeInfo uintptr, out *int32) uintptr
+	castTypeInfoToTensorInfo       func(typeInfo uintptr, out *uintptr) uintptr
+	getTensorElementType           func(tensorInfo uintptr, out *int32) uintptr
+	getDimensionsCount             func(tensorInfo uintptr, out *uintptr) uintptr
+	getDimensions                  func(tensorInfo uintptr, dims *int64, count uintptr) uintptr
+	getSymbolicDimensions          func(tensorInfo uintptr, names *uintptr, count uintptr) uintptr
+	releaseTypeInfo                func(typeInfo uintptr)
+}
+
+func newModelInfoAPI(api *OrtApi) *modelInfoAPI {
+	fns := &modelInfoAPI{}
+	purego.RegisterFunc(&fns.getAllocatorWithDefaultOptions, api.GetAllocatorWithDefaultOptions)
+	purego.RegisterFunc(&fns.allocatorFree, api.AllocatorFree)
+	purego.RegisterFunc(&fns.sessionGetInputCount, api.SessionGetInputCount)
+	purego.RegisterFunc(&fns.sessionGetOutputCount, api.SessionGetOutputCount)
+	purego.RegisterFunc(&fns.sessionGetInputName, api.SessionGetInputName)
+	purego.RegisterFunc(&fns.sessionGetOutputName, api.SessionGetOutputName)
+	purego.RegisterFunc(&fns.sessionGetInputTypeInfo, api.SessionGetInputTypeInfo)
+	purego.RegisterFunc(&fns.sessionGetOutputTypeInfo, api.SessionGetOutputTypeInfo)
+	purego.RegisterFunc(&fns.getOnnxTypeFromTypeInfo, api.GetOnnxTypeFromTypeInfo)
+	purego.RegisterFunc(&fns.castTypeInfoToTensorInfo, api.CastTypeInfoToTensorInfo)
+	purego.RegisterFunc(&fns.getTensorElementType, api.GetTensorElementType)
+	purego.RegisterFunc(&fns.getDimensionsCount, api.GetDimensionsCount)
+	purego.RegisterFunc(&fns.getDimensions, api.GetDimensions)
+	purego.RegisterFunc(&fns.getSymbolicDimensions, api.GetSymbolicDimensions)
+	purego.RegisterFunc(&fns.releaseTypeInfo, api.ReleaseTypeInfo)
+	return fns
+}
+
+type modelInfoFileKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+type modelInfoEntry struct {
+	inputs  []InputOutputInfo
+	outputs []InputOutputInfo
+}
+
+// modelInfoCache memoizes introspection results by model content hash. The file key
+// (path, size, mtime) only avoids re-hashing an unchanged file.
+type modelInfoCache struct {
+	mu         sync.Mutex
+	hashByFile map[modelInfoFileKey]string
+	byHash     map[string]modelInfoEntry
+}
+
+var (
+	defaultModelInfoCache = newModelInfoCache()
+	// queryModelInfo introspects a model through a temporary session on a cache miss;
+	// tests replace it to observe the cache.
+	queryModelInfo = queryInputOutputInfo
+)
+
+func newModelInfoCache() *modelInfoCache {
+	return &modelInfoCache{
+		hashByFile: make(map[modelInfoFileKey]string),
+		byHash:     make(map[string]modelInfoEntry),
+	}
+}
+
+// GetInputOutputInfo loads the model at modelPath into a temporary session and
+// returns metadata for its inputs and outputs in model declaration order.
+// ONNX Runtime must be initialized before calling this function.
+// Results are cached by model content, so repeated calls for the same model
+// file skip session creation.
+func GetInputOutputInfo(modelPath string) ([]InputOutputInfo, []InputOutputInfo, error) {
+	if modelPath == "" {
+		return nil, nil, fmt.Errorf("model path cannot be empty")
+	}
+	if !IsInitialized() {
+		return nil, nil, fmt.Errorf("ONNX Runtime not initialized")
+	}
+	return defaultModelInfoCache.get(modelPath, queryModelInfo)
+}
+
+// ModelSHA256 returns the hex-encoded SHA-256 of the model file at modelPath, for
+// recording which model produced a set of outputs. The hash computed by
+// GetInputOutputInfo is reused while the file is unchanged, so this is cheap after
+// introspection. ONNX Runtime does not need to be initialized.
+func ModelSHA256(modelPath string) (string, error) {
+	if modelPath == "" {
+		return "", fmt.Errorf("model path cannot be empty")
+	}
+	_, hash, err := defaultModelInfoCache.hash(modelPath)
+	return hash, err
+}
+
+// hash returns the content hash of modelPath, hashing the file only when its
+// (path, size, mtime) key has not been seen.
+func (c *modelInfoCache) hash(modelPath string) (modelInfoFileKey, string, error) {
+	stat, err := os.Stat(modelPath)
+	if err != nil {
+		return modelInfoFileKey{}, "", fmt.Errorf("model path %q is not usable: %w", modelPath, err)
+	}
+	fileKey := modelInfoFileKey{path: modelPath, size: stat.Size(), modTime: stat.ModTime()}
+
+	c.mu.Lock()
+	hash, ok := c.hashByFile[fileKey]
+	c.mu.Unlock()
+	if ok {
+		return fileKey, hash, nil
+	}
+	if hash, err = hashModelFile(modelPath); err != nil {
+		return modelInfoFileKey{}, "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rememberHashLocked(fileKey, hash)
+	return fileKey, hash, nil
+}
+
+func (c *modelInfoCache) get(modelPath string, query func(string) ([]InputOutputInfo, []InputOutputInfo, error)) ([]InputOutputInfo, []InputOutputInfo, error) {
+	fileKey, hash, err := c.hash(modelPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.byHash[hash]
+	c.mu.Unlock()
+	if ok {
+		c.remember(fileKey, hash, entry)
+		return cloneInputOutputInfos(entry.inputs), cloneInputOutputInfos(entry.outputs), nil
+	}
+
+	inputs, outputs, err := query(modelPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	entry = modelInfoEntry{inputs: cloneInputOutputInfos(inputs), outputs: cloneInputOutputInfos(outputs)}
+	c.remember(fileKey, hash, entry)
+	return inputs, outputs, nil
+}
+
+func (c *modelInfoCache) remember(fileKey modelInfoFileKey, hash string, entry modelInfoEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rememberHashLocked(fileKey, hash)
+	if _, ok := c.byHash[hash]; !ok && len(c.byHash) >= maxModelInfoCacheEntries {
+		clear(c.byHash)
+	}
+	c.byHash[hash] = entry
+}
+
+func (c *modelInfoCache) rememberHashLocked(fileKey modelInfoFileKey, hash string) {
+	if _, ok := c.hashByFile[fileKey]; !ok && len(c.hashByFile) >= maxModelInfoCacheEntries {
+		clear(c.hashByFile)
+	}
+	c.hashByFile[fileKey] = hash
+}
+
+func hashModelFile(modelPath string) (string, error) {
+	// #nosec G304 -- modelPath is the caller-provided model file being introspected.
+	file, err := os.Open(modelPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open model %q: %w", modelPath, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", fmt.Errorf("failed to hash model %q: %w", modelPath, err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func cloneInputOutputInfos(infos []InputOutputInfo) []InputOutputInfo {
+	out := make([]InputOutputInfo, len(infos))
+	for i, info := range infos {
+		out[i] = info
+		out[i].Dimensions = append(Shape(nil), info.Dimensions...)
+		out[i].SymbolicDimensions = append([]string(nil), info.SymbolicDimensions...)
+	}
+	return out
+}
+
+func queryInputOutputInfo(modelPath string) ([]InputOutputInfo, []InputOutputInfo, error) {
+	ortCallMu.RLock()
+	defer ortCallMu.RUnlock()
+
+	mu.Lock()
+	// Safe to snapshot under mu here because ortCallMu.RLock is already held.
+	// DestroyEnvironment takes ortCallMu.Lock before it can nil these globals.
+	if ortAPI == nil || ortEnv == 0 || createSessionOptionsFunc == nil || releaseSessionOptionsFunc == nil || createSessionFunc == nil || releaseSessionFunc == nil {
+		mu.Unlock()
+		return nil, nil, fmt.Errorf("ONNX Runtime not initialized")
+	}
+	api := ortAPI
+	envHandle := ortEnv
+	createSessionOptions := createSessionOptionsFunc
+	releaseSessionOptions := releaseSessionOptionsFunc
+	createSession := createSessionFunc
+	releaseSession := releaseSessionFunc
+	mu.Unlock()
+
+	fns := newModelInfoAPI(api)
+
+	var optionsHandle uintptr
+	if status := createSessionOptions(&optionsHandle); status != 0 {
+		return nil, nil, statusError(status, "failed to create session options")
+	}
+	defer releaseSessionOptions(optionsHandle)
+
+	modelPathPtr, modelPathBacking, err := goStringToORTChar(modelPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sessionHandle uintptr
+	status := createSession(envHandle, modelPathPtr, optionsHandle, &sessionHandle)
+	runtime.KeepAlive(modelPathBacking)
+	if status != 0 {
+		return nil, nil, statusError(status, "failed to create session")
+	}
+	defer releaseSession(sessionHandle)
+
+	var allocator uintptr
+	if status := fns.getAllocatorWithDefaultOptions(&allocator); status != 0 {
+		return nil, nil, statusError(status, "failed to get default allocator")
+	}
+
+	inputs, err := fns.collect(sessionHandle, allocator, "input", fns.sessionGetInputCount, fns.sessionGetInputName, fns.sessionGetInputTypeInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	outputs, err := fns.collect(sessionHandle, allocator, "output", fns.sessionGetOutputCount, fns.sessionGetOutputName, fns.sessionGetOutputTypeInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inputs, outputs, nil
+}
+
+func (fns *modelInfoAPI) collect(
+	session uintptr,
+	allocator uintptr,
+	role string,
+	getCount func(session uintptr, out *uintptr) uintptr,
+	getName func(session uintptr, index uintptr, allocator uintptr, out *uintptr) uintptr,
+	getTypeInfo func(session uintptr, index uintptr, out *uintptr) uintptr,
+) ([]InputOutputInfo, error) {
+	var count uintptr
+	if status := getCount(session, &count); status != 0 {
+		return nil, statusError(status, fmt.Sprintf("failed to get %s count", role))
+	}
+
+	infos := make([]InputOutputInfo, 0, count)
+	for i := uintptr(0); i < count; i++ {
+		var namePtr uintptr
+		if status := getName(session, i, allocator, &namePtr); status != 0 {
+			return nil, statusError(status, fmt.Sprintf("failed to get %s name at index %d", role, i))
+		}
+		name := CstringToGo(namePtr)
+		if status := fns.allocatorFree(allocator, namePtr); status != 0 {
+			return nil, statusError(status, fmt.Sprintf("failed to free %s name at index %d", role, i))
+		}
+
+		var typeInfo uintptr
+		if status := getTypeInfo(session, i, &typeInfo); status != 0 {
+			return nil, statusError(status, fmt.Sprintf("failed to get type info for %s %q", role, name))
+		}
+		info, err := fns.describe(typeInfo)
+		fns.releaseTypeInfo(typeInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe %s %q: %w", role, name, err)
+		}
+		info.Name = name
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (fns *modelInfoAPI) describe(typeInfo uintptr) (InputOutputInfo, error) {
+	var onnxType int32
+	if status := fns.getOnnxTypeFromTypeInfo(typeInfo, &onnxType); status != 0 {
+		return InputOutputInfo{}, statusError(status, "failed to get ONNX type")
+	}
+	info := InputOutputInfo{OrtValueType: ONNXType(onnxType)}
+	if info.OrtValueType != ONNXTypeTensor {
+		// Non-tensor values (sequences, maps, ...) carry no tensor shape metadata.
+		return info, nil
+	}
+
+	// The tensor info is owned by typeInfo and must not be released separately.
+	var tensorInfo uintptr
+	if status := fns.castTypeInfoToTensorInfo(typeInfo, &tensorInfo); status != 0 {
+		return InputOutputInfo{}, statusError(status, "failed to cast type info to tensor info")
+	}
+	if tensorInfo == 0 {
+		return InputOutputInfo{}, fmt.Errorf("tensor info is unavailable")
+	}
+
+	var elementType int32
+	if status := fns.getTensorElementType(tensorInfo, &elementType); status != 0 {
+		return InputOutputInfo{}, statusError(status, "failed to get tensor element type")
+	}
+	info.DataType = TensorElementDataType(elementType)
+
+	var dimCount uintptr
+	if status := fns.getDimensionsCount(tensorInfo, &dimCount); status != 0 {
+		return InputOutputInfo{}, statusError(status, "failed to get tensor rank")
+	}
+	dims := make(Shape, dimCount)
+	symbolic := make([]string, dimCount)
+	if dimCount > 0 {
+		if status := fns.getDimensions(tensorInfo, shapePtr(dims), dimCount); status != 0 {
+			return InputOutputInfo{}, statusError(status, "failed to get tensor dimensions")
+		}
+		runtime.KeepAlive(dims)
+
+		// The name strings are owned by typeInfo, so copy them before it is released.
+		// The names are informational, so a failure to read them leaves them empty.
+		names := make([]uintptr, dimCount)
+		if status := fns.getSymbolicDimensions(tensorInfo, unsafe.SliceData(names), dimCount); status != 0 {
+			releaseStatus(status)
+			clear(names)
+		}
+		for i, name := range names {
+			if name != 0 {
+				symbolic[i] = CstringToGo(name)
+			}
+		}
+	}
+	info.Dimensions = dims
+	info.SymbolicDimensions = symbolic
+	return info, nil
+}
+
+// statusError converts a non-zero ORT status into an error and releases the status.
+func statusError(status uintptr, action string) error {
+	errMsg := getErrorMessage(status)
+	releaseStatus(status)
+	return fmt.Errorf("%s: %s", action, errMsg)
+}
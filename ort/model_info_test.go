@@ -0,0 +1,241 @@
+package ort
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+)
+
+func TestGetInputOutputInfoValidation(t *testing.T) {
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	if _, _, err := GetInputOutputInfo(""); err == nil || !strings.Contains(err.Error(), "model path cannot be empty") {
+		t.Fatalf("expected empty model path error, got: %v", err)
+	}
+	if _, _, err := GetInputOutputInfo("model.onnx"); err == nil || !strings.Contains(err.Error(), "ONNX Runtime not initialized") {
+		t.Fatalf("expected not initialized error, got: %v", err)
+	}
+}
+
+func TestGetInputOutputInfoWithAllMiniLML6V2(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	modelPath := resolveAllMiniLMModelPath(t)
+	inputs, outputs, err := GetInputOutputInfo(modelPath)
+	if err != nil {
+		t.Fatalf("GetInputOutputInfo failed: %v", err)
+	}
+
+	wantInputs := []string{"input_ids", "attention_mask", "token_type_ids"}
+	if len(inputs) != len(wantInputs) {
+		t.Fatalf("unexpected input count: got %d, want %d", len(inputs), len(wantInputs))
+	}
+	for i, want := range wantInputs {
+		if inputs[i].Name != want {
+			t.Fatalf("unexpected input name at %d: got %q, want %q", i, inputs[i].Name, want)
+		}
+		if inputs[i].OrtValueType != ONNXTypeTensor || inputs[i].DataType != TensorElementDataTypeInt64 {
+			t.Fatalf("unexpected input %q type: %+v", want, inputs[i])
+		}
+		if len(inputs[i].Dimensions) != 2 {
+			t.Fatalf("unexpected input %q rank: %v", want, inputs[i].Dimensions)
+		}
+	}
+
+	if len(outputs) == 0 || outputs[0].Name != "last_hidden_state" {
+		t.Fatalf("unexpected outputs: %+v", outputs)
+	}
+	dims := outputs[0].Dimensions
+	if len(dims) != 3 || dims[2] != 384 {
+		t.Fatalf("unexpected last_hidden_state dimensions: %v", dims)
+	}
+}
+
+func TestModelInfoCacheQueriesOncePerContent(t *testing.T) {
+	dir := t.TempDir()
+	writeModel := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+	first := writeModel("first.onnx", "model-a")
+	copyOfFirst := writeModel("copy.onnx", "model-a")
+	other := writeModel("other.onnx", "model-b")
+
+	queries := 0
+	query := func(string) ([]InputOutputInfo, []InputOutputInfo, error) {
+		queries++
+		return []InputOutputInfo{{Name: "x", Dimensions: Shape{-1, 3}}}, []InputOutputInfo{{Name: "y", Dimensions: Shape{-1, 2}}}, nil
+	}
+
+	cache := newModelInfoCache()
+	inputs, _, err := cache.get(first, query)
+	if err != nil {
+		t.Fatalf("first lookup failed: %v", err)
+	}
+	inputs[0].Dimensions[1] = 99
+
+	for _, path := range []string{first, copyOfFirst} {
+		cached, outputs, err := cache.get(path, query)
+		if err != nil {
+			t.Fatalf("cached lookup for %s failed: %v", path, err)
+		}
+		if cached[0].Dimensions[1] != 3 || outputs[0].Name != "y" {
+			t.Fatalf("unexpected cached info for %s: inputs=%+v outputs=%+v", path, cached, outputs)
+		}
+	}
+	if queries != 1 {
+		t.Fatalf("unexpected query count for identical content: got %d, want 1", queries)
+	}
+
+	if _, _, err := cache.get(other, query); err != nil {
+		t.Fatalf("lookup for different content failed: %v", err)
+	}
+	if queries != 2 {
+		t.Fatalf("unexpected query count after different content: got %d, want 2", queries)
+	}
+}
+
+func TestModelInfoCacheDoesNotCacheErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.onnx")
+	if err := os.WriteFile(path, []byte("model"), 0o600); err != nil {
+		t.Fatalf("failed to write model: %v", err)
+	}
+
+	queries := 0
+	failing := func(string) ([]InputOutputInfo, []InputOutputInfo, error) {
+		queries++
+		return nil, nil, errors.New("boom")
+	}
+
+	cache := newModelInfoCache()
+	for i := 0; i < 2; i++ {
+		if _, _, err := cache.get(path, failing); err == nil || !strings.Contains(err.Error(), "boom") {
+			t.Fatalf("expected query error, got: %v", err)
+		}
+	}
+	if queries != 2 {
+		t.Fatalf("unexpected query count: got %d, want 2", queries)
+	}
+
+	if _, _, err := cache.get(filepath.Join(t.TempDir(), "missing.onnx"), failing); err == nil || !strings.Contains(err.Error(), "is not usable") {
+		t.Fatalf("expected missing model error, got: %v", err)
+	}
+}
+
+func TestModelSHA256(t *testing.T) {
+	if _, err := ModelSHA256(""); err == nil || !strings.Contains(err.Error(), "model path cannot be empty") {
+		t.Fatalf("expected empty model path error, got: %v", err)
+	}
+	if _, err := ModelSHA256(filepath.Join(t.TempDir(), "missing.onnx")); err == nil || !strings.Contains(err.Error(), "is not usable") {
+		t.Fatalf("expected missing model error, got: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "model.onnx")
+	if err := os.WriteFile(path, []byte("model"), 0o600); err != nil {
+		t.Fatalf("failed to write model: %v", err)
+	}
+	sum := sha256.Sum256([]byte("model"))
+	want := hex.EncodeToString(sum[:])
+	for i := 0; i < 2; i++ {
+		got, err := ModelSHA256(path)
+		if err != nil {
+			t.Fatalf("ModelSHA256 failed: %v", err)
+		}
+		if got != want {
+			t.Fatalf("unexpected hash: got %q, want %q", got, want)
+		}
+	}
+}
+
+// countModelInfoQueries starts tb with an empty model info cache and counts the
+// introspection sessions created until tb ends.
+func countModelInfoQueries(tb testing.TB) func() int64 {
+	tb.Helper()
+	var queries atomic.Int64
+	previousCache, previousQuery := defaultModelInfoCache, queryModelInfo
+	defaultModelInfoCache = newModelInfoCache()
+	queryModelInfo = func(modelPath string) ([]InputOutputInfo, []InputOutputInfo, error) {
+		queries.Add(1)
+		return previousQuery(modelPath)
+	}
+	tb.Cleanup(func() {
+		defaultModelInfoCache, queryModelInfo = previousCache, previousQuery
+	})
+	return queries.Load
+}
+
+func TestGetInputOutputInfoCachesRepeatedIntrospection(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	modelPath := writeLinearTestModel(t, "features", "projected", 3, 2, make([]float32, 6))
+
+	queries := countModelInfoQueries(t)
+	for i := 0; i < 2; i++ {
+		inputs, outputs, err := GetInputOutputInfo(modelPath)
+		if err != nil {
+			t.Fatalf("GetInputOutputInfo call %d failed: %v", i, err)
+		}
+		if len(inputs) != 1 || inputs[0].Name != "features" || len(outputs) != 1 || outputs[0].Name != "projected" {
+			t.Fatalf("unexpected model info: inputs=%+v outputs=%+v", inputs, outputs)
+		}
+	}
+	if got := queries(); got != 1 {
+		t.Fatalf("unexpected introspection session count: got %d, want 1", got)
+	}
+}
+
+func TestDescribeToleratesSymbolicDimensionFailure(t *testing.T) {
+	// The fake status is not a real ORT status, so it must not reach ReleaseStatus.
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	fns := &modelInfoAPI{
+		getOnnxTypeFromTypeInfo: func(_ uintptr, out *int32) uintptr {
+			*out = int32(ONNXTypeTensor)
+			return 0
+		},
+		castTypeInfoToTensorInfo: func(_ uintptr, out *uintptr) uintptr {
+			*out = 1
+			return 0
+		},
+		getTensorElementType: func(_ uintptr, out *int32) uintptr {
+			*out = int32(TensorElementDataTypeFloat)
+			return 0
+		},
+		getDimensionsCount: func(_ uintptr, out *uintptr) uintptr {
+			*out = 2
+			return 0
+		},
+		getDimensions: func(_ uintptr, dims *int64, count uintptr) uintptr {
+			copy(unsafe.Slice(dims, count), []int64{-1, 384})
+			return 0
+		},
+		getSymbolicDimensions: func(uintptr, *uintptr, uintptr) uintptr {
+			return 1
+		},
+	}
+
+	info, err := fns.describe(1)
+	if err != nil {
+		t.Fatalf("expected a symbolic dimension failure to be non-fatal, got: %v", err)
+	}
+	if len(info.Dimensions) != 2 || info.Dimensions[0] != -1 || info.Dimensions[1] != 384 {
+		t.Fatalf("unexpected dimensions: %v", info.Dimensions)
+	}
+	if len(info.SymbolicDimensions) != 2 || info.SymbolicDimensions[0] != "" || info.SymbolicDimensions[1] != "" {
+		t.Fatalf("expected empty symbolic dimension names, got %q", info.SymbolicDimensions)
+	}
+}
@@ -0,0 +1,140 @@
+package ort
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Minimal ONNX protobuf writer for tiny test models, so tests do not depend on
+// downloaded assets. Field numbers follow onnx.proto.
+
+func protoVarint(buf []byte, v uint64) []byte {
+	return binary.AppendUvarint(buf, v)
+}
+
+func protoTag(buf []byte, field int, wireType int) []byte {
+	return protoVarint(buf, uint64(field)<<3|uint64(wireType))
+}
+
+func protoIntField(buf []byte, field int, v int64) []byte {
+	buf = protoTag(buf, field, 0)
+	return protoVarint(buf, uint64(v))
+}
+
+func protoBytesField(buf []byte, field int, v []byte) []byte {
+	buf = protoTag(buf, field, 2)
+	buf = protoVarint(buf, uint64(len(v)))
+	return append(buf, v...)
+}
+
+func protoStringField(buf []byte, field int, v string) []byte {
+	return protoBytesField(buf, field, []byte(v))
+}
+
+// onnxValueInfo encodes a ValueInfoProto for a tensor; dims <= 0 become symbolic "N".
+func onnxValueInfo(name string, elemType TensorElementDataType, dims ...int64) []byte {
+	var shape []byte
+	for _, dim := range dims {
+		var d []byte
+		if dim > 0 {
+			d = protoIntField(d, 1, dim)
+		} else {
+			d = protoStringField(d, 2, "N")
+		}
+		shape = protoBytesField(shape, 1, d)
+	}
+	var tensorType []byte
+	tensorType = protoIntField(tensorType, 1, int64(elemType))
+	tensorType = protoBytesField(tensorType, 2, shape)
+	var typeProto []byte
+	typeProto = protoBytesField(typeProto, 1, tensorType)
+
+	var info []byte
+	info = protoStringField(info, 1, name)
+	return protoBytesField(info, 2, typeProto)
+}
+
+func onnxFloatInitializer(name string, dims []int64, values []float32) []byte {
+	var tensor []byte
+	for _, dim := range dims {
+		tensor = protoIntField(tensor, 1, dim)
+	}
+	tensor = protoIntField(tensor, 2, int64(TensorElementDataTypeFloat))
+	tensor = protoStringField(tensor, 8, name)
+	raw := make([]byte, 0, 4*len(values))
+	for _, v := range values {
+		raw = binary.LittleEndian.AppendUint32(raw, math.Float32bits(v))
+	}
+	return protoBytesField(tensor, 9, raw)
+}
+
+func onnxNode(opType string, inputs []string, outputs []string) []byte {
+	var node []byte
+	for _, in := range inputs {
+		node = protoStringField(node, 1, in)
+	}
+	for _, out := range outputs {
+		node = protoStringField(node, 2, out)
+	}
+	return protoStringField(node, 4, opType)
+}
+
+// writeLinearTestModel writes Y = MatMul(X, W) with X [N, inputDim] and W [inputDim, outputDim]
+// (row-major) and returns the model path.
+func writeLinearTestModel(tb testing.TB, inputName, outputName string, inputDim, outputDim int64, weights []float32) string {
+	tb.Helper()
+	return writeLinearTestModelWithOpset(tb, inputName, outputName, inputDim, outputDim, weights, 13)
+}
+
+func writeLinearTestModelWithOpset(tb testing.TB, inputName, outputName string, inputDim, outputDim int64, weights []float32, opsetVersion int64) string {
+	tb.Helper()
+
+	var graph []byte
+	graph = protoBytesField(graph, 1, onnxNode("MatMul", []string{inputName, "W"}, []string{outputName}))
+	graph = protoStringField(graph, 2, "linear")
+	graph = protoBytesField(graph, 5, onnxFloatInitializer("W", []int64{inputDim, outputDim}, weights))
+	graph = protoBytesField(graph, 11, onnxValueInfo(inputName, TensorElementDataTypeFloat, -1, inputDim))
+	graph = protoBytesField(graph, 12, onnxValueInfo(outputName, TensorElementDataTypeFloat, -1, outputDim))
+
+	var opset []byte
+	opset = protoIntField(opset, 2, opsetVersion)
+
+	var model []byte
+	model = protoIntField(model, 1, 8)
+	model = protoBytesField(model, 7, graph)
+	model = protoBytesField(model, 8, opset)
+
+	path := filepath.Join(tb.TempDir(), "linear.onnx")
+	if err := os.WriteFile(path, model, 0o600); err != nil {
+		tb.Fatalf("failed to write test model: %v", err)
+	}
+	return path
+}
+
+// writeIdentityTestModel writes Y = Identity(X) with X and Y of elemType and shape [N, dim].
+func writeIdentityTestModel(tb testing.TB, elemType TensorElementDataType, dim int64) string {
+	tb.Helper()
+
+	var graph []byte
+	graph = protoBytesField(graph, 1, onnxNode("Identity", []string{"x"}, []string{"y"}))
+	graph = protoStringField(graph, 2, "identity")
+	graph = protoBytesField(graph, 11, onnxValueInfo("x", elemType, -1, dim))
+	graph = protoBytesField(graph, 12, onnxValueInfo("y", elemType, -1, dim))
+
+	var opset []byte
+	opset = protoIntField(opset, 2, 13)
+
+	var model []byte
+	model = protoIntField(model, 1, 8)
+	model = protoBytesField(model, 7, graph)
+	model = protoBytesField(model, 8, opset)
+
+	path := filepath.Join(tb.TempDir(), "identity.onnx")
+	if err := os.WriteFile(path, model, 0o600); err != nil {
+		tb.Fatalf("failed to write test model: %v", err)
+	}
+	return path
+}
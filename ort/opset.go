@@ -0,0 +1,187 @@
+package ort
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/amikos-tech/pure-onnx/internal/onnxproto"
+)
+
+// runtimeOpsetSupport lists, in ascending order, the first ONNX Runtime release that
+// supports each default-domain (ai.onnx) opset, per the ONNX Runtime compatibility table.
+var runtimeOpsetSupport = []struct {
+	opset          int64
+	runtimeVersion string
+}{
+	{opset: 11, runtimeVersion: "1.0.0"},
+	{opset: 12, runtimeVersion: "1.3.0"},
+	{opset: 13, runtimeVersion: "1.6.0"},
+	{opset: 14, runtimeVersion: "1.8.0"},
+	{opset: 15, runtimeVersion: "1.9.0"},
+	{opset: 16, runtimeVersion: "1.11.0"},
+	{opset: 17, runtimeVersion: "1.12.0"},
+	{opset: 18, runtimeVersion: "1.14.0"},
+	{opset: 19, runtimeVersion: "1.15.0"},
+	{opset: 20, runtimeVersion: "1.17.0"},
+	{opset: 21, runtimeVersion: "1.18.0"},
+	{opset: 22, runtimeVersion: "1.21.0"},
+}
+
+// ONNX ModelProto field numbers read by ReadModelOpset.
+const (
+	modelProtoOpsetImportField = 8
+	opsetIDDomainField         = 1
+	opsetIDVersionField        = 2
+)
+
+// maxOpsetIDSize bounds a single OperatorSetIdProto; real entries are a few dozen bytes.
+const maxOpsetIDSize = 1 << 16
+
+// ReadModelOpset returns the default-domain (ai.onnx) opset version a model imports.
+// Only the top-level ModelProto fields are read; the graph is skipped without being
+// loaded into memory.
+func ReadModelOpset(modelPath string) (int64, error) {
+	file, err := os.Open(modelPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open model %q: %w", modelPath, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat model %q: %w", modelPath, err)
+	}
+
+	opset, err := readModelOpset(file, uint64(info.Size()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read opset of model %q: %w", modelPath, err)
+	}
+	return opset, nil
+}
+
+// CheckModelOpsetCompatibility returns an error when runtimeVersion (x.y.z, for example
+// GetVersionString()) is known to be too old for the model's ai.onnx opset.
+// Opsets newer than this package's compatibility table require at least the newest
+// runtime release in the table.
+func CheckModelOpsetCompatibility(modelPath string, runtimeVersion string) error {
+	version, err := normalizeRuntimeVersion(runtimeVersion)
+	if err != nil {
+		return err
+	}
+	opset, err := ReadModelOpset(modelPath)
+	if err != nil {
+		return err
+	}
+	required, known := minimumRuntimeVersionForOpset(opset)
+	if compareRuntimeVersions(version, required) >= 0 {
+		return nil
+	}
+	if !known {
+		return fmt.Errorf("model %q requires ONNX opset %d, which is newer than every opset this package knows of; upgrade ONNX Runtime %s to >= %s", modelPath, opset, version, required)
+	}
+	return fmt.Errorf("model %q requires ONNX opset %d, which ONNX Runtime %s does not support; upgrade ONNX Runtime to >= %s", modelPath, opset, version, required)
+}
+
+// minimumRuntimeVersionForOpset returns the first runtime release supporting opset.
+// For opsets newer than the compatibility table it returns the newest release in the
+// table, a lower bound only, and reports false.
+func minimumRuntimeVersionForOpset(opset int64) (string, bool) {
+	if opset <= runtimeOpsetSupport[0].opset {
+		return runtimeOpsetSupport[0].runtimeVersion, true
+	}
+	for _, entry := range runtimeOpsetSupport {
+		if entry.opset == opset {
+			return entry.runtimeVersion, true
+		}
+	}
+	return runtimeOpsetSupport[len(runtimeOpsetSupport)-1].runtimeVersion, false
+}
+
+// readModelOpset scans the top-level fields of a serialized ModelProto of size bytes.
+// Large fields (the graph, in particular) are skipped by seeking.
+func readModelOpset(file io.ReadSeeker, size uint64) (int64, error) {
+	s := onnxproto.NewScanner(file, size)
+	opset := int64(-1)
+	for {
+		key, err := s.ReadUvarint()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("malformed model protobuf: %w", err)
+		}
+		field, wireType := key>>3, key&0x7
+		if field != modelProtoOpsetImportField || wireType != 2 {
+			if err := s.SkipField(wireType); err != nil {
+				return 0, fmt.Errorf("malformed model protobuf: %w", err)
+			}
+			continue
+		}
+		length, err := s.ReadUvarint()
+		if err != nil {
+			return 0, fmt.Errorf("malformed model protobuf: %w", err)
+		}
+		if length > maxOpsetIDSize {
+			return 0, fmt.Errorf("malformed model protobuf: opset_import entry of %d bytes", length)
+		}
+		entry, err := s.ReadBytes(length, maxOpsetIDSize)
+		if err != nil {
+			return 0, fmt.Errorf("malformed model protobuf: %w", err)
+		}
+		domain, version, err := parseOpsetID(entry)
+		if err != nil {
+			return 0, err
+		}
+		if (domain == "" || domain == "ai.onnx") && version > opset {
+			opset = version
+		}
+	}
+	if opset < 0 {
+		return 0, fmt.Errorf("model does not import the ai.onnx opset")
+	}
+	return opset, nil
+}
+
+// parseOpsetID decodes an OperatorSetIdProto {domain, version}.
+func parseOpsetID(data []byte) (string, int64, error) {
+	var (
+		domain  string
+		version int64
+	)
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return "", 0, fmt.Errorf("malformed opset_import entry")
+		}
+		data = data[n:]
+		field, wireType := key>>3, key&0x7
+		switch {
+		case wireType == 0:
+			value, n := binary.Uvarint(data)
+			if n <= 0 {
+				return "", 0, fmt.Errorf("malformed opset_import entry")
+			}
+			data = data[n:]
+			if field == opsetIDVersionField {
+				version = int64(value)
+			}
+		case wireType == 2:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return "", 0, fmt.Errorf("malformed opset_import entry")
+			}
+			value := data[n : n+int(length)]
+			data = data[n+int(length):]
+			if field == opsetIDDomainField {
+				domain = string(value)
+			}
+		default:
+			return "", 0, fmt.Errorf("malformed opset_import entry: unsupported wire type %d", wireType)
+		}
+	}
+	return domain, version, nil
+}
@@ -0,0 +1,154 @@
+package ort
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeOpsetTestModel(t *testing.T, opsets map[string]int64) string {
+	t.Helper()
+
+	var model []byte
+	model = protoIntField(model, 1, 8)
+	model = protoBytesField(model, 7, protoStringField(nil, 2, "empty"))
+	for domain, version := range opsets {
+		var opset []byte
+		if domain != "" {
+			opset = protoStringField(opset, 1, domain)
+		}
+		opset = protoIntField(opset, 2, version)
+		model = protoBytesField(model, 8, opset)
+	}
+
+	path := filepath.Join(t.TempDir(), "opset.onnx")
+	if err := os.WriteFile(path, model, 0o600); err != nil {
+		t.Fatalf("failed to write test model: %v", err)
+	}
+	return path
+}
+
+func TestReadModelOpset(t *testing.T) {
+	tests := []struct {
+		name    string
+		opsets  map[string]int64
+		want    int64
+		wantErr string
+	}{
+		{name: "default domain", opsets: map[string]int64{"": 17}, want: 17},
+		{name: "explicit ai.onnx domain", opsets: map[string]int64{"ai.onnx": 19}, want: 19},
+		{name: "ignores custom domains", opsets: map[string]int64{"": 14, "com.microsoft": 1}, want: 14},
+		{name: "no ai.onnx opset", opsets: map[string]int64{"com.microsoft": 1}, wantErr: "does not import the ai.onnx opset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadModelOpset(writeOpsetTestModel(t, tt.opsets))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("unexpected opset: got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadModelOpsetSkipsLargeGraph(t *testing.T) {
+	// The initializer is far larger than the read buffer, so the graph is skipped by seeking.
+	weights := make([]float32, 4096*3)
+	modelPath := writeLinearTestModelWithOpset(t, "features", "projected", 4096, 3, weights, 20)
+
+	got, err := ReadModelOpset(modelPath)
+	if err != nil {
+		t.Fatalf("ReadModelOpset failed: %v", err)
+	}
+	if got != 20 {
+		t.Fatalf("unexpected opset: got %d, want 20", got)
+	}
+}
+
+func TestReadModelOpsetMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "truncated.onnx")
+	// Field 8, length-delimited, declares 5 bytes but provides 1.
+	if err := os.WriteFile(path, []byte{0x42, 0x05, 0x10}, 0o600); err != nil {
+		t.Fatalf("failed to write test model: %v", err)
+	}
+	if _, err := ReadModelOpset(path); err == nil || !strings.Contains(err.Error(), "malformed model protobuf") {
+		t.Fatalf("expected malformed model error, got: %v", err)
+	}
+	if _, err := ReadModelOpset(filepath.Join(t.TempDir(), "missing.onnx")); err == nil || !strings.Contains(err.Error(), "failed to open model") {
+		t.Fatalf("expected open error, got: %v", err)
+	}
+}
+
+func TestMinimumRuntimeVersionForOpset(t *testing.T) {
+	tests := []struct {
+		opset  int64
+		want   string
+		wantOK bool
+	}{
+		{opset: 7, want: "1.0.0", wantOK: true},
+		{opset: 13, want: "1.6.0", wantOK: true},
+		{opset: 20, want: "1.17.0", wantOK: true},
+		{opset: 22, want: "1.21.0", wantOK: true},
+		{opset: 23, want: "1.21.0", wantOK: false},
+		{opset: 99, want: "1.21.0", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := minimumRuntimeVersionForOpset(tt.opset)
+		if got != tt.want || ok != tt.wantOK {
+			t.Fatalf("minimumRuntimeVersionForOpset(%d) = (%q, %v), want (%q, %v)", tt.opset, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestCheckModelOpsetCompatibility(t *testing.T) {
+	modelPath := writeOpsetTestModel(t, map[string]int64{"": 20})
+
+	err := CheckModelOpsetCompatibility(modelPath, "1.16.3")
+	if err == nil || !strings.Contains(err.Error(), "requires ONNX opset 20") || !strings.Contains(err.Error(), "upgrade ONNX Runtime to >= 1.17.0") {
+		t.Fatalf("expected actionable upgrade error, got: %v", err)
+	}
+	if err := CheckModelOpsetCompatibility(modelPath, "1.17.0"); err != nil {
+		t.Fatalf("expected 1.17.0 to support opset 20, got: %v", err)
+	}
+	futurePath := writeOpsetTestModel(t, map[string]int64{"": 23})
+	for _, runtimeVersion := range []string{"1.16.3", "1.20.1"} {
+		err := CheckModelOpsetCompatibility(futurePath, runtimeVersion)
+		if err == nil || !strings.Contains(err.Error(), "requires ONNX opset 23") || !strings.Contains(err.Error(), "newer than every opset this package knows") {
+			t.Fatalf("expected opset 23 to be rejected on %s, got: %v", runtimeVersion, err)
+		}
+	}
+	if err := CheckModelOpsetCompatibility(futurePath, "1.21.0"); err != nil {
+		t.Fatalf("expected opset 23 to be allowed on the newest known runtime, got: %v", err)
+	}
+	if err := CheckModelOpsetCompatibility(modelPath, "latest"); err == nil || !strings.Contains(err.Error(), "format x.y.z") {
+		t.Fatalf("expected version format error, got: %v", err)
+	}
+}
+
+func TestBootstrapModelOpsetCheckFailsBeforeDownload(t *testing.T) {
+	clearBootstrapEnv(t)
+
+	modelPath := writeOpsetTestModel(t, map[string]int64{"": 20})
+	_, err := EnsureOnnxRuntimeSharedLibrary(
+		WithBootstrapCacheDir(t.TempDir()),
+		WithBootstrapVersion("1.16.0"),
+		WithBootstrapDisableDownload(true),
+		WithBootstrapModelOpsetCheck(modelPath),
+	)
+	if err == nil || !strings.Contains(err.Error(), "upgrade ONNX Runtime to >= 1.17.0") {
+		t.Fatalf("expected opset upgrade error, got: %v", err)
+	}
+
+	if err := WithBootstrapModelOpsetCheck(" ")(&bootstrapConfig{}); err == nil {
+		t.Fatalf("expected empty model path to be rejected")
+	}
+}
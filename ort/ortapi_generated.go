@@ -6,317 +6,356 @@
 package ort
 
 // Auto-generated from: internal/c_api/onnxruntime_c_api.h
-// Generated on: 2025-10-15T09:46:35+03:00
+// Generated on: 2026-10-15T08:57:49Z
 // Generator: tools/gen_ortapi.go
 // Found OrtApi struct at line 776
-// Parsed 305 function pointers
+// Parsed 315 function pointers
 //
 // OrtApi represents the ONNX Runtime C API function pointers
 // DO NOT EDIT MANUALLY - regenerate using tools/gen_ortapi.go
 type OrtApi struct {
-	CreateStatus                                        uintptr // Function 1
-	GetErrorCode                                        uintptr // Function 2
-	GetErrorMessage                                     uintptr // Function 3
-	CreateEnv                                           uintptr // Function 4
-	CreateEnvWithCustomLogger                           uintptr // Function 5
-	EnableTelemetryEvents                               uintptr // Function 6
-	DisableTelemetryEvents                              uintptr // Function 7
-	CreateSession                                       uintptr // Function 8
-	CreateSessionFromArray                              uintptr // Function 9
-	Run                                                 uintptr // Function 10
-	CreateSessionOptions                                uintptr // Function 11
-	SetOptimizedModelFilePath                           uintptr // Function 12
-	CloneSessionOptions                                 uintptr // Function 13
-	SetSessionExecutionMode                             uintptr // Function 14
-	EnableProfiling                                     uintptr // Function 15
-	DisableProfiling                                    uintptr // Function 16
-	EnableMemPattern                                    uintptr // Function 17
-	DisableMemPattern                                   uintptr // Function 18
-	EnableCpuMemArena                                   uintptr // Function 19
-	DisableCpuMemArena                                  uintptr // Function 20
-	SetSessionLogId                                     uintptr // Function 21
-	SetSessionLogVerbosityLevel                         uintptr // Function 22
-	SetSessionLogSeverityLevel                          uintptr // Function 23
-	SetSessionGraphOptimizationLevel                    uintptr // Function 24
-	SetIntraOpNumThreads                                uintptr // Function 25
-	SetInterOpNumThreads                                uintptr // Function 26
-	CreateCustomOpDomain                                uintptr // Function 27
-	CustomOpDomain_Add                                  uintptr // Function 28
-	AddCustomOpDomain                                   uintptr // Function 29
-	RegisterCustomOpsLibrary                            uintptr // Function 30
-	SessionGetInputCount                                uintptr // Function 31
-	SessionGetOutputCount                               uintptr // Function 32
-	SessionGetOverridableInitializerCount               uintptr // Function 33
-	SessionGetInputTypeInfo                             uintptr // Function 34
-	SessionGetOutputTypeInfo                            uintptr // Function 35
-	SessionGetOverridableInitializerTypeInfo            uintptr // Function 36
-	SessionGetInputName                                 uintptr // Function 37
-	SessionGetOutputName                                uintptr // Function 38
-	SessionGetOverridableInitializerName                uintptr // Function 39
-	CreateRunOptions                                    uintptr // Function 40
-	RunOptionsSetRunLogVerbosityLevel                   uintptr // Function 41
-	RunOptionsSetRunLogSeverityLevel                    uintptr // Function 42
-	RunOptionsSetRunTag                                 uintptr // Function 43
-	RunOptionsGetRunLogVerbosityLevel                   uintptr // Function 44
-	RunOptionsGetRunLogSeverityLevel                    uintptr // Function 45
-	RunOptionsGetRunTag                                 uintptr // Function 46
-	RunOptionsSetTerminate                              uintptr // Function 47
-	RunOptionsUnsetTerminate                            uintptr // Function 48
-	CreateTensorAsOrtValue                              uintptr // Function 49
-	CreateTensorWithDataAsOrtValue                      uintptr // Function 50
-	IsTensor                                            uintptr // Function 51
-	GetTensorMutableData                                uintptr // Function 52
-	FillStringTensor                                    uintptr // Function 53
-	GetStringTensorDataLength                           uintptr // Function 54
-	GetStringTensorContent                              uintptr // Function 55
-	CastTypeInfoToTensorInfo                            uintptr // Function 56
-	GetOnnxTypeFromTypeInfo                             uintptr // Function 57
-	CreateTensorTypeAndShapeInfo                        uintptr // Function 58
-	SetTensorElementType                                uintptr // Function 59
-	SetDimensions                                       uintptr // Function 60
-	GetTensorElementType                                uintptr // Function 61
-	GetDimensionsCount                                  uintptr // Function 62
-	GetDimensions                                       uintptr // Function 63
-	GetSymbolicDimensions                               uintptr // Function 64
-	GetTensorShapeElementCount                          uintptr // Function 65
-	GetTensorTypeAndShape                               uintptr // Function 66
-	GetTypeInfo                                         uintptr // Function 67
-	GetValueType                                        uintptr // Function 68
-	CreateMemoryInfo                                    uintptr // Function 69
-	CreateCpuMemoryInfo                                 uintptr // Function 70
-	CompareMemoryInfo                                   uintptr // Function 71
-	MemoryInfoGetName                                   uintptr // Function 72
-	MemoryInfoGetId                                     uintptr // Function 73
-	MemoryInfoGetMemType                                uintptr // Function 74
-	MemoryInfoGetType                                   uintptr // Function 75
-	AllocatorAlloc                                      uintptr // Function 76
-	AllocatorFree                                       uintptr // Function 77
-	AllocatorGetInfo                                    uintptr // Function 78
-	GetAllocatorWithDefaultOptions                      uintptr // Function 79
-	AddFreeDimensionOverride                            uintptr // Function 80
-	GetValue                                            uintptr // Function 81
-	GetValueCount                                       uintptr // Function 82
-	CreateValue                                         uintptr // Function 83
-	CreateOpaqueValue                                   uintptr // Function 84
-	GetOpaqueValue                                      uintptr // Function 85
-	KernelInfoGetAttribute_float                        uintptr // Function 86
-	KernelInfoGetAttribute_int64                        uintptr // Function 87
-	KernelInfoGetAttribute_string                       uintptr // Function 88
-	KernelContext_GetInputCount                         uintptr // Function 89
-	KernelContext_GetOutputCount                        uintptr // Function 90
-	KernelContext_GetInput                              uintptr // Function 91
-	KernelContext_GetOutput                             uintptr // Function 92
-	ReleaseEnv                                          uintptr // Function 93
-	ReleaseStatus                                       uintptr // Function 94
-	ReleaseMemoryInfo                                   uintptr // Function 95
-	ReleaseSession                                      uintptr // Function 96
-	ReleaseValue                                        uintptr // Function 97
-	ReleaseRunOptions                                   uintptr // Function 98
-	ReleaseTypeInfo                                     uintptr // Function 99
-	ReleaseTensorTypeAndShapeInfo                       uintptr // Function 100
-	ReleaseSessionOptions                               uintptr // Function 101
-	ReleaseCustomOpDomain                               uintptr // Function 102
-	GetDenotationFromTypeInfo                           uintptr // Function 103
-	CastTypeInfoToMapTypeInfo                           uintptr // Function 104
-	CastTypeInfoToSequenceTypeInfo                      uintptr // Function 105
-	GetMapKeyType                                       uintptr // Function 106
-	GetMapValueType                                     uintptr // Function 107
-	GetSequenceElementType                              uintptr // Function 108
-	ReleaseMapTypeInfo                                  uintptr // Function 109
-	ReleaseSequenceTypeInfo                             uintptr // Function 110
-	SessionEndProfiling                                 uintptr // Function 111
-	SessionGetModelMetadata                             uintptr // Function 112
-	ModelMetadataGetProducerName                        uintptr // Function 113
-	ModelMetadataGetGraphName                           uintptr // Function 114
-	ModelMetadataGetDomain                              uintptr // Function 115
-	ModelMetadataGetDescription                         uintptr // Function 116
-	ModelMetadataLookupCustomMetadataMap                uintptr // Function 117
-	ModelMetadataGetVersion                             uintptr // Function 118
-	ReleaseModelMetadata                                uintptr // Function 119
-	CreateEnvWithGlobalThreadPools                      uintptr // Function 120
-	DisablePerSessionThreads                            uintptr // Function 121
-	CreateThreadingOptions                              uintptr // Function 122
-	ReleaseThreadingOptions                             uintptr // Function 123
-	ModelMetadataGetCustomMetadataMapKeys               uintptr // Function 124
-	AddFreeDimensionOverrideByName                      uintptr // Function 125
-	GetAvailableProviders                               uintptr // Function 126
-	ReleaseAvailableProviders                           uintptr // Function 127
-	GetStringTensorElementLength                        uintptr // Function 128
-	GetStringTensorElement                              uintptr // Function 129
-	FillStringTensorElement                             uintptr // Function 130
-	AddSessionConfigEntry                               uintptr // Function 131
-	CreateAllocator                                     uintptr // Function 132
-	ReleaseAllocator                                    uintptr // Function 133
-	RunWithBinding                                      uintptr // Function 134
-	CreateIoBinding                                     uintptr // Function 135
-	ReleaseIoBinding                                    uintptr // Function 136
-	BindInput                                           uintptr // Function 137
-	BindOutput                                          uintptr // Function 138
-	BindOutputToDevice                                  uintptr // Function 139
-	GetBoundOutputNames                                 uintptr // Function 140
-	GetBoundOutputValues                                uintptr // Function 141
-	ClearBoundInputs                                    uintptr // Function 142
-	ClearBoundOutputs                                   uintptr // Function 143
-	TensorAt                                            uintptr // Function 144
-	CreateAndRegisterAllocator                          uintptr // Function 145
-	SetLanguageProjection                               uintptr // Function 146
-	SessionGetProfilingStartTimeNs                      uintptr // Function 147
-	SetGlobalIntraOpNumThreads                          uintptr // Function 148
-	SetGlobalInterOpNumThreads                          uintptr // Function 149
-	SetGlobalSpinControl                                uintptr // Function 150
-	AddInitializer                                      uintptr // Function 151
-	CreateEnvWithCustomLoggerAndGlobalThreadPools       uintptr // Function 152
-	SessionOptionsAppendExecutionProvider_CUDA          uintptr // Function 153
-	SessionOptionsAppendExecutionProvider_ROCM          uintptr // Function 154
-	SessionOptionsAppendExecutionProvider_OpenVINO      uintptr // Function 155
-	SetGlobalDenormalAsZero                             uintptr // Function 156
-	CreateArenaCfg                                      uintptr // Function 157
-	ReleaseArenaCfg                                     uintptr // Function 158
-	ModelMetadataGetGraphDescription                    uintptr // Function 159
-	SessionOptionsAppendExecutionProvider_TensorRT      uintptr // Function 160
-	SetCurrentGpuDeviceId                               uintptr // Function 161
-	GetCurrentGpuDeviceId                               uintptr // Function 162
-	KernelInfoGetAttributeArray_float                   uintptr // Function 163
-	KernelInfoGetAttributeArray_int64                   uintptr // Function 164
-	CreateArenaCfgV2                                    uintptr // Function 165
-	AddRunConfigEntry                                   uintptr // Function 166
-	CreatePrepackedWeightsContainer                     uintptr // Function 167
-	ReleasePrepackedWeightsContainer                    uintptr // Function 168
-	CreateSessionWithPrepackedWeightsContainer          uintptr // Function 169
-	CreateSessionFromArrayWithPrepackedWeightsContainer uintptr // Function 170
-	SessionOptionsAppendExecutionProvider_TensorRT_V2   uintptr // Function 171
-	CreateTensorRTProviderOptions                       uintptr // Function 172
-	UpdateTensorRTProviderOptions                       uintptr // Function 173
-	GetTensorRTProviderOptionsAsString                  uintptr // Function 174
-	ReleaseTensorRTProviderOptions                      uintptr // Function 175
-	EnableOrtCustomOps                                  uintptr // Function 176
-	RegisterAllocator                                   uintptr // Function 177
-	UnregisterAllocator                                 uintptr // Function 178
-	IsSparseTensor                                      uintptr // Function 179
-	CreateSparseTensorAsOrtValue                        uintptr // Function 180
-	FillSparseTensorCoo                                 uintptr // Function 181
-	FillSparseTensorCsr                                 uintptr // Function 182
-	FillSparseTensorBlockSparse                         uintptr // Function 183
-	CreateSparseTensorWithValuesAsOrtValue              uintptr // Function 184
-	UseCooIndices                                       uintptr // Function 185
-	UseCsrIndices                                       uintptr // Function 186
-	UseBlockSparseIndices                               uintptr // Function 187
-	GetSparseTensorFormat                               uintptr // Function 188
-	GetSparseTensorValuesTypeAndShape                   uintptr // Function 189
-	GetSparseTensorValues                               uintptr // Function 190
-	GetSparseTensorIndicesTypeShape                     uintptr // Function 191
-	GetSparseTensorIndices                              uintptr // Function 192
-	HasValue                                            uintptr // Function 193
-	KernelContext_GetGPUComputeStream                   uintptr // Function 194
-	GetTensorMemoryInfo                                 uintptr // Function 195
-	GetExecutionProviderApi                             uintptr // Function 196
-	SessionOptionsSetCustomCreateThreadFn               uintptr // Function 197
-	SessionOptionsSetCustomThreadCreationOptions        uintptr // Function 198
-	SessionOptionsSetCustomJoinThreadFn                 uintptr // Function 199
-	SetGlobalCustomCreateThreadFn                       uintptr // Function 200
-	SetGlobalCustomThreadCreationOptions                uintptr // Function 201
-	SetGlobalCustomJoinThreadFn                         uintptr // Function 202
-	SynchronizeBoundInputs                              uintptr // Function 203
-	SynchronizeBoundOutputs                             uintptr // Function 204
-	SessionOptionsAppendExecutionProvider_CUDA_V2       uintptr // Function 205
-	CreateCUDAProviderOptions                           uintptr // Function 206
-	UpdateCUDAProviderOptions                           uintptr // Function 207
-	GetCUDAProviderOptionsAsString                      uintptr // Function 208
-	ReleaseCUDAProviderOptions                          uintptr // Function 209
-	SessionOptionsAppendExecutionProvider_MIGraphX      uintptr // Function 210
-	AddExternalInitializers                             uintptr // Function 211
-	CreateOpAttr                                        uintptr // Function 212
-	ReleaseOpAttr                                       uintptr // Function 213
-	CreateOp                                            uintptr // Function 214
-	InvokeOp                                            uintptr // Function 215
-	ReleaseOp                                           uintptr // Function 216
-	SessionOptionsAppendExecutionProvider               uintptr // Function 217
-	CopyKernelInfo                                      uintptr // Function 218
-	ReleaseKernelInfo                                   uintptr // Function 219
-	SessionOptionsAppendExecutionProvider_CANN          uintptr // Function 220
-	CreateCANNProviderOptions                           uintptr // Function 221
-	UpdateCANNProviderOptions                           uintptr // Function 222
-	GetCANNProviderOptionsAsString                      uintptr // Function 223
-	ReleaseCANNProviderOptions                          uintptr // Function 224
-	MemoryInfoGetDeviceType                             uintptr // Function 225
-	UpdateEnvWithCustomLogLevel                         uintptr // Function 226
-	SetGlobalIntraOpThreadAffinity                      uintptr // Function 227
-	RegisterCustomOpsLibrary_V2                         uintptr // Function 228
-	RegisterCustomOpsUsingFunction                      uintptr // Function 229
-	KernelInfo_GetInputCount                            uintptr // Function 230
-	KernelInfo_GetOutputCount                           uintptr // Function 231
-	KernelInfo_GetInputName                             uintptr // Function 232
-	KernelInfo_GetOutputName                            uintptr // Function 233
-	KernelInfo_GetInputTypeInfo                         uintptr // Function 234
-	KernelInfo_GetOutputTypeInfo                        uintptr // Function 235
-	KernelInfoGetAttribute_tensor                       uintptr // Function 236
-	HasSessionConfigEntry                               uintptr // Function 237
-	GetSessionConfigEntry                               uintptr // Function 238
-	SessionOptionsAppendExecutionProvider_Dnnl          uintptr // Function 239
-	CreateDnnlProviderOptions                           uintptr // Function 240
-	UpdateDnnlProviderOptions                           uintptr // Function 241
-	GetDnnlProviderOptionsAsString                      uintptr // Function 242
-	ReleaseDnnlProviderOptions                          uintptr // Function 243
-	KernelInfo_GetNodeName                              uintptr // Function 244
-	KernelInfo_GetLogger                                uintptr // Function 245
-	KernelContext_GetLogger                             uintptr // Function 246
-	Logger_LogMessage                                   uintptr // Function 247
-	Logger_GetLoggingSeverityLevel                      uintptr // Function 248
-	KernelInfoGetConstantInput_tensor                   uintptr // Function 249
-	CastTypeInfoToOptionalTypeInfo                      uintptr // Function 250
-	GetOptionalContainedTypeInfo                        uintptr // Function 251
-	GetResizedStringTensorElementBuffer                 uintptr // Function 252
-	KernelContext_GetAllocator                          uintptr // Function 253
-	GetBuildInfoString                                  uintptr // Function 254
-	CreateROCMProviderOptions                           uintptr // Function 255
-	UpdateROCMProviderOptions                           uintptr // Function 256
-	GetROCMProviderOptionsAsString                      uintptr // Function 257
-	ReleaseROCMProviderOptions                          uintptr // Function 258
-	CreateAndRegisterAllocatorV2                        uintptr // Function 259
-	RunAsync                                            uintptr // Function 260
-	UpdateTensorRTProviderOptionsWithValue              uintptr // Function 261
-	GetTensorRTProviderOptionsByName                    uintptr // Function 262
-	UpdateCUDAProviderOptionsWithValue                  uintptr // Function 263
-	GetCUDAProviderOptionsByName                        uintptr // Function 264
-	KernelContext_GetResource                           uintptr // Function 265
-	SetUserLoggingFunction                              uintptr // Function 266
-	ShapeInferContext_GetInputCount                     uintptr // Function 267
-	ShapeInferContext_GetInputTypeShape                 uintptr // Function 268
-	ShapeInferContext_GetAttribute                      uintptr // Function 269
-	ShapeInferContext_SetOutputTypeShape                uintptr // Function 270
-	SetSymbolicDimensions                               uintptr // Function 271
-	ReadOpAttr                                          uintptr // Function 272
-	SetDeterministicCompute                             uintptr // Function 273
-	KernelContext_ParallelFor                           uintptr // Function 274
-	SessionOptionsAppendExecutionProvider_OpenVINO_V2   uintptr // Function 275
-	SessionOptionsAppendExecutionProvider_VitisAI       uintptr // Function 276
-	KernelContext_GetScratchBuffer                      uintptr // Function 277
-	KernelInfoGetAllocator                              uintptr // Function 278
-	AddExternalInitializersFromFilesInMemory            uintptr // Function 279
-	CreateLoraAdapter                                   uintptr // Function 280
-	CreateLoraAdapterFromArray                          uintptr // Function 281
-	ReleaseLoraAdapter                                  uintptr // Function 282
-	RunOptionsAddActiveLoraAdapter                      uintptr // Function 283
-	SetEpDynamicOptions                                 uintptr // Function 284
-	ReleaseValueInfo                                    uintptr // Function 285
-	ReleaseNode                                         uintptr // Function 286
-	ReleaseGraph                                        uintptr // Function 287
-	ReleaseModel                                        uintptr // Function 288
-	GetValueInfoName                                    uintptr // Function 289
-	GetValueInfoTypeInfo                                uintptr // Function 290
-	CreateTensorWithDataAndDeleterAsOrtValue            uintptr // Function 291
-	SessionOptionsSetLoadCancellationFlag               uintptr // Function 292
-	CreateKeyValuePairs                                 uintptr // Function 293
-	AddKeyValuePair                                     uintptr // Function 294
-	GetKeyValue                                         uintptr // Function 295
-	GetKeyValuePairs                                    uintptr // Function 296
-	RemoveKeyValuePair                                  uintptr // Function 297
-	ReleaseKeyValuePairs                                uintptr // Function 298
-	RegisterExecutionProviderLibrary                    uintptr // Function 299
-	UnregisterExecutionProviderLibrary                  uintptr // Function 300
-	GetEpDevices                                        uintptr // Function 301
-	SessionOptionsAppendExecutionProvider_V2            uintptr // Function 302
-	HardwareDevice_Vendor                               uintptr // Function 303
-	EpDevice_EpName                                     uintptr // Function 304
-	EpDevice_EpVendor                                   uintptr // Function 305
+	CreateStatus                                        uintptr // Function 1, API version 1
+	GetErrorCode                                        uintptr // Function 2, API version 1
+	GetErrorMessage                                     uintptr // Function 3, API version 1
+	CreateEnv                                           uintptr // Function 4, API version 1
+	CreateEnvWithCustomLogger                           uintptr // Function 5, API version 1
+	EnableTelemetryEvents                               uintptr // Function 6, API version 1
+	DisableTelemetryEvents                              uintptr // Function 7, API version 1
+	CreateSession                                       uintptr // Function 8, API version 1
+	CreateSessionFromArray                              uintptr // Function 9, API version 1
+	Run                                                 uintptr // Function 10, API version 1
+	CreateSessionOptions                                uintptr // Function 11, API version 1
+	SetOptimizedModelFilePath                           uintptr // Function 12, API version 1
+	CloneSessionOptions                                 uintptr // Function 13, API version 1
+	SetSessionExecutionMode                             uintptr // Function 14, API version 1
+	EnableProfiling                                     uintptr // Function 15, API version 1
+	DisableProfiling                                    uintptr // Function 16, API version 1
+	EnableMemPattern                                    uintptr // Function 17, API version 1
+	DisableMemPattern                                   uintptr // Function 18, API version 1
+	EnableCpuMemArena                                   uintptr // Function 19, API version 1
+	DisableCpuMemArena                                  uintptr // Function 20, API version 1
+	SetSessionLogId                                     uintptr // Function 21, API version 1
+	SetSessionLogVerbosityLevel                         uintptr // Function 22, API version 1
+	SetSessionLogSeverityLevel                          uintptr // Function 23, API version 1
+	SetSessionGraphOptimizationLevel                    uintptr // Function 24, API version 1
+	SetIntraOpNumThreads                                uintptr // Function 25, API version 1
+	SetInterOpNumThreads                                uintptr // Function 26, API version 1
+	CreateCustomOpDomain                                uintptr // Function 27, API version 1
+	CustomOpDomain_Add                                  uintptr // Function 28, API version 1
+	AddCustomOpDomain                                   uintptr // Function 29, API version 1
+	RegisterCustomOpsLibrary                            uintptr // Function 30, API version 1
+	SessionGetInputCount                                uintptr // Function 31, API version 1
+	SessionGetOutputCount                               uintptr // Function 32, API version 1
+	SessionGetOverridableInitializerCount               uintptr // Function 33, API version 1
+	SessionGetInputTypeInfo                             uintptr // Function 34, API version 1
+	SessionGetOutputTypeInfo                            uintptr // Function 35, API version 1
+	SessionGetOverridableInitializerTypeInfo            uintptr // Function 36, API version 1
+	SessionGetInputName                                 uintptr // Function 37, API version 1
+	SessionGetOutputName                                uintptr // Function 38, API version 1
+	SessionGetOverridableInitializerName                uintptr // Function 39, API version 1
+	CreateRunOptions                                    uintptr // Function 40, API version 1
+	RunOptionsSetRunLogVerbosityLevel                   uintptr // Function 41, API version 1
+	RunOptionsSetRunLogSeverityLevel                    uintptr // Function 42, API version 1
+	RunOptionsSetRunTag                                 uintptr // Function 43, API version 1
+	RunOptionsGetRunLogVerbosityLevel                   uintptr // Function 44, API version 1
+	RunOptionsGetRunLogSeverityLevel                    uintptr // Function 45, API version 1
+	RunOptionsGetRunTag                                 uintptr // Function 46, API version 1
+	RunOptionsSetTerminate                              uintptr // Function 47, API version 1
+	RunOptionsUnsetTerminate                            uintptr // Function 48, API version 1
+	CreateTensorAsOrtValue                              uintptr // Function 49, API version 1
+	CreateTensorWithDataAsOrtValue                      uintptr // Function 50, API version 1
+	IsTensor                                            uintptr // Function 51, API version 1
+	GetTensorMutableData                                uintptr // Function 52, API version 1
+	FillStringTensor                                    uintptr // Function 53, API version 1
+	GetStringTensorDataLength                           uintptr // Function 54, API version 1
+	GetStringTensorContent                              uintptr // Function 55, API version 1
+	CastTypeInfoToTensorInfo                            uintptr // Function 56, API version 1
+	GetOnnxTypeFromTypeInfo                             uintptr // Function 57, API version 1
+	CreateTensorTypeAndShapeInfo                        uintptr // Function 58, API version 1
+	SetTensorElementType                                uintptr // Function 59, API version 1
+	SetDimensions                                       uintptr // Function 60, API version 1
+	GetTensorElementType                                uintptr // Function 61, API version 1
+	GetDimensionsCount                                  uintptr // Function 62, API version 1
+	GetDimensions                                       uintptr // Function 63, API version 1
+	GetSymbolicDimensions                               uintptr // Function 64, API version 1
+	GetTensorShapeElementCount                          uintptr // Function 65, API version 1
+	GetTensorTypeAndShape                               uintptr // Function 66, API version 1
+	GetTypeInfo                                         uintptr // Function 67, API version 1
+	GetValueType                                        uintptr // Function 68, API version 1
+	CreateMemoryInfo                                    uintptr // Function 69, API version 1
+	CreateCpuMemoryInfo                                 uintptr // Function 70, API version 1
+	CompareMemoryInfo                                   uintptr // Function 71, API version 1
+	MemoryInfoGetName                                   uintptr // Function 72, API version 1
+	MemoryInfoGetId                                     uintptr // Function 73, API version 1
+	MemoryInfoGetMemType                                uintptr // Function 74, API version 1
+	MemoryInfoGetType                                   uintptr // Function 75, API version 1
+	AllocatorAlloc                                      uintptr // Function 76, API version 1
+	AllocatorFree                                       uintptr // Function 77, API version 1
+	AllocatorGetInfo                                    uintptr // Function 78, API version 1
+	GetAllocatorWithDefaultOptions                      uintptr // Function 79, API version 1
+	AddFreeDimensionOverride                            uintptr // Function 80, API version 1
+	GetValue                                            uintptr // Function 81, API version 1
+	GetValueCount                                       uintptr // Function 82, API version 1
+	CreateValue                                         uintptr // Function 83, API version 1
+	CreateOpaqueValue                                   uintptr // Function 84, API version 1
+	GetOpaqueValue                                      uintptr // Function 85, API version 1
+	KernelInfoGetAttribute_float                        uintptr // Function 86, API version 1
+	KernelInfoGetAttribute_int64                        uintptr // Function 87, API version 1
+	KernelInfoGetAttribute_string                       uintptr // Function 88, API version 1
+	KernelContext_GetInputCount                         uintptr // Function 89, API version 1
+	KernelContext_GetOutputCount                        uintptr // Function 90, API version 1
+	KernelContext_GetInput                              uintptr // Function 91, API version 1
+	KernelContext_GetOutput                             uintptr // Function 92, API version 1
+	ReleaseEnv                                          uintptr // Function 93, API version 1
+	ReleaseStatus                                       uintptr // Function 94, API version 1
+	ReleaseMemoryInfo                                   uintptr // Function 95, API version 1
+	ReleaseSession                                      uintptr // Function 96, API version 1
+	ReleaseValue                                        uintptr // Function 97, API version 1
+	ReleaseRunOptions                                   uintptr // Function 98, API version 1
+	ReleaseTypeInfo                                     uintptr // Function 99, API version 1
+	ReleaseTensorTypeAndShapeInfo                       uintptr // Function 100, API version 1
+	ReleaseSessionOptions                               uintptr // Function 101, API version 1
+	ReleaseCustomOpDomain                               uintptr // Function 102, API version 1
+	GetDenotationFromTypeInfo                           uintptr // Function 103, API version 3
+	CastTypeInfoToMapTypeInfo                           uintptr // Function 104, API version 3
+	CastTypeInfoToSequenceTypeInfo                      uintptr // Function 105, API version 3
+	GetMapKeyType                                       uintptr // Function 106, API version 3
+	GetMapValueType                                     uintptr // Function 107, API version 3
+	GetSequenceElementType                              uintptr // Function 108, API version 3
+	ReleaseMapTypeInfo                                  uintptr // Function 109, API version 3
+	ReleaseSequenceTypeInfo                             uintptr // Function 110, API version 3
+	SessionEndProfiling                                 uintptr // Function 111, API version 3
+	SessionGetModelMetadata                             uintptr // Function 112, API version 3
+	ModelMetadataGetProducerName                        uintptr // Function 113, API version 3
+	ModelMetadataGetGraphName                           uintptr // Function 114, API version 3
+	ModelMetadataGetDomain                              uintptr // Function 115, API version 3
+	ModelMetadataGetDescription                         uintptr // Function 116, API version 3
+	ModelMetadataLookupCustomMetadataMap                uintptr // Function 117, API version 3
+	ModelMetadataGetVersion                             uintptr // Function 118, API version 3
+	ReleaseModelMetadata                                uintptr // Function 119, API version 3
+	CreateEnvWithGlobalThreadPools                      uintptr // Function 120, API version 4
+	DisablePerSessionThreads                            uintptr // Function 121, API version 4
+	CreateThreadingOptions                              uintptr // Function 122, API version 4
+	ReleaseThreadingOptions                             uintptr // Function 123, API version 4
+	ModelMetadataGetCustomMetadataMapKeys               uintptr // Function 124, API version 4
+	AddFreeDimensionOverrideByName                      uintptr // Function 125, API version 4
+	GetAvailableProviders                               uintptr // Function 126, API version 4
+	ReleaseAvailableProviders                           uintptr // Function 127, API version 4
+	GetStringTensorElementLength                        uintptr // Function 128, API version 5
+	GetStringTensorElement                              uintptr // Function 129, API version 5
+	FillStringTensorElement                             uintptr // Function 130, API version 5
+	AddSessionConfigEntry                               uintptr // Function 131, API version 5
+	CreateAllocator                                     uintptr // Function 132, API version 5
+	ReleaseAllocator                                    uintptr // Function 133, API version 5
+	RunWithBinding                                      uintptr // Function 134, API version 5
+	CreateIoBinding                                     uintptr // Function 135, API version 5
+	ReleaseIoBinding                                    uintptr // Function 136, API version 5
+	BindInput                                           uintptr // Function 137, API version 5
+	BindOutput                                          uintptr // Function 138, API version 5
+	BindOutputToDevice                                  uintptr // Function 139, API version 5
+	GetBoundOutputNames                                 uintptr // Function 140, API version 5
+	GetBoundOutputValues                                uintptr // Function 141, API version 5
+	ClearBoundInputs                                    uintptr // Function 142, API version 5
+	ClearBoundOutputs                                   uintptr // Function 143, API version 5
+	TensorAt                                            uintptr // Function 144, API version 5
+	CreateAndRegisterAllocator                          uintptr // Function 145, API version 5
+	SetLanguageProjection                               uintptr // Function 146, API version 5
+	SessionGetProfilingStartTimeNs                      uintptr // Function 147, API version 5
+	SetGlobalIntraOpNumThreads                          uintptr // Function 148, API version 5
+	SetGlobalInterOpNumThreads                          uintptr // Function 149, API version 5
+	SetGlobalSpinControl                                uintptr // Function 150, API version 5
+	AddInitializer                                      uintptr // Function 151, API version 6
+	CreateEnvWithCustomLoggerAndGlobalThreadPools       uintptr // Function 152, API version 6
+	SessionOptionsAppendExecutionProvider_CUDA          uintptr // Function 153, API version 6
+	SessionOptionsAppendExecutionProvider_ROCM          uintptr // Function 154, API version 6
+	SessionOptionsAppendExecutionProvider_OpenVINO      uintptr // Function 155, API version 6
+	SetGlobalDenormalAsZero                             uintptr // Function 156, API version 6
+	CreateArenaCfg                                      uintptr // Function 157, API version 6
+	ReleaseArenaCfg                                     uintptr // Function 158, API version 6
+	ModelMetadataGetGraphDescription                    uintptr // Function 159, API version 7
+	SessionOptionsAppendExecutionProvider_TensorRT      uintptr // Function 160, API version 7
+	SetCurrentGpuDeviceId                               uintptr // Function 161, API version 7
+	GetCurrentGpuDeviceId                               uintptr // Function 162, API version 7
+	KernelInfoGetAttributeArray_float                   uintptr // Function 163, API version 8
+	KernelInfoGetAttributeArray_int64                   uintptr // Function 164, API version 8
+	CreateArenaCfgV2                                    uintptr // Function 165, API version 8
+	AddRunConfigEntry                                   uintptr // Function 166, API version 8
+	CreatePrepackedWeightsContainer                     uintptr // Function 167, API version 8
+	ReleasePrepackedWeightsContainer                    uintptr // Function 168, API version 8
+	CreateSessionWithPrepackedWeightsContainer          uintptr // Function 169, API version 8
+	CreateSessionFromArrayWithPrepackedWeightsContainer uintptr // Function 170, API version 8
+	SessionOptionsAppendExecutionProvider_TensorRT_V2   uintptr // Function 171, API version 9
+	CreateTensorRTProviderOptions                       uintptr // Function 172, API version 9
+	UpdateTensorRTProviderOptions                       uintptr // Function 173, API version 9
+	GetTensorRTProviderOptionsAsString                  uintptr // Function 174, API version 9
+	ReleaseTensorRTProviderOptions                      uintptr // Function 175, API version 9
+	EnableOrtCustomOps                                  uintptr // Function 176, API version 9
+	RegisterAllocator                                   uintptr // Function 177, API version 9
+	UnregisterAllocator                                 uintptr // Function 178, API version 9
+	IsSparseTensor                                      uintptr // Function 179, API version 9
+	CreateSparseTensorAsOrtValue                        uintptr // Function 180, API version 9
+	FillSparseTensorCoo                                 uintptr // Function 181, API version 9
+	FillSparseTensorCsr                                 uintptr // Function 182, API version 9
+	FillSparseTensorBlockSparse                         uintptr // Function 183, API version 9
+	CreateSparseTensorWithValuesAsOrtValue              uintptr // Function 184, API version 9
+	UseCooIndices                                       uintptr // Function 185, API version 9
+	UseCsrIndices                                       uintptr // Function 186, API version 9
+	UseBlockSparseIndices                               uintptr // Function 187, API version 9
+	GetSparseTensorFormat                               uintptr // Function 188, API version 9
+	GetSparseTensorValuesTypeAndShape                   uintptr // Function 189, API version 9
+	GetSparseTensorValues                               uintptr // Function 190, API version 9
+	GetSparseTensorIndicesTypeShape                     uintptr // Function 191, API version 9
+	GetSparseTensorIndices                              uintptr // Function 192, API version 9
+	HasValue                                            uintptr // Function 193, API version 10
+	KernelContext_GetGPUComputeStream                   uintptr // Function 194, API version 10
+	GetTensorMemoryInfo                                 uintptr // Function 195, API version 10
+	GetExecutionProviderApi                             uintptr // Function 196, API version 10
+	SessionOptionsSetCustomCreateThreadFn               uintptr // Function 197, API version 10
+	SessionOptionsSetCustomThreadCreationOptions        uintptr // Function 198, API version 10
+	SessionOptionsSetCustomJoinThreadFn                 uintptr // Function 199, API version 10
+	SetGlobalCustomCreateThreadFn                       uintptr // Function 200, API version 10
+	SetGlobalCustomThreadCreationOptions                uintptr // Function 201, API version 10
+	SetGlobalCustomJoinThreadFn                         uintptr // Function 202, API version 10
+	SynchronizeBoundInputs                              uintptr // Function 203, API version 10
+	SynchronizeBoundOutputs                             uintptr // Function 204, API version 10
+	SessionOptionsAppendExecutionProvider_CUDA_V2       uintptr // Function 205, API version 11
+	CreateCUDAProviderOptions                           uintptr // Function 206, API version 11
+	UpdateCUDAProviderOptions                           uintptr // Function 207, API version 11
+	GetCUDAProviderOptionsAsString                      uintptr // Function 208, API version 11
+	ReleaseCUDAProviderOptions                          uintptr // Function 209, API version 11
+	SessionOptionsAppendExecutionProvider_MIGraphX      uintptr // Function 210, API version 11
+	AddExternalInitializers                             uintptr // Function 211, API version 12
+	CreateOpAttr                                        uintptr // Function 212, API version 12
+	ReleaseOpAttr                                       uintptr // Function 213, API version 12
+	CreateOp                                            uintptr // Function 214, API version 12
+	InvokeOp                                            uintptr // Function 215, API version 12
+	ReleaseOp                                           uintptr // Function 216, API version 12
+	SessionOptionsAppendExecutionProvider               uintptr // Function 217, API version 12
+	CopyKernelInfo                                      uintptr // Function 218, API version 12
+	ReleaseKernelInfo                                   uintptr // Function 219, API version 12
+	GetTrainingApi                                      uintptr // Function 220, API version 13
+	SessionOptionsAppendExecutionProvider_CANN          uintptr // Function 221, API version 13
+	CreateCANNProviderOptions                           uintptr // Function 222, API version 13
+	UpdateCANNProviderOptions                           uintptr // Function 223, API version 13
+	GetCANNProviderOptionsAsString                      uintptr // Function 224, API version 13
+	ReleaseCANNProviderOptions                          uintptr // Function 225, API version 13
+	MemoryInfoGetDeviceType                             uintptr // Function 226, API version 14
+	UpdateEnvWithCustomLogLevel                         uintptr // Function 227, API version 14
+	SetGlobalIntraOpThreadAffinity                      uintptr // Function 228, API version 14
+	RegisterCustomOpsLibrary_V2                         uintptr // Function 229, API version 14
+	RegisterCustomOpsUsingFunction                      uintptr // Function 230, API version 14
+	KernelInfo_GetInputCount                            uintptr // Function 231, API version 14
+	KernelInfo_GetOutputCount                           uintptr // Function 232, API version 14
+	KernelInfo_GetInputName                             uintptr // Function 233, API version 14
+	KernelInfo_GetOutputName                            uintptr // Function 234, API version 14
+	KernelInfo_GetInputTypeInfo                         uintptr // Function 235, API version 14
+	KernelInfo_GetOutputTypeInfo                        uintptr // Function 236, API version 14
+	KernelInfoGetAttribute_tensor                       uintptr // Function 237, API version 14
+	HasSessionConfigEntry                               uintptr // Function 238, API version 14
+	GetSessionConfigEntry                               uintptr // Function 239, API version 14
+	SessionOptionsAppendExecutionProvider_Dnnl          uintptr // Function 240, API version 15
+	CreateDnnlProviderOptions                           uintptr // Function 241, API version 15
+	UpdateDnnlProviderOptions                           uintptr // Function 242, API version 15
+	GetDnnlProviderOptionsAsString                      uintptr // Function 243, API version 15
+	ReleaseDnnlProviderOptions                          uintptr // Function 244, API version 15
+	KernelInfo_GetNodeName                              uintptr // Function 245, API version 15
+	KernelInfo_GetLogger                                uintptr // Function 246, API version 15
+	KernelContext_GetLogger                             uintptr // Function 247, API version 15
+	Logger_LogMessage                                   uintptr // Function 248, API version 15
+	Logger_GetLoggingSeverityLevel                      uintptr // Function 249, API version 15
+	KernelInfoGetConstantInput_tensor                   uintptr // Function 250, API version 15
+	CastTypeInfoToOptionalTypeInfo                      uintptr // Function 251, API version 15
+	GetOptionalContainedTypeInfo                        uintptr // Function 252, API version 15
+	GetResizedStringTensorElementBuffer                 uintptr // Function 253, API version 15
+	KernelContext_GetAllocator                          uintptr // Function 254, API version 15
+	GetBuildInfoString                                  uintptr // Function 255, API version 15
+	CreateROCMProviderOptions                           uintptr // Function 256, API version 16
+	UpdateROCMProviderOptions                           uintptr // Function 257, API version 16
+	GetROCMProviderOptionsAsString                      uintptr // Function 258, API version 16
+	ReleaseROCMProviderOptions                          uintptr // Function 259, API version 16
+	CreateAndRegisterAllocatorV2                        uintptr // Function 260, API version 16
+	RunAsync                                            uintptr // Function 261, API version 16
+	UpdateTensorRTProviderOptionsWithValue              uintptr // Function 262, API version 16
+	GetTensorRTProviderOptionsByName                    uintptr // Function 263, API version 16
+	UpdateCUDAProviderOptionsWithValue                  uintptr // Function 264, API version 16
+	GetCUDAProviderOptionsByName                        uintptr // Function 265, API version 16
+	KernelContext_GetResource                           uintptr // Function 266, API version 16
+	SetUserLoggingFunction                              uintptr // Function 267, API version 17
+	ShapeInferContext_GetInputCount                     uintptr // Function 268, API version 17
+	ShapeInferContext_GetInputTypeShape                 uintptr // Function 269, API version 17
+	ShapeInferContext_GetAttribute                      uintptr // Function 270, API version 17
+	ShapeInferContext_SetOutputTypeShape                uintptr // Function 271, API version 17
+	SetSymbolicDimensions                               uintptr // Function 272, API version 17
+	ReadOpAttr                                          uintptr // Function 273, API version 17
+	SetDeterministicCompute                             uintptr // Function 274, API version 17
+	KernelContext_ParallelFor                           uintptr // Function 275, API version 17
+	SessionOptionsAppendExecutionProvider_OpenVINO_V2   uintptr // Function 276, API version 17
+	SessionOptionsAppendExecutionProvider_VitisAI       uintptr // Function 277, API version 18
+	KernelContext_GetScratchBuffer                      uintptr // Function 278, API version 18
+	KernelInfoGetAllocator                              uintptr // Function 279, API version 18
+	AddExternalInitializersFromFilesInMemory            uintptr // Function 280, API version 18
+	CreateLoraAdapter                                   uintptr // Function 281, API version 20
+	CreateLoraAdapterFromArray                          uintptr // Function 282, API version 20
+	ReleaseLoraAdapter                                  uintptr // Function 283, API version 20
+	RunOptionsAddActiveLoraAdapter                      uintptr // Function 284, API version 20
+	SetEpDynamicOptions                                 uintptr // Function 285, API version 20
+	ReleaseValueInfo                                    uintptr // Function 286, API version 22
+	ReleaseNode                                         uintptr // Function 287, API version 22
+	ReleaseGraph                                        uintptr // Function 288, API version 22
+	ReleaseModel                                        uintptr // Function 289, API version 22
+	GetValueInfoName                                    uintptr // Function 290, API version 22
+	GetValueInfoTypeInfo                                uintptr // Function 291, API version 22
+	GetModelEditorApi                                   uintptr // Function 292, API version 22
+	CreateTensorWithDataAndDeleterAsOrtValue            uintptr // Function 293, API version 22
+	SessionOptionsSetLoadCancellationFlag               uintptr // Function 294, API version 22
+	GetCompileApi                                       uintptr // Function 295, API version 22
+	CreateKeyValuePairs                                 uintptr // Function 296, API version 22
+	AddKeyValuePair                                     uintptr // Function 297, API version 22
+	GetKeyValue                                         uintptr // Function 298, API version 22
+	GetKeyValuePairs                                    uintptr // Function 299, API version 22
+	RemoveKeyValuePair                                  uintptr // Function 300, API version 22
+	ReleaseKeyValuePairs                                uintptr // Function 301, API version 22
+	RegisterExecutionProviderLibrary                    uintptr // Function 302, API version 22
+	UnregisterExecutionProviderLibrary                  uintptr // Function 303, API version 22
+	GetEpDevices                                        uintptr // Function 304, API version 22
+	SessionOptionsAppendExecutionProvider_V2            uintptr // Function 305, API version 22
+	HardwareDevice_Type                                 uintptr // Function 306, API version 22
+	HardwareDevice_VendorId                             uintptr // Function 307, API version 22
+	HardwareDevice_Vendor                               uintptr // Function 308, API version 22
+	HardwareDevice_DeviceId                             uintptr // Function 309, API version 22
+	HardwareDevice_Metadata                             uintptr // Function 310, API version 22
+	EpDevice_EpName                                     uintptr // Function 311, API version 22
+	EpDevice_EpVendor                                   uintptr // Function 312, API version 22
+	EpDevice_EpMetadata                                 uintptr // Function 313, API version 22
+	EpDevice_EpOptions                                  uintptr // Function 314, API version 22
+	EpDevice_Device                                     uintptr // Function 315, API version 22
+}
+
+// ortAPIFunctionCounts[v] is the number of function pointers in the OrtApi table of
+// API version v. A runtime negotiated at version v only provides the first
+// ortAPIFunctionCounts[v] fields; the memory past them is not part of its table.
+var ortAPIFunctionCounts = [...]int{
+	0,   // API version 0
+	102, // API version 1
+	102, // API version 2
+	119, // API version 3
+	127, // API version 4
+	150, // API version 5
+	158, // API version 6
+	162, // API version 7
+	170, // API version 8
+	192, // API version 9
+	204, // API version 10
+	210, // API version 11
+	219, // API version 12
+	225, // API version 13
+	239, // API version 14
+	255, // API version 15
+	266, // API version 16
+	276, // API version 17
+	280, // API version 18
+	280, // API version 19
+	285, // API version 20
+	285, // API version 21
+	315, // API version 22
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"unsafe"
 )
 
 // TestOrtApiStructLayout verifies that the OrtApi struct layout matches the C API
@@ -66,7 +67,7 @@ func TestReleaseEnvMultipleTimes(t *testing.T) {
 
 func TestOrtApiFieldCount(t *testing.T) {
 	apiType := reflect.TypeOf(OrtApi{})
-	expectedFields := 305
+	expectedFields := 315
 	actualFields := apiType.NumField()
 
 	if actualFields != expectedFields {
@@ -74,3 +75,99 @@ func TestOrtApiFieldCount(t *testing.T) {
 	}
 	t.Logf("OrtApi struct has correct field count: %d", actualFields)
 }
+
+func TestAPICapabilitiesReportsNonNullPointers(t *testing.T) {
+	api := &OrtApi{CreateEnv: 0x1000, Run: 0x2000, SessionGetProfilingStartTimeNs: 0x3000}
+	capabilities := apiCapabilities(api, ORT_API_VERSION)
+
+	if got, want := len(capabilities), reflect.TypeOf(OrtApi{}).NumField(); got != want {
+		t.Fatalf("expected one entry per OrtApi field: got %d, want %d", got, want)
+	}
+	for name, want := range map[string]bool{
+		"CreateEnv":                      true,
+		"Run":                            true,
+		"SessionGetProfilingStartTimeNs": true,
+		"FillStringTensor":               false,
+		"GetErrorMessage":                false,
+	} {
+		got, ok := capabilities[name]
+		if !ok {
+			t.Fatalf("missing capability entry for %s", name)
+		}
+		if got != want {
+			t.Fatalf("unexpected capability for %s: got %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestOrtApiFunctionCounts(t *testing.T) {
+	if got, want := ortAPIFunctionCounts[ORT_API_VERSION], reflect.TypeOf(OrtApi{}).NumField(); got != want {
+		t.Fatalf("API version %d table size %d does not match OrtApi field count %d", ORT_API_VERSION, got, want)
+	}
+	for version := 1; version < len(ortAPIFunctionCounts); version++ {
+		if ortAPIFunctionCounts[version] < ortAPIFunctionCounts[version-1] {
+			t.Fatalf("API version %d table is smaller than version %d", version, version-1)
+		}
+	}
+	// Offsets ONNX Runtime pins for older versions; a field the parser drops shifts them.
+	apiType := reflect.TypeOf(OrtApi{})
+	for name, index := range map[string]int{
+		"ReleaseCustomOpDomain":       101,
+		"ReleaseKernelInfo":           218,
+		"GetTrainingApi":              219,
+		"RegisterCustomOpsLibrary_V2": 228,
+		"SetEpDynamicOptions":         284,
+	} {
+		field, ok := apiType.FieldByName(name)
+		if !ok {
+			t.Fatalf("OrtApi has no field %s", name)
+		}
+		if got := int(field.Offset / unsafe.Sizeof(uintptr(0))); got != index {
+			t.Fatalf("unexpected OrtApi index for %s: got %d, want %d", name, got, index)
+		}
+	}
+}
+
+func TestAPICapabilitiesLimitedToNegotiatedVersion(t *testing.T) {
+	// A version 11 runtime's table ends before RegisterCustomOpsLibrary_V2; whatever
+	// follows it in memory must not be reported as an available function.
+	api := &OrtApi{CreateEnv: 0x1000, SessionOptionsAppendExecutionProvider_CUDA_V2: 0x2000, RegisterCustomOpsLibrary_V2: 0x3000, SetEpDynamicOptions: 0x4000}
+	capabilities := apiCapabilities(api, 11)
+
+	if got, want := len(capabilities), reflect.TypeOf(OrtApi{}).NumField(); got != want {
+		t.Fatalf("expected one entry per OrtApi field: got %d, want %d", got, want)
+	}
+	for name, want := range map[string]bool{
+		"CreateEnv": true,
+		"SessionOptionsAppendExecutionProvider_CUDA_V2": true,
+		"RegisterCustomOpsLibrary_V2":                   false,
+		"SetEpDynamicOptions":                           false,
+	} {
+		if got := capabilities[name]; got != want {
+			t.Fatalf("unexpected capability for %s at API version 11: got %v, want %v", name, got, want)
+		}
+	}
+	if capabilities := apiCapabilities(api, 20); !capabilities["SetEpDynamicOptions"] || !capabilities["RegisterCustomOpsLibrary_V2"] {
+		t.Fatalf("expected version 20 functions to be reported at API version 20")
+	}
+}
+
+func TestCheckAPICapabilities(t *testing.T) {
+	resetEnvironmentState()
+	if _, err := CheckAPICapabilities(); err == nil {
+		t.Fatalf("expected an error before ONNX Runtime is initialized")
+	}
+
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	capabilities, err := CheckAPICapabilities()
+	if err != nil {
+		t.Fatalf("CheckAPICapabilities failed: %v", err)
+	}
+	for _, name := range []string{"CreateEnv", "CreateSession", "Run", "ReleaseEnv"} {
+		if !capabilities[name] {
+			t.Fatalf("expected the loaded library to provide %s", name)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package ort
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExpectedOutputElements returns the number of elements the model at modelPath produces
+// for outputName when run with inputs of the given shapes, so the output can be
+// pre-allocated with NewEmptyTensor. inputShapes maps input names to shapes; inputs that
+// only carry dynamic dimensions the output does not depend on may be omitted.
+//
+// Dynamic output dimensions are resolved through the model's symbolic dimension names:
+// an output dimension named "sequence_length" takes the size given for the input
+// dimension of the same name. Outputs whose size depends on input values rather than
+// shapes cannot be resolved this way and are reported as an error; bind them with
+// NewRuntimeAllocatedTensor instead. ONNX Runtime must be initialized.
+func ExpectedOutputElements(modelPath, outputName string, inputShapes map[string]Shape) (int, error) {
+	inputs, outputs, err := GetInputOutputInfo(modelPath)
+	if err != nil {
+		return 0, err
+	}
+	shape, err := resolveOutputShape(inputs, outputs, outputName, inputShapes)
+	if err != nil {
+		return 0, err
+	}
+	return shapeElementCount(shape)
+}
+
+// resolveOutputShape substitutes the dimension sizes bound by inputShapes into the
+// declared shape of outputName.
+func resolveOutputShape(inputs, outputs []InputOutputInfo, outputName string, inputShapes map[string]Shape) (Shape, error) {
+	output, ok := findInputOutputInfo(outputs, outputName)
+	if !ok {
+		return nil, fmt.Errorf("model has no output %q (outputs: %s)", outputName, joinInputOutputNames(outputs))
+	}
+	if output.OrtValueType != ONNXTypeTensor {
+		return nil, fmt.Errorf("output %q is not a tensor", outputName)
+	}
+
+	bound := make(map[string]int64)
+	for name, shape := range inputShapes {
+		input, ok := findInputOutputInfo(inputs, name)
+		if !ok {
+			return nil, fmt.Errorf("model has no input %q (inputs: %s)", name, joinInputOutputNames(inputs))
+		}
+		if len(shape) != len(input.Dimensions) {
+			return nil, fmt.Errorf("input %q has rank %d, but shape %v has rank %d", name, len(input.Dimensions), shape, len(shape))
+		}
+		for i, dim := range shape {
+			if dim < 0 {
+				return nil, fmt.Errorf("input %q dimension %d is %d (must be >= 0)", name, i, dim)
+			}
+			if declared := input.Dimensions[i]; declared >= 0 && declared != dim {
+				return nil, fmt.Errorf("input %q dimension %d is fixed at %d, got %d", name, i, declared, dim)
+			}
+			symbol := symbolicDimension(input, i)
+			if symbol == "" {
+				continue
+			}
+			if previous, ok := bound[symbol]; ok && previous != dim {
+				return nil, fmt.Errorf("dimension %q is %d in one input but %d in input %q", symbol, previous, dim, name)
+			}
+			bound[symbol] = dim
+		}
+	}
+
+	shape := make(Shape, len(output.Dimensions))
+	for i, dim := range output.Dimensions {
+		if dim >= 0 {
+			shape[i] = dim
+			continue
+		}
+		symbol := symbolicDimension(output, i)
+		size, ok := bound[symbol]
+		if symbol == "" || !ok {
+			return nil, fmt.Errorf("output %q dimension %d is dynamic and not determined by the input shapes", outputName, i)
+		}
+		shape[i] = size
+	}
+	return shape, nil
+}
+
+func findInputOutputInfo(infos []InputOutputInfo, name string) (InputOutputInfo, bool) {
+	for _, info := range infos {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return InputOutputInfo{}, false
+}
+
+func symbolicDimension(info InputOutputInfo, index int) string {
+	if index < len(info.SymbolicDimensions) {
+		return info.SymbolicDimensions[index]
+	}
+	return ""
+}
+
+func joinInputOutputNames(infos []InputOutputInfo) string {
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = fmt.Sprintf("%q", info.Name)
+	}
+	return strings.Join(names, ", ")
+}
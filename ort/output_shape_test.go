@@ -0,0 +1,77 @@
+package ort
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveOutputShape(t *testing.T) {
+	inputs := []InputOutputInfo{
+		{Name: "input_ids", OrtValueType: ONNXTypeTensor, Dimensions: Shape{-1, -1}, SymbolicDimensions: []string{"batch_size", "sequence_length"}},
+		{Name: "attention_mask", OrtValueType: ONNXTypeTensor, Dimensions: Shape{-1, -1}, SymbolicDimensions: []string{"batch_size", "sequence_length"}},
+		{Name: "pixel_values", OrtValueType: ONNXTypeTensor, Dimensions: Shape{-1, 3}, SymbolicDimensions: []string{"batch_size", ""}},
+	}
+	outputs := []InputOutputInfo{
+		{Name: "last_hidden_state", OrtValueType: ONNXTypeTensor, Dimensions: Shape{-1, -1, 384}, SymbolicDimensions: []string{"batch_size", "sequence_length", ""}},
+		{Name: "top_k", OrtValueType: ONNXTypeTensor, Dimensions: Shape{-1}, SymbolicDimensions: []string{"num_selected"}},
+		{Name: "unnamed", OrtValueType: ONNXTypeTensor, Dimensions: Shape{-1}, SymbolicDimensions: []string{""}},
+		{Name: "labels", OrtValueType: ONNXTypeSequence},
+	}
+
+	shape, err := resolveOutputShape(inputs, outputs, "last_hidden_state", map[string]Shape{"input_ids": {2, 128}})
+	if err != nil {
+		t.Fatalf("resolveOutputShape failed: %v", err)
+	}
+	if len(shape) != 3 || shape[0] != 2 || shape[1] != 128 || shape[2] != 384 {
+		t.Fatalf("expected [2 128 384], got %v", shape)
+	}
+
+	tests := []struct {
+		name    string
+		output  string
+		shapes  map[string]Shape
+		wantErr string
+	}{
+		{name: "unknown output", output: "pooler_output", wantErr: `model has no output "pooler_output"`},
+		{name: "non-tensor output", output: "labels", wantErr: "is not a tensor"},
+		{name: "unknown input", output: "last_hidden_state", shapes: map[string]Shape{"input": {1, 8}}, wantErr: `model has no input "input"`},
+		{name: "rank mismatch", output: "last_hidden_state", shapes: map[string]Shape{"input_ids": {8}}, wantErr: "has rank 2"},
+		{name: "negative dimension", output: "last_hidden_state", shapes: map[string]Shape{"input_ids": {1, -1}}, wantErr: "must be >= 0"},
+		{name: "fixed dimension mismatch", output: "last_hidden_state", shapes: map[string]Shape{"pixel_values": {1, 4}}, wantErr: "is fixed at 3"},
+		{name: "conflicting symbols", output: "last_hidden_state", shapes: map[string]Shape{"input_ids": {1, 8}, "attention_mask": {1, 16}}, wantErr: `dimension "sequence_length"`},
+		{name: "unbound symbol", output: "last_hidden_state", shapes: map[string]Shape{"pixel_values": {1, 3}}, wantErr: "dimension 1 is dynamic"},
+		{name: "value-dependent output", output: "top_k", shapes: map[string]Shape{"input_ids": {1, 8}}, wantErr: "dimension 0 is dynamic"},
+		{name: "unnamed dynamic output", output: "unnamed", shapes: map[string]Shape{"input_ids": {1, 8}}, wantErr: "dimension 0 is dynamic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := resolveOutputShape(inputs, outputs, tt.output, tt.shapes); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExpectedOutputElementsWithAllMiniLML6V2(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	modelPath := resolveAllMiniLMModelPath(t)
+	for _, seq := range []int64{8, 128} {
+		count, err := ExpectedOutputElements(modelPath, "last_hidden_state", map[string]Shape{
+			"input_ids":      {1, seq},
+			"attention_mask": {1, seq},
+			"token_type_ids": {1, seq},
+		})
+		if err != nil {
+			t.Fatalf("ExpectedOutputElements failed for seq=%d: %v", seq, err)
+		}
+		if want := int(seq) * 384; count != want {
+			t.Fatalf("unexpected element count for seq=%d: got %d, want %d", seq, count, want)
+		}
+	}
+
+	if _, err := ExpectedOutputElements(modelPath, "logits", map[string]Shape{"input_ids": {1, 8}}); err == nil || !strings.Contains(err.Error(), "has no output") {
+		t.Fatalf("expected unknown output error, got: %v", err)
+	}
+}
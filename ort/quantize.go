@@ -0,0 +1,44 @@
+package ort
+
+import (
+	"fmt"
+	"math"
+)
+
+// QuantizeFloat32 converts data to int8 for models with QuantizeLinear-style inputs:
+// each value becomes round(x/scale) + zeroPoint, rounded half to even and saturated to
+// [-128, 127] as ONNX QuantizeLinear does. scale must be positive and finite.
+func QuantizeFloat32(data []float32, scale float32, zeroPoint int8) ([]int8, error) {
+	if err := validateQuantizationScale(scale); err != nil {
+		return nil, err
+	}
+	quantized := make([]int8, len(data))
+	for i, value := range data {
+		if math.IsNaN(float64(value)) {
+			return nil, fmt.Errorf("cannot quantize NaN at index %d", i)
+		}
+		q := math.RoundToEven(float64(value)/float64(scale)) + float64(zeroPoint)
+		quantized[i] = int8(max(math.MinInt8, min(math.MaxInt8, q)))
+	}
+	return quantized, nil
+}
+
+// DequantizeInt8 is the inverse of QuantizeFloat32: each value becomes
+// (q - zeroPoint) * scale, as ONNX DequantizeLinear computes it.
+func DequantizeInt8(data []int8, scale float32, zeroPoint int8) ([]float32, error) {
+	if err := validateQuantizationScale(scale); err != nil {
+		return nil, err
+	}
+	values := make([]float32, len(data))
+	for i, q := range data {
+		values[i] = float32(int32(q)-int32(zeroPoint)) * scale
+	}
+	return values, nil
+}
+
+func validateQuantizationScale(scale float32) error {
+	if !(scale > 0) || math.IsInf(float64(scale), 1) {
+		return fmt.Errorf("quantization scale must be positive and finite, got %v", scale)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package ort
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQuantizeFloat32(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []float32
+		scale     float32
+		zeroPoint int8
+		want      []int8
+	}{
+		{name: "unit scale", data: []float32{0, 1, -1, 2.4, -2.6}, scale: 1, want: []int8{0, 1, -1, 2, -3}},
+		{name: "rounds half to even", data: []float32{0.5, 1.5, 2.5, -0.5, -1.5}, scale: 1, want: []int8{0, 2, 2, 0, -2}},
+		{name: "scale and zero point", data: []float32{0, 0.1, -0.1, 1}, scale: 0.05, zeroPoint: 10, want: []int8{10, 12, 8, 30}},
+		{name: "saturates", data: []float32{1000, -1000, float32(math.Inf(1)), float32(math.Inf(-1))}, scale: 0.5, zeroPoint: -3, want: []int8{127, -128, 127, -128}},
+		{name: "empty", data: []float32{}, scale: 1, want: []int8{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := QuantizeFloat32(tt.data, tt.scale, tt.zeroPoint)
+			if err != nil {
+				t.Fatalf("QuantizeFloat32 failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected quantized values: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantizeRoundTrip(t *testing.T) {
+	tests := []struct {
+		scale     float32
+		zeroPoint int8
+	}{
+		{scale: 1.0 / 127, zeroPoint: 0},
+		{scale: 0.02, zeroPoint: -20},
+		{scale: 0.5, zeroPoint: 17},
+	}
+	for _, tt := range tests {
+		// Values inside the representable range [(-128-zp)*scale, (127-zp)*scale].
+		low := float32(math.MinInt8-int32(tt.zeroPoint)) * tt.scale
+		high := float32(math.MaxInt8-int32(tt.zeroPoint)) * tt.scale
+		data := make([]float32, 101)
+		for i := range data {
+			data[i] = low + (high-low)*float32(i)/float32(len(data)-1)
+		}
+
+		quantized, err := QuantizeFloat32(data, tt.scale, tt.zeroPoint)
+		if err != nil {
+			t.Fatalf("QuantizeFloat32(scale=%v, zp=%d) failed: %v", tt.scale, tt.zeroPoint, err)
+		}
+		restored, err := DequantizeInt8(quantized, tt.scale, tt.zeroPoint)
+		if err != nil {
+			t.Fatalf("DequantizeInt8(scale=%v, zp=%d) failed: %v", tt.scale, tt.zeroPoint, err)
+		}
+		for i := range data {
+			// Rounding to the nearest step loses at most half a step.
+			if diff := math.Abs(float64(restored[i] - data[i])); diff > float64(tt.scale)/2+1e-6 {
+				t.Fatalf("scale=%v zp=%d: value %d round-tripped %v -> %v (error %v)", tt.scale, tt.zeroPoint, i, data[i], restored[i], diff)
+			}
+		}
+	}
+}
+
+func TestDequantizeInt8(t *testing.T) {
+	got, err := DequantizeInt8([]int8{10, 12, 8, -128, 127}, 0.25, 10)
+	if err != nil {
+		t.Fatalf("DequantizeInt8 failed: %v", err)
+	}
+	want := []float32{0, 0.5, -0.5, -34.5, 29.25}
+	if err := ApproxEqual(got, want, 0); err != nil {
+		t.Fatalf("unexpected dequantized values: %v", err)
+	}
+}
+
+func TestQuantizationValidation(t *testing.T) {
+	for _, scale := range []float32{0, -1, float32(math.NaN()), float32(math.Inf(1))} {
+		if _, err := QuantizeFloat32([]float32{1}, scale, 0); err == nil || !strings.Contains(err.Error(), "positive and finite") {
+			t.Fatalf("expected invalid scale error for %v, got: %v", scale, err)
+		}
+		if _, err := DequantizeInt8([]int8{1}, scale, 0); err == nil || !strings.Contains(err.Error(), "positive and finite") {
+			t.Fatalf("expected invalid scale error for %v, got: %v", scale, err)
+		}
+	}
+	if _, err := QuantizeFloat32([]float32{1, float32(math.NaN())}, 1, 0); err == nil || !strings.Contains(err.Error(), "NaN at index 1") {
+		t.Fatalf("expected NaN error, got: %v", err)
+	}
+}
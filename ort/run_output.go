@@ -0,0 +1,86 @@
+package ort
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RunOutput runs the model at modelPath and returns only the output named outputName,
+// for models that declare several outputs (for example hidden states and attentions)
+// when the caller needs just one. The output is checked against the model metadata
+// before running, and ONNX Runtime only evaluates the part of the graph it depends on,
+// so no buffers are allocated for the outputs that are not requested.
+//
+// inputNames and inputValues are bound as in NewAdvancedSession and stay owned by the
+// caller. The returned tensor is allocated by ONNX Runtime with the produced shape; the
+// caller owns it and must Destroy it. Each call creates and releases its own session;
+// use NewAdvancedSession directly for repeated inference on the same model.
+func RunOutput[T any](modelPath string, inputNames []string, inputValues []Value, outputName string) (_ *Tensor[T], err error) {
+	if modelPath == "" {
+		return nil, fmt.Errorf("model path cannot be empty")
+	}
+	if outputName == "" {
+		return nil, fmt.Errorf("output name cannot be empty")
+	}
+	elementType, _, err := tensorElementType[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	inputs, outputs, err := GetInputOutputInfo(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRunOutput(inputs, outputs, inputNames, outputName, elementType); err != nil {
+		return nil, err
+	}
+
+	output, err := NewRuntimeAllocatedTensor[T]()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output tensor: %w", err)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if destroyErr := output.Destroy(); destroyErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to destroy output tensor: %w", destroyErr))
+		}
+	}()
+
+	session, err := NewAdvancedSession(modelPath, inputNames, []string{outputName}, inputValues, []Value{output}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	runErr := session.Run()
+	destroyErr := session.Destroy()
+	if runErr != nil {
+		return nil, errors.Join(fmt.Errorf("inference for output %q failed: %w", outputName, runErr), destroyErr)
+	}
+	if destroyErr != nil {
+		return nil, fmt.Errorf("failed to destroy session: %w", destroyErr)
+	}
+	return output, nil
+}
+
+// validateRunOutput checks that the bound inputs and the requested output exist in the
+// model and that the output has the requested element type.
+func validateRunOutput(inputs, outputs []InputOutputInfo, inputNames []string, outputName string, elementType TensorElementDataType) error {
+	for _, name := range inputNames {
+		if _, ok := findInputOutputInfo(inputs, name); !ok {
+			return fmt.Errorf("model has no input %q (inputs: %s)", name, joinInputOutputNames(inputs))
+		}
+	}
+	output, ok := findInputOutputInfo(outputs, outputName)
+	if !ok {
+		return fmt.Errorf("model has no output %q (outputs: %s)", outputName, joinInputOutputNames(outputs))
+	}
+	if output.OrtValueType != ONNXTypeTensor {
+		return fmt.Errorf("output %q is not a tensor", outputName)
+	}
+	if output.DataType != elementType {
+		return fmt.Errorf("output %q has element type %d, but element type %d was requested", outputName, output.DataType, elementType)
+	}
+	return nil
+}
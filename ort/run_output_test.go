@@ -0,0 +1,117 @@
+package ort
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTwoOutputTestModel writes a model with input X [N, 3] and two outputs:
+// projected = MatMul(X, W) [N, 2] and negated = Neg(X) [N, 3].
+func writeTwoOutputTestModel(tb testing.TB) string {
+	tb.Helper()
+
+	var graph []byte
+	graph = protoBytesField(graph, 1, onnxNode("MatMul", []string{"features", "W"}, []string{"projected"}))
+	graph = protoBytesField(graph, 1, onnxNode("Neg", []string{"features"}, []string{"negated"}))
+	graph = protoStringField(graph, 2, "two_outputs")
+	graph = protoBytesField(graph, 5, onnxFloatInitializer("W", []int64{3, 2}, []float32{1, 0, 0, 1, 2, -1}))
+	graph = protoBytesField(graph, 11, onnxValueInfo("features", TensorElementDataTypeFloat, -1, 3))
+	graph = protoBytesField(graph, 12, onnxValueInfo("projected", TensorElementDataTypeFloat, -1, 2))
+	graph = protoBytesField(graph, 12, onnxValueInfo("negated", TensorElementDataTypeFloat, -1, 3))
+
+	var opset []byte
+	opset = protoIntField(opset, 2, 13)
+
+	var model []byte
+	model = protoIntField(model, 1, 8)
+	model = protoBytesField(model, 7, graph)
+	model = protoBytesField(model, 8, opset)
+
+	path := filepath.Join(tb.TempDir(), "two_outputs.onnx")
+	if err := os.WriteFile(path, model, 0o600); err != nil {
+		tb.Fatalf("failed to write test model: %v", err)
+	}
+	return path
+}
+
+func TestRunOutputValidation(t *testing.T) {
+	if _, err := RunOutput[float32]("", []string{"x"}, nil, "y"); err == nil || !strings.Contains(err.Error(), "model path cannot be empty") {
+		t.Fatalf("expected empty model path error, got: %v", err)
+	}
+	if _, err := RunOutput[float32]("model.onnx", []string{"x"}, nil, ""); err == nil || !strings.Contains(err.Error(), "output name cannot be empty") {
+		t.Fatalf("expected empty output name error, got: %v", err)
+	}
+	if _, err := RunOutput[struct{}]("model.onnx", []string{"x"}, nil, "y"); err == nil {
+		t.Fatalf("expected unsupported element type error")
+	}
+
+	inputs := []InputOutputInfo{{Name: "features", OrtValueType: ONNXTypeTensor, DataType: TensorElementDataTypeFloat}}
+	outputs := []InputOutputInfo{
+		{Name: "projected", OrtValueType: ONNXTypeTensor, DataType: TensorElementDataTypeFloat},
+		{Name: "labels", OrtValueType: ONNXTypeSequence},
+	}
+	if err := validateRunOutput(inputs, outputs, []string{"features"}, "projected", TensorElementDataTypeFloat); err != nil {
+		t.Fatalf("expected valid output, got: %v", err)
+	}
+	tests := []struct {
+		name        string
+		inputNames  []string
+		output      string
+		elementType TensorElementDataType
+		wantErr     string
+	}{
+		{name: "unknown input", inputNames: []string{"x"}, output: "projected", elementType: TensorElementDataTypeFloat, wantErr: `model has no input "x"`},
+		{name: "unknown output", inputNames: []string{"features"}, output: "attentions", elementType: TensorElementDataTypeFloat, wantErr: `model has no output "attentions" (outputs: "projected", "labels")`},
+		{name: "non-tensor output", inputNames: []string{"features"}, output: "labels", elementType: TensorElementDataTypeFloat, wantErr: "is not a tensor"},
+		{name: "element type mismatch", inputNames: []string{"features"}, output: "projected", elementType: TensorElementDataTypeInt64, wantErr: "was requested"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRunOutput(inputs, outputs, tt.inputNames, tt.output, tt.elementType); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRunOutputWithTwoOutputModel(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	modelPath := writeTwoOutputTestModel(t)
+	input, err := NewTensor(Shape{2, 3}, []float32{1, 2, 3, 0, 0, 1})
+	if err != nil {
+		t.Fatalf("NewTensor failed: %v", err)
+	}
+	defer func() { _ = input.Destroy() }()
+
+	negated, err := RunOutput[float32](modelPath, []string{"features"}, []Value{input}, "negated")
+	if err != nil {
+		t.Fatalf("RunOutput failed: %v", err)
+	}
+	defer func() { _ = negated.Destroy() }()
+	if got := negated.Shape(); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Fatalf("expected the negated output shape [2 3], got %v", got)
+	}
+	if err := ApproxEqual(negated.GetData(), []float32{-1, -2, -3, 0, 0, -1}, 0); err != nil {
+		t.Fatalf("unexpected negated output: %v", err)
+	}
+
+	projected, err := RunOutput[float32](modelPath, []string{"features"}, []Value{input}, "projected")
+	if err != nil {
+		t.Fatalf("RunOutput failed: %v", err)
+	}
+	defer func() { _ = projected.Destroy() }()
+	if got := projected.Shape(); len(got) != 2 || got[0] != 2 || got[1] != 2 {
+		t.Fatalf("expected the projected output shape [2 2], got %v", got)
+	}
+	if err := ApproxEqual(projected.GetData(), []float32{7, -1, 2, -1}, 1e-6); err != nil {
+		t.Fatalf("unexpected projected output: %v", err)
+	}
+
+	if _, err := RunOutput[float32](modelPath, []string{"features"}, []Value{input}, "attentions"); err == nil || !strings.Contains(err.Error(), "has no output") {
+		t.Fatalf("expected unknown output error, got: %v", err)
+	}
+}
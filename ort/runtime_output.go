@@ -0,0 +1,170 @@
+package ort
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+// NewRuntimeAllocatedTensor returns an output tensor whose shape and storage are chosen
+// by ONNX Runtime on each Run, for outputs with symbolic or unknown dimensions that
+// cannot be pre-allocated with NewEmptyTensor.
+//
+// It can only be bound as a session output. Before the first successful Run, Shape and
+// GetData return nil. After each Run they report the produced output; the data is copied
+// into Go memory and the runtime-owned value is released, so it stays valid until the
+// next Run replaces it.
+func NewRuntimeAllocatedTensor[T any]() (*Tensor[T], error) {
+	if _, _, err := tensorElementType[T](); err != nil {
+		return nil, err
+	}
+	return &Tensor[T]{runtimeAllocated: true}, nil
+}
+
+// runtimeAllocatedValue is implemented by output values that ONNX Runtime allocates
+// during Run instead of binding a pre-created OrtValue.
+type runtimeAllocatedValue interface {
+	isRuntimeAllocated() bool
+	adoptRuntimeOutput(reader *ortValueReader, value uintptr) error
+}
+
+func isRuntimeAllocated(v Value) bool {
+	allocated, ok := v.(runtimeAllocatedValue)
+	return ok && allocated.isRuntimeAllocated()
+}
+
+func (t *Tensor[T]) isRuntimeAllocated() bool {
+	if t == nil {
+		return false
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return t.runtimeAllocated && !t.destroyed
+}
+
+// adoptRuntimeOutput copies a runtime-allocated OrtValue into the tensor.
+// The caller keeps ownership of value and must release it.
+func (t *Tensor[T]) adoptRuntimeOutput(reader *ortValueReader, value uintptr) error {
+	elementType, _, err := tensorElementType[T]()
+	if err != nil {
+		return err
+	}
+	gotType, shape, err := reader.describe(value)
+	if err != nil {
+		return err
+	}
+	if gotType != elementType {
+		return fmt.Errorf("runtime-allocated output has element type %d, but the tensor expects %d", gotType, elementType)
+	}
+	count, err := shapeElementCount(shape)
+	if err != nil {
+		return err
+	}
+
+	data := make([]T, count)
+	if count > 0 {
+		var dataPtr unsafe.Pointer
+		if status := reader.getTensorMutableData(value, &dataPtr); status != 0 {
+			return statusError(status, "failed to read runtime-allocated output data")
+		}
+		if dataPtr == nil {
+			return fmt.Errorf("runtime-allocated output data is unavailable")
+		}
+		// #nosec G103 -- Copies out of the ORT-owned buffer, which stays valid until the caller releases value.
+		copy(data, unsafe.Slice((*T)(dataPtr), count))
+	}
+
+	mu.Lock()
+	if !t.destroyed {
+		t.shape = shape
+		t.data = data
+	}
+	mu.Unlock()
+	return nil
+}
+
+// ortValueReader holds the ORT functions used to read runtime-allocated outputs.
+type ortValueReader struct {
+	getTensorTypeAndShape         func(value uintptr, out *uintptr) uintptr
+	getTensorElementType          func(info uintptr, out *int32) uintptr
+	getDimensionsCount            func(info uintptr, out *uintptr) uintptr
+	getDimensions                 func(info uintptr, dims *int64, count uintptr) uintptr
+	releaseTensorTypeAndShapeInfo func(info uintptr)
+	getTensorMutableData          func(value uintptr, out *unsafe.Pointer) uintptr
+}
+
+func newOrtValueReader(api *OrtApi) *ortValueReader {
+	reader := &ortValueReader{}
+	purego.RegisterFunc(&reader.getTensorTypeAndShape, api.GetTensorTypeAndShape)
+	purego.RegisterFunc(&reader.getTensorElementType, api.GetTensorElementType)
+	purego.RegisterFunc(&reader.getDimensionsCount, api.GetDimensionsCount)
+	purego.RegisterFunc(&reader.getDimensions, api.GetDimensions)
+	purego.RegisterFunc(&reader.releaseTensorTypeAndShapeInfo, api.ReleaseTensorTypeAndShapeInfo)
+	purego.RegisterFunc(&reader.getTensorMutableData, api.GetTensorMutableData)
+	return reader
+}
+
+// describe returns the element type and shape of a tensor OrtValue.
+func (r *ortValueReader) describe(value uintptr) (TensorElementDataType, Shape, error) {
+	var info uintptr
+	if status := r.getTensorTypeAndShape(value, &info); status != 0 {
+		return 0, nil, statusError(status, "failed to get runtime-allocated output type and shape")
+	}
+	defer r.releaseTensorTypeAndShapeInfo(info)
+
+	var elementType int32
+	if status := r.getTensorElementType(info, &elementType); status != 0 {
+		return 0, nil, statusError(status, "failed to get runtime-allocated output element type")
+	}
+	var dimCount uintptr
+	if status := r.getDimensionsCount(info, &dimCount); status != 0 {
+		return 0, nil, statusError(status, "failed to get runtime-allocated output rank")
+	}
+	shape := make(Shape, dimCount)
+	if dimCount > 0 {
+		if status := r.getDimensions(info, shapePtr(shape), dimCount); status != 0 {
+			return 0, nil, statusError(status, "failed to get runtime-allocated output dimensions")
+		}
+		runtime.KeepAlive(shape)
+	}
+	return TensorElementDataType(elementType), shape, nil
+}
+
+// adoptRuntimeAllocatedOutputs copies every runtime-allocated output written by Run into
+// its tensor and releases the ORT-owned values. Callers must hold runMu and ortCallMu.
+func (s *AdvancedSession) adoptRuntimeAllocatedOutputs(api *OrtApi, names []string, values []Value, handles []uintptr, releaseValue func(uintptr)) error {
+	defer releaseRuntimeAllocatedOutputs(values, handles, releaseValue)
+
+	for i, v := range values {
+		allocated, ok := v.(runtimeAllocatedValue)
+		if !ok || !allocated.isRuntimeAllocated() {
+			continue
+		}
+		if handles[i] == 0 {
+			return fmt.Errorf("ONNX Runtime did not allocate output %q", names[i])
+		}
+		if s.valueReader == nil {
+			s.valueReader = newOrtValueReader(api)
+		}
+		if err := allocated.adoptRuntimeOutput(s.valueReader, handles[i]); err != nil {
+			return fmt.Errorf("failed to read output %q: %w", names[i], err)
+		}
+	}
+	return nil
+}
+
+// releaseRuntimeAllocatedOutputs releases the values ORT allocated for runtime-allocated
+// outputs; handles bound by the caller are left alone.
+func releaseRuntimeAllocatedOutputs(values []Value, handles []uintptr, releaseValue func(uintptr)) {
+	if releaseValue == nil {
+		return
+	}
+	for i, v := range values {
+		if handles[i] != 0 && isRuntimeAllocated(v) {
+			releaseValue(handles[i])
+			handles[i] = 0
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package ort
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// fakeValueReader returns an ortValueReader that reports elementType and shape for data.
+func fakeValueReader[T any](elementType TensorElementDataType, shape Shape, data []T) *ortValueReader {
+	return &ortValueReader{
+		getTensorTypeAndShape: func(value uintptr, out *uintptr) uintptr {
+			*out = 1
+			return 0
+		},
+		getTensorElementType: func(info uintptr, out *int32) uintptr {
+			*out = int32(elementType)
+			return 0
+		},
+		getDimensionsCount: func(info uintptr, out *uintptr) uintptr {
+			*out = uintptr(len(shape))
+			return 0
+		},
+		getDimensions: func(info uintptr, dims *int64, count uintptr) uintptr {
+			copy(unsafe.Slice(dims, count), shape)
+			return 0
+		},
+		releaseTensorTypeAndShapeInfo: func(info uintptr) {},
+		getTensorMutableData: func(value uintptr, out *unsafe.Pointer) uintptr {
+			*out = unsafe.Pointer(unsafe.SliceData(data))
+			return 0
+		},
+	}
+}
+
+func TestNewRuntimeAllocatedTensor(t *testing.T) {
+	tensor, err := NewRuntimeAllocatedTensor[float32]()
+	if err != nil {
+		t.Fatalf("NewRuntimeAllocatedTensor failed: %v", err)
+	}
+	if tensor.Shape() != nil || tensor.GetData() != nil {
+		t.Fatalf("expected no shape or data before Run, got shape %v data %v", tensor.Shape(), tensor.GetData())
+	}
+	if data, err := tensor.Data(); err != nil || data != nil {
+		t.Fatalf("expected nil data without error before Run, got data=%v err=%v", data, err)
+	}
+	if err := tensor.Destroy(); err != nil {
+		t.Fatalf("Destroy failed: %v", err)
+	}
+	if _, err := tensor.Data(); err == nil || !strings.Contains(err.Error(), "destroyed") {
+		t.Fatalf("expected destroyed error after Destroy, got: %v", err)
+	}
+
+	if _, err := NewRuntimeAllocatedTensor[struct{}](); err == nil {
+		t.Fatalf("expected error for unsupported element type")
+	}
+}
+
+func TestRuntimeAllocatedTensorBindingValidation(t *testing.T) {
+	allocated, err := NewRuntimeAllocatedTensor[float32]()
+	if err != nil {
+		t.Fatalf("NewRuntimeAllocatedTensor failed: %v", err)
+	}
+	input := &Tensor[float32]{handle: 11}
+
+	if err := validateSessionValue(allocated, "output", 0); err != nil {
+		t.Fatalf("expected runtime-allocated output to be accepted, got: %v", err)
+	}
+	if err := validateSessionValue(allocated, "input", 0); err == nil || !strings.Contains(err.Error(), "can only be bound as outputs") {
+		t.Fatalf("expected runtime-allocated input to be rejected, got: %v", err)
+	}
+	if err := validateNoAliasedValues([]string{"x"}, []Value{input}, []string{"a", "b"}, []Value{allocated, allocated}); err == nil || !strings.Contains(err.Error(), `output "b" reuses the value bound to output "a"`) {
+		t.Fatalf("expected aliased runtime-allocated outputs to be rejected, got: %v", err)
+	}
+
+	handles, err := valuesToHandles([]Value{input, allocated}, "output")
+	if err != nil {
+		t.Fatalf("valuesToHandles failed: %v", err)
+	}
+	if handles[0] != 11 || handles[1] != 0 {
+		t.Fatalf("unexpected handles: %v", handles)
+	}
+
+	if err := allocated.Destroy(); err != nil {
+		t.Fatalf("Destroy failed: %v", err)
+	}
+	if err := validateSessionValue(allocated, "output", 0); err == nil || !strings.Contains(err.Error(), "has been destroyed") {
+		t.Fatalf("expected destroyed runtime-allocated output to be rejected, got: %v", err)
+	}
+}
+
+func TestAdvancedSessionRunRuntimeAllocatedOutput(t *testing.T) {
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	const ortOwnedHandle = 500
+	var released []uintptr
+	failRun := false
+	mu.Lock()
+	ortAPI = &OrtApi{}
+	releaseValueFunc = func(handle uintptr) {
+		released = append(released, handle)
+	}
+	runSessionFunc = func(session uintptr, runOptions uintptr, inputNames *uintptr, inputValues *uintptr, inputLen uintptr, outputNames *uintptr, outputLen uintptr, outputValues *uintptr) uintptr {
+		outputs := unsafe.Slice(outputValues, outputLen)
+		if outputs[0] != 0 {
+			t.Errorf("expected a zero handle for the runtime-allocated output, got %d", outputs[0])
+		}
+		outputs[0] = ortOwnedHandle
+		if failRun {
+			return 1
+		}
+		return 0
+	}
+	mu.Unlock()
+
+	output, err := NewRuntimeAllocatedTensor[float32]()
+	if err != nil {
+		t.Fatalf("NewRuntimeAllocatedTensor failed: %v", err)
+	}
+	produced := []float32{1, 2, 3, 4, 5, 6}
+	session := &AdvancedSession{
+		handle:       1,
+		inputNames:   []string{"x"},
+		outputNames:  []string{"y"},
+		inputValues:  []Value{&Tensor[float32]{handle: 11}},
+		outputValues: []Value{output},
+		valueReader:  fakeValueReader(TensorElementDataTypeFloat, Shape{2, 3}, produced),
+	}
+
+	if err := session.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := output.Shape(); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Fatalf("unexpected output shape: %v", got)
+	}
+	if err := ApproxEqual(output.GetData(), produced, 0); err != nil {
+		t.Fatalf("unexpected output data: %v", err)
+	}
+	// The data must be a copy, not a view of the runtime-owned buffer.
+	produced[0] = 100
+	if output.GetData()[0] != 1 {
+		t.Fatalf("expected output data to be copied out of the runtime buffer")
+	}
+	if len(released) != 1 || released[0] != ortOwnedHandle {
+		t.Fatalf("expected the runtime-owned value to be released once, got %v", released)
+	}
+
+	// A failed run still releases whatever ORT allocated.
+	released = nil
+	failRun = true
+	if err := session.Run(); err == nil || !strings.Contains(err.Error(), "failed to run inference") {
+		t.Fatalf("expected run failure, got: %v", err)
+	}
+	if len(released) != 1 || released[0] != ortOwnedHandle {
+		t.Fatalf("expected the runtime-owned value to be released after a failed run, got %v", released)
+	}
+
+	// An element type mismatch is reported instead of reinterpreting the buffer.
+	released = nil
+	failRun = false
+	session.valueReader = fakeValueReader(TensorElementDataTypeInt64, Shape{2, 3}, produced)
+	if err := session.Run(); err == nil || !strings.Contains(err.Error(), `failed to read output "y"`) {
+		t.Fatalf("expected element type mismatch error, got: %v", err)
+	}
+	if len(released) != 1 {
+		t.Fatalf("expected the runtime-owned value to be released after a read failure, got %v", released)
+	}
+}
+
+func TestRuntimeAllocatedFloat64Output(t *testing.T) {
+	// Values that do not survive a float32 round trip, so a 4-byte stride would corrupt them.
+	produced := []float64{math.Pi, 1 + 1.0/(1<<40), -1e300, 0, math.SmallestNonzeroFloat64, 42}
+	output, err := NewRuntimeAllocatedTensor[float64]()
+	if err != nil {
+		t.Fatalf("NewRuntimeAllocatedTensor failed: %v", err)
+	}
+	if err := output.adoptRuntimeOutput(fakeValueReader(TensorElementDataTypeDouble, Shape{3, 2}, produced), 1); err != nil {
+		t.Fatalf("adoptRuntimeOutput failed: %v", err)
+	}
+	if got := output.GetData(); !reflect.DeepEqual(got, produced) {
+		t.Fatalf("unexpected float64 output: got %v, want %v", got, produced)
+	}
+
+	mismatched, err := NewRuntimeAllocatedTensor[float64]()
+	if err != nil {
+		t.Fatalf("NewRuntimeAllocatedTensor failed: %v", err)
+	}
+	if err := mismatched.adoptRuntimeOutput(fakeValueReader(TensorElementDataTypeFloat, Shape{2}, []float32{1, 2}), 1); err == nil || !strings.Contains(err.Error(), "element type") {
+		t.Fatalf("expected element type mismatch for float32 data, got: %v", err)
+	}
+}
+
+func TestAdvancedSessionRunRuntimeAllocatedOutputWithLinearModel(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	// The caller does not tell the session the output width; it is read back after Run.
+	modelPath := writeLinearTestModel(t, "features", "projected", 3, 2, []float32{
+		1, 0,
+		0, 1,
+		2, -1,
+	})
+	input, err := NewTensor(Shape{2, 3}, []float32{1, 2, 3, 0, 0, 1})
+	if err != nil {
+		t.Fatalf("NewTensor failed: %v", err)
+	}
+	defer func() { _ = input.Destroy() }()
+	output, err := NewRuntimeAllocatedTensor[float32]()
+	if err != nil {
+		t.Fatalf("NewRuntimeAllocatedTensor failed: %v", err)
+	}
+	defer func() { _ = output.Destroy() }()
+
+	session, err := NewAdvancedSession(modelPath, []string{"features"}, []string{"projected"}, []Value{input}, []Value{output}, nil)
+	if err != nil {
+		t.Fatalf("NewAdvancedSession failed: %v", err)
+	}
+	defer func() { _ = session.Destroy() }()
+
+	if err := session.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := output.Shape(); len(got) != 2 || got[0] != 2 || got[1] != 2 {
+		t.Fatalf("expected auto-detected output shape [2 2], got %v", got)
+	}
+	if err := ApproxEqual(output.GetData(), []float32{7, -1, 2, -1}, 1e-6); err != nil {
+		t.Fatalf("unexpected output data: %v", err)
+	}
+}
@@ -15,13 +15,32 @@ type AdvancedSession struct {
 	outputNames  []string
 	inputValues  []Value
 	outputValues []Value
-	runMu        sync.Mutex
+	runObserver  RunObserver
+	// valueReader reads runtime-allocated outputs; created on the first Run that needs it.
+	valueReader *ortValueReader
+	// tracked is set for sessions counted in liveSessions.
+	tracked bool
+	runMu   sync.Mutex
+
+	// statsMu guards the run statistics, which are read without waiting for runMu.
+	statsMu      sync.Mutex
+	runSuccesses uint64
+	runFailures  uint64
+	lastRunErr   error
 }
 
+// RunObserver is invoked after each successful Run with the session's bound input and
+// output values keyed by name, so callers can dump what went into and came out of the model.
+// It runs while the session is locked: it must not call Run or Destroy on the same session,
+// and it must not retain the values beyond the callback.
+type RunObserver func(inputs, outputs map[string]Value)
+
 // NewAdvancedSession creates a new session with specified inputs and outputs.
 // Callers retain ownership of input/output values and must keep them alive.
 // Values must not be Destroy()'d while this session may still Run().
-// If a value is destroyed early, Run() returns a "...value at index N has been destroyed" error.
+// If a value is destroyed early, Run() returns a "...value at index N has been destroyed" error
+// and the tensor's Destroy logs a warning.
+// Nil options use the SetDefaultSessionOptions default, or fresh default options if none is set.
 func NewAdvancedSession(modelPath string, inputNames []string, outputNames []string,
 	inputValues []Value, outputValues []Value, options *SessionOptions) (*AdvancedSession, error) {
 	if modelPath == "" {
@@ -39,9 +58,21 @@ func NewAdvancedSession(modelPath string, inputNames []string, outputNames []str
 	if len(outputNames) != len(outputValues) {
 		return nil, fmt.Errorf("output names/values count mismatch: got %d names and %d values", len(outputNames), len(outputValues))
 	}
+	if err := validateUniqueNames(inputNames, "input"); err != nil {
+		return nil, err
+	}
+	if err := validateUniqueNames(outputNames, "output"); err != nil {
+		return nil, err
+	}
+	if err := validateBatchDimensions(inputNames, inputValues); err != nil {
+		return nil, err
+	}
 	if options != nil && options.handle == 0 {
 		return nil, fmt.Errorf("session options handle is not initialized")
 	}
+	if err := checkStrictInputTypes(modelPath, inputNames, inputValues, options); err != nil {
+		return nil, err
+	}
 
 	ortCallMu.RLock()
 	defer ortCallMu.RUnlock()
@@ -57,6 +88,9 @@ func NewAdvancedSession(modelPath string, inputNames []string, outputNames []str
 			return nil, err
 		}
 	}
+	if err := validateNoAliasedValues(inputNames, inputValues, outputNames, outputValues); err != nil {
+		return nil, err
+	}
 
 	mu.Lock()
 	// Safe to snapshot under mu here because ortCallMu.RLock is already held.
@@ -69,6 +103,13 @@ func NewAdvancedSession(modelPath string, inputNames []string, outputNames []str
 	createSessionOptions := createSessionOptionsFunc
 	releaseSessionOptions := releaseSessionOptionsFunc
 	createSession := createSessionFunc
+	if options == nil && defaultSessionOptions != nil {
+		options = defaultSessionOptions
+		if options.handle == 0 {
+			mu.Unlock()
+			return nil, fmt.Errorf("default session options have been destroyed; call SetDefaultSessionOptions(nil) before destroying them")
+		}
+	}
 	mu.Unlock()
 
 	sessionOptionsHandle := uintptr(0)
@@ -98,6 +139,7 @@ func NewAdvancedSession(modelPath string, inputNames []string, outputNames []str
 	// modelPathBacking owns the native char buffer returned by goStringToORTChar.
 	// Keep it alive until createSession returns.
 	runtime.KeepAlive(modelPathBacking)
+	runtime.KeepAlive(options)
 	if status != 0 {
 		errMsg := getErrorMessage(status)
 		releaseStatus(status)
@@ -110,8 +152,15 @@ func NewAdvancedSession(modelPath string, inputNames []string, outputNames []str
 		outputNames:  cloneStringSlice(outputNames),
 		inputValues:  cloneValueSlice(inputValues),
 		outputValues: cloneValueSlice(outputValues),
+		tracked:      true,
 	}
 
+	mu.Lock()
+	adjustSessionBindings(session.inputValues, 1)
+	adjustSessionBindings(session.outputValues, 1)
+	liveSessions++
+	mu.Unlock()
+
 	runtime.SetFinalizer(session, func(s *AdvancedSession) {
 		_ = s.Destroy()
 	})
@@ -119,6 +168,39 @@ func NewAdvancedSession(modelPath string, inputNames []string, outputNames []str
 	return session, nil
 }
 
+// SetRunObserver installs observer to be called after each successful Run.
+// Passing nil removes it; without an observer Run does no extra work.
+func (s *AdvancedSession) SetRunObserver(observer RunObserver) {
+	if s == nil {
+		return
+	}
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	s.runObserver = observer
+}
+
+// RunCount returns how many Run calls on the session succeeded and failed, for
+// health and diagnostics endpoints. It is safe to call concurrently with Run.
+func (s *AdvancedSession) RunCount() (success, failure uint64) {
+	if s == nil {
+		return 0, 0
+	}
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	return s.runSuccesses, s.runFailures
+}
+
+// LastError returns the error of the most recent failed Run, or nil if no Run has
+// failed. A later successful Run does not clear it.
+func (s *AdvancedSession) LastError() error {
+	if s == nil {
+		return nil
+	}
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	return s.lastRunErr
+}
+
 // Run executes inference on the session.
 // Calls are intentionally serialized per session instance via runMu because this MVP
 // binds fixed input/output value handles onto the session object.
@@ -126,7 +208,20 @@ func (s *AdvancedSession) Run() error {
 	if s == nil {
 		return fmt.Errorf("session is nil")
 	}
+	err := s.run()
+
+	s.statsMu.Lock()
+	if err != nil {
+		s.runFailures++
+		s.lastRunErr = err
+	} else {
+		s.runSuccesses++
+	}
+	s.statsMu.Unlock()
+	return err
+}
 
+func (s *AdvancedSession) run() error {
 	// Lock order here is runMu -> ortCallMu -> mu.
 	s.runMu.Lock()
 	defer s.runMu.Unlock()
@@ -142,6 +237,7 @@ func (s *AdvancedSession) Run() error {
 		outputNames   []string
 		inputValues   []Value
 		outputValues  []Value
+		observer      RunObserver
 		run           func(session uintptr, runOptions uintptr, inputNames *uintptr, inputValues *uintptr, inputLen uintptr, outputNames *uintptr, outputLen uintptr, outputValues *uintptr) uintptr
 	)
 
@@ -163,6 +259,7 @@ func (s *AdvancedSession) Run() error {
 	outputNames = s.outputNames
 	inputValues = s.inputValues
 	outputValues = s.outputValues
+	observer = s.runObserver
 
 	// Global runtime pointers/functions are guarded by mu.
 	// Safe to snapshot under mu here because ortCallMu.RLock is already held.
@@ -173,6 +270,8 @@ func (s *AdvancedSession) Run() error {
 		return fmt.Errorf("ONNX Runtime not initialized")
 	}
 	run = runSessionFunc
+	api := ortAPI
+	releaseValue := releaseValueFunc
 	mu.Unlock()
 
 	inputNameBackings, inputNamePtrs := makeCStringPointerArray(inputNames)
@@ -205,15 +304,33 @@ func (s *AdvancedSession) Run() error {
 	runtime.KeepAlive(inputValueHandles)
 	runtime.KeepAlive(outputValueHandles)
 	if status != 0 {
+		releaseRuntimeAllocatedOutputs(outputValues, outputValueHandles, releaseValue)
 		errMsg := getErrorMessage(status)
 		releaseStatus(status)
 		return fmt.Errorf("failed to run inference: %s", errMsg)
 	}
+	if err := s.adoptRuntimeAllocatedOutputs(api, outputNames, outputValues, outputValueHandles, releaseValue); err != nil {
+		return err
+	}
+
+	if observer != nil {
+		observer(namedValues(inputNames, inputValues), namedValues(outputNames, outputValues))
+	}
 
 	return nil
 }
 
-// Destroy releases the session resources
+func namedValues(names []string, values []Value) map[string]Value {
+	named := make(map[string]Value, len(names))
+	for i, name := range names {
+		named[name] = values[i]
+	}
+	return named
+}
+
+// Destroy releases the session resources.
+// A session must be destroyed before the values it references: destroy the session
+// first, then its input and output tensors.
 func (s *AdvancedSession) Destroy() error {
 	if s == nil {
 		return nil
@@ -237,11 +354,19 @@ func (s *AdvancedSession) Destroy() error {
 	mu.Lock()
 	handle = s.handle
 	releaseSession = releaseSessionFunc
+	if handle != 0 {
+		adjustSessionBindings(s.inputValues, -1)
+		adjustSessionBindings(s.outputValues, -1)
+		if s.tracked {
+			liveSessions--
+		}
+	}
 	s.handle = 0
 	s.inputNames = nil
 	s.outputNames = nil
 	s.inputValues = nil
 	s.outputValues = nil
+	s.runObserver = nil
 	runtime.SetFinalizer(s, nil)
 	mu.Unlock()
 
@@ -252,6 +377,22 @@ func (s *AdvancedSession) Destroy() error {
 	return nil
 }
 
+// sessionBindable is implemented by values that track how many live sessions
+// reference them, so destroying one too early can be reported.
+type sessionBindable interface {
+	adjustSessionBindings(delta int)
+}
+
+// adjustSessionBindings records values being bound to (delta=1) or released from
+// (delta=-1) a session. Callers must hold mu.
+func adjustSessionBindings(values []Value, delta int) {
+	for _, v := range values {
+		if bindable, ok := v.(sessionBindable); ok {
+			bindable.adjustSessionBindings(delta)
+		}
+	}
+}
+
 // valueWithORTHandle is intentionally package-private.
 // Today, sessions only support Value implementations created by this package.
 type valueWithORTHandle interface {
@@ -280,6 +421,12 @@ func valueHandle(v Value) (uintptr, error) {
 }
 
 func validateSessionValue(v Value, role string, index int) error {
+	if isRuntimeAllocated(v) {
+		if role != "output" {
+			return fmt.Errorf("%s value at index %d is runtime-allocated; runtime-allocated tensors can only be bound as outputs", role, index)
+		}
+		return nil
+	}
 	_, err := valueHandle(v)
 	if err == nil {
 		return nil
@@ -290,12 +437,148 @@ func validateSessionValue(v Value, role string, index int) error {
 	return fmt.Errorf("invalid %s value at index %d: %w", role, index, err)
 }
 
+func validateUniqueNames(names []string, role string) error {
+	seen := make(map[string]int, len(names))
+	for i, name := range names {
+		if first, ok := seen[name]; ok {
+			return fmt.Errorf("duplicate %s name %q at indices %d and %d", role, name, first, i)
+		}
+		seen[name] = i
+	}
+	return nil
+}
+
+// shapedValue is implemented by values that expose their shape, such as *Tensor[T].
+type shapedValue interface {
+	Shape() Shape
+}
+
+// validateBatchDimensions checks that input values exposing a shape of rank >= 2 agree on
+// dimension 0, the batch dimension. Lower-rank inputs (scalars and per-model parameter
+// vectors) and values without a shape accessor are not checked.
+func validateBatchDimensions(names []string, values []Value) error {
+	first := -1
+	var firstShape Shape
+	for i, v := range values {
+		shaped, ok := v.(shapedValue)
+		if !ok {
+			continue
+		}
+		shape := shaped.Shape()
+		if len(shape) < 2 {
+			continue
+		}
+		if first < 0 {
+			first, firstShape = i, shape
+			continue
+		}
+		if shape[0] != firstShape[0] {
+			return fmt.Errorf(
+				"input %q has batch dimension %d but input %q has batch dimension %d (shapes %v and %v)",
+				names[i], shape[0], names[first], firstShape[0], shape, firstShape,
+			)
+		}
+	}
+	return nil
+}
+
+// elementTypedValue is implemented by values that know their element type, such as *Tensor[T].
+type elementTypedValue interface {
+	elementDataType() TensorElementDataType
+}
+
+// checkStrictInputTypes runs validateInputElementTypes when the effective session options
+// (options, or the SetDefaultSessionOptions default) enable WithStrictInputTypeCheck.
+// It must be called without ortCallMu held, since it reads the model metadata.
+func checkStrictInputTypes(modelPath string, inputNames []string, inputValues []Value, options *SessionOptions) error {
+	if options == nil {
+		mu.Lock()
+		options = defaultSessionOptions
+		mu.Unlock()
+	}
+	if options == nil || !options.strictInputTypeCheck {
+		return nil
+	}
+	inputs, _, err := GetInputOutputInfo(modelPath)
+	if err != nil {
+		return fmt.Errorf("strict input type check failed to inspect model: %w", err)
+	}
+	return validateInputElementTypes(inputs, inputNames, inputValues)
+}
+
+// validateInputElementTypes checks each input value's element type against the type the
+// model declares for its name. Values without an element type accessor are not checked.
+func validateInputElementTypes(declared []InputOutputInfo, inputNames []string, inputValues []Value) error {
+	for i, name := range inputNames {
+		info, ok := findInputOutputInfo(declared, name)
+		if !ok {
+			return fmt.Errorf("model has no input %q (inputs: %s)", name, joinInputOutputNames(declared))
+		}
+		typed, ok := inputValues[i].(elementTypedValue)
+		if !ok || info.OrtValueType != ONNXTypeTensor {
+			continue
+		}
+		if got := typed.elementDataType(); got != info.DataType {
+			return fmt.Errorf("input %q (index %d) has element type %d, but the model declares element type %d; check that input values are in the same order as input names", name, i, got, info.DataType)
+		}
+	}
+	return nil
+}
+
+// validateNoAliasedValues rejects an OrtValue handle bound as an output that is also bound
+// as an input or as another output; ORT does not define the result of writing into a
+// buffer it is reading from. Feeding one value to several inputs is allowed.
+// Callers must have validated the values and hold ortCallMu.
+func validateNoAliasedValues(inputNames []string, inputValues []Value, outputNames []string, outputValues []Value) error {
+	inputIndex := make(map[uintptr]int, len(inputValues))
+	for i, v := range inputValues {
+		handle, err := valueHandle(v)
+		if err != nil {
+			return err
+		}
+		if _, ok := inputIndex[handle]; !ok {
+			inputIndex[handle] = i
+		}
+	}
+	outputIndex := make(map[uintptr]int, len(outputValues))
+	// Runtime-allocated outputs have no handle until Run, so they are keyed by identity.
+	allocatedIndex := make(map[Value]int)
+	for i, v := range outputValues {
+		if isRuntimeAllocated(v) {
+			if j, ok := allocatedIndex[v]; ok {
+				return fmt.Errorf("output %q reuses the value bound to output %q; each output needs its own value", outputNames[i], outputNames[j])
+			}
+			allocatedIndex[v] = i
+			continue
+		}
+		handle, err := valueHandle(v)
+		if err != nil {
+			return err
+		}
+		if j, ok := inputIndex[handle]; ok {
+			return fmt.Errorf("output %q reuses the value bound to input %q; inputs and outputs must be distinct values", outputNames[i], inputNames[j])
+		}
+		if j, ok := outputIndex[handle]; ok {
+			return fmt.Errorf("output %q reuses the value bound to output %q; each output needs its own value", outputNames[i], outputNames[j])
+		}
+		outputIndex[handle] = i
+	}
+	return nil
+}
+
 func valuesToHandles(values []Value, role string) ([]uintptr, error) {
 	if len(values) == 0 {
 		return nil, nil
 	}
 	handles := make([]uintptr, len(values))
 	for i, v := range values {
+		if isRuntimeAllocated(v) {
+			if role != "output" {
+				return nil, fmt.Errorf("%s value at index %d is runtime-allocated; runtime-allocated tensors can only be bound as outputs", role, i)
+			}
+			// ORT allocates the value during Run and writes its handle into this slot.
+			continue
+		}
 		handle, err := valueHandle(v)
 		if err != nil {
 			if errors.Is(err, errValueDestroyed) {
@@ -0,0 +1,425 @@
+package ort
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+const (
+	// CPUExecutionProviderName is the default provider ONNX Runtime always falls back to.
+	CPUExecutionProviderName = "CPUExecutionProvider"
+	// CUDAExecutionProviderName is the NVIDIA CUDA execution provider.
+	CUDAExecutionProviderName = "CUDAExecutionProvider"
+	// CoreMLExecutionProviderName is the Apple CoreML execution provider.
+	CoreMLExecutionProviderName = "CoreMLExecutionProvider"
+)
+
+// genericProviderNames maps GetAvailableProviders names to the short names accepted by
+// OrtApi::SessionOptionsAppendExecutionProvider.
+var genericProviderNames = map[string]string{
+	CoreMLExecutionProviderName: "CoreML",
+	"QNNExecutionProvider":      "QNN",
+	"OpenVINOExecutionProvider": "OpenVINO",
+	"XnnpackExecutionProvider":  "XNNPACK",
+	"SNPEExecutionProvider":     "SNPE",
+	"WebGpuExecutionProvider":   "WebGPU",
+	"AzureExecutionProvider":    "AZURE",
+	"VitisAIExecutionProvider":  "VitisAI",
+}
+
+// ProviderSpec names an execution provider and its provider-specific options.
+type ProviderSpec struct {
+	// Name is the provider name as reported by GetAvailableProviders, for example
+	// "CUDAExecutionProvider", "CoreMLExecutionProvider", or "CPUExecutionProvider".
+	Name string
+	// Options are provider-specific settings, for example {"device_id": "0"} for CUDA.
+	Options map[string]string
+}
+
+// SessionOption customizes NewSessionOptions.
+type SessionOption func(*sessionOptionsConfig) error
+
+type sessionOptionsConfig struct {
+	providers            []ProviderSpec
+	strictInputTypeCheck bool
+	customOpsLibraries   []string
+}
+
+// registerCustomOpsLibraryFunc registers one custom-op library; tests replace it.
+var registerCustomOpsLibraryFunc = registerCustomOpsLibrary
+
+// WithExecutionProviderPriority appends execution providers in the given order, so ONNX
+// Runtime assigns each graph node to the first listed provider that supports it.
+// Providers this runtime build does not ship (per GetAvailableProviders) or that fail to
+// initialize on this machine are skipped with a log line, so one configuration works
+// across machines. CPUExecutionProvider is always the final fallback; if listed, it must
+// be last.
+func WithExecutionProviderPriority(providers []ProviderSpec) SessionOption {
+	return func(cfg *sessionOptionsConfig) error {
+		if len(providers) == 0 {
+			return fmt.Errorf("execution provider list cannot be empty")
+		}
+		seen := make(map[string]bool, len(providers))
+		specs := make([]ProviderSpec, len(providers))
+		for i, provider := range providers {
+			if provider.Name == "" {
+				return fmt.Errorf("execution provider name at index %d cannot be empty", i)
+			}
+			if seen[provider.Name] {
+				return fmt.Errorf("duplicate execution provider %q", provider.Name)
+			}
+			seen[provider.Name] = true
+			if provider.Name == CPUExecutionProviderName && i != len(providers)-1 {
+				return fmt.Errorf("%s is the implicit final fallback and must be listed last", CPUExecutionProviderName)
+			}
+			specs[i] = ProviderSpec{Name: provider.Name, Options: cloneStringMap(provider.Options)}
+		}
+		cfg.providers = specs
+		return nil
+	}
+}
+
+// WithStrictInputTypeCheck makes NewAdvancedSession compare the element type of each
+// input tensor with the type the model declares for that input name, failing session
+// creation on a mismatch. This catches input values passed in a different order than
+// their names, such as a float mask bound to input_ids, which ONNX Runtime would
+// otherwise only reject at Run or silently accept when the types happen to agree.
+// The check reads the model metadata once per model (see GetInputOutputInfo).
+func WithStrictInputTypeCheck() SessionOption {
+	return func(cfg *sessionOptionsConfig) error {
+		cfg.strictInputTypeCheck = true
+		return nil
+	}
+}
+
+// WithCustomOpsLibrary registers the custom-op shared library at path on the session
+// options, so models with domain-specific operators (for example onnxruntime-extensions
+// tokenizer ops) can be loaded. ONNX Runtime loads the library when the options are
+// created and unloads it once the options and every session created from them are
+// released. Repeat the option to register several libraries.
+func WithCustomOpsLibrary(path string) SessionOption {
+	return func(cfg *sessionOptionsConfig) error {
+		if path == "" {
+			return fmt.Errorf("custom ops library path cannot be empty")
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("custom ops library %q is not usable: %w", path, err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("custom ops library %q is a directory", path)
+		}
+		cfg.customOpsLibraries = append(cfg.customOpsLibraries, path)
+		return nil
+	}
+}
+
+// NewSessionOptions creates session options for NewAdvancedSession.
+// The caller owns the returned options and must call Destroy once no more sessions
+// are being created from them; sessions already created are unaffected.
+func NewSessionOptions(opts ...SessionOption) (*SessionOptions, error) {
+	var cfg sessionOptionsConfig
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	ortCallMu.RLock()
+	defer ortCallMu.RUnlock()
+
+	mu.Lock()
+	if ortAPI == nil || createSessionOptionsFunc == nil || releaseSessionOptionsFunc == nil {
+		mu.Unlock()
+		return nil, fmt.Errorf("ONNX Runtime not initialized")
+	}
+	api, version := ortAPI, apiVersion
+	createSessionOptions := createSessionOptionsFunc
+	releaseSessionOptions := releaseSessionOptionsFunc
+	mu.Unlock()
+
+	var handle uintptr
+	if status := createSessionOptions(&handle); status != 0 {
+		errMsg := getErrorMessage(status)
+		releaseStatus(status)
+		return nil, fmt.Errorf("failed to create session options: %s", errMsg)
+	}
+
+	var appended []string
+	if len(cfg.providers) > 0 {
+		available, err := availableProviders(api)
+		if err == nil {
+			appended, err = appendExecutionProviders(cfg.providers, available, func(spec ProviderSpec) error {
+				return appendExecutionProvider(api, handle, spec)
+			})
+		}
+		if err != nil {
+			releaseSessionOptions(handle)
+			return nil, err
+		}
+	}
+	for _, path := range cfg.customOpsLibraries {
+		if err := registerCustomOpsLibraryFunc(api, version, handle, path); err != nil {
+			releaseSessionOptions(handle)
+			return nil, err
+		}
+	}
+
+	options := &SessionOptions{handle: handle, executionProviders: appended, strictInputTypeCheck: cfg.strictInputTypeCheck}
+	// Finalizer is a safety net to avoid leaking OrtSessionOptions if callers forget Destroy().
+	runtime.SetFinalizer(options, func(o *SessionOptions) {
+		_ = o.Destroy()
+	})
+	return options, nil
+}
+
+// SetDefaultSessionOptions makes NewAdvancedSession use options whenever it is called
+// with nil options, so settings such as execution providers are configured once per
+// process. Passing nil restores fresh default options per session. The caller keeps
+// ownership: clear the default before destroying options that are set as the default.
+// The default is cleared when the last environment reference is destroyed.
+func SetDefaultSessionOptions(options *SessionOptions) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if options != nil && options.handle == 0 {
+		return fmt.Errorf("session options handle is not initialized")
+	}
+	defaultSessionOptions = options
+	return nil
+}
+
+// ExecutionProviders returns the providers appended by WithExecutionProviderPriority,
+// in priority order, after unavailable ones were skipped.
+func (o *SessionOptions) ExecutionProviders() []string {
+	if o == nil {
+		return nil
+	}
+	return cloneStringSlice(o.executionProviders)
+}
+
+// Destroy releases the session options. It is safe to call more than once.
+func (o *SessionOptions) Destroy() error {
+	if o == nil {
+		return nil
+	}
+
+	ortCallMu.RLock()
+	defer ortCallMu.RUnlock()
+
+	mu.Lock()
+	handle := o.handle
+	releaseSessionOptions := releaseSessionOptionsFunc
+	o.handle = 0
+	runtime.SetFinalizer(o, nil)
+	mu.Unlock()
+
+	if handle != 0 && releaseSessionOptions != nil {
+		releaseSessionOptions(handle)
+	}
+	return nil
+}
+
+// GetAvailableProviders returns the execution providers compiled into the loaded
+// ONNX Runtime library, in ONNX Runtime's default priority order. A listed provider
+// may still fail to initialize if its device libraries are missing.
+func GetAvailableProviders() ([]string, error) {
+	ortCallMu.RLock()
+	defer ortCallMu.RUnlock()
+
+	mu.Lock()
+	api := ortAPI
+	mu.Unlock()
+	if api == nil {
+		return nil, fmt.Errorf("ONNX Runtime not initialized")
+	}
+	return availableProviders(api)
+}
+
+// availableProviders calls OrtApi::GetAvailableProviders. Callers must hold ortCallMu.
+func availableProviders(api *OrtApi) ([]string, error) {
+	var getAvailable func(out *uintptr, length *int32) uintptr
+	var releaseAvailable func(ptr uintptr, length int32) uintptr
+	purego.RegisterFunc(&getAvailable, api.GetAvailableProviders)
+	purego.RegisterFunc(&releaseAvailable, api.ReleaseAvailableProviders)
+
+	var namesPtr uintptr
+	var count int32
+	if status := getAvailable(&namesPtr, &count); status != 0 {
+		errMsg := getErrorMessage(status)
+		releaseStatus(status)
+		return nil, fmt.Errorf("failed to get available execution providers: %s", errMsg)
+	}
+	defer func() {
+		if status := releaseAvailable(namesPtr, count); status != 0 {
+			releaseStatus(status)
+		}
+	}()
+
+	if namesPtr == 0 || count <= 0 {
+		return []string{}, nil
+	}
+	// #nosec G103 -- Reads the char* array returned by ORT; it stays valid until ReleaseAvailableProviders.
+	namePtrs := unsafe.Slice((*uintptr)(unsafe.Pointer(namesPtr)), int(count))
+	names := make([]string, len(namePtrs))
+	for i, ptr := range namePtrs {
+		names[i] = CstringToGo(ptr)
+	}
+	return names, nil
+}
+
+// appendExecutionProviders appends specs in order through appendProvider, skipping
+// providers missing from available and providers whose append fails. The CPU provider
+// is never appended explicitly since ONNX Runtime always registers it last.
+// It returns the names of the providers that were kept.
+func appendExecutionProviders(specs []ProviderSpec, available []string, appendProvider func(ProviderSpec) error) ([]string, error) {
+	isAvailable := make(map[string]bool, len(available))
+	for _, name := range available {
+		isAvailable[name] = true
+	}
+
+	appended := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		if spec.Name == CPUExecutionProviderName {
+			appended = append(appended, spec.Name)
+			continue
+		}
+		if !isAvailable[spec.Name] {
+			log.Printf("INFO: skipping execution provider %q: not available in this ONNX Runtime build", spec.Name)
+			continue
+		}
+		if err := appendProvider(spec); err != nil {
+			log.Printf("WARNING: skipping execution provider %q: %v", spec.Name, err)
+			continue
+		}
+		appended = append(appended, spec.Name)
+	}
+	return appended, nil
+}
+
+// appendExecutionProvider registers one provider on the session options handle.
+// Callers must hold ortCallMu.
+func appendExecutionProvider(api *OrtApi, optionsHandle uintptr, spec ProviderSpec) error {
+	keys, values := sortedStringMap(spec.Options)
+	keyBackings, keyPtrs := makeCStringPointerArray(keys)
+	valueBackings, valuePtrs := makeCStringPointerArray(values)
+	defer func() {
+		runtime.KeepAlive(keyBackings)
+		runtime.KeepAlive(valueBackings)
+		runtime.KeepAlive(keyPtrs)
+		runtime.KeepAlive(valuePtrs)
+	}()
+
+	if spec.Name == CUDAExecutionProviderName {
+		return appendCUDAExecutionProvider(api, optionsHandle, keyPtrs, valuePtrs)
+	}
+
+	shortName, ok := genericProviderNames[spec.Name]
+	if !ok {
+		return fmt.Errorf("execution provider %q is not supported by this package", spec.Name)
+	}
+	var appendProvider func(options uintptr, name uintptr, keys *uintptr, values *uintptr, numKeys uintptr) uintptr
+	purego.RegisterFunc(&appendProvider, api.SessionOptionsAppendExecutionProvider)
+
+	nameBytes, namePtr := GoToCstring(shortName)
+	status := appendProvider(optionsHandle, namePtr, uintptrSlicePtr(keyPtrs), uintptrSlicePtr(valuePtrs), uintptr(len(keyPtrs)))
+	runtime.KeepAlive(nameBytes)
+	if status != 0 {
+		errMsg := getErrorMessage(status)
+		releaseStatus(status)
+		return fmt.Errorf("failed to append execution provider: %s", errMsg)
+	}
+	return nil
+}
+
+// registerCustomOpsLibrary calls OrtApi::RegisterCustomOpsLibrary_V2, which, unlike
+// RegisterCustomOpsLibrary, leaves unloading the library to ONNX Runtime. The function
+// was added in API version 14, so older tables do not contain it.
+func registerCustomOpsLibrary(api *OrtApi, version uint32, optionsHandle uintptr, path string) error {
+	if !apiProvides(api, version, "RegisterCustomOpsLibrary_V2") {
+		return fmt.Errorf("ONNX Runtime API version %d does not provide RegisterCustomOpsLibrary_V2", version)
+	}
+	var register func(options uintptr, libraryName uintptr) uintptr
+	purego.RegisterFunc(&register, api.RegisterCustomOpsLibrary_V2)
+
+	pathPtr, pathBacking, err := goStringToORTChar(path)
+	if err != nil {
+		return fmt.Errorf("failed to convert custom ops library path %q: %w", path, err)
+	}
+	status := register(optionsHandle, pathPtr)
+	runtime.KeepAlive(pathBacking)
+	if status != 0 {
+		errMsg := getErrorMessage(status)
+		releaseStatus(status)
+		return fmt.Errorf("failed to register custom ops library %q: %s", path, errMsg)
+	}
+	return nil
+}
+
+func appendCUDAExecutionProvider(api *OrtApi, optionsHandle uintptr, keyPtrs []uintptr, valuePtrs []uintptr) error {
+	var (
+		createOptions  func(out *uintptr) uintptr
+		updateOptions  func(cudaOptions uintptr, keys *uintptr, values *uintptr, numKeys uintptr) uintptr
+		appendProvider func(options uintptr, cudaOptions uintptr) uintptr
+		releaseOptions func(cudaOptions uintptr)
+	)
+	purego.RegisterFunc(&createOptions, api.CreateCUDAProviderOptions)
+	purego.RegisterFunc(&updateOptions, api.UpdateCUDAProviderOptions)
+	purego.RegisterFunc(&appendProvider, api.SessionOptionsAppendExecutionProvider_CUDA_V2)
+	purego.RegisterFunc(&releaseOptions, api.ReleaseCUDAProviderOptions)
+
+	var cudaOptions uintptr
+	if status := createOptions(&cudaOptions); status != 0 {
+		errMsg := getErrorMessage(status)
+		releaseStatus(status)
+		return fmt.Errorf("failed to create CUDA provider options: %s", errMsg)
+	}
+	defer releaseOptions(cudaOptions)
+
+	if len(keyPtrs) > 0 {
+		if status := updateOptions(cudaOptions, uintptrSlicePtr(keyPtrs), uintptrSlicePtr(valuePtrs), uintptr(len(keyPtrs))); status != 0 {
+			errMsg := getErrorMessage(status)
+			releaseStatus(status)
+			return fmt.Errorf("failed to set CUDA provider options: %s", errMsg)
+		}
+	}
+	if status := appendProvider(optionsHandle, cudaOptions); status != 0 {
+		errMsg := getErrorMessage(status)
+		releaseStatus(status)
+		return fmt.Errorf("failed to append execution provider: %s", errMsg)
+	}
+	return nil
+}
+
+func cloneStringMap(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	cloned := make(map[string]string, len(values))
+	for key, value := range values {
+		cloned[key] = value
+	}
+	return cloned
+}
+
+// sortedStringMap returns the keys of values in sorted order with their matching values.
+func sortedStringMap(values map[string]string) ([]string, []string) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	ordered := make([]string, len(keys))
+	for i, key := range keys {
+		ordered[i] = values[key]
+	}
+	return keys, ordered
+}
@@ -0,0 +1,308 @@
+package ort
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithExecutionProviderPriorityValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []ProviderSpec
+		wantErr   string
+	}{
+		{name: "empty", providers: nil, wantErr: "cannot be empty"},
+		{name: "empty name", providers: []ProviderSpec{{Name: ""}}, wantErr: "name at index 0 cannot be empty"},
+		{
+			name:      "duplicate",
+			providers: []ProviderSpec{{Name: CUDAExecutionProviderName}, {Name: CUDAExecutionProviderName}},
+			wantErr:   "duplicate execution provider",
+		},
+		{
+			name:      "cpu not last",
+			providers: []ProviderSpec{{Name: CPUExecutionProviderName}, {Name: CUDAExecutionProviderName}},
+			wantErr:   "must be listed last",
+		},
+		{
+			name: "valid",
+			providers: []ProviderSpec{
+				{Name: CUDAExecutionProviderName, Options: map[string]string{"device_id": "0"}},
+				{Name: CoreMLExecutionProviderName},
+				{Name: CPUExecutionProviderName},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg sessionOptionsConfig
+			err := WithExecutionProviderPriority(tt.providers)(&cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(cfg.providers) != len(tt.providers) {
+					t.Fatalf("expected %d providers, got %d", len(tt.providers), len(cfg.providers))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWithExecutionProviderPriorityCopiesOptions(t *testing.T) {
+	options := map[string]string{"device_id": "0"}
+	var cfg sessionOptionsConfig
+	if err := WithExecutionProviderPriority([]ProviderSpec{{Name: CUDAExecutionProviderName, Options: options}})(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	options["device_id"] = "1"
+	if got := cfg.providers[0].Options["device_id"]; got != "0" {
+		t.Fatalf("expected provider options to be copied, got device_id=%q", got)
+	}
+}
+
+func TestAppendExecutionProvidersOrderAndSkips(t *testing.T) {
+	specs := []ProviderSpec{
+		{Name: "TensorrtExecutionProvider"},
+		{Name: CUDAExecutionProviderName, Options: map[string]string{"device_id": "0"}},
+		{Name: "DmlExecutionProvider"},
+		{Name: CoreMLExecutionProviderName},
+		{Name: CPUExecutionProviderName},
+	}
+	available := []string{CoreMLExecutionProviderName, "DmlExecutionProvider", CUDAExecutionProviderName, CPUExecutionProviderName}
+
+	var calls []string
+	appended, err := appendExecutionProviders(specs, available, func(spec ProviderSpec) error {
+		calls = append(calls, spec.Name)
+		if spec.Name == "DmlExecutionProvider" {
+			return errors.New("device not found")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantCalls := []string{CUDAExecutionProviderName, "DmlExecutionProvider", CoreMLExecutionProviderName}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Fatalf("providers appended in wrong order: got %v, want %v", calls, wantCalls)
+	}
+	wantAppended := []string{CUDAExecutionProviderName, CoreMLExecutionProviderName, CPUExecutionProviderName}
+	if !reflect.DeepEqual(appended, wantAppended) {
+		t.Fatalf("unexpected appended providers: got %v, want %v", appended, wantAppended)
+	}
+}
+
+func TestSortedStringMap(t *testing.T) {
+	keys, values := sortedStringMap(map[string]string{"b": "2", "a": "1", "c": "3"})
+	if !reflect.DeepEqual(keys, []string{"a", "b", "c"}) || !reflect.DeepEqual(values, []string{"1", "2", "3"}) {
+		t.Fatalf("unexpected sorted map: keys=%v values=%v", keys, values)
+	}
+}
+
+func TestNewSessionOptionsWithoutORT(t *testing.T) {
+	resetEnvironmentState()
+
+	_, err := NewSessionOptions()
+	if err == nil || !strings.Contains(err.Error(), "ONNX Runtime not initialized") {
+		t.Fatalf("expected not initialized error, got: %v", err)
+	}
+	if _, err := GetAvailableProviders(); err == nil || !strings.Contains(err.Error(), "ONNX Runtime not initialized") {
+		t.Fatalf("expected not initialized error, got: %v", err)
+	}
+}
+
+func TestNewSessionOptionsInvalidOption(t *testing.T) {
+	resetEnvironmentState()
+
+	_, err := NewSessionOptions(WithExecutionProviderPriority(nil))
+	if err == nil || !strings.Contains(err.Error(), "cannot be empty") {
+		t.Fatalf("expected option error, got: %v", err)
+	}
+}
+
+func TestNewSessionOptionsCreateAndDestroy(t *testing.T) {
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	var releaseCalls int32
+	mu.Lock()
+	ortAPI = &OrtApi{}
+	createSessionOptionsFunc = func(out *uintptr) uintptr {
+		*out = 321
+		return 0
+	}
+	releaseSessionOptionsFunc = func(handle uintptr) {
+		if handle == 321 {
+			atomic.AddInt32(&releaseCalls, 1)
+		}
+	}
+	mu.Unlock()
+
+	options, err := NewSessionOptions()
+	if err != nil {
+		t.Fatalf("NewSessionOptions failed: %v", err)
+	}
+	if options.handle != 321 {
+		t.Fatalf("expected handle 321, got %d", options.handle)
+	}
+	if got := options.ExecutionProviders(); len(got) != 0 {
+		t.Fatalf("expected no execution providers, got %v", got)
+	}
+
+	if err := options.Destroy(); err != nil {
+		t.Fatalf("Destroy failed: %v", err)
+	}
+	if err := options.Destroy(); err != nil {
+		t.Fatalf("second Destroy failed: %v", err)
+	}
+	if got := atomic.LoadInt32(&releaseCalls); got != 1 {
+		t.Fatalf("expected session options to be released once, got %d", got)
+	}
+}
+
+func TestNewSessionOptionsRegistersCustomOpsLibraries(t *testing.T) {
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "libcustom_ops.so")
+	second := filepath.Join(dir, "libortextensions.so")
+	for _, path := range []string{first, second} {
+		if err := os.WriteFile(path, []byte("not a real library"), 0o600); err != nil {
+			t.Fatalf("failed to write library: %v", err)
+		}
+	}
+
+	var released []uintptr
+	mu.Lock()
+	ortAPI = &OrtApi{}
+	createSessionOptionsFunc = func(out *uintptr) uintptr {
+		*out = 321
+		return 0
+	}
+	releaseSessionOptionsFunc = func(handle uintptr) { released = append(released, handle) }
+	mu.Unlock()
+
+	var registered []string
+	var registerErr error
+	original := registerCustomOpsLibraryFunc
+	registerCustomOpsLibraryFunc = func(api *OrtApi, version uint32, optionsHandle uintptr, path string) error {
+		if optionsHandle != 321 {
+			t.Errorf("expected options handle 321, got %d", optionsHandle)
+		}
+		registered = append(registered, path)
+		return registerErr
+	}
+	t.Cleanup(func() { registerCustomOpsLibraryFunc = original })
+
+	options, err := NewSessionOptions(WithCustomOpsLibrary(first), WithCustomOpsLibrary(second))
+	if err != nil {
+		t.Fatalf("NewSessionOptions failed: %v", err)
+	}
+	if !reflect.DeepEqual(registered, []string{first, second}) {
+		t.Fatalf("unexpected registered libraries: got %v, want %v", registered, []string{first, second})
+	}
+	if err := options.Destroy(); err != nil {
+		t.Fatalf("Destroy failed: %v", err)
+	}
+
+	registered = nil
+	registerErr = errors.New("undefined symbol: RegisterCustomOps")
+	if _, err := NewSessionOptions(WithCustomOpsLibrary(first)); err == nil || !strings.Contains(err.Error(), "undefined symbol") {
+		t.Fatalf("expected registration error, got: %v", err)
+	}
+	if !reflect.DeepEqual(released, []uintptr{321, 321}) {
+		t.Fatalf("expected the options to be released after a failed registration, got %v", released)
+	}
+
+	if err := registerCustomOpsLibrary(&OrtApi{}, ORT_API_VERSION, 321, first); err == nil || !strings.Contains(err.Error(), "does not provide RegisterCustomOpsLibrary_V2") {
+		t.Fatalf("expected missing API function error, got: %v", err)
+	}
+	// A version 13 table ends before RegisterCustomOpsLibrary_V2, so whatever follows it
+	// in memory must not be called.
+	if err := registerCustomOpsLibrary(&OrtApi{RegisterCustomOpsLibrary_V2: 0x1000}, 13, 321, first); err == nil || !strings.Contains(err.Error(), "API version 13 does not provide RegisterCustomOpsLibrary_V2") {
+		t.Fatalf("expected API version error, got: %v", err)
+	}
+	for _, path := range []string{"", filepath.Join(dir, "missing.so"), dir} {
+		if err := WithCustomOpsLibrary(path)(&sessionOptionsConfig{}); err == nil {
+			t.Fatalf("expected WithCustomOpsLibrary(%q) to fail", path)
+		}
+	}
+}
+
+func TestNewSessionOptionsWithExecutionProviderPriority(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	available, err := GetAvailableProviders()
+	if err != nil {
+		t.Fatalf("GetAvailableProviders failed: %v", err)
+	}
+	found := false
+	for _, name := range available {
+		if name == CPUExecutionProviderName {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected %s in available providers, got %v", CPUExecutionProviderName, available)
+	}
+
+	options, err := NewSessionOptions(WithExecutionProviderPriority([]ProviderSpec{
+		{Name: "NonexistentExecutionProvider"},
+		{Name: CPUExecutionProviderName},
+	}))
+	if err != nil {
+		t.Fatalf("NewSessionOptions failed: %v", err)
+	}
+	defer func() {
+		_ = options.Destroy()
+	}()
+	if got, want := options.ExecutionProviders(), []string{CPUExecutionProviderName}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected execution providers: got %v, want %v", got, want)
+	}
+
+	modelPath := writeLinearTestModel(t, "features", "projected", 2, 1, []float32{1, 1})
+	input, err := NewTensor[float32](NewShape(1, 2), []float32{2, 3})
+	if err != nil {
+		t.Fatalf("NewTensor failed: %v", err)
+	}
+	defer func() {
+		_ = input.Destroy()
+	}()
+	output, err := NewEmptyTensor[float32](NewShape(1, 1))
+	if err != nil {
+		t.Fatalf("NewEmptyTensor failed: %v", err)
+	}
+	defer func() {
+		_ = output.Destroy()
+	}()
+
+	session, err := NewAdvancedSession(modelPath, []string{"features"}, []string{"projected"}, []Value{input}, []Value{output}, options)
+	if err != nil {
+		t.Fatalf("NewAdvancedSession failed: %v", err)
+	}
+	defer func() {
+		_ = session.Destroy()
+	}()
+	if err := session.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	data, err := output.Data()
+	if err != nil {
+		t.Fatalf("Data failed: %v", err)
+	}
+	if data[0] != 5 {
+		t.Fatalf("expected output 5, got %v", data[0])
+	}
+}
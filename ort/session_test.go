@@ -1,7 +1,10 @@
 package ort
 
 import (
+	"fmt"
+	"log"
 	"os"
+	"reflect"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -17,6 +20,13 @@ func (f *fakeValue) Destroy() error          { return nil }
 func (f *fakeValue) Type() ValueType         { return ValueTypeTensor }
 func (f *fakeValue) ortValueHandle() uintptr { return f.handle }
 
+type fakeShapedValue struct {
+	fakeValue
+	shape Shape
+}
+
+func (f *fakeShapedValue) Shape() Shape { return f.shape }
+
 type unsupportedValue struct{}
 
 func (u *unsupportedValue) Destroy() error  { return nil }
@@ -79,6 +89,64 @@ func TestNewAdvancedSessionValidation(t *testing.T) {
 			outputValues: []Value{validValue},
 			wantErr:      "output names/values count mismatch",
 		},
+		{
+			name:         "duplicate input names",
+			modelPath:    "model.onnx",
+			inputNames:   []string{"input_ids", "attention_mask", "input_ids"},
+			outputNames:  []string{"output"},
+			inputValues:  []Value{validValue, validValue, validValue},
+			outputValues: []Value{validValue},
+			wantErr:      `duplicate input name "input_ids" at indices 0 and 2`,
+		},
+		{
+			name:         "duplicate output names",
+			modelPath:    "model.onnx",
+			inputNames:   []string{"input"},
+			outputNames:  []string{"logits", "logits"},
+			inputValues:  []Value{validValue},
+			outputValues: []Value{validValue, validValue},
+			wantErr:      `duplicate output name "logits" at indices 0 and 1`,
+		},
+		{
+			name:        "mismatched input batch dimensions",
+			modelPath:   "model.onnx",
+			inputNames:  []string{"input_ids", "attention_mask"},
+			outputNames: []string{"output"},
+			inputValues: []Value{
+				&fakeShapedValue{fakeValue: fakeValue{handle: 1}, shape: Shape{2, 128}},
+				&fakeShapedValue{fakeValue: fakeValue{handle: 2}, shape: Shape{1, 128}},
+			},
+			outputValues: []Value{validValue},
+			wantErr:      `input "attention_mask" has batch dimension 1 but input "input_ids" has batch dimension 2`,
+		},
+		{
+			// Names only need to be unique per role; this reaches value validation.
+			name:         "same name as input and output",
+			modelPath:    "model.onnx",
+			inputNames:   []string{"state"},
+			outputNames:  []string{"state"},
+			inputValues:  []Value{validValue},
+			outputValues: []Value{&fakeValue{handle: 0}},
+			wantErr:      "output value at index 0 has been destroyed",
+		},
+		{
+			name:         "same value as input and output",
+			modelPath:    "model.onnx",
+			inputNames:   []string{"input_ids", "state"},
+			outputNames:  []string{"logits", "next_state"},
+			inputValues:  []Value{validValue, &fakeValue{handle: 7}},
+			outputValues: []Value{&fakeValue{handle: 8}, &fakeValue{handle: 7}},
+			wantErr:      `output "next_state" reuses the value bound to input "state"`,
+		},
+		{
+			name:         "same value for two outputs",
+			modelPath:    "model.onnx",
+			inputNames:   []string{"input"},
+			outputNames:  []string{"logits", "probabilities"},
+			inputValues:  []Value{validValue},
+			outputValues: []Value{&fakeValue{handle: 8}, &fakeValue{handle: 8}},
+			wantErr:      `output "probabilities" reuses the value bound to output "logits"`,
+		},
 		{
 			name:         "unsupported input value implementation",
 			modelPath:    "model.onnx",
@@ -226,6 +294,110 @@ func TestNewAdvancedSessionWithProvidedSessionOptionsHandle(t *testing.T) {
 	}
 }
 
+func TestNewAdvancedSessionUsesDefaultSessionOptions(t *testing.T) {
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	var (
+		createSessionOptionsCalls int32
+		receivedSessionOptions    []uintptr
+	)
+	mu.Lock()
+	ortAPI = &OrtApi{}
+	ortEnv = 99
+	createSessionOptionsFunc = func(out *uintptr) uintptr {
+		atomic.AddInt32(&createSessionOptionsCalls, 1)
+		*out = 111
+		return 0
+	}
+	releaseSessionOptionsFunc = func(handle uintptr) {}
+	createSessionFunc = func(env uintptr, modelPath uintptr, sessionOptions uintptr, out *uintptr) uintptr {
+		receivedSessionOptions = append(receivedSessionOptions, sessionOptions)
+		*out = 123
+		return 0
+	}
+	releaseSessionFunc = func(handle uintptr) {}
+	mu.Unlock()
+
+	newSession := func(options *SessionOptions) (*AdvancedSession, error) {
+		return NewAdvancedSession("model.onnx", []string{"input"}, []string{"output"}, []Value{&fakeValue{handle: 1}}, []Value{&fakeValue{handle: 2}}, options)
+	}
+
+	if err := SetDefaultSessionOptions(&SessionOptions{}); err == nil || !strings.Contains(err.Error(), "session options handle is not initialized") {
+		t.Fatalf("expected uninitialized options to be rejected, got: %v", err)
+	}
+	defaults := &SessionOptions{handle: 777}
+	if err := SetDefaultSessionOptions(defaults); err != nil {
+		t.Fatalf("SetDefaultSessionOptions failed: %v", err)
+	}
+
+	session, err := newSession(nil)
+	if err != nil {
+		t.Fatalf("NewAdvancedSession with nil options failed: %v", err)
+	}
+	_ = session.Destroy()
+	explicit, err := newSession(&SessionOptions{handle: 888})
+	if err != nil {
+		t.Fatalf("NewAdvancedSession with explicit options failed: %v", err)
+	}
+	_ = explicit.Destroy()
+	if got := atomic.LoadInt32(&createSessionOptionsCalls); got != 0 {
+		t.Fatalf("expected no per-session options to be created, got %d", got)
+	}
+	if want := []uintptr{777, 888}; !reflect.DeepEqual(receivedSessionOptions, want) {
+		t.Fatalf("unexpected session options handles: got %v, want %v", receivedSessionOptions, want)
+	}
+
+	defaults.handle = 0
+	if _, err := newSession(nil); err == nil || !strings.Contains(err.Error(), "default session options have been destroyed") {
+		t.Fatalf("expected destroyed default options error, got: %v", err)
+	}
+
+	if err := SetDefaultSessionOptions(nil); err != nil {
+		t.Fatalf("clearing default session options failed: %v", err)
+	}
+	session, err = newSession(nil)
+	if err != nil {
+		t.Fatalf("NewAdvancedSession after clearing defaults failed: %v", err)
+	}
+	_ = session.Destroy()
+	if got := atomic.LoadInt32(&createSessionOptionsCalls); got != 1 {
+		t.Fatalf("expected fresh options after clearing the default, got %d creations", got)
+	}
+}
+
+func TestValidateBatchDimensions(t *testing.T) {
+	shaped := func(dims ...int64) Value {
+		return &fakeShapedValue{fakeValue: fakeValue{handle: 1}, shape: Shape(dims)}
+	}
+	tests := []struct {
+		name    string
+		values  []Value
+		wantErr string
+	}{
+		{name: "matching batch", values: []Value{shaped(2, 8), shaped(2, 8), shaped(2, 8, 4)}},
+		{name: "low-rank inputs are not batch checked", values: []Value{shaped(2, 8), shaped(3), shaped()}},
+		{name: "values without shapes are skipped", values: []Value{shaped(2, 8), &fakeValue{handle: 2}, nil}},
+		{name: "typed nil tensor is skipped", values: []Value{shaped(2, 8), (*Tensor[int64])(nil)}},
+		{name: "mismatch", values: []Value{shaped(2, 8), shaped(2, 8), shaped(4, 8)}, wantErr: `input "c" has batch dimension 4 but input "a" has batch dimension 2 (shapes [4 8] and [2 8])`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := []string{"a", "b", "c"}[:len(tt.values)]
+			err := validateBatchDimensions(names, tt.values)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestAdvancedSessionRunNil(t *testing.T) {
 	var session *AdvancedSession
 	err := session.Run()
@@ -375,6 +547,117 @@ func TestAdvancedSessionRunConcurrent(t *testing.T) {
 	}
 }
 
+func TestAdvancedSessionRunObserver(t *testing.T) {
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	runStatus := uintptr(0)
+	mu.Lock()
+	ortAPI = &OrtApi{}
+	runSessionFunc = func(session uintptr, runOptions uintptr, inputNames *uintptr, inputValues *uintptr, inputLen uintptr, outputNames *uintptr, outputLen uintptr, outputValues *uintptr) uintptr {
+		return runStatus
+	}
+	mu.Unlock()
+
+	inputIDs := &fakeValue{handle: 1}
+	attentionMask := &fakeValue{handle: 2}
+	output := &fakeValue{handle: 3}
+	session := &AdvancedSession{
+		handle:       123,
+		inputNames:   []string{"input_ids", "attention_mask"},
+		outputNames:  []string{"last_hidden_state"},
+		inputValues:  []Value{inputIDs, attentionMask},
+		outputValues: []Value{output},
+	}
+
+	// Without an observer Run must behave exactly as before.
+	if err := session.Run(); err != nil {
+		t.Fatalf("run without observer failed: %v", err)
+	}
+
+	var calls int
+	session.SetRunObserver(func(inputs, outputs map[string]Value) {
+		calls++
+		if len(inputs) != 2 || inputs["input_ids"] != inputIDs || inputs["attention_mask"] != attentionMask {
+			t.Errorf("unexpected observed inputs: %v", inputs)
+		}
+		if len(outputs) != 1 || outputs["last_hidden_state"] != output {
+			t.Errorf("unexpected observed outputs: %v", outputs)
+		}
+	})
+	if err := session.Run(); err != nil {
+		t.Fatalf("run with observer failed: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("unexpected observer calls: got %d, want 1", calls)
+	}
+
+	session.SetRunObserver(nil)
+	if err := session.Run(); err != nil {
+		t.Fatalf("run after removing observer failed: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("observer called after removal: got %d calls, want 1", calls)
+	}
+
+	var nilSession *AdvancedSession
+	nilSession.SetRunObserver(func(inputs, outputs map[string]Value) {})
+}
+
+func TestAdvancedSessionRunCountAndLastError(t *testing.T) {
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	runStatus := uintptr(0)
+	mu.Lock()
+	ortAPI = &OrtApi{}
+	runSessionFunc = func(session uintptr, runOptions uintptr, inputNames *uintptr, inputValues *uintptr, inputLen uintptr, outputNames *uintptr, outputLen uintptr, outputValues *uintptr) uintptr {
+		return runStatus
+	}
+	mu.Unlock()
+
+	session := &AdvancedSession{
+		handle:       123,
+		inputNames:   []string{"input"},
+		outputNames:  []string{"output"},
+		inputValues:  []Value{&fakeValue{handle: 1}},
+		outputValues: []Value{&fakeValue{handle: 2}},
+	}
+	if success, failure := session.RunCount(); success != 0 || failure != 0 || session.LastError() != nil {
+		t.Fatalf("expected empty statistics for a new session, got %d/%d and %v", success, failure, session.LastError())
+	}
+
+	for _, status := range []uintptr{0, 0, 7, 0} {
+		runStatus = status
+		err := session.Run()
+		if (status != 0) != (err != nil) {
+			t.Fatalf("unexpected Run result for status %d: %v", status, err)
+		}
+	}
+	if success, failure := session.RunCount(); success != 3 || failure != 1 {
+		t.Fatalf("unexpected run counts: got %d successes and %d failures, want 3 and 1", success, failure)
+	}
+	if err := session.LastError(); err == nil || !strings.Contains(err.Error(), "failed to run inference") {
+		t.Fatalf("expected the last failure to be kept after a later success, got: %v", err)
+	}
+
+	session.handle = 0
+	if err := session.Run(); err == nil {
+		t.Fatalf("expected Run on a destroyed session to fail")
+	}
+	if success, failure := session.RunCount(); success != 3 || failure != 2 {
+		t.Fatalf("unexpected run counts after a destroyed run: got %d and %d", success, failure)
+	}
+	if err := session.LastError(); err == nil || !strings.Contains(err.Error(), "session has been destroyed") {
+		t.Fatalf("expected the destroyed-session error, got: %v", err)
+	}
+
+	var nilSession *AdvancedSession
+	if success, failure := nilSession.RunCount(); success != 0 || failure != 0 || nilSession.LastError() != nil {
+		t.Fatalf("expected zero statistics for a nil session")
+	}
+}
+
 func TestAdvancedSessionRunAndDestroyConcurrent(t *testing.T) {
 	resetEnvironmentState()
 	defer resetEnvironmentState()
@@ -615,6 +898,74 @@ func TestAdvancedSessionRunDestroyedInputValue(t *testing.T) {
 	resetEnvironmentState()
 }
 
+func TestDestroyTensorBoundToLiveSessionWarns(t *testing.T) {
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+
+	var released []uintptr
+	runCalled := false
+	mu.Lock()
+	ortAPI = &OrtApi{}
+	ortEnv = 99
+	createSessionOptionsFunc = func(out *uintptr) uintptr { return 0 }
+	releaseSessionOptionsFunc = func(handle uintptr) {}
+	createSessionFunc = func(env uintptr, modelPath uintptr, sessionOptions uintptr, out *uintptr) uintptr {
+		*out = 123
+		return 0
+	}
+	releaseSessionFunc = func(handle uintptr) {
+		released = append(released, handle)
+	}
+	releaseValueFunc = func(handle uintptr) {
+		released = append(released, handle)
+	}
+	runSessionFunc = func(session uintptr, runOptions uintptr, inputNames *uintptr, inputValues *uintptr, inputLen uintptr, outputNames *uintptr, outputLen uintptr, outputValues *uintptr) uintptr {
+		runCalled = true
+		return 0
+	}
+	mu.Unlock()
+
+	input := &Tensor[float32]{handle: 11}
+	output := &Tensor[float32]{handle: 12}
+	session, err := NewAdvancedSession("model.onnx", []string{"input"}, []string{"output"}, []Value{input}, []Value{output}, &SessionOptions{handle: 777})
+	if err != nil {
+		t.Fatalf("NewAdvancedSession failed: %v", err)
+	}
+
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	// Wrong order: the input is destroyed while the session still references it.
+	if err := input.Destroy(); err != nil {
+		t.Fatalf("input Destroy failed: %v", err)
+	}
+	if !strings.Contains(logs.String(), "still bound to 1 live session(s)") {
+		t.Fatalf("expected bound-tensor warning, got logs: %q", logs.String())
+	}
+	if err := session.Run(); err == nil || !strings.Contains(err.Error(), "input value at index 0 has been destroyed") {
+		t.Fatalf("expected destroyed input value error, got: %v", err)
+	}
+	if runCalled {
+		t.Fatalf("expected runSessionFunc not to be called after the input was destroyed")
+	}
+
+	// Right order: once the session is gone, its output can be destroyed silently.
+	logs.Reset()
+	if err := session.Destroy(); err != nil {
+		t.Fatalf("session Destroy failed: %v", err)
+	}
+	if err := output.Destroy(); err != nil {
+		t.Fatalf("output Destroy failed: %v", err)
+	}
+	if logs.Len() != 0 {
+		t.Fatalf("expected no warning when destroying after the session, got logs: %q", logs.String())
+	}
+	if want := []uintptr{11, 123, 12}; fmt.Sprint(released) != fmt.Sprint(want) {
+		t.Fatalf("unexpected release order: got %v, want %v", released, want)
+	}
+}
+
 func TestMakeCStringPointerArrayEmpty(t *testing.T) {
 	backings, ptrs := makeCStringPointerArray(nil)
 	if backings != nil {
@@ -746,3 +1097,72 @@ func TestAdvancedSessionRunWithAllMiniLML6V2(t *testing.T) {
 	output := runAllMiniLMInference(t, modelPath, sequenceLength)
 	requireFiniteFloat32Slice(t, "all-MiniLM output", output)
 }
+
+func TestValidateInputElementTypes(t *testing.T) {
+	declared := []InputOutputInfo{
+		{Name: "input_ids", OrtValueType: ONNXTypeTensor, DataType: TensorElementDataTypeInt64},
+		{Name: "attention_mask", OrtValueType: ONNXTypeTensor, DataType: TensorElementDataTypeInt64},
+	}
+	names := []string{"input_ids", "attention_mask"}
+
+	if err := validateInputElementTypes(declared, names, []Value{&Tensor[int64]{}, &Tensor[int64]{}}); err != nil {
+		t.Fatalf("expected matching element types to pass, got: %v", err)
+	}
+	err := validateInputElementTypes(declared, names, []Value{&Tensor[int64]{}, &Tensor[float32]{}})
+	if err == nil || !strings.Contains(err.Error(), `input "attention_mask" (index 1) has element type 1, but the model declares element type 7`) {
+		t.Fatalf("expected element type mismatch error, got: %v", err)
+	}
+	if err := validateInputElementTypes(declared, names, []Value{&fakeValue{handle: 1}, &fakeValue{handle: 2}}); err != nil {
+		t.Fatalf("expected values without element types to be skipped, got: %v", err)
+	}
+	err = validateInputElementTypes(declared, []string{"token_type_ids"}, []Value{&Tensor[int64]{}})
+	if err == nil || !strings.Contains(err.Error(), `model has no input "token_type_ids"`) {
+		t.Fatalf("expected unknown input error, got: %v", err)
+	}
+}
+
+func TestStrictInputTypeCheckWithAllMiniLML6V2(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	modelPath := resolveAllMiniLMModelPath(t)
+	options, err := NewSessionOptions(WithStrictInputTypeCheck())
+	if err != nil {
+		t.Fatalf("NewSessionOptions failed: %v", err)
+	}
+	defer requireDestroy(t, "session options", options.Destroy)
+
+	shape := NewShape(1, 4)
+	inputIDs, err := NewTensor[int64](shape, []int64{101, 2023, 102, 0})
+	if err != nil {
+		t.Fatalf("failed to create input_ids tensor: %v", err)
+	}
+	defer requireDestroy(t, "input_ids tensor", inputIDs.Destroy)
+	floatMask, err := NewTensor[float32](shape, []float32{1, 1, 1, 0})
+	if err != nil {
+		t.Fatalf("failed to create float mask tensor: %v", err)
+	}
+	defer requireDestroy(t, "float mask tensor", floatMask.Destroy)
+	tokenTypeIDs, err := NewEmptyTensor[int64](shape)
+	if err != nil {
+		t.Fatalf("failed to create token_type_ids tensor: %v", err)
+	}
+	defer requireDestroy(t, "token_type_ids tensor", tokenTypeIDs.Destroy)
+	output, err := NewEmptyTensor[float32](NewShape(1, 4, allMiniLMOutputEmbeddingDim))
+	if err != nil {
+		t.Fatalf("failed to create output tensor: %v", err)
+	}
+	defer requireDestroy(t, "output tensor", output.Destroy)
+
+	// The float mask is bound to input_ids, as if the inputs were passed in the wrong order.
+	_, err = NewAdvancedSession(modelPath,
+		[]string{"input_ids", "attention_mask", "token_type_ids"},
+		[]string{"last_hidden_state"},
+		[]Value{floatMask, inputIDs, tokenTypeIDs},
+		[]Value{output},
+		options,
+	)
+	if err == nil || !strings.Contains(err.Error(), `input "input_ids" (index 0) has element type 1, but the model declares element type 7`) {
+		t.Fatalf("expected strict input type error, got: %v", err)
+	}
+}
@@ -6,21 +6,27 @@ import (
 	"strings"
 )
 
+const utf8BOM = "\ufeff"
+
 // ParseShape parses a comma-separated shape string (for example: "1,384").
 // All dimensions must be non-negative concrete sizes.
 // Dynamic dimensions from model metadata (for example -1) are not accepted here.
+// A leading UTF-8 byte order mark (as left by spreadsheet exports) is ignored.
 func ParseShape(raw string) (Shape, error) {
-	raw = strings.TrimSpace(raw)
+	raw = strings.TrimSpace(strings.TrimPrefix(raw, utf8BOM))
 	if raw == "" {
 		return nil, fmt.Errorf("shape string must not be empty")
 	}
 
 	parts := strings.Split(raw, ",")
 	shape := make(Shape, 0, len(parts))
-	for _, part := range parts {
+	for i, part := range parts {
 		part = strings.TrimSpace(part)
 		if part == "" {
-			return nil, fmt.Errorf("empty dimension")
+			if i == len(parts)-1 {
+				return nil, fmt.Errorf("empty dimension at index %d (trailing comma in %q)", i, raw)
+			}
+			return nil, fmt.Errorf("empty dimension at index %d in %q", i, raw)
 		}
 
 		dim, err := strconv.ParseInt(part, 10, 64)
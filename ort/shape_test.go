@@ -130,6 +130,36 @@ func TestParseShape(t *testing.T) {
 			raw:     "1,,3",
 			wantErr: "empty dimension",
 		},
+		{
+			name: "leading BOM",
+			raw:  "\ufeff1,384",
+			want: Shape{1, 384},
+		},
+		{
+			name: "BOM before whitespace",
+			raw:  "\ufeff 1, 384",
+			want: Shape{1, 384},
+		},
+		{
+			name:    "only BOM",
+			raw:     "\ufeff",
+			wantErr: "shape string must not be empty",
+		},
+		{
+			name:    "trailing comma",
+			raw:     "1,384,",
+			wantErr: "empty dimension at index 2 (trailing comma",
+		},
+		{
+			name:    "trailing comma with space",
+			raw:     "1,384, ",
+			wantErr: "trailing comma",
+		},
+		{
+			name:    "leading comma",
+			raw:     ",1",
+			wantErr: "empty dimension at index 0",
+		},
 		{
 			name:    "negative dimension",
 			raw:     "1,-1,3",
@@ -1,17 +1,37 @@
 package ort
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"runtime"
 	"unsafe"
 )
 
+var (
+	// ErrTensorDestroyed is returned when tensor data is accessed after Destroy.
+	ErrTensorDestroyed = errors.New("tensor has been destroyed")
+	// ErrTensorUninitialized is returned when tensor data is accessed on a tensor
+	// that was not created by NewTensor or NewEmptyTensor.
+	ErrTensorUninitialized = errors.New("tensor is not backed by ONNX Runtime data")
+)
+
 // Tensor represents a tensor with data of type T
 type Tensor[T any] struct {
-	shape  Shape
-	data   []T
-	handle uintptr         // Pointer to OrtValue
-	pinner *runtime.Pinner // Pins data backing array while OrtValue may access it.
+	shape     Shape
+	data      []T
+	handle    uintptr         // Pointer to OrtValue
+	pinner    *runtime.Pinner // Pins data backing array while OrtValue may access it.
+	destroyed bool
+	// liveSessions counts sessions that reference this tensor and have not been destroyed.
+	liveSessions int
+	// runtimeAllocated marks output tensors created by NewRuntimeAllocatedTensor, which
+	// have no OrtValue of their own and receive their shape and data after each Run.
+	runtimeAllocated bool
+}
+
+func (t *Tensor[T]) adjustSessionBindings(delta int) {
+	t.liveSessions += delta
 }
 
 func (t *Tensor[T]) ortValueHandle() uintptr {
@@ -133,6 +153,7 @@ func newTensorFromData[T any](shape Shape, data []T, elementType TensorElementDa
 
 // GetData returns the tensor data.
 // After Destroy() it returns nil. Calling on a nil receiver also returns nil.
+// Use Data to distinguish those cases from an empty tensor.
 func (t *Tensor[T]) GetData() []T {
 	if t == nil {
 		return nil
@@ -140,6 +161,89 @@ func (t *Tensor[T]) GetData() []T {
 	return t.data
 }
 
+// Data returns the tensor data, or an error wrapping ErrTensorDestroyed after Destroy()
+// and ErrTensorUninitialized for tensors not created by NewTensor, NewEmptyTensor or
+// NewRuntimeAllocatedTensor. A runtime-allocated tensor returns nil data before its first Run.
+// Prefer it over GetData when a nil slice would be indistinguishable from a cleanup bug.
+func (t *Tensor[T]) Data() ([]T, error) {
+	if t == nil {
+		return nil, fmt.Errorf("nil tensor: %w", ErrTensorUninitialized)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return t.dataLocked()
+}
+
+// dataLocked implements Data; the caller holds mu.
+func (t *Tensor[T]) dataLocked() ([]T, error) {
+	if t.handle == 0 {
+		if t.destroyed {
+			return nil, fmt.Errorf("cannot read data: %w", ErrTensorDestroyed)
+		}
+		if t.runtimeAllocated {
+			return t.data, nil
+		}
+		return nil, fmt.Errorf("cannot read data: %w", ErrTensorUninitialized)
+	}
+	return t.data, nil
+}
+
+// GetData2D returns the data of a rank-2 tensor as rows, for example a [batch, dim]
+// output as one slice per batch entry. The rows are views into the tensor's data, not
+// copies, so they share its lifetime. It errors like Data, and when the rank is not 2.
+func (t *Tensor[T]) GetData2D() ([][]T, error) {
+	data, shape, err := t.dataWithRank(2)
+	if err != nil {
+		return nil, err
+	}
+	return splitRows(data, int(shape[0]), int(shape[1])), nil
+}
+
+// GetData3D returns the data of a rank-3 tensor as nested rows, for example a
+// [batch, sequence, dim] output. Like GetData2D, the innermost rows are views into the
+// tensor's data. It errors like Data, and when the rank is not 3.
+func (t *Tensor[T]) GetData3D() ([][][]T, error) {
+	data, shape, err := t.dataWithRank(3)
+	if err != nil {
+		return nil, err
+	}
+	rows := splitRows(data, int(shape[0]*shape[1]), int(shape[2]))
+	return splitRows(rows, int(shape[0]), int(shape[1])), nil
+}
+
+func (t *Tensor[T]) dataWithRank(rank int) ([]T, Shape, error) {
+	if t == nil {
+		return nil, nil, fmt.Errorf("nil tensor: %w", ErrTensorUninitialized)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	data, err := t.dataLocked()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(t.shape) != rank {
+		return nil, nil, fmt.Errorf("cannot reshape tensor of shape %v to rank %d", t.shape, rank)
+	}
+	if count, err := shapeElementCount(t.shape); err != nil {
+		return nil, nil, err
+	} else if count != len(data) {
+		return nil, nil, fmt.Errorf("tensor data length %d does not match shape %v", len(data), t.shape)
+	}
+	return data, t.shape, nil
+}
+
+// splitRows views data as count rows of width elements. Each row's capacity is capped at
+// its length so appending to one row cannot overwrite the next.
+func splitRows[E any](data []E, count int, width int) [][]E {
+	rows := make([][]E, count)
+	for i := range rows {
+		rows[i] = data[i*width : (i+1)*width : (i+1)*width]
+	}
+	return rows
+}
+
 // Shape returns the tensor shape
 func (t *Tensor[T]) Shape() Shape {
 	if t == nil {
@@ -154,6 +258,9 @@ func (t *Tensor[T]) Shape() Shape {
 // cannot overlap any in-flight ORT call that may still read this OrtValue handle.
 // As a result, tensor destruction may block while inference is running, and can
 // temporarily pause new inference calls across sessions.
+//
+// Sessions referencing the tensor must be destroyed first; destroying a tensor that is
+// still bound to a live session logs a warning, and that session's Run then fails.
 func (t *Tensor[T]) Destroy() error {
 	if t == nil {
 		return nil
@@ -173,15 +280,21 @@ func (t *Tensor[T]) Destroy() error {
 
 	mu.Lock()
 	handle = t.handle
+	liveSessions := t.liveSessions
+	runtimeAllocated := t.runtimeAllocated && !t.destroyed
 	releaseValue = releaseValueFunc
 	pinner = t.pinner
 	t.handle = 0
 	t.data = nil
 	t.shape = nil
 	t.pinner = nil
+	t.destroyed = true
 	runtime.SetFinalizer(t, nil)
 	mu.Unlock()
 
+	if (handle != 0 || runtimeAllocated) && liveSessions > 0 {
+		log.Printf("WARNING: destroying a tensor still bound to %d live session(s); destroy sessions before the values they reference", liveSessions)
+	}
 	if handle != 0 && releaseValue != nil {
 		releaseValue(handle)
 	}
@@ -192,6 +305,16 @@ func (t *Tensor[T]) Destroy() error {
 	return nil
 }
 
+// elementDataType returns the ONNX element type of T, or TensorElementDataTypeUndefined
+// for unsupported types.
+func (t *Tensor[T]) elementDataType() TensorElementDataType {
+	elementType, _, err := tensorElementType[T]()
+	if err != nil {
+		return TensorElementDataTypeUndefined
+	}
+	return elementType
+}
+
 // Type returns the value type (always ValueTypeTensor for tensors)
 func (t *Tensor[T]) Type() ValueType {
 	return ValueTypeTensor
@@ -278,7 +401,7 @@ func tensorDataByteSize(elementCount int, elementSize uintptr) (uintptr, error)
 }
 
 // tensorElementType maps Go generic element type T to ONNX tensor element metadata.
-// Supported types in this MVP are float32, float64, int32, and int64.
+// Supported types in this MVP are float32, float64, int8, int32, int64, and Float16.
 func tensorElementType[T any]() (TensorElementDataType, uintptr, error) {
 	var zero T
 
@@ -287,10 +410,14 @@ func tensorElementType[T any]() (TensorElementDataType, uintptr, error) {
 		return TensorElementDataTypeFloat, unsafe.Sizeof(zero), nil
 	case float64:
 		return TensorElementDataTypeDouble, unsafe.Sizeof(zero), nil
+	case int8:
+		return TensorElementDataTypeInt8, unsafe.Sizeof(zero), nil
 	case int32:
 		return TensorElementDataTypeInt32, unsafe.Sizeof(zero), nil
 	case int64:
 		return TensorElementDataTypeInt64, unsafe.Sizeof(zero), nil
+	case Float16:
+		return TensorElementDataTypeFloat16, unsafe.Sizeof(zero), nil
 	default:
 		return TensorElementDataTypeUndefined, 0, fmt.Errorf("unsupported tensor element type %T", zero)
 	}
@@ -0,0 +1,127 @@
+package ort
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// TensorPool recycles tensors of one fixed shape, so high-throughput callers avoid
+// creating and releasing an OrtValue per request. Get returns an idle tensor or creates
+// one; Put zeroes the tensor's data and keeps it for the next Get, destroying it instead
+// once maxIdle tensors are already idle. A TensorPool is safe for concurrent use.
+//
+// Tensors handed out by Get are owned by the caller until Put: a tensor must not be
+// returned while a session still runs on it, and pooled tensors must not be destroyed
+// directly. Close destroys the idle tensors, after the sessions using them.
+type TensorPool[T any] struct {
+	shape   Shape
+	maxIdle int
+
+	mu     sync.Mutex
+	idle   []*Tensor[T]
+	closed bool
+}
+
+// NewTensorPool creates a pool of tensors with the given shape that keeps at most
+// maxIdle returned tensors for reuse.
+func NewTensorPool[T any](shape Shape, maxIdle int) (*TensorPool[T], error) {
+	if _, _, err := tensorElementType[T](); err != nil {
+		return nil, err
+	}
+	shapeCopy := cloneShape(shape)
+	if _, err := shapeElementCount(shapeCopy); err != nil {
+		return nil, err
+	}
+	if maxIdle <= 0 {
+		return nil, fmt.Errorf("max idle tensors must be > 0, got %d", maxIdle)
+	}
+	return &TensorPool[T]{shape: shapeCopy, maxIdle: maxIdle}, nil
+}
+
+// Shape returns the shape of the pool's tensors.
+func (p *TensorPool[T]) Shape() Shape {
+	if p == nil {
+		return nil
+	}
+	return cloneShape(p.shape)
+}
+
+// Get returns a zeroed tensor of the pool's shape, reusing an idle one when available.
+func (p *TensorPool[T]) Get() (*Tensor[T], error) {
+	if p == nil {
+		return nil, fmt.Errorf("tensor pool is nil")
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("tensor pool has been closed")
+	}
+	if n := len(p.idle); n > 0 {
+		tensor := p.idle[n-1]
+		p.idle[n-1] = nil
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return tensor, nil
+	}
+	p.mu.Unlock()
+	return NewEmptyTensor[T](p.shape)
+}
+
+// Put zeroes tensor and returns it to the pool. It rejects tensors of another shape and
+// destroyed or runtime-allocated tensors. When the pool is full or closed, tensor is
+// destroyed instead.
+func (p *TensorPool[T]) Put(tensor *Tensor[T]) error {
+	if p == nil {
+		return fmt.Errorf("tensor pool is nil")
+	}
+	if tensor == nil {
+		return fmt.Errorf("tensor cannot be nil")
+	}
+
+	mu.Lock()
+	data, err := tensor.dataLocked()
+	switch {
+	case err != nil:
+		err = fmt.Errorf("cannot return tensor to pool: %w", err)
+	case tensor.runtimeAllocated:
+		err = fmt.Errorf("cannot return a runtime-allocated tensor to pool")
+	case !slices.Equal(tensor.shape, p.shape):
+		err = fmt.Errorf("cannot return tensor with shape %v to pool of shape %v", tensor.shape, p.shape)
+	default:
+		clear(data)
+	}
+	mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	if !p.closed && len(p.idle) < p.maxIdle {
+		p.idle = append(p.idle, tensor)
+		p.mu.Unlock()
+		return nil
+	}
+	p.mu.Unlock()
+	return tensor.Destroy()
+}
+
+// Close destroys the idle tensors. Later Get calls fail and Put destroys the tensor it
+// is given. It is safe to call more than once.
+func (p *TensorPool[T]) Close() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+
+	var err error
+	for _, tensor := range idle {
+		err = errors.Join(err, tensor.Destroy())
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package ort
+
+import (
+	"strings"
+	"testing"
+)
+
+// installFakeTensorRuntime stubs the ORT functions tensor creation and release use,
+// handing out increasing OrtValue handles, and returns the number of created and
+// released handles so far.
+func installFakeTensorRuntime(t *testing.T) func() (created, released int) {
+	t.Helper()
+	var createdCount, releasedCount int
+	mu.Lock()
+	ortAPI = &OrtApi{}
+	createMemoryInfoFunc = func(name uintptr, allocatorType AllocatorType, deviceID int32, memType MemType, out *uintptr) uintptr {
+		*out = 1
+		return 0
+	}
+	releaseMemoryInfoFunc = func(uintptr) {}
+	createTensorWithDataAsOrtValueFunc = func(info uintptr, pData uintptr, pDataLen uintptr, shape *int64, shapeLen uintptr, dataType TensorElementDataType, out *uintptr) uintptr {
+		createdCount++
+		*out = uintptr(1000 + createdCount)
+		return 0
+	}
+	releaseValueFunc = func(uintptr) { releasedCount++ }
+	mu.Unlock()
+	return func() (int, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return createdCount, releasedCount
+	}
+}
+
+func TestTensorPoolReusesAndResetsTensors(t *testing.T) {
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+	counts := installFakeTensorRuntime(t)
+
+	pool, err := NewTensorPool[int64](NewShape(2, 3), 1)
+	if err != nil {
+		t.Fatalf("NewTensorPool failed: %v", err)
+	}
+
+	first, err := pool.Get()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	data, err := first.Data()
+	if err != nil {
+		t.Fatalf("Data failed: %v", err)
+	}
+	for i := range data {
+		data[i] = int64(i + 1)
+	}
+	handle := first.handle
+	if err := pool.Put(first); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	reused, err := pool.Get()
+	if err != nil {
+		t.Fatalf("second Get failed: %v", err)
+	}
+	if reused.handle != handle {
+		t.Fatalf("expected the pooled handle %d to be reused, got %d", handle, reused.handle)
+	}
+	data, err = reused.Data()
+	if err != nil {
+		t.Fatalf("Data failed: %v", err)
+	}
+	for i, value := range data {
+		if value != 0 {
+			t.Fatalf("expected reused tensor data to be reset, got %d at %d", value, i)
+		}
+	}
+	if created, _ := counts(); created != 1 {
+		t.Fatalf("expected one tensor to be created, got %d", created)
+	}
+
+	// The pool keeps one idle tensor; a second returned tensor is destroyed.
+	extra, err := pool.Get()
+	if err != nil {
+		t.Fatalf("Get of a new tensor failed: %v", err)
+	}
+	if err := pool.Put(reused); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := pool.Put(extra); err != nil {
+		t.Fatalf("Put of an overflow tensor failed: %v", err)
+	}
+	if created, released := counts(); created != 2 || released != 1 {
+		t.Fatalf("expected 2 created and 1 released tensors, got %d and %d", created, released)
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, released := counts(); released != 2 {
+		t.Fatalf("expected Close to release the idle tensor, got %d releases", released)
+	}
+	if _, err := pool.Get(); err == nil || !strings.Contains(err.Error(), "tensor pool has been closed") {
+		t.Fatalf("expected closed pool error, got: %v", err)
+	}
+}
+
+func TestTensorPoolRejectsForeignTensors(t *testing.T) {
+	resetEnvironmentState()
+	defer resetEnvironmentState()
+	installFakeTensorRuntime(t)
+
+	if _, err := NewTensorPool[int64](NewShape(2), 0); err == nil || !strings.Contains(err.Error(), "max idle tensors must be > 0") {
+		t.Fatalf("expected max idle validation error, got: %v", err)
+	}
+	if _, err := NewTensorPool[string](NewShape(2), 1); err == nil {
+		t.Fatalf("expected unsupported element type error")
+	}
+
+	pool, err := NewTensorPool[float32](NewShape(1, 4), 2)
+	if err != nil {
+		t.Fatalf("NewTensorPool failed: %v", err)
+	}
+	defer func() {
+		_ = pool.Close()
+	}()
+
+	other, err := NewEmptyTensor[float32](NewShape(2, 2))
+	if err != nil {
+		t.Fatalf("NewEmptyTensor failed: %v", err)
+	}
+	if err := pool.Put(other); err == nil || !strings.Contains(err.Error(), "cannot return tensor with shape [2 2] to pool of shape [1 4]") {
+		t.Fatalf("expected shape mismatch error, got: %v", err)
+	}
+	if err := other.Destroy(); err != nil {
+		t.Fatalf("Destroy failed: %v", err)
+	}
+	if err := pool.Put(other); err == nil || !strings.Contains(err.Error(), "tensor has been destroyed") {
+		t.Fatalf("expected destroyed tensor error, got: %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package ort
 
 import (
+	"errors"
+	"math"
 	"reflect"
 	"strings"
 	"testing"
@@ -31,6 +33,14 @@ func TestTensorElementType(t *testing.T) {
 			wantType: TensorElementDataTypeDouble,
 			wantSize: unsafe.Sizeof(float64(0)),
 		},
+		{
+			name: "int8",
+			fn: func() (TensorElementDataType, uintptr, error) {
+				return tensorElementType[int8]()
+			},
+			wantType: TensorElementDataTypeInt8,
+			wantSize: 1,
+		},
 		{
 			name: "int32",
 			fn: func() (TensorElementDataType, uintptr, error) {
@@ -47,6 +57,14 @@ func TestTensorElementType(t *testing.T) {
 			wantType: TensorElementDataTypeInt64,
 			wantSize: unsafe.Sizeof(int64(0)),
 		},
+		{
+			name: "float16",
+			fn: func() (TensorElementDataType, uintptr, error) {
+				return tensorElementType[Float16]()
+			},
+			wantType: TensorElementDataTypeFloat16,
+			wantSize: 2,
+		},
 		{
 			name: "unsupported uint16",
 			fn: func() (TensorElementDataType, uintptr, error) {
@@ -147,6 +165,37 @@ func TestTensorDataByteSizeOverflow(t *testing.T) {
 	}
 }
 
+func TestFloat64TensorSizing(t *testing.T) {
+	elementType, elementSize, err := tensorElementType[float64]()
+	if err != nil || elementType != TensorElementDataTypeDouble || elementSize != 8 {
+		t.Fatalf("unexpected float64 mapping: type=%v size=%d err=%v", elementType, elementSize, err)
+	}
+	if got, err := tensorDataByteSize(6, elementSize); err != nil || got != 48 {
+		t.Fatalf("unexpected float64 byte size: got %d, err %v; want 48", got, err)
+	}
+
+	maxInt := int(^uint(0) >> 1)
+	largest := int(^uintptr(0) / elementSize)
+	if largest <= maxInt {
+		if _, err := tensorDataByteSize(largest, elementSize); err != nil {
+			t.Fatalf("expected %d float64 elements to fit, got: %v", largest, err)
+		}
+	}
+	if largest < maxInt {
+		if _, err := tensorDataByteSize(largest+1, elementSize); err == nil || !strings.Contains(err.Error(), "overflow") {
+			t.Fatalf("expected overflow error for %d float64 elements, got: %v", largest+1, err)
+		}
+	}
+	if _, err := shapeElementCount(Shape{1 << 62, 4}); err == nil {
+		t.Fatalf("expected element count overflow error")
+	}
+
+	resetEnvironmentState()
+	if _, err := NewTensor[float64](Shape{2, 3}, make([]float64, 5)); err == nil || !strings.Contains(err.Error(), "data length mismatch") {
+		t.Fatalf("expected data length mismatch error, got: %v", err)
+	}
+}
+
 func TestNewTensorValidationErrorsWithoutORT(t *testing.T) {
 	resetEnvironmentState()
 
@@ -217,6 +266,119 @@ func TestTensorDestroyDoubleWithoutORT(t *testing.T) {
 	}
 }
 
+func TestTensorDataGuards(t *testing.T) {
+	resetEnvironmentState()
+
+	var nilTensor *Tensor[float32]
+	if _, err := nilTensor.Data(); !errors.Is(err, ErrTensorUninitialized) {
+		t.Fatalf("expected ErrTensorUninitialized for nil tensor, got: %v", err)
+	}
+
+	if _, err := (&Tensor[float32]{}).Data(); !errors.Is(err, ErrTensorUninitialized) {
+		t.Fatalf("expected ErrTensorUninitialized for zero-value tensor, got: %v", err)
+	}
+
+	tensor := &Tensor[float32]{
+		handle: 123,
+		data:   []float32{1, 2, 3},
+		shape:  Shape{3},
+	}
+	data, err := tensor.Data()
+	if err != nil {
+		t.Fatalf("unexpected error before destroy: %v", err)
+	}
+	if !reflect.DeepEqual(data, []float32{1, 2, 3}) {
+		t.Fatalf("unexpected data: %v", data)
+	}
+
+	if err := tensor.Destroy(); err != nil {
+		t.Fatalf("destroy failed: %v", err)
+	}
+	data, err = tensor.Data()
+	if !errors.Is(err, ErrTensorDestroyed) {
+		t.Fatalf("expected ErrTensorDestroyed after destroy, got: %v", err)
+	}
+	if data != nil {
+		t.Fatalf("expected nil data after destroy, got %v", data)
+	}
+}
+
+func TestTensorGetData2D(t *testing.T) {
+	resetEnvironmentState()
+
+	tensor := &Tensor[float32]{
+		handle: 123,
+		data:   []float32{1, 2, 3, 4, 5, 6},
+		shape:  Shape{2, 3},
+	}
+	rows, err := tensor.GetData2D()
+	if err != nil {
+		t.Fatalf("GetData2D failed: %v", err)
+	}
+	if want := [][]float32{{1, 2, 3}, {4, 5, 6}}; !reflect.DeepEqual(rows, want) {
+		t.Fatalf("unexpected rows: got %v, want %v", rows, want)
+	}
+
+	// Rows are views: writes reach the tensor, and appends cannot spill into the next row.
+	rows[0][1] = 20
+	if tensor.data[1] != 20 {
+		t.Fatalf("expected rows to share the tensor data")
+	}
+	_ = append(rows[0], 99)
+	if tensor.data[3] != 4 {
+		t.Fatalf("append to a row overwrote the next row: %v", tensor.data)
+	}
+
+	if _, err := tensor.GetData3D(); err == nil || !strings.Contains(err.Error(), "to rank 3") {
+		t.Fatalf("expected rank mismatch error, got: %v", err)
+	}
+
+	empty := &Tensor[int64]{handle: 1, data: []int64{}, shape: Shape{0, 4}}
+	if rows, err := empty.GetData2D(); err != nil || len(rows) != 0 {
+		t.Fatalf("expected no rows for a zero-batch tensor, got rows=%v err=%v", rows, err)
+	}
+
+	if err := tensor.Destroy(); err != nil {
+		t.Fatalf("destroy failed: %v", err)
+	}
+	if _, err := tensor.GetData2D(); !errors.Is(err, ErrTensorDestroyed) {
+		t.Fatalf("expected ErrTensorDestroyed after destroy, got: %v", err)
+	}
+	var nilTensor *Tensor[float32]
+	if _, err := nilTensor.GetData2D(); !errors.Is(err, ErrTensorUninitialized) {
+		t.Fatalf("expected ErrTensorUninitialized for nil tensor, got: %v", err)
+	}
+}
+
+func TestTensorGetData3D(t *testing.T) {
+	resetEnvironmentState()
+
+	tensor := &Tensor[int64]{
+		handle: 123,
+		data:   []int64{1, 2, 3, 4, 5, 6, 7, 8},
+		shape:  Shape{2, 2, 2},
+	}
+	blocks, err := tensor.GetData3D()
+	if err != nil {
+		t.Fatalf("GetData3D failed: %v", err)
+	}
+	want := [][][]int64{
+		{{1, 2}, {3, 4}},
+		{{5, 6}, {7, 8}},
+	}
+	if !reflect.DeepEqual(blocks, want) {
+		t.Fatalf("unexpected blocks: got %v, want %v", blocks, want)
+	}
+	if _, err := tensor.GetData2D(); err == nil || !strings.Contains(err.Error(), "cannot reshape tensor of shape [2 2 2] to rank 2") {
+		t.Fatalf("expected rank mismatch error, got: %v", err)
+	}
+
+	mismatched := &Tensor[int64]{handle: 1, data: []int64{1, 2, 3}, shape: Shape{2, 2, 1}}
+	if _, err := mismatched.GetData3D(); err == nil || !strings.Contains(err.Error(), "does not match shape") {
+		t.Fatalf("expected data length mismatch error, got: %v", err)
+	}
+}
+
 func TestNewTensorWithORT(t *testing.T) {
 	cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -271,11 +433,62 @@ func TestNewEmptyTensorWithORT(t *testing.T) {
 	if err := tensor.Destroy(); err != nil {
 		t.Fatalf("first destroy failed: %v", err)
 	}
+	if _, err := tensor.Data(); !errors.Is(err, ErrTensorDestroyed) {
+		t.Fatalf("expected ErrTensorDestroyed after destroy, got: %v", err)
+	}
 	if err := tensor.Destroy(); err != nil {
 		t.Fatalf("second destroy should be no-op, got: %v", err)
 	}
 }
 
+func TestFloat64TensorIdentityRunWithORT(t *testing.T) {
+	cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	// Values that do not survive a float32 round trip, so a 4-byte stride would corrupt them.
+	values := []float64{math.Pi, 1 + 1.0/(1<<40), -1e300, 0, math.SmallestNonzeroFloat64, 42}
+	input, err := NewTensor(Shape{2, 3}, values)
+	if err != nil {
+		t.Fatalf("NewTensor failed: %v", err)
+	}
+	defer func() { _ = input.Destroy() }()
+	if got := input.GetData(); len(got) != 6 || !reflect.DeepEqual(got, values) {
+		t.Fatalf("unexpected float64 input data: %v", got)
+	}
+
+	output, err := NewEmptyTensor[float64](Shape{2, 3})
+	if err != nil {
+		t.Fatalf("NewEmptyTensor failed: %v", err)
+	}
+	defer func() { _ = output.Destroy() }()
+	allocated, err := NewRuntimeAllocatedTensor[float64]()
+	if err != nil {
+		t.Fatalf("NewRuntimeAllocatedTensor failed: %v", err)
+	}
+	defer func() { _ = allocated.Destroy() }()
+
+	modelPath := writeIdentityTestModel(t, TensorElementDataTypeDouble, 3)
+	for _, out := range []*Tensor[float64]{output, allocated} {
+		session, err := NewAdvancedSession(modelPath, []string{"x"}, []string{"y"}, []Value{input}, []Value{out}, nil)
+		if err != nil {
+			t.Fatalf("NewAdvancedSession failed: %v", err)
+		}
+		if err := session.Run(); err != nil {
+			_ = session.Destroy()
+			t.Fatalf("Run failed: %v", err)
+		}
+		if err := session.Destroy(); err != nil {
+			t.Fatalf("session destroy failed: %v", err)
+		}
+		if got := out.GetData(); !reflect.DeepEqual(got, values) {
+			t.Fatalf("identity output mismatch: got %v, want %v", got, values)
+		}
+		if got := out.Shape(); !reflect.DeepEqual(got, Shape{2, 3}) {
+			t.Fatalf("unexpected identity output shape: %v", got)
+		}
+	}
+}
+
 func TestScalarTensorWithORT(t *testing.T) {
 	cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -101,6 +101,8 @@ type SessionOptions struct {
 	enableMemPattern       bool
 	enableProfiling        bool
 	optimizedModelFilePath string
+	executionProviders     []string
+	strictInputTypeCheck   bool
 }
 
 // MemoryInfo represents memory allocation information
@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -39,13 +40,15 @@ func main() {
 	lineNum := 0
 	structLineNum := 0
 	var functions []FunctionPointer
+	currentSince, pendingSince := 1, 0
 
 	// Regex patterns
 	ortApiPattern := regexp.MustCompile(`^struct OrtApi \{`)
 	ortApi2StatusPattern := regexp.MustCompile(`ORT_API2_STATUS\((\w+),`)
-	functionPtrPattern := regexp.MustCompile(`^\s+(OrtStatus|OrtErrorCode|const char|void)\s*\(\s*ORT_API_CALL\s*\*\s*(\w+)\)`)
-	// Also match patterns like "OrtStatus*(ORT_API_CALL* CreateStatus)"
-	functionPtrPattern2 := regexp.MustCompile(`^\s+(OrtStatus|OrtErrorCode|const char)\s*\*\s*\(\s*ORT_API_CALL\s*\*\s*(\w+)\)`)
+	// Matches any return type, e.g. "OrtStatus*(ORT_API_CALL* CreateStatus)" or
+	// "const OrtTrainingApi*(ORT_API_CALL* GetTrainingApi)".
+	functionPtrPattern := regexp.MustCompile(`^\s+[\w\s]+\**\s*\(\s*ORT_API_CALL\s*\*\s*(\w+)\)`)
+	sincePattern := regexp.MustCompile(`\\since Version 1\.(\d+)`)
 	ortClassReleasePattern := regexp.MustCompile(`ORT_CLASS_RELEASE\((\w+)\)`)
 	endStructPattern := regexp.MustCompile(`^\s*\};`)
 
@@ -67,9 +70,18 @@ func main() {
 			break
 		}
 
-		// Skip comments and empty lines
+		// Skip comments and empty lines, remembering the API version a doc comment
+		// introduces its function in.
 		trimmed := strings.TrimSpace(line)
 		if trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "///") {
+			if matches := sincePattern.FindStringSubmatch(line); len(matches) > 1 {
+				version, err := strconv.Atoi(matches[1])
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Invalid version on line %d: %v\n", lineNum, err)
+					os.Exit(1)
+				}
+				pendingSince = version
+			}
 			continue
 		}
 
@@ -78,18 +90,25 @@ func main() {
 		// Check for ORT_API2_STATUS macro
 		if matches := ortApi2StatusPattern.FindStringSubmatch(line); len(matches) > 1 {
 			funcName = matches[1]
-		} else if matches := functionPtrPattern.FindStringSubmatch(line); len(matches) > 2 {
-			funcName = matches[2]
-		} else if matches := functionPtrPattern2.FindStringSubmatch(line); len(matches) > 2 {
-			funcName = matches[2]
+		} else if matches := functionPtrPattern.FindStringSubmatch(line); len(matches) > 1 {
+			funcName = matches[1]
 		} else if matches := ortClassReleasePattern.FindStringSubmatch(line); len(matches) > 1 {
 			funcName = "Release" + matches[1]
 		}
 
 		if funcName != "" {
+			// Versions never decrease along the struct; untagged functions belong to the
+			// version of the function before them.
+			since := max(pendingSince, currentSince)
+			if version, ok := legacyVersionStarts[funcName]; ok {
+				since = max(since, version)
+			}
+			currentSince = since
+			pendingSince = 0
 			functions = append(functions, FunctionPointer{
 				Name:    funcName,
 				LineNum: lineNum,
+				Since:   since,
 			})
 		}
 	}
@@ -100,8 +119,8 @@ func main() {
 	}
 
 	// Validate function count
-	if len(functions) < 290 || len(functions) > 320 {
-		fmt.Fprintf(os.Stderr, "Warning: Parsed %d functions, expected ~305 (valid range: 290-320). Header may have changed.\n", len(functions))
+	if len(functions) < 300 || len(functions) > 330 {
+		fmt.Fprintf(os.Stderr, "Warning: Parsed %d functions, expected ~315 (valid range: 300-330). Header may have changed.\n", len(functions))
 	}
 
 	// Check for duplicate function names
@@ -120,6 +139,7 @@ func main() {
 		"CreateTensorWithDataAsOrtValue": 50,
 		"CreateMemoryInfo":               69,
 		"ReleaseEnv":                     93,
+		"GetTrainingApi":                 220,
 	}
 
 	for name, expectedPos := range keyFunctions {
@@ -150,6 +170,23 @@ func main() {
 type FunctionPointer struct {
 	Name    string
 	LineNum int
+	Since   int // API version that introduced the function
+}
+
+// legacyVersionStarts maps the first function of each API version before 11 to that
+// version. The header only marks functions added from version 11 on ("\since Version
+// 1.N"); the older boundaries follow the table sizes ONNX Runtime pins per version in
+// onnxruntime_c_api.cc. Where a boundary's version is ambiguous the later version is
+// used, so a function is never attributed to a table that may not contain it.
+var legacyVersionStarts = map[string]int{
+	"GetDenotationFromTypeInfo":                         3, // after ReleaseCustomOpDomain
+	"CreateEnvWithGlobalThreadPools":                    4, // after ReleaseModelMetadata
+	"GetStringTensorElementLength":                      5, // after ReleaseAvailableProviders
+	"AddInitializer":                                    6, // after SetGlobalSpinControl
+	"ModelMetadataGetGraphDescription":                  7, // after ReleaseArenaCfg
+	"KernelInfoGetAttributeArray_float":                 8, // after GetCurrentGpuDeviceId
+	"SessionOptionsAppendExecutionProvider_TensorRT_V2": 9, // after CreateSessionFromArrayWithPrepackedWeightsContainer
+	"HasValue": 10, // after GetSparseTensorIndices
 }
 
 func generateGoStruct(functions []FunctionPointer, headerPath string, structLineNum int) {
@@ -166,8 +203,25 @@ func generateGoStruct(functions []FunctionPointer, headerPath string, structLine
 	fmt.Println("type OrtApi struct {")
 
 	for i, fn := range functions {
-		fmt.Printf("\t%-50s uintptr // Function %d\n", fn.Name, i+1)
+		fmt.Printf("\t%-50s uintptr // Function %d, API version %d\n", fn.Name, i+1, fn.Since)
 	}
 
 	fmt.Println("}")
+
+	latest := functions[len(functions)-1].Since
+	counts := make([]int, latest+1)
+	for _, fn := range functions {
+		for version := fn.Since; version <= latest; version++ {
+			counts[version]++
+		}
+	}
+	fmt.Println()
+	fmt.Println("// ortAPIFunctionCounts[v] is the number of function pointers in the OrtApi table of")
+	fmt.Println("// API version v. A runtime negotiated at version v only provides the first")
+	fmt.Println("// ortAPIFunctionCounts[v] fields; the memory past them is not part of its table.")
+	fmt.Println("var ortAPIFunctionCounts = [...]int{")
+	for version, count := range counts {
+		fmt.Printf("\t%d, // API version %d\n", count, version)
+	}
+	fmt.Println("}")
 }